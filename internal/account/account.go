@@ -0,0 +1,306 @@
+// Package account picks Claude Code accounts for new sessions and tracks
+// which accounts are cooling down after hitting a usage or rate limit.
+//
+// Health lives in mayor/account-health.json. Spawners pick an account with
+// Pick, which skips accounts in cool-down and applies the town's selection
+// policy; the daemon records limits it sees in session output with
+// MarkLimited and moves the affected sessions to a healthy account. The
+// account each polecat runs on is recorded with Assign, so a crashed
+// polecat is restarted on the same account.
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// DefaultCooldown is how long an account rests after a limit whose banner
+// doesn't say when it resets.
+const DefaultCooldown = time.Hour
+
+// Health is the recorded state of one account.
+type Health struct {
+	LimitedAt     time.Time `json:"limited_at,omitempty"`     // last time a limit was seen
+	CooldownUntil time.Time `json:"cooldown_until,omitempty"` // not picked before this
+	Reason        string    `json:"reason,omitempty"`         // banner that tripped the limit
+	Limits        int       `json:"limits,omitempty"`         // limits seen in total
+	LastPicked    time.Time `json:"last_picked,omitempty"`
+	Picks         int       `json:"picks,omitempty"` // sessions started on the account
+}
+
+// CoolingDown reports whether the account is resting at now.
+func (h *Health) CoolingDown(now time.Time) bool {
+	return h != nil && now.Before(h.CooldownUntil)
+}
+
+// State is the health of every account plus the rotation cursor and the
+// account each polecat was last started on.
+type State struct {
+	Accounts map[string]*Health `json:"accounts"`
+	Last     string             `json:"last,omitempty"`     // last account picked
+	Assigned map[string]string  `json:"assigned,omitempty"` // polecat identity -> account handle
+}
+
+// health returns handle's record, creating it if needed.
+func (s *State) health(handle string) *Health {
+	h := s.Accounts[handle]
+	if h == nil {
+		h = &Health{}
+		s.Accounts[handle] = h
+	}
+	return h
+}
+
+// LoadState reads the town's account health. A missing file is an empty
+// state.
+func LoadState(townRoot string) (*State, error) {
+	st := &State{Accounts: make(map[string]*Health)}
+	data, err := os.ReadFile(constants.MayorAccountHealthPath(townRoot))
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading account health: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("parsing account health: %w", err)
+	}
+	if st.Accounts == nil {
+		st.Accounts = make(map[string]*Health)
+	}
+	return st, nil
+}
+
+// update applies fn to the town's account health under a file lock, since
+// spawners and the daemon write it concurrently.
+func update(townRoot string, fn func(*State)) error {
+	path := constants.MayorAccountHealthPath(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating mayor directory: %w", err)
+	}
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking account health: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	st, err := LoadState(townRoot)
+	if err != nil {
+		return err
+	}
+	fn(st)
+	return util.AtomicWriteJSON(path, st)
+}
+
+// Pick selects an account for a new session with Select and records the
+// pick. It returns "" when no accounts are configured.
+func Pick(townRoot string, cfg *config.AccountsConfig, now time.Time) (string, error) {
+	return pick(townRoot, cfg, now, "")
+}
+
+// Failover picks a healthy account other than from for a session that hit
+// a limit on from. It returns "" when every other account is resting.
+func Failover(townRoot string, cfg *config.AccountsConfig, from string, now time.Time) (string, error) {
+	return pick(townRoot, cfg, now, from)
+}
+
+func pick(townRoot string, cfg *config.AccountsConfig, now time.Time, exclude string) (string, error) {
+	var handle string
+	err := update(townRoot, func(st *State) {
+		handle = Select(cfg, st, now, exclude)
+		if handle == "" || (exclude != "" && (handle == exclude || st.Accounts[handle].CoolingDown(now))) {
+			handle = ""
+			return
+		}
+		h := st.health(handle)
+		h.LastPicked = now
+		h.Picks++
+		st.Last = handle
+	})
+	return handle, err
+}
+
+// PolecatIdentity is the identity a polecat's account assignment is
+// recorded under. rigName is the bare rig name, without a machine prefix.
+func PolecatIdentity(rigName, polecatName string) string {
+	return rigName + "/polecats/" + polecatName
+}
+
+// Assign records that the polecat identity (see PolecatIdentity) runs on
+// handle, so a restart after a crash keeps it on the same account. An
+// empty handle clears the record.
+func Assign(townRoot, identity, handle string) error {
+	return update(townRoot, func(st *State) {
+		if handle == "" {
+			delete(st.Assigned, identity)
+			return
+		}
+		if st.Assigned == nil {
+			st.Assigned = make(map[string]string)
+		}
+		st.Assigned[identity] = handle
+	})
+}
+
+// AssignedTo returns the account the polecat identity was last started on,
+// or "" if none was recorded.
+func AssignedTo(townRoot, identity string) (string, error) {
+	st, err := LoadState(townRoot)
+	if err != nil {
+		return "", err
+	}
+	return st.Assigned[identity], nil
+}
+
+// MarkLimited records that handle hit a limit at now and rests it until
+// until (now+DefaultCooldown if zero).
+func MarkLimited(townRoot, handle, reason string, until, now time.Time) error {
+	if until.IsZero() || !until.After(now) {
+		until = now.Add(DefaultCooldown)
+	}
+	return update(townRoot, func(st *State) {
+		h := st.health(handle)
+		// The same limit is often seen by several sessions at once; count it once.
+		if !h.CoolingDown(now) {
+			h.Limits++
+		}
+		h.LimitedAt = now
+		h.Reason = reason
+		if until.After(h.CooldownUntil) {
+			h.CooldownUntil = until
+		}
+	})
+}
+
+// Select returns the account a new session should use under cfg's policy,
+// skipping accounts cooling down at now and the account exclude. If every
+// account is resting, it returns the one that recovers first. It returns ""
+// when there are no accounts, or for a new session under the default policy
+// when no default account is set.
+func Select(cfg *config.AccountsConfig, st *State, now time.Time, exclude string) string {
+	if cfg == nil || len(cfg.Accounts) == 0 {
+		return ""
+	}
+	if cfg.Policy == "" && cfg.Default == "" && exclude == "" {
+		return ""
+	}
+
+	var handles, healthy []string
+	for handle := range cfg.Accounts {
+		handles = append(handles, handle)
+	}
+	sort.Strings(handles)
+	for _, handle := range handles {
+		if handle != exclude && !st.Accounts[handle].CoolingDown(now) {
+			healthy = append(healthy, handle)
+		}
+	}
+
+	if len(healthy) == 0 {
+		best := ""
+		for _, handle := range handles {
+			if handle == exclude && len(handles) > 1 {
+				continue
+			}
+			if best == "" || cooldownUntil(st.Accounts[handle]).Before(cooldownUntil(st.Accounts[best])) {
+				best = handle
+			}
+		}
+		return best
+	}
+
+	switch cfg.Policy {
+	case config.AccountPolicyRoundRobin:
+		// The first healthy account after the last one picked.
+		start := sort.SearchStrings(handles, st.Last)
+		if start < len(handles) && handles[start] == st.Last {
+			start++
+		}
+		for i := 0; i < len(handles); i++ {
+			handle := handles[(start+i)%len(handles)]
+			if contains(healthy, handle) {
+				return handle
+			}
+		}
+
+	case config.AccountPolicyLeastRecentlyLimited:
+		best := healthy[0]
+		for _, handle := range healthy[1:] {
+			h, b := st.Accounts[handle], st.Accounts[best]
+			if limitedAt(h).Before(limitedAt(b)) ||
+				(limitedAt(h).Equal(limitedAt(b)) && lastPicked(h).Before(lastPicked(b))) {
+				best = handle
+			}
+		}
+		return best
+
+	case config.AccountPolicyWeighted:
+		// The account furthest below its share of picks.
+		best, bestLoad := "", 0.0
+		for _, handle := range healthy {
+			load := float64(picks(st.Accounts[handle])+1) / float64(weight(cfg.Accounts[handle]))
+			if best == "" || load < bestLoad {
+				best, bestLoad = handle, load
+			}
+		}
+		return best
+	}
+
+	if contains(healthy, cfg.Default) {
+		return cfg.Default
+	}
+	return healthy[0]
+}
+
+func contains(handles []string, handle string) bool {
+	for _, h := range handles {
+		if h == handle {
+			return true
+		}
+	}
+	return false
+}
+
+func cooldownUntil(h *Health) time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	return h.CooldownUntil
+}
+
+func limitedAt(h *Health) time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	return h.LimitedAt
+}
+
+func lastPicked(h *Health) time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	return h.LastPicked
+}
+
+func picks(h *Health) int {
+	if h == nil {
+		return 0
+	}
+	return h.Picks
+}
+
+func weight(a config.Account) int {
+	if a.Weight <= 0 {
+		return 1
+	}
+	return a.Weight
+}
@@ -0,0 +1,221 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func testAccounts(policy string) *config.AccountsConfig {
+	return &config.AccountsConfig{
+		Version: 1,
+		Default: "alpha",
+		Policy:  policy,
+		Accounts: map[string]config.Account{
+			"alpha": {ConfigDir: "/a"},
+			"beta":  {ConfigDir: "/b", Weight: 3},
+			"gamma": {ConfigDir: "/c"},
+		},
+	}
+}
+
+func newState() *State {
+	return &State{Accounts: make(map[string]*Health)}
+}
+
+func TestSelectDefaultPolicy(t *testing.T) {
+	now := time.Now()
+	cfg := testAccounts("")
+	st := newState()
+
+	if got := Select(cfg, st, now, ""); got != "alpha" {
+		t.Errorf("Select = %q, want default account", got)
+	}
+
+	// A resting default account is passed over.
+	st.Accounts["alpha"] = &Health{CooldownUntil: now.Add(time.Hour)}
+	if got := Select(cfg, st, now, ""); got != "beta" {
+		t.Errorf("Select with default cooling down = %q, want beta", got)
+	}
+
+	// Without a default, new sessions keep the runtime's own config.
+	cfg.Default = ""
+	if got := Select(cfg, newState(), now, ""); got != "" {
+		t.Errorf("Select without default = %q, want empty", got)
+	}
+}
+
+func TestSelectRoundRobin(t *testing.T) {
+	now := time.Now()
+	cfg := testAccounts(config.AccountPolicyRoundRobin)
+	st := newState()
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		handle := Select(cfg, st, now, "")
+		st.Last = handle
+		got = append(got, handle)
+	}
+	want := []string{"alpha", "beta", "gamma", "alpha"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rotation = %v, want %v", got, want)
+		}
+	}
+
+	// Resting accounts are skipped.
+	st.Last = "alpha"
+	st.Accounts["beta"] = &Health{CooldownUntil: now.Add(time.Minute)}
+	if handle := Select(cfg, st, now, ""); handle != "gamma" {
+		t.Errorf("Select = %q, want gamma (beta cooling down)", handle)
+	}
+}
+
+func TestSelectLeastRecentlyLimited(t *testing.T) {
+	now := time.Now()
+	cfg := testAccounts(config.AccountPolicyLeastRecentlyLimited)
+	st := newState()
+	st.Accounts["alpha"] = &Health{LimitedAt: now.Add(-1 * time.Hour)}
+	st.Accounts["beta"] = &Health{LimitedAt: now.Add(-3 * time.Hour)}
+	st.Accounts["gamma"] = &Health{LimitedAt: now.Add(-2 * time.Hour)}
+
+	if got := Select(cfg, st, now, ""); got != "beta" {
+		t.Errorf("Select = %q, want beta", got)
+	}
+
+	// Never limited beats limited long ago.
+	delete(st.Accounts, "gamma")
+	if got := Select(cfg, st, now, ""); got != "gamma" {
+		t.Errorf("Select = %q, want gamma", got)
+	}
+}
+
+func TestSelectWeighted(t *testing.T) {
+	now := time.Now()
+	cfg := testAccounts(config.AccountPolicyWeighted)
+	st := newState()
+
+	counts := make(map[string]int)
+	for i := 0; i < 50; i++ {
+		handle := Select(cfg, st, now, "")
+		st.health(handle).Picks++
+		counts[handle]++
+	}
+	if counts["beta"] != 30 || counts["alpha"] != 10 || counts["gamma"] != 10 {
+		t.Errorf("weighted picks = %v, want beta 30, alpha 10, gamma 10", counts)
+	}
+}
+
+func TestSelectAllCoolingDown(t *testing.T) {
+	now := time.Now()
+	cfg := testAccounts(config.AccountPolicyRoundRobin)
+	st := newState()
+	st.Accounts["alpha"] = &Health{CooldownUntil: now.Add(3 * time.Hour)}
+	st.Accounts["beta"] = &Health{CooldownUntil: now.Add(1 * time.Hour)}
+	st.Accounts["gamma"] = &Health{CooldownUntil: now.Add(2 * time.Hour)}
+
+	if got := Select(cfg, st, now, ""); got != "beta" {
+		t.Errorf("Select = %q, want the account that recovers first", got)
+	}
+}
+
+func TestPickFailoverAndMarkLimited(t *testing.T) {
+	townRoot := t.TempDir()
+	now := time.Now()
+	cfg := testAccounts(config.AccountPolicyRoundRobin)
+
+	handle, err := Pick(townRoot, cfg, now)
+	if err != nil || handle != "alpha" {
+		t.Fatalf("Pick = %q, %v", handle, err)
+	}
+	handle, _ = Pick(townRoot, cfg, now)
+	if handle != "beta" {
+		t.Errorf("second Pick = %q, want beta", handle)
+	}
+
+	if err := MarkLimited(townRoot, "gamma", "usage limit reached", time.Time{}, now); err != nil {
+		t.Fatalf("MarkLimited: %v", err)
+	}
+	// A second sighting of the same limit extends the rest but isn't
+	// counted again.
+	if err := MarkLimited(townRoot, "gamma", "usage limit reached", time.Time{}, now.Add(time.Minute)); err != nil {
+		t.Fatalf("MarkLimited: %v", err)
+	}
+	st, err := LoadState(townRoot)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	gamma := st.Accounts["gamma"]
+	if gamma.Limits != 1 || !gamma.CoolingDown(now) || !gamma.CooldownUntil.Equal(now.Add(time.Minute+DefaultCooldown)) {
+		t.Errorf("gamma health = %+v", gamma)
+	}
+	if st.Accounts["alpha"].Picks != 1 || st.Last != "beta" {
+		t.Errorf("state = %+v", st)
+	}
+
+	// Rotation skips gamma while it rests.
+	if handle, _ = Pick(townRoot, cfg, now); handle != "alpha" {
+		t.Errorf("Pick = %q, want alpha (gamma cooling down)", handle)
+	}
+
+	// Failing over from alpha lands on beta; with beta resting too, there
+	// is nowhere to go.
+	if next, _ := Failover(townRoot, cfg, "alpha", now); next != "beta" {
+		t.Errorf("Failover = %q, want beta", next)
+	}
+	_ = MarkLimited(townRoot, "beta", "rate_limit_error", now.Add(time.Hour), now)
+	if next, _ := Failover(townRoot, cfg, "alpha", now); next != "" {
+		t.Errorf("Failover = %q, want none", next)
+	}
+}
+
+func TestDetectLimit(t *testing.T) {
+	now := time.Date(2026, 1, 8, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		output    string
+		wantLimit bool
+		wantReset time.Time
+	}{
+		{"clean", "⏺ Running tests...\n> ", false, time.Time{}},
+		{"epoch", "Claude AI usage limit reached|1767877200\n> ", true, time.Unix(1767877200, 0)},
+		{"clock later today", "  ⎿  5-hour limit reached ∙ resets 3pm\n> ", true, time.Date(2026, 1, 8, 15, 0, 0, 0, time.UTC)},
+		{"clock tomorrow", "You've hit your limit · resets 9:30am", true, time.Date(2026, 1, 9, 9, 30, 0, 0, time.UTC)},
+		{"zone", "Claude usage limit reached. Your limit will reset at 11am (UTC).", true, time.Date(2026, 1, 8, 11, 0, 0, 0, time.UTC)},
+		{"no reset", "Claude usage limit reached.", true, time.Time{}},
+		{"throttled", `API Error: 429 {"type":"error","error":{"type":"rate_limit_error"}}`, false, time.Time{}},
+		{"throttled retrying", "  ⎿  API Error (429 rate_limit_error) · Retrying in 4 seconds…", false, time.Time{}},
+	}
+	for _, tc := range tests {
+		limit, ok := DetectLimit(tc.output, now)
+		if ok != tc.wantLimit {
+			t.Errorf("%s: limited = %v, want %v", tc.name, ok, tc.wantLimit)
+			continue
+		}
+		if ok && !limit.ResetAt.Equal(tc.wantReset) {
+			t.Errorf("%s: reset = %v, want %v", tc.name, limit.ResetAt, tc.wantReset)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	townRoot := t.TempDir()
+	identity := "gastown/polecats/Toast"
+
+	if got, _ := AssignedTo(townRoot, identity); got != "" {
+		t.Fatalf("AssignedTo before Assign = %q, want none", got)
+	}
+	if err := Assign(townRoot, identity, "beta"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := AssignedTo(townRoot, identity); got != "beta" {
+		t.Errorf("AssignedTo = %q, want beta", got)
+	}
+	if err := Assign(townRoot, identity, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := AssignedTo(townRoot, identity); got != "" {
+		t.Errorf("AssignedTo after clearing = %q, want none", got)
+	}
+}
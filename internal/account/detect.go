@@ -0,0 +1,86 @@
+package account
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a usage or rate limit banner found in session output.
+type Limit struct {
+	Banner  string    // the line that matched
+	ResetAt time.Time // when the limit lifts; zero if the banner doesn't say
+}
+
+// limitPatterns match the banners agent CLIs print when an account runs out
+// of usage. Transient per-request throttling (HTTP 429, rate_limit_error)
+// is retried by the CLI itself and must not trigger a failover.
+var limitPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)usage limit reached`),
+	regexp.MustCompile(`(?i)limit reached\s*[∙·•|-]\s*resets`),
+	regexp.MustCompile(`(?i)you['’]ve hit your (?:usage )?limit`),
+	regexp.MustCompile(`(?i)out of extra usage`),
+}
+
+var (
+	// "Claude AI usage limit reached|1767283200"
+	resetEpochPattern = regexp.MustCompile(`\|(\d{10})\b`)
+	// "resets 3pm", "reset at 10:30am (America/New_York)"
+	resetClockPattern = regexp.MustCompile(`(?i)resets?\s+(?:at\s+)?(\d{1,2})(?::(\d{2}))?\s*([ap]m)(?:\s*\(([^)]+)\))?`)
+)
+
+// DetectLimit looks for a limit banner in a session's output, preferring
+// the most recent one. Callers should pass only the last few lines: a
+// banner that has scrolled up may belong to a limit that already lifted.
+func DetectLimit(output string, now time.Time) (*Limit, bool) {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		for _, p := range limitPatterns {
+			if p.MatchString(line) {
+				return &Limit{Banner: line, ResetAt: parseReset(line, now)}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseReset extracts when a limit resets from its banner.
+func parseReset(banner string, now time.Time) time.Time {
+	if m := resetEpochPattern.FindStringSubmatch(banner); m != nil {
+		if secs, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			return time.Unix(secs, 0)
+		}
+	}
+
+	m := resetClockPattern.FindStringSubmatch(banner)
+	if m == nil {
+		return time.Time{}
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if hour < 1 || hour > 12 || minute > 59 {
+		return time.Time{}
+	}
+	hour %= 12
+	if strings.EqualFold(m[3], "pm") {
+		hour += 12
+	}
+
+	loc := now.Location()
+	if m[4] != "" {
+		if l, err := time.LoadLocation(m[4]); err == nil {
+			loc = l
+		}
+	}
+	local := now.In(loc)
+	reset := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if !reset.After(now) {
+		reset = reset.AddDate(0, 0, 1)
+	}
+	return reset
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/claude"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
@@ -31,6 +32,10 @@ type StartupAdapter interface {
 	// before launching the agent (e.g., hook settings).
 	EnsureRoleSettings(workDir, role string) error
 
+	// EnsureRoleSettingsOn is EnsureRoleSettings for a workDir on the
+	// machine behind conn (remote rigs).
+	EnsureRoleSettingsOn(conn connection.Connection, workDir, role string) error
+
 	// AcceptStartupWarnings dismisses any interactive startup dialogs
 	// needed for automation (no-op if none are needed).
 	AcceptStartupWarnings(t *tmux.Tmux, session string) error
@@ -72,6 +77,13 @@ func (a *startupAdapter) EnsureRoleSettings(workDir, role string) error {
 	return nil
 }
 
+func (a *startupAdapter) EnsureRoleSettingsOn(conn connection.Connection, workDir, role string) error {
+	if a.preset != nil && a.preset.SupportsHooks {
+		return claude.EnsureSettingsOn(conn, workDir, claude.RoleTypeFor(role))
+	}
+	return nil
+}
+
 func (a *startupAdapter) AcceptStartupWarnings(t *tmux.Tmux, session string) error {
 	if a.preset != nil && a.preset.Name == config.AgentClaude {
 		return t.AcceptBypassPermissionsWarning(session)
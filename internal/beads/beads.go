@@ -10,6 +10,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/steveyegge/gastown/internal/connection"
 )
 
 // Common errors
@@ -94,6 +96,7 @@ type Issue struct {
 	CreatedBy   string   `json:"created_by,omitempty"`
 	UpdatedAt   string   `json:"updated_at"`
 	ClosedAt    string   `json:"closed_at,omitempty"`
+	CloseReason string   `json:"close_reason,omitempty"`
 	Parent      string   `json:"parent,omitempty"`
 	Assignee    string   `json:"assignee,omitempty"`
 	Children    []string `json:"children,omitempty"`
@@ -103,9 +106,10 @@ type Issue struct {
 	Labels      []string `json:"labels,omitempty"`
 
 	// Agent bead slots (type=agent only)
-	HookBead   string `json:"hook_bead,omitempty"`   // Current work attached to agent's hook
-	RoleBead   string `json:"role_bead,omitempty"`   // Role definition bead (shared)
-	AgentState string `json:"agent_state,omitempty"` // Agent lifecycle state (spawning, working, done, stuck)
+	HookBead     string `json:"hook_bead,omitempty"`     // Current work attached to agent's hook
+	RoleBead     string `json:"role_bead,omitempty"`     // Role definition bead (shared)
+	AgentState   string `json:"agent_state,omitempty"`   // Agent lifecycle state (spawning, working, done, stuck)
+	LastActivity string `json:"last_activity,omitempty"` // When the agent last reported activity (RFC 3339)
 
 	// Counts from list output
 	DependencyCount int `json:"dependency_count,omitempty"`
@@ -210,7 +214,9 @@ type SyncStatus struct {
 // Beads wraps bd CLI operations for a working directory.
 type Beads struct {
 	workDir  string
-	beadsDir string // Optional BEADS_DIR override for cross-database access
+	beadsDir string            // Optional BEADS_DIR override for cross-database access
+	runner   connection.Runner // Optional: nil runs bd as a local subprocess
+	cache    *Cache            // Optional: serves reads from memory; see Cached
 }
 
 // New creates a new Beads wrapper for the given directory.
@@ -224,11 +230,63 @@ func NewWithBeadsDir(workDir, beadsDir string) *Beads {
 	return &Beads{workDir: workDir, beadsDir: beadsDir}
 }
 
+// NewWithRunner creates a Beads wrapper that runs bd through r.
+func NewWithRunner(r connection.Runner, workDir string) *Beads {
+	return &Beads{workDir: workDir, runner: r}
+}
+
+// Cached returns a copy of b whose Show, ShowMultiple, List and
+// ListAgentBeads are served from the shared Cache of its database, falling
+// back to bd if the cache can't be loaded. Writes still run bd, and
+// invalidate the cache. A Beads that runs bd remotely, or outside any beads
+// directory, is returned as is.
+func (b *Beads) Cached() *Beads {
+	if b.cache != nil || b.runner != nil {
+		return b
+	}
+	beadsDir := b.beadsDir
+	if beadsDir == "" {
+		beadsDir = findBeadsDir(b.workDir)
+	}
+	if beadsDir == "" {
+		return b
+	}
+	cached := *b
+	cached.cache = SharedCache(beadsDir)
+	return &cached
+}
+
+// readOnlyCommands are the bd commands that don't modify the database.
+var readOnlyCommands = map[string]bool{
+	"blocked": true,
+	"export":  true,
+	"list":    true,
+	"ready":   true,
+	"show":    true,
+	"stats":   true,
+}
+
 // run executes a bd command and returns stdout.
 func (b *Beads) run(args ...string) ([]byte, error) {
+	if b.cache != nil && len(args) > 0 && !readOnlyCommands[args[0]] {
+		defer b.cache.Invalidate()
+	}
+
 	// Use --no-daemon for faster read operations (avoids daemon IPC overhead)
 	// The daemon is primarily useful for write coalescing, not reads
 	fullArgs := append([]string{"--no-daemon"}, args...)
+
+	if b.runner != nil {
+		var env map[string]string
+		if b.beadsDir != "" {
+			env = map[string]string{"BEADS_DIR": b.beadsDir}
+		}
+		stdout, stderr, err := b.runner.Run(b.workDir, env, "bd", fullArgs...)
+		if err != nil {
+			return nil, b.wrapError(err, string(stderr), args)
+		}
+		return stdout, nil
+	}
 	cmd := exec.Command("bd", fullArgs...) //nolint:gosec // G204: bd is a trusted internal tool
 	cmd.Dir = b.workDir
 
@@ -254,7 +312,7 @@ func (b *Beads) wrapError(err error, stderr string, args []string) error {
 	stderr = strings.TrimSpace(stderr)
 
 	// Check for bd not installed
-	if execErr, ok := err.(*exec.Error); ok && errors.Is(execErr.Err, exec.ErrNotFound) {
+	if errors.Is(err, exec.ErrNotFound) {
 		return ErrNotInstalled
 	}
 
@@ -279,6 +337,12 @@ func (b *Beads) wrapError(err error, stderr string, args []string) error {
 
 // List returns issues matching the given options.
 func (b *Beads) List(opts ListOptions) ([]*Issue, error) {
+	if b.cache != nil {
+		if issues, err := b.cache.List(opts); err == nil {
+			return issues, nil
+		}
+	}
+
 	args := []string{"list", "--json"}
 
 	if opts.Status != "" {
@@ -387,6 +451,12 @@ func (b *Beads) ReadyWithType(issueType string) ([]*Issue, error) {
 
 // Show returns detailed information about an issue.
 func (b *Beads) Show(id string) (*Issue, error) {
+	if b.cache != nil {
+		if issue, err := b.cache.Show(id); err == nil {
+			return issue, nil
+		}
+	}
+
 	out, err := b.run("show", id, "--json")
 	if err != nil {
 		return nil, err
@@ -412,11 +482,32 @@ func (b *Beads) ShowMultiple(ids []string) (map[string]*Issue, error) {
 		return make(map[string]*Issue), nil
 	}
 
+	// Serve what the cache has; ask bd only for the rest.
+	var cached map[string]*Issue
+	if b.cache != nil {
+		if found, err := b.cache.ShowMultiple(ids); err == nil {
+			cached = found
+			var missing []string
+			for _, id := range ids {
+				if _, ok := found[id]; !ok {
+					missing = append(missing, id)
+				}
+			}
+			if len(missing) == 0 {
+				return found, nil
+			}
+			ids = missing
+		}
+	}
+
 	// bd show supports multiple IDs
 	args := append([]string{"show", "--json"}, ids...)
 	out, err := b.run(args...)
 	if err != nil {
-		// If bd fails, return empty map (some IDs might not exist)
+		// If bd fails, return what we have (some IDs might not exist)
+		if cached != nil {
+			return cached, nil
+		}
 		return make(map[string]*Issue), nil
 	}
 
@@ -425,7 +516,10 @@ func (b *Beads) ShowMultiple(ids []string) (map[string]*Issue, error) {
 		return nil, fmt.Errorf("parsing bd show output: %w", err)
 	}
 
-	result := make(map[string]*Issue, len(issues))
+	result := make(map[string]*Issue, len(issues)+len(cached))
+	for id, issue := range cached {
+		result[id] = issue
+	}
 	for _, issue := range issues {
 		result[issue.ID] = issue
 	}
@@ -433,9 +527,38 @@ func (b *Beads) ShowMultiple(ids []string) (map[string]*Issue, error) {
 	return result, nil
 }
 
+// Dependencies returns the issue's dependency edges of the given type, or
+// of every type if depType is empty. Only the read cache (see Cached) sees
+// cross-database edges; without it they come from bd show, which omits them.
+func (b *Beads) Dependencies(id, depType string) ([]Dependency, error) {
+	if b.cache != nil {
+		if deps, err := b.cache.Dependencies(id, depType); err == nil {
+			return deps, nil
+		}
+	}
+
+	issue, err := b.Show(id)
+	if err != nil {
+		return nil, err
+	}
+	var deps []Dependency
+	for _, d := range issue.Dependencies {
+		if depType == "" || d.DependencyType == depType {
+			deps = append(deps, Dependency{IssueID: id, DependsOnID: d.ID, Type: d.DependencyType})
+		}
+	}
+	return deps, nil
+}
+
 // ListAgentBeads returns all agent beads in a single query.
 // Returns a map of agent bead ID to Issue.
 func (b *Beads) ListAgentBeads() (map[string]*Issue, error) {
+	if b.cache != nil {
+		if result, err := b.cache.ListAgentBeads(); err == nil {
+			return result, nil
+		}
+	}
+
 	out, err := b.run("list", "--type=agent", "--json")
 	if err != nil {
 		return nil, err
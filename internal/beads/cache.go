@@ -0,0 +1,413 @@
+package beads
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Dependency is an edge in the beads dependency graph: IssueID depends on
+// DependsOnID. Cross-database edges use "external:<rig>:<id>" references.
+type Dependency struct {
+	IssueID     string `json:"issue_id"`
+	DependsOnID string `json:"depends_on_id"`
+	Type        string `json:"type"`
+}
+
+// Dependency types used by Gas Town.
+const (
+	DepTracks      = "tracks"       // Convoy tracks an issue
+	DepParentChild = "parent-child" // Child depends on its parent
+)
+
+// ExternalRefID returns the issue ID of a dependency target, unwrapping
+// "external:<rig>:<id>" references.
+func ExternalRefID(ref string) string {
+	if strings.HasPrefix(ref, "external:") {
+		if parts := strings.SplitN(ref, ":", 3); len(parts) == 3 {
+			return parts[2]
+		}
+	}
+	return ref
+}
+
+// stampFiles are the files in a beads directory whose changes invalidate a
+// Cache: the database, its write-ahead log, and the git-synced export.
+var stampFiles = []string{"beads.db", "beads.db-wal", "issues.jsonl"}
+
+// Cache is an in-memory, read-only copy of one beads database. It loads
+// every issue and dependency with a single `bd export`, and reloads when
+// the database files change, which it checks (with a few stats) on each
+// read. Issues with prefixes routed to other databases are looked up in
+// those databases' caches.
+//
+// Reading through a Cache replaces the bd (and sqlite3) subprocess per
+// Show or List that dashboards and status commands would otherwise spawn.
+// Writes still go through bd.
+type Cache struct {
+	beadsDir string
+	load     func() ([]byte, error)
+
+	mu     sync.Mutex
+	stamp  string // Database file signature at the last load; "" forces a reload
+	issues map[string]*Issue
+	order  []*Issue
+	deps   map[string][]Dependency // By IssueID
+	rdeps  map[string][]Dependency // By DependsOnID, external refs unwrapped
+}
+
+var (
+	sharedMu     sync.Mutex
+	sharedCaches = make(map[string]*Cache)
+)
+
+// SharedCache returns the process-wide cache for beadsDir, so that every
+// reader in the process shares one copy of each database.
+func SharedCache(beadsDir string) *Cache {
+	beadsDir = filepath.Clean(beadsDir)
+
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	c, ok := sharedCaches[beadsDir]
+	if !ok {
+		bd := NewWithBeadsDir(filepath.Dir(beadsDir), beadsDir)
+		c = newCache(beadsDir, func() ([]byte, error) { return bd.run("export") })
+		sharedCaches[beadsDir] = c
+	}
+	return c
+}
+
+func newCache(beadsDir string, load func() ([]byte, error)) *Cache {
+	return &Cache{beadsDir: beadsDir, load: load}
+}
+
+// Invalidate forces the next read to reload the database.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.stamp = ""
+	c.mu.Unlock()
+}
+
+// currentStamp returns a signature of the database files' sizes and
+// modification times.
+func (c *Cache) currentStamp() string {
+	var b strings.Builder
+	for _, name := range stampFiles {
+		if info, err := os.Stat(filepath.Join(c.beadsDir, name)); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	if b.Len() == 0 {
+		return "empty" // Never "", which means unloaded
+	}
+	return b.String()
+}
+
+// refresh reloads the database if it changed since the last load. The
+// caller must hold c.mu.
+func (c *Cache) refresh() error {
+	stamp := c.currentStamp()
+	if stamp == c.stamp {
+		return nil
+	}
+
+	out, err := c.load()
+	if err != nil {
+		return err
+	}
+	if err := c.parse(out); err != nil {
+		return err
+	}
+	c.stamp = stamp
+	return nil
+}
+
+// exportedIssue is one line of `bd export`. Its dependencies are raw edges
+// rather than the resolved IssueDeps of `bd show`.
+type exportedIssue struct {
+	Issue
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// parse replaces the cache contents with the export in data. The caller
+// must hold c.mu.
+func (c *Cache) parse(data []byte) error {
+	issues := make(map[string]*Issue)
+	var order []*Issue
+	deps := make(map[string][]Dependency)
+	rdeps := make(map[string][]Dependency)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec exportedIssue
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("parsing bd export output: %w", err)
+		}
+		if rec.ID == "" || rec.Status == "tombstone" {
+			continue
+		}
+		issue := rec.Issue
+		issues[issue.ID] = &issue
+		order = append(order, &issue)
+		for _, d := range rec.Dependencies {
+			if d.IssueID == "" {
+				d.IssueID = issue.ID
+			}
+			deps[d.IssueID] = append(deps[d.IssueID], d)
+			target := ExternalRefID(d.DependsOnID)
+			rdeps[target] = append(rdeps[target], d)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading bd export output: %w", err)
+	}
+
+	c.issues, c.order, c.deps, c.rdeps = issues, order, deps, rdeps
+	return nil
+}
+
+// Show returns an issue with its dependencies and dependents, as `bd show`
+// would. It returns ErrNotFound if the issue is in neither this database
+// nor the one its prefix routes to.
+func (c *Cache) Show(id string) (*Issue, error) {
+	found, err := c.ShowMultiple([]string{id})
+	if err != nil {
+		return nil, err
+	}
+	issue, ok := found[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return issue, nil
+}
+
+// ShowMultiple returns the issues with the given IDs. Missing IDs are not
+// included in the map.
+func (c *Cache) ShowMultiple(ids []string) (map[string]*Issue, error) {
+	result := make(map[string]*Issue, len(ids))
+	routed := make(map[*Cache][]string)
+
+	c.mu.Lock()
+	err := c.refresh()
+	if err == nil {
+		for _, id := range ids {
+			if issue, ok := c.issues[id]; ok {
+				result[id] = c.detail(issue)
+			} else if other := c.route(id); other != nil {
+				routed[other] = append(routed[other], id)
+			}
+		}
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Routed lookups happen outside c.mu: two databases may route to each
+	// other.
+	for other, otherIDs := range routed {
+		found, err := other.ShowMultiple(otherIDs)
+		if err != nil {
+			continue
+		}
+		for id, issue := range found {
+			result[id] = issue
+		}
+	}
+	return result, nil
+}
+
+// List returns the issues matching opts, as `bd list` would. An empty
+// Status matches every issue that isn't closed; "all" matches every issue.
+func (c *Cache) List(opts ListOptions) ([]*Issue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	var result []*Issue
+	for _, issue := range c.order {
+		if c.matches(issue, opts) {
+			result = append(result, c.summary(issue))
+		}
+	}
+	return result, nil
+}
+
+// ListAgentBeads returns all agent beads, keyed by ID.
+func (c *Cache) ListAgentBeads() (map[string]*Issue, error) {
+	issues, err := c.List(ListOptions{Type: "agent", Priority: -1})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*Issue, len(issues))
+	for _, issue := range issues {
+		result[issue.ID] = issue
+	}
+	return result, nil
+}
+
+// Dependencies returns the issue's dependency edges, optionally only those
+// of one type. Targets may be external references; see ExternalRefID.
+func (c *Cache) Dependencies(id, depType string) ([]Dependency, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	var result []Dependency
+	for _, d := range c.deps[id] {
+		if depType == "" || d.Type == depType {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// matches reports whether issue passes the list filters. The caller must
+// hold c.mu.
+func (c *Cache) matches(issue *Issue, opts ListOptions) bool {
+	switch opts.Status {
+	case "all":
+	case "":
+		if issue.Status == "closed" {
+			return false
+		}
+	default:
+		if issue.Status != opts.Status {
+			return false
+		}
+	}
+	if opts.Type != "" && issue.Type != opts.Type {
+		return false
+	}
+	if opts.Priority >= 0 && issue.Priority != opts.Priority {
+		return false
+	}
+	if opts.Parent != "" && c.parent(issue.ID) != opts.Parent {
+		return false
+	}
+	if opts.Assignee != "" && issue.Assignee != opts.Assignee {
+		return false
+	}
+	if opts.NoAssignee && issue.Assignee != "" {
+		return false
+	}
+	return true
+}
+
+// parent returns the issue's parent ID. The caller must hold c.mu.
+func (c *Cache) parent(id string) string {
+	for _, d := range c.deps[id] {
+		if d.Type == DepParentChild {
+			return ExternalRefID(d.DependsOnID)
+		}
+	}
+	return ""
+}
+
+// summary returns a copy of issue with the counts `bd list` reports. The
+// caller must hold c.mu.
+func (c *Cache) summary(issue *Issue) *Issue {
+	out := *issue
+	out.Parent = c.parent(issue.ID)
+	out.DependencyCount = len(c.deps[issue.ID])
+	out.DependentCount = len(c.rdeps[issue.ID])
+	return &out
+}
+
+// detail returns a copy of issue with the dependencies and dependents
+// `bd show` reports. The caller must hold c.mu.
+func (c *Cache) detail(issue *Issue) *Issue {
+	out := c.summary(issue)
+	out.Dependencies = nil
+	out.Dependents = nil
+	for _, d := range c.deps[issue.ID] {
+		out.Dependencies = append(out.Dependencies, c.issueDep(ExternalRefID(d.DependsOnID), d.Type))
+	}
+	for _, d := range c.rdeps[issue.ID] {
+		out.Dependents = append(out.Dependents, c.issueDep(d.IssueID, d.Type))
+	}
+	return out
+}
+
+// issueDep describes the other end of a dependency edge. The caller must
+// hold c.mu.
+func (c *Cache) issueDep(id, depType string) IssueDep {
+	dep := IssueDep{ID: id, DependencyType: depType}
+	if other, ok := c.issues[id]; ok {
+		dep.Title = other.Title
+		dep.Status = other.Status
+		dep.Priority = other.Priority
+		dep.Type = other.Type
+	}
+	return dep
+}
+
+// route returns the cache of the database that id's prefix routes to, or
+// nil if it routes here or nowhere. Routes are read from the town's
+// routes.jsonl: this database's own, or the nearest one above it.
+func (c *Cache) route(id string) *Cache {
+	townBeads := c.beadsDir
+	for {
+		if _, err := os.Stat(filepath.Join(townBeads, RoutesFileName)); err == nil {
+			break
+		}
+		parent := filepath.Dir(filepath.Dir(townBeads))
+		if parent == filepath.Dir(townBeads) {
+			return nil
+		}
+		townBeads = filepath.Join(parent, ".beads")
+	}
+
+	routes, err := LoadRoutes(townBeads)
+	if err != nil {
+		return nil
+	}
+	townRoot := filepath.Dir(townBeads)
+	for _, r := range routes {
+		if !strings.HasPrefix(id, r.Prefix) {
+			continue
+		}
+		target := filepath.Clean(ResolveBeadsDir(filepath.Join(townRoot, r.Path)))
+		if target == c.beadsDir {
+			return nil
+		}
+		return SharedCache(target)
+	}
+	return nil
+}
+
+// findBeadsDir returns the beads directory bd would use from workDir: the
+// nearest .beads at or above it, following redirects.
+func findBeadsDir(workDir string) string {
+	dir, err := filepath.Abs(workDir)
+	if err != nil {
+		return ""
+	}
+	if filepath.Base(dir) == ".beads" {
+		return ResolveBeadsDir(filepath.Dir(dir))
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".beads")); err == nil && info.IsDir() {
+			return ResolveBeadsDir(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,188 @@
+package beads
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testExport = `{"id":"hq-cv-1","title":"Convoy","status":"open","issue_type":"convoy","priority":2,"dependencies":[{"issue_id":"hq-cv-1","depends_on_id":"external:gastown:gt-1","type":"tracks"},{"issue_id":"hq-cv-1","depends_on_id":"hq-2","type":"tracks"}]}
+{"id":"hq-2","title":"Town task","status":"closed","issue_type":"task","priority":1}
+{"id":"hq-3","title":"Child","status":"open","issue_type":"task","priority":2,"dependencies":[{"issue_id":"hq-3","depends_on_id":"hq-cv-1","type":"parent-child"}]}
+{"id":"hq-mayor","title":"Mayor","status":"open","issue_type":"agent","priority":2,"hook_bead":"hq-3"}
+{"id":"hq-old","title":"Deleted","status":"tombstone","issue_type":"task"}
+`
+
+// newTestCache returns a cache of a .beads directory in a temp town, loaded
+// from export, and a pointer to its load count.
+func newTestCache(t *testing.T, export string) (*Cache, *int) {
+	t.Helper()
+	beadsDir := filepath.Join(t.TempDir(), ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	loads := 0
+	c := newCache(beadsDir, func() ([]byte, error) {
+		loads++
+		return []byte(export), nil
+	})
+	return c, &loads
+}
+
+func TestCacheList(t *testing.T) {
+	c, _ := newTestCache(t, testExport)
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"default hides closed", ListOptions{Priority: -1}, []string{"hq-cv-1", "hq-3", "hq-mayor"}},
+		{"all", ListOptions{Status: "all", Priority: -1}, []string{"hq-cv-1", "hq-2", "hq-3", "hq-mayor"}},
+		{"closed", ListOptions{Status: "closed", Priority: -1}, []string{"hq-2"}},
+		{"type", ListOptions{Type: "convoy", Priority: -1}, []string{"hq-cv-1"}},
+		{"priority", ListOptions{Status: "all", Priority: 1}, []string{"hq-2"}},
+		{"parent", ListOptions{Parent: "hq-cv-1", Priority: -1}, []string{"hq-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := c.List(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("List = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("List = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	agents, err := c.ListAgentBeads()
+	if err != nil || len(agents) != 1 || agents["hq-mayor"].HookBead != "hq-3" {
+		t.Errorf("ListAgentBeads = %v, %v", agents, err)
+	}
+}
+
+func TestCacheShow(t *testing.T) {
+	c, _ := newTestCache(t, testExport)
+
+	convoy, err := c.Show("hq-cv-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if convoy.DependencyCount != 2 || len(convoy.Dependencies) != 2 {
+		t.Fatalf("dependencies = %+v", convoy.Dependencies)
+	}
+	if d := convoy.Dependencies[1]; d.ID != "hq-2" || d.Status != "closed" || d.DependencyType != DepTracks {
+		t.Errorf("dependency = %+v", d)
+	}
+	if len(convoy.Dependents) != 1 || convoy.Dependents[0].ID != "hq-3" {
+		t.Errorf("dependents = %+v", convoy.Dependents)
+	}
+
+	child, err := c.Show("hq-3")
+	if err != nil || child.Parent != "hq-cv-1" {
+		t.Errorf("Show(hq-3) = %+v, %v; want parent hq-cv-1", child, err)
+	}
+
+	if _, err := c.Show("hq-old"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Show(tombstone) error = %v, want ErrNotFound", err)
+	}
+
+	deps, err := c.Dependencies("hq-cv-1", DepTracks)
+	if err != nil || len(deps) != 2 || ExternalRefID(deps[0].DependsOnID) != "gt-1" {
+		t.Errorf("Dependencies = %+v, %v", deps, err)
+	}
+}
+
+func TestCacheReloadsOnChange(t *testing.T) {
+	c, loads := newTestCache(t, testExport)
+	dbPath := filepath.Join(c.beadsDir, "beads.db")
+	if err := os.WriteFile(dbPath, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.List(ListOptions{Priority: -1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *loads != 1 {
+		t.Fatalf("loads = %d after unchanged reads, want 1", *loads)
+	}
+
+	if err := os.WriteFile(dbPath, []byte("v2 changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Show("hq-2"); err != nil {
+		t.Fatal(err)
+	}
+	if *loads != 2 {
+		t.Fatalf("loads = %d after the database changed, want 2", *loads)
+	}
+
+	c.Invalidate()
+	if _, err := c.Show("hq-2"); err != nil {
+		t.Fatal(err)
+	}
+	if *loads != 3 {
+		t.Errorf("loads = %d after Invalidate, want 3", *loads)
+	}
+}
+
+func TestCacheRoutesToRigDatabase(t *testing.T) {
+	town := t.TempDir()
+	townBeads := filepath.Join(town, ".beads")
+	if err := os.MkdirAll(townBeads, 0755); err != nil {
+		t.Fatal(err)
+	}
+	routes := `{"prefix": "gt-", "path": "gastown/mayor/rig"}
+{"prefix": "hq-", "path": "."}
+`
+	if err := os.WriteFile(filepath.Join(townBeads, RoutesFileName), []byte(routes), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rigBeads := filepath.Join(town, "gastown", "mayor", "rig", ".beads")
+	sharedMu.Lock()
+	sharedCaches[rigBeads] = newCache(rigBeads, func() ([]byte, error) {
+		return []byte(`{"id":"gt-1","title":"Rig task","status":"in_progress","issue_type":"task"}` + "\n"), nil
+	})
+	sharedMu.Unlock()
+	t.Cleanup(func() {
+		sharedMu.Lock()
+		delete(sharedCaches, rigBeads)
+		sharedMu.Unlock()
+	})
+
+	c := newCache(townBeads, func() ([]byte, error) { return []byte(testExport), nil })
+	found, err := c.ShowMultiple([]string{"hq-2", "gt-1", "gt-missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found["gt-1"].Status != "in_progress" || found["hq-2"].Status != "closed" {
+		t.Errorf("ShowMultiple = %v", found)
+	}
+}
+
+func TestExternalRefID(t *testing.T) {
+	tests := map[string]string{
+		"external:gastown:gt-1": "gt-1",
+		"gt-1":                  "gt-1",
+		"external:broken":       "external:broken",
+	}
+	for ref, want := range tests {
+		if got := ExternalRefID(ref); got != want {
+			t.Errorf("ExternalRefID(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+// Package budget evaluates spend in the cost ledger against the budgets
+// configured for the town, its rigs, convoys and accounts.
+//
+// A budget has a soft and a hard limit. The daemon escalates when spend
+// crosses the soft limit; past the hard limit, new polecat spawns in the
+// budget's scope are refused and its running polecats are parked, until
+// the period rolls over or an operator records an override.
+package budget
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/costs"
+)
+
+// Scope kinds.
+const (
+	ScopeTown    = "town"
+	ScopeRig     = "rig"
+	ScopeConvoy  = "convoy"
+	ScopeAccount = "account"
+)
+
+// DefaultConvoyKey is the ConvoyBudgets key that applies to every convoy
+// without a budget of its own.
+const DefaultConvoyKey = "*"
+
+// Scope identifies what a budget covers: the town, or one rig, convoy or
+// account.
+type Scope struct {
+	Kind string `json:"kind"`
+	Name string `json:"name,omitempty"`
+}
+
+// String returns the scope as written on the command line: "town",
+// "rig:gastown", "convoy:hq-cv-abc" or "account:work".
+func (s Scope) String() string {
+	if s.Kind == ScopeTown {
+		return ScopeTown
+	}
+	return s.Kind + ":" + s.Name
+}
+
+// ParseScope parses the String form of a scope.
+func ParseScope(s string) (Scope, error) {
+	if s == ScopeTown {
+		return Scope{Kind: ScopeTown}, nil
+	}
+	kind, name, ok := strings.Cut(s, ":")
+	if ok && name != "" {
+		switch kind {
+		case ScopeRig, ScopeConvoy, ScopeAccount:
+			return Scope{Kind: kind, Name: name}, nil
+		}
+	}
+	return Scope{}, fmt.Errorf("invalid budget scope %q: use town, rig:<name>, convoy:<id> or account:<handle>", s)
+}
+
+// Level is how far spend has gone against a budget.
+type Level int
+
+const (
+	LevelOK Level = iota
+	LevelSoft
+	LevelHard
+)
+
+// String returns the level's name.
+func (l Level) String() string {
+	switch l {
+	case LevelSoft:
+		return "soft"
+	case LevelHard:
+		return "hard"
+	default:
+		return "ok"
+	}
+}
+
+// MarshalText encodes the level by name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a level name.
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "soft":
+		*l = LevelSoft
+	case "hard":
+		*l = LevelHard
+	default:
+		*l = LevelOK
+	}
+	return nil
+}
+
+// Status is a budget's standing in its current period.
+type Status struct {
+	Scope    Scope         `json:"scope"`
+	Budget   config.Budget `json:"budget"`
+	Since    time.Time     `json:"since,omitempty"` // period start; zero for total
+	Spent    float64       `json:"spent_usd"`
+	Level    Level         `json:"level"`
+	Override *Override     `json:"override,omitempty"`
+}
+
+// Blocking reports whether the budget stops new work: spend is past the
+// hard limit and no override is in effect.
+func (s Status) Blocking() bool {
+	return s.Level == LevelHard && s.Override == nil
+}
+
+// Report is the standing of every configured budget.
+type Report struct {
+	Statuses []Status
+
+	latest map[string]costs.Attribution // actor -> attribution of its latest usage
+}
+
+// Find returns the status of scope's budget, or nil if it has none.
+func (r *Report) Find(scope Scope) *Status {
+	for i := range r.Statuses {
+		if r.Statuses[i].Scope == scope {
+			return &r.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// Blocking returns the first budget that stops work attributed to attr
+// (checking town, rig, convoy and account), or nil.
+func (r *Report) Blocking(attr costs.Attribution) *Status {
+	scopes := []Scope{{Kind: ScopeTown}}
+	if attr.Rig != "" {
+		scopes = append(scopes, Scope{Kind: ScopeRig, Name: attr.Rig})
+	}
+	if attr.Convoy != "" {
+		scopes = append(scopes, Scope{Kind: ScopeConvoy, Name: attr.Convoy})
+	}
+	if attr.Account != "" {
+		scopes = append(scopes, Scope{Kind: ScopeAccount, Name: attr.Account})
+	}
+	for _, scope := range scopes {
+		if st := r.Find(scope); st != nil && st.Blocking() {
+			return st
+		}
+	}
+	return nil
+}
+
+// AgentAttribution returns the attribution of actor's most recent usage,
+// so callers can tell which convoy and account an agent is spending on.
+func (r *Report) AgentAttribution(actor string) (costs.Attribution, bool) {
+	attr, ok := r.latest[actor]
+	return attr, ok
+}
+
+// Limits are the budgets configured for a town.
+type Limits struct {
+	Budgets       map[Scope]config.Budget
+	DefaultConvoy *config.Budget // applies to convoys without their own budget
+}
+
+// LoadLimits reads budgets from town settings, each registered rig's
+// settings and the accounts config.
+func LoadLimits(townRoot string) (*Limits, error) {
+	limits := &Limits{Budgets: make(map[Scope]config.Budget)}
+
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil {
+		return nil, fmt.Errorf("loading town settings: %w", err)
+	}
+	if settings.Budget != nil {
+		limits.Budgets[Scope{Kind: ScopeTown}] = *settings.Budget
+	}
+	for id, b := range settings.ConvoyBudgets {
+		if b == nil {
+			continue
+		}
+		if id == DefaultConvoyKey {
+			limits.DefaultConvoy = b
+			continue
+		}
+		limits.Budgets[Scope{Kind: ScopeConvoy, Name: id}] = *b
+	}
+
+	if rigs, err := config.LoadRigsConfig(filepath.Join(townRoot, "mayor", "rigs.json")); err == nil {
+		for name := range rigs.Rigs {
+			rs, err := config.LoadRigSettings(config.RigSettingsPath(filepath.Join(townRoot, name)))
+			if err == nil && rs.Budget != nil {
+				limits.Budgets[Scope{Kind: ScopeRig, Name: name}] = *rs.Budget
+			}
+		}
+	}
+
+	if accounts, err := config.LoadAccountsConfig(constants.MayorAccountsPath(townRoot)); err == nil {
+		for handle, acct := range accounts.Accounts {
+			if acct.Budget != nil {
+				limits.Budgets[Scope{Kind: ScopeAccount, Name: handle}] = *acct.Budget
+			}
+		}
+	}
+	return limits, nil
+}
+
+// Empty reports whether no budgets are configured.
+func (l *Limits) Empty() bool {
+	return len(l.Budgets) == 0 && l.DefaultConvoy == nil
+}
+
+// Evaluate measures the town's ledger against its budgets.
+func Evaluate(townRoot string, now time.Time) (*Report, error) {
+	limits, err := LoadLimits(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	if limits.Empty() {
+		return &Report{}, nil
+	}
+	entries, err := costs.NewLedger(townRoot).Entries(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := LoadOverrides(townRoot, now)
+	if err != nil {
+		return nil, err
+	}
+	return limits.Evaluate(entries, overrides, now), nil
+}
+
+// Evaluate measures entries against the limits, applying any overrides.
+func (l *Limits) Evaluate(entries []costs.Entry, overrides map[Scope]Override, now time.Time) *Report {
+	budgets := make(map[Scope]config.Budget, len(l.Budgets))
+	for scope, b := range l.Budgets {
+		budgets[scope] = b
+	}
+	if l.DefaultConvoy != nil {
+		for _, e := range entries {
+			scope := Scope{Kind: ScopeConvoy, Name: e.Convoy}
+			if _, ok := budgets[scope]; e.Convoy != "" && !ok {
+				budgets[scope] = *l.DefaultConvoy
+			}
+		}
+	}
+
+	report := &Report{latest: make(map[string]costs.Attribution)}
+	latestAt := make(map[string]time.Time)
+	for _, e := range entries {
+		if e.Actor != "" && !e.Time.Before(latestAt[e.Actor]) {
+			latestAt[e.Actor] = e.Time
+			report.latest[e.Actor] = e.Attribution
+		}
+	}
+
+	for scope, b := range budgets {
+		st := Status{Scope: scope, Budget: b, Since: PeriodStart(periodFor(scope, b), now)}
+		for _, e := range entries {
+			if inScope(scope, e) && !e.Time.Before(st.Since) {
+				st.Spent += e.CostUSD
+			}
+		}
+		switch {
+		case b.HardUSD > 0 && st.Spent >= b.HardUSD:
+			st.Level = LevelHard
+		case b.SoftUSD > 0 && st.Spent >= b.SoftUSD:
+			st.Level = LevelSoft
+		}
+		if o, ok := overrides[scope]; ok {
+			st.Override = &o
+		}
+		report.Statuses = append(report.Statuses, st)
+	}
+
+	sort.Slice(report.Statuses, func(i, j int) bool {
+		a, b := report.Statuses[i].Scope, report.Statuses[j].Scope
+		if a.Kind != b.Kind {
+			return kindOrder(a.Kind) < kindOrder(b.Kind)
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// periodFor returns a budget's period, defaulting by scope kind.
+func periodFor(scope Scope, b config.Budget) string {
+	if b.Period != "" {
+		return b.Period
+	}
+	if scope.Kind == ScopeConvoy {
+		return config.BudgetPeriodTotal
+	}
+	return config.BudgetPeriodDay
+}
+
+// PeriodStart returns when the period containing now began, in now's
+// location. The zero time means the period is unbounded ("total").
+func PeriodStart(period string, now time.Time) time.Time {
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch period {
+	case config.BudgetPeriodWeek:
+		daysSinceMonday := (int(now.Weekday()) + 6) % 7
+		return midnight.AddDate(0, 0, -daysSinceMonday)
+	case config.BudgetPeriodMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	case config.BudgetPeriodTotal:
+		return time.Time{}
+	default:
+		return midnight
+	}
+}
+
+// inScope reports whether a ledger entry counts against scope.
+func inScope(scope Scope, e costs.Entry) bool {
+	switch scope.Kind {
+	case ScopeTown:
+		return true
+	case ScopeRig:
+		return e.Rig == scope.Name
+	case ScopeConvoy:
+		return e.Convoy == scope.Name
+	case ScopeAccount:
+		return e.Account == scope.Name
+	}
+	return false
+}
+
+func kindOrder(kind string) int {
+	switch kind {
+	case ScopeTown:
+		return 0
+	case ScopeRig:
+		return 1
+	case ScopeConvoy:
+		return 2
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,248 @@
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/costs"
+)
+
+func TestParseScope(t *testing.T) {
+	for _, s := range []string{"town", "rig:gastown", "convoy:hq-cv-abc", "account:work"} {
+		scope, err := ParseScope(s)
+		if err != nil {
+			t.Errorf("ParseScope(%q): %v", s, err)
+			continue
+		}
+		if scope.String() != s {
+			t.Errorf("ParseScope(%q).String() = %q", s, scope.String())
+		}
+	}
+	for _, s := range []string{"", "rig", "rig:", "team:x"} {
+		if _, err := ParseScope(s); err == nil {
+			t.Errorf("ParseScope(%q) should fail", s)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	now := time.Date(2026, 1, 8, 15, 30, 0, 0, time.UTC) // a Thursday
+	tests := []struct {
+		period string
+		want   time.Time
+	}{
+		{config.BudgetPeriodDay, time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"", time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{config.BudgetPeriodWeek, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{config.BudgetPeriodMonth, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{config.BudgetPeriodTotal, time.Time{}},
+	}
+	for _, tc := range tests {
+		if got := PeriodStart(tc.period, now); !got.Equal(tc.want) {
+			t.Errorf("PeriodStart(%q) = %v, want %v", tc.period, got, tc.want)
+		}
+	}
+}
+
+func entry(ts time.Time, cost float64, attr costs.Attribution) costs.Entry {
+	return costs.Entry{Time: ts, CostUSD: cost, Attribution: attr}
+}
+
+func TestLimitsEvaluate(t *testing.T) {
+	now := time.Date(2026, 1, 8, 15, 0, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	toast := costs.Attribution{Actor: "gastown/polecats/Toast", Rig: "gastown", Convoy: "hq-cv-1", Account: "work"}
+	nux := costs.Attribution{Actor: "beads/polecats/Nux", Rig: "beads", Convoy: "hq-cv-2"}
+
+	entries := []costs.Entry{
+		entry(yesterday, 100, toast), // outside today's period
+		entry(now.Add(-2*time.Hour), 6, toast),
+		entry(now.Add(-1*time.Hour), 5, toast),
+		entry(now.Add(-1*time.Hour), 3, nux),
+	}
+	limits := &Limits{
+		Budgets: map[Scope]config.Budget{
+			{Kind: ScopeTown}:                    {SoftUSD: 10, HardUSD: 50},
+			{Kind: ScopeRig, Name: "gastown"}:    {SoftUSD: 5, HardUSD: 10},
+			{Kind: ScopeAccount, Name: "work"}:   {Period: config.BudgetPeriodWeek, HardUSD: 200},
+			{Kind: ScopeConvoy, Name: "hq-cv-1"}: {HardUSD: 1000},
+			{Kind: ScopeRig, Name: "unused-rig"}: {HardUSD: 1},
+		},
+		DefaultConvoy: &config.Budget{SoftUSD: 2},
+	}
+
+	report := limits.Evaluate(entries, nil, now)
+
+	want := map[string]struct {
+		spent float64
+		level Level
+	}{
+		"town":           {14, LevelSoft},
+		"rig:gastown":    {11, LevelHard},
+		"rig:unused-rig": {0, LevelOK},
+		"convoy:hq-cv-1": {111, LevelOK}, // own budget, total period
+		"convoy:hq-cv-2": {3, LevelSoft}, // default convoy budget
+		"account:work":   {111, LevelOK}, // weekly, so yesterday counts
+	}
+
+	if len(report.Statuses) != len(want) {
+		t.Fatalf("got %d statuses, want %d: %+v", len(report.Statuses), len(want), report.Statuses)
+	}
+	for _, st := range report.Statuses {
+		w, ok := want[st.Scope.String()]
+		if !ok {
+			t.Errorf("unexpected scope %s", st.Scope)
+			continue
+		}
+		if st.Spent != w.spent || st.Level != w.level {
+			t.Errorf("%s: spent %.2f level %s, want %.2f %s", st.Scope, st.Spent, st.Level, w.spent, w.level)
+		}
+	}
+	if report.Statuses[0].Scope.Kind != ScopeTown {
+		t.Errorf("town should sort first, got %s", report.Statuses[0].Scope)
+	}
+
+	// Toast's work is blocked by its rig; Nux's is not.
+	attr, ok := report.AgentAttribution("gastown/polecats/Toast")
+	if !ok || attr.Account != "work" {
+		t.Fatalf("AgentAttribution = %+v, %v", attr, ok)
+	}
+	if st := report.Blocking(attr); st == nil || st.Scope.String() != "rig:gastown" {
+		t.Errorf("Blocking(toast) = %+v, want rig:gastown", st)
+	}
+	if st := report.Blocking(nux); st != nil {
+		t.Errorf("Blocking(nux) = %+v, want nil", st)
+	}
+
+	// An override lifts the block.
+	overrides := map[Scope]Override{
+		{Kind: ScopeRig, Name: "gastown"}: {Scope: Scope{Kind: ScopeRig, Name: "gastown"}, Until: now.Add(time.Hour)},
+	}
+	report = limits.Evaluate(entries, overrides, now)
+	if st := report.Blocking(attr); st != nil {
+		t.Errorf("Blocking with override = %+v, want nil", st)
+	}
+	if st := report.Find(Scope{Kind: ScopeRig, Name: "gastown"}); st == nil || st.Level != LevelHard || st.Override == nil {
+		t.Errorf("overridden status = %+v, want hard level with override", st)
+	}
+}
+
+func TestLoadLimits(t *testing.T) {
+	townRoot := t.TempDir()
+	writeFile(t, filepath.Join(townRoot, "settings", "config.json"),
+		`{"type":"town-settings","version":1,"budget":{"soft_usd":40,"hard_usd":50},"convoy_budgets":{"*":{"hard_usd":20},"hq-cv-1":{"hard_usd":5}}}`)
+	writeFile(t, filepath.Join(townRoot, "mayor", "rigs.json"),
+		`{"version":1,"rigs":{"gastown":{"git_url":"https://example.com/gastown.git"},"beads":{"git_url":"https://example.com/beads.git"}}}`)
+	writeFile(t, filepath.Join(townRoot, "gastown", "settings", "config.json"),
+		`{"type":"rig-settings","version":1,"budget":{"period":"week","hard_usd":100}}`)
+	writeFile(t, filepath.Join(townRoot, "mayor", "accounts.json"),
+		`{"version":1,"accounts":{"work":{"email":"w@example.com","config_dir":"/tmp/work","budget":{"hard_usd":30}}}}`)
+
+	limits, err := LoadLimits(townRoot)
+	if err != nil {
+		t.Fatalf("LoadLimits: %v", err)
+	}
+	want := map[Scope]float64{
+		{Kind: ScopeTown}:                    50,
+		{Kind: ScopeConvoy, Name: "hq-cv-1"}: 5,
+		{Kind: ScopeRig, Name: "gastown"}:    100,
+		{Kind: ScopeAccount, Name: "work"}:   30,
+	}
+	if len(limits.Budgets) != len(want) {
+		t.Fatalf("budgets = %+v", limits.Budgets)
+	}
+	for scope, hard := range want {
+		if limits.Budgets[scope].HardUSD != hard {
+			t.Errorf("%s hard = %v, want %v", scope, limits.Budgets[scope].HardUSD, hard)
+		}
+	}
+	if limits.DefaultConvoy == nil || limits.DefaultConvoy.HardUSD != 20 {
+		t.Errorf("default convoy = %+v", limits.DefaultConvoy)
+	}
+
+	empty, err := LoadLimits(t.TempDir())
+	if err != nil || !empty.Empty() {
+		t.Errorf("town without settings: %+v, %v", empty, err)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	townRoot := t.TempDir()
+	now := time.Now()
+	rig := Scope{Kind: ScopeRig, Name: "gastown"}
+	town := Scope{Kind: ScopeTown}
+
+	if err := SetOverride(townRoot, Override{Scope: rig, Until: now.Add(time.Hour), Reason: "crunch", By: "mayor", At: now}); err != nil {
+		t.Fatalf("SetOverride: %v", err)
+	}
+	if err := SetOverride(townRoot, Override{Scope: town, Until: now.Add(time.Minute), At: now}); err != nil {
+		t.Fatalf("SetOverride: %v", err)
+	}
+
+	active, err := LoadOverrides(townRoot, now)
+	if err != nil {
+		t.Fatalf("LoadOverrides: %v", err)
+	}
+	if len(active) != 2 || active[rig].Reason != "crunch" || active[rig].By != "mayor" {
+		t.Errorf("active = %+v", active)
+	}
+
+	// Expired overrides are not active.
+	active, _ = LoadOverrides(townRoot, now.Add(30*time.Minute))
+	if _, ok := active[town]; ok || len(active) != 1 {
+		t.Errorf("after town override expiry: %+v", active)
+	}
+
+	if err := ClearOverride(townRoot, rig); err != nil {
+		t.Fatalf("ClearOverride: %v", err)
+	}
+	active, _ = LoadOverrides(townRoot, now)
+	if _, ok := active[rig]; ok {
+		t.Errorf("rig override should be cleared: %+v", active)
+	}
+}
+
+func TestCrossings(t *testing.T) {
+	townRoot := t.TempDir()
+	day1 := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)
+	rig := Scope{Kind: ScopeRig, Name: "gastown"}
+	report := func(since time.Time, level Level) *Report {
+		return &Report{Statuses: []Status{{Scope: rig, Since: since, Level: level}}}
+	}
+
+	steps := []struct {
+		since time.Time
+		level Level
+		want  int
+	}{
+		{day1, LevelOK, 0},
+		{day1, LevelSoft, 1},
+		{day1, LevelSoft, 0}, // already reported
+		{day1, LevelHard, 1},
+		{day1, LevelHard, 0},
+		{day1.AddDate(0, 0, 1), LevelHard, 1}, // new period, crossed again
+		{day1.AddDate(0, 0, 2), LevelOK, 0},
+	}
+	for i, step := range steps {
+		crossed, err := Crossings(townRoot, report(step.since, step.level))
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if len(crossed) != step.want {
+			t.Errorf("step %d: %d crossings, want %d", i, len(crossed), step.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,130 @@
+package budget
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/costs"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// File names in the town's costs directory.
+const (
+	OverridesFile = "budget-overrides.json"
+	StateFile     = "budget-state.json"
+)
+
+// Override suspends enforcement of a scope's budget until it expires.
+type Override struct {
+	Scope  Scope     `json:"scope"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+	By     string    `json:"by,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+func overridesPath(townRoot string) string {
+	return filepath.Join(costs.NewLedger(townRoot).Dir(), OverridesFile)
+}
+
+// LoadOverrides returns the overrides still in effect at now, by scope.
+func LoadOverrides(townRoot string, now time.Time) (map[Scope]Override, error) {
+	all, err := loadOverrides(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[Scope]Override, len(all))
+	for _, o := range all {
+		if now.Before(o.Until) {
+			active[o.Scope] = o
+		}
+	}
+	return active, nil
+}
+
+func loadOverrides(townRoot string) ([]Override, error) {
+	data, err := os.ReadFile(overridesPath(townRoot))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading budget overrides: %w", err)
+	}
+	var overrides []Override
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("parsing budget overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+// SetOverride records o, replacing any override for the same scope and
+// dropping expired ones.
+func SetOverride(townRoot string, o Override) error {
+	return replaceOverride(townRoot, o.Scope, &o, o.At)
+}
+
+// ClearOverride removes the override for scope, if any.
+func ClearOverride(townRoot string, scope Scope) error {
+	return replaceOverride(townRoot, scope, nil, time.Now())
+}
+
+// replaceOverride swaps scope's override for o (nil removes it).
+func replaceOverride(townRoot string, scope Scope, o *Override, now time.Time) error {
+	all, err := loadOverrides(townRoot)
+	if err != nil {
+		return err
+	}
+	var kept []Override
+	for _, existing := range all {
+		if existing.Scope != scope && now.Before(existing.Until) {
+			kept = append(kept, existing)
+		}
+	}
+	if o != nil {
+		kept = append(kept, *o)
+	}
+	path := overridesPath(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating costs directory: %w", err)
+	}
+	return util.AtomicWriteJSON(path, kept)
+}
+
+// levelRecord is the last level the daemon acted on for a scope.
+type levelRecord struct {
+	Level Level     `json:"level"`
+	Since time.Time `json:"since"` // period start the level was reached in
+}
+
+// Crossings returns the budgets whose level rose since the last call, and
+// records the current levels. A new period starts every budget back at
+// ok, so crossing again in the next period is reported again.
+func Crossings(townRoot string, report *Report) ([]Status, error) {
+	path := filepath.Join(costs.NewLedger(townRoot).Dir(), StateFile)
+	prev := make(map[string]levelRecord)
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &prev)
+	}
+
+	var crossed []Status
+	next := make(map[string]levelRecord, len(report.Statuses))
+	for _, st := range report.Statuses {
+		key := st.Scope.String()
+		last, ok := prev[key]
+		if !ok || !last.Since.Equal(st.Since) {
+			last = levelRecord{Level: LevelOK, Since: st.Since}
+		}
+		if st.Level > last.Level {
+			crossed = append(crossed, st)
+		}
+		next[key] = levelRecord{Level: st.Level, Since: st.Since}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return crossed, fmt.Errorf("creating costs directory: %w", err)
+	}
+	return crossed, util.AtomicWriteJSON(path, next)
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/connection"
 )
 
 //go:embed config/*.json
@@ -51,7 +53,50 @@ func EnsureSettings(workDir string, roleType RoleType) error {
 		return fmt.Errorf("creating .claude directory: %w", err)
 	}
 
-	// Select template based on role type
+	content, err := settingsTemplate(roleType)
+	if err != nil {
+		return err
+	}
+
+	// Write settings file
+	if err := os.WriteFile(settingsPath, content, 0600); err != nil {
+		return fmt.Errorf("writing settings: %w", err)
+	}
+
+	return nil
+}
+
+// EnsureSettingsOn is EnsureSettings for a workDir on the machine behind conn.
+// Used when starting agents on remote rigs.
+func EnsureSettingsOn(conn connection.Connection, workDir string, roleType RoleType) error {
+	claudeDir := filepath.Join(workDir, ".claude")
+	settingsPath := filepath.Join(claudeDir, "settings.json")
+
+	// If settings already exist, don't overwrite
+	if exists, err := conn.Exists(settingsPath); err != nil {
+		return fmt.Errorf("checking settings: %w", err)
+	} else if exists {
+		return nil
+	}
+
+	if err := conn.MkdirAll(claudeDir, 0755); err != nil {
+		return fmt.Errorf("creating .claude directory: %w", err)
+	}
+
+	content, err := settingsTemplate(roleType)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.WriteFile(settingsPath, content, 0600); err != nil {
+		return fmt.Errorf("writing settings: %w", err)
+	}
+
+	return nil
+}
+
+// settingsTemplate returns the embedded settings template for a role type.
+func settingsTemplate(roleType RoleType) ([]byte, error) {
 	var templateName string
 	switch roleType {
 	case Autonomous:
@@ -60,18 +105,11 @@ func EnsureSettings(workDir string, roleType RoleType) error {
 		templateName = "config/settings-interactive.json"
 	}
 
-	// Read template
 	content, err := configFS.ReadFile(templateName)
 	if err != nil {
-		return fmt.Errorf("reading template %s: %w", templateName, err)
+		return nil, fmt.Errorf("reading template %s: %w", templateName, err)
 	}
-
-	// Write settings file
-	if err := os.WriteFile(settingsPath, content, 0600); err != nil {
-		return fmt.Errorf("writing settings: %w", err)
-	}
-
-	return nil
+	return content, nil
 }
 
 // EnsureSettingsForRole is a convenience function that combines RoleTypeFor and EnsureSettings.
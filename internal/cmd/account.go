@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/account"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/style"
@@ -34,7 +36,7 @@ Commands:
   gt account list              List registered accounts
   gt account add <handle>      Add a new account
   gt account default <handle>  Set the default account
-  gt account status            Show current account info`,
+  gt account status            Show current account and account health`,
 }
 
 var accountListCmd = &cobra.Command{
@@ -251,19 +253,49 @@ func runAccountDefault(cmd *cobra.Command, args []string) error {
 
 var accountStatusCmd = &cobra.Command{
 	Use:   "status",
-	Short: "Show current account info",
-	Long: `Show which Claude Code account would be used for new sessions.
+	Short: "Show current account and account health",
+	Long: `Show which Claude Code account would be used for new sessions, and
+the health of every account.
 
-Displays the currently resolved account based on:
+The account for new sessions is resolved from:
 1. GT_ACCOUNT environment variable (highest priority)
-2. Default account from config
+2. The account policy in mayor/accounts.json ("policy"):
+     round-robin              rotate through accounts in turn
+     least-recently-limited   prefer accounts that hit a limit longest ago
+     weighted                 share spawns by each account's "weight"
+3. Default account from config
+
+Accounts that hit a usage or rate limit cool down until the limit resets
+(or an hour, if the agent didn't say) and are skipped while resting. The
+daemon detects limit banners in polecat sessions and restarts the affected
+polecats on a healthy account.
 
 Examples:
-  gt account status           # Show current account
+  gt account status           # Show current account and health
+  gt account status --json    # JSON output
   GT_ACCOUNT=work gt account status  # Show with env override`,
 	RunE: runAccountStatus,
 }
 
+// AccountHealthItem is an account's health in status output.
+type AccountHealthItem struct {
+	Handle        string    `json:"handle"`
+	CoolingDown   bool      `json:"cooling_down"`
+	CooldownUntil time.Time `json:"cooldown_until,omitempty"`
+	LimitedAt     time.Time `json:"limited_at,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Limits        int       `json:"limits"`
+	Picks         int       `json:"picks"`
+	Weight        int       `json:"weight,omitempty"`
+}
+
+// AccountStatusOutput is the JSON output of gt account status.
+type AccountStatusOutput struct {
+	Current  string              `json:"current,omitempty"`
+	Policy   string              `json:"policy,omitempty"`
+	Accounts []AccountHealthItem `json:"accounts"`
+}
+
 func runAccountStatus(cmd *cobra.Command, args []string) error {
 	townRoot, err := workspace.FindFromCwd()
 	if err != nil {
@@ -271,48 +303,96 @@ func runAccountStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	accountsPath := constants.MayorAccountsPath(townRoot)
-
-	// Resolve account (empty flag since we want to show default resolution)
-	configDir, handle, err := config.ResolveAccountConfigDir(accountsPath, "")
-	if err != nil {
-		return fmt.Errorf("resolving account: %w", err)
-	}
-
-	if handle == "" {
+	cfg, err := config.LoadAccountsConfig(accountsPath)
+	if err != nil || len(cfg.Accounts) == 0 {
 		fmt.Println("No account configured.")
 		fmt.Println("\nTo add an account:")
 		fmt.Println("  gt account add <handle>")
 		return nil
 	}
 
-	// Check if GT_ACCOUNT is overriding
-	envAccount := os.Getenv("GT_ACCOUNT")
-
-	// Load config to get full account info
-	cfg, err := config.LoadAccountsConfig(accountsPath)
+	state, err := account.LoadState(townRoot)
 	if err != nil {
-		return fmt.Errorf("loading accounts config: %w", err)
+		return fmt.Errorf("loading account health: %w", err)
 	}
+	now := time.Now()
 
-	acct := cfg.GetAccount(handle)
-	if acct == nil {
-		return fmt.Errorf("account '%s' not found", handle)
+	// Resolve the account a new session would get (without recording a pick)
+	envAccount := os.Getenv("GT_ACCOUNT")
+	handle := envAccount
+	if handle == "" {
+		handle = account.Select(cfg, state, now, "")
 	}
 
-	fmt.Printf("%s\n\n", style.Bold.Render("Current Account"))
-	fmt.Printf("Handle:     %s\n", style.Bold.Render(handle))
-	if acct.Email != "" {
-		fmt.Printf("Email:      %s\n", acct.Email)
+	var items []AccountHealthItem
+	for h, acct := range cfg.Accounts {
+		item := AccountHealthItem{Handle: h, Weight: acct.Weight}
+		if health := state.Accounts[h]; health != nil {
+			item.CoolingDown = health.CoolingDown(now)
+			item.CooldownUntil = health.CooldownUntil
+			item.LimitedAt = health.LimitedAt
+			item.Reason = health.Reason
+			item.Limits = health.Limits
+			item.Picks = health.Picks
+		}
+		items = append(items, item)
 	}
-	if acct.Description != "" {
-		fmt.Printf("Description: %s\n", acct.Description)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Handle < items[j].Handle
+	})
+
+	if accountJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(AccountStatusOutput{Current: handle, Policy: cfg.Policy, Accounts: items})
 	}
-	fmt.Printf("Config Dir: %s\n", configDir)
 
-	if envAccount != "" {
-		fmt.Printf("\n%s\n", style.Dim.Render("(set via GT_ACCOUNT environment variable)"))
-	} else if handle == cfg.Default {
-		fmt.Printf("\n%s\n", style.Dim.Render("(default account)"))
+	if handle != "" {
+		acct := cfg.GetAccount(handle)
+		if acct == nil {
+			return fmt.Errorf("account '%s' not found", handle)
+		}
+
+		fmt.Printf("%s\n\n", style.Bold.Render("Current Account"))
+		fmt.Printf("Handle:     %s\n", style.Bold.Render(handle))
+		if acct.Email != "" {
+			fmt.Printf("Email:      %s\n", acct.Email)
+		}
+		if acct.Description != "" {
+			fmt.Printf("Description: %s\n", acct.Description)
+		}
+		fmt.Printf("Config Dir: %s\n", cfg.ConfigDirs()[handle])
+
+		switch {
+		case envAccount != "":
+			fmt.Printf("\n%s\n", style.Dim.Render("(set via GT_ACCOUNT environment variable)"))
+		case cfg.Policy != "":
+			fmt.Printf("\n%s\n", style.Dim.Render(fmt.Sprintf("(next pick under %s policy)", cfg.Policy)))
+		case handle == cfg.Default:
+			fmt.Printf("\n%s\n", style.Dim.Render("(default account)"))
+		default:
+			fmt.Printf("\n%s\n", style.Dim.Render(fmt.Sprintf("(default account %s is cooling down)", cfg.Default)))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%s\n\n", style.Bold.Render("Account Health"))
+	for _, item := range items {
+		status := style.Success.Render("healthy")
+		if item.CoolingDown {
+			status = style.Warning.Render(fmt.Sprintf("cooling down until %s (%s left)",
+				item.CooldownUntil.Local().Format("Jan 2 15:04"), item.CooldownUntil.Sub(now).Round(time.Minute)))
+		}
+		fmt.Printf("  %-16s %s\n", item.Handle, status)
+
+		details := fmt.Sprintf("%d sessions started, %d limits hit", item.Picks, item.Limits)
+		if !item.LimitedAt.IsZero() {
+			details += ", last " + item.LimitedAt.Local().Format("Jan 2 15:04")
+		}
+		fmt.Printf("  %-16s %s\n", "", style.Dim.Render(details))
+		if item.CoolingDown && item.Reason != "" {
+			fmt.Printf("  %-16s %s\n", "", style.Dim.Render(item.Reason))
+		}
 	}
 
 	return nil
@@ -321,6 +401,7 @@ func runAccountStatus(cmd *cobra.Command, args []string) error {
 func init() {
 	// Add flags
 	accountListCmd.Flags().BoolVar(&accountJSON, "json", false, "Output as JSON")
+	accountStatusCmd.Flags().BoolVar(&accountJSON, "json", false, "Output as JSON")
 
 	accountAddCmd.Flags().StringVar(&accountEmail, "email", "", "Account email address")
 	accountAddCmd.Flags().StringVar(&accountDescription, "desc", "", "Account description")
@@ -3,10 +3,12 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -91,7 +93,7 @@ func runActivityEmit(cmd *cobra.Command, args []string) error {
 	eventType := args[0]
 
 	// Validate we're in a Gas Town workspace
-	_, err := workspace.FindFromCwdOrError()
+	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
 		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
@@ -185,6 +187,7 @@ func runActivityEmit(cmd *cobra.Command, args []string) error {
 	if err := events.LogFeed(eventType, actor, payload); err != nil {
 		return fmt.Errorf("emitting event: %w", err)
 	}
+	recordPatrolTelemetry(townRoot, eventType, actor, activityRig)
 
 	// Print confirmation
 	payloadJSON, _ := json.Marshal(payload)
@@ -195,4 +198,32 @@ func runActivityEmit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// recordPatrolTelemetry counts witness patrols. A completed patrol is timed
+// from the same actor's last patrol_started event. The patrol formula emits
+// both events; witness nudges are counted by gt nudge itself.
+func recordPatrolTelemetry(townRoot, eventType, actor, rig string) {
+	rec := telemetry.At(townRoot)
+	labels := telemetry.Labels{"rig": rig}
+	switch eventType {
+	case events.TypePatrolStarted:
+		rec.Count(telemetry.MetricWitnessPatrols, labels)
+	case events.TypePatrolComplete:
+		filter, err := events.ParseFilter(`type=`+events.TypePatrolStarted+` "actor=`+actor+`"`, time.Now())
+		if err != nil {
+			return
+		}
+		started, found, err := events.FindLast(townRoot, filter)
+		if err != nil || !found {
+			return
+		}
+		start, err := time.Parse(time.RFC3339, started.Timestamp)
+		if err != nil {
+			return
+		}
+		now := time.Now()
+		rec.Observe(telemetry.MetricWitnessPatrol, now.Sub(start).Seconds(), labels)
+		rec.RecordSpan("witness patrol", start, now, telemetry.Labels{"rig": rig, "actor": actor})
+	}
+}
+
 // Note: detectActor is defined in sling.go and reused here
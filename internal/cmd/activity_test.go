@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/telemetry"
+)
+
+// metricValue returns a series' counter value, or its histogram count.
+func metricValue(t *testing.T, townRoot, name string) float64 {
+	t.Helper()
+	snap, err := telemetry.Load(townRoot)
+	if err != nil {
+		t.Fatalf("loading telemetry: %v", err)
+	}
+	for _, s := range snap.Series {
+		if s.Name == name {
+			if s.Kind == telemetry.KindHistogram {
+				return float64(s.Count)
+			}
+			return s.Value
+		}
+	}
+	return 0
+}
+
+func TestRecordPatrolTelemetry(t *testing.T) {
+	town := t.TempDir()
+	actor := "gastown/witness"
+
+	if err := events.LogTo(town, events.TypePatrolStarted, actor, events.PatrolPayload("gastown", 2, ""), events.VisibilityFeed); err != nil {
+		t.Fatal(err)
+	}
+	recordPatrolTelemetry(town, events.TypePatrolStarted, actor, "gastown")
+	recordPatrolTelemetry(town, events.TypePatrolComplete, actor, "gastown")
+
+	if got := metricValue(t, town, telemetry.MetricWitnessPatrols); got != 1 {
+		t.Errorf("%s = %v, want 1", telemetry.MetricWitnessPatrols, got)
+	}
+	if got := metricValue(t, town, telemetry.MetricWitnessPatrol); got != 1 {
+		t.Errorf("%s observations = %v, want 1", telemetry.MetricWitnessPatrol, got)
+	}
+}
@@ -403,26 +403,10 @@ func formatTownlogSummary(e townlog.Event) string {
 func collectFeedEvents(townRoot, actor string, since time.Time) ([]AuditEntry, error) {
 	var entries []AuditEntry
 
-	eventsPath := filepath.Join(townRoot, events.EventsFile)
-	file, err := os.Open(eventsPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No events file yet
-		}
-		return nil, err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		var e events.Event
-		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
-			continue // Skip malformed lines
-		}
-
+	err := events.Each(townRoot, func(e events.Event) {
 		// Apply actor filter
 		if actor != "" && !matchesActor(e.Actor, actor) {
-			continue
+			return
 		}
 
 		// Parse timestamp
@@ -430,7 +414,7 @@ func collectFeedEvents(townRoot, actor string, since time.Time) ([]AuditEntry, e
 
 		// Apply since filter
 		if !since.IsZero() && ts.Before(since) {
-			continue
+			return
 		}
 
 		entries = append(entries, AuditEntry{
@@ -440,9 +424,9 @@ func collectFeedEvents(townRoot, actor string, since time.Time) ([]AuditEntry, e
 			Actor:     e.Actor,
 			Summary:   formatFeedSummary(e),
 		})
-	}
+	})
 
-	return entries, nil
+	return entries, err
 }
 
 // formatFeedSummary creates a readable summary from a feed event.
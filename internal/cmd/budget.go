@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/budget"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/costs"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	budgetStatusJSON bool
+
+	budgetOverrideFor    time.Duration
+	budgetOverrideReason string
+	budgetOverrideClear  bool
+)
+
+var budgetCmd = &cobra.Command{
+	Use:     "budget",
+	GroupID: GroupDiag,
+	Short:   "Show and override spend budgets",
+	Long: `Spend budgets cap what agents may spend, measured from the cost ledger
+(see gt costs).
+
+Budgets can be set for the whole town, for a rig, for convoys and for
+accounts. Each has a soft and a hard limit in USD over a period (day, week,
+month or total):
+
+  settings/config.json          "budget": {...}
+                                "convoy_budgets": {"hq-cv-abc": {...}, "*": {...}}
+  <rig>/settings/config.json    "budget": {...}
+  mayor/accounts.json           "accounts": {"work": {..., "budget": {...}}}
+
+  {"period": "day", "soft_usd": 40, "hard_usd": 50}
+
+Periods default to day, or total for convoys. The "*" convoy budget
+applies to every convoy without one of its own.
+
+The daemon checks budgets on every heartbeat. Crossing a soft limit
+escalates (MEDIUM); crossing a hard limit escalates (HIGH), refuses new
+polecat spawns in that scope and parks its running polecats until the
+period rolls over or an override is recorded.`,
+	RunE: requireSubcommand,
+}
+
+var budgetStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show spend against each budget",
+	Long: `Show spend in the current period against each configured budget.
+
+Examples:
+  gt budget status
+  gt budget status --json`,
+	RunE: runBudgetStatus,
+}
+
+var budgetOverrideCmd = &cobra.Command{
+	Use:   "override <scope>",
+	Short: "Suspend a budget's hard limit for a while",
+	Long: `Suspend enforcement of a budget's hard limit, letting spawns proceed and
+parked polecats resume. The override is recorded as an audit event.
+
+Scopes are town, rig:<name>, convoy:<id> or account:<handle>.
+
+Examples:
+  gt budget override rig:gastown --for 4h --reason "release crunch"
+  gt budget override convoy:hq-cv-abc --reason "approved by overseer"
+  gt budget override rig:gastown --clear`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBudgetOverride,
+}
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+
+	budgetStatusCmd.Flags().BoolVar(&budgetStatusJSON, "json", false, "Output as JSON")
+	budgetCmd.AddCommand(budgetStatusCmd)
+
+	budgetOverrideCmd.Flags().DurationVar(&budgetOverrideFor, "for", 24*time.Hour, "How long the override lasts")
+	budgetOverrideCmd.Flags().StringVar(&budgetOverrideReason, "reason", "", "Why the budget is being overridden (required)")
+	budgetOverrideCmd.Flags().BoolVar(&budgetOverrideClear, "clear", false, "Remove the override instead")
+	budgetCmd.AddCommand(budgetOverrideCmd)
+}
+
+func runBudgetStatus(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	if _, err := ingestCosts(townRoot, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ingesting costs: %v\n", err)
+	}
+	report, err := budget.Evaluate(townRoot, time.Now())
+	if err != nil {
+		return fmt.Errorf("evaluating budgets: %w", err)
+	}
+
+	if budgetStatusJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		statuses := report.Statuses
+		if statuses == nil {
+			statuses = []budget.Status{}
+		}
+		return enc.Encode(statuses)
+	}
+
+	if len(report.Statuses) == 0 {
+		fmt.Println("No budgets configured. See 'gt budget --help'.")
+		return nil
+	}
+
+	fmt.Printf("\n%s Budgets\n\n", style.Bold.Render("💰"))
+	fmt.Printf("  %-24s %-7s %10s %10s %10s  %s\n", "SCOPE", "PERIOD", "SPENT", "SOFT", "HARD", "STATUS")
+	for _, st := range report.Statuses {
+		fmt.Printf("  %-24s %-7s %10s %10s %10s  %s\n",
+			st.Scope, budgetPeriodLabel(st), fmt.Sprintf("$%.2f", st.Spent),
+			budgetLimitLabel(st.Budget.SoftUSD), budgetLimitLabel(st.Budget.HardUSD),
+			budgetStatusLabel(st))
+	}
+	fmt.Println()
+	return nil
+}
+
+// budgetPeriodLabel returns the period a status was measured over.
+func budgetPeriodLabel(st budget.Status) string {
+	if st.Budget.Period != "" {
+		return st.Budget.Period
+	}
+	if st.Since.IsZero() {
+		return config.BudgetPeriodTotal
+	}
+	return config.BudgetPeriodDay
+}
+
+func budgetLimitLabel(usd float64) string {
+	if usd <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+func budgetStatusLabel(st budget.Status) string {
+	switch {
+	case st.Override != nil:
+		label := fmt.Sprintf("%s (overridden until %s)", st.Level, st.Override.Until.Local().Format("Jan 2 15:04"))
+		return style.Warning.Render(label)
+	case st.Level == budget.LevelHard:
+		return style.Error.Render("over hard limit")
+	case st.Level == budget.LevelSoft:
+		return style.Warning.Render("over soft limit")
+	default:
+		return style.Success.Render("ok")
+	}
+}
+
+func runBudgetOverride(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	scope, err := budget.ParseScope(args[0])
+	if err != nil {
+		return err
+	}
+	actor := detectActor()
+
+	if budgetOverrideClear {
+		if err := budget.ClearOverride(townRoot, scope); err != nil {
+			return fmt.Errorf("clearing override: %w", err)
+		}
+		_ = events.LogAudit(events.TypeBudgetOverride, actor,
+			events.BudgetOverridePayload(scope.String(), time.Time{}, budgetOverrideReason))
+		fmt.Printf("%s Cleared budget override for %s\n", style.Success.Render("✓"), scope)
+		return nil
+	}
+
+	if budgetOverrideReason == "" {
+		return fmt.Errorf("--reason is required")
+	}
+	if budgetOverrideFor <= 0 {
+		return fmt.Errorf("--for must be positive")
+	}
+
+	now := time.Now()
+	o := budget.Override{
+		Scope:  scope,
+		Until:  now.Add(budgetOverrideFor),
+		Reason: budgetOverrideReason,
+		By:     actor,
+		At:     now,
+	}
+	if err := budget.SetOverride(townRoot, o); err != nil {
+		return fmt.Errorf("recording override: %w", err)
+	}
+	_ = events.LogAudit(events.TypeBudgetOverride, actor,
+		events.BudgetOverridePayload(scope.String(), o.Until, o.Reason))
+
+	fmt.Printf("%s Overrode budget %s until %s\n", style.Success.Render("✓"), scope, o.Until.Format("Jan 2 15:04"))
+	fmt.Printf("  %s\n", style.Dim.Render("Parked polecats resume on the daemon's next heartbeat"))
+	return nil
+}
+
+// checkSpawnBudget refuses a polecat spawn whose work would be charged to a
+// budget past its hard limit: the town, the rig, the bead's convoy or the
+// account the polecat would run under.
+func checkSpawnBudget(townRoot, rigName, accountHandle, beadID string) error {
+	report, err := budget.Evaluate(townRoot, time.Now())
+	if err != nil {
+		// Budgets must not break slinging when the ledger is unreadable.
+		fmt.Fprintf(os.Stderr, "Warning: checking budgets: %v\n", err)
+		return nil
+	}
+	if len(report.Statuses) == 0 {
+		return nil
+	}
+
+	attr := costs.Attribution{Rig: rigName, Account: accountHandle}
+	if beadID != "" {
+		attr.Convoy = isTrackedByConvoy(beadID)
+	}
+
+	if st := report.Blocking(attr); st != nil {
+		return fmt.Errorf("budget %s is over its hard limit ($%.2f of $%.2f)\nTo spawn anyway: gt budget override %s --for <duration> --reason <why>",
+			st.Scope, st.Spent, st.Budget.HardUSD, st.Scope)
+	}
+	return nil
+}
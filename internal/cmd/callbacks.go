@@ -11,23 +11,20 @@ import (
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/townlog"
+	"github.com/steveyegge/gastown/internal/witness"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
-// Callback message subject patterns for routing.
+// Callback message subject patterns for routing messages without a
+// protocol envelope. POLECAT_DONE and HELP are classified by the witness
+// package.
 var (
-	// POLECAT_DONE <name> - polecat signaled completion
-	patternPolecatDone = regexp.MustCompile(`^POLECAT_DONE\s+(\S+)`)
-
 	// Merge Request Rejected: <branch> - refinery rejected MR
 	patternMergeRejected = regexp.MustCompile(`^Merge Request Rejected:\s+(.+)`)
 
 	// Merge Request Completed: <branch> - refinery completed MR
 	patternMergeCompleted = regexp.MustCompile(`^Merge Request Completed:\s+(.+)`)
 
-	// HELP: <topic> - polecat requesting help
-	patternHelp = regexp.MustCompile(`^HELP:\s+(.+)`)
-
 	// ESCALATION: <topic> - witness escalating issue
 	patternEscalation = regexp.MustCompile(`^ESCALATION:\s+(.+)`)
 
@@ -208,7 +205,7 @@ func processCallback(townRoot string, msg *mail.Message, dryRun bool) CallbackRe
 	}
 
 	// Classify the callback
-	result.CallbackType = classifyCallback(msg.Subject)
+	result.CallbackType = classifyCallback(msg)
 
 	// Handle based on type
 	switch result.CallbackType {
@@ -252,17 +249,25 @@ func processCallback(townRoot string, msg *mail.Message, dryRun bool) CallbackRe
 	return result
 }
 
-// classifyCallback determines the type of callback from the subject line.
-func classifyCallback(subject string) CallbackType {
-	switch {
-	case patternPolecatDone.MatchString(subject):
+// classifyCallback determines the type of callback from the message's
+// protocol envelope, or for a legacy message, its subject line.
+func classifyCallback(msg *mail.Message) CallbackType {
+	switch witness.Classify(msg) {
+	case witness.ProtoPolecatDone:
 		return CallbackPolecatDone
+	case witness.ProtoHelp:
+		return CallbackHelp
+	}
+	if msg.Envelope != nil {
+		return CallbackUnknown
+	}
+
+	subject := msg.Subject
+	switch {
 	case patternMergeRejected.MatchString(subject):
 		return CallbackMergeRejected
 	case patternMergeCompleted.MatchString(subject):
 		return CallbackMergeCompleted
-	case patternHelp.MatchString(subject):
-		return CallbackHelp
 	case patternEscalation.MatchString(subject):
 		return CallbackEscalation
 	case patternSling.MatchString(subject):
@@ -274,24 +279,12 @@ func classifyCallback(subject string) CallbackType {
 
 // handlePolecatDone processes a POLECAT_DONE callback.
 // These come from Witnesses forwarding polecat completion notices.
-func handlePolecatDone(townRoot string, msg *mail.Message, dryRun bool) (string, error) { //nolint:unparam // error return kept for consistency with callback interface
-	matches := patternPolecatDone.FindStringSubmatch(msg.Subject)
-	polecatName := ""
-	if len(matches) > 1 {
-		polecatName = matches[1]
-	}
-
-	// Extract info from body
-	var exitType, issueID string
-	for _, line := range strings.Split(msg.Body, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "Exit:") {
-			exitType = strings.TrimSpace(strings.TrimPrefix(line, "Exit:"))
-		}
-		if strings.HasPrefix(line, "Issue:") {
-			issueID = strings.TrimSpace(strings.TrimPrefix(line, "Issue:"))
-		}
+func handlePolecatDone(townRoot string, msg *mail.Message, dryRun bool) (string, error) {
+	payload, err := witness.DecodePolecatDone(msg)
+	if err != nil {
+		return "", fmt.Errorf("parsing POLECAT_DONE: %w", err)
 	}
+	polecatName, exitType, issueID := payload.PolecatName, payload.Exit, payload.IssueID
 
 	if dryRun {
 		return fmt.Sprintf("would log completion for %s (exit=%s, issue=%s)",
@@ -385,11 +378,11 @@ func handleMergeRejected(townRoot string, msg *mail.Message, dryRun bool) (strin
 
 // handleHelp processes a HELP: request from a polecat.
 func handleHelp(townRoot string, msg *mail.Message, dryRun bool) (string, error) {
-	matches := patternHelp.FindStringSubmatch(msg.Subject)
-	topic := ""
-	if len(matches) > 1 {
-		topic = matches[1]
+	payload, err := witness.DecodeHelp(msg)
+	if err != nil {
+		return "", fmt.Errorf("parsing HELP: %w", err)
 	}
+	topic := payload.Topic
 
 	if dryRun {
 		return fmt.Sprintf("would forward help request to overseer: %s", topic), nil
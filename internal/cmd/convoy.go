@@ -16,6 +16,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tui/convoy"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -657,6 +659,7 @@ func checkAndCloseCompletedConvoys(townBeads string) ([]struct{ ID, Title string
 			}
 
 			closed = append(closed, struct{ ID, Title string }{convoy.ID, convoy.Title})
+			_ = events.LogFeed(events.TypeConvoyClosed, "gt", events.ConvoyPayload(convoy.ID, convoy.Title))
 
 			// Check if convoy has notify address and send notification
 			notifyConvoyCompletion(townBeads, convoy.ID, convoy.Title)
@@ -1027,29 +1030,12 @@ type trackedIssueInfo struct {
 	WorkerAge string `json:"worker_age,omitempty"` // How long worker has been on this issue
 }
 
-// getTrackedIssues queries SQLite directly to get issues tracked by a convoy.
-// This is needed because bd dep list doesn't properly show cross-rig external dependencies.
-// Uses batched lookup to avoid N+1 subprocess calls.
+// getTrackedIssues returns the issues tracked by a convoy. It reads the
+// dependencies from the beads cache because bd dep list doesn't properly
+// show cross-rig external dependencies.
 func getTrackedIssues(townBeads, convoyID string) []trackedIssueInfo {
-	dbPath := filepath.Join(townBeads, "beads.db")
-
-	// Query tracked dependencies from SQLite
-	// Escape single quotes to prevent SQL injection
-	safeConvoyID := strings.ReplaceAll(convoyID, "'", "''")
-	queryCmd := exec.Command("sqlite3", "-json", dbPath,
-		fmt.Sprintf(`SELECT depends_on_id, type FROM dependencies WHERE issue_id = '%s' AND type = 'tracks'`, safeConvoyID))
-
-	var stdout bytes.Buffer
-	queryCmd.Stdout = &stdout
-	if err := queryCmd.Run(); err != nil {
-		return nil
-	}
-
-	var deps []struct {
-		DependsOnID string `json:"depends_on_id"`
-		Type        string `json:"type"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &deps); err != nil {
+	deps, err := beads.New(townBeads).Cached().Dependencies(convoyID, beads.DepTracks)
+	if err != nil {
 		return nil
 	}
 
@@ -1057,21 +1043,12 @@ func getTrackedIssues(townBeads, convoyID string) []trackedIssueInfo {
 	issueIDs := make([]string, 0, len(deps))
 	idToDepType := make(map[string]string)
 	for _, dep := range deps {
-		issueID := dep.DependsOnID
-
-		// Handle external reference format: external:rig:issue-id
-		if strings.HasPrefix(issueID, "external:") {
-			parts := strings.SplitN(issueID, ":", 3)
-			if len(parts) == 3 {
-				issueID = parts[2] // Extract the actual issue ID
-			}
-		}
-
+		issueID := beads.ExternalRefID(dep.DependsOnID)
 		issueIDs = append(issueIDs, issueID)
 		idToDepType[issueID] = dep.Type
 	}
 
-	// Single batch call to get all issue details
+	// Single batch lookup to get all issue details
 	detailsMap := getIssueDetailsBatch(issueIDs)
 
 	// Get workers for these issues (only for non-closed issues)
@@ -1122,7 +1099,28 @@ type issueDetails struct {
 	Assignee  string
 }
 
-// getIssueDetailsBatch fetches details for multiple issues in a single bd show call.
+func newIssueDetails(issue *beads.Issue) *issueDetails {
+	return &issueDetails{
+		ID:        issue.ID,
+		Title:     issue.Title,
+		Status:    issue.Status,
+		IssueType: issue.Type,
+		Assignee:  issue.Assignee,
+	}
+}
+
+// issueLookup returns a cached beads reader for looking up issues in any
+// rig. Lookups start in the town database and follow its routes, falling
+// back to bd in the current directory outside a town.
+func issueLookup() *beads.Beads {
+	townBeads, err := getTownBeadsDir()
+	if err != nil {
+		townBeads = "."
+	}
+	return beads.New(townBeads).Cached()
+}
+
+// getIssueDetailsBatch fetches details for multiple issues in one lookup.
 // Returns a map from issue ID to details. Missing/invalid issues are omitted from the map.
 func getIssueDetailsBatch(issueIDs []string) map[string]*issueDetails {
 	result := make(map[string]*issueDetails)
@@ -1130,81 +1128,24 @@ func getIssueDetailsBatch(issueIDs []string) map[string]*issueDetails {
 		return result
 	}
 
-	// Build args: bd --no-daemon show id1 id2 id3 ... --json
-	// Use --no-daemon to ensure fresh data (avoid stale cache from daemon)
-	args := append([]string{"--no-daemon", "show"}, issueIDs...)
-	args = append(args, "--json")
-
-	showCmd := exec.Command("bd", args...)
-	var stdout bytes.Buffer
-	showCmd.Stdout = &stdout
-
-	if err := showCmd.Run(); err != nil {
-		// Batch failed - fall back to individual lookups for robustness
-		// This handles cases where some IDs are invalid/missing
-		for _, id := range issueIDs {
-			if details := getIssueDetails(id); details != nil {
-				result[id] = details
-			}
-		}
-		return result
-	}
-
-	var issues []struct {
-		ID        string `json:"id"`
-		Title     string `json:"title"`
-		Status    string `json:"status"`
-		IssueType string `json:"issue_type"`
-		Assignee  string `json:"assignee"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil {
+	issues, err := issueLookup().ShowMultiple(issueIDs)
+	if err != nil {
 		return result
 	}
-
-	for _, issue := range issues {
-		result[issue.ID] = &issueDetails{
-			ID:        issue.ID,
-			Title:     issue.Title,
-			Status:    issue.Status,
-			IssueType: issue.IssueType,
-			Assignee:  issue.Assignee,
-		}
+	for id, issue := range issues {
+		result[id] = newIssueDetails(issue)
 	}
 
 	return result
 }
 
-// getIssueDetails fetches issue details by trying to show it via bd.
-// Prefer getIssueDetailsBatch for multiple issues to avoid N+1 subprocess calls.
+// getIssueDetails fetches details for one issue, or nil if it can't be found.
 func getIssueDetails(issueID string) *issueDetails {
-	// Use bd show with routing - it should find the issue in the right rig
-	// Use --no-daemon to ensure fresh data (avoid stale cache)
-	showCmd := exec.Command("bd", "--no-daemon", "show", issueID, "--json")
-	var stdout bytes.Buffer
-	showCmd.Stdout = &stdout
-
-	if err := showCmd.Run(); err != nil {
-		return nil
-	}
-
-	var issues []struct {
-		ID        string `json:"id"`
-		Title     string `json:"title"`
-		Status    string `json:"status"`
-		IssueType string `json:"issue_type"`
-		Assignee  string `json:"assignee"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil || len(issues) == 0 {
+	issue, err := issueLookup().Show(issueID)
+	if err != nil {
 		return nil
 	}
-
-	return &issueDetails{
-		ID:        issues[0].ID,
-		Title:     issues[0].Title,
-		Status:    issues[0].Status,
-		IssueType: issues[0].IssueType,
-		Assignee:  issues[0].Assignee,
-	}
+	return newIssueDetails(issue)
 }
 
 // workerInfo holds info about a worker assigned to an issue.
@@ -1216,8 +1157,8 @@ type workerInfo struct {
 // getWorkersForIssues finds workers currently assigned to the given issues.
 // Returns a map from issue ID to worker info.
 //
-// Optimized to batch queries per rig (O(R) instead of O(N×R)) and
-// parallelize across rigs.
+// Agent beads are read once per rig from the beads cache, in parallel
+// across rigs.
 func getWorkersForIssues(issueIDs []string) map[string]*workerInfo {
 	result := make(map[string]*workerInfo)
 	if len(issueIDs) == 0 {
@@ -1232,75 +1173,49 @@ func getWorkersForIssues(issueIDs []string) map[string]*workerInfo {
 
 	// Discover rigs with beads databases
 	rigDirs, _ := filepath.Glob(filepath.Join(townRoot, "*", "polecats"))
-	var beadsDBS []string
+	var rigBeads []*beads.Beads
 	for _, polecatsDir := range rigDirs {
-		rigDir := filepath.Dir(polecatsDir)
-		beadsDB := filepath.Join(rigDir, "mayor", "rig", ".beads", "beads.db")
-		if _, err := os.Stat(beadsDB); err == nil {
-			beadsDBS = append(beadsDBS, beadsDB)
+		rigPath := filepath.Join(filepath.Dir(polecatsDir), "mayor", "rig")
+		if _, err := os.Stat(filepath.Join(rigPath, ".beads", "beads.db")); err == nil {
+			rigBeads = append(rigBeads, beads.New(rigPath).Cached())
 		}
 	}
 
-	if len(beadsDBS) == 0 {
+	if len(rigBeads) == 0 {
 		return result
 	}
 
-	// Build the IN clause with properly escaped issue IDs
-	var quotedIDs []string
+	wanted := make(map[string]bool, len(issueIDs))
 	for _, id := range issueIDs {
-		safeID := strings.ReplaceAll(id, "'", "''")
-		quotedIDs = append(quotedIDs, fmt.Sprintf("'%s'", safeID))
-	}
-	inClause := strings.Join(quotedIDs, ", ")
-
-	// Batch query: fetch all matching agents in one query per rig
-	query := fmt.Sprintf(
-		`SELECT id, hook_bead, last_activity FROM issues WHERE issue_type = 'agent' AND status = 'open' AND hook_bead IN (%s)`,
-		inClause)
-
-	// Query all rigs in parallel
-	type rigResult struct {
-		agents []struct {
-			ID           string `json:"id"`
-			HookBead     string `json:"hook_bead"`
-			LastActivity string `json:"last_activity"`
-		}
+		wanted[id] = true
 	}
 
-	resultChan := make(chan rigResult, len(beadsDBS))
+	// Load agent beads from all rigs in parallel
+	resultChan := make(chan map[string]*beads.Issue, len(rigBeads))
 	var wg sync.WaitGroup
 
-	for _, beadsDB := range beadsDBS {
+	for _, b := range rigBeads {
 		wg.Add(1)
-		go func(db string) {
+		go func(b *beads.Beads) {
 			defer wg.Done()
-
-			queryCmd := exec.Command("sqlite3", "-json", db, query)
-			var stdout bytes.Buffer
-			queryCmd.Stdout = &stdout
-			if err := queryCmd.Run(); err != nil {
-				resultChan <- rigResult{}
-				return
-			}
-
-			var rr rigResult
-			if err := json.Unmarshal(stdout.Bytes(), &rr.agents); err != nil {
-				resultChan <- rigResult{}
-				return
-			}
-			resultChan <- rr
-		}(beadsDB)
+			agents, _ := b.ListAgentBeads()
+			resultChan <- agents
+		}(b)
 	}
 
-	// Wait for all queries to complete
+	// Wait for all lookups to complete
 	go func() {
 		wg.Wait()
 		close(resultChan)
 	}()
 
 	// Collect results from all rigs
-	for rr := range resultChan {
-		for _, agent := range rr.agents {
+	for agents := range resultChan {
+		for _, agent := range agents {
+			if agent.Status != "open" || !wanted[agent.HookBead] {
+				continue
+			}
+
 			// Skip if we already found a worker for this issue
 			if _, ok := result[agent.HookBead]; ok {
 				continue
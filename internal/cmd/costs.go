@@ -2,27 +2,38 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
-	"regexp"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/costs"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 var (
-	costsJSON   bool
-	costsToday  bool
-	costsWeek   bool
-	costsByRole bool
-	costsByRig  bool
+	costsJSON     bool
+	costsToday    bool
+	costsWeek     bool
+	costsSince    string
+	costsByRole   bool
+	costsByRig    bool
+	costsByBead   bool
+	costsByConvoy bool
 
 	// Record subcommand flags
 	recordSession  string
@@ -32,29 +43,45 @@ var (
 var costsCmd = &cobra.Command{
 	Use:     "costs",
 	GroupID: GroupDiag,
-	Short:   "Show costs for running Claude sessions",
-	Long: `Display costs for Claude Code sessions in Gas Town.
+	Short:   "Show token usage and costs for agent sessions",
+	Long: `Display token usage and costs for agent sessions in Gas Town.
 
-By default, shows live costs scraped from running tmux sessions.
+Costs come from a local ledger (<town>/costs/ledger.jsonl) built from the
+transcripts each agent runtime writes: Claude Code (~/.claude/projects and
+each account's config dir), Codex (~/.codex/sessions) and Gemini CLI
+(~/.gemini/tmp). Every turn is priced per model and attributed to the
+session, role, rig, bead and convoy that incurred it. New transcript lines
+are ingested each time the command runs.
+
+By default, shows the current agent session cost of each Gas Town session.
+Time filters and breakdowns report over the whole ledger.
+
+Model prices can be overridden in <town>/settings/pricing.json, mapping
+model name prefixes to USD per million tokens:
+  {"claude-sonnet": {"input": 3, "output": 15, "cache_read": 0.3, "cache_write": 3.75}}
 
 Examples:
-  gt costs              # Live costs from running sessions
-  gt costs --today      # Today's total from session events
-  gt costs --week       # This week's total
-  gt costs --by-role    # Breakdown by role (polecat, witness, etc.)
-  gt costs --by-rig     # Breakdown by rig
-  gt costs --json       # Output as JSON`,
+  gt costs                  # Current cost of each Gas Town session
+  gt costs --today          # Today's total
+  gt costs --week           # Last 7 days
+  gt costs --since 36h      # Since a duration ago (or 3d, or 2026-01-02)
+  gt costs --by-role        # Breakdown by role (polecat, witness, etc.)
+  gt costs --by-rig         # Breakdown by rig
+  gt costs --by-bead        # Breakdown by the bead being worked
+  gt costs --by-convoy      # Breakdown by convoy
+  gt costs --json           # Output as JSON`,
 	RunE: runCosts,
 }
 
 var costsRecordCmd = &cobra.Command{
 	Use:   "record",
-	Short: "Record session cost as a bead event (called by Stop hook)",
-	Long: `Record the final cost of a session as a session.ended event in beads.
+	Short: "Ingest a session's usage into the cost ledger (called by Stop hook)",
+	Long: `Ingest new agent transcript usage into the cost ledger and report the
+session's cost so far.
 
-This command is intended to be called from a Claude Code Stop hook.
-It captures the final cost from the tmux session and creates an event
-bead with the cost data.
+This command is intended to be called from a Claude Code Stop hook. With
+--work-item, usage ingested for the session that is not already attributed
+to a bead is charged to that work item.
 
 Examples:
   gt costs record --session gt-gastown-toast
@@ -62,18 +89,32 @@ Examples:
 	RunE: runCostsRecord,
 }
 
+var costsIngestCmd = &cobra.Command{
+	Use:   "ingest",
+	Short: "Ingest new agent transcript usage into the cost ledger",
+	Long: `Scan agent transcripts for usage written since the last ingest and
+append it to the cost ledger. gt costs does this automatically; use this
+to refresh the ledger from scripts or patrols.`,
+	RunE: runCostsIngest,
+}
+
 func init() {
 	rootCmd.AddCommand(costsCmd)
 	costsCmd.Flags().BoolVar(&costsJSON, "json", false, "Output as JSON")
-	costsCmd.Flags().BoolVar(&costsToday, "today", false, "Show today's total from session events")
-	costsCmd.Flags().BoolVar(&costsWeek, "week", false, "Show this week's total from session events")
+	costsCmd.Flags().BoolVar(&costsToday, "today", false, "Show today's total")
+	costsCmd.Flags().BoolVar(&costsWeek, "week", false, "Show the last 7 days' total")
+	costsCmd.Flags().StringVar(&costsSince, "since", "", "Show costs since a duration ago (36h, 3d) or a date (2026-01-02)")
 	costsCmd.Flags().BoolVar(&costsByRole, "by-role", false, "Show breakdown by role")
 	costsCmd.Flags().BoolVar(&costsByRig, "by-rig", false, "Show breakdown by rig")
+	costsCmd.Flags().BoolVar(&costsByBead, "by-bead", false, "Show breakdown by bead")
+	costsCmd.Flags().BoolVar(&costsByConvoy, "by-convoy", false, "Show breakdown by convoy")
+	costsCmd.MarkFlagsMutuallyExclusive("today", "week", "since")
 
-	// Add record subcommand
+	// Add subcommands
 	costsCmd.AddCommand(costsRecordCmd)
 	costsRecordCmd.Flags().StringVar(&recordSession, "session", "", "Tmux session name to record")
 	costsRecordCmd.Flags().StringVar(&recordWorkItem, "work-item", "", "Work item ID (bead) for attribution")
+	costsCmd.AddCommand(costsIngestCmd)
 }
 
 // SessionCost represents cost info for a single session.
@@ -82,350 +123,298 @@ type SessionCost struct {
 	Role    string  `json:"role"`
 	Rig     string  `json:"rig,omitempty"`
 	Worker  string  `json:"worker,omitempty"`
+	Agent   string  `json:"agent,omitempty"`
+	Model   string  `json:"model,omitempty"`
+	Tokens  int64   `json:"tokens"`
 	Cost    float64 `json:"cost_usd"`
 	Running bool    `json:"running"`
 }
 
-// CostEntry is a ledger entry for historical cost tracking.
-type CostEntry struct {
-	SessionID string    `json:"session_id"`
-	Role      string    `json:"role"`
-	Rig       string    `json:"rig,omitempty"`
-	Worker    string    `json:"worker,omitempty"`
-	CostUSD   float64   `json:"cost_usd"`
-	StartedAt time.Time `json:"started_at"`
-	EndedAt   time.Time `json:"ended_at"`
-	WorkItem  string    `json:"work_item,omitempty"`
-}
-
 // CostsOutput is the JSON output structure.
 type CostsOutput struct {
 	Sessions []SessionCost      `json:"sessions,omitempty"`
 	Total    float64            `json:"total_usd"`
+	Tokens   int64              `json:"tokens"`
 	ByRole   map[string]float64 `json:"by_role,omitempty"`
 	ByRig    map[string]float64 `json:"by_rig,omitempty"`
+	ByBead   map[string]float64 `json:"by_bead,omitempty"`
+	ByConvoy map[string]float64 `json:"by_convoy,omitempty"`
 	Period   string             `json:"period,omitempty"`
+	Entries  int                `json:"entries"`
 }
 
-// costRegex matches cost patterns like "$1.23" or "$12.34"
-var costRegex = regexp.MustCompile(`\$(\d+\.\d{2})`)
-
 func runCosts(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	if _, err := ingestCosts(townRoot, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "%s cost ingest failed: %v\n", style.Dim.Render("Warning:"), err)
+	}
+
 	// If querying ledger, use ledger functions
-	if costsToday || costsWeek || costsByRole || costsByRig {
-		return runCostsFromLedger()
+	if costsToday || costsWeek || costsSince != "" || costsByRole || costsByRig || costsByBead || costsByConvoy {
+		return runCostsFromLedger(townRoot)
 	}
 
-	// Default: show live costs from running sessions
-	return runLiveCosts()
+	// Default: show current costs of Gas Town sessions
+	return runLiveCosts(townRoot)
 }
 
-func runLiveCosts() error {
-	t := tmux.NewTmux()
+// runLiveCosts shows each Gas Town session's cost for its current agent
+// session: the runtime session (conversation) that most recently
+// reported usage under that tmux session.
+func runLiveCosts(townRoot string) error {
+	entries, err := costs.NewLedger(townRoot).Entries(time.Time{})
+	if err != nil {
+		return err
+	}
+	bySession := currentSessionCosts(entries)
 
-	// Get all tmux sessions
+	t := tmux.NewTmux()
 	sessions, err := t.ListSessions()
 	if err != nil {
 		return fmt.Errorf("listing sessions: %w", err)
 	}
 
-	var costs []SessionCost
+	var sessionCosts []SessionCost
 	var total float64
-
-	for _, session := range sessions {
-		// Only process Gas Town sessions (start with "gt-")
-		if !strings.HasPrefix(session, constants.SessionPrefix) {
-			continue
-		}
-
-		// Parse session name to get role/rig/worker
-		role, rig, worker := parseSessionName(session)
-
-		// Capture pane content
-		content, err := t.CapturePaneAll(session)
+	for _, name := range sessions {
+		// Only process Gas Town sessions
+		identity, err := session.ParseSessionName(name)
 		if err != nil {
-			continue // Skip sessions we can't capture
+			continue
 		}
-
-		// Extract cost from content
-		cost := extractCost(content)
-
-		// Check if an agent is running
-		running := t.IsAgentRunning(session)
-
-		costs = append(costs, SessionCost{
-			Session: session,
-			Role:    role,
-			Rig:     rig,
-			Worker:  worker,
-			Cost:    cost,
-			Running: running,
-		})
-		total += cost
+		c := bySession[name]
+		c.Session = name
+		c.Role = string(identity.Role)
+		c.Rig = identity.Rig
+		c.Worker = identity.Name
+		c.Running = t.IsAgentRunning(name)
+		sessionCosts = append(sessionCosts, c)
+		total += c.Cost
 	}
 
 	// Sort by session name
-	sort.Slice(costs, func(i, j int) bool {
-		return costs[i].Session < costs[j].Session
+	sort.Slice(sessionCosts, func(i, j int) bool {
+		return sessionCosts[i].Session < sessionCosts[j].Session
 	})
 
 	if costsJSON {
 		return outputCostsJSON(CostsOutput{
-			Sessions: costs,
+			Sessions: sessionCosts,
 			Total:    total,
 		})
 	}
 
-	return outputCostsHuman(costs, total)
+	return outputCostsHuman(sessionCosts, total)
 }
 
-func runCostsFromLedger() error {
-	// Query session events from beads
-	entries, err := querySessionEvents()
-	if err != nil {
-		return fmt.Errorf("querying session events: %w", err)
-	}
-
-	if len(entries) == 0 {
-		fmt.Println(style.Dim.Render("No session events found. Costs are recorded when sessions end."))
-		return nil
+// currentSessionCosts sums, for each gt session in entries, the usage of
+// the runtime session that reported most recently.
+func currentSessionCosts(entries []costs.Entry) map[string]SessionCost {
+	latest := make(map[string]costs.Entry)
+	for _, e := range entries {
+		if e.Session == "" {
+			continue
+		}
+		if prev, ok := latest[e.Session]; !ok || !e.Time.Before(prev.Time) {
+			latest[e.Session] = e
+		}
 	}
 
-	// Filter entries by time period
-	var filtered []CostEntry
-	now := time.Now()
-
-	for _, entry := range entries {
-		if costsToday {
-			// Today: same day
-			if entry.EndedAt.Year() == now.Year() &&
-				entry.EndedAt.YearDay() == now.YearDay() {
-				filtered = append(filtered, entry)
-			}
-		} else if costsWeek {
-			// This week: within 7 days
-			weekAgo := now.AddDate(0, 0, -7)
-			if entry.EndedAt.After(weekAgo) {
-				filtered = append(filtered, entry)
-			}
-		} else {
-			// No time filter
-			filtered = append(filtered, entry)
+	result := make(map[string]SessionCost)
+	for _, e := range entries {
+		cur, ok := latest[e.Session]
+		if !ok || e.SessionID != cur.SessionID || e.Agent != cur.Agent {
+			continue
 		}
+		c := result[e.Session]
+		c.Agent, c.Model = cur.Agent, cur.Model
+		c.Tokens += e.Tokens()
+		c.Cost += e.CostUSD
+		result[e.Session] = c
 	}
+	return result
+}
 
-	// Calculate totals
-	var total float64
-	byRole := make(map[string]float64)
-	byRig := make(map[string]float64)
-
-	for _, entry := range filtered {
-		total += entry.CostUSD
-		byRole[entry.Role] += entry.CostUSD
-		if entry.Rig != "" {
-			byRig[entry.Rig] += entry.CostUSD
-		}
+func runCostsFromLedger(townRoot string) error {
+	since, period, err := costsPeriod(time.Now())
+	if err != nil {
+		return err
+	}
+	entries, err := costs.NewLedger(townRoot).Entries(since)
+	if err != nil {
+		return err
 	}
 
-	// Build output
-	output := CostsOutput{
-		Total: total,
+	if len(entries) == 0 {
+		fmt.Println(style.Dim.Render("No usage recorded. Costs are ingested from agent transcripts as sessions run."))
+		return nil
 	}
 
-	if costsByRole {
-		output.ByRole = byRole
+	output := summarizeCosts(entries)
+	output.Period = period
+	if !costsByRole {
+		output.ByRole = nil
 	}
-	if costsByRig {
-		output.ByRig = byRig
+	if !costsByRig {
+		output.ByRig = nil
 	}
-
-	// Set period label
-	if costsToday {
-		output.Period = "today"
-	} else if costsWeek {
-		output.Period = "this week"
+	if !costsByBead {
+		output.ByBead = nil
+	}
+	if !costsByConvoy {
+		output.ByConvoy = nil
 	}
 
 	if costsJSON {
 		return outputCostsJSON(output)
 	}
 
-	return outputLedgerHuman(output, filtered)
+	return outputLedgerHuman(output)
 }
 
-// SessionEvent represents a session.ended event from beads.
-type SessionEvent struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	EventKind string    `json:"event_kind"`
-	Actor     string    `json:"actor"`
-	Target    string    `json:"target"`
-	Payload   string    `json:"payload"`
-}
-
-// SessionPayload represents the JSON payload of a session event.
-type SessionPayload struct {
-	CostUSD   float64 `json:"cost_usd"`
-	SessionID string  `json:"session_id"`
-	Role      string  `json:"role"`
-	Rig       string  `json:"rig"`
-	Worker    string  `json:"worker"`
-	EndedAt   string  `json:"ended_at"`
-}
-
-// EventListItem represents an event from bd list (minimal fields).
-type EventListItem struct {
-	ID string `json:"id"`
-}
-
-// querySessionEvents queries beads for session.ended events and converts them to CostEntry.
-func querySessionEvents() ([]CostEntry, error) {
-	// Step 1: Get list of event IDs
-	listArgs := []string{
-		"list",
-		"--type=event",
-		"--all",
-		"--limit=0",
-		"--json",
-	}
-
-	listCmd := exec.Command("bd", listArgs...)
-	listOutput, err := listCmd.Output()
-	if err != nil {
-		// If bd fails (e.g., no beads database), return empty list
-		return nil, nil
-	}
-
-	var listItems []EventListItem
-	if err := json.Unmarshal(listOutput, &listItems); err != nil {
-		return nil, fmt.Errorf("parsing event list: %w", err)
-	}
-
-	if len(listItems) == 0 {
-		return nil, nil
+// summarizeCosts totals entries and breaks them down by role, rig, bead
+// and convoy. Entries without a rig, bead or convoy only count toward the
+// total.
+func summarizeCosts(entries []costs.Entry) CostsOutput {
+	output := CostsOutput{
+		ByRole:   make(map[string]float64),
+		ByRig:    make(map[string]float64),
+		ByBead:   make(map[string]float64),
+		ByConvoy: make(map[string]float64),
+		Entries:  len(entries),
+	}
+	for _, e := range entries {
+		output.Total += e.CostUSD
+		output.Tokens += e.Tokens()
+		output.ByRole[e.Role] += e.CostUSD
+		if e.Rig != "" {
+			output.ByRig[e.Rig] += e.CostUSD
+		}
+		if e.Bead != "" {
+			output.ByBead[e.Bead] += e.CostUSD
+		}
+		if e.Convoy != "" {
+			output.ByConvoy[e.Convoy] += e.CostUSD
+		}
 	}
+	return output
+}
 
-	// Step 2: Get full details for all events using bd show
-	// (bd list doesn't include event_kind, actor, payload)
-	showArgs := []string{"show", "--json"}
-	for _, item := range listItems {
-		showArgs = append(showArgs, item.ID)
+// costsPeriod returns the start of the period selected by --today, --week
+// or --since, and its label. A zero time means all of the ledger.
+func costsPeriod(now time.Time) (time.Time, string, error) {
+	switch {
+	case costsToday:
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), "today", nil
+	case costsWeek:
+		return now.AddDate(0, 0, -7), "this week", nil
+	case costsSince != "":
+		since, err := events.ParseTime(costsSince, now)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("--since: %w", err)
+		}
+		return since, "since " + since.Format("2006-01-02 15:04"), nil
 	}
+	return time.Time{}, "", nil
+}
 
-	showCmd := exec.Command("bd", showArgs...)
-	showOutput, err := showCmd.Output()
+// ingestCosts brings the town's cost ledger up to date. adjust, if set,
+// can amend each entry's attribution before it is recorded.
+func ingestCosts(townRoot string, adjust func(*costs.Attribution)) (int, error) {
+	prices, err := costs.LoadPrices(townRoot)
 	if err != nil {
-		return nil, fmt.Errorf("showing events: %w", err)
-	}
-
-	var events []SessionEvent
-	if err := json.Unmarshal(showOutput, &events); err != nil {
-		return nil, fmt.Errorf("parsing event details: %w", err)
+		return 0, err
+	}
+	var accountDirs map[string]string
+	if accounts, err := config.LoadAccountsConfig(constants.MayorAccountsPath(townRoot)); err == nil {
+		accountDirs = accounts.ConfigDirs()
+	}
+
+	attribute := costAttributor(townRoot)
+	in := &costs.Ingester{
+		Ledger:  costs.NewLedger(townRoot),
+		Sources: costs.DefaultSources(accountDirs),
+		Prices:  prices,
+		Attribute: func(u costs.Usage) (costs.Attribution, bool) {
+			attr, ok := attribute(u)
+			if ok && adjust != nil {
+				adjust(&attr)
+			}
+			return attr, ok
+		},
 	}
+	return in.Run()
+}
 
-	var entries []CostEntry
-	for _, event := range events {
-		// Filter for session.ended events only
-		if event.EventKind != "session.ended" {
-			continue
-		}
-
-		// Parse payload
-		var payload SessionPayload
-		if event.Payload != "" {
-			if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
-				continue // Skip malformed payloads
+// costAttributor attributes usage to the town agent whose directory the
+// runtime ran in, and to the bead that agent had hooked at the time.
+// Usage from outside the town is rejected.
+func costAttributor(townRoot string) func(costs.Usage) (costs.Attribution, bool) {
+	timeline := costs.LoadWorkTimeline(townRoot)
+	convoys := make(map[string]string)
+	var geminiDirs map[string]string
+
+	return func(u costs.Usage) (costs.Attribution, bool) {
+		dir := u.Cwd
+		if dir == "" && u.ProjectHash != "" {
+			if geminiDirs == nil {
+				geminiDirs = geminiProjectDirs(townRoot)
 			}
+			dir = geminiDirs[u.ProjectHash]
+		}
+		if dir == "" {
+			return costs.Attribution{}, false
+		}
+		if rel, err := filepath.Rel(townRoot, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return costs.Attribution{}, false
+		}
+		info := detectRole(dir, townRoot)
+		if info.Role == RoleUnknown {
+			return costs.Attribution{}, false
 		}
 
-		// Parse ended_at from payload, fall back to created_at
-		endedAt := event.CreatedAt
-		if payload.EndedAt != "" {
-			if parsed, err := time.Parse(time.RFC3339, payload.EndedAt); err == nil {
-				endedAt = parsed
+		identity := session.AgentIdentity{Role: session.Role(info.Role), Rig: info.Rig, Name: info.Polecat}
+		attr := costs.Attribution{
+			Session: identity.SessionName(),
+			Actor:   info.ActorString(),
+			Role:    string(info.Role),
+			Rig:     info.Rig,
+			Worker:  info.Polecat,
+		}
+		attr.Bead = timeline.BeadAt(attr.Actor, u.Time)
+		if attr.Bead != "" {
+			convoy, ok := convoys[attr.Bead]
+			if !ok {
+				convoy = isTrackedByConvoy(attr.Bead)
+				convoys[attr.Bead] = convoy
 			}
+			attr.Convoy = convoy
 		}
-
-		entries = append(entries, CostEntry{
-			SessionID: payload.SessionID,
-			Role:      payload.Role,
-			Rig:       payload.Rig,
-			Worker:    payload.Worker,
-			CostUSD:   payload.CostUSD,
-			EndedAt:   endedAt,
-			WorkItem:  event.Target,
-		})
+		return attr, true
 	}
-
-	return entries, nil
 }
 
-// parseSessionName extracts role, rig, and worker from a session name.
-// Session names follow the pattern: gt-<rig>-<worker> or gt-<global-agent>
-// Examples:
-//   - gt-mayor -> role=mayor, rig="", worker="mayor"
-//   - gt-deacon -> role=deacon, rig="", worker="deacon"
-//   - gt-gastown-toast -> role=polecat, rig=gastown, worker=toast
-//   - gt-gastown-witness -> role=witness, rig=gastown, worker=""
-//   - gt-gastown-refinery -> role=refinery, rig=gastown, worker=""
-//   - gt-gastown-crew-joe -> role=crew, rig=gastown, worker=joe
-func parseSessionName(session string) (role, rig, worker string) {
-	// Remove gt- prefix
-	name := strings.TrimPrefix(session, constants.SessionPrefix)
-
-	// Check for global agents
-	switch name {
-	case "mayor":
-		return constants.RoleMayor, "", "mayor"
-	case "deacon":
-		return constants.RoleDeacon, "", "deacon"
-	}
-
-	// Parse rig-based session: rig-worker or rig-crew-name
-	parts := strings.SplitN(name, "-", 3)
-	if len(parts) < 2 {
-		return "unknown", "", name
-	}
-
-	rig = parts[0]
-	worker = parts[1]
-
-	// Check for crew pattern: rig-crew-name
-	if worker == "crew" && len(parts) >= 3 {
-		return constants.RoleCrew, rig, parts[2]
-	}
-
-	// Check for special workers
-	switch worker {
-	case "witness":
-		return constants.RoleWitness, rig, ""
-	case "refinery":
-		return constants.RoleRefinery, rig, ""
-	}
-
-	// Default to polecat
-	return constants.RolePolecat, rig, worker
-}
-
-// extractCost finds the most recent cost value in pane content.
-// Claude Code displays cost in the format "$X.XX" in the status area.
-func extractCost(content string) float64 {
-	matches := costRegex.FindAllStringSubmatch(content, -1)
-	if len(matches) == 0 {
-		return 0.0
-	}
-
-	// Get the last (most recent) match
-	lastMatch := matches[len(matches)-1]
-	if len(lastMatch) < 2 {
-		return 0.0
+// geminiProjectDirs maps Gemini CLI project hashes (SHA-256 of the project
+// root) to the town's agent directories.
+func geminiProjectDirs(townRoot string) map[string]string {
+	patterns := []string{
+		"", "mayor", "mayor/rig", "deacon",
+		"*/witness", "*/witness/rig", "*/refinery", "*/refinery/rig",
+		"*/polecats/*", "*/crew/*",
+	}
+	dirs := make(map[string]string)
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(filepath.Join(townRoot, filepath.FromSlash(pattern)))
+		for _, dir := range matches {
+			sum := sha256.Sum256([]byte(dir))
+			dirs[hex.EncodeToString(sum[:])] = dir
+		}
 	}
-
-	var cost float64
-	_, _ = fmt.Sscanf(lastMatch[1], "%f", &cost)
-	return cost
+	return dirs
 }
 
 func outputCostsJSON(output CostsOutput) error {
@@ -434,21 +423,21 @@ func outputCostsJSON(output CostsOutput) error {
 	return enc.Encode(output)
 }
 
-func outputCostsHuman(costs []SessionCost, total float64) error {
-	if len(costs) == 0 {
+func outputCostsHuman(sessionCosts []SessionCost, total float64) error {
+	if len(sessionCosts) == 0 {
 		fmt.Println(style.Dim.Render("No Gas Town sessions found"))
 		return nil
 	}
 
-	fmt.Printf("\n%s Live Session Costs\n\n", style.Bold.Render("💰"))
+	fmt.Printf("\n%s Session Costs\n\n", style.Bold.Render("💰"))
 
 	// Print table header
-	fmt.Printf("%-25s %-10s %-15s %10s %8s\n",
-		"Session", "Role", "Rig/Worker", "Cost", "Status")
-	fmt.Println(strings.Repeat("─", 75))
+	fmt.Printf("%-25s %-10s %-15s %10s %10s %8s\n",
+		"Session", "Role", "Rig/Worker", "Tokens", "Cost", "Status")
+	fmt.Println(strings.Repeat("─", 86))
 
 	// Print each session
-	for _, c := range costs {
+	for _, c := range sessionCosts {
 		statusIcon := style.Success.Render("●")
 		if !c.Running {
 			statusIcon = style.Dim.Render("○")
@@ -463,22 +452,23 @@ func outputCostsHuman(costs []SessionCost, total float64) error {
 			}
 		}
 
-		fmt.Printf("%-25s %-10s %-15s %10s %8s\n",
+		fmt.Printf("%-25s %-10s %-15s %10s %10s %8s\n",
 			c.Session,
 			c.Role,
 			rigWorker,
+			formatTokens(c.Tokens),
 			fmt.Sprintf("$%.2f", c.Cost),
 			statusIcon)
 	}
 
 	// Print total
-	fmt.Println(strings.Repeat("─", 75))
+	fmt.Println(strings.Repeat("─", 86))
 	fmt.Printf("%s %s\n", style.Bold.Render("Total:"), fmt.Sprintf("$%.2f", total))
 
 	return nil
 }
 
-func outputLedgerHuman(output CostsOutput, entries []CostEntry) error {
+func outputLedgerHuman(output CostsOutput) error {
 	periodStr := ""
 	if output.Period != "" {
 		periodStr = fmt.Sprintf(" (%s)", output.Period)
@@ -487,136 +477,134 @@ func outputLedgerHuman(output CostsOutput, entries []CostEntry) error {
 	fmt.Printf("\n%s Cost Summary%s\n\n", style.Bold.Render("📊"), periodStr)
 
 	// Total
-	fmt.Printf("%s $%.2f\n", style.Bold.Render("Total:"), output.Total)
+	fmt.Printf("%s $%.2f  %s\n", style.Bold.Render("Total:"), output.Total,
+		style.Dim.Render(formatTokens(output.Tokens)+" tokens"))
 
 	// By role breakdown
-	if output.ByRole != nil && len(output.ByRole) > 0 {
+	if len(output.ByRole) > 0 {
 		fmt.Printf("\n%s\n", style.Bold.Render("By Role:"))
-		for role, cost := range output.ByRole {
+		for _, role := range sortedByCost(output.ByRole) {
 			icon := constants.RoleEmoji(role)
-			fmt.Printf("  %s %-12s $%.2f\n", icon, role, cost)
+			fmt.Printf("  %s %-12s $%.2f\n", icon, role, output.ByRole[role])
 		}
 	}
 
 	// By rig breakdown
-	if output.ByRig != nil && len(output.ByRig) > 0 {
+	if len(output.ByRig) > 0 {
 		fmt.Printf("\n%s\n", style.Bold.Render("By Rig:"))
-		for rig, cost := range output.ByRig {
-			fmt.Printf("  %-15s $%.2f\n", rig, cost)
+		for _, rig := range sortedByCost(output.ByRig) {
+			fmt.Printf("  %-15s $%.2f\n", rig, output.ByRig[rig])
+		}
+	}
+
+	// By bead breakdown
+	if len(output.ByBead) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("By Bead:"))
+		for _, bead := range sortedByCost(output.ByBead) {
+			fmt.Printf("  %-15s $%.2f\n", bead, output.ByBead[bead])
 		}
 	}
 
-	// Session count
-	fmt.Printf("\n%s %d sessions\n", style.Dim.Render("Entries:"), len(entries))
+	// By convoy breakdown
+	if len(output.ByConvoy) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("By Convoy:"))
+		for _, convoy := range sortedByCost(output.ByConvoy) {
+			fmt.Printf("  %-15s $%.2f\n", convoy, output.ByConvoy[convoy])
+		}
+	}
+
+	fmt.Printf("\n%s %d\n", style.Dim.Render("Entries:"), output.Entries)
 
 	return nil
 }
 
-// runCostsRecord captures the final cost from a session and records it as a bead event.
-// This is called by the Claude Code Stop hook.
-func runCostsRecord(cmd *cobra.Command, args []string) error {
-	// Get session from flag or try to detect from environment
-	session := recordSession
-	if session == "" {
-		session = os.Getenv("GT_SESSION")
-	}
-	if session == "" {
-		// Derive session name from GT_* environment variables
-		session = deriveSessionName()
-	}
-	if session == "" {
-		// Try to detect current tmux session (works when running inside tmux)
-		session = detectCurrentTmuxSession()
-	}
-	if session == "" {
-		return fmt.Errorf("--session flag required (or set GT_SESSION env var, or GT_RIG/GT_ROLE)")
+// sortedByCost returns the keys of m, most expensive first.
+func sortedByCost(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
 
-	t := tmux.NewTmux()
+// formatTokens renders a token count compactly (e.g., "1.2M", "34.5k").
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return strconv.FormatInt(n, 10)
+}
 
-	// Capture pane content
-	content, err := t.CapturePaneAll(session)
+func runCostsIngest(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
-		// Session may already be gone - that's OK, we'll record with zero cost
-		content = ""
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
-
-	// Extract cost
-	cost := extractCost(content)
-
-	// Parse session name
-	role, rig, worker := parseSessionName(session)
-
-	// Build agent path for actor field
-	agentPath := buildAgentPath(role, rig, worker)
-
-	// Build event title
-	title := fmt.Sprintf("Session ended: %s", session)
-	if recordWorkItem != "" {
-		title = fmt.Sprintf("Session: %s completed %s", session, recordWorkItem)
+	n, err := ingestCosts(townRoot, nil)
+	if err != nil {
+		return fmt.Errorf("ingesting costs: %w", err)
 	}
+	fmt.Printf("%s Ingested %d ledger entries\n", style.Success.Render("✓"), n)
+	return nil
+}
 
-	// Build payload JSON
-	payload := map[string]interface{}{
-		"cost_usd":   cost,
-		"session_id": session,
-		"role":       role,
-		"ended_at":   time.Now().Format(time.RFC3339),
+// runCostsRecord ingests new usage and reports the session's cost.
+// This is called by the Claude Code Stop hook.
+func runCostsRecord(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
-	if rig != "" {
-		payload["rig"] = rig
+
+	// Get session from flag or try to detect from environment
+	sessionName := recordSession
+	if sessionName == "" {
+		sessionName = os.Getenv("GT_SESSION")
 	}
-	if worker != "" {
-		payload["worker"] = worker
+	if sessionName == "" {
+		// Derive session name from GT_* environment variables
+		sessionName = deriveSessionName()
 	}
-	payloadJSON, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("marshaling payload: %w", err)
+	if sessionName == "" {
+		// Try to detect current tmux session (works when running inside tmux)
+		sessionName = detectCurrentTmuxSession()
 	}
 
-	// Build bd create command
-	bdArgs := []string{
-		"create",
-		"--type=event",
-		"--title=" + title,
-		"--event-category=session.ended",
-		"--event-actor=" + agentPath,
-		"--event-payload=" + string(payloadJSON),
-		"--silent",
+	// Charge unattributed usage of this session to the work item
+	var adjust func(*costs.Attribution)
+	if recordWorkItem != "" && sessionName != "" {
+		convoy := isTrackedByConvoy(recordWorkItem)
+		adjust = func(attr *costs.Attribution) {
+			if attr.Session == sessionName && attr.Bead == "" {
+				attr.Bead, attr.Convoy = recordWorkItem, convoy
+			}
+		}
 	}
-
-	// Add work item as event target if specified
-	if recordWorkItem != "" {
-		bdArgs = append(bdArgs, "--event-target="+recordWorkItem)
+	if _, err := ingestCosts(townRoot, adjust); err != nil {
+		return fmt.Errorf("ingesting costs: %w", err)
 	}
-
-	// NOTE: We intentionally don't use --rig flag here because it causes
-	// event fields (event_kind, actor, payload) to not be stored properly.
-	// The bd command will auto-detect the correct rig from cwd.
-	// TODO: File beads bug about --rig flag losing event fields.
-
-	// Execute bd create
-	bdCmd := exec.Command("bd", bdArgs...)
-	output, err := bdCmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("creating session event: %w\nOutput: %s", err, string(output))
+	if sessionName == "" {
+		return nil
 	}
 
-	eventID := strings.TrimSpace(string(output))
-
-	// Auto-close session events immediately after creation.
-	// These are informational audit events that don't need to stay open.
-	// The event data is preserved in the closed bead and remains queryable.
-	closeCmd := exec.Command("bd", "close", eventID, "--reason=auto-closed session event")
-	if closeErr := closeCmd.Run(); closeErr != nil {
-		// Non-fatal: event was created, just couldn't auto-close
-		// The witness patrol can clean these up if needed
-		fmt.Fprintf(os.Stderr, "warning: could not auto-close session event %s: %v\n", eventID, closeErr)
+	entries, err := costs.NewLedger(townRoot).Entries(time.Time{})
+	if err != nil {
+		return err
 	}
+	c := currentSessionCosts(entries)[sessionName]
 
 	// Output confirmation (silent if cost is zero and no work item)
-	if cost > 0 || recordWorkItem != "" {
-		fmt.Printf("%s Recorded $%.2f for %s (event: %s)", style.Success.Render("✓"), cost, session, eventID)
+	if c.Cost > 0 || recordWorkItem != "" {
+		fmt.Printf("%s Recorded $%.2f for %s", style.Success.Render("✓"), c.Cost, sessionName)
 		if recordWorkItem != "" {
 			fmt.Printf(" (work: %s)", recordWorkItem)
 		}
@@ -683,41 +671,3 @@ func detectCurrentTmuxSession() string {
 	}
 	return ""
 }
-
-// buildAgentPath builds the agent path from role, rig, and worker.
-// Examples: "mayor", "gastown/witness", "gastown/polecats/toast"
-func buildAgentPath(role, rig, worker string) string {
-	switch role {
-	case constants.RoleMayor, constants.RoleDeacon:
-		return role
-	case constants.RoleWitness, constants.RoleRefinery:
-		if rig != "" {
-			return rig + "/" + role
-		}
-		return role
-	case constants.RolePolecat:
-		if rig != "" && worker != "" {
-			return rig + "/polecats/" + worker
-		}
-		if rig != "" {
-			return rig + "/polecat"
-		}
-		return "polecat/" + worker
-	case constants.RoleCrew:
-		if rig != "" && worker != "" {
-			return rig + "/crew/" + worker
-		}
-		if rig != "" {
-			return rig + "/crew"
-		}
-		return "crew/" + worker
-	default:
-		if rig != "" && worker != "" {
-			return rig + "/" + worker
-		}
-		if rig != "" {
-			return rig
-		}
-		return worker
-	}
-}
@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/costs"
+	"github.com/steveyegge/gastown/internal/events"
 )
 
 func TestDeriveSessionName(t *testing.T) {
@@ -98,3 +103,100 @@ func TestDeriveSessionName(t *testing.T) {
 		})
 	}
 }
+
+func TestCostsPeriodSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	defer func() { costsSince = "" }()
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"36h", now.Add(-36 * time.Hour)},
+		{"3d", now.AddDate(0, 0, -3)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-03-09T08:00:00Z", time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		costsSince = tt.in
+		got, _, err := costsPeriod(now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("costsPeriod(--since %s) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	costsSince = "yesterday"
+	if _, _, err := costsPeriod(now); err == nil {
+		t.Error("costsPeriod(--since yesterday) succeeded, want error")
+	}
+}
+
+func TestSummarizeCosts(t *testing.T) {
+	entries := []costs.Entry{
+		{Attribution: costs.Attribution{Role: "polecat", Rig: "gastown", Bead: "gt-1", Convoy: "hq-cv-1"}, CostUSD: 1.5, InputTokens: 100},
+		{Attribution: costs.Attribution{Role: "polecat", Rig: "gastown", Bead: "gt-2"}, CostUSD: 0.5, OutputTokens: 10},
+		{Attribution: costs.Attribution{Role: "mayor"}, CostUSD: 2},
+	}
+	out := summarizeCosts(entries)
+	if out.Total != 4 || out.Tokens != 110 || out.Entries != 3 {
+		t.Errorf("totals = $%v, %d tokens, %d entries", out.Total, out.Tokens, out.Entries)
+	}
+	if out.ByRole["polecat"] != 2 || out.ByRole["mayor"] != 2 {
+		t.Errorf("ByRole = %v", out.ByRole)
+	}
+	if len(out.ByRig) != 1 || out.ByRig["gastown"] != 2 {
+		t.Errorf("ByRig = %v", out.ByRig)
+	}
+	if out.ByBead["gt-1"] != 1.5 || out.ByBead["gt-2"] != 0.5 || len(out.ByBead) != 2 {
+		t.Errorf("ByBead = %v", out.ByBead)
+	}
+	if len(out.ByConvoy) != 1 || out.ByConvoy["hq-cv-1"] != 1.5 {
+		t.Errorf("ByConvoy = %v", out.ByConvoy)
+	}
+	if got := sortedByCost(out.ByBead); got[0] != "gt-1" {
+		t.Errorf("sortedByCost = %v, want most expensive first", got)
+	}
+}
+
+func TestCurrentSessionCosts(t *testing.T) {
+	at := func(m int) time.Time { return time.Date(2026, 1, 1, 10, m, 0, 0, time.UTC) }
+	sess := costs.Attribution{Session: "gt-gastown-Toast"}
+	entries := []costs.Entry{
+		{Agent: "claude", SessionID: "old", Time: at(0), Attribution: sess, CostUSD: 5},
+		{Agent: "claude", SessionID: "new", Time: at(5), Attribution: sess, CostUSD: 1, InputTokens: 10},
+		{Agent: "claude", SessionID: "new", Time: at(6), Attribution: sess, CostUSD: 2, InputTokens: 20},
+	}
+	got := currentSessionCosts(entries)["gt-gastown-Toast"]
+	if got.Cost != 3 || got.Tokens != 30 {
+		t.Errorf("current session = $%v, %d tokens; want only the latest runtime session", got.Cost, got.Tokens)
+	}
+}
+
+func TestCostAttributor(t *testing.T) {
+	town := t.TempDir()
+	eventsLog := `{"ts":"2026-01-02T10:00:00Z","type":"sling","actor":"mayor","payload":{"bead":"gt-1","target":"gastown/polecats/Toast"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(town, events.EventsFile), []byte(eventsLog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	attribute := costAttributor(town)
+	when := time.Date(2026, 1, 2, 10, 30, 0, 0, time.UTC)
+
+	attr, ok := attribute(costs.Usage{Cwd: filepath.Join(town, "gastown", "polecats", "Toast"), Time: when})
+	if !ok {
+		t.Fatal("polecat usage rejected")
+	}
+	if attr.Role != "polecat" || attr.Rig != "gastown" || attr.Worker != "Toast" ||
+		attr.Actor != "gastown/polecats/Toast" || attr.Session != "gt-gastown-Toast" || attr.Bead != "gt-1" {
+		t.Errorf("attribution = %+v", attr)
+	}
+
+	attr, ok = attribute(costs.Usage{Cwd: filepath.Join(town, "gastown", "crew", "max"), Time: when})
+	if !ok || attr.Role != "crew" || attr.Worker != "max" || attr.Bead != "" {
+		t.Errorf("crew attribution = %+v, %v", attr, ok)
+	}
+
+	if _, ok := attribute(costs.Usage{Cwd: t.TempDir(), Time: when}); ok {
+		t.Error("usage outside the town was attributed")
+	}
+	if _, ok := attribute(costs.Usage{Time: when}); ok {
+		t.Error("usage without a directory was attributed")
+	}
+}
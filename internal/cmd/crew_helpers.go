@@ -14,6 +14,7 @@ import (
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -190,9 +191,13 @@ func isInTmuxSession(targetSession string) bool {
 	return currentSession == targetSession
 }
 
-// attachToTmuxSession attaches to a tmux session.
+// attachToTmuxSession attaches to a tmux session, or to a session on the
+// town's other session backend.
 // Should only be called from outside tmux.
 func attachToTmuxSession(sessionID string) error {
+	if b := tmux.DefaultBackend(); b != nil {
+		return b.AttachSession(sessionID)
+	}
 	tmuxPath, err := exec.LookPath("tmux")
 	if err != nil {
 		return fmt.Errorf("tmux not found: %w", err)
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os/exec"
@@ -8,13 +9,15 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/web"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 var (
-	dashboardPort int
-	dashboardOpen bool
+	dashboardPort    int
+	dashboardOpen    bool
+	dashboardRefresh time.Duration
 )
 
 var dashboardCmd = &cobra.Command{
@@ -27,26 +30,38 @@ The dashboard shows real-time convoy status with:
 - Convoy list with status indicators
 - Progress tracking for each convoy
 - Last activity indicator (green/yellow/red)
-- Auto-refresh every 30 seconds via htmx
+- Live updates as convoys, polecats, the merge queue and events change
+
+Town state is refreshed in the background (see --refresh) and when events
+are logged. Changes are pushed to browsers over Server-Sent Events at
+/api/town/stream; /api/town/snapshot returns the full state as JSON.
+
+Agent lifecycle metrics are served for Prometheus at /metrics.
 
 Example:
   gt dashboard              # Start on default port 8080
   gt dashboard --port 3000  # Start on port 3000
-  gt dashboard --open       # Start and open browser`,
+  gt dashboard --open       # Start and open browser
+  gt dashboard --refresh 30s # Poll beads and tmux less often`,
 	RunE: runDashboard,
 }
 
 func init() {
 	dashboardCmd.Flags().IntVar(&dashboardPort, "port", 8080, "HTTP port to listen on")
 	dashboardCmd.Flags().BoolVar(&dashboardOpen, "open", false, "Open browser automatically")
+	dashboardCmd.Flags().DurationVar(&dashboardRefresh, "refresh", 5*time.Second, "How often to refresh town state from beads and tmux")
 	rootCmd.AddCommand(dashboardCmd)
 }
 
 func runDashboard(cmd *cobra.Command, args []string) error {
 	// Verify we're in a workspace
-	if _, err := workspace.FindFromCwdOrError(); err != nil {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
 		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
+	if dashboardRefresh <= 0 {
+		return fmt.Errorf("--refresh must be positive")
+	}
 
 	// Create the live convoy fetcher
 	fetcher, err := web.NewLiveConvoyFetcher()
@@ -55,28 +70,31 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 	}
 	threadSafeFetcher := web.NewThreadSafeConvoyFetcher(fetcher)
 
+	// Keep a town snapshot refreshed in the background; pages and API
+	// reads are served from it, and changes are pushed to browsers.
+	townEngine := web.NewTownStateEngine(threadSafeFetcher)
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	go townEngine.Run(ctx, townRoot, dashboardRefresh)
+
 	// Create the handler
-	handler, err := web.NewConvoyHandler(threadSafeFetcher)
+	handler, err := web.NewConvoyHandler(townEngine)
 	if err != nil {
 		return fmt.Errorf("creating convoy handler: %w", err)
 	}
 
-        // 1. Create the engine using the fetcher
-        townEngine := web.NewTownStateEngine(threadSafeFetcher)
-
-        // 2. Pass the engine to the handler
-        snapshotHandler := web.NewTownSnapshotHandler(townEngine)
-	
-        frontendHandler, err := web.NewTownFrontendHandler()
+	frontendHandler, err := web.NewTownFrontendHandler()
 	if err != nil {
 		return fmt.Errorf("creating town frontend handler: %w", err)
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", handler)
-	mux.Handle("/api/town/snapshot", snapshotHandler)
+	mux.Handle("/api/town/snapshot", web.NewTownSnapshotHandler(townEngine))
+	mux.Handle("/api/town/stream", web.NewTownStreamHandler(townEngine))
 	mux.Handle("/town", http.RedirectHandler("/town/", http.StatusMovedPermanently))
 	mux.Handle("/town/", http.StripPrefix("/town/", frontendHandler))
+	mux.Handle("/metrics", telemetry.Handler(townRoot))
 
 	// Build the URL
 	url := fmt.Sprintf("http://localhost:%d", dashboardPort)
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
@@ -14,6 +15,8 @@ import (
 	"github.com/steveyegge/gastown/internal/polecat"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/telemetry"
+	"github.com/steveyegge/gastown/internal/witness"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -323,6 +326,14 @@ func runDone(cmd *cobra.Command, args []string) error {
 		Subject: fmt.Sprintf("POLECAT_DONE %s", polecatName),
 		Body:    strings.Join(bodyLines, "\n"),
 	}
+	_ = doneNotification.SetEnvelope(witness.ProtocolPolecatDone, witness.SchemaVersion, &witness.PolecatDonePayload{
+		PolecatName: polecatName,
+		Exit:        exitType,
+		IssueID:     issueID,
+		MRID:        mrID,
+		Branch:      branch,
+		Gate:        doneGate,
+	})
 
 	fmt.Printf("\nNotifying Witness...\n")
 	if err := townRouter.Send(doneNotification); err != nil {
@@ -351,6 +362,7 @@ func runDone(cmd *cobra.Command, args []string) error {
 	// Log done event (townlog and activity feed)
 	_ = LogDone(townRoot, sender, issueID)
 	_ = events.LogFeed(events.TypeDone, sender, events.DonePayload(issueID, branch))
+	recordDoneTelemetry(townRoot, rigName, issueID, exitType)
 
 	// Update agent bead state (ZFC: self-report completion)
 	updateAgentStateOnDone(cwd, townRoot, exitType, issueID)
@@ -367,6 +379,37 @@ func runDone(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// recordDoneTelemetry counts the exit and, when the bead's sling is in the
+// event log, records the polecat's work from sling to done in the sling's
+// trace.
+func recordDoneTelemetry(townRoot, rigName, issueID, exitType string) {
+	telemetry.Count(telemetry.MetricDone, telemetry.Labels{"rig": rigName, "exit": exitType})
+	if issueID == "" {
+		return
+	}
+	filter, err := events.ParseFilter(`type=`+events.TypeSling+` "payload.bead=`+issueID+`"`, time.Now())
+	if err != nil {
+		return
+	}
+	sling, found, err := events.FindLast(townRoot, filter)
+	if err != nil || !found {
+		return
+	}
+	slungAt, err := time.Parse(time.RFC3339, sling.Timestamp)
+	if err != nil {
+		return
+	}
+	convoyID, _ := sling.Payload["convoy"].(string)
+	now := time.Now()
+	telemetry.Observe(telemetry.MetricPolecatWork, now.Sub(slungAt).Seconds(), telemetry.Labels{"rig": rigName})
+	telemetry.RecordSpan("polecat work", slungAt, now, telemetry.Labels{
+		"bead":   issueID,
+		"convoy": convoyID,
+		"rig":    rigName,
+		"exit":   exitType,
+	}, convoyID, issueID)
+}
+
 // updateAgentStateOnDone updates the agent bead state when work is complete.
 // Maps exit type to agent state:
 //   - COMPLETED → "done"
@@ -3,9 +3,12 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/telemetry"
 )
 
 // TestDoneUsesResolveBeadsDir verifies that the done command correctly uses
@@ -245,3 +248,47 @@ func TestDoneCircularRedirectProtection(t *testing.T) {
 		t.Errorf("circular redirect should return original: got %s, want %s", resolved, beadsDir)
 	}
 }
+
+// TestRecordDoneTelemetry verifies that gt done times the work from the
+// bead's sling and counts the exit.
+func TestRecordDoneTelemetry(t *testing.T) {
+	townRoot := setupTestTownForConfig(t)
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	if err := os.Chdir(townRoot); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	payload := events.SlingPayload("gt-abc", "gastown/polecats/Toast")
+	payload["convoy"] = "hq-cv-1"
+	if err := events.LogTo(townRoot, events.TypeSling, "mayor", payload, events.VisibilityFeed); err != nil {
+		t.Fatal(err)
+	}
+
+	recordDoneTelemetry(townRoot, "gastown", "gt-abc", ExitCompleted)
+
+	snap, err := telemetry.Load(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var done, work *telemetry.Series
+	for _, s := range snap.Series {
+		switch s.Name {
+		case telemetry.MetricDone:
+			done = s
+		case telemetry.MetricPolecatWork:
+			work = s
+		}
+	}
+	if done == nil || done.Value != 1 || done.Labels["exit"] != ExitCompleted {
+		t.Errorf("done counter = %+v", done)
+	}
+	if work == nil || work.Count != 1 || work.Labels["rig"] != "gastown" {
+		t.Errorf("work histogram = %+v", work)
+	}
+
+	spans, _ := os.ReadFile(filepath.Join(townRoot, telemetry.Dir, "spans.jsonl"))
+	if want := telemetry.TraceID("hq-cv-1"); !strings.Contains(string(spans), want) {
+		t.Errorf("spans %s not in the convoy's trace %s", spans, want)
+	}
+}
@@ -18,15 +18,15 @@ import (
 const (
 	// SeverityCritical (P0) - System-threatening issues requiring immediate human attention.
 	// Examples: data corruption, security breach, complete system failure.
-	SeverityCritical = "CRITICAL"
+	SeverityCritical = events.SeverityCritical
 
 	// SeverityHigh (P1) - Important blockers that need human attention soon.
 	// Examples: unresolvable merge conflicts, critical blocking bugs, ambiguous requirements.
-	SeverityHigh = "HIGH"
+	SeverityHigh = events.SeverityHigh
 
 	// SeverityMedium (P2) - Standard escalations for human attention at convenience.
 	// Examples: unclear requirements, design decisions needed, non-blocking issues.
-	SeverityMedium = "MEDIUM"
+	SeverityMedium = events.SeverityMedium
 )
 
 var escalateCmd = &cobra.Command{
@@ -0,0 +1,479 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Events command flags
+var (
+	eventsType      string
+	eventsActor     string
+	eventsRig       string
+	eventsWhere     []string
+	eventsSince     string
+	eventsUntil     string
+	eventsBetween   string
+	eventsLimit     int
+	eventsFollow    bool
+	eventsCountBy   string
+	eventsHistogram string
+	eventsFormat    string
+	eventsJSON      bool
+)
+
+var eventsCmd = &cobra.Command{
+	Use:     "events [query]",
+	GroupID: GroupDiag,
+	Short:   "Query the town event log",
+	Long: `Query the raw event log (~/gt/.events.jsonl and its archived segments).
+
+Events can be filtered with flags or with a query, or both. A query is a
+list of terms that must all hold:
+
+  type=sling,done       field equals any of the values
+  actor!=mayor          field equals none of the values
+  actor=gastown/*       values are globs (* matches anything, ? one character)
+  payload.bead=gt-abc   payload fields compare as text
+  since=2h until=30m    time range (durations ago, dates or RFC3339 times)
+
+Fields are type, actor, rig, source, visibility, seq and payload.<key>.
+The same query syntax filters the gt feed TUI (press f).
+
+Results can be listed, followed, counted per field (--count-by) or
+bucketed over time (--histogram), as a table, JSON or CSV.
+
+Examples:
+  gt events                                  # Last 50 events
+  gt events --type sling --since 2h          # Slings in the last two hours
+  gt events --actor 'gastown/polecats/*' -f  # Follow a rig's polecats
+  gt events --where bead=gt-abc              # Everything about one bead
+  gt events 'type=done rig=gastown since=1d'
+  gt events --between 2026-01-05,2026-01-06 --count-by type,actor
+  gt events --type merged --histogram        # Merges per hour
+  gt events --since 7d --histogram 1d --format csv`,
+	RunE: runEvents,
+}
+
+func init() {
+	eventsCmd.Flags().StringVarP(&eventsType, "type", "t", "", "Filter by event type (comma-separated)")
+	eventsCmd.Flags().StringVarP(&eventsActor, "actor", "a", "", "Filter by actor glob (e.g., gastown/polecats/*)")
+	eventsCmd.Flags().StringVar(&eventsRig, "rig", "", "Filter by rig")
+	eventsCmd.Flags().StringArrayVar(&eventsWhere, "where", nil, "Filter by payload field (key=value, repeatable)")
+	eventsCmd.Flags().StringVar(&eventsSince, "since", "", "Show events since a time or duration ago (e.g., 2h, 7d, 2026-01-05)")
+	eventsCmd.Flags().StringVar(&eventsUntil, "until", "", "Show events until a time or duration ago")
+	eventsCmd.Flags().StringVar(&eventsBetween, "between", "", "Show events between two times (start,end)")
+	eventsCmd.Flags().IntVarP(&eventsLimit, "limit", "n", 50, "Maximum number of events to list (0 for all)")
+	eventsCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false, "Keep printing matching events as they arrive")
+	eventsCmd.Flags().StringVar(&eventsCountBy, "count-by", "", "Count events per value of these fields (comma-separated)")
+	eventsCmd.Flags().StringVar(&eventsHistogram, "histogram", "", "Count events per time bucket (default 1h)")
+	eventsCmd.Flags().Lookup("histogram").NoOptDefVal = "1h"
+	eventsCmd.Flags().StringVar(&eventsFormat, "format", "table", "Output format: table, json or csv")
+	eventsCmd.Flags().BoolVar(&eventsJSON, "json", false, "Output as JSON (same as --format json)")
+
+	rootCmd.AddCommand(eventsCmd)
+}
+
+func runEvents(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	format := eventsFormat
+	if eventsJSON {
+		format = "json"
+	}
+	switch format {
+	case "table", "json", "csv":
+	default:
+		return fmt.Errorf("invalid --format %q: use table, json or csv", format)
+	}
+	if eventsCountBy != "" && eventsHistogram != "" {
+		return fmt.Errorf("--count-by and --histogram cannot be combined")
+	}
+	if eventsFollow && (eventsCountBy != "" || eventsHistogram != "") {
+		return fmt.Errorf("--follow cannot be combined with --count-by or --histogram")
+	}
+
+	filter, err := buildEventsFilter(args, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Open the tail before reading history so nothing written in between
+	// is missed; events seen in both are skipped by sequence number.
+	var tailer *events.Tailer
+	if eventsFollow {
+		if tailer, err = events.NewTailer(townRoot); err != nil {
+			return err
+		}
+		defer tailer.Close()
+	}
+
+	var matched []events.Event
+	if err := events.Each(townRoot, func(e events.Event) {
+		if filter.Match(e.Record()) {
+			matched = append(matched, e)
+		}
+	}); err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+
+	switch {
+	case eventsCountBy != "":
+		fields := strings.Split(eventsCountBy, ",")
+		for _, f := range fields {
+			if !validEventField(f) {
+				return fmt.Errorf("invalid --count-by field %q", f)
+			}
+		}
+		return outputEventCounts(format, fields, countEvents(matched, fields))
+	case eventsHistogram != "":
+		bucket, err := events.ParseDuration(eventsHistogram)
+		if err != nil || bucket <= 0 {
+			return fmt.Errorf("invalid --histogram bucket %q", eventsHistogram)
+		}
+		return outputEventHistogram(format, bucket, eventHistogram(matched, bucket))
+	}
+
+	if eventsLimit > 0 && len(matched) > eventsLimit {
+		matched = matched[len(matched)-eventsLimit:]
+	}
+	if len(matched) == 0 && format == "table" && !eventsFollow {
+		fmt.Println("No matching events.")
+		return nil
+	}
+	out := newEventWriter(format, eventsFollow)
+	for _, e := range matched {
+		out.write(e)
+	}
+	if !eventsFollow {
+		return out.flush()
+	}
+
+	var lastSeq int64
+	if len(matched) > 0 {
+		lastSeq = matched[len(matched)-1].Seq
+	}
+	_ = out.flush()
+	for {
+		time.Sleep(250 * time.Millisecond)
+		lines, _ := tailer.Poll()
+		for _, line := range lines {
+			var e events.Event
+			if json.Unmarshal([]byte(line), &e) != nil {
+				continue
+			}
+			if e.Seq > 0 && e.Seq <= lastSeq {
+				continue
+			}
+			if filter.Match(e.Record()) {
+				out.write(e)
+			}
+		}
+		_ = out.flush()
+	}
+}
+
+// buildEventsFilter combines the query arguments and filter flags into one
+// event filter.
+func buildEventsFilter(args []string, now time.Time) (*events.Filter, error) {
+	terms := append([]string(nil), args...)
+	addTerm := func(field, value string) {
+		if strings.ContainsAny(value, " \t") {
+			value = `"` + value + `"`
+		}
+		terms = append(terms, field+"="+value)
+	}
+
+	if eventsType != "" {
+		addTerm("type", eventsType)
+	}
+	if eventsActor != "" {
+		addTerm("actor", eventsActor)
+	}
+	if eventsRig != "" {
+		addTerm("rig", eventsRig)
+	}
+	for _, w := range eventsWhere {
+		key, value, ok := strings.Cut(w, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --where %q: expected key=value", w)
+		}
+		addTerm("payload."+strings.TrimPrefix(key, "payload."), value)
+	}
+	if eventsBetween != "" {
+		start, end, ok := strings.Cut(eventsBetween, ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid --between %q: expected start,end", eventsBetween)
+		}
+		addTerm("since", start)
+		addTerm("until", end)
+	}
+	if eventsSince != "" {
+		addTerm("since", eventsSince)
+	}
+	if eventsUntil != "" {
+		addTerm("until", eventsUntil)
+	}
+
+	return events.ParseFilter(strings.Join(terms, " "), now)
+}
+
+// validEventField reports whether an event filter field exists.
+func validEventField(name string) bool {
+	_, ok := events.Record{}.Field(name)
+	return ok || (strings.HasPrefix(name, "payload.") && name != "payload.")
+}
+
+// EventCount is the number of events sharing a value for each counted field.
+type EventCount struct {
+	Values []string
+	Count  int
+}
+
+// countEvents groups events by the given fields, most frequent first.
+func countEvents(evts []events.Event, fields []string) []EventCount {
+	index := make(map[string]int)
+	var counts []EventCount
+	for _, e := range evts {
+		r := e.Record()
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			values[i], _ = r.Field(f)
+		}
+		key := strings.Join(values, "\x00")
+		if i, ok := index[key]; ok {
+			counts[i].Count++
+			continue
+		}
+		index[key] = len(counts)
+		counts = append(counts, EventCount{Values: values, Count: 1})
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return strings.Join(counts[i].Values, "\x00") < strings.Join(counts[j].Values, "\x00")
+	})
+	return counts
+}
+
+// EventBucket is the number of events in a time bucket.
+type EventBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// eventHistogram counts events per bucket, including empty buckets between
+// the first and the last event.
+func eventHistogram(evts []events.Event, size time.Duration) []EventBucket {
+	counts := make(map[time.Time]int)
+	var first, last time.Time
+	for _, e := range evts {
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		b := ts.Truncate(size)
+		counts[b]++
+		if first.IsZero() || b.Before(first) {
+			first = b
+		}
+		if b.After(last) {
+			last = b
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+	var buckets []EventBucket
+	for b := first; !b.After(last); b = b.Add(size) {
+		buckets = append(buckets, EventBucket{Start: b, Count: counts[b]})
+	}
+	return buckets
+}
+
+func outputEventCounts(format string, fields []string, counts []EventCount) error {
+	switch format {
+	case "json":
+		rows := make([]map[string]interface{}, 0, len(counts))
+		for _, c := range counts {
+			row := map[string]interface{}{"count": c.Count}
+			for i, f := range fields {
+				row[f] = c.Values[i]
+			}
+			rows = append(rows, row)
+		}
+		return printEventsJSON(rows)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write(append(append([]string(nil), fields...), "count"))
+		for _, c := range counts {
+			_ = w.Write(append(append([]string(nil), c.Values...), strconv.Itoa(c.Count)))
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("No matching events.")
+		return nil
+	}
+	widths := make([]int, len(fields))
+	for i, f := range fields {
+		widths[i] = len(f)
+		for _, c := range counts {
+			if len(c.Values[i]) > widths[i] {
+				widths[i] = len(c.Values[i])
+			}
+		}
+	}
+	var header []string
+	for i, f := range fields {
+		header = append(header, fmt.Sprintf("%-*s", widths[i], strings.ToUpper(f)))
+	}
+	fmt.Printf("%s  %s\n", style.Bold.Render(strings.Join(header, "  ")), style.Bold.Render("COUNT"))
+	for _, c := range counts {
+		var cols []string
+		for i, v := range c.Values {
+			if v == "" {
+				v = "-"
+			}
+			cols = append(cols, fmt.Sprintf("%-*s", widths[i], v))
+		}
+		fmt.Printf("%s  %5d\n", strings.Join(cols, "  "), c.Count)
+	}
+	return nil
+}
+
+func outputEventHistogram(format string, size time.Duration, buckets []EventBucket) error {
+	switch format {
+	case "json":
+		if buckets == nil {
+			buckets = []EventBucket{}
+		}
+		return printEventsJSON(buckets)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"start", "count"})
+		for _, b := range buckets {
+			_ = w.Write([]string{b.Start.Format(time.RFC3339), strconv.Itoa(b.Count)})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	if len(buckets) == 0 {
+		fmt.Println("No matching events.")
+		return nil
+	}
+	layout := "2006-01-02 15:04"
+	if size%(24*time.Hour) == 0 {
+		layout = "2006-01-02"
+	}
+	peak := 0
+	for _, b := range buckets {
+		if b.Count > peak {
+			peak = b.Count
+		}
+	}
+	const barWidth = 40
+	for _, b := range buckets {
+		bar := strings.Repeat("█", (b.Count*barWidth+peak-1)/peak)
+		fmt.Printf("%s %5d %s\n", style.Dim.Render(b.Start.Local().Format(layout)), b.Count, bar)
+	}
+	return nil
+}
+
+func printEventsJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// eventWriter prints listed events in one of the output formats. When
+// following, JSON is written one event per line instead of as an array.
+type eventWriter struct {
+	format    string
+	stream    bool
+	buffered  []events.Event
+	csv       *csv.Writer
+	headerOut bool
+}
+
+func newEventWriter(format string, stream bool) *eventWriter {
+	w := &eventWriter{format: format, stream: stream}
+	if format == "csv" {
+		w.csv = csv.NewWriter(os.Stdout)
+	}
+	return w
+}
+
+func (w *eventWriter) write(e events.Event) {
+	switch w.format {
+	case "json":
+		if !w.stream {
+			w.buffered = append(w.buffered, e)
+			return
+		}
+		data, err := json.Marshal(e)
+		if err == nil {
+			fmt.Println(string(data))
+		}
+	case "csv":
+		if !w.headerOut {
+			_ = w.csv.Write([]string{"seq", "ts", "type", "actor", "rig", "visibility", "payload"})
+			w.headerOut = true
+		}
+		payload := ""
+		if len(e.Payload) > 0 {
+			data, _ := json.Marshal(e.Payload)
+			payload = string(data)
+		}
+		_ = w.csv.Write([]string{strconv.FormatInt(e.Seq, 10), e.Timestamp, e.Type, e.Actor,
+			e.Record().Rig, e.Visibility, payload})
+	default:
+		ts := e.Timestamp
+		if t, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+			ts = t.Local().Format("2006-01-02 15:04:05")
+		}
+		seq := "-"
+		if e.Seq > 0 {
+			seq = strconv.FormatInt(e.Seq, 10)
+		}
+		fmt.Printf("%s %s %-16s %-28s %s\n",
+			style.Dim.Render(fmt.Sprintf("%6s", seq)),
+			style.Dim.Render(ts),
+			e.Type, e.Actor, formatFeedSummary(e))
+	}
+}
+
+func (w *eventWriter) flush() error {
+	switch w.format {
+	case "json":
+		if w.stream {
+			return nil
+		}
+		if w.buffered == nil {
+			w.buffered = []events.Event{}
+		}
+		return printEventsJSON(w.buffered)
+	case "csv":
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
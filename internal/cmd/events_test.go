@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+func TestBuildEventsFilter(t *testing.T) {
+	now := time.Date(2026, 1, 8, 12, 0, 0, 0, time.UTC)
+	eventsType, eventsActor, eventsWhere, eventsBetween = "sling,done", "gastown/*", []string{"bead=gt-abc"}, "2026-01-08T10:00:00Z,2026-01-08T11:00:00Z"
+	defer func() { eventsType, eventsActor, eventsWhere, eventsBetween = "", "", nil, "" }()
+
+	f, err := buildEventsFilter([]string{"visibility=feed"}, now)
+	if err != nil {
+		t.Fatalf("buildEventsFilter: %v", err)
+	}
+	match := events.Record{
+		Time:       time.Date(2026, 1, 8, 10, 30, 0, 0, time.UTC),
+		Type:       events.TypeSling,
+		Actor:      "gastown/polecats/Toast",
+		Visibility: events.VisibilityFeed,
+		Payload:    map[string]interface{}{"bead": "gt-abc"},
+	}
+	if !f.Match(match) {
+		t.Errorf("filter %q rejected %+v", f, match)
+	}
+	late := match
+	late.Time = now
+	if f.Match(late) {
+		t.Errorf("filter %q matched an event outside --between", f)
+	}
+	other := match
+	other.Payload = map[string]interface{}{"bead": "gt-xyz"}
+	if f.Match(other) {
+		t.Errorf("filter %q matched another bead", f)
+	}
+
+	eventsWhere = []string{"nokey"}
+	if _, err := buildEventsFilter(nil, now); err == nil {
+		t.Error("invalid --where accepted")
+	}
+}
+
+func TestCountEvents(t *testing.T) {
+	evts := []events.Event{
+		{Type: "sling", Actor: "mayor"},
+		{Type: "done", Actor: "gastown/polecats/Toast"},
+		{Type: "sling", Actor: "mayor"},
+		{Type: "done", Actor: "gastown/polecats/Nux"},
+	}
+	counts := countEvents(evts, []string{"type"})
+	if len(counts) != 2 || counts[0].Values[0] != "done" || counts[0].Count != 2 || counts[1].Count != 2 {
+		t.Errorf("counts by type = %+v", counts)
+	}
+	counts = countEvents(evts, []string{"type", "actor"})
+	if len(counts) != 3 || counts[0].Values[0] != "sling" || counts[0].Count != 2 {
+		t.Errorf("counts by type,actor = %+v", counts)
+	}
+
+	if !validEventField("payload.bead") || !validEventField("rig") || validEventField("colour") {
+		t.Error("validEventField misjudged a field")
+	}
+}
+
+func TestEventHistogram(t *testing.T) {
+	at := func(h, m int) events.Event {
+		return events.Event{Timestamp: time.Date(2026, 1, 8, h, m, 0, 0, time.UTC).Format(time.RFC3339)}
+	}
+	buckets := eventHistogram([]events.Event{at(9, 5), at(9, 55), at(12, 0)}, time.Hour)
+	want := []int{2, 0, 0, 1}
+	if len(buckets) != len(want) {
+		t.Fatalf("buckets = %+v, want %d", buckets, len(want))
+	}
+	for i, b := range buckets {
+		if b.Count != want[i] {
+			t.Errorf("bucket %s = %d, want %d", b.Start.Format("15:04"), b.Count, want[i])
+		}
+	}
+	if eventHistogram(nil, time.Hour) != nil {
+		t.Error("histogram of no events should be empty")
+	}
+}
@@ -52,6 +52,7 @@ By default, launches an interactive TUI dashboard with:
   - Convoy panel (middle): Shows in-progress and recently landed convoys
   - Event stream (bottom): Chronological feed you can scroll through
   - Vim-style navigation: j/k to scroll, tab to switch panels, 1/2/3 for panels, q to quit
+  - Filtering: f to filter the event stream with a query (see gt events), esc to clear
 
 The feed combines multiple event sources:
   - Beads activity: Issue creates, updates, completions (from bd activity)
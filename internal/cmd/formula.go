@@ -4,13 +4,19 @@ import (
 	"bufio"
 	"crypto/rand"
 	"encoding/base32"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 	"golang.org/x/text/cases"
@@ -19,12 +25,19 @@ import (
 
 // Formula command flags
 var (
-	formulaListJSON   bool
-	formulaShowJSON   bool
-	formulaRunPR      int
-	formulaRunRig     string
-	formulaRunDryRun  bool
-	formulaCreateType string
+	formulaListJSON     bool
+	formulaShowJSON     bool
+	formulaShowResolved bool
+	formulaRunPR        int
+	formulaRunRig       string
+	formulaRunDryRun    bool
+	formulaRunVars      []string
+	formulaRunAspects   []string
+	formulaCreateType   string
+
+	// formulaRunPollInterval is how often a workflow run checks whether
+	// slung steps have closed.
+	formulaRunPollInterval = 30 * time.Second
 )
 
 var formulaCmd = &cobra.Command{
@@ -84,9 +97,14 @@ Shows:
   - Steps with dependencies
   - Composition rules (extends, aspects)
 
+With --resolved, prints the formula as TOML after resolving its
+composition: extended and included steps merged by ID, expansions
+applied and aspect advice inserted around matching steps.
+
 Examples:
   gt formula show shiny
-  gt formula show rule-of-five --json`,
+  gt formula show rule-of-five --json
+  gt formula show shiny-secure --resolved`,
 	Args: cobra.ExactArgs(1),
 	RunE: runFormulaShow,
 }
@@ -98,19 +116,35 @@ var formulaRunCmd = &cobra.Command{
 
 This command:
   1. Looks up the formula by name
-  2. Pours it to create a molecule (or uses existing proto)
-  3. Dispatches the molecule to available workers
+  2. Creates a convoy bead tracking a bead per leg or step
+  3. Dispatches the work to available workers
+
+Convoy formulas sling every leg at once. Workflow and expansion formulas
+sling each step as soon as the steps it needs have closed, and wait until
+every step is closed (Ctrl-C stops waiting; slung steps keep running).
+Aspect formulas sling one polecat per aspect (or per --aspects entry),
+write each aspect's output using the formula's leg pattern, and start
+synthesis once every aspect has closed.
+
+Variables declared in the formula's [vars] are bound with --var; required
+variables must be given unless they have a default. Expansion formulas
+expand over --var target=<bead>, whose title and description fill
+{target.title} and {target.description}.
 
 For PR-based workflows, use --pr to specify the GitHub PR number.
 
 Options:
-  --pr=N      Run formula on GitHub PR #N
-  --rig=NAME  Target specific rig (default: current or gastown)
-  --dry-run   Show what would happen without executing
+  --pr=N          Run formula on GitHub PR #N
+  --rig=NAME      Target specific rig (default: current or gastown)
+  --var KEY=VAL   Bind a formula variable (repeatable)
+  --aspects=A,B   Run only these aspects of an aspect formula
+  --dry-run       Show what would happen without executing
 
 Examples:
-  gt formula run shiny                    # Run formula in current rig
-  gt formula run shiny --pr=123           # Run on PR #123
+  gt formula run shiny --var feature=login    # Run workflow in current rig
+  gt formula run rule-of-five --var target=gt-abc
+  gt formula run code-review --pr=123         # Run on PR #123
+  gt formula run security-audit --aspects auth,crypto
   gt formula run security-audit --rig=beads  # Run in specific rig
   gt formula run release --dry-run        # Preview execution`,
 	Args: cobra.ExactArgs(1),
@@ -144,11 +178,15 @@ func init() {
 
 	// Show flags
 	formulaShowCmd.Flags().BoolVar(&formulaShowJSON, "json", false, "Output as JSON")
+	formulaShowCmd.Flags().BoolVar(&formulaShowResolved, "resolved", false, "Print the formula with its composition resolved")
 
 	// Run flags
 	formulaRunCmd.Flags().IntVar(&formulaRunPR, "pr", 0, "GitHub PR number to run formula on")
 	formulaRunCmd.Flags().StringVar(&formulaRunRig, "rig", "", "Target rig (default: current or gastown)")
 	formulaRunCmd.Flags().BoolVar(&formulaRunDryRun, "dry-run", false, "Preview execution without running")
+	formulaRunCmd.Flags().StringArrayVar(&formulaRunVars, "var", nil, "Formula variable as key=value (repeatable)")
+	formulaRunCmd.Flags().StringSliceVar(&formulaRunAspects, "aspects", nil, "Aspects to run, comma-separated (aspect formulas; default: all)")
+	formulaRunCmd.Flags().DurationVar(&formulaRunPollInterval, "poll-interval", formulaRunPollInterval, "How often to check step progress (workflow/expansion)")
 
 	// Create flags
 	formulaCreateCmd.Flags().StringVar(&formulaCreateType, "type", "task", "Formula type: task, workflow, or patrol")
@@ -178,6 +216,10 @@ func runFormulaList(cmd *cobra.Command, args []string) error {
 // runFormulaShow delegates to bd formula show
 func runFormulaShow(cmd *cobra.Command, args []string) error {
 	formulaName := args[0]
+	if formulaShowResolved {
+		return showResolvedFormula(formulaName)
+	}
+
 	bdArgs := []string{"formula", "show", formulaName}
 	if formulaShowJSON {
 		bdArgs = append(bdArgs, "--json")
@@ -189,9 +231,31 @@ func runFormulaShow(cmd *cobra.Command, args []string) error {
 	return bdCmd.Run()
 }
 
-// runFormulaRun executes a formula by spawning a convoy of polecats.
-// For convoy-type formulas, it creates a convoy bead, creates leg beads,
-// and slings each leg to a separate polecat with leg-specific prompts.
+// showResolvedFormula prints a formula with extends, include and compose
+// flattened into plain steps.
+func showResolvedFormula(name string) error {
+	formulaPath, err := findFormulaFile(name)
+	if err != nil {
+		return fmt.Errorf("finding formula: %w", err)
+	}
+	f, err := formula.ParseFile(formulaPath, formulaSearchPaths()...)
+	if err != nil {
+		return fmt.Errorf("parsing formula: %w", err)
+	}
+
+	if formulaShowJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	}
+	return toml.NewEncoder(os.Stdout).Encode(f)
+}
+
+// runFormulaRun executes a formula.
+// Convoy formulas create a convoy bead and leg beads, and sling each leg to
+// a separate polecat with leg-specific prompts. Workflow and expansion
+// formulas bind their variables, create a bead per step with dependencies
+// mirroring the steps' needs, and sling each step as its needs close.
 func runFormulaRun(cmd *cobra.Command, args []string) error {
 	formulaName := args[0]
 
@@ -202,7 +266,7 @@ func runFormulaRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Parse the formula
-	f, err := parseFormulaFile(formulaPath)
+	f, err := formula.ParseFile(formulaPath, formulaSearchPaths()...)
 	if err != nil {
 		return fmt.Errorf("parsing formula: %w", err)
 	}
@@ -223,30 +287,89 @@ func runFormulaRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if len(formulaRunAspects) > 0 {
+		if f.Type != formula.TypeAspect {
+			return fmt.Errorf("--aspects only applies to aspect formulas (%s is %s)", formulaName, f.Type)
+		}
+		if f, err = selectAspects(f, formulaRunAspects); err != nil {
+			return err
+		}
+	}
+
+	// Bind variables for step-based formulas
+	var vars map[string]string
+	if f.Type == formula.TypeWorkflow || f.Type == formula.TypeExpansion {
+		values, err := parseFormulaVars(formulaRunVars)
+		if err != nil {
+			return err
+		}
+		if f.Type == formula.TypeExpansion {
+			describeExpansionTarget(values)
+		}
+		if vars, err = f.BindVars(values); err != nil {
+			return fmt.Errorf("formula %s: %w", formulaName, err)
+		}
+	}
+
 	// Handle dry-run mode
 	if formulaRunDryRun {
-		return dryRunFormula(f, formulaName, targetRig)
+		return dryRunFormula(f, vars, formulaName, targetRig)
+	}
+
+	switch f.Type {
+	case formula.TypeConvoy:
+		return executeConvoyFormula(f, formulaName, targetRig)
+	case formula.TypeWorkflow, formula.TypeExpansion:
+		return executeWorkflowFormula(f, vars, formulaName, targetRig)
+	case formula.TypeAspect:
+		return executeAspectFormula(f, formulaPath, formulaName, targetRig)
 	}
+	return fmt.Errorf("formula type '%s' cannot be run", f.Type)
+}
 
-	// Currently only convoy formulas are supported for execution
-	if f.Type != "convoy" {
-		fmt.Printf("%s Formula type '%s' not yet supported for execution.\n",
-			style.Dim.Render("Note:"), f.Type)
-		fmt.Printf("Currently only 'convoy' formulas can be run.\n")
-		fmt.Printf("\nTo run '%s' manually:\n", formulaName)
-		fmt.Printf("  1. View formula:   gt formula show %s\n", formulaName)
-		fmt.Printf("  2. Cook to proto:  bd cook %s\n", formulaName)
-		fmt.Printf("  3. Pour molecule:  bd pour %s\n", formulaName)
-		fmt.Printf("  4. Sling to rig:   gt sling <mol-id> %s\n", targetRig)
-		return nil
+// parseFormulaVars parses --var key=value flags.
+func parseFormulaVars(flags []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, kv := range flags {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected key=value", kv)
+		}
+		values[strings.TrimSpace(key)] = value
 	}
+	return values, nil
+}
 
-	// Execute convoy formula
-	return executeConvoyFormula(f, formulaName, targetRig)
+// describeExpansionTarget fills target.title and target.description from
+// the target bead when the target is a bead ID and they were not given.
+func describeExpansionTarget(values map[string]string) {
+	target := values[formula.TargetVar]
+	if target == "" {
+		return
+	}
+	_, hasTitle := values[formula.TargetVar+".title"]
+	_, hasDesc := values[formula.TargetVar+".description"]
+	if hasTitle && hasDesc {
+		return
+	}
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil || townRoot == "" {
+		return
+	}
+	issue, err := beads.New(townRoot).Show(target)
+	if err != nil {
+		return // not a bead; the target itself is used
+	}
+	if !hasTitle && issue.Title != "" {
+		values[formula.TargetVar+".title"] = issue.Title
+	}
+	if !hasDesc && issue.Description != "" {
+		values[formula.TargetVar+".description"] = issue.Description
+	}
 }
 
 // dryRunFormula shows what would happen without executing
-func dryRunFormula(f *formulaData, formulaName, targetRig string) error {
+func dryRunFormula(f *formula.Formula, vars map[string]string, formulaName, targetRig string) error {
 	fmt.Printf("%s Would execute formula:\n", style.Dim.Render("[dry-run]"))
 	fmt.Printf("  Formula: %s\n", style.Bold.Render(formulaName))
 	fmt.Printf("  Type:    %s\n", f.Type)
@@ -255,22 +378,70 @@ func dryRunFormula(f *formulaData, formulaName, targetRig string) error {
 		fmt.Printf("  PR:      #%d\n", formulaRunPR)
 	}
 
-	if f.Type == "convoy" && len(f.Legs) > 0 {
-		fmt.Printf("\n  Legs (%d parallel):\n", len(f.Legs))
-		for _, leg := range f.Legs {
-			fmt.Printf("    • %s: %s\n", leg.ID, leg.Title)
+	switch f.Type {
+	case formula.TypeConvoy:
+		if len(f.Legs) > 0 {
+			fmt.Printf("\n  Legs (%d parallel):\n", len(f.Legs))
+			for _, leg := range f.Legs {
+				fmt.Printf("    • %s: %s\n", leg.ID, leg.Title)
+			}
 		}
 		if f.Synthesis != nil {
 			fmt.Printf("\n  Synthesis:\n")
 			fmt.Printf("    • %s\n", f.Synthesis.Title)
 		}
+
+	case formula.TypeAspect:
+		fmt.Printf("\n  Aspects (%d parallel):\n", len(f.Aspects))
+		for _, aspect := range f.Aspects {
+			title, _, _ := workflowStep(f, aspect.ID)
+			fmt.Printf("    • %s: %s\n", aspect.ID, title)
+		}
+		if f.Output != nil && f.Output.LegPattern != "" {
+			fmt.Printf("\n  Output: %s\n", aspectOutputPath(f, "<review-id>", "<aspect>"))
+		}
+		if f.Synthesis != nil {
+			fmt.Printf("\n  Synthesis (when all aspects close):\n")
+			fmt.Printf("    • %s\n", f.Synthesis.Title)
+		}
+
+	case formula.TypeWorkflow, formula.TypeExpansion:
+		if len(vars) > 0 {
+			names := make([]string, 0, len(vars))
+			for name := range vars {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Printf("\n  Variables:\n")
+			for _, name := range names {
+				fmt.Printf("    %s = %s\n", name, vars[name])
+			}
+		}
+
+		inst, err := f.Instantiate(vars)
+		if err != nil {
+			return err
+		}
+		order, err := inst.TopologicalSort()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n  Steps (%d, in dependency order):\n", len(order))
+		for _, id := range order {
+			title, _, needs := workflowStep(inst, id)
+			line := fmt.Sprintf("    • %s: %s", id, title)
+			if len(needs) > 0 {
+				line += style.Dim.Render(fmt.Sprintf(" (needs %s)", strings.Join(needs, ", ")))
+			}
+			fmt.Println(line)
+		}
 	}
 
 	return nil
 }
 
 // executeConvoyFormula spawns a convoy of polecats to execute a convoy formula
-func executeConvoyFormula(f *formulaData, formulaName, targetRig string) error {
+func executeConvoyFormula(f *formula.Formula, formulaName, targetRig string) error {
 	fmt.Printf("%s Executing convoy formula: %s\n\n",
 		style.Bold.Render("🚚"), formulaName)
 
@@ -449,52 +620,11 @@ func executeConvoyFormula(f *formulaData, formulaName, targetRig string) error {
 	return nil
 }
 
-// formulaData holds parsed formula information
-type formulaData struct {
-	Name        string
-	Description string
-	Type        string
-	Legs        []formulaLeg
-	Synthesis   *formulaSynthesis
-	Prompts     map[string]string
-}
-
-type formulaLeg struct {
-	ID          string
-	Title       string
-	Focus       string
-	Description string
-}
-
-type formulaSynthesis struct {
-	Title       string
-	Description string
-	DependsOn   []string
-}
-
 // findFormulaFile searches for a formula file by name
 func findFormulaFile(name string) (string, error) {
-	// Search paths in order
-	searchPaths := []string{}
-
-	// 1. Project .beads/formulas/
-	if cwd, err := os.Getwd(); err == nil {
-		searchPaths = append(searchPaths, filepath.Join(cwd, ".beads", "formulas"))
-	}
-
-	// 2. Town .beads/formulas/
-	if townRoot, err := workspace.FindFromCwd(); err == nil {
-		searchPaths = append(searchPaths, filepath.Join(townRoot, ".beads", "formulas"))
-	}
-
-	// 3. User ~/.beads/formulas/
-	if home, err := os.UserHomeDir(); err == nil {
-		searchPaths = append(searchPaths, filepath.Join(home, ".beads", "formulas"))
-	}
-
 	// Try each path with common extensions
 	extensions := []string{".formula.toml", ".formula.json"}
-	for _, basePath := range searchPaths {
+	for _, basePath := range formulaSearchPaths() {
 		for _, ext := range extensions {
 			path := filepath.Join(basePath, name+ext)
 			if _, err := os.Stat(path); err == nil {
@@ -506,178 +636,28 @@ func findFormulaFile(name string) (string, error) {
 	return "", fmt.Errorf("formula '%s' not found in search paths", name)
 }
 
-// parseFormulaFile parses a formula file into formulaData
-func parseFormulaFile(path string) (*formulaData, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	// Use simple TOML parsing for the fields we need
-	// (avoids importing the full formula package which might cause cycles)
-	f := &formulaData{
-		Prompts: make(map[string]string),
-	}
-
-	content := string(data)
-
-	// Parse formula name
-	if match := extractTOMLValue(content, "formula"); match != "" {
-		f.Name = match
-	}
-
-	// Parse description
-	if match := extractTOMLMultiline(content, "description"); match != "" {
-		f.Description = match
-	}
-
-	// Parse type
-	if match := extractTOMLValue(content, "type"); match != "" {
-		f.Type = match
-	}
-
-	// Parse legs (convoy formulas)
-	f.Legs = extractLegs(content)
-
-	// Parse synthesis
-	f.Synthesis = extractSynthesis(content)
-
-	// Parse prompts
-	f.Prompts = extractPrompts(content)
-
-	return f, nil
-}
-
-// extractTOMLValue extracts a simple quoted value from TOML
-func extractTOMLValue(content, key string) string {
-	// Match: key = "value" or key = 'value'
-	for _, line := range strings.Split(content, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, key+" =") || strings.HasPrefix(line, key+"=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				val := strings.TrimSpace(parts[1])
-				// Remove quotes
-				if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') {
-					return val[1 : len(val)-1]
-				}
-				return val
-			}
-		}
-	}
-	return ""
-}
-
-// extractTOMLMultiline extracts a multiline string (""" ... """)
-func extractTOMLMultiline(content, key string) string {
-	// Look for key = """
-	keyPattern := key + ` = """`
-	idx := strings.Index(content, keyPattern)
-	if idx == -1 {
-		// Try single-line
-		return extractTOMLValue(content, key)
-	}
-
-	start := idx + len(keyPattern)
-	end := strings.Index(content[start:], `"""`)
-	if end == -1 {
-		return ""
-	}
-
-	return strings.TrimSpace(content[start : start+end])
-}
-
-// extractLegs parses [[legs]] sections from TOML
-func extractLegs(content string) []formulaLeg {
-	var legs []formulaLeg
-
-	// Split by [[legs]]
-	sections := strings.Split(content, "[[legs]]")
-	for i, section := range sections {
-		if i == 0 {
-			continue // Skip content before first [[legs]]
-		}
-
-		// Find where this section ends (next [[ or EOF)
-		endIdx := strings.Index(section, "[[")
-		if endIdx == -1 {
-			endIdx = len(section)
-		}
-		section = section[:endIdx]
-
-		leg := formulaLeg{
-			ID:          extractTOMLValue(section, "id"),
-			Title:       extractTOMLValue(section, "title"),
-			Focus:       extractTOMLValue(section, "focus"),
-			Description: extractTOMLMultiline(section, "description"),
-		}
-
-		if leg.ID != "" {
-			legs = append(legs, leg)
-		}
-	}
-
-	return legs
-}
-
-// extractSynthesis parses [synthesis] section from TOML
-func extractSynthesis(content string) *formulaSynthesis {
-	idx := strings.Index(content, "[synthesis]")
-	if idx == -1 {
-		return nil
-	}
-
-	section := content[idx:]
-	// Find where section ends
-	if endIdx := strings.Index(section[1:], "\n["); endIdx != -1 {
-		section = section[:endIdx+1]
-	}
-
-	syn := &formulaSynthesis{
-		Title:       extractTOMLValue(section, "title"),
-		Description: extractTOMLMultiline(section, "description"),
-	}
-
-	// Parse depends_on array
-	if depsLine := extractTOMLValue(section, "depends_on"); depsLine != "" {
-		// Simple array parsing: ["a", "b", "c"]
-		depsLine = strings.Trim(depsLine, "[]")
-		for _, dep := range strings.Split(depsLine, ",") {
-			dep = strings.Trim(strings.TrimSpace(dep), `"'`)
-			if dep != "" {
-				syn.DependsOn = append(syn.DependsOn, dep)
-			}
-		}
-	}
-
-	if syn.Title == "" && syn.Description == "" {
-		return nil
-	}
-
-	return syn
-}
-
-// extractPrompts parses [prompts] section from TOML
-func extractPrompts(content string) map[string]string {
-	prompts := make(map[string]string)
+// formulaSearchPaths returns the directories formulas are looked up in,
+// in order. The formulas a formula extends or includes are resolved
+// against the same directories.
+func formulaSearchPaths() []string {
+	searchPaths := []string{}
 
-	idx := strings.Index(content, "[prompts]")
-	if idx == -1 {
-		return prompts
+	// 1. Project .beads/formulas/
+	if cwd, err := os.Getwd(); err == nil {
+		searchPaths = append(searchPaths, filepath.Join(cwd, ".beads", "formulas"))
 	}
 
-	section := content[idx:]
-	// Find where section ends
-	if endIdx := strings.Index(section[1:], "\n["); endIdx != -1 {
-		section = section[:endIdx+1]
+	// 2. Town .beads/formulas/
+	if townRoot, err := workspace.FindFromCwd(); err == nil {
+		searchPaths = append(searchPaths, filepath.Join(townRoot, ".beads", "formulas"))
 	}
 
-	// Extract base prompt
-	if base := extractTOMLMultiline(section, "base"); base != "" {
-		prompts["base"] = base
+	// 3. User ~/.beads/formulas/
+	if home, err := os.UserHomeDir(); err == nil {
+		searchPaths = append(searchPaths, filepath.Join(home, ".beads", "formulas"))
 	}
 
-	return prompts
+	return searchPaths
 }
 
 // generateFormulaShortID generates a short random ID (5 lowercase chars)
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// executeAspectFormula fans an aspect formula out to one polecat per
+// aspect. The run is an ordinary convoy: its description carries the
+// formula and review ID that the synthesis machinery reads back, and each
+// aspect writes its output where the formula's leg pattern puts it. Once
+// every aspect closes, synthesis starts if the formula defines one.
+func executeAspectFormula(f *formula.Formula, formulaPath, formulaName, targetRig string) error {
+	if len(f.Aspects) == 0 {
+		return fmt.Errorf("%s only has advice; apply it with [compose] aspects in a workflow", formulaName)
+	}
+
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil {
+		return fmt.Errorf("finding town root: %w", err)
+	}
+	backend := &bdWorkflowBackend{
+		townBeads: filepath.Join(townRoot, ".beads"),
+		rig:       targetRig,
+	}
+
+	fmt.Printf("%s Executing aspect formula: %s\n\n", style.Bold.Render("🔍"), formulaName)
+
+	run, err := createAspectRun(f, backend, formulaPath, formulaName, targetRig)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("\n%s Dispatching aspects to polecats...\n\n", style.Bold.Render("→"))
+	if err := driveWorkflow(ctx, run, backend, formulaRunPollInterval); err != nil {
+		if ctx.Err() != nil {
+			fmt.Printf("\n%s Stopped waiting; slung aspects keep running.\n", style.Dim.Render("○"))
+			fmt.Printf("  Track progress:  gt convoy status %s\n", run.convoyID)
+			if f.Synthesis != nil {
+				fmt.Printf("  Then synthesize: gt synthesis start %s\n", run.convoyID)
+			}
+			return nil
+		}
+		return err
+	}
+
+	fmt.Printf("\n%s All %d aspects closed\n", style.Bold.Render("✓"), len(run.order))
+	if f.Synthesis == nil {
+		fmt.Printf("  Convoy: %s\n", run.convoyID)
+		return nil
+	}
+	if err := TriggerSynthesisIfReady(run.convoyID, targetRig); err != nil {
+		return fmt.Errorf("starting synthesis: %w", err)
+	}
+	fmt.Printf("  Monitor: gt convoy status %s\n", run.convoyID)
+	return nil
+}
+
+// createAspectRun creates a convoy bead tracking one bead per aspect of f.
+// The convoy description records the formula and review ID so that
+// getConvoyMeta can find the aspects' outputs for synthesis.
+func createAspectRun(f *formula.Formula, b workflowBackend, formulaPath, formulaName, targetRig string) (*workflowRun, error) {
+	reviewID := generateFormulaShortID()
+	run := &workflowRun{
+		formula:  f,
+		convoyID: "hq-cv-" + reviewID,
+		beads:    make(map[string]string),
+	}
+
+	convoyTitle := fmt.Sprintf("%s: %s", formulaName, f.Description)
+	if len(convoyTitle) > 80 {
+		convoyTitle = convoyTitle[:77] + "..."
+	}
+	description := fmt.Sprintf("Formula aspects: %s\n\nAspects: %d\nRig: %s\nformula: %s\nformula_path: %s\nreview_id: %s",
+		formulaName, len(f.Aspects), targetRig, formulaName, formulaPath, reviewID)
+	if formulaRunPR > 0 {
+		description += fmt.Sprintf("\nPR: #%d", formulaRunPR)
+	}
+	if err := b.CreateBead(run.convoyID, "convoy", convoyTitle, description); err != nil {
+		return nil, fmt.Errorf("creating convoy bead: %w", err)
+	}
+	fmt.Printf("%s Created convoy: %s\n", style.Bold.Render("✓"), run.convoyID)
+
+	for _, aspect := range f.Aspects {
+		beadID := fmt.Sprintf("hq-asp-%s", generateFormulaShortID())
+		title, desc, _ := workflowStep(f, aspect.ID)
+		if path := aspectOutputPath(f, reviewID, aspect.ID); path != "" {
+			desc += fmt.Sprintf("\n\n---\nWrite output to: %s", path)
+		}
+		if err := b.CreateBead(beadID, "task", title, desc); err != nil {
+			return nil, fmt.Errorf("creating bead for aspect %s: %w", aspect.ID, err)
+		}
+		if err := b.Track(run.convoyID, beadID); err != nil {
+			fmt.Printf("%s Failed to track aspect %s: %v\n",
+				style.Dim.Render("Warning:"), aspect.ID, err)
+		}
+		run.beads[aspect.ID] = beadID
+		run.order = append(run.order, aspect.ID)
+		fmt.Printf("  %s Created aspect: %s (%s)\n", style.Dim.Render("○"), aspect.ID, beadID)
+	}
+
+	return run, nil
+}
+
+// selectAspects returns a copy of an aspect formula restricted to the
+// aspects named in ids, in formula order. An empty ids selects every aspect.
+func selectAspects(f *formula.Formula, ids []string) (*formula.Formula, error) {
+	if len(ids) == 0 {
+		return f, nil
+	}
+	want := make(map[string]bool)
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if f.GetAspect(id) == nil {
+			return nil, fmt.Errorf("formula %s has no aspect %q (have: %s)",
+				f.Name, id, strings.Join(f.GetAllIDs(), ", "))
+		}
+		want[id] = true
+	}
+
+	selected := *f
+	selected.Aspects = nil
+	for _, aspect := range f.Aspects {
+		if want[aspect.ID] {
+			selected.Aspects = append(selected.Aspects, aspect)
+		}
+	}
+	return &selected, nil
+}
+
+// aspectOutputPath returns where an aspect writes its output, following the
+// formula's leg pattern, or "" if the formula configures no output.
+func aspectOutputPath(f *formula.Formula, reviewID, aspectID string) string {
+	if f.Output == nil || f.Output.LegPattern == "" {
+		return ""
+	}
+	return expandOutputPath(f.Output.Directory, f.Output.LegPattern, reviewID, aspectID)
+}
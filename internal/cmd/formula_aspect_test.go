@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+const auditAspects = `
+formula = "audit"
+type = "aspect"
+
+[[aspects]]
+id = "auth"
+title = "Authentication"
+focus = "Session handling"
+
+[[aspects]]
+id = "crypto"
+title = "Cryptography"
+
+[[aspects]]
+id = "deps"
+title = "Dependencies"
+
+[output]
+directory = "AUDIT_DIR/{{review_id}}"
+leg_pattern = "{{leg.id}}.md"
+
+[synthesis]
+title = "Audit report"
+`
+
+func parseAuditFormula(t *testing.T, dir string) *formula.Formula {
+	t.Helper()
+	f, err := formula.Parse([]byte(strings.ReplaceAll(auditAspects, "AUDIT_DIR", dir)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return f
+}
+
+func TestAspectRun_FansOutSelectedAspects(t *testing.T) {
+	dir := t.TempDir()
+	f, err := selectAspects(parseAuditFormula(t, dir), []string{"crypto", "auth"})
+	if err != nil {
+		t.Fatalf("selectAspects: %v", err)
+	}
+	b := newFakeWorkflowBackend()
+
+	run, err := createAspectRun(f, b, "/formulas/audit.formula.toml", "audit", "gastown")
+	if err != nil {
+		t.Fatalf("createAspectRun: %v", err)
+	}
+	if strings.Join(run.order, ",") != "auth,crypto" {
+		t.Errorf("order = %v, want formula order", run.order)
+	}
+	if len(b.tracked) != 2 {
+		t.Errorf("tracked = %v", b.tracked)
+	}
+
+	// The convoy carries what getConvoyMeta needs to find the outputs.
+	reviewID := strings.TrimPrefix(run.convoyID, "hq-cv-")
+	desc := b.descriptions[run.convoyID]
+	for _, want := range []string{"formula: audit", "formula_path: /formulas/audit.formula.toml", "review_id: " + reviewID} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("convoy description missing %q:\n%s", want, desc)
+		}
+	}
+	authDesc := b.descriptions[run.beads["auth"]]
+	wantPath := filepath.Join(dir, reviewID, "auth.md")
+	if !strings.Contains(authDesc, "Focus: Session handling") || !strings.Contains(authDesc, wantPath) {
+		t.Errorf("auth description = %q, want focus and output %s", authDesc, wantPath)
+	}
+
+	// Aspects are independent, so all are slung in the first round.
+	if err := driveWorkflow(context.Background(), run, b, time.Millisecond); err != nil {
+		t.Fatalf("driveWorkflow: %v", err)
+	}
+	if len(b.slingLogs[0]) != 2 {
+		t.Errorf("first sling round = %v, want both aspects", b.slingLogs[0])
+	}
+}
+
+func TestSelectAspects_Unknown(t *testing.T) {
+	_, err := selectAspects(parseAuditFormula(t, t.TempDir()), []string{"auth", "tls"})
+	if err == nil || !strings.Contains(err.Error(), `no aspect "tls"`) {
+		t.Errorf("selectAspects error = %v", err)
+	}
+}
+
+func TestCollectLegOutputs_Aspects(t *testing.T) {
+	dir := t.TempDir()
+	f := parseAuditFormula(t, dir)
+	writeAspectOutput := func(id, content string) {
+		path := filepath.Join(dir, "r1", id+".md")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeAspectOutput("auth", "no findings")
+	writeAspectOutput("crypto", "weak cipher")
+
+	outputs, _, err := collectLegOutputs(&ConvoyMeta{ID: "hq-cv-r1", ReviewID: "r1"}, f)
+	if err != nil {
+		t.Fatalf("collectLegOutputs: %v", err)
+	}
+	if len(outputs) != 2 || outputs[1].LegID != "crypto" || outputs[1].Content != "weak cipher" {
+		t.Errorf("outputs = %+v", outputs)
+	}
+}
@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// workflowBackend is the bead and dispatch operations a workflow run needs.
+// bdWorkflowBackend implements it with bd and gt sling; tests use a fake.
+type workflowBackend interface {
+	// CreateBead creates a bead with the given ID.
+	CreateBead(id, beadType, title, description string) error
+	// Track records that the convoy tracks a step bead.
+	Track(convoyID, beadID string) error
+	// AddDependency makes beadID depend on dependsOn.
+	AddDependency(beadID, dependsOn string) error
+	// Sling dispatches a step bead to a worker. force re-dispatches a
+	// step whose previous attempt failed or timed out.
+	Sling(beadID, title, description string, force bool) error
+	// Reopen reopens the bead of a step whose attempt closed it as
+	// failed, before the step is retried.
+	Reopen(beadID string) error
+	// Skip closes the bead of a step whose when condition is false, so
+	// beads that depend on it are unblocked.
+	Skip(beadID, reason string) error
+	// StepResult reports whether a bead has been closed, and its close
+	// reason, which is the step's output for when conditions.
+	StepResult(beadID string) (closed bool, output string, err error)
+}
+
+// workflowRun is an instantiated workflow or expansion formula whose steps
+// have beads.
+type workflowRun struct {
+	formula  *formula.Formula
+	vars     map[string]string // bound vars, for when conditions
+	convoyID string
+	order    []string          // step IDs in dependency order
+	beads    map[string]string // step ID -> bead ID
+}
+
+// executeWorkflowFormula creates beads for the steps of a workflow or
+// expansion formula and drives the run: each step is slung once all of its
+// needs have closed, until every step is closed.
+func executeWorkflowFormula(f *formula.Formula, vars map[string]string, formulaName, targetRig string) error {
+	inst, err := f.Instantiate(vars)
+	if err != nil {
+		return err
+	}
+
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil {
+		return fmt.Errorf("finding town root: %w", err)
+	}
+	backend := &bdWorkflowBackend{
+		townBeads: filepath.Join(townRoot, ".beads"),
+		rig:       targetRig,
+	}
+
+	fmt.Printf("%s Executing %s formula: %s\n\n",
+		style.Bold.Render("⚙"), inst.Type, formulaName)
+
+	run, err := createWorkflowRun(inst, backend, formulaName, targetRig)
+	if err != nil {
+		return err
+	}
+	run.vars = vars
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("\n%s Dispatching steps as their needs close...\n\n", style.Bold.Render("→"))
+	if err := driveWorkflow(ctx, run, backend, formulaRunPollInterval); err != nil {
+		if ctx.Err() != nil {
+			fmt.Printf("\n%s Stopped waiting; slung steps keep running.\n", style.Dim.Render("○"))
+			fmt.Printf("  Track progress: gt convoy status %s\n", run.convoyID)
+			return nil
+		}
+		return err
+	}
+
+	fmt.Printf("\n%s Formula complete: %d steps closed\n", style.Bold.Render("✓"), len(run.order))
+	fmt.Printf("  Convoy: %s\n", run.convoyID)
+	return nil
+}
+
+// createWorkflowRun creates a convoy bead tracking one bead per step, with
+// bead dependencies mirroring the steps' needs.
+func createWorkflowRun(f *formula.Formula, b workflowBackend, formulaName, targetRig string) (*workflowRun, error) {
+	order, err := f.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+	run := &workflowRun{
+		formula:  f,
+		convoyID: fmt.Sprintf("hq-cv-%s", generateFormulaShortID()),
+		order:    order,
+		beads:    make(map[string]string),
+	}
+
+	convoyTitle := fmt.Sprintf("%s: %s", formulaName, f.Description)
+	if len(convoyTitle) > 80 {
+		convoyTitle = convoyTitle[:77] + "..."
+	}
+	description := fmt.Sprintf("Formula %s: %s\n\nSteps: %d\nRig: %s",
+		f.Type, formulaName, len(order), targetRig)
+	if formulaRunPR > 0 {
+		description += fmt.Sprintf("\nPR: #%d", formulaRunPR)
+	}
+	if err := b.CreateBead(run.convoyID, "convoy", convoyTitle, description); err != nil {
+		return nil, fmt.Errorf("creating convoy bead: %w", err)
+	}
+	fmt.Printf("%s Created convoy: %s\n", style.Bold.Render("✓"), run.convoyID)
+
+	// Dependency order guarantees each step's needs already have beads.
+	for _, id := range order {
+		title, desc, needs := workflowStep(f, id)
+		beadID := fmt.Sprintf("hq-step-%s", generateFormulaShortID())
+		if err := b.CreateBead(beadID, "task", title, desc); err != nil {
+			return nil, fmt.Errorf("creating bead for step %s: %w", id, err)
+		}
+		run.beads[id] = beadID
+
+		if err := b.Track(run.convoyID, beadID); err != nil {
+			fmt.Printf("%s Failed to track step %s: %v\n",
+				style.Dim.Render("Warning:"), id, err)
+		}
+		for _, need := range needs {
+			if err := b.AddDependency(beadID, run.beads[need]); err != nil {
+				return nil, fmt.Errorf("adding dependency %s -> %s: %w", id, need, err)
+			}
+		}
+		fmt.Printf("  %s Created step: %s (%s)\n", style.Dim.Render("○"), id, beadID)
+	}
+
+	return run, nil
+}
+
+// driveWorkflow slings ready steps and polls slung steps until every step
+// is closed or skipped, or ctx is canceled. Steps whose when condition is
+// false are skipped. A step whose attempt fails to sling, closes with a
+// failed: reason or outlives its timeout is re-slung after its retry
+// backoff; a step that runs out of attempts aborts the run, since nothing
+// that needs it could ever become ready.
+func driveWorkflow(ctx context.Context, run *workflowRun, b workflowBackend, poll time.Duration) error {
+	state := formula.NewRunState(run.vars)
+	attempts := make(map[string]int)        // step ID -> dispatches so far
+	deadlines := make(map[string]time.Time) // step ID -> current attempt's timeout
+	retryAt := make(map[string]time.Time)   // step ID -> when to re-dispatch
+	reopen := make(map[string]bool)         // step ID -> bead closed by a failed attempt
+
+	// retryOrAbort schedules the next attempt of a step whose attempt
+	// failed, or returns abort once the step has no attempts left.
+	retryOrAbort := func(id, reason string, abort error) error {
+		delete(deadlines, id)
+		step := run.formula.GetStep(id)
+		if step == nil || attempts[id] >= step.MaxAttempts() {
+			return abort
+		}
+		delay := step.Retry.Delay(attempts[id])
+		retryAt[id] = time.Now().Add(delay)
+		fmt.Printf("  %s Step %s: %s (retrying in %s)\n", style.Warning.Render("⚠"), reason, id, delay)
+		return nil
+	}
+
+	dispatch := func(id string) error {
+		title, desc, _ := workflowStep(run.formula, id)
+		retry := attempts[id] > 0
+		if reopen[id] {
+			if err := b.Reopen(run.beads[id]); err != nil {
+				return fmt.Errorf("reopening step %s (%s): %w", id, run.beads[id], err)
+			}
+			delete(reopen, id)
+		}
+		attempts[id]++
+		if err := b.Sling(run.beads[id], title, desc, retry); err != nil {
+			return retryOrAbort(id, "sling failed", fmt.Errorf("slinging step %s (%s): %w", id, run.beads[id], err))
+		}
+		if step := run.formula.GetStep(id); step != nil && step.TimeoutDuration() > 0 {
+			deadlines[id] = time.Now().Add(step.TimeoutDuration())
+		}
+		if retry {
+			fmt.Printf("  %s Re-slung step: %s (attempt %d)\n", style.Bold.Render("↻"), id, attempts[id])
+		} else {
+			fmt.Printf("  %s Slung step: %s (%s)\n", style.Bold.Render("→"), id, run.beads[id])
+		}
+		return nil
+	}
+
+	for {
+		// Skipping a step can make its dependents ready, so repeat.
+		ready, skip := run.formula.NextSteps(state)
+		for len(skip) > 0 {
+			for _, id := range skip {
+				if err := b.Skip(run.beads[id], "skipped: when condition false"); err != nil {
+					return fmt.Errorf("skipping step %s (%s): %w", id, run.beads[id], err)
+				}
+				state.Status[id] = formula.StepSkipped
+				fmt.Printf("  %s Skipped step: %s (condition false)\n", style.Dim.Render("○"), id)
+			}
+			ready, skip = run.formula.NextSteps(state)
+		}
+
+		for _, id := range ready {
+			if attempts[id] > 0 {
+				continue
+			}
+			if err := dispatch(id); err != nil {
+				return err
+			}
+		}
+		for _, id := range run.order {
+			if at, ok := retryAt[id]; ok && !time.Now().Before(at) {
+				delete(retryAt, id)
+				if err := dispatch(id); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(state.Status) == len(run.order) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll):
+		}
+
+		for _, id := range run.order {
+			if attempts[id] == 0 || state.Status[id] != "" {
+				continue
+			}
+			if _, waiting := retryAt[id]; waiting {
+				continue
+			}
+			closed, output, err := b.StepResult(run.beads[id])
+			if err != nil {
+				fmt.Printf("%s Checking step %s: %v\n", style.Dim.Render("Warning:"), id, err)
+				continue
+			}
+			if closed && formula.StepFailed(output) {
+				reopen[id] = true
+				abort := fmt.Errorf("step %s (%s) failed after %d attempts: %s", id, run.beads[id], attempts[id], output)
+				if err := retryOrAbort(id, "failed", abort); err != nil {
+					return err
+				}
+				continue
+			}
+			if closed {
+				delete(deadlines, id)
+				state.Status[id] = formula.StepDone
+				state.Outputs[id] = output
+				fmt.Printf("  %s Step closed: %s (%d/%d)\n",
+					style.Bold.Render("✓"), id, len(state.Status), len(run.order))
+				continue
+			}
+
+			deadline, ok := deadlines[id]
+			if !ok || time.Now().Before(deadline) {
+				continue
+			}
+			abort := fmt.Errorf("step %s (%s) timed out after %d attempts", id, run.beads[id], attempts[id])
+			if err := retryOrAbort(id, "timed out", abort); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// workflowStep returns the title, description and needs of a workflow step,
+// expansion template or aspect.
+func workflowStep(f *formula.Formula, id string) (title, description string, needs []string) {
+	if s := f.GetStep(id); s != nil {
+		return s.Title, s.Description, s.Needs
+	}
+	if t := f.GetTemplate(id); t != nil {
+		return t.Title, t.Description, t.Needs
+	}
+	if a := f.GetAspect(id); a != nil {
+		title, description = a.Title, a.Description
+		if title == "" {
+			title = a.ID
+		}
+		if a.Focus != "" {
+			description = strings.TrimSpace(description + "\n\nFocus: " + a.Focus)
+		}
+		return title, description, nil
+	}
+	return id, "", nil
+}
+
+// bdWorkflowBackend runs workflow steps against the town beads database,
+// dispatching them to targetRig with gt sling.
+type bdWorkflowBackend struct {
+	townBeads string
+	rig       string
+}
+
+func (b *bdWorkflowBackend) CreateBead(id, beadType, title, description string) error {
+	cmd := exec.Command("bd", "create",
+		"--type="+beadType,
+		"--id="+id,
+		"--title="+title,
+		"--description="+description,
+	)
+	cmd.Dir = b.townBeads
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (b *bdWorkflowBackend) Track(convoyID, beadID string) error {
+	cmd := exec.Command("bd", "dep", "add", convoyID, beadID, "--type=tracks")
+	cmd.Dir = b.townBeads
+	return cmd.Run()
+}
+
+func (b *bdWorkflowBackend) AddDependency(beadID, dependsOn string) error {
+	return beads.New(b.townBeads).AddDependency(beadID, dependsOn)
+}
+
+func (b *bdWorkflowBackend) Sling(beadID, title, description string, force bool) error {
+	args := []string{"sling", beadID, b.rig, "-s", title}
+	if description != "" {
+		args = append(args, "-a", description)
+	}
+	if force {
+		args = append(args, "--force")
+	}
+	cmd := exec.Command("gt", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (b *bdWorkflowBackend) Reopen(beadID string) error {
+	status := "open"
+	return beads.New(b.townBeads).Update(beadID, beads.UpdateOptions{Status: &status})
+}
+
+func (b *bdWorkflowBackend) Skip(beadID, reason string) error {
+	return beads.New(b.townBeads).CloseWithReason(reason, beadID)
+}
+
+func (b *bdWorkflowBackend) StepResult(beadID string) (bool, string, error) {
+	issue, err := beads.New(b.townBeads).Show(beadID)
+	if err != nil {
+		return false, "", err
+	}
+	return issue.Status == "closed", issue.CloseReason, nil
+}
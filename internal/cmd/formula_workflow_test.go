@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+// fakeWorkflowBackend records bead operations. A slung bead closes on the
+// next poll, so each poll round completes the steps slung before it.
+type fakeWorkflowBackend struct {
+	created      []string            // bead IDs in creation order
+	titles       map[string]string   // bead ID -> title
+	descriptions map[string]string   // bead ID -> description
+	deps         map[string][]string // bead ID -> beads it depends on
+	tracked      []string
+	slung        []string
+	forced       []string // titles re-slung with force
+	attempts     map[string]int
+	closed       map[string]bool
+	slingErr     map[string]error  // by title
+	slingFails   map[string]int    // by title: sling calls that fail first
+	outputs      map[string]string // by title: close reason
+	hangs        map[string]int    // by title: attempts that never close
+	fails        map[string]int    // by title: attempts that close as failed
+	reopened     []string          // titles reopened after a failed attempt
+	slingLogs    [][]string        // titles slung in each poll round
+}
+
+func newFakeWorkflowBackend() *fakeWorkflowBackend {
+	return &fakeWorkflowBackend{
+		titles:       make(map[string]string),
+		descriptions: make(map[string]string),
+		deps:         make(map[string][]string),
+		attempts:     make(map[string]int),
+		closed:       make(map[string]bool),
+		slingErr:     make(map[string]error),
+		slingFails:   make(map[string]int),
+		outputs:      make(map[string]string),
+		hangs:        make(map[string]int),
+		fails:        make(map[string]int),
+	}
+}
+
+func (b *fakeWorkflowBackend) CreateBead(id, beadType, title, description string) error {
+	b.created = append(b.created, id)
+	b.titles[id] = title
+	b.descriptions[id] = description
+	return nil
+}
+
+func (b *fakeWorkflowBackend) Track(convoyID, beadID string) error {
+	b.tracked = append(b.tracked, beadID)
+	return nil
+}
+
+func (b *fakeWorkflowBackend) AddDependency(beadID, dependsOn string) error {
+	b.deps[beadID] = append(b.deps[beadID], dependsOn)
+	return nil
+}
+
+func (b *fakeWorkflowBackend) Sling(beadID, title, description string, force bool) error {
+	if err := b.slingErr[title]; err != nil {
+		return err
+	}
+	if b.slingFails[title] > 0 {
+		b.slingFails[title]--
+		return errors.New("sling exited 1")
+	}
+	// Every need of a slung bead must already be closed.
+	for _, dep := range b.deps[beadID] {
+		if !b.closed[dep] {
+			return errors.New("slung " + title + " before its needs closed")
+		}
+	}
+	b.slung = append(b.slung, beadID)
+	if force {
+		b.forced = append(b.forced, title)
+	}
+	b.attempts[beadID]++
+	if len(b.slingLogs) == 0 {
+		b.slingLogs = append(b.slingLogs, nil)
+	}
+	last := len(b.slingLogs) - 1
+	b.slingLogs[last] = append(b.slingLogs[last], title)
+	return nil
+}
+
+func (b *fakeWorkflowBackend) Reopen(beadID string) error {
+	b.closed[beadID] = false
+	b.reopened = append(b.reopened, b.titles[beadID])
+	return nil
+}
+
+func (b *fakeWorkflowBackend) Skip(beadID, reason string) error {
+	b.closed[beadID] = true
+	return nil
+}
+
+func (b *fakeWorkflowBackend) StepResult(beadID string) (bool, string, error) {
+	title := b.titles[beadID]
+	if b.attempts[beadID] <= b.hangs[title] {
+		return false, "", nil
+	}
+	if !b.closed[beadID] {
+		b.closed[beadID] = true
+		b.slingLogs = append(b.slingLogs, nil)
+	}
+	if b.attempts[beadID] <= b.hangs[title]+b.fails[title] {
+		return true, "failed: attempt " + strconv.Itoa(b.attempts[beadID]), nil
+	}
+	return true, b.outputs[title], nil
+}
+
+// rounds formats the titles slung in each poll round.
+func (b *fakeWorkflowBackend) rounds() string {
+	var rounds []string
+	for _, r := range b.slingLogs {
+		if len(r) > 0 {
+			rounds = append(rounds, strings.Join(r, "+"))
+		}
+	}
+	return strings.Join(rounds, " | ")
+}
+
+const diamondWorkflow = `
+formula = "diamond"
+type = "workflow"
+
+[[steps]]
+id = "design"
+title = "Design {{feature}}"
+
+[[steps]]
+id = "backend"
+title = "Backend"
+needs = ["design"]
+
+[[steps]]
+id = "frontend"
+title = "Frontend"
+needs = ["design"]
+
+[[steps]]
+id = "ship"
+title = "Ship {{feature}}"
+needs = ["backend", "frontend"]
+
+[vars.feature]
+required = true
+`
+
+func instantiateTestFormula(t *testing.T, src string, values map[string]string) *formula.Formula {
+	t.Helper()
+	f, err := formula.Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	vars, err := f.BindVars(values)
+	if err != nil {
+		t.Fatalf("BindVars: %v", err)
+	}
+	inst, err := f.Instantiate(vars)
+	if err != nil {
+		t.Fatalf("Instantiate: %v", err)
+	}
+	return inst
+}
+
+func TestWorkflowRun_SlingsStepsAsNeedsClose(t *testing.T) {
+	f := instantiateTestFormula(t, diamondWorkflow, map[string]string{"feature": "login"})
+	b := newFakeWorkflowBackend()
+
+	run, err := createWorkflowRun(f, b, "diamond", "gastown")
+	if err != nil {
+		t.Fatalf("createWorkflowRun: %v", err)
+	}
+
+	// Convoy plus one bead per step, each tracked, with deps mirroring needs.
+	if len(b.created) != 5 || b.created[0] != run.convoyID {
+		t.Fatalf("created = %v", b.created)
+	}
+	if len(b.tracked) != 4 {
+		t.Errorf("tracked = %v, want 4 steps", b.tracked)
+	}
+	shipDeps := b.deps[run.beads["ship"]]
+	if len(shipDeps) != 2 || shipDeps[0] != run.beads["backend"] || shipDeps[1] != run.beads["frontend"] {
+		t.Errorf("ship deps = %v", shipDeps)
+	}
+	if b.titles[run.beads["design"]] != "Design login" {
+		t.Errorf("design title = %q, want vars substituted", b.titles[run.beads["design"]])
+	}
+
+	if err := driveWorkflow(context.Background(), run, b, time.Millisecond); err != nil {
+		t.Fatalf("driveWorkflow: %v", err)
+	}
+
+	want := "Design login | Backend+Frontend | Ship login"
+	if got := b.rounds(); got != want {
+		t.Errorf("sling rounds = %q, want %q", got, want)
+	}
+}
+
+func TestWorkflowRun_SlingFailureAborts(t *testing.T) {
+	f := instantiateTestFormula(t, diamondWorkflow, map[string]string{"feature": "login"})
+	b := newFakeWorkflowBackend()
+	b.slingErr["Backend"] = errors.New("no polecats")
+
+	run, err := createWorkflowRun(f, b, "diamond", "gastown")
+	if err != nil {
+		t.Fatalf("createWorkflowRun: %v", err)
+	}
+	err = driveWorkflow(context.Background(), run, b, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "slinging step backend") {
+		t.Fatalf("driveWorkflow error = %v, want backend sling failure", err)
+	}
+	for _, id := range b.slung {
+		if id == run.beads["ship"] {
+			t.Error("ship was slung despite backend never running")
+		}
+	}
+}
+
+func TestWorkflowRun_CanceledStopsWaiting(t *testing.T) {
+	f := instantiateTestFormula(t, diamondWorkflow, map[string]string{"feature": "login"})
+	b := newFakeWorkflowBackend()
+	run, err := createWorkflowRun(f, b, "diamond", "gastown")
+	if err != nil {
+		t.Fatalf("createWorkflowRun: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := driveWorkflow(ctx, run, b, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("driveWorkflow error = %v, want context.Canceled", err)
+	}
+	if len(b.slung) != 1 {
+		t.Errorf("slung = %v, want only the first ready step", b.slung)
+	}
+}
+
+const patrolWorkflow = `
+formula = "patrol"
+type = "workflow"
+
+[[steps]]
+id = "check-queue"
+title = "Check queue"
+
+[[steps]]
+id = "process"
+title = "Process queue"
+needs = ["check-queue"]
+when = 'steps.check-queue.output != "empty"'
+timeout = "1ms"
+[steps.retry]
+attempts = 2
+
+[[steps]]
+id = "sleep"
+title = "Sleep"
+needs = ["process"]
+`
+
+func newPatrolRun(t *testing.T, b *fakeWorkflowBackend) *workflowRun {
+	t.Helper()
+	f := instantiateTestFormula(t, patrolWorkflow, nil)
+	run, err := createWorkflowRun(f, b, "patrol", "gastown")
+	if err != nil {
+		t.Fatalf("createWorkflowRun: %v", err)
+	}
+	return run
+}
+
+func TestWorkflowRun_SkipsFalseCondition(t *testing.T) {
+	b := newFakeWorkflowBackend()
+	b.outputs["Check queue"] = "empty"
+	run := newPatrolRun(t, b)
+
+	if err := driveWorkflow(context.Background(), run, b, time.Millisecond); err != nil {
+		t.Fatalf("driveWorkflow: %v", err)
+	}
+	if got := b.rounds(); got != "Check queue | Sleep" {
+		t.Errorf("sling rounds = %q, want process skipped", got)
+	}
+}
+
+func TestWorkflowRun_RetriesTimedOutStep(t *testing.T) {
+	b := newFakeWorkflowBackend()
+	b.outputs["Check queue"] = "3 MRs"
+	b.hangs["Process queue"] = 1
+	run := newPatrolRun(t, b)
+
+	if err := driveWorkflow(context.Background(), run, b, 5*time.Millisecond); err != nil {
+		t.Fatalf("driveWorkflow: %v", err)
+	}
+	if len(b.forced) != 1 || b.forced[0] != "Process queue" {
+		t.Errorf("forced = %v, want process re-slung once", b.forced)
+	}
+	if !strings.HasSuffix(b.rounds(), "Sleep") {
+		t.Errorf("sling rounds = %q, want run to finish", b.rounds())
+	}
+}
+
+func TestWorkflowRun_RetriesExhausted(t *testing.T) {
+	b := newFakeWorkflowBackend()
+	b.hangs["Process queue"] = 2
+	run := newPatrolRun(t, b)
+
+	err := driveWorkflow(context.Background(), run, b, 5*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "process") || !strings.Contains(err.Error(), "timed out after 2 attempts") {
+		t.Fatalf("driveWorkflow error = %v, want timeout after 2 attempts", err)
+	}
+}
+
+func TestWorkflowRun_RetriesFailedStep(t *testing.T) {
+	b := newFakeWorkflowBackend()
+	b.outputs["Check queue"] = "3 MRs"
+	b.fails["Process queue"] = 1
+	run := newPatrolRun(t, b)
+
+	if err := driveWorkflow(context.Background(), run, b, 5*time.Millisecond); err != nil {
+		t.Fatalf("driveWorkflow: %v", err)
+	}
+	if len(b.reopened) != 1 || len(b.forced) != 1 || b.forced[0] != "Process queue" {
+		t.Errorf("reopened = %v, forced = %v, want process reopened and re-slung once", b.reopened, b.forced)
+	}
+	if !strings.HasSuffix(b.rounds(), "Sleep") {
+		t.Errorf("sling rounds = %q, want run to finish", b.rounds())
+	}
+}
+
+func TestWorkflowRun_RetriesSlingFailure(t *testing.T) {
+	b := newFakeWorkflowBackend()
+	b.outputs["Check queue"] = "3 MRs"
+	b.slingFails["Process queue"] = 1
+	run := newPatrolRun(t, b)
+
+	if err := driveWorkflow(context.Background(), run, b, 5*time.Millisecond); err != nil {
+		t.Fatalf("driveWorkflow: %v", err)
+	}
+	if b.attempts[run.beads["process"]] != 1 || len(b.forced) != 1 {
+		t.Errorf("process slung %d times, forced = %v, want one retried sling",
+			b.attempts[run.beads["process"]], b.forced)
+	}
+}
+
+func TestWorkflowRun_FailedStepExhaustsRetries(t *testing.T) {
+	b := newFakeWorkflowBackend()
+	b.fails["Process queue"] = 2
+	run := newPatrolRun(t, b)
+
+	err := driveWorkflow(context.Background(), run, b, 5*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "failed after 2 attempts: failed: attempt 2") {
+		t.Fatalf("driveWorkflow error = %v, want failure after 2 attempts", err)
+	}
+	if len(b.reopened) != 1 {
+		t.Errorf("reopened = %v, want only the retried attempt reopened", b.reopened)
+	}
+}
+
+func TestParseFormulaVars(t *testing.T) {
+	values, err := parseFormulaVars([]string{"feature=login", "target.title=a=b"})
+	if err != nil {
+		t.Fatalf("parseFormulaVars: %v", err)
+	}
+	if values["feature"] != "login" || values["target.title"] != "a=b" {
+		t.Errorf("values = %v", values)
+	}
+	if _, err := parseFormulaVars([]string{"feature"}); err == nil {
+		t.Error("expected error for missing '='")
+	}
+}
+
+func TestFindAndParseFormula_ParentInTownFormulas(t *testing.T) {
+	townRoot := t.TempDir()
+	projectDir := filepath.Join(townRoot, "gastown", "crew", "max")
+	townFormulas := filepath.Join(townRoot, ".beads", "formulas")
+	projectFormulas := filepath.Join(projectDir, ".beads", "formulas")
+	for _, dir := range []string{filepath.Join(townRoot, "mayor"), townFormulas, projectFormulas} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(townRoot, "mayor", "town.json"): `{"name": "test"}`,
+		filepath.Join(townFormulas, "town-base.formula.toml"): `
+formula = "town-base"
+type = "workflow"
+
+[[steps]]
+id = "plan"
+title = "Plan"
+`,
+		filepath.Join(projectFormulas, "feature.formula.toml"): `
+formula = "feature"
+extends = "town-base"
+
+[[steps]]
+id = "build"
+title = "Build"
+needs = ["plan"]
+`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd) //nolint:errcheck
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := findFormulaFile("feature")
+	if err != nil {
+		t.Fatalf("findFormulaFile: %v", err)
+	}
+	f, err := formula.ParseFile(path, formulaSearchPaths()...)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if len(f.Steps) != 2 || f.Steps[0].ID != "plan" || f.Steps[1].ID != "build" {
+		t.Errorf("steps = %+v, want plan then build", f.Steps)
+	}
+}
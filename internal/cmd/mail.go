@@ -35,6 +35,10 @@ var (
 	mailNotify        bool
 	mailSendSelf      bool
 	mailCC            []string // CC recipients
+	mailAck           bool
+	mailAckBy         time.Duration
+	mailAt            string
+	mailIn            time.Duration
 	mailInboxJSON     bool
 	mailReadJSON      bool
 	mailInboxUnread   bool
@@ -58,6 +62,12 @@ var (
 
 	// Clear flags
 	mailClearAll bool
+
+	// Status flags
+	mailStatusJSON bool
+
+	// Scheduled flags
+	mailScheduledJSON bool
 )
 
 var mailCmd = &cobra.Command{
@@ -142,7 +152,19 @@ Examples:
   gt mail send mayor/ -s "Re: Status" -m "Done" --reply-to msg-abc123
   gt mail send --self -s "Handoff" -m "Context for next session"
   gt mail send greenplace/Toast -s "Update" -m "Progress report" --cc overseer
-  gt mail send list:oncall -s "Alert" -m "System down"`,
+  gt mail send list:oncall -s "Alert" -m "System down"
+  gt mail send list:oncall -s "Deploy freeze" -m "Until 17:00" --ack --ack-by 30m
+
+With --ack, each recipient (including list members and CC) must run
+'gt mail ack'. The daemon renotifies recipients who haven't, and mails you
+a receipt if the deadline passes. Track it with 'gt mail status <id>'.
+
+With --at or --in, the message waits until then and the daemon delivers
+it (and notifies the recipient) on its next heartbeat. See
+'gt mail scheduled' and 'gt mail cancel'.
+
+  gt mail send gastown/witness -s "Check Toast" -m "Still stuck?" --in 2h
+  gt mail send mayor/ -s "Morning review" -m "Convoys" --at 09:00`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runMailSend,
 }
@@ -208,6 +230,68 @@ Examples:
 	RunE: runMailArchive,
 }
 
+var mailAckCmd = &cobra.Command{
+	Use:   "ack <message-id> [message-id...]",
+	Short: "Acknowledge messages",
+	Long: `Acknowledge one or more messages, marking them read.
+
+Senders who asked for acknowledgement (gt mail send --ack) see it in
+'gt mail status'. Archiving or deleting a message also acknowledges it.
+
+Examples:
+  gt mail ack hq-abc123
+  gt mail ack hq-abc123 hq-def456`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMailAck,
+}
+
+var mailScheduledCmd = &cobra.Command{
+	Use:   "scheduled",
+	Short: "List scheduled messages",
+	Long: `List messages waiting for delivery (sent with --at or --in), and the
+recurring schedules from config/messaging.json with their next run.
+
+Recurring schedules send to mailing lists and announce channels:
+
+  "schedules": {
+    "standup": {"cron": "0 9 * * 1-5", "to": "list:oncall", "subject": "Standup"}
+  }
+
+Cancel a waiting message with 'gt mail cancel <id>'.`,
+	Args: cobra.NoArgs,
+	RunE: runMailScheduled,
+}
+
+var mailCancelCmd = &cobra.Command{
+	Use:   "cancel <message-id>",
+	Short: "Cancel a scheduled message",
+	Long: `Cancel a message waiting for delivery, as listed by 'gt mail scheduled'.
+
+Recurring schedules are removed from config/messaging.json instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMailCancel,
+}
+
+var mailStatusCmd = &cobra.Command{
+	Use:   "status <message-id>",
+	Short: "Show delivery status of a message",
+	Long: `Show the delivery status of a message sent with --ack, per recipient:
+
+  sent       - In the inbox; the recipient's session wasn't running
+  delivered  - The recipient's session was notified
+  read       - Opened with 'gt mail read'
+  acked      - Acknowledged with 'gt mail ack' (or archived/deleted)
+
+The ID is the one printed by 'gt mail send --ack', or the ID of any
+recipient's copy.
+
+Examples:
+  gt mail status msg-a1b2c3d4
+  gt mail status hq-abc123 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMailStatus,
+}
+
 var mailCheckCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check for new mail (for hooks)",
@@ -410,6 +494,10 @@ func init() {
 	mailSendCmd.Flags().BoolVar(&mailPermanent, "permanent", false, "Send as permanent (not ephemeral, synced to remote)")
 	mailSendCmd.Flags().BoolVar(&mailSendSelf, "self", false, "Send to self (auto-detect from cwd)")
 	mailSendCmd.Flags().StringArrayVar(&mailCC, "cc", nil, "CC recipients (can be used multiple times)")
+	mailSendCmd.Flags().BoolVar(&mailAck, "ack", false, "Require recipients to acknowledge (tracked, renotified until acked)")
+	mailSendCmd.Flags().DurationVar(&mailAckBy, "ack-by", mail.DefaultAckTimeout, "Acknowledgement deadline, after which you get a receipt (implies --ack)")
+	mailSendCmd.Flags().StringVar(&mailAt, "at", "", "Deliver at a time: HH:MM (next occurrence), \"YYYY-MM-DD HH:MM\" or RFC 3339")
+	mailSendCmd.Flags().DurationVar(&mailIn, "in", 0, "Deliver after a delay (e.g., 30m, 2h)")
 	_ = mailSendCmd.MarkFlagRequired("subject") // cobra flags: error only at runtime if missing
 
 	// Inbox flags
@@ -448,6 +536,12 @@ func init() {
 	// Clear flags
 	mailClearCmd.Flags().BoolVar(&mailClearAll, "all", false, "Clear all messages (default behavior)")
 
+	// Status flags
+	mailStatusCmd.Flags().BoolVar(&mailStatusJSON, "json", false, "Output as JSON")
+
+	// Scheduled flags
+	mailScheduledCmd.Flags().BoolVar(&mailScheduledJSON, "json", false, "Output as JSON")
+
 	// Add subcommands
 	mailCmd.AddCommand(mailSendCmd)
 	mailCmd.AddCommand(mailInboxCmd)
@@ -455,6 +549,10 @@ func init() {
 	mailCmd.AddCommand(mailPeekCmd)
 	mailCmd.AddCommand(mailDeleteCmd)
 	mailCmd.AddCommand(mailArchiveCmd)
+	mailCmd.AddCommand(mailAckCmd)
+	mailCmd.AddCommand(mailStatusCmd)
+	mailCmd.AddCommand(mailScheduledCmd)
+	mailCmd.AddCommand(mailCancelCmd)
 	mailCmd.AddCommand(mailCheckCmd)
 	mailCmd.AddCommand(mailThreadCmd)
 	mailCmd.AddCommand(mailReplyCmd)
@@ -540,6 +638,39 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 	// Set CC recipients
 	msg.CC = mailCC
 
+	// Defer delivery (--at or --in)
+	if mailAt != "" && mailIn != 0 {
+		return fmt.Errorf("use --at or --in, not both")
+	}
+	if mailAt != "" {
+		at, err := parseDeliverAt(mailAt, time.Now())
+		if err != nil {
+			return err
+		}
+		msg.DeliverAfter = at
+	} else if mailIn < 0 {
+		return fmt.Errorf("--in must be positive")
+	} else if mailIn > 0 {
+		msg.DeliverAfter = time.Now().Add(mailIn)
+	}
+
+	// Request acknowledgement (--ack-by implies --ack)
+	if mailAck || cmd.Flags().Changed("ack-by") {
+		if strings.HasPrefix(to, "queue:") || strings.HasPrefix(to, "announce:") {
+			return fmt.Errorf("--ack is not supported for queue or announce addresses")
+		}
+		if mailAckBy <= 0 {
+			return fmt.Errorf("--ack-by must be positive")
+		}
+		msg.RequireAck = true
+		// The deadline runs from delivery
+		start := time.Now()
+		if msg.DeliverAfter.After(start) {
+			start = msg.DeliverAfter
+		}
+		msg.AckBy = start.Add(mailAckBy)
+	}
+
 	// Handle reply-to: auto-set type to reply and look up thread
 	if mailReplyTo != "" {
 		msg.ReplyTo = mailReplyTo
@@ -579,6 +710,18 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("sending message: %w", err)
 	}
 
+	if !msg.DeliverAfter.IsZero() {
+		fmt.Printf("%s Message to %s scheduled for %s\n",
+			style.Bold.Render("✓"), to, msg.DeliverAfter.Format("2006-01-02 15:04"))
+		fmt.Printf("  Subject: %s\n", mailSubject)
+		fmt.Printf("  ID: %s (cancel with: gt mail cancel %s)\n", msg.ID, msg.ID)
+		if msg.RequireAck {
+			fmt.Printf("  Ack by: %s (track with: gt mail status %s)\n",
+				msg.AckBy.Format("2006-01-02 15:04"), msg.ID)
+		}
+		return nil
+	}
+
 	// Log mail event to activity feed
 	_ = events.LogFeed(events.TypeMail, from, events.MailPayload(to, mailSubject))
 
@@ -596,6 +739,10 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 	if msg.Type != mail.TypeNotification {
 		fmt.Printf("  Type: %s\n", msg.Type)
 	}
+	if msg.RequireAck {
+		fmt.Printf("  Ack by: %s (track with: gt mail status %s)\n",
+			msg.AckBy.Format("2006-01-02 15:04"), msg.ReceiptID)
+	}
 
 	return nil
 }
@@ -669,8 +816,12 @@ func runMailInbox(cmd *cobra.Command, args []string) error {
 		if msg.Wisp {
 			wispMarker = " " + style.Dim.Render("(wisp)")
 		}
+		ackMarker := ""
+		if msg.RequireAck {
+			ackMarker = " " + style.Bold.Render("[ack]")
+		}
 
-		fmt.Printf("  %s %s%s%s%s\n", readMarker, msg.Subject, typeMarker, priorityMarker, wispMarker)
+		fmt.Printf("  %s %s%s%s%s%s\n", readMarker, msg.Subject, typeMarker, priorityMarker, wispMarker, ackMarker)
 		fmt.Printf("    %s from %s\n",
 			style.Dim.Render(msg.ID),
 			msg.From)
@@ -708,6 +859,8 @@ func runMailRead(cmd *cobra.Command, args []string) error {
 	// Note: We intentionally do NOT mark as read/ack on read.
 	// User must explicitly delete/ack the message.
 	// This preserves handoff messages for reference.
+	// A tracked message's receipt does record the read (best-effort).
+	_ = router.RecordReceipt(msg, address, mail.StateRead)
 
 	// JSON output
 	if mailReadJSON {
@@ -746,6 +899,10 @@ func runMailRead(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\n%s\n", msg.Body)
 	}
 
+	if msg.RequireAck {
+		fmt.Printf("\n%s Acknowledgement requested: gt mail ack %s\n", style.Bold.Render("!"), msg.ID)
+	}
+
 	return nil
 }
 
@@ -827,7 +984,7 @@ func runMailDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting mailbox: %w", err)
 	}
 
-	if err := mailbox.Delete(msgID); err != nil {
+	if err := ackMessage(router, mailbox, msgID, address); err != nil {
 		return fmt.Errorf("deleting message: %w", err)
 	}
 
@@ -856,7 +1013,7 @@ func runMailArchive(cmd *cobra.Command, args []string) error {
 	archived := 0
 	var errors []string
 	for _, msgID := range args {
-		if err := mailbox.Delete(msgID); err != nil {
+		if err := ackMessage(router, mailbox, msgID, address); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", msgID, err))
 		} else {
 			archived++
@@ -881,6 +1038,191 @@ func runMailArchive(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runMailAck(cmd *cobra.Command, args []string) error {
+	// Determine which inbox
+	address := detectSender()
+
+	// All mail uses town beads (two-level architecture)
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	// Get mailbox
+	router := mail.NewRouter(workDir)
+	mailbox, err := router.GetMailbox(address)
+	if err != nil {
+		return fmt.Errorf("getting mailbox: %w", err)
+	}
+
+	var errs []string
+	for _, msgID := range args {
+		if err := ackMessage(router, mailbox, msgID, address); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", msgID, err))
+		}
+	}
+	if len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Printf("  Error: %s\n", e)
+		}
+		return fmt.Errorf("failed to acknowledge %d messages", len(errs))
+	}
+
+	fmt.Printf("%s Acknowledged %d message(s)\n", style.Bold.Render("✓"), len(args))
+	return nil
+}
+
+// ackMessage closes a message and, if its sender asked for acknowledgement,
+// records that address has acknowledged it. Recording is best-effort: the
+// message is closed either way.
+func ackMessage(router *mail.Router, mailbox *mail.Mailbox, msgID, address string) error {
+	msg, _ := mailbox.Get(msgID)
+	if err := mailbox.Delete(msgID); err != nil {
+		return err
+	}
+	if msg != nil {
+		_ = router.RecordReceipt(msg, address, mail.StateAcked)
+	}
+	return nil
+}
+
+// parseDeliverAt parses a --at time: "HH:MM" (the next occurrence after
+// now), "YYYY-MM-DD HH:MM" (local time) or RFC 3339.
+func parseDeliverAt(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("15:04", value, now.Location()); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --at %q (want HH:MM, \"YYYY-MM-DD HH:MM\" or RFC 3339)", value)
+}
+
+func runMailScheduled(cmd *cobra.Command, args []string) error {
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	router := mail.NewRouter(workDir)
+
+	pending, err := router.ListScheduled()
+	if err != nil {
+		return err
+	}
+	recurring, err := router.ListRecurring()
+	if err != nil {
+		return err
+	}
+
+	if mailScheduledJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Pending   []*mail.Message     `json:"pending"`
+			Recurring []mail.ScheduledRun `json:"recurring"`
+		}{pending, recurring})
+	}
+
+	fmt.Printf("%s Scheduled messages (%d)\n\n", style.Bold.Render("⏰"), len(pending))
+	if len(pending) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(none)"))
+	}
+	for _, msg := range pending {
+		fmt.Printf("  %s %s\n", msg.DeliverAfter.Format("2006-01-02 15:04"), msg.Subject)
+		fmt.Printf("    %s from %s to %s\n", style.Dim.Render(msg.ID), msg.From, msg.To)
+	}
+
+	if len(recurring) > 0 {
+		fmt.Printf("\n%s Recurring (config/messaging.json)\n\n", style.Bold.Render("🔁"))
+		for _, run := range recurring {
+			next := "never"
+			if !run.NextRun.IsZero() {
+				next = run.NextRun.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("  %s %s\n", next, run.Schedule.Subject)
+			fmt.Printf("    %s %s to %s\n", style.Dim.Render(run.Name), style.Dim.Render("("+run.Schedule.Cron+")"), run.Schedule.To)
+		}
+	}
+	return nil
+}
+
+func runMailCancel(cmd *cobra.Command, args []string) error {
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	msg, err := mail.NewRouter(workDir).CancelScheduled(args[0])
+	if err != nil {
+		if err == mail.ErrMessageNotFound {
+			return fmt.Errorf("no scheduled message %s (already delivered?)", args[0])
+		}
+		return err
+	}
+
+	fmt.Printf("%s Cancelled %q to %s\n", style.Bold.Render("✓"), msg.Subject, msg.To)
+	return nil
+}
+
+func runMailStatus(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	receipt, err := mail.FindReceipt(townRoot, args[0])
+	if err != nil {
+		if err == mail.ErrMessageNotFound {
+			return fmt.Errorf("no delivery receipt for %s (was it sent with --ack?)", args[0])
+		}
+		return err
+	}
+
+	if mailStatusJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(receipt)
+	}
+
+	pending := len(receipt.Pending())
+	summary := fmt.Sprintf("%d/%d acknowledged", len(receipt.Recipients)-pending, len(receipt.Recipients))
+	if receipt.Overdue {
+		summary += ", " + style.Bold.Render("OVERDUE")
+	}
+	fmt.Printf("%s %s (%s)\n", style.Bold.Render("📨"), receipt.Subject, summary)
+	fmt.Printf("  ID: %s  From: %s\n", style.Dim.Render(receipt.ID), receipt.From)
+	fmt.Printf("  Sent: %s  Ack by: %s\n\n",
+		receipt.SentAt.Format("2006-01-02 15:04"), receipt.AckBy.Format("2006-01-02 15:04"))
+
+	for _, rr := range receipt.Recipients {
+		marker := "●"
+		detail := fmt.Sprintf("%d attempt(s)", rr.Attempts)
+		switch rr.State {
+		case mail.StateAcked:
+			marker = "✓"
+			detail = "acked " + rr.AckedAt.Format("15:04")
+		case mail.StateRead:
+			detail += ", read " + rr.ReadAt.Format("15:04")
+		}
+		if rr.State != mail.StateAcked && !receipt.Done() {
+			detail += ", next " + rr.NextAttempt.Format("15:04")
+		}
+		cc := ""
+		if rr.CC {
+			cc = " " + style.Dim.Render("(cc)")
+		}
+		fmt.Printf("  %s %-30s %-10s %s%s\n", marker, rr.Address, rr.State, style.Dim.Render(detail), cc)
+	}
+	return nil
+}
+
 func runMailClear(cmd *cobra.Command, args []string) error {
 	// Determine which inbox to clear (target arg or auto-detect)
 	address := ""
@@ -921,6 +1263,7 @@ func runMailClear(cmd *cobra.Command, args []string) error {
 		if err := mailbox.Delete(msg.ID); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", msg.ID, err))
 		} else {
+			_ = router.RecordReceipt(msg, address, mail.StateAcked)
 			deleted++
 		}
 	}
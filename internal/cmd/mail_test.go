@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/steveyegge/gastown/internal/config"
 )
@@ -381,3 +382,32 @@ func TestAnnounceMessageParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDeliverAt(t *testing.T) {
+	loc := time.FixedZone("test", 2*60*60)
+	now := time.Date(2026, 1, 2, 10, 30, 0, 0, loc)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "11:00", want: time.Date(2026, 1, 2, 11, 0, 0, 0, loc)},
+		{value: "09:00", want: time.Date(2026, 1, 3, 9, 0, 0, 0, loc)},   // Already passed today
+		{value: "10:30", want: time.Date(2026, 1, 3, 10, 30, 0, 0, loc)}, // Now is not the future
+		{value: "2026-02-01 08:15", want: time.Date(2026, 2, 1, 8, 15, 0, 0, loc)},
+		{value: "2026-02-01T08:15:00Z", want: time.Date(2026, 2, 1, 8, 15, 0, 0, time.UTC)},
+		{value: "tomorrow", wantErr: true},
+		{value: "25:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDeliverAt(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDeliverAt(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parseDeliverAt(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,19 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
-	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
@@ -44,10 +47,16 @@ Role shortcuts (expand to session names):
   witness   Maps to gt-<rig>-witness (uses current rig)
   refinery  Maps to gt-<rig>-refinery (uses current rig)
 
-Channel syntax:
-  channel:<name>  Nudges all members of a named channel defined in
-                  ~/gt/config/messaging.json under "nudge_channels".
-                  Patterns like "gastown/polecats/*" are expanded.
+Channels and groups:
+  @<channel>      Nudges all running members of a named channel defined in
+                  ~/gt/config/messaging.json under "nudge_channels"
+                  (channel:<name> also works). Patterns like
+                  "gastown/polecats/*" and @group members are expanded.
+  @<group>        Without a channel of that name, nudges a group like mail
+                  does: @town, @witnesses, @rig/<rig>, @polecats/<rig> ...
+
+  Members are nudged concurrently (rate limited), each one's DND is
+  honored, and a per-member summary is printed.
 
 DND (Do Not Disturb):
   If the target has DND enabled (gt dnd on), the nudge is skipped.
@@ -59,7 +68,8 @@ Examples:
   gt nudge mayor "Status update requested"
   gt nudge witness "Check polecat health"
   gt nudge deacon session-started
-  gt nudge channel:workers "New priority work available"`,
+  gt nudge @workers "rebase on main"
+  gt nudge @witnesses "Check polecat health"`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: runNudge,
 }
@@ -77,39 +87,20 @@ func runNudge(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("message required: use -m flag or provide as second argument")
 	}
 
-	// Handle channel syntax: channel:<name>
-	if strings.HasPrefix(target, "channel:") {
-		channelName := strings.TrimPrefix(target, "channel:")
-		return runNudgeChannel(channelName, message)
+	// Broadcast to a channel or group: @<name> or channel:<name>
+	if strings.HasPrefix(target, "@") || strings.HasPrefix(target, "channel:") {
+		return runNudgeBroadcast(target, message)
 	}
 
 	// Identify sender for message prefix
-	sender := "unknown"
-	if roleInfo, err := GetRole(); err == nil {
-		switch roleInfo.Role {
-		case RoleMayor:
-			sender = "mayor"
-		case RoleCrew:
-			sender = fmt.Sprintf("%s/crew/%s", roleInfo.Rig, roleInfo.Polecat)
-		case RolePolecat:
-			sender = fmt.Sprintf("%s/%s", roleInfo.Rig, roleInfo.Polecat)
-		case RoleWitness:
-			sender = fmt.Sprintf("%s/witness", roleInfo.Rig)
-		case RoleRefinery:
-			sender = fmt.Sprintf("%s/refinery", roleInfo.Rig)
-		case RoleDeacon:
-			sender = "deacon"
-		default:
-			sender = string(roleInfo.Role)
-		}
-	}
+	sender := detectNudgeSender()
 
 	// Prefix message with sender
 	message = fmt.Sprintf("[from %s] %s", sender, message)
 
-	// Check DND status for target (unless force flag or channel target)
+	// Check DND status for target (unless force flag)
 	townRoot, _ := workspace.FindFromCwd()
-	if townRoot != "" && !nudgeForceFlag && !strings.HasPrefix(target, "channel:") {
+	if townRoot != "" && !nudgeForceFlag {
 		shouldSend, level, _ := shouldNudgeTarget(townRoot, target, nudgeForceFlag)
 		if !shouldSend {
 			fmt.Printf("%s Target has DND enabled (%s) - nudge skipped\n", style.Dim.Render("○"), level)
@@ -166,6 +157,7 @@ func runNudge(cmd *cobra.Command, args []string) error {
 			_ = LogNudge(townRoot, "deacon", message)
 		}
 		_ = events.LogFeed(events.TypeNudge, sender, events.NudgePayload("", "deacon", message))
+		countWitnessNudge(townRoot, sender)
 		return nil
 	}
 
@@ -205,6 +197,7 @@ func runNudge(cmd *cobra.Command, args []string) error {
 			_ = LogNudge(townRoot, target, message)
 		}
 		_ = events.LogFeed(events.TypeNudge, sender, events.NudgePayload(rigName, target, message))
+		countWitnessNudge(townRoot, sender)
 	} else {
 		// Raw session name (legacy)
 		exists, err := t.HasSession(target)
@@ -226,58 +219,65 @@ func runNudge(cmd *cobra.Command, args []string) error {
 			_ = LogNudge(townRoot, target, message)
 		}
 		_ = events.LogFeed(events.TypeNudge, sender, events.NudgePayload("", target, message))
+		countWitnessNudge(townRoot, sender)
 	}
 
 	return nil
 }
 
-// runNudgeChannel nudges all members of a named channel.
-func runNudgeChannel(channelName, message string) error {
-	// Find town root
+// countWitnessNudge records a nudge in the witness nudge metric when the
+// sender is a rig's witness. Other senders' nudges are not counted.
+func countWitnessNudge(townRoot, sender string) {
+	rig, ok := strings.CutSuffix(sender, "/witness")
+	if townRoot == "" || !ok || rig == "" {
+		return
+	}
+	telemetry.At(townRoot).Count(telemetry.MetricWitnessNudges, telemetry.Labels{"rig": rig})
+}
+
+const (
+	// nudgeBroadcastConcurrency is how many members of a channel or group
+	// are nudged at once.
+	nudgeBroadcastConcurrency = 4
+
+	// nudgeBroadcastInterval is the minimum gap between starting nudges,
+	// so a large broadcast doesn't flood tmux.
+	nudgeBroadcastInterval = 100 * time.Millisecond
+)
+
+// nudgeTarget is a running session a broadcast nudge goes to.
+type nudgeTarget struct {
+	Address string // Agent address, for DND checks and events
+	Rig     string
+	Session string
+}
+
+// nudgeResult is the outcome of nudging one target.
+type nudgeResult struct {
+	nudgeTarget
+	Sent    bool
+	Skipped string // Why the nudge wasn't sent (e.g., DND level), if it wasn't
+	Err     error
+}
+
+// runNudgeBroadcast nudges every running member of a nudge channel
+// (@<channel>, or the older channel:<channel>) or, if there is no channel
+// by that name, of a @group address.
+func runNudgeBroadcast(target, message string) error {
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
 		return fmt.Errorf("cannot find town root: %w", err)
 	}
 
-	// Load messaging config
-	msgConfigPath := config.MessagingConfigPath(townRoot)
-	msgConfig, err := config.LoadMessagingConfig(msgConfigPath)
+	members, err := resolveNudgeMembers(mail.NewRouterWithTownRoot(townRoot, townRoot), target)
 	if err != nil {
-		return fmt.Errorf("loading messaging config: %w", err)
-	}
-
-	// Look up channel
-	patterns, ok := msgConfig.NudgeChannels[channelName]
-	if !ok {
-		return fmt.Errorf("nudge channel %q not found in messaging config", channelName)
+		return err
 	}
-
-	if len(patterns) == 0 {
-		return fmt.Errorf("nudge channel %q has no members", channelName)
+	if len(members) == 0 {
+		return fmt.Errorf("%s has no members", target)
 	}
 
-	// Identify sender for message prefix
-	sender := "unknown"
-	if roleInfo, err := GetRole(); err == nil {
-		switch roleInfo.Role {
-		case RoleMayor:
-			sender = "mayor"
-		case RoleCrew:
-			sender = fmt.Sprintf("%s/crew/%s", roleInfo.Rig, roleInfo.Polecat)
-		case RolePolecat:
-			sender = fmt.Sprintf("%s/%s", roleInfo.Rig, roleInfo.Polecat)
-		case RoleWitness:
-			sender = fmt.Sprintf("%s/witness", roleInfo.Rig)
-		case RoleRefinery:
-			sender = fmt.Sprintf("%s/refinery", roleInfo.Rig)
-		case RoleDeacon:
-			sender = "deacon"
-		default:
-			sender = string(roleInfo.Role)
-		}
-	}
-
-	// Prefix message with sender
+	sender := detectNudgeSender()
 	prefixedMessage := fmt.Sprintf("[from %s] %s", sender, message)
 
 	// Get all running sessions for pattern matching
@@ -285,65 +285,178 @@ func runNudgeChannel(channelName, message string) error {
 	if err != nil {
 		return fmt.Errorf("listing sessions: %w", err)
 	}
+	targets := resolveNudgeTargets(members, agents)
+	if len(targets) == 0 {
+		fmt.Printf("%s No running sessions match %s\n", style.WarningPrefix, target)
+		return nil
+	}
 
-	// Resolve patterns to session names
-	var targets []string
-	seenTargets := make(map[string]bool)
+	fmt.Printf("Nudging %s (%d target(s))...\n\n", target, len(targets))
 
-	for _, pattern := range patterns {
-		resolved := resolveNudgePattern(pattern, agents)
-		for _, sessionName := range resolved {
-			if !seenTargets[sessionName] {
-				seenTargets[sessionName] = true
-				targets = append(targets, sessionName)
-			}
+	t := tmux.NewTmux()
+	results := broadcastNudge(targets,
+		func(nt nudgeTarget) (bool, string) {
+			ok, level, _ := shouldNudgeTarget(townRoot, nt.Address, nudgeForceFlag)
+			return ok, level
+		},
+		func(nt nudgeTarget) error {
+			return t.NudgeSession(nt.Session, prefixedMessage)
+		})
+
+	var sent, skipped, failed int
+	for _, r := range results {
+		switch {
+		case r.Sent:
+			sent++
+			fmt.Printf("  %s %s\n", style.SuccessPrefix, r.Address)
+			_ = LogNudge(townRoot, r.Address, prefixedMessage)
+			_ = events.LogFeed(events.TypeNudge, sender, events.NudgePayload(r.Rig, r.Address, message))
+			countWitnessNudge(townRoot, sender)
+		case r.Err != nil:
+			failed++
+			fmt.Printf("  %s %s %s\n", style.ErrorPrefix, r.Address, style.Dim.Render(r.Err.Error()))
+		default:
+			skipped++
+			fmt.Printf("  %s %s %s\n", style.Dim.Render("○"), r.Address, style.Dim.Render("DND ("+r.Skipped+")"))
 		}
 	}
+	fmt.Println()
 
-	if len(targets) == 0 {
-		fmt.Printf("%s No sessions match channel %q patterns\n", style.WarningPrefix, channelName)
-		return nil
+	summary := fmt.Sprintf("%d nudged", sent)
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped (DND, use --force to override)", skipped)
+	}
+	if failed > 0 {
+		fmt.Printf("%s %s: %s, %d failed\n", style.WarningPrefix, target, summary, failed)
+		return fmt.Errorf("%d nudge(s) failed", failed)
 	}
+	fmt.Printf("%s %s: %s\n", style.SuccessPrefix, target, summary)
+	return nil
+}
 
-	// Send nudges
-	t := tmux.NewTmux()
-	var succeeded, failed int
-	var failures []string
+// resolveNudgeMembers returns the member addresses and patterns of a
+// broadcast target: a nudge channel, or else a @group address.
+func resolveNudgeMembers(router *mail.Router, target string) ([]string, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(target, "channel:"), "@")
+	members, err := router.ExpandNudgeChannel(name)
+	if err == nil || !errors.Is(err, mail.ErrUnknownNudgeChannel) {
+		return members, err
+	}
+	if strings.HasPrefix(target, "channel:") {
+		return nil, fmt.Errorf("nudge channel %q not found in messaging config", name)
+	}
 
-	fmt.Printf("Nudging channel %q (%d target(s))...\n\n", channelName, len(targets))
+	members, groupErr := router.ResolveGroupAddress(target)
+	if groupErr != nil {
+		return nil, fmt.Errorf("%s is not a nudge channel or group: %w", target, groupErr)
+	}
+	return members, nil
+}
 
-	for i, sessionName := range targets {
-		if err := t.NudgeSession(sessionName, prefixedMessage); err != nil {
-			failed++
-			failures = append(failures, fmt.Sprintf("%s: %v", sessionName, err))
-			fmt.Printf("  %s %s\n", style.ErrorPrefix, sessionName)
-		} else {
-			succeeded++
-			fmt.Printf("  %s %s\n", style.SuccessPrefix, sessionName)
-		}
+// resolveNudgeTargets matches channel members against running sessions,
+// once per session.
+func resolveNudgeTargets(members []string, agents []*AgentSession) []nudgeTarget {
+	bySession := make(map[string]*AgentSession, len(agents))
+	for _, a := range agents {
+		bySession[a.Name] = a
+	}
 
-		// Small delay between nudges
-		if i < len(targets)-1 {
-			time.Sleep(100 * time.Millisecond)
+	var targets []nudgeTarget
+	seen := make(map[string]bool)
+	for _, member := range members {
+		for _, sessionName := range resolveNudgePattern(member, agents) {
+			agent, running := bySession[sessionName]
+			if !running || seen[sessionName] {
+				continue
+			}
+			seen[sessionName] = true
+			targets = append(targets, nudgeTarget{
+				Address: agentSessionAddress(agent),
+				Rig:     agent.Rig,
+				Session: sessionName,
+			})
 		}
 	}
+	return targets
+}
 
-	fmt.Println()
+// agentSessionAddress returns the address of a running agent, in the form
+// shouldNudgeTarget understands.
+func agentSessionAddress(a *AgentSession) string {
+	switch a.Type {
+	case AgentMayor:
+		return "mayor"
+	case AgentDeacon:
+		return "deacon"
+	case AgentWitness:
+		return a.Rig + "/witness"
+	case AgentRefinery:
+		return a.Rig + "/refinery"
+	case AgentCrew:
+		return a.Rig + "/crew/" + a.AgentName
+	default:
+		return a.Rig + "/" + a.AgentName
+	}
+}
 
-	// Log nudge event
-	_ = events.LogFeed(events.TypeNudge, sender, events.NudgePayload("", "channel:"+channelName, message))
+// broadcastNudge nudges targets concurrently, running at most
+// nudgeBroadcastConcurrency nudges at once and starting at most one per
+// nudgeBroadcastInterval. Targets that allow rejects (DND) are skipped.
+// Results are in target order.
+func broadcastNudge(targets []nudgeTarget, allow func(nudgeTarget) (bool, string), send func(nudgeTarget) error) []nudgeResult {
+	results := make([]nudgeResult, len(targets))
+	limiter := time.NewTicker(nudgeBroadcastInterval)
+	defer limiter.Stop()
+
+	sem := make(chan struct{}, nudgeBroadcastConcurrency)
+	var wg sync.WaitGroup
+	for i, nt := range targets {
+		results[i].nudgeTarget = nt
+		if ok, level := allow(nt); !ok {
+			results[i].Skipped = level
+			continue
+		}
 
-	if failed > 0 {
-		fmt.Printf("%s Channel nudge complete: %d succeeded, %d failed\n",
-			style.WarningPrefix, succeeded, failed)
-		for _, f := range failures {
-			fmt.Printf("  %s\n", style.Dim.Render(f))
+		if i > 0 {
+			<-limiter.C
 		}
-		return fmt.Errorf("%d nudge(s) failed", failed)
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(r *nudgeResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if r.Err = send(r.nudgeTarget); r.Err == nil {
+				r.Sent = true
+			}
+		}(&results[i])
 	}
+	wg.Wait()
+	return results
+}
 
-	fmt.Printf("%s Channel nudge complete: %d target(s) nudged\n", style.SuccessPrefix, succeeded)
-	return nil
+// detectNudgeSender returns the sender named in a nudge's "[from ...]"
+// prefix.
+func detectNudgeSender() string {
+	roleInfo, err := GetRole()
+	if err != nil {
+		return "unknown"
+	}
+	switch roleInfo.Role {
+	case RoleMayor:
+		return "mayor"
+	case RoleCrew:
+		return fmt.Sprintf("%s/crew/%s", roleInfo.Rig, roleInfo.Polecat)
+	case RolePolecat:
+		return fmt.Sprintf("%s/%s", roleInfo.Rig, roleInfo.Polecat)
+	case RoleWitness:
+		return fmt.Sprintf("%s/witness", roleInfo.Rig)
+	case RoleRefinery:
+		return fmt.Sprintf("%s/refinery", roleInfo.Rig)
+	case RoleDeacon:
+		return "deacon"
+	default:
+		return string(roleInfo.Role)
+	}
 }
 
 // resolveNudgePattern resolves a nudge channel pattern to session names.
@@ -352,12 +465,15 @@ func runNudgeChannel(channelName, message string) error {
 //   - Wildcard: "gastown/polecats/*" → all polecat sessions in gastown
 //   - Role: "*/witness" → all witness sessions
 //   - Special: "mayor", "deacon" → gt-{town}-mayor, gt-{town}-deacon
+//   - Address: "gastown/max" → the crew member or polecat named max, as
+//     @group addresses resolve to
+//
 // townName is used to generate the correct session names for mayor/deacon.
 func resolveNudgePattern(pattern string, agents []*AgentSession) []string {
 	var results []string
 
-	// Handle special cases
-	switch pattern {
+	// Handle special cases ("mayor/" and "deacon/" are their mail addresses)
+	switch strings.TrimSuffix(pattern, "/") {
 	case "mayor":
 		return []string{session.MayorSessionName()}
 	case "deacon":
@@ -408,8 +524,8 @@ func resolveNudgePattern(pattern string, agents []*AgentSession) []string {
 				continue
 			}
 		} else {
-			// Assume it's a polecat name (legacy short format)
-			if agent.Type != AgentPolecat || agent.AgentName != targetPattern {
+			// Polecat or crew name (legacy short format, and mail addresses)
+			if (agent.Type != AgentPolecat && agent.Type != AgentCrew) || agent.AgentName != targetPattern {
 				continue
 			}
 		}
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"errors"
+	"sync"
 	"testing"
+
+	"github.com/steveyegge/gastown/internal/telemetry"
 )
 
 func TestResolveNudgePattern(t *testing.T) {
@@ -74,6 +78,16 @@ func TestResolveNudgePattern(t *testing.T) {
 			pattern:  "gastown/alpha",
 			expected: []string{"gt-gastown-alpha"},
 		},
+		{
+			name:     "crew mail address",
+			pattern:  "gastown/max",
+			expected: []string{"gt-gastown-crew-max"},
+		},
+		{
+			name:     "mayor mail address",
+			pattern:  "mayor/",
+			expected: []string{"hq-mayor"},
+		},
 		{
 			name:     "no matches",
 			pattern:  "nonexistent/polecats/*",
@@ -110,3 +124,89 @@ func TestResolveNudgePattern(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveNudgeTargets(t *testing.T) {
+	// The mayor is not running
+	agents := []*AgentSession{
+		{Name: "gt-gastown-witness", Type: AgentWitness, Rig: "gastown"},
+		{Name: "gt-gastown-crew-max", Type: AgentCrew, Rig: "gastown", AgentName: "max"},
+		{Name: "gt-gastown-alpha", Type: AgentPolecat, Rig: "gastown", AgentName: "alpha"},
+	}
+
+	targets := resolveNudgeTargets([]string{"gastown/crew/*", "gastown/max", "*/witness", "mayor/", "gastown/alpha"}, agents)
+	want := []nudgeTarget{
+		{Address: "gastown/crew/max", Rig: "gastown", Session: "gt-gastown-crew-max"},
+		{Address: "gastown/witness", Rig: "gastown", Session: "gt-gastown-witness"},
+		{Address: "gastown/alpha", Rig: "gastown", Session: "gt-gastown-alpha"},
+	}
+	if len(targets) != len(want) {
+		t.Fatalf("resolveNudgeTargets = %+v, want %+v", targets, want)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Errorf("target %d = %+v, want %+v", i, targets[i], want[i])
+		}
+	}
+}
+
+func TestBroadcastNudge(t *testing.T) {
+	targets := []nudgeTarget{
+		{Address: "gastown/alpha", Session: "gt-gastown-alpha"},
+		{Address: "gastown/beta", Session: "gt-gastown-beta"},
+		{Address: "gastown/crew/max", Session: "gt-gastown-crew-max"},
+		{Address: "gastown/gamma", Session: "gt-gastown-gamma"},
+	}
+
+	var mu sync.Mutex
+	var sent []string
+	results := broadcastNudge(targets,
+		func(nt nudgeTarget) (bool, string) {
+			if nt.Address == "gastown/crew/max" {
+				return false, "muted"
+			}
+			return true, ""
+		},
+		func(nt nudgeTarget) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, nt.Session)
+			if nt.Address == "gastown/beta" {
+				return errors.New("no session")
+			}
+			return nil
+		})
+
+	if len(sent) != 3 {
+		t.Errorf("sent to %v, want all but the muted member", sent)
+	}
+	if len(results) != len(targets) {
+		t.Fatalf("got %d results, want %d", len(results), len(targets))
+	}
+	for i, r := range results {
+		if r.nudgeTarget != targets[i] {
+			t.Errorf("result %d is for %s, want %s (results in target order)", i, r.Address, targets[i].Address)
+		}
+	}
+	if !results[0].Sent || !results[3].Sent {
+		t.Errorf("results = %+v, want alpha and gamma sent", results)
+	}
+	if results[1].Sent || results[1].Err == nil {
+		t.Errorf("beta = %+v, want failed", results[1])
+	}
+	if results[2].Sent || results[2].Err != nil || results[2].Skipped != "muted" {
+		t.Errorf("max = %+v, want skipped as muted", results[2])
+	}
+}
+
+func TestCountWitnessNudge(t *testing.T) {
+	town := t.TempDir()
+
+	countWitnessNudge(town, "gastown/witness")
+	countWitnessNudge(town, "gastown/witness")
+	countWitnessNudge(town, "mayor")
+	countWitnessNudge(town, "gastown/crew/max")
+
+	if got := metricValue(t, town, telemetry.MetricWitnessNudges); got != 2 {
+		t.Errorf("%s = %v, want only the witness's 2 nudges", telemetry.MetricWitnessNudges, got)
+	}
+}
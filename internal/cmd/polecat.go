@@ -12,6 +12,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/polecat"
 	"github.com/steveyegge/gastown/internal/rig"
@@ -320,20 +321,21 @@ type PolecatListItem struct {
 }
 
 // getPolecatManager creates a polecat manager for the given rig.
+// rigName may carry a machine prefix ("vm:myrig") to target a remote rig.
 func getPolecatManager(rigName string) (*polecat.Manager, *rig.Rig, error) {
-	_, r, err := getRig(rigName)
+	_, r, conn, err := getRigWithConnection(rigName)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	polecatGit := git.NewGit(r.Path)
-	mgr := polecat.NewManager(r, polecatGit)
+	mgr := polecat.NewManagerWithConnection(r, conn)
 
 	return mgr, r, nil
 }
 
 func runPolecatList(cmd *cobra.Command, args []string) error {
 	var rigs []*rig.Rig
+	var conn connection.Connection = connection.NewLocalConnection()
 
 	if polecatListAll {
 		// List all rigs
@@ -347,21 +349,20 @@ func runPolecatList(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return fmt.Errorf("rig name required (or use --all)")
 		}
-		_, r, err := getPolecatManager(args[0])
+		_, r, rigConn, err := getRigWithConnection(args[0])
 		if err != nil {
 			return err
 		}
 		rigs = []*rig.Rig{r}
+		conn = rigConn
 	}
 
 	// Collect polecats from all rigs
-	t := tmux.NewTmux()
 	var allPolecats []PolecatListItem
 
 	for _, r := range rigs {
-		polecatGit := git.NewGit(r.Path)
-		mgr := polecat.NewManager(r, polecatGit)
-		sessMgr := session.NewManager(t, r)
+		mgr := polecat.NewManagerWithConnection(r, conn)
+		sessMgr := session.NewManagerWithConnection(conn, r)
 
 		polecats, err := mgr.List()
 		if err != nil {
@@ -3,9 +3,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/steveyegge/gastown/internal/account"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/events"
@@ -25,6 +28,13 @@ type SpawnedPolecatInfo struct {
 	ClonePath   string // Path to polecat's git worktree
 	SessionName string // Tmux session name (e.g., "gt-gastown-p-Toast")
 	Pane        string // Tmux pane ID
+	Machine     string // Machine hosting the polecat (empty = local)
+	Headless    bool   // Agent runs as a supervised subprocess (no session)
+
+	tmux            *tmux.Tmux // Tmux server hosting the session
+	townRoot        string
+	rigPath         string
+	claudeConfigDir string // Account config for headless runs
 }
 
 // AgentID returns the agent identifier (e.g., "gastown/polecats/Toast")
@@ -32,42 +42,59 @@ func (s *SpawnedPolecatInfo) AgentID() string {
 	return fmt.Sprintf("%s/polecats/%s", s.RigName, s.PolecatName)
 }
 
+// IsLocal reports whether the polecat was spawned on this machine.
+func (s *SpawnedPolecatInfo) IsLocal() bool {
+	return s.Machine == ""
+}
+
+// Tmux returns the tmux server hosting the polecat's session.
+func (s *SpawnedPolecatInfo) Tmux() *tmux.Tmux {
+	if s.tmux == nil {
+		return tmux.NewTmux()
+	}
+	return s.tmux
+}
+
 // SlingSpawnOptions contains options for spawning a polecat via sling.
 type SlingSpawnOptions struct {
 	Force    bool   // Force spawn even if polecat has uncommitted work
 	Naked    bool   // No-tmux mode: skip session creation
+	Headless bool   // Run the agent non-interactively under gt (no tmux)
 	Account  string // Claude Code account handle to use
 	Create   bool   // Create polecat if it doesn't exist (currently always true for sling)
 	HookBead string // Bead ID to set as hook_bead at spawn time (atomic assignment)
 }
 
 // SpawnPolecatForSling creates a fresh polecat and optionally starts its session.
-// This is used by gt sling when the target is a rig name.
+// This is used by gt sling when the target is a rig name. rigName may carry
+// a machine prefix ("vm:myrig") to spawn on a registered remote machine.
 // The caller (sling) handles hook attachment and nudging.
 func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolecatInfo, error) {
-	// Find workspace
-	townRoot, err := workspace.FindFromCwdOrError()
+	townRoot, r, conn, err := getRigWithConnection(rigName)
 	if err != nil {
-		return nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+		return nil, err
+	}
+	machine, _, _ := splitMachinePrefix(rigName)
+	if conn.IsLocal() {
+		machine = ""
+	}
+	if opts.Headless && machine != "" {
+		return nil, fmt.Errorf("--headless is only supported for local rigs")
 	}
 
-	// Load rig config
-	rigsConfigPath := filepath.Join(townRoot, "mayor", "rigs.json")
-	rigsConfig, err := config.LoadRigsConfig(rigsConfigPath)
+	// Resolve account for Claude config
+	claudeConfigDir, accountHandle, err := resolveSpawnAccount(townRoot, opts.Account)
 	if err != nil {
-		rigsConfig = &config.RigsConfig{Rigs: make(map[string]config.RigEntry)}
+		return nil, fmt.Errorf("resolving account: %w", err)
 	}
 
-	g := git.NewGit(townRoot)
-	rigMgr := rig.NewManager(townRoot, rigsConfig, g)
-	r, err := rigMgr.GetRig(rigName)
-	if err != nil {
-		return nil, fmt.Errorf("rig '%s' not found", rigName)
+	// Refuse to spawn into a scope whose budget is exhausted
+	if err := checkSpawnBudget(townRoot, r.Name, accountHandle, opts.HookBead); err != nil {
+		return nil, err
 	}
 
 	// Get polecat manager
-	polecatGit := git.NewGit(r.Path)
-	polecatMgr := polecat.NewManager(r, polecatGit)
+	polecatMgr := polecat.NewManagerWithConnection(r, conn)
 
 	// Allocate a new polecat name
 	polecatName, err := polecatMgr.AllocateName()
@@ -87,7 +114,7 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 	if err == nil {
 		// Stale state: polecat exists despite fresh name allocation - repair it
 		// Check for uncommitted work first
-		if !opts.Force {
+		if !opts.Force && conn.IsLocal() {
 			pGit := git.NewGit(existingPolecat.ClonePath)
 			workStatus, checkErr := pGit.CheckUncommittedWork()
 			if checkErr == nil && !workStatus.Clean() {
@@ -128,27 +155,39 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 		fmt.Printf("Agent will discover work via gt prime on startup.\n")
 
 		return &SpawnedPolecatInfo{
-			RigName:     rigName,
+			RigName:     r.Name,
 			PolecatName: polecatName,
 			ClonePath:   polecatObj.ClonePath,
 			SessionName: "", // No session in naked mode
 			Pane:        "", // No pane in naked mode
+			Machine:     machine,
 		}, nil
 	}
 
-	// Resolve account for Claude config
-	accountsPath := constants.MayorAccountsPath(townRoot)
-	claudeConfigDir, accountHandle, err := config.ResolveAccountConfigDir(accountsPath, opts.Account)
-	if err != nil {
-		return nil, fmt.Errorf("resolving account: %w", err)
-	}
 	if accountHandle != "" {
 		fmt.Printf("Using account: %s\n", accountHandle)
 	}
+	if err := account.Assign(townRoot, account.PolecatIdentity(r.Name, polecatName), accountHandle); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: recording account for %s: %v\n", polecatName, err)
+	}
+
+	// Headless mode: the caller runs the agent once work is hooked
+	if opts.Headless {
+		fmt.Printf("%s Polecat %s created (headless)\n", style.Bold.Render("✓"), polecatName)
+		_ = events.LogFeed(events.TypeSpawn, "gt", events.SpawnPayload(rigName, polecatName))
+		return &SpawnedPolecatInfo{
+			RigName:         r.Name,
+			PolecatName:     polecatName,
+			ClonePath:       polecatObj.ClonePath,
+			Headless:        true,
+			townRoot:        townRoot,
+			rigPath:         r.Path,
+			claudeConfigDir: claudeConfigDir,
+		}, nil
+	}
 
-	// Start session
-	t := tmux.NewTmux()
-	sessMgr := session.NewManager(t, r)
+	// Start session on the rig's machine
+	sessMgr := session.NewManagerWithConnection(conn, r)
 
 	// Check if already running
 	running, _ := sessMgr.IsRunning(polecatName)
@@ -164,7 +203,8 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 
 	// Get session name and pane
 	sessionName := sessMgr.SessionName(polecatName)
-	pane, err := getSessionPane(sessionName)
+	t := sessMgr.Tmux()
+	pane, err := t.GetPaneID(sessionName)
 	if err != nil {
 		return nil, fmt.Errorf("getting pane for %s: %w", sessionName, err)
 	}
@@ -175,14 +215,36 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 	_ = events.LogFeed(events.TypeSpawn, "gt", events.SpawnPayload(rigName, polecatName))
 
 	return &SpawnedPolecatInfo{
-		RigName:     rigName,
+		RigName:     r.Name,
 		PolecatName: polecatName,
 		ClonePath:   polecatObj.ClonePath,
 		SessionName: sessionName,
 		Pane:        pane,
+		Machine:     machine,
+		tmux:        t,
 	}, nil
 }
 
+// resolveSpawnAccount resolves the Claude account for a new polecat. An
+// explicit GT_ACCOUNT or --account wins; otherwise the town's account policy
+// picks one, passing over accounts cooling down from a usage limit.
+func resolveSpawnAccount(townRoot, accountFlag string) (configDir, handle string, err error) {
+	accountsPath := constants.MayorAccountsPath(townRoot)
+	if accountFlag != "" || os.Getenv("GT_ACCOUNT") != "" {
+		return config.ResolveAccountConfigDir(accountsPath, accountFlag)
+	}
+
+	cfg, err := config.LoadAccountsConfig(accountsPath)
+	if err != nil {
+		return "", "", nil // No accounts configured
+	}
+	picked, err := account.Pick(townRoot, cfg, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: picking account: %v\n", err)
+	}
+	return config.ResolveAccountConfigDir(accountsPath, picked)
+}
+
 // IsRigName checks if a target string is a rig name (not a role or path).
 // Returns the rig name and true if it's a valid rig.
 func IsRigName(target string) (string, bool) {
@@ -197,6 +259,14 @@ func IsRigName(target string) (string, bool) {
 		return "", false
 	}
 
+	// machine:rig targets a rig on a registered machine
+	if machine, _, ok := splitMachinePrefix(target); ok && machine != "local" {
+		if _, _, _, err := getRigWithConnection(target); err != nil {
+			return "", false
+		}
+		return target, true
+	}
+
 	// Try to load as a rig
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/mrqueue"
@@ -205,6 +209,51 @@ Examples:
 
 var refineryBlockedJSON bool
 
+var refineryFlakyCmd = &cobra.Command{
+	Use:   "flaky [rig]",
+	Short: "List flaky tests seen by the refinery",
+	Long: `List the rig's flaky-test ledger, most flaky first.
+
+With merge_queue.test_format set, the refinery records each failing test
+it sees: a test that fails and then passes on one of the
+merge_queue.retry_flaky_tests retries counts as a flake, one that fails
+every attempt as a failure.
+
+Examples:
+  gt refinery flaky
+  gt refinery flaky --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryFlaky,
+}
+
+var refineryFlakyJSON bool
+
+var refineryProcessCmd = &cobra.Command{
+	Use:   "process [rig]",
+	Short: "Merge ready MRs, up to max_concurrent at a time",
+	Long: `Process the ready merge requests for a rig.
+
+Claims up to merge_queue.max_concurrent ready MRs at once. Each MR is merged
+and tested in its own scratch worktree; merges then land on the target
+branch one at a time (a merge train). Failed MRs are released back to the
+queue.
+
+When merge_queue.batch_size is greater than 1, the top batch_size MRs are
+instead merged together and tested once. A failing batch is bisected (up to
+merge_queue.max_bisect_depth halvings) to find the culprits; the rest land
+and each culprit is reported to the witness with MERGE_FAILED.
+
+With --watch, keeps polling the queue every poll_interval until interrupted.
+
+Examples:
+  gt refinery process
+  gt refinery process greenplace --watch`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryProcess,
+}
+
+var refineryProcessWatch bool
+
 func init() {
 	// Start flags
 	refineryStartCmd.Flags().BoolVar(&refineryForeground, "foreground", false, "Run in foreground (default: background)")
@@ -224,6 +273,12 @@ func init() {
 	// Blocked flags
 	refineryBlockedCmd.Flags().BoolVar(&refineryBlockedJSON, "json", false, "Output as JSON")
 
+	// Flaky flags
+	refineryFlakyCmd.Flags().BoolVar(&refineryFlakyJSON, "json", false, "Output as JSON")
+
+	// Process flags
+	refineryProcessCmd.Flags().BoolVar(&refineryProcessWatch, "watch", false, "Keep polling the queue until interrupted")
+
 	// Add subcommands
 	refineryCmd.AddCommand(refineryStartCmd)
 	refineryCmd.AddCommand(refineryStopCmd)
@@ -236,6 +291,8 @@ func init() {
 	refineryCmd.AddCommand(refineryUnclaimedCmd)
 	refineryCmd.AddCommand(refineryReadyCmd)
 	refineryCmd.AddCommand(refineryBlockedCmd)
+	refineryCmd.AddCommand(refineryFlakyCmd)
+	refineryCmd.AddCommand(refineryProcessCmd)
 
 	rootCmd.AddCommand(refineryCmd)
 }
@@ -707,3 +764,82 @@ func runRefineryBlocked(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runRefineryFlaky(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	tests, err := refinery.NewEngineer(r).FlakyTests()
+	if err != nil {
+		return fmt.Errorf("reading flaky-test ledger: %w", err)
+	}
+
+	// JSON output
+	if refineryFlakyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tests)
+	}
+
+	// Human-readable output
+	fmt.Printf("%s Flaky tests for '%s':\n\n", style.Bold.Render("🎲"), rigName)
+
+	if len(tests) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(none recorded)"))
+		return nil
+	}
+
+	for i, t := range tests {
+		fmt.Printf("  %d. %s\n", i+1, t.ID)
+		fmt.Printf("     Flakes: %d  Failures: %d", t.Flakes, t.Failures)
+		if !t.LastFlake.IsZero() {
+			fmt.Printf("  Last flake: %s", t.LastFlake.Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func runRefineryProcess(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if refineryProcessWatch {
+		fmt.Printf("%s Processing merge queue for '%s' (max %d concurrent, Ctrl-C to stop)\n",
+			style.Bold.Render("🚂"), rigName, eng.Config().MaxConcurrent)
+		if err := eng.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		return nil
+	}
+
+	n, err := eng.ProcessQueue(ctx)
+	if err != nil {
+		return fmt.Errorf("processing merge queue: %w", err)
+	}
+	fmt.Printf("%s Processed %d MR(s) for '%s'\n", style.Bold.Render("✓"), n, rigName)
+	return nil
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
@@ -14,6 +16,10 @@ import (
 // This is the common boilerplate extracted from get*Manager functions.
 // Returns the town root path and rig instance.
 func getRig(rigName string) (string, *rig.Rig, error) {
+	if machine, _, ok := splitMachinePrefix(rigName); ok && machine != "local" {
+		return "", nil, fmt.Errorf("rig '%s' is on machine '%s': this command only supports local rigs", rigName, machine)
+	}
+
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
 		return "", nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
@@ -27,10 +33,74 @@ func getRig(rigName string) (string, *rig.Rig, error) {
 
 	g := git.NewGit(townRoot)
 	rigMgr := rig.NewManager(townRoot, rigsConfig, g)
-	r, err := rigMgr.GetRig(rigName)
+	r, err := rigMgr.GetRig(strings.TrimPrefix(rigName, "local:"))
 	if err != nil {
 		return "", nil, fmt.Errorf("rig '%s' not found", rigName)
 	}
 
 	return townRoot, r, nil
 }
+
+// getRigWithConnection resolves a rig that may live on another machine.
+// rigName is either "rig" or "machine:rig"; machines are looked up in the
+// town's mayor/machines.json. Returns the local town root, the rig as seen
+// on its machine, and the connection used to reach it.
+func getRigWithConnection(rigName string) (string, *rig.Rig, connection.Connection, error) {
+	machine, name, ok := splitMachinePrefix(rigName)
+	if !ok || machine == "local" {
+		townRoot, r, err := getRig(name)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return townRoot, r, connection.NewLocalConnection(), nil
+	}
+
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	registry, err := connection.NewMachineRegistry(constants.MayorMachinesPath(townRoot))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	m, err := registry.Get(machine)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if m.TownPath == "" {
+		return "", nil, nil, fmt.Errorf("machine '%s' has no town_path configured", machine)
+	}
+	conn, err := registry.Connection(machine)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	rigsConfig := &config.RigsConfig{Rigs: make(map[string]config.RigEntry)}
+	if data, err := conn.ReadFile(constants.MayorRigsPath(m.TownPath)); err == nil {
+		if parsed, err := config.ParseRigsConfig(data); err == nil {
+			rigsConfig = parsed
+		}
+	}
+
+	rigMgr := rig.NewManagerWithConnection(m.TownPath, rigsConfig, conn)
+	r, err := rigMgr.GetRig(name)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("rig '%s' not found on machine '%s'", name, machine)
+	}
+
+	return townRoot, r, conn, nil
+}
+
+// splitMachinePrefix splits "machine:rig" into its parts. ok is false when
+// rigName has no machine prefix, in which case name is rigName unchanged.
+func splitMachinePrefix(rigName string) (machine, name string, ok bool) {
+	if !strings.Contains(rigName, ":") {
+		return "", rigName, false
+	}
+	addr, err := connection.ParseAddress(rigName)
+	if err != nil {
+		return "", rigName, false
+	}
+	return addr.Machine, addr.Rig, true
+}
@@ -6,6 +6,10 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/ptyd"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 var rootCmd = &cobra.Command{
@@ -16,6 +20,26 @@ var rootCmd = &cobra.Command{
 
 It coordinates agent spawning, work distribution, and communication
 across distributed teams of AI agents working on shared codebases.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		configureSessionBackend()
+	},
+}
+
+// configureSessionBackend routes session operations to the town's session
+// backend. Towns using the pty backend reach the daemon's PTY supervisor;
+// everything else (including running outside a town) uses tmux.
+func configureSessionBackend() {
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil || townRoot == "" {
+		return
+	}
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil {
+		return
+	}
+	if settings.SessionBackend == config.SessionBackendPTY {
+		tmux.SetDefaultBackend(ptyd.NewClient(ptyd.SocketPath(townRoot)))
+	}
 }
 
 // Execute runs the root command and returns an exit code.
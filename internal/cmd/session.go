@@ -79,7 +79,8 @@ var sessionAtCmd = &cobra.Command{
 	Short:   "Attach to a running session",
 	Long: `Attach to a running polecat session.
 
-Attaches the current terminal to the tmux session. Detach with Ctrl-B D.`,
+Attaches the current terminal to the session. Detach with Ctrl-B D
+(tmux) or Ctrl-] (pty session backend).`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSessionAttach,
 }
@@ -224,14 +225,14 @@ func parseAddress(addr string) (rigName, polecatName string, err error) {
 }
 
 // getSessionManager creates a session manager for the given rig.
+// rigName may carry a machine prefix ("vm:myrig") to target a remote rig.
 func getSessionManager(rigName string) (*session.Manager, *rig.Rig, error) {
-	_, r, err := getRig(rigName)
+	_, r, conn, err := getRigWithConnection(rigName)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	t := tmux.NewTmux()
-	mgr := session.NewManager(t, r)
+	mgr := session.NewManagerWithConnection(conn, r)
 
 	return mgr, r, nil
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
@@ -55,6 +56,7 @@ Target Resolution:
 Spawning Options (when target is a rig):
   gt sling gp-abc greenplace --create               # Create polecat if missing
   gt sling gp-abc greenplace --naked                # No-tmux (manual start)
+  gt sling gp-abc greenplace --headless             # Run agent non-interactively (no tmux)
   gt sling gp-abc greenplace --force                # Ignore unread mail
   gt sling gp-abc greenplace --account work         # Use specific Claude account
 
@@ -62,6 +64,13 @@ Natural Language Args:
   gt sling gt-abc --args "patch release"
   gt sling code-review --args "focus on security"
 
+Headless Mode:
+  --headless runs the polecat's agent as a subprocess of gt sling using the
+  agent's non-interactive mode (claude -p, codex exec, gemini -p). Its
+  structured output goes to logs/polecats/<rig>-<polecat>.log and the
+  events feed, and gt done runs when it exits. gt sling waits for the
+  agent, so batch work in CI needs no terminal multiplexer.
+
 The --args string is stored in the bead and shown via gt prime. Since the
 executor is an LLM, it interprets these instructions naturally.
 
@@ -99,6 +108,7 @@ var (
 
 	// Flags migrated for polecat spawning (used by sling for work assignment
 	slingNaked    bool   // --naked: no-tmux mode (skip session creation)
+	slingHeadless bool   // --headless: run agent as a supervised subprocess
 	slingCreate   bool   // --create: create polecat if it doesn't exist
 	slingForce    bool   // --force: force spawn even if polecat has unread mail
 	slingAccount  string // --account: Claude Code account handle to use
@@ -115,6 +125,7 @@ func init() {
 
 	// Flags for polecat spawning (when target is a rig)
 	slingCmd.Flags().BoolVar(&slingNaked, "naked", false, "No-tmux mode: assign work but skip session creation (manual start)")
+	slingCmd.Flags().BoolVar(&slingHeadless, "headless", false, "Run the polecat's agent non-interactively under gt (no tmux); waits for it to finish")
 	slingCmd.Flags().BoolVar(&slingCreate, "create", false, "Create polecat if it doesn't exist")
 	slingCmd.Flags().BoolVar(&slingForce, "force", false, "Force spawn even if polecat has unread mail")
 	slingCmd.Flags().StringVar(&slingAccount, "account", "", "Claude Code account handle to use")
@@ -136,12 +147,17 @@ func runSling(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("finding town root: %w", err)
 	}
 	townBeadsDir := filepath.Join(townRoot, ".beads")
+	span := telemetry.StartSpan("gt sling", nil)
 
 	// --var is only for standalone formula mode, not formula-on-bead mode
 	if slingOnTarget != "" && len(slingVars) > 0 {
 		return fmt.Errorf("--var cannot be used with --on (formula-on-bead mode doesn't support variables)")
 	}
 
+	if slingHeadless && slingNaked {
+		return fmt.Errorf("--headless and --naked are mutually exclusive")
+	}
+
 	// Batch mode detection: multiple beads with rig target
 	// Pattern: gt sling gt-abc gt-def gt-ghi gastown
 	// When len(args) > 2 and last arg is a rig, sling each bead to its own polecat
@@ -186,10 +202,21 @@ func runSling(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if slingHeadless {
+		if len(args) < 2 {
+			return fmt.Errorf("--headless requires a rig target")
+		}
+		if _, isRig := IsRigName(args[1]); !isRig {
+			return fmt.Errorf("--headless requires a rig target, not %q", args[1])
+		}
+	}
+
 	// Determine target agent (self or specified)
 	var targetAgent string
 	var targetPane string
-	var hookWorkDir string // Working directory for running bd hook commands
+	var targetTmux *tmux.Tmux        // nil = local tmux server
+	var hookWorkDir string           // Working directory for running bd hook commands
+	var headless *SpawnedPolecatInfo // Set when the polecat runs headless
 
 	if len(args) > 1 {
 		target := args[1]
@@ -230,6 +257,9 @@ func runSling(cmd *cobra.Command, args []string) error {
 				if slingNaked {
 					fmt.Printf("  --naked: would skip tmux session\n")
 				}
+				if slingHeadless {
+					fmt.Printf("  --headless: would run agent non-interactively and wait for it\n")
+				}
 				targetAgent = fmt.Sprintf("%s/polecats/<new>", rigName)
 				targetPane = "<new-pane>"
 			} else {
@@ -238,6 +268,7 @@ func runSling(cmd *cobra.Command, args []string) error {
 				spawnOpts := SlingSpawnOptions{
 					Force:    slingForce,
 					Naked:    slingNaked,
+					Headless: slingHeadless,
 					Account:  slingAccount,
 					Create:   slingCreate,
 					HookBead: beadID, // Set atomically at spawn time
@@ -248,7 +279,13 @@ func runSling(cmd *cobra.Command, args []string) error {
 				}
 				targetAgent = spawnInfo.AgentID()
 				targetPane = spawnInfo.Pane
-				hookWorkDir = spawnInfo.ClonePath // Run bd commands from polecat's worktree
+				targetTmux = spawnInfo.Tmux()
+				if spawnInfo.IsLocal() {
+					hookWorkDir = spawnInfo.ClonePath // Run bd commands from polecat's worktree
+				}
+				if spawnInfo.Headless {
+					headless = spawnInfo
+				}
 
 				// Wake witness and refinery to monitor the new polecat
 				wakeRigAgents(rigName)
@@ -301,6 +338,7 @@ func runSling(cmd *cobra.Command, args []string) error {
 
 	// Auto-convoy: check if issue is already tracked by a convoy
 	// If not, create one for dashboard visibility (unless --no-convoy is set)
+	var convoyID string
 	if !slingNoConvoy && formulaName == "" {
 		existingConvoy := isTrackedByConvoy(beadID)
 		if existingConvoy == "" {
@@ -308,16 +346,18 @@ func runSling(cmd *cobra.Command, args []string) error {
 				fmt.Printf("Would create convoy 'Work: %s'\n", info.Title)
 				fmt.Printf("Would add tracking relation to %s\n", beadID)
 			} else {
-				convoyID, err := createAutoConvoy(beadID, info.Title)
+				newConvoy, err := createAutoConvoy(beadID, info.Title)
 				if err != nil {
 					// Log warning but don't fail - convoy is optional
 					fmt.Printf("%s Could not create auto-convoy: %v\n", style.Dim.Render("Warning:"), err)
 				} else {
+					convoyID = newConvoy
 					fmt.Printf("%s Created convoy 🚚 %s\n", style.Bold.Render("→"), convoyID)
 					fmt.Printf("  Tracking: %s\n", beadID)
 				}
 			}
 		} else {
+			convoyID = existingConvoy
 			fmt.Printf("%s Already tracked by convoy %s\n", style.Dim.Render("○"), existingConvoy)
 		}
 	}
@@ -427,7 +467,12 @@ func runSling(cmd *cobra.Command, args []string) error {
 
 	// Log sling event to activity feed
 	actor := detectActor()
-	_ = events.LogFeed(events.TypeSling, actor, events.SlingPayload(beadID, targetAgent))
+	payload := events.SlingPayload(beadID, targetAgent)
+	if convoyID != "" {
+		payload["convoy"] = convoyID
+	}
+	_ = events.LogFeed(events.TypeSling, actor, payload)
+	recordSlingTelemetry(span, beadID, targetAgent, convoyID)
 
 	// Update agent bead's hook_bead field (ZFC: agents track their current work)
 	updateAgentHookBead(targetAgent, beadID, hookWorkDir, townBeadsDir)
@@ -448,10 +493,15 @@ func runSling(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Headless polecats get their prompt on the command line
+	if headless != nil {
+		return runHeadlessPolecat(headless, beadID, slingSubject, slingArgs)
+	}
+
 	// Try to inject the "start now" prompt (graceful if no tmux)
 	if targetPane == "" {
 		fmt.Printf("%s No pane to nudge (agent will discover work via gt prime)\n", style.Dim.Render("○"))
-	} else if err := injectStartPrompt(targetPane, beadID, slingSubject, slingArgs); err != nil {
+	} else if err := injectStartPrompt(targetTmux, targetPane, beadID, slingSubject, slingArgs); err != nil {
 		// Graceful fallback for no-tmux mode
 		fmt.Printf("%s Could not nudge (no tmux?): %v\n", style.Dim.Render("○"), err)
 		fmt.Printf("  Agent will discover work via gt prime / bd show\n")
@@ -552,7 +602,8 @@ func storeDispatcherInBead(beadID, dispatcher string) error {
 
 // injectStartPrompt sends a prompt to the target pane to start working.
 // Uses the reliable nudge pattern: literal mode + 500ms debounce + separate Enter.
-func injectStartPrompt(pane, beadID, subject, args string) error {
+// A nil t means the local tmux server.
+func injectStartPrompt(t *tmux.Tmux, pane, beadID, subject, args string) error {
 	if pane == "" {
 		return fmt.Errorf("no target pane")
 	}
@@ -573,7 +624,9 @@ func injectStartPrompt(pane, beadID, subject, args string) error {
 	}
 
 	// Use the reliable nudge pattern (same as gt nudge / tmux.NudgeSession)
-	t := tmux.NewTmux()
+	if t == nil {
+		t = tmux.NewTmux()
+	}
 	return t.NudgePane(pane, prompt)
 }
 
@@ -732,12 +785,17 @@ func verifyFormulaExists(formulaName string) error {
 func runSlingFormula(args []string) error {
 	formulaName := args[0]
 
+	if slingHeadless {
+		return fmt.Errorf("--headless is not supported when slinging a formula")
+	}
+
 	// Get town root early - needed for BEADS_DIR when running bd commands
 	townRoot, err := workspace.FindFromCwd()
 	if err != nil {
 		return fmt.Errorf("finding town root: %w", err)
 	}
 	townBeadsDir := filepath.Join(townRoot, ".beads")
+	span := telemetry.StartSpan("gt sling", telemetry.Labels{"formula": formulaName})
 
 	// Determine target (self or specified)
 	var target string
@@ -748,6 +806,7 @@ func runSlingFormula(args []string) error {
 	// Resolve target agent and pane
 	var targetAgent string
 	var targetPane string
+	var targetTmux *tmux.Tmux // nil = local tmux server
 
 	if target != "" {
 		// Resolve "." to current agent identity (like git's "." meaning current directory)
@@ -803,6 +862,7 @@ func runSlingFormula(args []string) error {
 				}
 				targetAgent = spawnInfo.AgentID()
 				targetPane = spawnInfo.Pane
+				targetTmux = spawnInfo.Tmux()
 
 				// Wake witness and refinery to monitor the new polecat
 				wakeRigAgents(rigName)
@@ -892,6 +952,7 @@ func runSlingFormula(args []string) error {
 	payload := events.SlingPayload(wispResult.RootID, targetAgent)
 	payload["formula"] = formulaName
 	_ = events.LogFeed(events.TypeSling, actor, payload)
+	recordSlingTelemetry(span, wispResult.RootID, targetAgent, "")
 
 	// Update agent bead's hook_bead field (ZFC: agents track their current work)
 	// Note: formula slinging uses town root as workDir (no polecat-specific path)
@@ -924,7 +985,10 @@ func runSlingFormula(args []string) error {
 	} else {
 		prompt = fmt.Sprintf("Formula %s slung. Run `gt hook` to see your hook, then execute the steps.", formulaName)
 	}
-	t := tmux.NewTmux()
+	t := targetTmux
+	if t == nil {
+		t = tmux.NewTmux()
+	}
 	if err := t.NudgePane(targetPane, prompt); err != nil {
 		// Graceful fallback for no-tmux mode
 		fmt.Printf("%s Could not nudge (no tmux?): %v\n", style.Dim.Render("○"), err)
@@ -1003,6 +1067,20 @@ func wakeRigAgents(rigName string) {
 	_ = t.NudgeSession(refinerySession, "Polecat dispatched - check for merge requests")
 }
 
+// recordSlingTelemetry counts a completed sling and finishes its span,
+// placing it in the trace of the convoy (or, failing that, the bead) that
+// later spans for the same work join.
+func recordSlingTelemetry(span *telemetry.Span, beadID, targetAgent, convoyID string) {
+	rig := events.ActorRig(targetAgent)
+	telemetry.Count(telemetry.MetricSlings, telemetry.Labels{"rig": rig})
+	span.SetTrace(convoyID, beadID)
+	span.SetAttr("bead", beadID)
+	span.SetAttr("convoy", convoyID)
+	span.SetAttr("target", targetAgent)
+	span.SetAttr("rig", rig)
+	span.Finish(nil)
+}
+
 // detectActor returns the current agent's actor string for event logging.
 func detectActor() string {
 	roleInfo, err := GetRole()
@@ -1049,7 +1127,6 @@ func agentIDToBeadID(agentID, townRoot string) string {
 	}
 }
 
-
 // IsDogTarget checks if target is a dog target pattern.
 // Returns the dog name (or empty for pool dispatch) and true if it's a dog target.
 // Patterns:
@@ -1304,6 +1381,9 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		if slingNaked {
 			fmt.Printf("  --naked: would skip tmux sessions\n")
 		}
+		if slingHeadless {
+			fmt.Printf("  --headless: would run agents non-interactively and wait for them\n")
+		}
 		return nil
 	}
 
@@ -1317,6 +1397,7 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		errMsg  string
 	}
 	results := make([]slingResult, 0, len(beadIDs))
+	var headlessRuns []headlessRun
 
 	// Spawn a polecat for each bead and sling it
 	for i, beadID := range beadIDs {
@@ -1340,6 +1421,7 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		spawnOpts := SlingSpawnOptions{
 			Force:    slingForce,
 			Naked:    slingNaked,
+			Headless: slingHeadless,
 			Account:  slingAccount,
 			Create:   slingCreate,
 			HookBead: beadID, // Set atomically at spawn time
@@ -1352,7 +1434,10 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		}
 
 		targetAgent := spawnInfo.AgentID()
-		hookWorkDir := spawnInfo.ClonePath
+		hookWorkDir := ""
+		if spawnInfo.IsLocal() {
+			hookWorkDir = spawnInfo.ClonePath
+		}
 
 		// Auto-convoy: check if issue is already tracked
 		if !slingNoConvoy {
@@ -1403,9 +1488,11 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 			}
 		}
 
-		// Nudge the polecat
-		if spawnInfo.Pane != "" {
-			if err := injectStartPrompt(spawnInfo.Pane, beadID, slingSubject, slingArgs); err != nil {
+		// Nudge the polecat (headless polecats run once all are hooked)
+		if spawnInfo.Headless {
+			headlessRuns = append(headlessRuns, headlessRun{info: spawnInfo, beadID: beadID})
+		} else if spawnInfo.Pane != "" {
+			if err := injectStartPrompt(spawnInfo.Tmux(), spawnInfo.Pane, beadID, slingSubject, slingArgs); err != nil {
 				fmt.Printf("  %s Could not nudge (agent will discover via gt prime)\n", style.Dim.Render("○"))
 			} else {
 				fmt.Printf("  %s Start prompt sent\n", style.Bold.Render("▶"))
@@ -1435,6 +1522,15 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 		}
 	}
 
+	if len(headlessRuns) > 0 {
+		fmt.Printf("\n%s Running %d headless polecats...\n", style.Bold.Render("▶"), len(headlessRuns))
+		completed := runHeadlessBatch(headlessRuns)
+		fmt.Printf("\n%s Headless work complete: %d/%d completed\n", style.Bold.Render("📊"), completed, len(headlessRuns))
+		if completed < len(headlessRuns) {
+			return NewSilentExit(1)
+		}
+	}
+
 	return nil
 }
 
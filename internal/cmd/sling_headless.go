@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/agent"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// maxOutputLine bounds a single line of agent output. Stream-JSON lines
+// carry whole tool results, so this is generous.
+const maxOutputLine = 16 * 1024 * 1024
+
+// maxEventText bounds the text summary copied into each output event.
+const maxEventText = 200
+
+// headlessLogPath returns where a headless polecat's output is kept.
+func headlessLogPath(townRoot, rigName, polecatName string) string {
+	return filepath.Join(townRoot, "logs", "polecats", fmt.Sprintf("%s-%s.log", rigName, polecatName))
+}
+
+// headlessPrompt builds the prompt for a headless run. Unlike the start
+// nudge, it has to say everything up front: nobody can answer follow-up
+// questions, and gt (not the agent) runs gt done.
+func headlessPrompt(beadID, subject, args string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Work slung: %s", beadID)
+	if subject != "" {
+		fmt.Fprintf(&b, " (%s)", subject)
+	}
+	b.WriteString(". Run `gt hook` to see the hook, then do the work.")
+	if args != "" {
+		fmt.Fprintf(&b, " Args: %s - use these args to guide your execution.", args)
+	}
+	b.WriteString(" You are running headless: there is no terminal and nobody will answer questions." +
+		" Commit your work and push your branch (git push -u origin HEAD) before you finish." +
+		" Do not run gt done; it runs automatically when you exit.")
+	return b.String()
+}
+
+// headlessEnv returns the environment for a headless polecat, matching what
+// a polecat's tmux session exports.
+func headlessEnv(info *SpawnedPolecatInfo) []string {
+	env := append(os.Environ(),
+		"GT_ROLE=polecat",
+		"GT_RIG="+info.RigName,
+		"GT_POLECAT="+info.PolecatName,
+		"GT_HEADLESS=1",
+		"BD_ACTOR="+info.AgentID(),
+		"GIT_AUTHOR_NAME="+info.PolecatName,
+		"BEADS_DIR="+filepath.Join(info.townRoot, ".beads"),
+		"BEADS_NO_DAEMON=1",
+		"BEADS_AGENT_NAME="+info.RigName+"/"+info.PolecatName,
+	)
+	if info.claudeConfigDir != "" {
+		env = append(env, "CLAUDE_CONFIG_DIR="+info.claudeConfigDir)
+	}
+	return env
+}
+
+// runHeadlessPolecat runs a headless polecat's agent to completion, then
+// records the outcome with gt done: COMPLETED when the agent exits cleanly
+// and its branch can be submitted, ESCALATED otherwise.
+func runHeadlessPolecat(info *SpawnedPolecatInfo, beadID, subject, args string) error {
+	resolved := config.ResolveAgent(info.townRoot, info.rigPath)
+	ni := config.NonInteractiveFor(resolved)
+	if ni == nil {
+		return fmt.Errorf("agent %q has no non-interactive mode (set non_interactive in its agent config)", resolved.Name)
+	}
+	if err := agent.AdapterFor(resolved).EnsureRoleSettings(info.ClonePath, "polecat"); err != nil {
+		return fmt.Errorf("ensuring agent settings: %w", err)
+	}
+
+	logPath := headlessLogPath(info.townRoot, info.RigName, info.PolecatName)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening polecat log: %w", err)
+	}
+	defer logFile.Close()
+
+	argv := ni.BuildArgs(resolved.Runtime, headlessPrompt(beadID, subject, args))
+	cmd := exec.Command(argv[0], argv[1:]...) //nolint:gosec // G204: argv comes from the agent config
+	cmd.Dir = info.ClonePath
+	cmd.Env = headlessEnv(info)
+	log := &lockedWriter{w: logFile}
+	cmd.Stderr = log
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("creating output pipe: %w", err)
+	}
+
+	fmt.Printf("%s Running %s headless (%s)\n", style.Bold.Render("▶"), info.AgentID(), resolved.Name)
+	fmt.Printf("  Log: %s\n", logPath)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", argv[0], err)
+	}
+	streamHeadlessOutput(stdout, log, info.RigName, info.PolecatName)
+
+	exitCode := 0
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("waiting for %s: %w", argv[0], err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	status := finishHeadlessPolecat(info, exitCode, log)
+	_ = events.LogFeed(events.TypeAgentExit, info.AgentID(),
+		events.AgentExitPayload(info.RigName, info.PolecatName, exitCode, status))
+
+	if status != ExitCompleted {
+		return fmt.Errorf("%s exited with code %d (%s); see %s", info.AgentID(), exitCode, status, logPath)
+	}
+	fmt.Printf("%s %s finished: %s\n", style.Bold.Render("✓"), info.AgentID(), status)
+	return nil
+}
+
+// finishHeadlessPolecat runs gt done in the polecat's worktree and returns
+// the exit status it recorded.
+func finishHeadlessPolecat(info *SpawnedPolecatInfo, exitCode int, log io.Writer) string {
+	runDone := func(status string) error {
+		doneCmd := exec.Command("gt", "done", "--status", status)
+		doneCmd.Dir = info.ClonePath
+		doneCmd.Env = headlessEnv(info)
+		doneCmd.Stdout, doneCmd.Stderr = log, log
+		return doneCmd.Run()
+	}
+
+	if exitCode == 0 {
+		err := runDone(ExitCompleted)
+		if err == nil {
+			return ExitCompleted
+		}
+		fmt.Printf("  %s Could not submit %s: %v\n", style.Dim.Render("Warning:"), info.AgentID(), err)
+	}
+	if err := runDone(ExitEscalated); err != nil {
+		fmt.Printf("  %s Could not record %s for %s: %v\n", style.Dim.Render("Warning:"), ExitEscalated, info.AgentID(), err)
+	}
+	return ExitEscalated
+}
+
+// streamHeadlessOutput copies an agent's output to its log and reports
+// each structured (JSON) line to the events log. Lines with readable text
+// are feed-visible; the rest are audit-only.
+func streamHeadlessOutput(r io.Reader, log io.Writer, rigName, polecatName string) {
+	agentID := fmt.Sprintf("%s/polecats/%s", rigName, polecatName)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxOutputLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		_, _ = log.Write(append(append([]byte(nil), line...), '\n'))
+
+		kind, text, ok := summarizeAgentOutput(line)
+		if !ok {
+			continue
+		}
+		visibility := events.VisibilityAudit
+		if text != "" {
+			visibility = events.VisibilityFeed
+		}
+		_ = events.Log(events.TypeAgentOutput, agentID,
+			events.AgentOutputPayload(rigName, polecatName, kind, text), visibility)
+	}
+	// Keep draining after an over-long line so the agent never blocks.
+	_, _ = io.Copy(log, r)
+}
+
+// summarizeAgentOutput extracts the type and a short text summary from one
+// line of an agent's JSON output. It understands the common shapes of
+// Claude stream-json, Codex exec --json and Gemini JSON output; ok is false
+// for lines that are not JSON objects.
+func summarizeAgentOutput(line []byte) (kind, text string, ok bool) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(line, &m); err != nil {
+		return "", "", false
+	}
+	kind, _ = m["type"].(string)
+	if kind == "" {
+		kind = "output"
+	}
+
+	text = firstText(m, "result", "response", "text")
+	if text == "" {
+		if item, isMap := m["item"].(map[string]interface{}); isMap {
+			text = firstText(item, "text")
+		}
+	}
+	if text == "" {
+		switch msg := m["message"].(type) {
+		case string:
+			text = msg
+		case map[string]interface{}:
+			text = contentText(msg["content"])
+		}
+	}
+	return kind, truncateText(strings.TrimSpace(text), maxEventText), true
+}
+
+// firstText returns the first non-empty string field of m among keys.
+func firstText(m map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// contentText returns the first text block of a message's content list.
+func contentText(content interface{}) string {
+	blocks, ok := content.([]interface{})
+	if !ok {
+		s, _ := content.(string)
+		return s
+	}
+	for _, b := range blocks {
+		if block, ok := b.(map[string]interface{}); ok && block["type"] == "text" {
+			if s, ok := block["text"].(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// truncateText shortens s to at most n runes, marking the cut.
+func truncateText(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// lockedWriter serializes writes from the output stream and stderr.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// runHeadlessBatch runs headless polecats concurrently and reports how
+// many finished their work.
+func runHeadlessBatch(runs []headlessRun) int {
+	var wg sync.WaitGroup
+	errs := make([]error, len(runs))
+	for i, run := range runs {
+		wg.Add(1)
+		go func(i int, run headlessRun) {
+			defer wg.Done()
+			errs[i] = runHeadlessPolecat(run.info, run.beadID, slingSubject, slingArgs)
+		}(i, run)
+	}
+	wg.Wait()
+
+	completed := 0
+	for i, err := range errs {
+		if err != nil {
+			fmt.Printf("  %s %s: %v\n", style.Dim.Render("✗"), runs[i].beadID, err)
+			continue
+		}
+		completed++
+	}
+	return completed
+}
+
+// headlessRun is a polecat waiting to run headless on a bead.
+type headlessRun struct {
+	info   *SpawnedPolecatInfo
+	beadID string
+}
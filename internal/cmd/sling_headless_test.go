@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeAgentOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantKind string
+		wantText string
+		wantOK   bool
+	}{
+		{
+			name:     "claude assistant message",
+			line:     `{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Bash"},{"type":"text","text":"Running tests"}]}}`,
+			wantKind: "assistant",
+			wantText: "Running tests",
+			wantOK:   true,
+		},
+		{
+			name:     "claude result",
+			line:     `{"type":"result","subtype":"success","result":"Done.\n"}`,
+			wantKind: "result",
+			wantText: "Done.",
+			wantOK:   true,
+		},
+		{
+			name:     "codex item",
+			line:     `{"type":"item.completed","item":{"type":"agent_message","text":"Pushed branch"}}`,
+			wantKind: "item.completed",
+			wantText: "Pushed branch",
+			wantOK:   true,
+		},
+		{
+			name:     "gemini response",
+			line:     `{"response":"All set"}`,
+			wantKind: "output",
+			wantText: "All set",
+			wantOK:   true,
+		},
+		{
+			name:     "no text",
+			line:     `{"type":"system","subtype":"init"}`,
+			wantKind: "system",
+			wantOK:   true,
+		},
+		{
+			name: "plain text",
+			line: "warning: something",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, text, ok := summarizeAgentOutput([]byte(tt.line))
+			if kind != tt.wantKind || text != tt.wantText || ok != tt.wantOK {
+				t.Errorf("summarizeAgentOutput = (%q, %q, %v), want (%q, %q, %v)",
+					kind, text, ok, tt.wantKind, tt.wantText, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSummarizeAgentOutput_Truncates(t *testing.T) {
+	line := `{"type":"result","result":"` + strings.Repeat("x", 500) + `"}`
+	_, text, _ := summarizeAgentOutput([]byte(line))
+	if n := len([]rune(text)); n != maxEventText {
+		t.Errorf("summary is %d runes, want %d", n, maxEventText)
+	}
+}
+
+func TestStreamHeadlessOutput_CopiesEveryLine(t *testing.T) {
+	// Outside a town, events are dropped; the log must still get every line.
+	t.Chdir(t.TempDir())
+	input := "{\"type\":\"system\"}\nplain line\n{\"type\":\"result\",\"result\":\"ok\"}\n"
+	var log bytes.Buffer
+	streamHeadlessOutput(strings.NewReader(input), &log, "gastown", "Toast")
+	if log.String() != input {
+		t.Errorf("log = %q, want %q", log.String(), input)
+	}
+}
+
+func TestHeadlessPrompt(t *testing.T) {
+	prompt := headlessPrompt("gt-abc", "Fix login", "patch release")
+	for _, want := range []string{"gt-abc", "Fix login", "patch release", "gt hook", "Do not run gt done"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
@@ -173,7 +173,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	// Fetch town-level agent beads (Mayor, Deacon) from town beads
 	townBeadsPath := beads.GetTownBeadsPath(townRoot)
-	townBeadsClient := beads.New(townBeadsPath)
+	townBeadsClient := beads.New(townBeadsPath).Cached()
 	townAgentBeads, _ := townBeadsClient.ListAgentBeads()
 	for id, issue := range townAgentBeads {
 		allAgentBeads[id] = issue
@@ -203,7 +203,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	// Fetch rig-level agent beads
 	for _, r := range rigs {
 		rigBeadsPath := filepath.Join(r.Path, "mayor", "rig")
-		rigBeads := beads.New(rigBeadsPath)
+		rigBeads := beads.New(rigBeadsPath).Cached()
 		rigAgentBeads, _ := rigBeads.ListAgentBeads()
 		if rigAgentBeads == nil {
 			continue
@@ -900,7 +900,7 @@ func getMQSummary(r *rig.Rig) *MQSummary {
 	}
 
 	// Create beads instance for the rig
-	b := beads.New(r.BeadsPath())
+	b := beads.New(r.BeadsPath()).Cached()
 
 	// Query for all open merge-request type issues
 	opts := beads.ListOptions{
@@ -140,7 +140,7 @@ func runSynthesisStart(cmd *cobra.Command, args []string) error {
 	// Load formula if specified
 	var f *formula.Formula
 	if meta.FormulaPath != "" {
-		f, err = formula.ParseFile(meta.FormulaPath)
+		f, err = formula.ParseFile(meta.FormulaPath, formulaSearchPaths()...)
 		if err != nil {
 			return fmt.Errorf("loading formula: %w", err)
 		}
@@ -148,7 +148,7 @@ func runSynthesisStart(cmd *cobra.Command, args []string) error {
 		// Try to find formula by name
 		formulaPath, findErr := findFormula(meta.Formula)
 		if findErr == nil {
-			f, err = formula.ParseFile(formulaPath)
+			f, err = formula.ParseFile(formulaPath, formulaSearchPaths()...)
 			if err != nil {
 				return fmt.Errorf("loading formula: %w", err)
 			}
@@ -250,10 +250,10 @@ func runSynthesisStatus(cmd *cobra.Command, args []string) error {
 	// Load formula if available
 	var f *formula.Formula
 	if meta.FormulaPath != "" {
-		f, _ = formula.ParseFile(meta.FormulaPath)
+		f, _ = formula.ParseFile(meta.FormulaPath, formulaSearchPaths()...)
 	} else if meta.Formula != "" {
 		if path, err := findFormula(meta.Formula); err == nil {
-			f, _ = formula.ParseFile(path)
+			f, _ = formula.ParseFile(path, formulaSearchPaths()...)
 		}
 	}
 
@@ -431,9 +431,14 @@ func collectLegOutputs(meta *ConvoyMeta, f *formula.Formula) ([]LegOutput, bool,
 		}
 	}
 
-	// If we have a formula, also try to find output files
+	// If we have a formula, also try to find output files.
+	// Aspect formulas write their outputs with the same leg pattern.
 	if f != nil && f.Output != nil && meta.ReviewID != "" {
-		for _, leg := range f.Legs {
+		legs := f.Legs
+		for _, aspect := range f.Aspects {
+			legs = append(legs, formula.Leg{ID: aspect.ID, Title: aspect.Title})
+		}
+		for _, leg := range legs {
 			// Expand output path template
 			outputPath := expandOutputPath(f.Output.Directory, f.Output.LegPattern,
 				meta.ReviewID, leg.ID)
@@ -469,13 +474,14 @@ func collectLegOutputs(meta *ConvoyMeta, f *formula.Formula) ([]LegOutput, bool,
 }
 
 // expandOutputPath expands template variables in output paths.
-// Supports: {{review_id}}, {{leg.id}}
+// Supports: {{review_id}}, {{leg.id}} (or {{aspect.id}} for aspect formulas)
 func expandOutputPath(directory, pattern, reviewID, legID string) string {
 	// Expand directory
 	dir := strings.ReplaceAll(directory, "{{review_id}}", reviewID)
 
 	// Expand pattern
 	file := strings.ReplaceAll(pattern, "{{leg.id}}", legID)
+	file = strings.ReplaceAll(file, "{{aspect.id}}", legID)
 
 	return filepath.Join(dir, file)
 }
@@ -650,10 +656,10 @@ func TriggerSynthesisIfReady(convoyID, targetRig string) error {
 	// Load formula if available
 	var f *formula.Formula
 	if meta.FormulaPath != "" {
-		f, _ = formula.ParseFile(meta.FormulaPath)
+		f, _ = formula.ParseFile(meta.FormulaPath, formulaSearchPaths()...)
 	} else if meta.Formula != "" {
 		if path, err := findFormula(meta.Formula); err == nil {
-			f, _ = formula.ParseFile(path)
+			f, _ = formula.ParseFile(path, formulaSearchPaths()...)
 		}
 	}
 
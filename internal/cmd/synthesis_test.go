@@ -37,6 +37,14 @@ func TestExpandOutputPath(t *testing.T) {
 			legID:     "performance",
 			want:      "reviews/pr-123/findings/leg-performance-analysis.md",
 		},
+		{
+			name:      "aspect id",
+			directory: ".audits/{{review_id}}",
+			pattern:   "{{aspect.id}}.md",
+			reviewID:  "abc123",
+			legID:     "crypto",
+			want:      ".audits/abc123/crypto.md",
+		},
 	}
 
 	for _, tt := range tests {
@@ -297,6 +297,47 @@ func RuntimeConfigFromPreset(preset AgentPreset) *RuntimeConfig {
 	}
 }
 
+// claudeNonInteractive is Claude's print mode. The Claude preset leaves
+// NonInteractive unset because print mode is built into its CLI.
+var claudeNonInteractive = NonInteractiveConfig{
+	PromptFlag: "-p",
+	OutputFlag: "--output-format stream-json --verbose",
+}
+
+// NonInteractiveFor returns how to run a resolved agent without a terminal,
+// or nil if the agent has no non-interactive mode.
+func NonInteractiveFor(resolved *ResolvedAgent) *NonInteractiveConfig {
+	if resolved == nil {
+		ni := claudeNonInteractive
+		return &ni
+	}
+	if resolved.Preset != nil && resolved.Preset.NonInteractive != nil {
+		return resolved.Preset.NonInteractive
+	}
+	if resolved.Runtime == nil || filepath.Base(resolved.Runtime.Command) == "claude" {
+		ni := claudeNonInteractive
+		return &ni
+	}
+	return nil
+}
+
+// BuildArgs returns the argv that runs rc non-interactively on prompt:
+// command, subcommand, the runtime's args, output flags, then the prompt
+// (after PromptFlag, or as the last positional argument).
+func (ni *NonInteractiveConfig) BuildArgs(rc *RuntimeConfig, prompt string) []string {
+	rc = fillRuntimeDefaults(rc)
+	argv := []string{rc.Command}
+	if ni.Subcommand != "" {
+		argv = append(argv, ni.Subcommand)
+	}
+	argv = append(argv, rc.Args...)
+	argv = append(argv, strings.Fields(ni.OutputFlag)...)
+	if ni.PromptFlag != "" {
+		argv = append(argv, ni.PromptFlag)
+	}
+	return append(argv, prompt)
+}
+
 // BuildResumeCommand builds a command to resume an agent session.
 // Returns the full command string including any YOLO/autonomous flags.
 // If sessionID is empty or the agent doesn't support resume, returns empty string.
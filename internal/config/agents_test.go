@@ -317,3 +317,43 @@ func TestGetSessionIDEnvVar(t *testing.T) {
 		})
 	}
 }
+
+func TestNonInteractiveBuildArgs(t *testing.T) {
+	tests := []struct {
+		agent string
+		want  []string
+	}{
+		{"claude", []string{"claude", "--dangerously-skip-permissions", "--output-format", "stream-json", "--verbose", "-p", "do it"}},
+		{"gemini", []string{"gemini", "--approval-mode", "yolo", "--output-format", "json", "-p", "do it"}},
+		{"codex", []string{"codex", "exec", "--yolo", "--json", "do it"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.agent, func(t *testing.T) {
+			resolved := &ResolvedAgent{
+				Name:    tt.agent,
+				Runtime: RuntimeConfigFromPreset(AgentPreset(tt.agent)),
+				Preset:  GetAgentPresetByName(tt.agent),
+			}
+			ni := NonInteractiveFor(resolved)
+			if ni == nil {
+				t.Fatalf("NonInteractiveFor(%s) = nil", tt.agent)
+			}
+			got := ni.BuildArgs(resolved.Runtime, "do it")
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("BuildArgs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonInteractiveFor_CustomAgent(t *testing.T) {
+	custom := &ResolvedAgent{Name: "aider", Runtime: &RuntimeConfig{Command: "aider"}}
+	if ni := NonInteractiveFor(custom); ni != nil {
+		t.Errorf("NonInteractiveFor(aider) = %+v, want nil", ni)
+	}
+
+	wrapped := &ResolvedAgent{Name: "claude-work", Runtime: &RuntimeConfig{Command: "/opt/bin/claude"}}
+	if ni := NonInteractiveFor(wrapped); ni == nil || ni.PromptFlag != "-p" {
+		t.Errorf("NonInteractiveFor(custom claude) = %+v, want print mode", ni)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/testreport"
+	"github.com/steveyegge/gastown/internal/util"
 )
 
 var (
@@ -79,6 +82,12 @@ func LoadRigsConfig(path string) (*RigsConfig, error) {
 		return nil, fmt.Errorf("reading config: %w", err)
 	}
 
+	return ParseRigsConfig(data)
+}
+
+// ParseRigsConfig parses and validates rigs registry content.
+// Used when the registry was read through a connection rather than from disk.
+func ParseRigsConfig(data []byte) (*RigsConfig, error) {
 	var config RigsConfig
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
@@ -223,6 +232,12 @@ func validateMergeQueueConfig(c *MergeQueueConfig) error {
 			ErrInvalidOnConflict, c.OnConflict, OnConflictAssignBack, OnConflictAutoRebase)
 	}
 
+	// Validate test_format
+	if c.TestFormat != "" && !testreport.ValidFormat(c.TestFormat) {
+		return fmt.Errorf("invalid test_format: got '%s', want '%s' or '%s'",
+			c.TestFormat, testreport.FormatGoJSON, testreport.FormatJUnit)
+	}
+
 	// Validate poll_interval if specified
 	if c.PollInterval != "" {
 		if _, err := time.ParseDuration(c.PollInterval); err != nil {
@@ -237,6 +252,12 @@ func validateMergeQueueConfig(c *MergeQueueConfig) error {
 	if c.MaxConcurrent < 0 {
 		return fmt.Errorf("%w: max_concurrent must be non-negative", ErrMissingField)
 	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("%w: batch_size must be non-negative", ErrMissingField)
+	}
+	if c.MaxBisectDepth < 0 {
+		return fmt.Errorf("%w: max_bisect_depth must be non-negative", ErrMissingField)
+	}
 
 	return nil
 }
@@ -271,6 +292,12 @@ func LoadRigSettings(path string) (*RigSettings, error) {
 		return nil, fmt.Errorf("reading settings: %w", err)
 	}
 
+	return ParseRigSettings(data)
+}
+
+// ParseRigSettings parses and validates rig settings content.
+// Used when the settings were read through a connection rather than from disk.
+func ParseRigSettings(data []byte) (*RigSettings, error) {
 	var settings RigSettings
 	if err := json.Unmarshal(data, &settings); err != nil {
 		return nil, fmt.Errorf("parsing settings: %w", err)
@@ -426,11 +453,20 @@ func validateAccountsConfig(c *AccountsConfig) error {
 			return fmt.Errorf("%w: default account '%s' not found in accounts", ErrMissingField, c.Default)
 		}
 	}
+	switch c.Policy {
+	case "", AccountPolicyRoundRobin, AccountPolicyLeastRecentlyLimited, AccountPolicyWeighted:
+	default:
+		return fmt.Errorf("invalid account policy '%s': want %s, %s or %s", c.Policy,
+			AccountPolicyRoundRobin, AccountPolicyLeastRecentlyLimited, AccountPolicyWeighted)
+	}
 	// Validate each account has required fields
 	for handle, acct := range c.Accounts {
 		if acct.ConfigDir == "" {
 			return fmt.Errorf("%w: config_dir for account '%s'", ErrMissingField, handle)
 		}
+		if acct.Weight < 0 {
+			return fmt.Errorf("account '%s': weight must not be negative", handle)
+		}
 	}
 	return nil
 }
@@ -459,6 +495,17 @@ func (c *AccountsConfig) GetDefaultAccount() *Account {
 	return c.GetAccount(c.Default)
 }
 
+// ConfigDirs maps each account handle to its expanded config directory.
+func (c *AccountsConfig) ConfigDirs() map[string]string {
+	dirs := make(map[string]string, len(c.Accounts))
+	for handle, acct := range c.Accounts {
+		if acct.ConfigDir != "" {
+			dirs[handle] = expandPath(acct.ConfigDir)
+		}
+	}
+	return dirs
+}
+
 // ResolveAccountConfigDir resolves the CLAUDE_CONFIG_DIR for account selection.
 // Priority order:
 //  1. GT_ACCOUNT environment variable
@@ -581,6 +628,9 @@ func validateMessagingConfig(c *MessagingConfig) error {
 	if c.NudgeChannels == nil {
 		c.NudgeChannels = make(map[string][]string)
 	}
+	if c.Schedules == nil {
+		c.Schedules = make(map[string]MailSchedule)
+	}
 
 	// Validate lists have at least one recipient
 	for name, recipients := range c.Lists {
@@ -619,6 +669,20 @@ func validateMessagingConfig(c *MessagingConfig) error {
 		}
 	}
 
+	// Validate schedules have a valid cron expression, a list or announce
+	// target, and a subject
+	for name, sched := range c.Schedules {
+		if _, err := util.ParseCron(sched.Cron); err != nil {
+			return fmt.Errorf("schedule '%s': %w", name, err)
+		}
+		if !strings.HasPrefix(sched.To, "list:") && !strings.HasPrefix(sched.To, "announce:") {
+			return fmt.Errorf("%w: schedule '%s' to must be list:<name> or announce:<name>", ErrMissingField, name)
+		}
+		if sched.Subject == "" {
+			return fmt.Errorf("%w: schedule '%s' subject", ErrMissingField, name)
+		}
+	}
+
 	return nil
 }
 
@@ -957,6 +1021,13 @@ func BuildAgentStartupCommand(role, bdActor, rigPath, prompt string) string {
 // BuildPolecatStartupCommand builds the startup command for a polecat.
 // Sets GT_ROLE, GT_RIG, GT_POLECAT, BD_ACTOR, and GIT_AUTHOR_NAME.
 func BuildPolecatStartupCommand(rigName, polecatName, rigPath, prompt string) string {
+	return BuildPolecatStartupCommandWithAccount(rigName, polecatName, rigPath, "", prompt)
+}
+
+// BuildPolecatStartupCommandWithAccount builds a polecat's startup command
+// for a specific Claude account, exporting CLAUDE_CONFIG_DIR alongside the
+// polecat's identity (tmux session environment doesn't reach the first pane).
+func BuildPolecatStartupCommandWithAccount(rigName, polecatName, rigPath, claudeConfigDir, prompt string) string {
 	bdActor := fmt.Sprintf("%s/polecats/%s", rigName, polecatName)
 	envVars := map[string]string{
 		"GT_ROLE":         "polecat",
@@ -965,6 +1036,9 @@ func BuildPolecatStartupCommand(rigName, polecatName, rigPath, prompt string) st
 		"BD_ACTOR":        bdActor,
 		"GIT_AUTHOR_NAME": polecatName,
 	}
+	if claudeConfigDir != "" {
+		envVars["CLAUDE_CONFIG_DIR"] = claudeConfigDir
+	}
 	return BuildStartupCommand(envVars, rigPath, prompt)
 }
 
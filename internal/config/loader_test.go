@@ -310,6 +310,28 @@ func TestRigSettingsValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid test_format",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					TestFormat: "tap",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative batch_size",
+			settings: &RigSettings{
+				Type:    "rig-settings",
+				Version: 1,
+				MergeQueue: &MergeQueueConfig{
+					BatchSize: -1,
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -505,6 +527,28 @@ func TestAccountsConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid rotation policy",
+			config: &AccountsConfig{
+				Version: 1,
+				Accounts: map[string]Account{
+					"test": {ConfigDir: "~/.claude-accounts/test", Weight: 2},
+				},
+				Policy: AccountPolicyWeighted,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown rotation policy",
+			config: &AccountsConfig{
+				Version: 1,
+				Accounts: map[string]Account{
+					"test": {ConfigDir: "~/.claude-accounts/test"},
+				},
+				Policy: "random",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -703,6 +747,36 @@ func TestMessagingConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid schedule",
+			config: &MessagingConfig{
+				Version: 1,
+				Schedules: map[string]MailSchedule{
+					"standup": {Cron: "0 9 * * 1-5", To: "list:oncall", Subject: "Standup"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "schedule with invalid cron",
+			config: &MessagingConfig{
+				Version: 1,
+				Schedules: map[string]MailSchedule{
+					"standup": {Cron: "0 25 * * *", To: "list:oncall", Subject: "Standup"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "schedule to a single agent",
+			config: &MessagingConfig{
+				Version: 1,
+				Schedules: map[string]MailSchedule{
+					"standup": {Cron: "@daily", To: "mayor/", Subject: "Standup"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -914,6 +988,20 @@ func TestBuildPolecatStartupCommand(t *testing.T) {
 	}
 }
 
+func TestBuildPolecatStartupCommandWithAccount(t *testing.T) {
+	cmd := BuildPolecatStartupCommandWithAccount("gastown", "toast", "", "/home/u/.claude-accounts/work", "")
+
+	if !strings.Contains(cmd, "CLAUDE_CONFIG_DIR=/home/u/.claude-accounts/work") {
+		t.Error("expected CLAUDE_CONFIG_DIR in command")
+	}
+	if !strings.Contains(cmd, "GT_POLECAT=toast") {
+		t.Error("expected GT_POLECAT=toast in command")
+	}
+	if strings.Contains(BuildPolecatStartupCommand("gastown", "toast", "", ""), "CLAUDE_CONFIG_DIR") {
+		t.Error("expected no CLAUDE_CONFIG_DIR without an account")
+	}
+}
+
 func TestBuildCrewStartupCommand(t *testing.T) {
 	cmd := BuildCrewStartupCommand("gastown", "max", "", "")
 
@@ -48,8 +48,31 @@ type TownSettings struct {
 	// Values override or extend the built-in presets.
 	// Example: {"gemini": {"command": "/custom/path/to/gemini"}}
 	Agents map[string]*RuntimeConfig `json:"agents,omitempty"`
+
+	// SessionBackend selects what hosts agent sessions: "tmux" (default)
+	// or "pty" for a PTY supervisor run by the daemon, on hosts without tmux.
+	SessionBackend string `json:"session_backend,omitempty"`
+
+	// Budget limits agent spend across the whole town.
+	Budget *Budget `json:"budget,omitempty"`
+
+	// ConvoyBudgets limits spend per convoy, keyed by convoy ID. The key
+	// "*" applies to every convoy without its own entry.
+	ConvoyBudgets map[string]*Budget `json:"convoy_budgets,omitempty"`
+
+	// Notifications are sinks the daemon delivers town events to.
+	Notifications []*NotificationSink `json:"notifications,omitempty"`
+
+	// Telemetry configures where the daemon exports metrics and traces.
+	Telemetry *TelemetryConfig `json:"telemetry,omitempty"`
 }
 
+// Session backends for TownSettings.SessionBackend.
+const (
+	SessionBackendTmux = "tmux"
+	SessionBackendPTY  = "pty"
+)
+
 // NewTownSettings creates a new TownSettings with defaults.
 func NewTownSettings() *TownSettings {
 	return &TownSettings{
@@ -138,6 +161,110 @@ type RigSettings struct {
 	// If empty, uses the town's default_agent setting.
 	// Takes precedence over Runtime if both are set.
 	Agent string `json:"agent,omitempty"`
+
+	// Budget limits agent spend in this rig.
+	Budget *Budget `json:"budget,omitempty"`
+}
+
+// Budget caps agent spend (as recorded in the cost ledger) over a period.
+// Crossing the soft limit escalates; crossing the hard limit stops new
+// polecat spawns in the budget's scope and parks its running polecats.
+type Budget struct {
+	// Period is the window spend is measured over: "day" (default for
+	// town, rig and account budgets), "week", "month" or "total" (default
+	// for convoy budgets). Periods start at local midnight, on Monday and
+	// on the 1st respectively.
+	Period string `json:"period,omitempty"`
+
+	// SoftUSD and HardUSD are the thresholds in dollars; zero disables one.
+	SoftUSD float64 `json:"soft_usd,omitempty"`
+	HardUSD float64 `json:"hard_usd,omitempty"`
+}
+
+// Budget periods for Budget.Period.
+const (
+	BudgetPeriodDay   = "day"
+	BudgetPeriodWeek  = "week"
+	BudgetPeriodMonth = "month"
+	BudgetPeriodTotal = "total"
+)
+
+// NotificationSink is a destination for town events such as merges, merge
+// failures, escalations, convoy completions and polecat crashes. Secrets are
+// never stored here: sinks name the environment variables holding them.
+type NotificationSink struct {
+	// Name identifies the sink in the delivery log. Defaults to its type.
+	Name string `json:"name,omitempty"`
+
+	// Type is "webhook", "slack", "email" or "command".
+	Type string `json:"type"`
+
+	// Events are the event types delivered, as globs (e.g. "merge*").
+	// Empty means merged, merge_failed, escalation_sent, convoy_closed and
+	// polecat_crashed.
+	Events []string `json:"events,omitempty"`
+
+	// MinSeverity drops events below this severity (LOW, MEDIUM, HIGH or
+	// CRITICAL). Empty delivers every severity.
+	MinSeverity string `json:"min_severity,omitempty"`
+
+	// Filter is an additional gt events query events must match,
+	// e.g. "rig=gastown".
+	Filter string `json:"filter,omitempty"`
+
+	// URL is the endpoint for webhook and slack sinks.
+	URL string `json:"url,omitempty"`
+
+	// SecretEnv names the environment variable holding the webhook's HMAC
+	// signing secret. Unset sends unsigned requests.
+	SecretEnv string `json:"secret_env,omitempty"`
+
+	// SMTP, From and To configure email sinks.
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+	From string      `json:"from,omitempty"`
+	To   []string    `json:"to,omitempty"`
+
+	// Command is a shell command for command sinks. It receives the event
+	// as JSON on stdin and as GT_EVENT_* environment variables.
+	Command string `json:"command,omitempty"`
+
+	// MaxAttempts bounds delivery attempts per event (default 5).
+	MaxAttempts int `json:"max_attempts,omitempty"`
+}
+
+// SMTPConfig is the mail server an email sink sends through.
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"` // Default 587
+	Username string `json:"username,omitempty"`
+
+	// PasswordEnv names the environment variable holding the password.
+	PasswordEnv string `json:"password_env,omitempty"`
+}
+
+// Notification sink types for NotificationSink.Type.
+const (
+	SinkWebhook = "webhook"
+	SinkSlack   = "slack"
+	SinkEmail   = "email"
+	SinkCommand = "command"
+)
+
+// TelemetryConfig configures metric and trace export. Metrics are always
+// served by the dashboard at /metrics; exporters additionally receive spans.
+type TelemetryConfig struct {
+	// OTLPEndpoint is an OTLP/HTTP collector, e.g. "http://localhost:4318".
+	// Defaults to $OTEL_EXPORTER_OTLP_ENDPOINT.
+	OTLPEndpoint string `json:"otlp_endpoint,omitempty"`
+
+	// OTLPHeadersEnv names the environment variable holding request headers
+	// ("key=value,..."), such as credentials. Defaults to
+	// OTEL_EXPORTER_OTLP_HEADERS.
+	OTLPHeadersEnv string `json:"otlp_headers_env,omitempty"`
+
+	// File appends OTLP JSON payloads to a file (relative to the town root),
+	// for local inspection.
+	File string `json:"file,omitempty"`
 }
 
 // CrewConfig represents crew workspace settings for a rig.
@@ -296,11 +423,31 @@ type MergeQueueConfig struct {
 	// RetryFlakyTests is the number of times to retry flaky tests.
 	RetryFlakyTests int `json:"retry_flaky_tests"`
 
+	// TestFormat selects structured test output parsing: "go-json" or "junit".
+	// Empty means tests pass or fail on the command's exit status alone.
+	TestFormat string `json:"test_format,omitempty"`
+
+	// TestReport is the JUnit XML file written by the test command (junit format).
+	TestReport string `json:"test_report,omitempty"`
+
+	// TestRetryCommand re-runs only failed tests; "{tests}" is replaced with
+	// a regexp matching their names. Without it, only a plain "go test"
+	// TestCommand is narrowed (by appending -run); any other command is
+	// re-run in full.
+	TestRetryCommand string `json:"test_retry_command,omitempty"`
+
 	// PollInterval is how often to poll for new merge requests (e.g., "30s").
 	PollInterval string `json:"poll_interval"`
 
 	// MaxConcurrent is the maximum number of concurrent merges.
 	MaxConcurrent int `json:"max_concurrent"`
+
+	// BatchSize is how many MRs to merge and test together (1 disables batching).
+	BatchSize int `json:"batch_size"`
+
+	// MaxBisectDepth limits bisection of a failing batch before falling back
+	// to testing the remaining MRs one at a time (0 disables bisection).
+	MaxBisectDepth int `json:"max_bisect_depth"`
 }
 
 // OnConflict strategy constants.
@@ -322,6 +469,8 @@ func DefaultMergeQueueConfig() *MergeQueueConfig {
 		RetryFlakyTests:      1,
 		PollInterval:         "30s",
 		MaxConcurrent:        1,
+		BatchSize:            1,
+		MaxBisectDepth:       4,
 	}
 }
 
@@ -351,18 +500,29 @@ func DefaultNamepoolConfig() *NamepoolConfig {
 // AccountsConfig represents Claude Code account configuration (mayor/accounts.json).
 // This enables Gas Town to manage multiple Claude Code accounts with easy switching.
 type AccountsConfig struct {
-	Version  int                `json:"version"`  // schema version
-	Accounts map[string]Account `json:"accounts"` // handle -> account details
-	Default  string             `json:"default"`  // default account handle
+	Version  int                `json:"version"`          // schema version
+	Accounts map[string]Account `json:"accounts"`         // handle -> account details
+	Default  string             `json:"default"`          // default account handle
+	Policy   string             `json:"policy,omitempty"` // how spawns pick accounts (AccountPolicy*)
 }
 
 // Account represents a single Claude Code account.
 type Account struct {
-	Email       string `json:"email"`                 // account email
-	Description string `json:"description,omitempty"` // human description
-	ConfigDir   string `json:"config_dir"`            // path to CLAUDE_CONFIG_DIR
+	Email       string  `json:"email"`                 // account email
+	Description string  `json:"description,omitempty"` // human description
+	ConfigDir   string  `json:"config_dir"`            // path to CLAUDE_CONFIG_DIR
+	Budget      *Budget `json:"budget,omitempty"`      // spend limits for this account
+	Weight      int     `json:"weight,omitempty"`      // share of spawns under the weighted policy (default 1)
 }
 
+// Account selection policies for AccountsConfig.Policy. With no policy,
+// spawns use the default account unless it is cooling down from a limit.
+const (
+	AccountPolicyRoundRobin           = "round-robin"
+	AccountPolicyLeastRecentlyLimited = "least-recently-limited"
+	AccountPolicyWeighted             = "weighted"
+)
+
 // CurrentAccountsVersion is the current schema version for AccountsConfig.
 const CurrentAccountsVersion = 1
 
@@ -397,6 +557,11 @@ type MessagingConfig struct {
 	// Like mailing lists but for tmux send-keys instead of durable mail.
 	// Example: {"workers": ["gastown/polecats/*", "gastown/crew/*"], "witnesses": ["*/witness"]}
 	NudgeChannels map[string][]string `json:"nudge_channels,omitempty"`
+
+	// Schedules are recurring messages to mailing lists and announce
+	// channels, sent by the daemon on a cron schedule.
+	// Example: {"standup": {"cron": "0 9 * * 1-5", "to": "list:oncall", "subject": "Standup"}}
+	Schedules map[string]MailSchedule `json:"schedules,omitempty"`
 }
 
 // QueueConfig represents a work queue configuration.
@@ -419,6 +584,22 @@ type AnnounceConfig struct {
 	RetainCount int `json:"retain_count,omitempty"`
 }
 
+// MailSchedule is a recurring message.
+type MailSchedule struct {
+	// Cron is when to send, as "minute hour day-of-month month day-of-week"
+	// in the daemon's local time, or @hourly, @daily, @weekly or @monthly.
+	Cron string `json:"cron"`
+
+	// To is the list ("list:<name>") or announce channel ("announce:<name>").
+	To string `json:"to"`
+
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+
+	// From is the sender address (default "daemon").
+	From string `json:"from,omitempty"`
+}
+
 // CurrentMessagingVersion is the current schema version for MessagingConfig.
 const CurrentMessagingVersion = 1
 
@@ -431,5 +612,6 @@ func NewMessagingConfig() *MessagingConfig {
 		Queues:        make(map[string]QueueConfig),
 		Announces:     make(map[string]AnnounceConfig),
 		NudgeChannels: make(map[string][]string),
+		Schedules:     make(map[string]MailSchedule),
 	}
 }
@@ -5,9 +5,23 @@ package connection
 
 import (
 	"io/fs"
+	"path/filepath"
+	"sort"
 	"time"
 )
 
+// Runner is the part of Connection that command wrappers need. The git and
+// beads packages take a Runner so the same API can operate on a remote
+// machine; tmux keeps its own copy because this package imports tmux.
+type Runner interface {
+	// Run runs a command in dir (if non-empty) with additional environment
+	// variables and returns stdout and stderr separately. Wrappers that parse
+	// command output (tmux, git, bd) use this instead of the Exec family.
+	// If the command could not be started at all, stdout and stderr are
+	// empty; for remote connections err is then a *ConnectionError.
+	Run(dir string, env map[string]string, cmd string, args ...string) (stdout, stderr []byte, err error)
+}
+
 // Connection abstracts file operations, command execution, and tmux management
 // for both local and remote (SSH) execution contexts.
 type Connection interface {
@@ -56,6 +70,8 @@ type Connection interface {
 	// ExecEnv runs a command with additional environment variables.
 	ExecEnv(env map[string]string, cmd string, args ...string) ([]byte, error)
 
+	Runner
+
 	// Tmux operations
 
 	// TmuxNewSession creates a new tmux session with the given name.
@@ -168,3 +184,26 @@ func (e *NotFoundError) Error() string {
 func (e *PermissionError) Error() string {
 	return "permission denied: " + e.Op + " " + e.Path
 }
+
+// ListDirs returns the names of the subdirectories of dir, sorted.
+// A missing dir yields an empty list, matching os.ReadDir callers that
+// treat "not exist" as "nothing there yet".
+func ListDirs(c Connection, dir string) ([]string, error) {
+	matches, err := c.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, m := range matches {
+		fi, err := c.Stat(m)
+		if err != nil {
+			continue // Raced with removal or broken symlink
+		}
+		if fi.IsDir() {
+			names = append(names, filepath.Base(m))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,459 @@
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeConnection is an in-memory Connection for tests.
+//
+// Files and directories live in a map keyed by cleaned path. Commands are
+// recorded and dispatched to ExecHandler, except for tmux, which is emulated
+// against an in-memory session table so that a tmux.Tmux built on top of the
+// fake (tmux.NewTmuxWithRunner) behaves like a real server for the common
+// subcommands.
+type FakeConnection struct {
+	mu       sync.Mutex
+	name     string
+	entries  map[string]*fakeEntry
+	sessions map[string]*FakeSession
+	panes    int
+
+	// ExecHandler handles non-tmux commands. If nil, commands succeed
+	// with no output.
+	ExecHandler func(c FakeCommand) (stdout, stderr []byte, err error)
+
+	// Commands records every command run through the connection, in order.
+	Commands []FakeCommand
+}
+
+// FakeCommand is a command run through a FakeConnection.
+type FakeCommand struct {
+	Dir  string
+	Env  map[string]string
+	Cmd  string
+	Args []string
+}
+
+// String returns the command line, for assertions and error messages.
+func (c FakeCommand) String() string {
+	return strings.Join(append([]string{c.Cmd}, c.Args...), " ")
+}
+
+// FakeSession is an emulated tmux session.
+type FakeSession struct {
+	Name    string
+	WorkDir string
+	Env     map[string]string
+	PaneID  string
+	// Pane holds everything "typed" into the session via send-keys.
+	Pane []string
+}
+
+type fakeEntry struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// errFakeExit is returned for failed emulated commands, standing in for
+// an *exec.ExitError.
+var errFakeExit = errors.New("exit status 1")
+
+// NewFakeConnection creates an empty in-memory connection.
+func NewFakeConnection(name string) *FakeConnection {
+	return &FakeConnection{
+		name:     name,
+		entries:  map[string]*fakeEntry{"/": {mode: fs.ModeDir | 0755}},
+		sessions: make(map[string]*FakeSession),
+	}
+}
+
+// Name returns the fake machine name.
+func (c *FakeConnection) Name() string {
+	return c.name
+}
+
+// IsLocal returns false; the fake stands in for a remote machine.
+func (c *FakeConnection) IsLocal() bool {
+	return false
+}
+
+// Session returns the emulated tmux session, or nil if it doesn't exist.
+func (c *FakeConnection) Session(name string) *FakeSession {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessions[name]
+}
+
+// ReadFile reads the named file.
+func (c *FakeConnection) ReadFile(p string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[path.Clean(p)]
+	if !ok {
+		return nil, &NotFoundError{Path: p}
+	}
+	if e.mode.IsDir() {
+		return nil, fmt.Errorf("read %s: is a directory", p)
+	}
+	return append([]byte(nil), e.data...), nil
+}
+
+// WriteFile writes data to the named file. The parent must exist.
+func (c *FakeConnection) WriteFile(p string, data []byte, perm fs.FileMode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p = path.Clean(p)
+	if parent, ok := c.entries[path.Dir(p)]; !ok || !parent.mode.IsDir() {
+		return &NotFoundError{Path: p}
+	}
+	c.entries[p] = &fakeEntry{data: append([]byte(nil), data...), mode: perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+// MkdirAll creates a directory and all parent directories.
+func (c *FakeConnection) MkdirAll(p string, perm fs.FileMode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p = path.Clean(p)
+	for dir := p; ; dir = path.Dir(dir) {
+		if e, ok := c.entries[dir]; ok {
+			if !e.mode.IsDir() {
+				return fmt.Errorf("mkdir %s: not a directory", dir)
+			}
+			break
+		}
+		c.entries[dir] = &fakeEntry{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+		if dir == "/" || dir == "." {
+			break
+		}
+	}
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (c *FakeConnection) Remove(p string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p = path.Clean(p)
+	e, ok := c.entries[p]
+	if !ok {
+		return nil
+	}
+	if e.mode.IsDir() && len(c.childrenLocked(p)) > 0 {
+		return fmt.Errorf("remove %s: directory not empty", p)
+	}
+	delete(c.entries, p)
+	return nil
+}
+
+// RemoveAll removes the named file or directory and any children.
+func (c *FakeConnection) RemoveAll(p string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p = path.Clean(p)
+	for k := range c.entries {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			delete(c.entries, k)
+		}
+	}
+	return nil
+}
+
+// Stat returns file info for the named file.
+func (c *FakeConnection) Stat(p string) (FileInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[path.Clean(p)]
+	if !ok {
+		return nil, &NotFoundError{Path: p}
+	}
+	return BasicFileInfo{
+		FileName:    path.Base(p),
+		FileSize:    int64(len(e.data)),
+		FileMode:    e.mode,
+		FileModTime: e.modTime,
+		FileIsDir:   e.mode.IsDir(),
+	}, nil
+}
+
+// Glob returns the names of all files matching the pattern.
+func (c *FakeConnection) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matches []string
+	for k := range c.entries {
+		if ok, _ := path.Match(pattern, k); ok {
+			matches = append(matches, k)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Exists returns true if the path exists.
+func (c *FakeConnection) Exists(p string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.entries[path.Clean(p)]
+	return ok, nil
+}
+
+// childrenLocked returns the direct children of dir. Caller holds c.mu.
+func (c *FakeConnection) childrenLocked(dir string) []string {
+	var children []string
+	for k := range c.entries {
+		if k != dir && path.Dir(k) == dir {
+			children = append(children, k)
+		}
+	}
+	return children
+}
+
+// Exec runs a command and returns its combined output.
+func (c *FakeConnection) Exec(cmd string, args ...string) ([]byte, error) {
+	stdout, stderr, err := c.Run("", nil, cmd, args...)
+	return append(stdout, stderr...), err
+}
+
+// ExecDir runs a command in the specified directory.
+func (c *FakeConnection) ExecDir(dir, cmd string, args ...string) ([]byte, error) {
+	stdout, stderr, err := c.Run(dir, nil, cmd, args...)
+	return append(stdout, stderr...), err
+}
+
+// ExecEnv runs a command with additional environment variables.
+func (c *FakeConnection) ExecEnv(env map[string]string, cmd string, args ...string) ([]byte, error) {
+	stdout, stderr, err := c.Run("", env, cmd, args...)
+	return append(stdout, stderr...), err
+}
+
+// Run records the command and dispatches it to the tmux emulator or
+// ExecHandler.
+func (c *FakeConnection) Run(dir string, env map[string]string, cmd string, args ...string) ([]byte, []byte, error) {
+	fc := FakeCommand{Dir: dir, Env: env, Cmd: cmd, Args: append([]string(nil), args...)}
+
+	c.mu.Lock()
+	c.Commands = append(c.Commands, fc)
+	if cmd == "tmux" {
+		defer c.mu.Unlock()
+		return c.runTmuxLocked(args)
+	}
+	handler := c.ExecHandler
+	c.mu.Unlock()
+
+	if handler == nil {
+		return nil, nil, nil
+	}
+	return handler(fc)
+}
+
+// runTmuxLocked emulates the tmux subcommands Gas Town relies on.
+// Unknown subcommands succeed silently. Caller holds c.mu.
+func (c *FakeConnection) runTmuxLocked(args []string) ([]byte, []byte, error) {
+	if len(args) == 0 {
+		return nil, []byte("usage: tmux"), errFakeExit
+	}
+
+	flags, rest := parseFakeTmuxFlags(args[1:])
+	target := strings.TrimPrefix(flags["-t"], "=")
+	if i := strings.IndexAny(target, ":."); i >= 0 {
+		target = target[:i]
+	}
+
+	sessionFor := func(name string) (*FakeSession, []byte, error) {
+		s, ok := c.sessions[name]
+		if !ok {
+			return nil, []byte("can't find session: " + name), errFakeExit
+		}
+		return s, nil, nil
+	}
+
+	switch args[0] {
+	case "new-session":
+		name := flags["-s"]
+		if _, ok := c.sessions[name]; ok {
+			return nil, []byte("duplicate session: " + name), errFakeExit
+		}
+		c.sessions[name] = &FakeSession{Name: name, WorkDir: flags["-c"], Env: make(map[string]string), PaneID: fmt.Sprintf("%%%d", c.panes)}
+		c.panes++
+		return nil, nil, nil
+
+	case "kill-session":
+		if _, stderr, err := sessionFor(target); err != nil {
+			return nil, stderr, err
+		}
+		delete(c.sessions, target)
+		return nil, nil, nil
+
+	case "has-session":
+		_, stderr, err := sessionFor(target)
+		return nil, stderr, err
+
+	case "list-sessions":
+		if len(c.sessions) == 0 {
+			return nil, []byte("no server running on /tmp/tmux-fake/default"), errFakeExit
+		}
+		names := make([]string, 0, len(c.sessions))
+		for name := range c.sessions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return []byte(strings.Join(names, "\n") + "\n"), nil, nil
+
+	case "list-panes":
+		s, stderr, err := sessionFor(target)
+		if err != nil {
+			return nil, stderr, err
+		}
+		return []byte(s.PaneID + "\n"), nil, nil
+
+	case "send-keys":
+		s, stderr, err := sessionFor(target)
+		if err != nil {
+			return nil, stderr, err
+		}
+		_, literal := flags["-l"]
+		for _, key := range rest {
+			switch {
+			case literal:
+				s.Pane = append(s.Pane, key)
+			case key == "Enter":
+				s.Pane = append(s.Pane, "\n")
+			}
+		}
+		return nil, nil, nil
+
+	case "capture-pane":
+		s, stderr, err := sessionFor(target)
+		if err != nil {
+			return nil, stderr, err
+		}
+		lines := strings.Split(strings.Join(s.Pane, ""), "\n")
+		if n := strings.TrimPrefix(flags["-S"], "-"); n != "" {
+			var max int
+			if _, scanErr := fmt.Sscanf(n, "%d", &max); scanErr == nil && max < len(lines) {
+				lines = lines[len(lines)-max:]
+			}
+		}
+		return []byte(strings.Join(lines, "\n")), nil, nil
+
+	case "set-environment":
+		s, stderr, err := sessionFor(target)
+		if err != nil {
+			return nil, stderr, err
+		}
+		if len(rest) == 2 {
+			s.Env[rest[0]] = rest[1]
+		}
+		return nil, nil, nil
+
+	case "show-environment":
+		s, stderr, err := sessionFor(target)
+		if err != nil {
+			return nil, stderr, err
+		}
+		if len(rest) == 1 {
+			v, ok := s.Env[rest[0]]
+			if !ok {
+				return nil, []byte("unknown variable: " + rest[0]), errFakeExit
+			}
+			return []byte(rest[0] + "=" + v + "\n"), nil, nil
+		}
+		return nil, nil, nil
+	}
+
+	return nil, nil, nil
+}
+
+// parseFakeTmuxFlags splits tmux arguments into flags (with values for the
+// flags that take one) and positional arguments.
+func parseFakeTmuxFlags(args []string) (map[string]string, []string) {
+	withValue := map[string]bool{"-t": true, "-s": true, "-c": true, "-S": true, "-F": true, "-E": true, "-x": true, "-y": true}
+	flags := make(map[string]string)
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case withValue[a] && i+1 < len(args):
+			flags[a] = args[i+1]
+			i++
+		case strings.HasPrefix(a, "-") && len(a) == 2 && len(rest) == 0:
+			flags[a] = ""
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return flags, rest
+}
+
+// TmuxNewSession creates an emulated tmux session.
+func (c *FakeConnection) TmuxNewSession(name, dir string) error {
+	return c.tmuxOp("new-session", "-d", "-s", name, "-c", dir)
+}
+
+// TmuxKillSession removes an emulated tmux session.
+func (c *FakeConnection) TmuxKillSession(name string) error {
+	return c.tmuxOp("kill-session", "-t", name)
+}
+
+// TmuxSendKeys appends keys and a newline to the session's pane.
+func (c *FakeConnection) TmuxSendKeys(session, keys string) error {
+	if err := c.tmuxOp("send-keys", "-t", session, "-l", keys); err != nil {
+		return err
+	}
+	return c.tmuxOp("send-keys", "-t", session, "Enter")
+}
+
+// TmuxCapturePane returns the last N lines typed into the session.
+func (c *FakeConnection) TmuxCapturePane(session string, lines int) (string, error) {
+	stdout, stderr, err := c.Run("", nil, "tmux", "capture-pane", "-p", "-t", session, "-S", fmt.Sprintf("-%d", lines))
+	if err != nil {
+		return "", fmt.Errorf("tmux capture-pane: %s", stderr)
+	}
+	return string(stdout), nil
+}
+
+// TmuxHasSession returns true if the emulated session exists.
+func (c *FakeConnection) TmuxHasSession(name string) (bool, error) {
+	return c.Session(name) != nil, nil
+}
+
+// TmuxListSessions returns all emulated session names.
+func (c *FakeConnection) TmuxListSessions() ([]string, error) {
+	stdout, _, err := c.Run("", nil, "tmux", "list-sessions", "-F", "#{session_name}")
+	if err != nil {
+		return nil, nil // No server = no sessions
+	}
+	return strings.Fields(string(stdout)), nil
+}
+
+func (c *FakeConnection) tmuxOp(args ...string) error {
+	_, stderr, err := c.Run("", nil, "tmux", args...)
+	if err != nil {
+		return fmt.Errorf("tmux %s: %s", args[0], stderr)
+	}
+	return nil
+}
+
+// Verify FakeConnection implements Connection.
+var _ Connection = (*FakeConnection)(nil)
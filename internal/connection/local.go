@@ -1,6 +1,7 @@
 package connection
 
 import (
+	"bytes"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -155,6 +156,25 @@ func (c *LocalConnection) ExecEnv(env map[string]string, cmd string, args ...str
 	return command.CombinedOutput()
 }
 
+// Run runs a command and returns stdout and stderr separately.
+func (c *LocalConnection) Run(dir string, env map[string]string, cmd string, args ...string) ([]byte, []byte, error) {
+	command := exec.Command(cmd, args...)
+	command.Dir = dir
+	if len(env) > 0 {
+		command.Env = os.Environ()
+		for k, v := range env {
+			command.Env = append(command.Env, k+"="+v)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	err := command.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
 // TmuxNewSession creates a new tmux session.
 func (c *LocalConnection) TmuxNewSession(name, dir string) error {
 	return c.tmux.NewSession(name, dir)
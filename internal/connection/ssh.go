@@ -0,0 +1,553 @@
+package connection
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Default SSH tuning. Connection multiplexing keeps a master connection
+// alive for ControlPersist so that the many small operations Gas Town
+// performs (stat, cat, tmux has-session, ...) don't each pay for a handshake.
+const (
+	defaultSSHConnectTimeout = 10 * time.Second
+	defaultSSHControlPersist = 10 * time.Minute
+)
+
+// sshExitConnectionFailure is the exit status ssh uses for its own errors
+// (unreachable host, auth failure, ...), as opposed to the remote command's.
+const sshExitConnectionFailure = 255
+
+// SSHConnection implements Connection for a remote machine over SSH.
+//
+// It drives the system ssh client rather than an in-process SSH stack, the
+// same way the tmux and git wrappers drive their binaries. This means
+// ~/.ssh/config, known_hosts, ProxyJump and ssh-agent all work as they do
+// for the user's interactive ssh.
+//
+// Authentication:
+//   - If the machine has a KeyPath, that key is used exclusively.
+//   - Otherwise ssh falls back to the agent (SSH_AUTH_SOCK) and default keys.
+//
+// BatchMode is always on, so a missing credential fails fast with a
+// ConnectionError instead of blocking on a password prompt.
+type SSHConnection struct {
+	name        string
+	host        string
+	keyPath     string
+	controlPath string
+
+	// sshPath is the ssh binary to invoke. Overridable for tests.
+	sshPath string
+
+	connectTimeout time.Duration
+	controlPersist time.Duration
+
+	// tmux runs tmux commands on the remote machine through this connection.
+	tmux *tmux.Tmux
+}
+
+// NewSSHConnection creates a connection to the given ssh machine.
+// No network activity happens until the first operation.
+func NewSSHConnection(m *Machine) *SSHConnection {
+	c := &SSHConnection{
+		name:           m.Name,
+		host:           m.Host,
+		keyPath:        m.KeyPath,
+		controlPath:    filepath.Join(sshControlDir(), "%C"),
+		sshPath:        "ssh",
+		connectTimeout: defaultSSHConnectTimeout,
+		controlPersist: defaultSSHControlPersist,
+	}
+	c.tmux = tmux.NewTmuxWithRunner(c)
+	return c
+}
+
+// sshControlDir returns the directory holding multiplexing control sockets.
+// Kept under the temp dir because unix socket paths are length-limited.
+func sshControlDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gt-ssh-%d", os.Getuid()))
+}
+
+// Name returns the machine name.
+func (c *SSHConnection) Name() string {
+	return c.name
+}
+
+// IsLocal returns false for SSH connections.
+func (c *SSHConnection) IsLocal() bool {
+	return false
+}
+
+// Host returns the ssh destination (user@host).
+func (c *SSHConnection) Host() string {
+	return c.host
+}
+
+// Close shuts down the multiplexed master connection, if one is running.
+// It is safe to call when no master exists.
+func (c *SSHConnection) Close() error {
+	args := append(c.sshArgs(), "-O", "exit", "--", c.host)
+	cmd := exec.Command(c.sshPath, args...) //nolint:gosec // G204: args built from registry config
+	_ = cmd.Run()
+	return nil
+}
+
+// sshArgs returns the common ssh options for every invocation.
+func (c *SSHConnection) sshArgs() []string {
+	args := []string{
+		"-o", "BatchMode=yes",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + c.controlPath,
+		"-o", fmt.Sprintf("ControlPersist=%ds", int(c.controlPersist.Seconds())),
+		"-o", fmt.Sprintf("ConnectTimeout=%d", int(c.connectTimeout.Seconds())),
+	}
+	if c.keyPath != "" {
+		args = append(args, "-i", c.keyPath, "-o", "IdentitiesOnly=yes")
+	}
+	return args
+}
+
+// command builds an exec.Cmd that runs script through the remote login shell.
+func (c *SSHConnection) command(script string) *exec.Cmd {
+	// Best effort: ssh will report a useful error if the control dir is unusable.
+	_ = os.MkdirAll(sshControlDir(), 0700)
+
+	args := append(c.sshArgs(), "-T", "--", c.host, script)
+	return exec.Command(c.sshPath, args...) //nolint:gosec // G204: args built from registry config
+}
+
+// run executes script remotely, optionally feeding stdin, and returns
+// stdout and stderr separately. A failure of ssh itself is returned as a
+// *ConnectionError; a non-zero exit of the remote script is returned as-is.
+func (c *SSHConnection) run(op string, stdin []byte, script string) (string, string, error) {
+	cmd := c.command(script)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		return stdout.String(), stderr.String(), c.wrapExecError(op, err, stderr.String())
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
+// runCombined executes script remotely and returns combined output,
+// mirroring exec.Cmd.CombinedOutput for the Exec family.
+func (c *SSHConnection) runCombined(op, script string) ([]byte, error) {
+	cmd := c.command(script)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	if err != nil {
+		return out.Bytes(), c.wrapExecError(op, err, out.String())
+	}
+	return out.Bytes(), nil
+}
+
+// wrapExecError converts ssh-level failures into a ConnectionError.
+// Remote command failures (any other exit status) pass through unchanged
+// so callers can inspect the *exec.ExitError just like a local command.
+func (c *SSHConnection) wrapExecError(op string, err error, stderr string) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() != sshExitConnectionFailure {
+		return err
+	}
+
+	msg := strings.TrimSpace(stderr)
+	if msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	return &ConnectionError{Op: op, Machine: c.name, Err: err}
+}
+
+// fileError classifies a failed remote file operation using the stderr of
+// the coreutils command that ran it (scripts force LC_ALL=C).
+func (c *SSHConnection) fileError(op, p, stderr string, err error) error {
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		return err
+	}
+
+	switch {
+	case strings.Contains(stderr, "No such file or directory"):
+		return &NotFoundError{Path: p}
+	case strings.Contains(stderr, "Permission denied"),
+		strings.Contains(stderr, "Operation not permitted"):
+		return &PermissionError{Path: p, Op: op}
+	}
+
+	msg := strings.TrimSpace(stderr)
+	if msg == "" {
+		return fmt.Errorf("%s %s on %s: %w", op, p, c.name, err)
+	}
+	return fmt.Errorf("%s %s on %s: %s", op, p, c.name, msg)
+}
+
+// runFileOp runs a file-manipulation script with a stable locale so that
+// error messages can be classified by fileError.
+func (c *SSHConnection) runFileOp(op, p string, stdin []byte, script string) (string, error) {
+	stdout, stderr, err := c.run(op, stdin, "LC_ALL=C; export LC_ALL; "+script)
+	if err != nil {
+		return "", c.fileError(op, p, stderr, err)
+	}
+	return stdout, nil
+}
+
+// ReadFile reads the named file on the remote machine.
+func (c *SSHConnection) ReadFile(p string) ([]byte, error) {
+	out, err := c.runFileOp("read", p, nil, "cat -- "+ShellQuote(p))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// WriteFile writes data to the named file on the remote machine.
+// Data is streamed over stdin, so binary content is preserved.
+func (c *SSHConnection) WriteFile(p string, data []byte, perm fs.FileMode) error {
+	q := ShellQuote(p)
+	script := fmt.Sprintf("cat > %s && chmod %04o %s", q, perm.Perm(), q)
+	if data == nil {
+		data = []byte{}
+	}
+	_, err := c.runFileOp("write", p, data, script)
+	return err
+}
+
+// MkdirAll creates a directory and all parent directories.
+func (c *SSHConnection) MkdirAll(p string, perm fs.FileMode) error {
+	_, err := c.runFileOp("mkdir", p, nil, fmt.Sprintf("mkdir -p -m %04o -- %s", perm.Perm(), ShellQuote(p)))
+	return err
+}
+
+// Remove removes the named file or empty directory.
+// Like LocalConnection, a missing path is not an error.
+func (c *SSHConnection) Remove(p string) error {
+	q := ShellQuote(p)
+	script := fmt.Sprintf("if [ -d %s ] && [ ! -L %s ]; then rmdir -- %s; else rm -f -- %s; fi", q, q, q, q)
+	_, err := c.runFileOp("remove", p, nil, script)
+	return err
+}
+
+// RemoveAll removes the named file or directory and any children.
+func (c *SSHConnection) RemoveAll(p string) error {
+	_, err := c.runFileOp("remove", p, nil, "rm -rf -- "+ShellQuote(p))
+	return err
+}
+
+// Stat returns file info for the named file, following symlinks.
+// Requires GNU stat on the remote machine.
+func (c *SSHConnection) Stat(p string) (FileInfo, error) {
+	out, err := c.runFileOp("stat", p, nil, "stat -L -c '%s %f %Y' -- "+ShellQuote(p))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("stat %s on %s: unexpected output %q", p, c.name, out)
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s on %s: parsing size: %w", p, c.name, err)
+	}
+	rawMode, err := strconv.ParseUint(fields[1], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s on %s: parsing mode: %w", p, c.name, err)
+	}
+	mtime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s on %s: parsing mtime: %w", p, c.name, err)
+	}
+
+	mode := unixModeToFileMode(uint32(rawMode))
+	return BasicFileInfo{
+		FileName:    path.Base(p),
+		FileSize:    size,
+		FileMode:    mode,
+		FileModTime: time.Unix(mtime, 0),
+		FileIsDir:   mode.IsDir(),
+	}, nil
+}
+
+// Glob returns the names of all files matching the pattern.
+// The pattern is expanded by the remote shell, so (unlike filepath.Glob)
+// a leading '*' does not match dot files.
+func (c *SSHConnection) Glob(pattern string) ([]string, error) {
+	// Reject malformed patterns locally, matching filepath.Glob.
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	script := fmt.Sprintf(`for f in %s; do [ -e "$f" ] || [ -L "$f" ] && printf '%%s\n' "$f"; done; exit 0`, globQuote(pattern))
+	out, err := c.runFileOp("glob", pattern, nil, script)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, line := range strings.Split(out, "\n") {
+		if line != "" {
+			matches = append(matches, line)
+		}
+	}
+	return matches, nil
+}
+
+// Exists returns true if the path exists.
+func (c *SSHConnection) Exists(p string) (bool, error) {
+	_, _, err := c.run("stat", nil, "test -e "+ShellQuote(p))
+	if err != nil {
+		var connErr *ConnectionError
+		if errors.As(err, &connErr) {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// Exec runs a command on the remote machine and returns its combined output.
+func (c *SSHConnection) Exec(cmd string, args ...string) ([]byte, error) {
+	return c.runCombined("exec", remoteCommand("", nil, cmd, args...))
+}
+
+// ExecDir runs a command in the specified remote directory.
+func (c *SSHConnection) ExecDir(dir, cmd string, args ...string) ([]byte, error) {
+	return c.runCombined("exec", remoteCommand(dir, nil, cmd, args...))
+}
+
+// ExecEnv runs a command with additional environment variables.
+func (c *SSHConnection) ExecEnv(env map[string]string, cmd string, args ...string) ([]byte, error) {
+	return c.runCombined("exec", remoteCommand("", env, cmd, args...))
+}
+
+// Run runs a command on the remote machine and returns stdout and stderr
+// separately.
+func (c *SSHConnection) Run(dir string, env map[string]string, cmd string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := c.run("exec", nil, remoteCommand(dir, env, cmd, args...))
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		return nil, nil, err
+	}
+	return []byte(stdout), []byte(stderr), err
+}
+
+// remoteCommand builds the shell command line for the Exec family.
+// Environment variables are passed with env(1) since sshd usually
+// rejects SendEnv.
+func remoteCommand(dir string, env map[string]string, cmd string, args ...string) string {
+	var sb strings.Builder
+	if dir != "" {
+		sb.WriteString("cd " + ShellQuote(dir) + " && ")
+	}
+	if len(env) > 0 {
+		keys := make([]string, 0, len(env))
+		for k := range env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		sb.WriteString("env")
+		for _, k := range keys {
+			sb.WriteString(" " + ShellQuote(k+"="+env[k]))
+		}
+		sb.WriteString(" ")
+	}
+	sb.WriteString(shellJoin(cmd, args...))
+	return sb.String()
+}
+
+// TmuxNewSession creates a new detached tmux session on the remote machine.
+func (c *SSHConnection) TmuxNewSession(name, dir string) error {
+	return c.tmux.NewSession(name, dir)
+}
+
+// TmuxKillSession terminates a remote tmux session.
+func (c *SSHConnection) TmuxKillSession(name string) error {
+	return c.tmux.KillSession(name)
+}
+
+// TmuxSendKeys sends keys to a remote tmux session followed by Enter.
+func (c *SSHConnection) TmuxSendKeys(session, keys string) error {
+	return c.tmux.SendKeys(session, keys)
+}
+
+// TmuxCapturePane captures the last N lines from a remote tmux pane.
+func (c *SSHConnection) TmuxCapturePane(session string, lines int) (string, error) {
+	return c.tmux.CapturePane(session, lines)
+}
+
+// TmuxHasSession returns true if the remote session exists (exact match).
+func (c *SSHConnection) TmuxHasSession(name string) (bool, error) {
+	return c.tmux.HasSession(name)
+}
+
+// TmuxListSessions returns all remote tmux session names.
+func (c *SSHConnection) TmuxListSessions() ([]string, error) {
+	return c.tmux.ListSessions()
+}
+
+// unixModeToFileMode converts a raw st_mode into an fs.FileMode.
+func unixModeToFileMode(m uint32) fs.FileMode {
+	mode := fs.FileMode(m & 0777)
+	switch m & 0170000 {
+	case 0040000:
+		mode |= fs.ModeDir
+	case 0120000:
+		mode |= fs.ModeSymlink
+	case 0010000:
+		mode |= fs.ModeNamedPipe
+	case 0140000:
+		mode |= fs.ModeSocket
+	case 0020000:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case 0060000:
+		mode |= fs.ModeDevice
+	}
+	if m&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if m&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if m&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// ShellQuote quotes s for a POSIX shell. Plain words are left unquoted.
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !isShellSafe(r) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellJoin quotes and joins a command and its arguments.
+func shellJoin(cmd string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, ShellQuote(cmd))
+	for _, a := range args {
+		parts = append(parts, ShellQuote(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+// globQuote quotes a glob pattern for the shell, leaving the wildcard
+// characters (*, ? and [...] classes) unquoted so the shell expands them.
+func globQuote(pattern string) string {
+	var sb strings.Builder
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			sb.WriteString(ShellQuote(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*', '?':
+			flush()
+			sb.WriteByte(ch)
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				literal.WriteByte(ch)
+				continue
+			}
+			flush()
+			sb.WriteString(classQuote(pattern[i+1 : i+end+1]))
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				literal.WriteByte(pattern[i])
+			}
+		default:
+			literal.WriteByte(ch)
+		}
+	}
+	flush()
+	return sb.String()
+}
+
+// classQuote renders the body of a [...] class for the shell. Only the
+// negation mark and range dashes stay special; every other character is
+// quoted, so a class can't smuggle a command substitution to the remote
+// shell.
+func classQuote(body string) string {
+	var sb strings.Builder
+	var members strings.Builder
+	flush := func() {
+		if members.Len() > 0 {
+			sb.WriteString(ShellQuote(members.String()))
+			members.Reset()
+		}
+	}
+
+	sb.WriteByte('[')
+	// filepath.Match uses '^' for negation; POSIX shells use '!'.
+	if strings.HasPrefix(body, "^") || strings.HasPrefix(body, "!") {
+		sb.WriteByte('!')
+		body = body[1:]
+	}
+	for i := 0; i < len(body); i++ {
+		switch ch := body[i]; ch {
+		case '-':
+			flush()
+			sb.WriteByte(ch)
+		case '\\':
+			if i+1 < len(body) {
+				i++
+				members.WriteByte(body[i])
+			}
+		default:
+			members.WriteByte(ch)
+		}
+	}
+	flush()
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func isShellSafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("@%+=:,./_-", r)
+}
+
+// Verify SSHConnection implements Connection.
+var _ Connection = (*SSHConnection)(nil)
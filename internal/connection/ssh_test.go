@@ -0,0 +1,423 @@
+package connection
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSHScript stands in for the ssh client: it records its arguments,
+// skips options, and runs the remote command locally through sh -c, which
+// is what sshd does with the command string on the far side.
+const fakeSSHScript = `#!/bin/sh
+printf '%s\n' "$*" >> "$(dirname "$0")/ssh.log"
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -o|-i|-p|-S|-O|-l|-F) shift 2 ;;
+    --) shift; break ;;
+    -*) shift ;;
+    *) break ;;
+  esac
+done
+host="$1"; shift
+if [ "$host" = "unreachable" ]; then
+  echo "ssh: connect to host unreachable port 22: Connection refused" >&2
+  exit 255
+fi
+exec sh -c "$*"
+`
+
+func newTestSSHConnection(t *testing.T, m *Machine) (*SSHConnection, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh requires a POSIX shell")
+	}
+
+	binDir := t.TempDir()
+	sshPath := filepath.Join(binDir, "ssh")
+	if err := os.WriteFile(sshPath, []byte(fakeSSHScript), 0755); err != nil {
+		t.Fatalf("writing fake ssh: %v", err)
+	}
+
+	if m == nil {
+		m = &Machine{Name: "vm", Type: "ssh", Host: "user@vm"}
+	}
+	c := NewSSHConnection(m)
+	c.sshPath = sshPath
+	return c, filepath.Join(binDir, "ssh.log")
+}
+
+func TestSSHConnection_Identity(t *testing.T) {
+	c, _ := newTestSSHConnection(t, nil)
+	if c.Name() != "vm" {
+		t.Errorf("Name() = %q, want %q", c.Name(), "vm")
+	}
+	if c.IsLocal() {
+		t.Error("IsLocal() = true, want false")
+	}
+	if c.Host() != "user@vm" {
+		t.Errorf("Host() = %q, want %q", c.Host(), "user@vm")
+	}
+}
+
+func TestSSHConnection_FileRoundTrip(t *testing.T) {
+	c, _ := newTestSSHConnection(t, nil)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub dir", "it's a file.txt")
+
+	if err := c.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	data := []byte("line one\n$HOME `whoami` \x00 binary\n")
+	if err := c.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := c.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("ReadFile = %q, want %q", got, data)
+	}
+
+	fi, err := c.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fi.Name() != "it's a file.txt" {
+		t.Errorf("Name() = %q", fi.Name())
+	}
+	if fi.Size() != int64(len(data)) {
+		t.Errorf("Size() = %d, want %d", fi.Size(), len(data))
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("Mode().Perm() = %o, want 600", fi.Mode().Perm())
+	}
+	if fi.IsDir() {
+		t.Error("IsDir() = true for a file")
+	}
+	if time.Since(fi.ModTime()) > time.Minute {
+		t.Errorf("ModTime() = %v, expected recent", fi.ModTime())
+	}
+
+	dfi, err := c.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Stat dir: %v", err)
+	}
+	if !dfi.IsDir() || !dfi.Mode().IsDir() {
+		t.Errorf("Stat dir: IsDir=%v Mode=%v", dfi.IsDir(), dfi.Mode())
+	}
+}
+
+func TestSSHConnection_NotFound(t *testing.T) {
+	c, _ := newTestSSHConnection(t, nil)
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	_, err := c.ReadFile(missing)
+	var nf *NotFoundError
+	if !errors.As(err, &nf) {
+		t.Fatalf("ReadFile error = %v, want NotFoundError", err)
+	}
+	if nf.Path != missing {
+		t.Errorf("NotFoundError.Path = %q, want %q", nf.Path, missing)
+	}
+
+	if _, err := c.Stat(missing); !errors.As(err, &nf) {
+		t.Errorf("Stat error = %v, want NotFoundError", err)
+	}
+
+	exists, err := c.Exists(missing)
+	if err != nil || exists {
+		t.Errorf("Exists(missing) = %v, %v; want false, nil", exists, err)
+	}
+
+	// Removing something already gone is not an error.
+	if err := c.Remove(missing); err != nil {
+		t.Errorf("Remove(missing) = %v", err)
+	}
+}
+
+func TestSSHConnection_RemoveAndGlob(t *testing.T) {
+	c, _ := newTestSSHConnection(t, nil)
+	dir := t.TempDir()
+
+	for _, name := range []string{"a.json", "b.json", "c.txt", "with space.json"} {
+		if err := c.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("WriteFile %s: %v", name, err)
+		}
+	}
+
+	matches, err := c.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "a.json"),
+		filepath.Join(dir, "b.json"),
+		filepath.Join(dir, "with space.json"),
+	}
+	if strings.Join(matches, "|") != strings.Join(want, "|") {
+		t.Errorf("Glob = %v, want %v", matches, want)
+	}
+
+	none, err := c.Glob(filepath.Join(dir, "*.nope"))
+	if err != nil || len(none) != 0 {
+		t.Errorf("Glob(no match) = %v, %v; want empty", none, err)
+	}
+
+	if _, err := c.Glob("[unterminated"); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Errorf("Glob(bad pattern) error = %v, want ErrBadPattern", err)
+	}
+
+	if err := c.Remove(filepath.Join(dir, "c.txt")); err != nil {
+		t.Fatalf("Remove file: %v", err)
+	}
+	if exists, _ := c.Exists(filepath.Join(dir, "c.txt")); exists {
+		t.Error("c.txt still exists after Remove")
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := c.MkdirAll(empty, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := c.Remove(empty); err != nil {
+		t.Fatalf("Remove empty dir: %v", err)
+	}
+
+	if err := c.RemoveAll(dir); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if exists, _ := c.Exists(dir); exists {
+		t.Error("dir still exists after RemoveAll")
+	}
+}
+
+func TestSSHConnection_Exec(t *testing.T) {
+	c, _ := newTestSSHConnection(t, nil)
+
+	out, err := c.Exec("printf", "%s|%s", "it's", "$HOME; rm -rf /")
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if string(out) != "it's|$HOME; rm -rf /" {
+		t.Errorf("Exec output = %q, arguments were not quoted", out)
+	}
+
+	dir := t.TempDir()
+	out, err = c.ExecDir(dir, "pwd")
+	if err != nil {
+		t.Fatalf("ExecDir: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != dir {
+		t.Errorf("ExecDir pwd = %q, want %q", got, dir)
+	}
+
+	out, err = c.ExecEnv(map[string]string{"GT_TEST_VAR": "a b'c"}, "sh", "-c", "printf %s \"$GT_TEST_VAR\"")
+	if err != nil {
+		t.Fatalf("ExecEnv: %v", err)
+	}
+	if string(out) != "a b'c" {
+		t.Errorf("ExecEnv output = %q", out)
+	}
+
+	// Remote failures surface as exit errors with output, not ConnectionErrors.
+	out, err = c.Exec("sh", "-c", "echo boom >&2; exit 3")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("Exec failure error = %v, want exit status 3", err)
+	}
+	if !strings.Contains(string(out), "boom") {
+		t.Errorf("Exec failure output = %q, want stderr included", out)
+	}
+}
+
+func TestSSHConnection_ConnectionError(t *testing.T) {
+	c, _ := newTestSSHConnection(t, &Machine{Name: "down", Type: "ssh", Host: "unreachable"})
+
+	_, err := c.ReadFile("/etc/hostname")
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("ReadFile error = %v, want ConnectionError", err)
+	}
+	if connErr.Machine != "down" || connErr.Op != "read" {
+		t.Errorf("ConnectionError = %+v", connErr)
+	}
+	if !strings.Contains(connErr.Error(), "Connection refused") {
+		t.Errorf("ConnectionError message %q lacks ssh stderr", connErr.Error())
+	}
+
+	if _, err := c.Exists("/"); !errors.As(err, &connErr) {
+		t.Errorf("Exists error = %v, want ConnectionError", err)
+	}
+	if _, err := c.Exec("true"); !errors.As(err, &connErr) {
+		t.Errorf("Exec error = %v, want ConnectionError", err)
+	}
+	if _, err := c.TmuxHasSession("x"); !errors.As(err, &connErr) {
+		t.Errorf("TmuxHasSession error = %v, want ConnectionError", err)
+	}
+}
+
+func TestSSHConnection_Args(t *testing.T) {
+	c, logPath := newTestSSHConnection(t, &Machine{
+		Name:    "vm",
+		Type:    "ssh",
+		Host:    "builder@vm.example",
+		KeyPath: "/keys/id_ed25519",
+	})
+
+	if _, err := c.Exec("true"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	logged, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("reading ssh log: %v", err)
+	}
+	args := string(logged)
+
+	for _, want := range []string{
+		"BatchMode=yes",
+		"ControlMaster=auto",
+		"ControlPath=",
+		"ControlPersist=",
+		"-i /keys/id_ed25519",
+		"IdentitiesOnly=yes",
+		"-- builder@vm.example true",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("ssh args %q missing %q", args, want)
+		}
+	}
+
+	// Without a key, ssh is left to use the agent.
+	agent, logPath := newTestSSHConnection(t, nil)
+	if _, err := agent.Exec("true"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	logged, _ = os.ReadFile(logPath)
+	if strings.Contains(string(logged), "-i ") || strings.Contains(string(logged), "IdentitiesOnly") {
+		t.Errorf("agent auth should not pin an identity: %q", logged)
+	}
+}
+
+func TestSSHConnection_FileErrorClassification(t *testing.T) {
+	c, _ := newTestSSHConnection(t, nil)
+	base := errors.New("exit status 1")
+
+	var perm *PermissionError
+	if err := c.fileError("write", "/root/x", "cat: /root/x: Permission denied\n", base); !errors.As(err, &perm) {
+		t.Errorf("fileError = %v, want PermissionError", err)
+	} else if perm.Op != "write" || perm.Path != "/root/x" {
+		t.Errorf("PermissionError = %+v", perm)
+	}
+
+	connErr := &ConnectionError{Op: "read", Machine: "vm", Err: base}
+	if err := c.fileError("read", "/x", "Permission denied (publickey)", connErr); err != connErr {
+		t.Errorf("fileError should pass ConnectionError through, got %v", err)
+	}
+}
+
+func TestSSHConnection_Tmux(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	c, _ := newTestSSHConnection(t, nil)
+	session := "gt-test-ssh-" + strings.ReplaceAll(t.Name(), "/", "-")
+	_ = c.TmuxKillSession(session)
+
+	if err := c.TmuxNewSession(session, t.TempDir()); err != nil {
+		t.Fatalf("TmuxNewSession: %v", err)
+	}
+	defer func() { _ = c.TmuxKillSession(session) }()
+
+	has, err := c.TmuxHasSession(session)
+	if err != nil || !has {
+		t.Fatalf("TmuxHasSession = %v, %v; want true", has, err)
+	}
+
+	sessions, err := c.TmuxListSessions()
+	if err != nil {
+		t.Fatalf("TmuxListSessions: %v", err)
+	}
+	found := false
+	for _, s := range sessions {
+		if s == session {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("TmuxListSessions = %v, missing %s", sessions, session)
+	}
+
+	if err := c.TmuxSendKeys(session, "echo gt-ssh-marker"); err != nil {
+		t.Fatalf("TmuxSendKeys: %v", err)
+	}
+	var pane string
+	for i := 0; i < 20; i++ {
+		pane, err = c.TmuxCapturePane(session, 50)
+		if err == nil && strings.Count(pane, "gt-ssh-marker") >= 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !strings.Contains(pane, "gt-ssh-marker") {
+		t.Errorf("TmuxCapturePane = %q, missing sent keys", pane)
+	}
+
+	if err := c.TmuxKillSession(session); err != nil {
+		t.Fatalf("TmuxKillSession: %v", err)
+	}
+	if has, _ := c.TmuxHasSession(session); has {
+		t.Error("session still exists after kill")
+	}
+}
+
+func TestGlobQuote(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"/a/b/*.json", "/a/b/*.json"},
+		{"/a dir/*", "'/a dir/'*"},
+		{"/x/[abc]?.go", "/x/[abc]?.go"},
+		{"/x/[^a]*", "/x/[!a]*"},
+		{"/x/[a-z0-9]", "/x/[a-z0-9]"},
+		{"/x/[$(id)]", "/x/['$(id)']"},
+		{"/x/[`id`]*", "/x/['`id`']*"},
+		{"/x/[!a-c']", `/x/[!a-'c'\''']`},
+		{"/it's/*", `'/it'\''s/'*`},
+	}
+	for _, tt := range tests {
+		if got := globQuote(tt.pattern); got != tt.want {
+			t.Errorf("globQuote(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMachineRegistry_SSHConnection(t *testing.T) {
+	r, err := NewMachineRegistry(filepath.Join(t.TempDir(), "machines.json"))
+	if err != nil {
+		t.Fatalf("NewMachineRegistry: %v", err)
+	}
+	if err := r.Add(&Machine{Name: "vm", Type: "ssh", Host: "user@vm"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	conn, err := r.Connection("vm")
+	if err != nil {
+		t.Fatalf("Connection: %v", err)
+	}
+	sc, ok := conn.(*SSHConnection)
+	if !ok {
+		t.Fatalf("Connection type = %T, want *SSHConnection", conn)
+	}
+	if sc.Name() != "vm" || sc.Host() != "user@vm" {
+		t.Errorf("SSHConnection = %s (%s)", sc.Name(), sc.Host())
+	}
+}
@@ -71,6 +71,12 @@ const (
 
 	// FileAccountsJSON is the accounts configuration file in mayor/.
 	FileAccountsJSON = "accounts.json"
+
+	// FileMachinesJSON is the machine registry file in mayor/.
+	FileMachinesJSON = "machines.json"
+
+	// FileAccountHealthJSON records account rate limits and rotation state in mayor/.
+	FileAccountHealthJSON = "account-health.json"
 )
 
 // Git branch names.
@@ -223,3 +229,13 @@ func RigSettingsPath(rigPath string) string {
 func MayorAccountsPath(townRoot string) string {
 	return townRoot + "/" + DirMayor + "/" + FileAccountsJSON
 }
+
+// MayorAccountHealthPath returns the path to mayor/account-health.json within a town root.
+func MayorAccountHealthPath(townRoot string) string {
+	return townRoot + "/" + DirMayor + "/" + FileAccountHealthJSON
+}
+
+// MayorMachinesPath returns the path to mayor/machines.json within a town root.
+func MayorMachinesPath(townRoot string) string {
+	return townRoot + "/" + DirMayor + "/" + FileMachinesJSON
+}
@@ -0,0 +1,323 @@
+package costs
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+const claudeTranscript = `{"type":"user","sessionId":"s1","cwd":"/town/gastown/polecats/Toast","timestamp":"2026-01-02T10:00:00Z","message":{"role":"user","content":"hi"}}
+{"type":"assistant","sessionId":"s1","cwd":"/town/gastown/polecats/Toast","timestamp":"2026-01-02T10:00:01Z","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":10,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000,"output_tokens":1}}}
+{"type":"assistant","sessionId":"s1","cwd":"/town/gastown/polecats/Toast","timestamp":"2026-01-02T10:00:02Z","requestId":"r1","message":{"id":"m1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":10,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000,"output_tokens":50}}}
+{"type":"assistant","sessionId":"s1","timestamp":"2026-01-02T10:00:03Z","requestId":"r2","message":{"id":"m2","model":"<synthetic>","usage":{"input_tokens":0,"output_tokens":0}}}
+`
+
+func TestParseClaude(t *testing.T) {
+	var st FileState
+	usages := ParseClaude([]byte(claudeTranscript), &st)
+	if len(usages) != 1 {
+		t.Fatalf("got %d usages, want 1 (repeated message lines merged, synthetic skipped)", len(usages))
+	}
+	u := usages[0]
+	if u.ID != "m1:r1" || u.SessionID != "s1" || u.Cwd != "/town/gastown/polecats/Toast" {
+		t.Errorf("usage identity = %+v", u)
+	}
+	if u.InputTokens != 10 || u.OutputTokens != 50 || u.CacheReadTokens != 1000 || u.CacheWriteTokens != 100 {
+		t.Errorf("tokens = %+v, want the last line's usage", u)
+	}
+	if st.SessionID != "s1" {
+		t.Errorf("state session = %q", st.SessionID)
+	}
+}
+
+func TestParseCodex(t *testing.T) {
+	data := `{"timestamp":"2026-01-02T10:00:00Z","type":"session_meta","payload":{"id":"c1","cwd":"/town/gastown/crew/max"}}
+{"timestamp":"2026-01-02T10:00:01Z","type":"turn_context","payload":{"cwd":"/town/gastown/crew/max","model":"gpt-5-codex"}}
+{"timestamp":"2026-01-02T10:00:02Z","type":"event_msg","payload":{"type":"token_count","info":null}}
+{"timestamp":"2026-01-02T10:00:03Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1200},"last_token_usage":{"input_tokens":1000,"cached_input_tokens":800,"output_tokens":200,"total_tokens":1200}}}}
+`
+	var st FileState
+	usages := ParseCodex([]byte(data), &st)
+	if len(usages) != 1 {
+		t.Fatalf("got %d usages, want 1", len(usages))
+	}
+	u := usages[0]
+	if u.ID != "c1:1200" || u.Model != "gpt-5-codex" || u.Cwd != "/town/gastown/crew/max" {
+		t.Errorf("usage identity = %+v", u)
+	}
+	if u.InputTokens != 200 || u.CacheReadTokens != 800 || u.OutputTokens != 200 {
+		t.Errorf("tokens = %+v, want cached input split out", u)
+	}
+
+	// Context carries over to the next incremental read.
+	more := `{"timestamp":"2026-01-02T10:01:00Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1300},"last_token_usage":{"input_tokens":90,"output_tokens":10,"total_tokens":100}}}}
+`
+	usages = ParseCodex([]byte(more), &st)
+	if len(usages) != 1 || usages[0].SessionID != "c1" || usages[0].Model != "gpt-5-codex" {
+		t.Errorf("incremental usages = %+v", usages)
+	}
+}
+
+func TestParseGemini(t *testing.T) {
+	data := `{"sessionId":"g1","projectHash":"abc","messages":[
+{"id":"u1","type":"user","content":"hi"},
+{"id":"a1","type":"gemini","timestamp":"2026-01-02T10:00:00Z","model":"gemini-2.5-pro","tokens":{"input":1000,"output":100,"cached":400,"thoughts":50,"tool":0,"total":1150}}]}`
+	var st FileState
+	usages := ParseGemini([]byte(data), &st)
+	if len(usages) != 1 {
+		t.Fatalf("got %d usages, want 1", len(usages))
+	}
+	u := usages[0]
+	if u.ID != "g1:a1" || u.ProjectHash != "abc" {
+		t.Errorf("usage identity = %+v", u)
+	}
+	if u.InputTokens != 600 || u.CacheReadTokens != 400 || u.OutputTokens != 150 {
+		t.Errorf("tokens = %+v, want thoughts billed as output", u)
+	}
+}
+
+func TestPricesLookup(t *testing.T) {
+	tests := []struct {
+		model string
+		input float64
+		found bool
+	}{
+		{"claude-opus-4-1-20250805", 15, true},
+		{"claude-opus-4-5-20251101", 5, true},
+		{"claude-sonnet-4-5-20250929", 3, true},
+		{"gpt-5-codex", 1.25, true},
+		{"gpt-5-mini", 0.25, true},
+		{"gemini-2.5-flash-lite", 0.10, true},
+		{"mystery-model", 0, false},
+	}
+	for _, tt := range tests {
+		p, found := DefaultPrices.Lookup(tt.model)
+		if found != tt.found || p.Input != tt.input {
+			t.Errorf("Lookup(%q) = %v, %v; want input %v, %v", tt.model, p, found, tt.input, tt.found)
+		}
+	}
+
+	e := Entry{InputTokens: 1_000_000, OutputTokens: 1_000_000, CacheReadTokens: 1_000_000, CacheWriteTokens: 1_000_000}
+	if got := DefaultPrices["claude-sonnet"].Cost(e); math.Abs(got-22.05) > 1e-9 {
+		t.Errorf("Cost = %v, want 22.05", got)
+	}
+}
+
+func TestLoadPricesOverride(t *testing.T) {
+	town := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(town, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	override := `{"local-llama": {"input": 0, "output": 0}, "claude-sonnet": {"input": 2, "output": 10}}`
+	if err := os.WriteFile(filepath.Join(town, "settings", PricingFile), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prices, err := LoadPrices(town)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := prices.Lookup("local-llama-70b"); !found {
+		t.Error("override model not found")
+	}
+	if p, _ := prices.Lookup("claude-sonnet-4"); p.Input != 2 {
+		t.Errorf("sonnet input = %v, want overridden 2", p.Input)
+	}
+	if DefaultPrices["claude-sonnet"].Input != 3 {
+		t.Error("LoadPrices modified DefaultPrices")
+	}
+}
+
+func TestIngester(t *testing.T) {
+	town := t.TempDir()
+	projects := filepath.Join(t.TempDir(), "projects", "-town")
+	if err := os.MkdirAll(projects, 0755); err != nil {
+		t.Fatal(err)
+	}
+	transcript := filepath.Join(projects, "s1.jsonl")
+	// Hold back a partial last line: it must wait for the next run.
+	partial := `{"type":"assistant","sessionId":"s1","timestamp":"2026-01-03T10:00:00Z","requestId":"r9","message":{"id":"m9","model":"claude-sonnet-4","usage":{"input_tokens":1`
+	if err := os.WriteFile(transcript, []byte(claudeTranscript+partial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger := NewLedger(town)
+	in := &Ingester{
+		Ledger:  ledger,
+		Sources: []Source{{Agent: "claude", Globs: []string{filepath.Join(projects, "*.jsonl")}, Incremental: true, Parse: ParseClaude}},
+		Prices:  DefaultPrices,
+		Attribute: func(u Usage) (Attribution, bool) {
+			if !strings.HasPrefix(u.Cwd, "/town/") {
+				return Attribution{}, false
+			}
+			return Attribution{Role: "polecat", Rig: "gastown", Bead: "gt-abc"}, true
+		},
+	}
+
+	n, err := in.Run()
+	if err != nil || n != 1 {
+		t.Fatalf("first Run = %d, %v; want 1 entry", n, err)
+	}
+	if n, err := in.Run(); err != nil || n != 0 {
+		t.Fatalf("second Run = %d, %v; want nothing new", n, err)
+	}
+
+	f, err := os.OpenFile(transcript, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`0,"output_tokens":1}}}` + "\n")
+	f.Close()
+	if n, err := in.Run(); err != nil || n != 1 {
+		t.Fatalf("third Run = %d, %v; want the completed line", n, err)
+	}
+
+	entries, err := ledger.Entries(time.Time{})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Entries = %d, %v", len(entries), err)
+	}
+	e := entries[0]
+	if e.ID != "claude:m1:r1" || e.Bead != "gt-abc" || e.Rig != "gastown" {
+		t.Errorf("entry = %+v", e)
+	}
+	want := (10*3 + 50*15 + 1000*0.30 + 100*3.75) / 1e6
+	if math.Abs(e.CostUSD-want) > 1e-12 {
+		t.Errorf("CostUSD = %v, want %v", e.CostUSD, want)
+	}
+
+	since := time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)
+	recent, _ := ledger.Entries(since)
+	if len(recent) != 1 || recent[0].ID != "claude:m9:r9" {
+		t.Errorf("Entries(since) = %+v", recent)
+	}
+}
+
+func TestLedgerAppend(t *testing.T) {
+	ledger := NewLedger(t.TempDir())
+	for i, batch := range [][]Entry{{{ID: "a", CostUSD: 1}}, {{ID: "b", CostUSD: 2}, {ID: "c", CostUSD: 3}}} {
+		if n, err := ledger.Append(batch); err != nil || n != len(batch) {
+			t.Fatalf("Append #%d = %d, %v", i, n, err)
+		}
+	}
+	entries, err := ledger.Entries(time.Time{})
+	if err != nil || len(entries) != 3 || entries[2].ID != "c" {
+		t.Errorf("Entries = %+v, %v; want a, b, c in order", entries, err)
+	}
+}
+
+func TestIngesterDedupesWholeFileSources(t *testing.T) {
+	town := t.TempDir()
+	chats := t.TempDir()
+	chat := filepath.Join(chats, "session-g1.json")
+	msg := func(id string) string {
+		return `{"id":"` + id + `","type":"gemini","timestamp":"2026-01-02T10:00:00Z","model":"gemini-2.5-pro","tokens":{"input":10,"output":1}}`
+	}
+	write := func(ids ...string) {
+		t.Helper()
+		var msgs []string
+		for _, id := range ids {
+			msgs = append(msgs, msg(id))
+		}
+		data := `{"sessionId":"g1","messages":[` + strings.Join(msgs, ",") + `]}`
+		if err := os.WriteFile(chat, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ledger := NewLedger(town)
+	in := &Ingester{
+		Ledger:  ledger,
+		Sources: []Source{{Agent: "gemini", Globs: []string{filepath.Join(chats, "*.json")}, Parse: ParseGemini}},
+		Prices:  DefaultPrices,
+	}
+
+	write("a1")
+	if n, err := in.Run(); err != nil || n != 1 {
+		t.Fatalf("first Run = %d, %v; want 1", n, err)
+	}
+	// The chat file is rewritten whole as the session grows.
+	write("a1", "a2")
+	if n, err := in.Run(); err != nil || n != 1 {
+		t.Fatalf("second Run = %d, %v; want only the new message", n, err)
+	}
+
+	// Losing the ingest state re-reads everything, but the ledger is
+	// consulted so nothing is recorded twice.
+	if err := os.Remove(filepath.Join(ledger.Dir(), StateFile)); err != nil {
+		t.Fatal(err)
+	}
+	write("a1", "a2", "a3")
+	if n, err := in.Run(); err != nil || n != 1 {
+		t.Fatalf("Run after lost state = %d, %v; want only a3", n, err)
+	}
+	entries, _ := ledger.Entries(time.Time{})
+	if len(entries) != 3 {
+		t.Errorf("ledger has %d entries, want 3", len(entries))
+	}
+}
+
+func TestIngesterSkipsRecordStraddlingReads(t *testing.T) {
+	town := t.TempDir()
+	projects := t.TempDir()
+	transcript := filepath.Join(projects, "s1.jsonl")
+	lines := strings.SplitAfter(claudeTranscript, "\n")
+	// Stop between the two streamed lines of turn m1.
+	if err := os.WriteFile(transcript, []byte(lines[0]+lines[1]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger := NewLedger(town)
+	in := &Ingester{
+		Ledger:  ledger,
+		Sources: []Source{{Agent: "claude", Globs: []string{filepath.Join(projects, "*.jsonl")}, Incremental: true, Parse: ParseClaude}},
+		Prices:  DefaultPrices,
+	}
+	if n, err := in.Run(); err != nil || n != 1 {
+		t.Fatalf("first Run = %d, %v; want 1", n, err)
+	}
+	f, err := os.OpenFile(transcript, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(strings.Join(lines[2:], ""))
+	f.Close()
+	if n, err := in.Run(); err != nil || n != 0 {
+		t.Fatalf("second Run = %d, %v; want the rest of m1 skipped", n, err)
+	}
+}
+
+func TestWorkTimeline(t *testing.T) {
+	town := t.TempDir()
+	log := `{"ts":"2026-01-02T10:00:00Z","type":"sling","actor":"mayor","payload":{"bead":"gt-1","target":"gastown/polecats/Toast"}}
+{"ts":"2026-01-02T11:00:00Z","type":"done","actor":"gastown/polecats/Toast","payload":{"bead":"gt-1"}}
+{"ts":"2026-01-02T12:00:00Z","type":"hook","actor":"gastown/polecats/Toast","payload":{"bead":"gt-2"}}
+`
+	if err := os.WriteFile(filepath.Join(town, events.EventsFile), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := LoadWorkTimeline(town)
+	at := func(h, m int) time.Time { return time.Date(2026, 1, 2, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{at(9, 0), ""},
+		{at(10, 0), "gt-1"},
+		{at(10, 30), "gt-1"},
+		{at(11, 30), ""},
+		{at(13, 0), "gt-2"},
+	}
+	for _, tt := range tests {
+		if got := w.BeadAt("gastown/polecats/Toast", tt.t); got != tt.want {
+			t.Errorf("BeadAt(%s) = %q, want %q", tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+	if got := w.BeadAt("gastown/polecats/Other", at(10, 30)); got != "" {
+		t.Errorf("BeadAt(other) = %q, want empty", got)
+	}
+}
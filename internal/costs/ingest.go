@@ -0,0 +1,212 @@
+package costs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// StateFile records ingest progress per transcript, next to the ledger.
+const StateFile = "ingest-state.json"
+
+// ingestState is the persisted progress of all sources.
+type ingestState struct {
+	Files map[string]*FileState `json:"files"`
+}
+
+// Ingester reads new usage from transcript sources into a ledger.
+type Ingester struct {
+	Ledger  *Ledger
+	Sources []Source
+	Prices  Prices
+
+	// Attribute maps a usage record to the Gas Town agent and work that
+	// incurred it. Records it rejects (work outside the town) are skipped.
+	Attribute func(u Usage) (Attribution, bool)
+}
+
+// Run ingests everything written since the last run and returns the
+// number of new ledger entries.
+func (in *Ingester) Run() (int, error) {
+	// Stop hooks of several agents can ingest at once.
+	if err := os.MkdirAll(in.Ledger.Dir(), 0755); err != nil {
+		return 0, fmt.Errorf("creating costs directory: %w", err)
+	}
+	lock := flock.New(filepath.Join(in.Ledger.Dir(), ".ingest.lock"))
+	if err := lock.Lock(); err != nil {
+		return 0, fmt.Errorf("locking cost ledger: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	state, ok := in.loadState()
+
+	// The per-file cursors keep each record from being read twice. Without
+	// them (first run, or lost state) everything is re-read, so skip what
+	// the ledger already holds.
+	seen := make(map[string]bool)
+	if !ok {
+		existing, err := in.Ledger.Entries(time.Time{})
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range existing {
+			seen[e.ID] = true
+		}
+	}
+
+	var entries []Entry
+	for _, src := range in.Sources {
+		for _, path := range src.files() {
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			st := state.Files[path]
+			if st == nil {
+				st = &FileState{}
+			}
+			if info.Size() == st.Size && info.ModTime().Equal(st.ModTime) {
+				continue
+			}
+
+			usages, err := readSource(src, path, info, st)
+			if err != nil {
+				continue // Transcript vanished or is unreadable; retry next run
+			}
+			state.Files[path] = st
+			for _, u := range usages {
+				if e, ok := in.entry(src, u); ok && !seen[e.ID] {
+					seen[e.ID] = true
+					entries = append(entries, e)
+				}
+			}
+		}
+	}
+
+	n, err := in.Ledger.Append(entries)
+	if err != nil {
+		return n, err
+	}
+	return n, in.saveState(state)
+}
+
+// readSource reads the unread part of a transcript and parses it,
+// advancing st past the records it returns.
+func readSource(src Source, path string, info os.FileInfo, st *FileState) ([]Usage, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path comes from the source globs
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if !src.Incremental {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		st.Size, st.ModTime = info.Size(), info.ModTime()
+		usages := src.Parse(data, st)
+		if st.Recorded > len(usages) {
+			// Replaced by a shorter file: start over.
+			st.Recorded = 0
+		}
+		usages = usages[st.Recorded:]
+		st.Recorded += len(usages)
+		return usages, nil
+	}
+
+	if info.Size() < st.Offset {
+		// Truncated or replaced: start over.
+		*st = FileState{}
+	}
+	if _, err := f.Seek(st.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	// Leave a partially written last line for the next run.
+	end := bytes.LastIndexByte(data, '\n') + 1
+	data = data[:end]
+	st.Offset += int64(end)
+	st.Size, st.ModTime = st.Offset, info.ModTime()
+	usages := src.Parse(data, st)
+	// A record written as several lines (streamed Claude turns) can
+	// straddle two reads; the first read already recorded it.
+	if len(usages) > 0 && usages[0].ID == st.LastID {
+		usages = usages[1:]
+	}
+	if len(usages) > 0 {
+		st.LastID = usages[len(usages)-1].ID
+	}
+	return usages, nil
+}
+
+// entry attributes and prices a usage record.
+func (in *Ingester) entry(src Source, u Usage) (Entry, bool) {
+	attr, ok := Attribution{}, true
+	if in.Attribute != nil {
+		attr, ok = in.Attribute(u)
+	}
+	if !ok {
+		return Entry{}, false
+	}
+	if attr.Account == "" {
+		attr.Account = src.Account
+	}
+	e := Entry{
+		ID:               src.Agent + ":" + u.ID,
+		Time:             u.Time,
+		Agent:            src.Agent,
+		Model:            u.Model,
+		SessionID:        u.SessionID,
+		Attribution:      attr,
+		InputTokens:      u.InputTokens,
+		OutputTokens:     u.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens,
+		CostUSD:          u.CostUSD,
+	}
+	if e.CostUSD == 0 {
+		// Unknown models keep their token counts at zero cost.
+		if price, found := in.Prices.Lookup(u.Model); found {
+			e.CostUSD = price.Cost(e)
+		}
+	}
+	return e, true
+}
+
+// loadState returns the saved ingest state, or an empty state and false
+// if there is none or it is corrupt.
+func (in *Ingester) loadState() (*ingestState, bool) {
+	state := &ingestState{Files: make(map[string]*FileState)}
+	data, err := os.ReadFile(filepath.Join(in.Ledger.Dir(), StateFile))
+	if err != nil {
+		return state, false
+	}
+	if json.Unmarshal(data, state) != nil || state.Files == nil {
+		state.Files = make(map[string]*FileState)
+		return state, false
+	}
+	return state, true
+}
+
+func (in *Ingester) saveState(state *ingestState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshaling ingest state: %w", err)
+	}
+	path := filepath.Join(in.Ledger.Dir(), StateFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing ingest state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
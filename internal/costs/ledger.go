@@ -0,0 +1,135 @@
+// Package costs keeps a ledger of agent token usage and dollar cost.
+//
+// Entries are ingested from the session transcripts and usage logs each
+// agent runtime writes (Claude Code, Codex, Gemini CLI), priced per model,
+// and attributed to the Gas Town session, role, rig, bead and convoy that
+// incurred them. The ledger lives at <town>/costs/ledger.jsonl.
+package costs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LedgerFile is the ledger's file name within the costs directory.
+const LedgerFile = "ledger.jsonl"
+
+// Attribution says which Gas Town agent and work an entry belongs to.
+type Attribution struct {
+	Session string `json:"session,omitempty"` // gt session name (e.g., "gt-gastown-Toast")
+	Actor   string `json:"actor,omitempty"`   // agent address (e.g., "gastown/polecats/Toast")
+	Role    string `json:"role,omitempty"`
+	Rig     string `json:"rig,omitempty"`
+	Worker  string `json:"worker,omitempty"`
+	Bead    string `json:"bead,omitempty"`    // work hooked when the tokens were spent
+	Convoy  string `json:"convoy,omitempty"`  // convoy tracking Bead
+	Account string `json:"account,omitempty"` // account handle whose credentials ran the agent
+}
+
+// Entry is one priced unit of agent usage: typically a single model turn.
+type Entry struct {
+	ID        string    `json:"id"` // dedupe key, unique per agent runtime
+	Time      time.Time `json:"ts"`
+	Agent     string    `json:"agent"` // runtime preset (claude, codex, gemini)
+	Model     string    `json:"model,omitempty"`
+	SessionID string    `json:"session_id,omitempty"` // the runtime's own session ID
+	Attribution
+
+	InputTokens      int64   `json:"input_tokens,omitempty"`
+	OutputTokens     int64   `json:"output_tokens,omitempty"`
+	CacheReadTokens  int64   `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int64   `json:"cache_write_tokens,omitempty"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// Tokens returns the entry's total token count.
+func (e Entry) Tokens() int64 {
+	return e.InputTokens + e.OutputTokens + e.CacheReadTokens + e.CacheWriteTokens
+}
+
+// Ledger is the append-only cost ledger for a town.
+type Ledger struct {
+	dir string
+}
+
+// NewLedger returns the ledger for the town at townRoot.
+func NewLedger(townRoot string) *Ledger {
+	return &Ledger{dir: filepath.Join(townRoot, "costs")}
+}
+
+// Dir returns the directory holding the ledger and its ingest state.
+func (l *Ledger) Dir() string {
+	return l.dir
+}
+
+// Path returns the ledger file path.
+func (l *Ledger) Path() string {
+	return filepath.Join(l.dir, LedgerFile)
+}
+
+// Entries returns the ledger entries at or after since (all entries when
+// since is zero), in the order they were recorded. A missing ledger is
+// empty; malformed lines are skipped.
+func (l *Ledger) Entries(since time.Time) ([]Entry, error) {
+	f, err := os.Open(l.Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening cost ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading cost ledger: %w", err)
+	}
+	return entries, nil
+}
+
+// Append writes entries to the end of the ledger and returns the number
+// written. It does not read the ledger: the Ingester's per-transcript
+// cursors keep entries from being ingested twice. Callers that may race
+// with other processes append under the ingest lock (see Ingester).
+func (l *Ledger) Append(entries []Entry) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return 0, fmt.Errorf("creating costs directory: %w", err)
+	}
+	f, err := os.OpenFile(l.Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("opening cost ledger: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for i, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return i, fmt.Errorf("marshaling cost entry: %w", err)
+		}
+		_, _ = w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		return 0, fmt.Errorf("writing cost ledger: %w", err)
+	}
+	return len(entries), nil
+}
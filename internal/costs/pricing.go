@@ -0,0 +1,96 @@
+package costs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PricingFile is an optional town-level override of model prices, at
+// <town>/settings/pricing.json. It maps model name prefixes to Price.
+const PricingFile = "pricing.json"
+
+// Price is a model's list price in USD per million tokens.
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// Prices maps model name prefixes to their price. The longest matching
+// prefix wins, so "claude-opus-4-5" can be priced apart from
+// "claude-opus-4".
+type Prices map[string]Price
+
+// DefaultPrices are published list prices for the models the built-in
+// agent presets run. Override or extend them with settings/pricing.json.
+var DefaultPrices = Prices{
+	// Anthropic
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.50, CacheWrite: 6.25},
+	"claude-sonnet":     {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.10, CacheWrite: 1.25},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+
+	// OpenAI
+	"gpt-5":      {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-mini": {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5-nano": {Input: 0.05, Output: 0.40, CacheRead: 0.005},
+	"o4-mini":    {Input: 1.10, Output: 4.40, CacheRead: 0.275},
+	"o3":         {Input: 2, Output: 8, CacheRead: 0.50},
+
+	// Google
+	"gemini-2.5-pro":        {Input: 1.25, Output: 10, CacheRead: 0.31},
+	"gemini-2.5-flash":      {Input: 0.30, Output: 2.50, CacheRead: 0.075},
+	"gemini-2.5-flash-lite": {Input: 0.10, Output: 0.40, CacheRead: 0.025},
+}
+
+// LoadPrices returns DefaultPrices merged with the town's pricing
+// overrides, if any.
+func LoadPrices(townRoot string) (Prices, error) {
+	prices := make(Prices, len(DefaultPrices))
+	for model, p := range DefaultPrices {
+		prices[model] = p
+	}
+
+	data, err := os.ReadFile(filepath.Join(townRoot, "settings", PricingFile)) //nolint:gosec // G304: path is constructed internally
+	if os.IsNotExist(err) {
+		return prices, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading pricing overrides: %w", err)
+	}
+	var overrides Prices
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", PricingFile, err)
+	}
+	for model, p := range overrides {
+		prices[model] = p
+	}
+	return prices, nil
+}
+
+// Lookup returns the price for model by longest prefix match.
+func (p Prices) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	best, found := "", false
+	for prefix := range p {
+		if strings.HasPrefix(model, prefix) && len(prefix) >= len(best) {
+			best, found = prefix, true
+		}
+	}
+	return p[best], found
+}
+
+// Cost prices an entry's tokens.
+func (p Price) Cost(e Entry) float64 {
+	return (float64(e.InputTokens)*p.Input +
+		float64(e.OutputTokens)*p.Output +
+		float64(e.CacheReadTokens)*p.CacheRead +
+		float64(e.CacheWriteTokens)*p.CacheWrite) / 1e6
+}
@@ -0,0 +1,300 @@
+package costs
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Usage is one usage record read from an agent transcript, before it is
+// attributed and priced.
+type Usage struct {
+	ID          string // dedupe key within the agent runtime
+	SessionID   string
+	Model       string
+	Time        time.Time
+	Cwd         string // agent working directory, when the transcript has it
+	ProjectHash string // Gemini CLI's hash of the project root
+
+	InputTokens      int64
+	OutputTokens     int64
+	CacheReadTokens  int64
+	CacheWriteTokens int64
+	CostUSD          float64 // cost reported by the runtime; zero means price it
+}
+
+// FileState is how far a transcript has been ingested, plus context (such
+// as the session ID from a header line) that later lines depend on.
+type FileState struct {
+	Offset    int64     `json:"offset"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	SessionID string    `json:"session_id,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Cwd       string    `json:"cwd,omitempty"`
+
+	// Recorded counts the usage records of a whole-file source already
+	// ingested; LastID is the last record read from an incremental one,
+	// which may be repeated at the start of the next chunk.
+	Recorded int    `json:"recorded,omitempty"`
+	LastID   string `json:"last_id,omitempty"`
+}
+
+// Source describes where an agent runtime writes transcripts and how to
+// read usage from them.
+type Source struct {
+	Agent   string   // agent preset name
+	Account string   // account handle the transcripts belong to, if known
+	Globs   []string // transcript file patterns
+
+	// Incremental sources are JSONL files that only grow; they are read
+	// from the last ingested offset. Other sources are re-read whole
+	// whenever they change, skipping the records already ingested.
+	Incremental bool
+
+	// Parse extracts usage records from data. Parse may read and update
+	// the context fields of st.
+	Parse func(data []byte, st *FileState) []Usage
+}
+
+// files returns the transcripts currently matching the source's globs.
+func (s Source) files() []string {
+	var files []string
+	for _, pattern := range s.Globs {
+		matches, _ := filepath.Glob(pattern)
+		files = append(files, matches...)
+	}
+	return files
+}
+
+// DefaultSources returns the transcript sources for the built-in agent
+// presets under the user's home directory. accounts maps account handles
+// to their CLAUDE_CONFIG_DIRs, so Claude usage can be charged to accounts.
+func DefaultSources(accounts map[string]string) []Source {
+	home, _ := os.UserHomeDir()
+
+	var sources []Source
+	defaultDir := filepath.Join(home, ".claude")
+	claudeDirs := map[string]string{defaultDir: ""}
+	for handle, dir := range accounts {
+		claudeDirs[dir] = handle
+	}
+	for dir, handle := range claudeDirs {
+		sources = append(sources, Source{
+			Agent:       string(config.AgentClaude),
+			Account:     handle,
+			Globs:       []string{filepath.Join(dir, "projects", "*", "*.jsonl")},
+			Incremental: true,
+			Parse:       ParseClaude,
+		})
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Globs[0] < sources[j].Globs[0] })
+
+	codexHome := os.Getenv("CODEX_HOME")
+	if codexHome == "" {
+		codexHome = filepath.Join(home, ".codex")
+	}
+	codex := Source{
+		Agent:       string(config.AgentCodex),
+		Globs:       []string{filepath.Join(codexHome, "sessions", "*", "*", "*", "rollout-*.jsonl")},
+		Incremental: true,
+		Parse:       ParseCodex,
+	}
+
+	gemini := Source{
+		Agent: string(config.AgentGemini),
+		Globs: []string{filepath.Join(home, ".gemini", "tmp", "*", "chats", "session-*.json")},
+		Parse: ParseGemini,
+	}
+
+	return append(sources, codex, gemini)
+}
+
+// claudeLine is the subset of a Claude Code transcript line used here.
+type claudeLine struct {
+	Type      string    `json:"type"`
+	SessionID string    `json:"sessionId"`
+	Cwd       string    `json:"cwd"`
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"requestId"`
+	CostUSD   float64   `json:"costUSD"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// ParseClaude reads usage from Claude Code transcript lines
+// (~/.claude/projects/<project>/<session>.jsonl). Claude Code writes one
+// line per content block, each repeating the message's usage, so records
+// are keyed by message and request ID and the last line for a message wins.
+func ParseClaude(data []byte, st *FileState) []Usage {
+	var usages []Usage
+	index := make(map[string]int)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var l claudeLine
+		if len(line) == 0 || json.Unmarshal(line, &l) != nil {
+			continue
+		}
+		if l.SessionID != "" {
+			st.SessionID = l.SessionID
+		}
+		if l.Cwd != "" {
+			st.Cwd = l.Cwd
+		}
+		if l.Type != "assistant" || l.Message.Usage == nil || l.Message.Model == "<synthetic>" {
+			continue
+		}
+		u := Usage{
+			ID:               l.Message.ID + ":" + l.RequestID,
+			SessionID:        st.SessionID,
+			Model:            l.Message.Model,
+			Time:             l.Timestamp,
+			Cwd:              st.Cwd,
+			InputTokens:      l.Message.Usage.InputTokens,
+			OutputTokens:     l.Message.Usage.OutputTokens,
+			CacheReadTokens:  l.Message.Usage.CacheReadInputTokens,
+			CacheWriteTokens: l.Message.Usage.CacheCreationInputTokens,
+			CostUSD:          l.CostUSD,
+		}
+		if i, ok := index[u.ID]; ok {
+			usages[i] = u
+			continue
+		}
+		index[u.ID] = len(usages)
+		usages = append(usages, u)
+	}
+	return usages
+}
+
+// codexLine is the subset of a Codex rollout line used here.
+type codexLine struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Payload   struct {
+		ID    string `json:"id"`
+		Cwd   string `json:"cwd"`
+		Model string `json:"model"`
+		Type  string `json:"type"`
+		Info  *struct {
+			Total codexTokens `json:"total_token_usage"`
+			Last  codexTokens `json:"last_token_usage"`
+		} `json:"info"`
+	} `json:"payload"`
+}
+
+type codexTokens struct {
+	InputTokens       int64 `json:"input_tokens"`
+	CachedInputTokens int64 `json:"cached_input_tokens"`
+	OutputTokens      int64 `json:"output_tokens"`
+	TotalTokens       int64 `json:"total_tokens"`
+}
+
+// ParseCodex reads usage from Codex rollout files
+// (~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl). The session and model
+// come from session_meta and turn_context lines; each token_count event
+// carries the usage of the last turn. Cached input is reported as part of
+// input, so it is split out as cache reads.
+func ParseCodex(data []byte, st *FileState) []Usage {
+	var usages []Usage
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var l codexLine
+		if len(line) == 0 || json.Unmarshal(line, &l) != nil {
+			continue
+		}
+		switch l.Type {
+		case "session_meta":
+			st.SessionID = l.Payload.ID
+			if l.Payload.Cwd != "" {
+				st.Cwd = l.Payload.Cwd
+			}
+		case "turn_context":
+			if l.Payload.Model != "" {
+				st.Model = l.Payload.Model
+			}
+			if l.Payload.Cwd != "" {
+				st.Cwd = l.Payload.Cwd
+			}
+		case "event_msg":
+			if l.Payload.Type != "token_count" || l.Payload.Info == nil {
+				continue
+			}
+			last := l.Payload.Info.Last
+			if last.TotalTokens == 0 {
+				continue
+			}
+			usages = append(usages, Usage{
+				// Codex repeats token_count events; the running total
+				// identifies the turn.
+				ID:              st.SessionID + ":" + strconv.FormatInt(l.Payload.Info.Total.TotalTokens, 10),
+				SessionID:       st.SessionID,
+				Model:           st.Model,
+				Time:            l.Timestamp,
+				Cwd:             st.Cwd,
+				InputTokens:     last.InputTokens - last.CachedInputTokens,
+				OutputTokens:    last.OutputTokens,
+				CacheReadTokens: last.CachedInputTokens,
+			})
+		}
+	}
+	return usages
+}
+
+// geminiSession is the subset of a Gemini CLI chat file used here.
+type geminiSession struct {
+	SessionID   string `json:"sessionId"`
+	ProjectHash string `json:"projectHash"`
+	Messages    []struct {
+		ID        string    `json:"id"`
+		Timestamp time.Time `json:"timestamp"`
+		Type      string    `json:"type"`
+		Model     string    `json:"model"`
+		Tokens    *struct {
+			Input    int64 `json:"input"`
+			Output   int64 `json:"output"`
+			Cached   int64 `json:"cached"`
+			Thoughts int64 `json:"thoughts"`
+		} `json:"tokens"`
+	} `json:"messages"`
+}
+
+// ParseGemini reads usage from a Gemini CLI chat file
+// (~/.gemini/tmp/<project-hash>/chats/session-*.json), which is rewritten
+// whole as the session grows. Thinking tokens are billed as output.
+func ParseGemini(data []byte, st *FileState) []Usage {
+	var s geminiSession
+	if json.Unmarshal(data, &s) != nil {
+		return nil
+	}
+	st.SessionID = s.SessionID
+
+	var usages []Usage
+	for _, m := range s.Messages {
+		if m.Tokens == nil {
+			continue
+		}
+		usages = append(usages, Usage{
+			ID:              s.SessionID + ":" + m.ID,
+			SessionID:       s.SessionID,
+			Model:           m.Model,
+			Time:            m.Timestamp,
+			ProjectHash:     s.ProjectHash,
+			InputTokens:     m.Tokens.Input - m.Tokens.Cached,
+			OutputTokens:    m.Tokens.Output + m.Tokens.Thoughts,
+			CacheReadTokens: m.Tokens.Cached,
+		})
+	}
+	return usages
+}
@@ -0,0 +1,68 @@
+package costs
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// WorkTimeline records which bead each agent had hooked over time, so
+// usage can be charged to the work in progress when it was spent.
+type WorkTimeline struct {
+	spans map[string][]workSpan // actor -> spans sorted by start
+}
+
+// workSpan is a bead hooked from start until the next span; an empty bead
+// means the agent was idle.
+type workSpan struct {
+	start time.Time
+	bead  string
+}
+
+// LoadWorkTimeline builds a timeline from the town's event log: sling and
+// hook events start work on a bead, unhook and done events end it. A
+// missing log yields an empty timeline.
+func LoadWorkTimeline(townRoot string) *WorkTimeline {
+	w := &WorkTimeline{spans: make(map[string][]workSpan)}
+	_ = events.Each(townRoot, func(ev events.Event) {
+		ts, err := time.Parse(time.RFC3339, ev.Timestamp)
+		if err != nil {
+			return
+		}
+		bead, _ := ev.Payload["bead"].(string)
+		switch ev.Type {
+		case events.TypeSling:
+			target, _ := ev.Payload["target"].(string)
+			w.add(target, ts, bead)
+		case events.TypeHook:
+			w.add(ev.Actor, ts, bead)
+		case events.TypeUnhook, events.TypeDone:
+			w.add(ev.Actor, ts, "")
+		}
+	})
+
+	for _, spans := range w.spans {
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+	}
+	return w
+}
+
+func (w *WorkTimeline) add(actor string, ts time.Time, bead string) {
+	actor = strings.TrimSuffix(actor, "/")
+	if actor == "" {
+		return
+	}
+	w.spans[actor] = append(w.spans[actor], workSpan{start: ts, bead: bead})
+}
+
+// BeadAt returns the bead actor was working on at t, or "" if none.
+func (w *WorkTimeline) BeadAt(actor string, t time.Time) string {
+	spans := w.spans[strings.TrimSuffix(actor, "/")]
+	i := sort.Search(len(spans), func(i int) bool { return spans[i].start.After(t) })
+	if i == 0 {
+		return ""
+	}
+	return spans[i-1].bead
+}
@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/account"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// accountLimitScanLines is how much of a polecat's pane is searched for a
+// limit banner. Agents print the banner as their last output and stop, so
+// a short tail avoids matching older scrollback.
+const accountLimitScanLines = 15
+
+// checkAccountLimits looks for usage and rate limit banners in polecat
+// sessions. The account a limited polecat runs on is put into cool-down and
+// the polecat is restarted on a healthy account. Its hooked work survives
+// the restart: the hook lives on the polecat's agent bead, not the session.
+func (d *Daemon) checkAccountLimits() {
+	cfg, err := config.LoadAccountsConfig(constants.MayorAccountsPath(d.config.TownRoot))
+	if err != nil || len(cfg.Accounts) == 0 {
+		return // No accounts configured - nothing to fail over to
+	}
+	dirs := cfg.ConfigDirs()
+	handleByDir := make(map[string]string, len(dirs))
+	for handle, dir := range dirs {
+		handleByDir[dir] = handle
+	}
+
+	for _, rigName := range d.getKnownRigs() {
+		entries, err := os.ReadDir(filepath.Join(d.config.TownRoot, rigName, "polecats"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			d.checkPolecatAccountLimit(cfg, dirs, handleByDir, rigName, entry.Name())
+		}
+	}
+}
+
+// checkPolecatAccountLimit fails a single polecat over to another account
+// if its session shows a limit banner.
+func (d *Daemon) checkPolecatAccountLimit(cfg *config.AccountsConfig, dirs, handleByDir map[string]string, rigName, polecatName string) {
+	sessionName := fmt.Sprintf("gt-%s-%s", rigName, polecatName)
+	if alive, _ := d.tmux.HasSession(sessionName); !alive {
+		return
+	}
+	output, err := d.tmux.CapturePane(sessionName, accountLimitScanLines)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	limit, ok := account.DetectLimit(output, now)
+	if !ok {
+		return
+	}
+
+	configDir, _ := d.tmux.GetEnvironment(sessionName, "CLAUDE_CONFIG_DIR")
+	handle := handleByDir[configDir]
+	if handle == "" {
+		d.logger.Printf("Polecat %s/%s hit a usage limit on an unregistered account: %s", rigName, polecatName, limit.Banner)
+		return
+	}
+
+	if err := account.MarkLimited(d.config.TownRoot, handle, limit.Banner, limit.ResetAt, now); err != nil {
+		d.logger.Printf("Warning: failed to record limit on account %s: %v", handle, err)
+	}
+
+	next, err := account.Failover(d.config.TownRoot, cfg, handle, now)
+	if err != nil {
+		d.logger.Printf("Error picking account for %s/%s: %v", rigName, polecatName, err)
+		return
+	}
+	if next == "" {
+		d.logger.Printf("Polecat %s/%s is limited on %s and every other account is cooling down; leaving it to wait",
+			rigName, polecatName, handle)
+		return
+	}
+
+	d.logger.Printf("ACCOUNT LIMIT: polecat %s/%s hit a limit on %s (%s); moving it to %s",
+		rigName, polecatName, handle, limit.Banner, next)
+	if err := d.tmux.KillSession(sessionName); err != nil {
+		d.logger.Printf("Error killing session %s: %v", sessionName, err)
+		return
+	}
+	if err := d.restartPolecatSession(rigName, polecatName, sessionName, dirs[next]); err != nil {
+		d.logger.Printf("Error restarting polecat %s/%s on %s: %v", rigName, polecatName, next, err)
+		hookBead := ""
+		if info, infoErr := d.getAgentBeadInfo(beads.PolecatBeadID(rigName, polecatName)); infoErr == nil {
+			hookBead = info.HookBead
+		}
+		d.notifyWitnessOfCrashedPolecat(rigName, polecatName, hookBead, err)
+		return
+	}
+	if err := account.Assign(d.config.TownRoot, account.PolecatIdentity(rigName, polecatName), next); err != nil {
+		d.logger.Printf("Warning: failed to record account %s for %s/%s: %v", next, rigName, polecatName, err)
+	}
+	d.logger.Printf("Restarted polecat %s/%s on account %s", rigName, polecatName, next)
+}
+
+// assignedConfigDir returns the config directory of the account the
+// polecat was last started on, or "" (the default account) if none is
+// recorded or the account is no longer configured.
+func (d *Daemon) assignedConfigDir(rigName, polecatName string) string {
+	handle, err := account.AssignedTo(d.config.TownRoot, account.PolecatIdentity(rigName, polecatName))
+	if err != nil || handle == "" {
+		return ""
+	}
+	cfg, err := config.LoadAccountsConfig(constants.MayorAccountsPath(d.config.TownRoot))
+	if err != nil {
+		return ""
+	}
+	return cfg.ConfigDirs()[handle]
+}
@@ -0,0 +1,154 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/budget"
+	"github.com/steveyegge/gastown/internal/costs"
+)
+
+// budgetParkPrefix marks parks made by budget enforcement, so the daemon
+// only unparks polecats it parked itself.
+const budgetParkPrefix = "budget: "
+
+// checkBudgets measures spend against the town's budgets. A budget crossing
+// its soft or hard limit is escalated once per period; while a hard limit is
+// exceeded (and not overridden), running polecats in its scope are parked
+// directly by the daemon. Polecats parked for budget reasons are
+// unparked once their budgets stop blocking.
+func (d *Daemon) checkBudgets() {
+	limits, err := budget.LoadLimits(d.config.TownRoot)
+	if err != nil {
+		d.logger.Printf("Error loading budgets: %v", err)
+		return
+	}
+	if limits.Empty() {
+		return
+	}
+
+	// Bring the ledger up to date before measuring it.
+	ingest := exec.Command("gt", "costs", "ingest")
+	ingest.Dir = d.config.TownRoot
+	if out, err := ingest.CombinedOutput(); err != nil {
+		d.logger.Printf("Warning: cost ingest failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	report, err := budget.Evaluate(d.config.TownRoot, time.Now())
+	if err != nil {
+		d.logger.Printf("Error evaluating budgets: %v", err)
+		return
+	}
+
+	crossed, err := budget.Crossings(d.config.TownRoot, report)
+	if err != nil {
+		d.logger.Printf("Warning: failed to record budget state: %v", err)
+	}
+	for _, st := range crossed {
+		d.escalateBudget(st)
+	}
+
+	d.enforceHardBudgets(report)
+}
+
+// escalateBudget escalates a budget that crossed a limit via gt escalate,
+// so it follows the town's escalation routing.
+func (d *Daemon) escalateBudget(st budget.Status) {
+	severity, limit := "MEDIUM", st.Budget.SoftUSD
+	if st.Level == budget.LevelHard {
+		severity, limit = "HIGH", st.Budget.HardUSD
+	}
+	topic := fmt.Sprintf("Budget %s over %s limit", st.Scope, st.Level)
+	details := fmt.Sprintf("Spent $%.2f of $%.2f (%s limit) since %s.", st.Spent, limit, st.Level, budgetSince(st))
+	if st.Level == budget.LevelHard {
+		if st.Override != nil {
+			details += fmt.Sprintf("\n\nEnforcement is overridden until %s.", st.Override.Until.Format(time.RFC3339))
+		} else {
+			details += fmt.Sprintf("\n\nNew polecats in this scope are refused and running ones parked until the period resets.\nTo continue anyway: gt budget override %s --for <duration> --reason <why>", st.Scope)
+		}
+	}
+
+	cmd := exec.Command("gt", "escalate", "-s", severity, topic, "-m", details) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	cmd.Env = append(os.Environ(), "GT_ROLE=daemon")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		d.logger.Printf("Warning: failed to escalate budget %s: %v: %s", st.Scope, err, strings.TrimSpace(string(out)))
+		return
+	}
+	d.logger.Printf("Escalated budget %s (%s limit, $%.2f spent)", st.Scope, st.Level, st.Spent)
+}
+
+// budgetSince describes the start of a budget's period.
+func budgetSince(st budget.Status) string {
+	if st.Since.IsZero() {
+		return "the start"
+	}
+	return st.Since.Format("2006-01-02 15:04")
+}
+
+// enforceHardBudgets parks running polecats whose work a budget blocks and
+// unparks budget-parked polecats whose budgets no longer block.
+func (d *Daemon) enforceHardBudgets(report *budget.Report) {
+	parked, err := LoadParked(d.config.TownRoot)
+	if err != nil {
+		d.logger.Printf("Error loading parked agents: %v", err)
+		return
+	}
+
+	unparked := false
+	for _, rigName := range d.getKnownRigs() {
+		entries, err := os.ReadDir(filepath.Join(d.config.TownRoot, rigName, "polecats"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			identity := rigName + "/polecats/" + entry.Name()
+
+			// A polecat without usage yet still spends against its rig.
+			attr, ok := report.AgentAttribution(identity)
+			if !ok {
+				attr = costs.Attribution{Rig: rigName}
+			}
+			blocking := report.Blocking(attr)
+
+			p, isParked := parked[identity]
+			switch {
+			case blocking != nil && !isParked:
+				sessionName := fmt.Sprintf("gt-%s-%s", rigName, entry.Name())
+				if alive, _ := d.tmux.HasSession(sessionName); !alive {
+					continue
+				}
+				reason := fmt.Sprintf("%s%s over hard limit ($%.2f of $%.2f)",
+					budgetParkPrefix, blocking.Scope, blocking.Spent, blocking.Budget.HardUSD)
+				d.budgetPark(identity, reason)
+
+			case blocking == nil && isParked && strings.HasPrefix(p.Reason, budgetParkPrefix):
+				delete(parked, identity)
+				unparked = true
+				d.logger.Printf("Unparked %s: budget no longer exceeded", identity)
+			}
+		}
+	}
+
+	if unparked {
+		if err := SaveParked(d.config.TownRoot, parked); err != nil {
+			d.logger.Printf("Warning: failed to save parked agents: %v", err)
+		}
+	}
+}
+
+// budgetPark parks identity in-process. Budget parks do not go through
+// lifecycle mail, so no mail sender can ask for them.
+func (d *Daemon) budgetPark(identity, reason string) {
+	request := &LifecycleRequest{From: identity, Action: ActionPark, Reason: reason, Timestamp: time.Now()}
+	if err := d.executeLifecycleAction(request); err != nil {
+		d.logger.Printf("Warning: failed to park %s: %v", identity, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -20,9 +21,14 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/deacon"
+	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/feed"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/notify"
 	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/ptyd"
 	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
 
@@ -37,6 +43,15 @@ type Daemon struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 	curator *feed.Curator
+
+	// notifier delivers town events to the configured notification
+	// sinks; nil when none are configured.
+	notifier *notify.Dispatcher
+
+	// pty hosts agent sessions when the town's session backend is "pty";
+	// nil means sessions run in tmux.
+	pty   *ptyd.Supervisor
+	ptyLn net.Listener
 }
 
 // New creates a new daemon instance.
@@ -56,13 +71,50 @@ func New(config *Config) (*Daemon, error) {
 	logger := log.New(logFile, "", log.LstdFlags)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Daemon{
+	d := &Daemon{
 		config: config,
 		tmux:   tmux.NewTmux(),
 		logger: logger,
 		ctx:    ctx,
 		cancel: cancel,
-	}, nil
+	}
+
+	// With the pty backend the daemon owns every session: everything in
+	// this process uses the supervisor directly, other gt processes reach
+	// it through its socket (see Run).
+	if usesPTYSessions(config.TownRoot) {
+		d.pty = ptyd.NewSupervisor()
+		tmux.SetDefaultBackend(d.pty)
+		d.tmux = tmux.NewTmuxWithBackend(d.pty)
+	}
+
+	return d, nil
+}
+
+// usesPTYSessions reports whether the town selected the pty session backend.
+func usesPTYSessions(townRoot string) bool {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	return err == nil && settings.SessionBackend == config.SessionBackendPTY
+}
+
+// startNotifier starts delivering events to the town's notification sinks,
+// if any are configured. Configuration errors are logged, not fatal.
+func (d *Daemon) startNotifier() {
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot))
+	if err != nil || len(settings.Notifications) == 0 {
+		return
+	}
+	dispatcher, err := notify.NewDispatcher(d.config.TownRoot, settings.Notifications)
+	if err != nil {
+		d.logger.Printf("Warning: notifications disabled: %v", err)
+		return
+	}
+	if err := dispatcher.Start(); err != nil {
+		d.logger.Printf("Warning: failed to start notification dispatcher: %v", err)
+		return
+	}
+	d.notifier = dispatcher
+	d.logger.Printf("Notification dispatcher started (%d sinks)", len(settings.Notifications))
 }
 
 // Run starts the daemon main loop.
@@ -102,6 +154,21 @@ func (d *Daemon) Run() error {
 		d.logger.Printf("Warning: failed to save state: %v", err)
 	}
 
+	// Serve pty sessions before anything tries to start one
+	if d.pty != nil {
+		ln, err := ptyd.Listen(ptyd.SocketPath(d.config.TownRoot))
+		if err != nil {
+			return fmt.Errorf("starting pty supervisor: %w", err)
+		}
+		d.ptyLn = ln
+		go func() {
+			if err := d.pty.Serve(ln); err != nil {
+				d.logger.Printf("Warning: pty supervisor stopped: %v", err)
+			}
+		}()
+		d.logger.Printf("PTY supervisor listening on %s", ln.Addr())
+	}
+
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
@@ -121,6 +188,8 @@ func (d *Daemon) Run() error {
 		d.logger.Println("Feed curator started")
 	}
 
+	d.startNotifier()
+
 	// Initial heartbeat
 	d.heartbeat(state)
 
@@ -164,6 +233,8 @@ const recoveryHeartbeatInterval = 3 * time.Minute
 // - Orphaned work (assigned to dead agents)
 func (d *Daemon) heartbeat(state *State) {
 	d.logger.Println("Heartbeat starting (recovery-focused)")
+	rec := telemetry.At(d.config.TownRoot)
+	span := rec.StartSpan("daemon heartbeat", nil)
 
 	// 1. Poke Boot (the Deacon's watchdog) instead of Deacon directly
 	// Boot handles the "when to wake Deacon" decision via triage logic
@@ -184,6 +255,10 @@ func (d *Daemon) heartbeat(state *State) {
 	// Uses regex-based WaitForClaudeReady, which is acceptable for daemon bootstrap.
 	d.triggerPendingSpawns()
 
+	// 3b. Enforce budgets (escalate on soft limits, park on hard limits).
+	// Runs before lifecycle processing so park requests are handled this beat.
+	d.checkBudgets()
+
 	// 4. Process lifecycle requests
 	d.processLifecycleRequests()
 
@@ -201,6 +276,14 @@ func (d *Daemon) heartbeat(state *State) {
 	// This validates tmux sessions are still alive for polecats with work-on-hook
 	d.checkPolecatSessionHealth()
 
+	// 9. Move rate-limited polecats to a healthy account
+	d.checkAccountLimits()
+
+	// 10. Deliver scheduled mail that is due (gt mail send --at/--in, and
+	// recurring schedules), renotify recipients of unacknowledged mail
+	// (gt mail send --ack) and send senders receipts for overdue messages
+	d.deliverMail()
+
 	// Update state
 	state.LastHeartbeat = time.Now()
 	state.HeartbeatCount++
@@ -208,9 +291,29 @@ func (d *Daemon) heartbeat(state *State) {
 		d.logger.Printf("Warning: failed to save state: %v", err)
 	}
 
+	rec.Count(telemetry.MetricHeartbeats, nil)
+	rec.Observe(telemetry.MetricHeartbeatLength, time.Since(span.Start).Seconds(), nil)
+	span.SetAttr("heartbeat", strconv.FormatInt(state.HeartbeatCount, 10))
+	span.Finish(nil)
+	d.flushTelemetry()
+
 	d.logger.Printf("Heartbeat complete (#%d)", state.HeartbeatCount)
 }
 
+// flushTelemetry folds the metrics recorded by gt processes into their
+// totals and ships them, with finished spans, to the configured exporters.
+func (d *Daemon) flushTelemetry() {
+	var cfg *config.TelemetryConfig
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot)); err == nil {
+		cfg = settings.Telemetry
+	}
+	ctx, cancel := context.WithTimeout(d.ctx, 30*time.Second)
+	defer cancel()
+	if err := telemetry.Flush(ctx, d.config.TownRoot, telemetry.Exporters(d.config.TownRoot, cfg)); err != nil {
+		d.logger.Printf("Warning: telemetry export failed: %v", err)
+	}
+}
+
 // DeaconRole is the role name for the Deacon's handoff bead.
 const DeaconRole = "deacon"
 
@@ -683,6 +786,22 @@ func (d *Daemon) processLifecycleRequests() {
 	d.ProcessLifecycleRequests()
 }
 
+// deliverMail delivers scheduled mail and renotifies recipients of mail
+// that requires acknowledgement.
+func (d *Daemon) deliverMail() {
+	router := mail.NewRouterWithTownRoot(d.config.TownRoot, d.config.TownRoot)
+	delivered, err := router.DeliverScheduled()
+	if err != nil {
+		d.logger.Printf("Warning: scheduled mail: %v", err)
+	}
+	if delivered > 0 {
+		d.logger.Printf("Delivered %d scheduled message(s)", delivered)
+	}
+	if err := router.Renotify(); err != nil {
+		d.logger.Printf("Warning: mail renotification: %v", err)
+	}
+}
+
 // shutdown performs graceful shutdown.
 func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return kept for future use
 	d.logger.Println("Daemon shutting down")
@@ -693,6 +812,18 @@ func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return
 		d.logger.Println("Feed curator stopped")
 	}
 
+	if d.notifier != nil {
+		d.notifier.Stop()
+		d.logger.Println("Notification dispatcher stopped")
+	}
+
+	// PTY sessions cannot outlive their supervisor
+	if d.pty != nil {
+		_ = d.ptyLn.Close()
+		d.pty.Shutdown()
+		d.logger.Println("PTY sessions stopped")
+	}
+
 	state.Running = false
 	if err := SaveState(d.config.TownRoot, state); err != nil {
 		d.logger.Printf("Warning: failed to save final state: %v", err)
@@ -832,6 +963,11 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 		return
 	}
 
+	// Parked polecats stay down until they are unparked.
+	if d.isParked(rigName + "/polecats/" + polecatName) {
+		return
+	}
+
 	// Session is dead. Check if the polecat has work-on-hook.
 	agentBeadID := beads.PolecatBeadID(rigName, polecatName)
 	info, err := d.getAgentBeadInfo(agentBeadID)
@@ -851,17 +987,22 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 		rigName, polecatName, info.HookBead, sessionName)
 
 	// Auto-restart the polecat
-	if err := d.restartPolecatSession(rigName, polecatName, sessionName); err != nil {
+	restartErr := ""
+	if err := d.restartPolecatSession(rigName, polecatName, sessionName, d.assignedConfigDir(rigName, polecatName)); err != nil {
 		d.logger.Printf("Error restarting polecat %s/%s: %v", rigName, polecatName, err)
 		// Notify witness as fallback
 		d.notifyWitnessOfCrashedPolecat(rigName, polecatName, info.HookBead, err)
+		restartErr = err.Error()
 	} else {
 		d.logger.Printf("Successfully restarted crashed polecat %s/%s", rigName, polecatName)
 	}
+	_ = events.LogTo(d.config.TownRoot, events.TypePolecatCrashed, "daemon",
+		events.CrashPayload(rigName, polecatName, info.HookBead, restartErr), events.VisibilityFeed)
 }
 
-// restartPolecatSession restarts a crashed polecat session.
-func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string) error {
+// restartPolecatSession restarts a crashed polecat session. A non-empty
+// claudeConfigDir runs the agent on that Claude account.
+func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName, claudeConfigDir string) error {
 	// Determine working directory
 	workDir := filepath.Join(d.config.TownRoot, rigName, "polecats", polecatName)
 	rigPath := filepath.Join(d.config.TownRoot, rigName)
@@ -894,6 +1035,9 @@ func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string)
 	_ = d.tmux.SetEnvironment(sessionName, "BEADS_DIR", beadsDir)
 	_ = d.tmux.SetEnvironment(sessionName, "BEADS_NO_DAEMON", "1")
 	_ = d.tmux.SetEnvironment(sessionName, "BEADS_AGENT_NAME", fmt.Sprintf("%s/%s", rigName, polecatName))
+	if claudeConfigDir != "" {
+		_ = d.tmux.SetEnvironment(sessionName, "CLAUDE_CONFIG_DIR", claudeConfigDir)
+	}
 
 	// Apply theme
 	theme := tmux.AssignTheme(rigName)
@@ -904,7 +1048,7 @@ func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string)
 	_ = d.tmux.SetPaneDiedHook(sessionName, agentID)
 
 	// Launch the configured agent with environment exported inline
-	startCmd := config.BuildPolecatStartupCommand(rigName, polecatName, rigPath, "")
+	startCmd := config.BuildPolecatStartupCommandWithAccount(rigName, polecatName, rigPath, claudeConfigDir, "")
 	if err := d.tmux.SendKeys(sessionName, startCmd); err != nil {
 		return fmt.Errorf("sending startup command: %w", err)
 	}
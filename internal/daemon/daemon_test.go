@@ -6,6 +6,10 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/account"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -246,3 +250,31 @@ func TestLifecycleRequest_Serialization(t *testing.T) {
 		t.Errorf("Action mismatch: got %q, want %q", loaded.Action, request.Action)
 	}
 }
+
+func TestAssignedConfigDir(t *testing.T) {
+	d, cleanup := testDaemonWithTown(t, "ai")
+	defer cleanup()
+
+	cfg := &config.AccountsConfig{
+		Version: 1,
+		Default: "alpha",
+		Accounts: map[string]config.Account{
+			"alpha": {ConfigDir: "/accounts/alpha"},
+			"beta":  {ConfigDir: "/accounts/beta"},
+		},
+	}
+	if err := config.SaveAccountsConfig(constants.MayorAccountsPath(d.config.TownRoot), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := d.assignedConfigDir("gastown", "Toast"); got != "" {
+		t.Errorf("unassigned polecat config dir = %q, want default", got)
+	}
+	if err := account.Assign(d.config.TownRoot, account.PolecatIdentity("gastown", "Toast"), "beta"); err != nil {
+		t.Fatal(err)
+	}
+	// A crash restart keeps the polecat on its account.
+	if got := d.assignedConfigDir("gastown", "Toast"); got != "/accounts/beta" {
+		t.Errorf("assigned config dir = %q, want /accounts/beta", got)
+	}
+}
@@ -99,8 +99,12 @@ func (d *Daemon) ProcessLifecycleRequests() {
 
 // LifecycleBody is the structured body format for lifecycle requests.
 // Claude should send mail with JSON body: {"action": "cycle"} or {"action": "shutdown"}
+// Supervisors (the daemon and deacon) may park another agent by naming it:
+// {"action": "park", "target": "gastown/polecats/Toast", "reason": "..."}
 type LifecycleBody struct {
 	Action string `json:"action"`
+	Target string `json:"target,omitempty"` // agent to act on (park only)
+	Reason string `json:"reason,omitempty"`
 }
 
 // parseLifecycleRequest extracts a lifecycle request from a message.
@@ -139,18 +143,39 @@ func (d *Daemon) parseLifecycleRequest(msg *BeadsMessage) *LifecycleRequest {
 		action = ActionShutdown
 	case "cycle":
 		action = ActionCycle
+	case "park":
+		action = ActionPark
 	default:
 		d.logger.Printf("Unknown lifecycle action: %q", body.Action)
 		return nil
 	}
 
+	from := msg.From
+	if body.Target != "" && action == ActionPark {
+		if !isSupervisor(msg.From) {
+			d.logger.Printf("Rejected park of %s requested by %s: only the daemon or deacon may park another agent", body.Target, msg.From)
+			return nil
+		}
+		from = body.Target
+	}
+
 	return &LifecycleRequest{
-		From:      msg.From,
+		From:      from,
 		Action:    action,
+		Reason:    body.Reason,
 		Timestamp: time.Now(),
 	}
 }
 
+// isSupervisor reports whether a mail sender may act on other agents.
+func isSupervisor(sender string) bool {
+	switch strings.TrimSuffix(sender, "/") {
+	case "daemon", "deacon":
+		return true
+	}
+	return false
+}
+
 // executeLifecycleAction performs the requested lifecycle action.
 func (d *Daemon) executeLifecycleAction(request *LifecycleRequest) error {
 	// Determine session name from sender identity
@@ -185,6 +210,20 @@ func (d *Daemon) executeLifecycleAction(request *LifecycleRequest) error {
 		}
 		return nil
 
+	case ActionPark:
+		// Record the park first so a health check can't restart the
+		// session in between.
+		if err := d.parkAgent(request.From, request.Reason); err != nil {
+			return fmt.Errorf("recording park: %w", err)
+		}
+		if running {
+			if err := d.tmux.KillSession(sessionName); err != nil {
+				return fmt.Errorf("killing session: %w", err)
+			}
+		}
+		d.logger.Printf("Parked %s (%s)", request.From, request.Reason)
+		return nil
+
 	case ActionCycle, ActionRestart:
 		if running {
 			// Kill the session first
@@ -180,6 +180,86 @@ func TestParseLifecycleRequest_AlwaysUsesFromField(t *testing.T) {
 	}
 }
 
+func TestParseLifecycleRequest_Park(t *testing.T) {
+	d := testDaemon()
+
+	// Park requests come from supervisors and name the agent to park
+	msg := &BeadsMessage{
+		Subject: "LIFECYCLE: park gastown/polecats/Toast",
+		Body:    `{"action": "park", "target": "gastown/polecats/Toast", "reason": "budget: rig:gastown over hard limit"}`,
+		From:    "daemon",
+	}
+	result := d.parseLifecycleRequest(msg)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Action != ActionPark {
+		t.Errorf("action = %s, expected %s", result.Action, ActionPark)
+	}
+	if result.From != "gastown/polecats/Toast" {
+		t.Errorf("from = %q, expected the target", result.From)
+	}
+	if result.Reason != "budget: rig:gastown over hard limit" {
+		t.Errorf("reason = %q", result.Reason)
+	}
+
+	msg.From = "deacon/"
+	if result = d.parseLifecycleRequest(msg); result == nil || result.From != "gastown/polecats/Toast" {
+		t.Errorf("deacon park should act on the target, got %+v", result)
+	}
+
+	// Target is ignored for other actions
+	msg.From = "daemon"
+	msg.Body = `{"action": "cycle", "target": "gastown/polecats/Toast"}`
+	result = d.parseLifecycleRequest(msg)
+	if result == nil || result.From != "daemon" {
+		t.Errorf("cycle request should act on its sender, got %+v", result)
+	}
+}
+
+func TestParseLifecycleRequest_ParkTargetRequiresSupervisor(t *testing.T) {
+	d := testDaemon()
+
+	msg := &BeadsMessage{
+		Subject: "LIFECYCLE: park gastown/witness",
+		Body:    `{"action": "park", "target": "gastown/witness"}`,
+		From:    "gastown/polecats/Toast",
+	}
+	if result := d.parseLifecycleRequest(msg); result != nil {
+		t.Errorf("polecat-sent park of another agent was accepted: %+v", result)
+	}
+
+	// An agent may still park itself
+	msg.Body = `{"action": "park"}`
+	if result := d.parseLifecycleRequest(msg); result == nil || result.From != "gastown/polecats/Toast" {
+		t.Errorf("self park should act on the sender, got %+v", result)
+	}
+}
+
+func TestParkedAgents(t *testing.T) {
+	d, cleanup := testDaemonWithTown(t, "ai")
+	defer cleanup()
+
+	if d.isParked("gastown/polecats/Toast") {
+		t.Fatal("nothing should be parked yet")
+	}
+	if err := d.parkAgent("gastown-polecat-Toast", "budget: town over hard limit"); err != nil {
+		t.Fatalf("parkAgent: %v", err)
+	}
+	// Identities are normalized, so either form finds the park
+	if !d.isParked("gastown/polecats/Toast") || !d.isParked("gastown-polecat-Toast") {
+		t.Error("polecat should be parked")
+	}
+
+	parked, err := LoadParked(d.config.TownRoot)
+	if err != nil {
+		t.Fatalf("LoadParked: %v", err)
+	}
+	if p := parked["gastown/polecats/Toast"]; p.Reason != "budget: town over hard limit" || p.ParkedAt.IsZero() {
+		t.Errorf("parked entry = %+v", p)
+	}
+}
+
 func TestIdentityToSession_Mayor(t *testing.T) {
 	d, cleanup := testDaemonWithTown(t, "ai")
 	defer cleanup()
@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ParkedAgent records why an agent was parked. Parked agents keep their
+// worktree and hooked work, but the daemon does not restart their sessions.
+type ParkedAgent struct {
+	Reason   string    `json:"reason,omitempty"`
+	ParkedAt time.Time `json:"parked_at"`
+}
+
+// ParkedFile returns the path to the parked agents file.
+func ParkedFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "parked.json")
+}
+
+// LoadParked loads parked agents, keyed by BD_ACTOR identity
+// (e.g. "gastown/polecats/Toast").
+func LoadParked(townRoot string) (map[string]ParkedAgent, error) {
+	data, err := os.ReadFile(ParkedFile(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]ParkedAgent{}, nil
+		}
+		return nil, err
+	}
+
+	parked := make(map[string]ParkedAgent)
+	if err := json.Unmarshal(data, &parked); err != nil {
+		return nil, err
+	}
+	return parked, nil
+}
+
+// SaveParked saves parked agents to disk using atomic write.
+func SaveParked(townRoot string, parked map[string]ParkedAgent) error {
+	path := ParkedFile(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(path, parked)
+}
+
+// parkAgent records identity as parked.
+func (d *Daemon) parkAgent(identity, reason string) error {
+	parked, err := LoadParked(d.config.TownRoot)
+	if err != nil {
+		return err
+	}
+	parked[identityToBDActor(identity)] = ParkedAgent{Reason: reason, ParkedAt: time.Now()}
+	return SaveParked(d.config.TownRoot, parked)
+}
+
+// isParked reports whether identity is parked.
+func (d *Daemon) isParked(identity string) bool {
+	parked, err := LoadParked(d.config.TownRoot)
+	if err != nil {
+		return false
+	}
+	_, ok := parked[identityToBDActor(identity)]
+	return ok
+}
@@ -108,6 +108,10 @@ const (
 
 	// ActionShutdown terminates without restart.
 	ActionShutdown LifecycleAction = "shutdown"
+
+	// ActionPark stops the session but keeps the agent's worktree and
+	// hooked work; the daemon won't restart it until it is unparked.
+	ActionPark LifecycleAction = "park"
 )
 
 // LifecycleRequest represents a request from an agent to the daemon.
@@ -118,6 +122,9 @@ type LifecycleRequest struct {
 	// Action is what lifecycle action to perform.
 	Action LifecycleAction `json:"action"`
 
+	// Reason explains the request (recorded for parks).
+	Reason string `json:"reason,omitempty"`
+
 	// Timestamp is when the request was made.
 	Timestamp time.Time `json:"timestamp"`
 }
@@ -1,14 +1,14 @@
 // Package events provides event logging for the gt activity feed.
 //
 // Events are written to ~/gt/.events.jsonl (raw audit log) and later
-// curated by the feed daemon into ~/.feed.jsonl (user-facing).
+// curated by the feed daemon into ~/.feed.jsonl (user-facing). The raw log
+// rotates: older segments are archived, compressed, under ~/gt/.events/.
 package events
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +17,7 @@ import (
 
 // Event represents an activity event in Gas Town.
 type Event struct {
+	Seq        int64                  `json:"seq,omitempty"` // Position in the town's event log
 	Timestamp  string                 `json:"ts"`
 	Source     string                 `json:"source"`
 	Type       string                 `json:"type"`
@@ -46,6 +47,10 @@ const (
 	TypeBoot    = "boot"
 	TypeHalt    = "halt"
 
+	// Headless polecat events (gt sling --headless)
+	TypeAgentOutput = "agent_output"
+	TypeAgentExit   = "agent_exit"
+
 	// Session events (for seance discovery)
 	TypeSessionStart = "session_start"
 	TypeSessionEnd   = "session_end"
@@ -62,18 +67,83 @@ const (
 	TypeMerged       = "merged"
 	TypeMergeFailed  = "merge_failed"
 	TypeMergeSkipped = "merge_skipped"
+
+	// Budget events
+	TypeBudgetOverride = "budget_override"
+
+	// Convoy and crash events (notification-worthy)
+	TypeConvoyClosed   = "convoy_closed"
+	TypePolecatCrashed = "polecat_crashed"
 )
 
-// EventsFile is the name of the raw events log.
+// Severity levels, shared with gt escalate. They rank how urgently an event
+// needs human attention; LOW marks informational events.
+const (
+	SeverityCritical = "CRITICAL"
+	SeverityHigh     = "HIGH"
+	SeverityMedium   = "MEDIUM"
+	SeverityLow      = "LOW"
+)
+
+// typeSeverity is the severity of event types that don't carry one in their
+// payload. Unlisted types are LOW.
+var typeSeverity = map[string]string{
+	TypeEscalationSent: SeverityMedium,
+	TypeMergeFailed:    SeverityMedium,
+	TypePolecatCrashed: SeverityHigh,
+}
+
+// Severity returns the event's severity: the payload's "severity" if set
+// (escalations carry one), otherwise the default for its type.
+func (e Event) Severity() string {
+	if s, ok := e.Payload["severity"].(string); ok && SeverityRank(s) > 0 {
+		return strings.ToUpper(s)
+	}
+	if s, ok := typeSeverity[e.Type]; ok {
+		return s
+	}
+	return SeverityLow
+}
+
+// SeverityRank orders severities from LOW (1) to CRITICAL (4). Unknown
+// severities rank 0.
+func SeverityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// EventsFile is the name of the raw events log's active segment.
 const EventsFile = ".events.jsonl"
 
-// mutex protects concurrent writes to the events file.
+// mutex serializes this process's writers; the log's file lock serializes
+// writers across processes.
 var mutex sync.Mutex
 
 // Log writes an event to the events log.
-// The event is appended to ~/gt/.events.jsonl.
+// The event is appended to ~/gt/.events.jsonl with the next sequence number.
 // Returns nil if logging fails (events are best-effort).
 func Log(eventType, actor string, payload map[string]interface{}, visibility string) error {
+	// Find town root
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil || townRoot == "" {
+		// Silently ignore - we're not in a Gas Town workspace
+		return nil
+	}
+	return LogTo(townRoot, eventType, actor, payload, visibility)
+}
+
+// LogTo writes an event to the events log of the given town, for callers
+// (like the daemon) whose working directory may be outside it.
+func LogTo(townRoot, eventType, actor string, payload map[string]interface{}, visibility string) error {
 	event := Event{
 		Timestamp:  time.Now().UTC().Format(time.RFC3339),
 		Source:     "gt",
@@ -82,7 +152,7 @@ func Log(eventType, actor string, payload map[string]interface{}, visibility str
 		Payload:    payload,
 		Visibility: visibility,
 	}
-	return write(event)
+	return write(townRoot, event)
 }
 
 // LogFeed is a convenience wrapper for feed-visible events.
@@ -96,38 +166,11 @@ func LogAudit(eventType, actor string, payload map[string]interface{}) error {
 }
 
 // write appends an event to the events file.
-func write(event Event) error {
-	// Find town root
-	townRoot, err := workspace.FindFromCwd()
-	if err != nil || townRoot == "" {
-		// Silently ignore - we're not in a Gas Town workspace
-		return nil
-	}
-
-	eventsPath := filepath.Join(townRoot, EventsFile)
-
-	// Marshal event to JSON
-	data, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("marshaling event: %w", err)
-	}
-	data = append(data, '\n')
-
-	// Append to file with proper locking
+func write(townRoot string, event Event) error {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	f, err := os.OpenFile(eventsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: events file is non-sensitive operational data
-	if err != nil {
-		return fmt.Errorf("opening events file: %w", err)
-	}
-	defer f.Close()
-
-	if _, err := f.Write(data); err != nil {
-		return fmt.Errorf("writing event: %w", err)
-	}
-
-	return nil
+	return appendEvent(townRoot, &event)
 }
 
 // Payload helpers for common event structures.
@@ -182,6 +225,31 @@ func SpawnPayload(rig, polecat string) map[string]interface{} {
 	}
 }
 
+// AgentOutputPayload creates a payload for a headless agent's structured
+// output line. kind is the line's type field as reported by the agent.
+func AgentOutputPayload(rig, polecat, kind, text string) map[string]interface{} {
+	p := map[string]interface{}{
+		"rig":     rig,
+		"polecat": polecat,
+		"kind":    kind,
+	}
+	if text != "" {
+		p["text"] = text
+	}
+	return p
+}
+
+// AgentExitPayload creates a payload for a headless agent's exit.
+// status is the gt done exit status recorded for the work.
+func AgentExitPayload(rig, polecat string, exitCode int, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"rig":       rig,
+		"polecat":   polecat,
+		"exit_code": exitCode,
+		"status":    status,
+	}
+}
+
 // BootPayload creates a payload for rig boot events.
 func BootPayload(rig string, agents []string) map[string]interface{} {
 	return map[string]interface{}{
@@ -274,6 +342,42 @@ func HaltPayload(services []string) map[string]interface{} {
 	}
 }
 
+// BudgetOverridePayload creates a payload for budget override events.
+// until is zero when an override is cleared.
+func BudgetOverridePayload(scope string, until time.Time, reason string) map[string]interface{} {
+	p := map[string]interface{}{
+		"scope":  scope,
+		"reason": reason,
+	}
+	if !until.IsZero() {
+		p["until"] = until.UTC().Format(time.RFC3339)
+	}
+	return p
+}
+
+// ConvoyPayload creates a payload for convoy events.
+func ConvoyPayload(convoyID, title string) map[string]interface{} {
+	return map[string]interface{}{
+		"convoy": convoyID,
+		"title":  title,
+	}
+}
+
+// CrashPayload creates a payload for polecat crash events.
+// restartErr is empty when the polecat was restarted.
+func CrashPayload(rig, polecat, beadID, restartErr string) map[string]interface{} {
+	p := map[string]interface{}{
+		"rig":       rig,
+		"polecat":   polecat,
+		"bead":      beadID,
+		"restarted": restartErr == "",
+	}
+	if restartErr != "" {
+		p["error"] = restartErr
+	}
+	return p
+}
+
 // SessionPayload creates a payload for session start/end events.
 // sessionID: Claude Code session UUID
 // role: Gas Town role (e.g., "gastown/crew/joe", "deacon")
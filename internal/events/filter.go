@@ -0,0 +1,311 @@
+package events
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is the view of an event that filters match against. Logged events
+// convert with Event.Record; other feeds (such as the TUI's) build their own.
+type Record struct {
+	Seq        int64
+	Time       time.Time
+	Type       string
+	Actor      string
+	Rig        string
+	Source     string
+	Visibility string
+	Severity   string
+	Payload    map[string]interface{}
+}
+
+// Record returns the event's filterable view. The rig comes from the
+// payload when present, otherwise from the actor's address.
+func (e Event) Record() Record {
+	ts, _ := time.Parse(time.RFC3339, e.Timestamp)
+	rig, _ := e.Payload["rig"].(string)
+	if rig == "" {
+		rig = ActorRig(e.Actor)
+	}
+	return Record{
+		Seq:        e.Seq,
+		Time:       ts,
+		Type:       e.Type,
+		Actor:      e.Actor,
+		Rig:        rig,
+		Source:     e.Source,
+		Visibility: e.Visibility,
+		Severity:   e.Severity(),
+		Payload:    e.Payload,
+	}
+}
+
+// ActorRig returns the rig of a rig-scoped actor address such as
+// "gastown/polecats/Toast", or "" for town-level agents.
+func ActorRig(actor string) string {
+	rig, _, ok := strings.Cut(actor, "/")
+	if !ok || rig == "mayor" || rig == "deacon" {
+		return ""
+	}
+	return rig
+}
+
+// Field returns a record field by its filter name: type, actor, rig,
+// source, visibility, severity, seq or payload.<key>.
+func (r Record) Field(name string) (string, bool) {
+	switch name {
+	case "type":
+		return r.Type, true
+	case "actor":
+		return r.Actor, true
+	case "rig":
+		return r.Rig, true
+	case "source":
+		return r.Source, true
+	case "visibility":
+		return r.Visibility, true
+	case "severity":
+		return r.Severity, true
+	case "seq":
+		return strconv.FormatInt(r.Seq, 10), true
+	}
+	if key, ok := strings.CutPrefix(name, "payload."); ok {
+		v, ok := r.Payload[key]
+		if !ok || v == nil {
+			return "", false
+		}
+		if s, isString := v.(string); isString {
+			return s, true
+		}
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// Filter is a parsed event query. A query is a space-separated list of
+// terms, all of which must hold:
+//
+//	type=sling,done           field equals any of the values
+//	actor!=mayor              field equals none of the values
+//	actor=gastown/*           values are globs: * matches any run, ? one character
+//	payload.bead=gt-abc       payload fields are compared as text
+//	since=2h until=30m        time range: durations ago, dates or RFC3339 times
+//
+// Values containing spaces can be double-quoted. A nil Filter matches
+// every record.
+type Filter struct {
+	Since time.Time
+	Until time.Time
+
+	terms []filterTerm
+	expr  string
+}
+
+type filterTerm struct {
+	field    string
+	negate   bool
+	patterns []*regexp.Regexp
+}
+
+// filterFields are the fields a term may name, besides payload.<key>.
+var filterFields = map[string]bool{
+	"type": true, "actor": true, "rig": true, "source": true, "visibility": true, "severity": true, "seq": true,
+}
+
+// ParseFilter parses a query. Relative times are resolved against now.
+func ParseFilter(expr string, now time.Time) (*Filter, error) {
+	tokens, err := splitQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Filter{expr: joinQuery(tokens)}
+	for _, tok := range tokens {
+		field, value, negate, ok := cutTerm(tok)
+		if !ok {
+			return nil, fmt.Errorf("invalid term %q: expected field=value", tok)
+		}
+		switch field {
+		case "since", "until":
+			if negate {
+				return nil, fmt.Errorf("invalid term %q: %s does not support !=", tok, field)
+			}
+			t, err := ParseTime(value, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", field, err)
+			}
+			if field == "since" {
+				f.Since = t
+			} else {
+				f.Until = t
+			}
+			continue
+		}
+		if !filterFields[field] && !strings.HasPrefix(field, "payload.") {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		term := filterTerm{field: field, negate: negate}
+		for _, v := range strings.Split(value, ",") {
+			term.patterns = append(term.patterns, globPattern(v))
+		}
+		f.terms = append(f.terms, term)
+	}
+	return f, nil
+}
+
+// MustParseFilter parses a query that contains no relative times, panicking
+// on error. It is meant for filters fixed at compile time.
+func MustParseFilter(expr string) *Filter {
+	f, err := ParseFilter(expr, time.Time{})
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Match reports whether the record satisfies every term of the filter.
+func (f *Filter) Match(r Record) bool {
+	if f == nil {
+		return true
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.Time.After(f.Until) {
+		return false
+	}
+	for _, term := range f.terms {
+		// A missing payload field matches no value, not even "*".
+		value, present := r.Field(term.field)
+		matched := false
+		for _, p := range term.patterns {
+			if present && p.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if matched == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the query the filter was parsed from.
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// ParseTime parses a point in time for a query: a duration before now
+// ("90s", "2h", "7d", "2w"), a date ("2006-01-02", local time) or an
+// RFC3339 timestamp.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if d, err := ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use a duration (2h, 7d), a date or an RFC3339 time", s)
+}
+
+// ParseDuration parses a duration, additionally accepting days ("7d") and
+// weeks ("2w").
+func ParseDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// cutTerm splits "field=value" or "field!=value".
+func cutTerm(tok string) (field, value string, negate, ok bool) {
+	i := strings.Index(tok, "=")
+	if i <= 0 {
+		return "", "", false, false
+	}
+	field, value = tok[:i], tok[i+1:]
+	if strings.HasSuffix(field, "!") {
+		field, negate = strings.TrimSuffix(field, "!"), true
+	}
+	return field, value, negate, field != ""
+}
+
+// splitQuery splits a query on whitespace, keeping double-quoted runs
+// together and dropping the quotes.
+func splitQuery(expr string) ([]string, error) {
+	var tokens []string
+	var cur strings.Builder
+	inQuote, inToken := false, false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			inToken = true
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n'):
+			if inToken {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inToken = false
+			}
+		default:
+			cur.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in %q", expr)
+	}
+	if inToken {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens, nil
+}
+
+// joinQuery is the inverse of splitQuery.
+func joinQuery(tokens []string) string {
+	quoted := make([]string, len(tokens))
+	for i, tok := range tokens {
+		if strings.ContainsAny(tok, " \t\n") {
+			field, value, _ := strings.Cut(tok, "=")
+			tok = field + `="` + value + `"`
+		}
+		quoted[i] = tok
+	}
+	return strings.Join(quoted, " ")
+}
+
+// globPattern compiles a glob into an anchored regexp.
+func globPattern(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
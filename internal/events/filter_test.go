@@ -0,0 +1,144 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Date(2026, 1, 8, 12, 0, 0, 0, time.UTC)
+	sling := Event{
+		Seq:        3,
+		Timestamp:  now.Add(-30 * time.Minute).Format(time.RFC3339),
+		Source:     "gt",
+		Type:       TypeSling,
+		Actor:      "gastown/polecats/Toast",
+		Payload:    map[string]interface{}{"bead": "gt-abc", "count": 2.0},
+		Visibility: VisibilityFeed,
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"type=sling", true},
+		{"type=done,sling", true},
+		{"type!=sling", false},
+		{"actor=gastown/*", true},
+		{"actor=gastown/polecats/T??st", true},
+		{"actor=mayor*", false},
+		{"rig=gastown", true},
+		{"rig=beads", false},
+		{"payload.bead=gt-abc", true},
+		{"payload.count=2", true},
+		{"payload.missing=*", false},
+		{"payload.missing!=x", true},
+		{"since=1h", true},
+		{"since=10m", false},
+		{"until=1h", false},
+		{"since=2026-01-07 until=2026-01-08T11:45:00Z", true},
+		{`type=sling visibility=feed,both "actor=gastown/polecats/Toast"`, true},
+		{"seq=3", true},
+		{"severity=LOW", true},
+		{"severity=HIGH,CRITICAL", false},
+	}
+	for _, tc := range tests {
+		f, err := ParseFilter(tc.query, now)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tc.query, err)
+			continue
+		}
+		if got := f.Match(sling.Record()); got != tc.want {
+			t.Errorf("%q matched = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, query := range []string{
+		"sling",
+		"colour=red",
+		"since=yesterday",
+		"since!=2h",
+		`type="sling`,
+	} {
+		if _, err := ParseFilter(query, time.Now()); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded, want error", query)
+		}
+	}
+}
+
+func TestFilterString(t *testing.T) {
+	f, err := ParseFilter(`type=mail  payload.subject="hello world"`, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `type=mail payload.subject="hello world"`
+	if f.String() != want {
+		t.Errorf("String = %q, want %q", f.String(), want)
+	}
+	if !f.Match(Record{Type: TypeMail, Payload: map[string]interface{}{"subject": "hello world"}}) {
+		t.Error("quoted value did not match")
+	}
+
+	var none *Filter
+	if none.String() != "" || !none.Match(Record{}) {
+		t.Error("nil filter should be empty and match everything")
+	}
+}
+
+func TestRecordRig(t *testing.T) {
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{Event{Actor: "gastown/witness"}, "gastown"},
+		{Event{Actor: "mayor/"}, ""},
+		{Event{Actor: "deacon"}, ""},
+		{Event{Actor: "mayor", Payload: map[string]interface{}{"rig": "beads"}}, "beads"},
+	}
+	for _, tc := range tests {
+		if got := tc.event.Record().Rig; got != tc.want {
+			t.Errorf("rig of %+v = %q, want %q", tc.event, got, tc.want)
+		}
+	}
+}
+
+func TestEventSeverity(t *testing.T) {
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{Event{Type: TypeSling}, SeverityLow},
+		{Event{Type: TypeMergeFailed}, SeverityMedium},
+		{Event{Type: TypePolecatCrashed}, SeverityHigh},
+		{Event{Type: TypeEscalationSent, Payload: map[string]interface{}{"severity": "critical"}}, SeverityCritical},
+		{Event{Type: TypeEscalationSent, Payload: map[string]interface{}{"severity": "bogus"}}, SeverityMedium},
+	}
+	for _, tc := range tests {
+		if got := tc.event.Severity(); got != tc.want {
+			t.Errorf("severity of %s %v = %q, want %q", tc.event.Type, tc.event.Payload, got, tc.want)
+		}
+	}
+	if SeverityRank(SeverityHigh) <= SeverityRank(SeverityMedium) || SeverityRank("nope") != 0 {
+		t.Error("SeverityRank misorders severities")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90s": 90 * time.Second,
+		"2h":  2 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+	}
+	for in, want := range tests {
+		if got, err := ParseDuration(in); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseDuration("xd"); err == nil {
+		t.Error("ParseDuration(xd) succeeded")
+	}
+}
@@ -0,0 +1,232 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/flock"
+)
+
+// Segments returns the paths of the event log's segments, oldest first:
+// archived segments, then the active one.
+func Segments(townRoot string) ([]string, error) {
+	dir := filepath.Join(townRoot, EventsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading events directory: %w", err)
+	}
+
+	// A segment caught mid-compression exists both plain and gzipped; the
+	// plain file is complete, the gzipped one may not have been renamed in.
+	byBase := make(map[string]string)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, segmentPrefix) {
+			continue
+		}
+		base := strings.TrimSuffix(name, gzipExt)
+		if !strings.HasSuffix(base, segmentExt) {
+			continue
+		}
+		if prev, ok := byBase[base]; ok && prev == base {
+			continue
+		}
+		byBase[base] = name
+	}
+
+	bases := make([]string, 0, len(byBase))
+	for base := range byBase {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	paths := make([]string, 0, len(bases)+1)
+	for _, base := range bases {
+		paths = append(paths, filepath.Join(dir, byBase[base]))
+	}
+	return append(paths, filepath.Join(townRoot, EventsFile)), nil
+}
+
+// ScanLines calls fn with each line of townRoot's event log, oldest first,
+// across all segments. A missing log has no lines.
+func ScanLines(townRoot string, fn func(line []byte)) error {
+	files, err := openSegments(townRoot)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	for _, f := range files {
+		var r io.Reader = f
+		if strings.HasSuffix(f.Name(), gzipExt) {
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				continue // Unreadable segment - skip it
+			}
+			r = zr
+		}
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			fn(scanner.Bytes())
+		}
+		if err := scanner.Err(); err != nil && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("reading %s: %w", filepath.Base(f.Name()), err)
+		}
+	}
+	return nil
+}
+
+// Each calls fn with each event in townRoot's event log, oldest first.
+// Malformed lines are skipped.
+func Each(townRoot string, fn func(Event)) error {
+	return ScanLines(townRoot, func(line []byte) {
+		var e Event
+		if json.Unmarshal(line, &e) == nil {
+			fn(e)
+		}
+	})
+}
+
+// FindLast returns the most recent event in townRoot's log that matches f.
+func FindLast(townRoot string, f *Filter) (Event, bool, error) {
+	var last Event
+	found := false
+	err := Each(townRoot, func(e Event) {
+		if f.Match(e.Record()) {
+			last, found = e, true
+		}
+	})
+	return last, found, err
+}
+
+// openSegments opens every segment under a shared lock, so a concurrent
+// rotation can't move the active segment between listing and opening.
+// Once open, the files stay readable whatever writers do next.
+func openSegments(townRoot string) ([]*os.File, error) {
+	dir := filepath.Join(townRoot, EventsDir)
+	if _, err := os.Stat(dir); err == nil {
+		lock := flock.New(filepath.Join(dir, lockFile))
+		if err := lock.RLock(); err == nil {
+			defer func() { _ = lock.Unlock() }()
+		}
+	}
+
+	paths, err := Segments(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	var files []*os.File
+	for _, path := range paths {
+		f, err := os.Open(path) //nolint:gosec // G304: path is constructed internally
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return nil, fmt.Errorf("opening events segment: %w", err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// Tailer follows the event log from the moment it is opened, across
+// rotations of the active segment. It must be polled more often than the
+// log rotates: a segment rotated in and out between two polls is skipped.
+type Tailer struct {
+	path    string
+	file    *os.File
+	partial []byte
+}
+
+// NewTailer returns a Tailer positioned at the end of townRoot's event log.
+// The active segment is created if it doesn't exist yet.
+func NewTailer(townRoot string) (*Tailer, error) {
+	path := filepath.Join(townRoot, EventsFile)
+	file, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0644) //nolint:gosec // G302: events file is non-sensitive operational data
+	if err != nil {
+		return nil, fmt.Errorf("opening events file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("seeking to end: %w", err)
+	}
+	return &Tailer{path: path, file: file}, nil
+}
+
+// Poll returns the complete lines appended since the last call. A line
+// still being written is held back until its newline arrives.
+func (t *Tailer) Poll() ([]string, error) {
+	lines, err := t.drain()
+	if err != nil {
+		return lines, err
+	}
+
+	// Writers rename the active segment away when rotating. Once a new one
+	// exists, finish the old file (it may have grown since the read above)
+	// and continue from the start of the new one.
+	cur, err := t.file.Stat()
+	if err != nil {
+		return lines, nil
+	}
+	next, err := os.Stat(t.path)
+	if err != nil || os.SameFile(cur, next) {
+		return lines, nil
+	}
+	rest, _ := t.drain()
+	lines = append(lines, rest...)
+
+	file, err := os.Open(t.path)
+	if err != nil {
+		return lines, nil // Rotated again meanwhile; retry next poll
+	}
+	_ = t.file.Close()
+	t.file = file
+	t.partial = nil // A torn line at the end of a rotated segment never completes
+
+	rest, err = t.drain()
+	return append(lines, rest...), err
+}
+
+// drain reads the current file to its end.
+func (t *Tailer) drain() ([]string, error) {
+	data, err := io.ReadAll(t.file)
+	if len(data) == 0 {
+		return nil, err
+	}
+	data = append(t.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		t.partial = data
+		return nil, err
+	}
+	t.partial = append([]byte(nil), data[end+1:]...)
+
+	var lines []string
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, err
+}
+
+// Close releases the Tailer's file.
+func (t *Tailer) Close() error {
+	return t.file.Close()
+}
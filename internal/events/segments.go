@@ -0,0 +1,286 @@
+package events
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// The event log is a series of segments. New events are appended to the
+// active segment (EventsFile in the town root). When it grows too large or
+// too old it is moved into EventsDir and compressed, and a fresh active
+// segment is started. Every gt process writes to the log, so appends and
+// rotation happen under a cross-process file lock.
+
+// EventsDir is the directory, relative to the town root, holding archived
+// segments and the log's lock and state files.
+const EventsDir = ".events"
+
+// Rotation thresholds for the active segment.
+var (
+	segmentMaxBytes int64 = 16 << 20
+	segmentMaxAge         = 24 * time.Hour
+)
+
+const (
+	segmentPrefix = "events-"
+	segmentExt    = ".jsonl"
+	gzipExt       = ".gz"
+	lockFile      = ".lock"
+	stateFile     = "state.json"
+
+	// seqScanWindow is how much of the active segment's tail is read to
+	// find the last sequence number before falling back to a full scan.
+	seqScanWindow = 64 * 1024
+)
+
+// segmentState records the active segment's metadata. The last sequence
+// number lives in the log itself; state only carries it across rotation,
+// when the active segment is empty.
+type segmentState struct {
+	Started  time.Time `json:"started"`
+	FirstSeq int64     `json:"first_seq"`
+	LastSeq  int64     `json:"last_seq"`
+}
+
+// appendEvent assigns the event the next sequence number and appends it to
+// the active segment of townRoot's event log, rotating first if needed.
+func appendEvent(townRoot string, event *Event) error {
+	dir := filepath.Join(townRoot, EventsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating events directory: %w", err)
+	}
+
+	lock := flock.New(filepath.Join(dir, lockFile))
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking events log: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	activePath := filepath.Join(townRoot, EventsFile)
+	st := loadSegmentState(dir)
+	now := time.Now()
+
+	info, err := os.Stat(activePath)
+	var size int64
+	if err == nil {
+		size = info.Size()
+	}
+	if size > 0 && !st.Started.IsZero() && (size >= segmentMaxBytes || now.Sub(st.Started) >= segmentMaxAge) {
+		if st, err = rotate(townRoot, st, now); err != nil {
+			return err
+		}
+		size = 0
+	}
+
+	last, err := lastSeq(activePath, size)
+	if err != nil {
+		return err
+	}
+	if st.LastSeq > last {
+		last = st.LastSeq
+	}
+	event.Seq = last + 1
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+	data = append(data, '\n')
+
+	f, err := os.OpenFile(activePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644) //nolint:gosec // G302: events file is non-sensitive operational data
+	if err != nil {
+		return fmt.Errorf("opening events file: %w", err)
+	}
+	defer f.Close()
+
+	// A writer that crashed mid-append leaves a torn last line. Start on a
+	// fresh line so the torn one stays a single malformed line readers skip.
+	if size > 0 && !endsWithNewline(f, size) {
+		data = append([]byte{'\n'}, data...)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("writing event: %w", err)
+	}
+
+	if st.Started.IsZero() {
+		st = segmentState{Started: now, FirstSeq: event.Seq, LastSeq: last}
+		if err := util.AtomicWriteJSON(filepath.Join(dir, stateFile), st); err != nil {
+			return fmt.Errorf("saving events state: %w", err)
+		}
+	}
+	return nil
+}
+
+// rotate archives the active segment and returns the state of the new,
+// empty one. The caller holds the log lock.
+func rotate(townRoot string, st segmentState, now time.Time) (segmentState, error) {
+	dir := filepath.Join(townRoot, EventsDir)
+	activePath := filepath.Join(townRoot, EventsFile)
+
+	last, err := lastSeq(activePath, -1)
+	if err != nil {
+		return st, err
+	}
+	if st.LastSeq > last {
+		last = st.LastSeq
+	}
+
+	name := fmt.Sprintf("%s%012d-%s%s", segmentPrefix, st.FirstSeq, st.Started.UTC().Format("20060102T150405Z"), segmentExt)
+	archived := filepath.Join(dir, name)
+	if err := os.Rename(activePath, archived); err != nil {
+		return st, fmt.Errorf("archiving events segment: %w", err)
+	}
+
+	next := segmentState{Started: now, FirstSeq: last + 1, LastSeq: last}
+	if err := util.AtomicWriteJSON(filepath.Join(dir, stateFile), next); err != nil {
+		return st, fmt.Errorf("saving events state: %w", err)
+	}
+
+	// Compression is best-effort: an uncompressed segment is still read,
+	// and is picked up again at the next rotation.
+	compressSegments(dir)
+	return next, nil
+}
+
+// compressSegments gzips every archived segment not yet compressed.
+func compressSegments(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := gzipFile(path, path+gzipExt); err == nil {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// gzipFile writes a compressed copy of src to dst via a temp file, so dst
+// only ever appears complete.
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+func loadSegmentState(dir string) segmentState {
+	var st segmentState
+	data, err := os.ReadFile(filepath.Join(dir, stateFile)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return st
+	}
+	_ = json.Unmarshal(data, &st)
+	return st
+}
+
+// lastSeq returns the highest sequence number in the segment at path, or 0
+// if it has none. size is the segment's size if already known, or -1.
+func lastSeq(path string, size int64) (int64, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("opening events file: %w", err)
+	}
+	defer f.Close()
+
+	if size < 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("reading events file: %w", err)
+		}
+		size = info.Size()
+	}
+	if size == 0 {
+		return 0, nil
+	}
+
+	// Sequence numbers only grow, so the last line carrying one is enough.
+	offset := size - seqScanWindow
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, size-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil && err != io.EOF {
+		return 0, fmt.Errorf("reading events file: %w", err)
+	}
+	if seq := lastSeqIn(tail); seq > 0 || offset == 0 {
+		return seq, nil
+	}
+
+	// Events larger than the window, or a log written before sequence
+	// numbers existed: scan the whole segment.
+	all, err := io.ReadAll(io.NewSectionReader(f, 0, size))
+	if err != nil {
+		return 0, fmt.Errorf("reading events file: %w", err)
+	}
+	return lastSeqIn(all), nil
+}
+
+// lastSeqIn returns the sequence number of the last line in data that has
+// one, skipping torn and malformed lines.
+func lastSeqIn(data []byte) int64 {
+	lines := bytes.Split(data, []byte{'\n'})
+	for i := len(lines) - 1; i >= 0; i-- {
+		var e struct {
+			Seq int64 `json:"seq"`
+		}
+		if json.Unmarshal(lines[i], &e) == nil && e.Seq > 0 {
+			return e.Seq
+		}
+	}
+	return 0
+}
+
+func endsWithNewline(f *os.File, size int64) bool {
+	b := make([]byte, 1)
+	if _, err := f.ReadAt(b, size-1); err != nil {
+		return true
+	}
+	return b[0] == '\n'
+}
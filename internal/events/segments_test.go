@@ -0,0 +1,189 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readSeqs(t *testing.T, townRoot string) []int64 {
+	t.Helper()
+	var seqs []int64
+	if err := Each(townRoot, func(e Event) { seqs = append(seqs, e.Seq) }); err != nil {
+		t.Fatalf("Each: %v", err)
+	}
+	return seqs
+}
+
+func TestAppendEventConcurrentWriters(t *testing.T) {
+	town := t.TempDir()
+
+	// Each goroutine opens its own lock, as separate gt processes do.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				if err := appendEvent(town, &Event{Type: TypeNudge, Visibility: VisibilityAudit}); err != nil {
+					t.Errorf("appendEvent: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	seqs := readSeqs(t, town)
+	if len(seqs) != 200 {
+		t.Fatalf("read %d events, want 200", len(seqs))
+	}
+	for i, seq := range seqs {
+		if seq != int64(i+1) {
+			t.Fatalf("event %d has seq %d, want %d", i, seq, i+1)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	town := t.TempDir()
+	oldBytes := segmentMaxBytes
+	segmentMaxBytes = 200
+	defer func() { segmentMaxBytes = oldBytes }()
+
+	for i := 0; i < 10; i++ {
+		if err := appendEvent(town, &Event{Type: TypeSling, Actor: "mayor"}); err != nil {
+			t.Fatalf("appendEvent: %v", err)
+		}
+	}
+
+	paths, err := Segments(town)
+	if err != nil {
+		t.Fatalf("Segments: %v", err)
+	}
+	if len(paths) < 3 {
+		t.Fatalf("segments = %v, want several", paths)
+	}
+	for _, p := range paths[:len(paths)-1] {
+		if !strings.HasSuffix(p, segmentExt+gzipExt) {
+			t.Errorf("archived segment %s not compressed", filepath.Base(p))
+		}
+	}
+	if paths[len(paths)-1] != filepath.Join(town, EventsFile) {
+		t.Errorf("last segment = %s, want the active one", paths[len(paths)-1])
+	}
+
+	seqs := readSeqs(t, town)
+	if len(seqs) != 10 {
+		t.Fatalf("read %d events across segments, want 10", len(seqs))
+	}
+	for i, seq := range seqs {
+		if seq != int64(i+1) {
+			t.Errorf("event %d has seq %d, want %d", i, seq, i+1)
+		}
+	}
+}
+
+func TestRotationByAge(t *testing.T) {
+	town := t.TempDir()
+	if err := appendEvent(town, &Event{Type: TypeSling}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Backdate the segment past its maximum age.
+	dir := filepath.Join(town, EventsDir)
+	st := loadSegmentState(dir)
+	st.Started = time.Now().Add(-segmentMaxAge - time.Minute)
+	if err := os.WriteFile(filepath.Join(dir, stateFile), []byte(`{"started":"`+st.Started.Format(time.RFC3339)+`","first_seq":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appendEvent(town, &Event{Type: TypeDone}); err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := Segments(town)
+	if len(paths) != 2 {
+		t.Fatalf("segments = %v, want one archived and the active one", paths)
+	}
+	if seqs := readSeqs(t, town); len(seqs) != 2 || seqs[1] != 2 {
+		t.Errorf("seqs = %v, want [1 2]", seqs)
+	}
+}
+
+func TestAppendEventAfterTornLine(t *testing.T) {
+	town := t.TempDir()
+	active := filepath.Join(town, EventsFile)
+	legacy := `{"ts":"2026-01-02T10:00:00Z","type":"sling","actor":"mayor"}` + "\n" + `{"seq":7,"type":"hook"}` + "\n" + `{"seq":8,"ty`
+	if err := os.WriteFile(active, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appendEvent(town, &Event{Type: TypeDone}); err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	if err := Each(town, func(e Event) { types = append(types, e.Type) }); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(types, ",") != "sling,hook,done" {
+		t.Errorf("types = %v, want the torn line skipped", types)
+	}
+	if seqs := readSeqs(t, town); seqs[len(seqs)-1] != 8 {
+		t.Errorf("seq after torn line = %d, want 8", seqs[len(seqs)-1])
+	}
+}
+
+func TestTailerFollowsRotation(t *testing.T) {
+	town := t.TempDir()
+	oldBytes := segmentMaxBytes
+	segmentMaxBytes = 100
+	defer func() { segmentMaxBytes = oldBytes }()
+
+	if err := appendEvent(town, &Event{Type: "before"}); err != nil {
+		t.Fatal(err)
+	}
+	tailer, err := NewTailer(town)
+	if err != nil {
+		t.Fatalf("NewTailer: %v", err)
+	}
+	defer tailer.Close()
+
+	// Write a partial line by hand: it is held back until completed.
+	active := filepath.Join(town, EventsFile)
+	f, err := os.OpenFile(active, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"seq":2,"type":"partial"`)
+	if lines, _ := tailer.Poll(); len(lines) != 0 {
+		t.Errorf("Poll = %v, want nothing before the newline", lines)
+	}
+	_, _ = f.WriteString("}\n")
+	_ = f.Close()
+
+	// Each pair of events rotates the active segment once.
+	var got []string
+	for i := 0; i < 6; i++ {
+		if err := appendEvent(town, &Event{Type: "after"}); err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 1 {
+			lines, err := tailer.Poll()
+			if err != nil {
+				t.Fatalf("Poll: %v", err)
+			}
+			got = append(got, lines...)
+		}
+	}
+	if len(got) != 7 || !strings.Contains(got[0], "partial") {
+		t.Fatalf("tailed %d lines %v, want the completed line and 6 events", len(got), got)
+	}
+	for _, line := range got {
+		if strings.Contains(line, "before") {
+			t.Errorf("tailer returned an event written before it opened: %s", line)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 // Package feed provides the feed daemon that curates raw events into a user-facing feed.
 //
 // The curator:
-// 1. Tails ~/gt/.events.jsonl (raw events)
+// 1. Tails ~/gt/.events.jsonl (raw events), following it across rotations
 // 2. Filters by visibility tag (drops audit-only events)
 // 3. Deduplicates repeated updates (5 molecule updates → "agent active")
 // 4. Aggregates related events (3 issues closed → "batch complete")
@@ -9,11 +9,9 @@
 package feed
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -70,6 +68,21 @@ const (
 	minAggregateCount = 3
 )
 
+// Curation rules, expressed as event filters.
+var (
+	// feedVisible selects the events that belong in the curated feed.
+	feedVisible = events.MustParseFilter("visibility=" + events.VisibilityFeed + "," + events.VisibilityBoth)
+
+	// dedupeDone selects events deduplicated per actor within doneDedupeWindow.
+	dedupeDone = events.MustParseFilter("type=" + events.TypeDone)
+
+	// aggregateSling selects events aggregated per actor within slingAggregateWindow.
+	aggregateSling = events.MustParseFilter("type=" + events.TypeSling)
+
+	// aggregateMail selects events counted per actor within mailAggregateWindow.
+	aggregateMail = events.MustParseFilter("type=" + events.TypeMail)
+)
+
 // NewCurator creates a new feed curator.
 func NewCurator(townRoot string) *Curator {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -85,22 +98,14 @@ func NewCurator(townRoot string) *Curator {
 
 // Start begins the curator goroutine.
 func (c *Curator) Start() error {
-	eventsPath := filepath.Join(c.townRoot, events.EventsFile)
-
-	// Open events file, creating if needed
-	file, err := os.OpenFile(eventsPath, os.O_RDONLY|os.O_CREATE, 0644) //nolint:gosec // G302: events file is non-sensitive operational data
+	// Start at the end of the log to only process new events
+	tailer, err := events.NewTailer(c.townRoot)
 	if err != nil {
-		return fmt.Errorf("opening events file: %w", err)
-	}
-
-	// Seek to end to only process new events
-	if _, err := file.Seek(0, io.SeekEnd); err != nil {
-		_ = file.Close() //nolint:gosec // G104: best effort cleanup on error
-		return fmt.Errorf("seeking to end: %w", err)
+		return err
 	}
 
 	c.wg.Add(1)
-	go c.run(file)
+	go c.run(tailer)
 
 	return nil
 }
@@ -112,11 +117,10 @@ func (c *Curator) Stop() {
 }
 
 // run is the main curator loop.
-func (c *Curator) run(file *os.File) {
+func (c *Curator) run(tailer *events.Tailer) {
 	defer c.wg.Done()
-	defer file.Close()
+	defer tailer.Close()
 
-	reader := bufio.NewReader(file)
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -134,11 +138,8 @@ func (c *Curator) run(file *os.File) {
 
 		case <-ticker.C:
 			// Read available lines
-			for {
-				line, err := reader.ReadString('\n')
-				if err != nil {
-					break // No more data available
-				}
+			lines, _ := tailer.Poll()
+			for _, line := range lines {
 				c.processLine(line)
 			}
 		}
@@ -157,7 +158,7 @@ func (c *Curator) processLine(line string) {
 	}
 
 	// Filter by visibility - only process feed-visible events
-	if rawEvent.Visibility != events.VisibilityFeed && rawEvent.Visibility != events.VisibilityBoth {
+	if !feedVisible.Match(rawEvent.Record()) {
 		return
 	}
 
@@ -178,8 +179,9 @@ func (c *Curator) shouldDedupe(event *events.Event) bool {
 
 	now := time.Now()
 
-	switch event.Type {
-	case events.TypeDone:
+	record := event.Record()
+	switch {
+	case dedupeDone.Match(record):
 		// Dedupe repeated done events from same actor within window
 		if lastDone, ok := c.recentDone[event.Actor]; ok {
 			if now.Sub(lastDone) < doneDedupeWindow {
@@ -189,7 +191,7 @@ func (c *Curator) shouldDedupe(event *events.Event) bool {
 		c.recentDone[event.Actor] = now
 		return false
 
-	case events.TypeSling:
+	case aggregateSling.Match(record):
 		// Track for potential aggregation (but don't dedupe single slings)
 		target, _ := event.Payload["target"].(string)
 		c.recentSling[event.Actor] = append(c.recentSling[event.Actor], slingRecord{
@@ -200,7 +202,7 @@ func (c *Curator) shouldDedupe(event *events.Event) bool {
 		c.recentSling[event.Actor] = c.pruneRecords(c.recentSling[event.Actor], slingAggregateWindow)
 		return false
 
-	case events.TypeMail:
+	case aggregateMail.Match(record):
 		// Track mail count for potential aggregation
 		c.recentMail[event.Actor]++
 		// Reset after window (rough approximation)
@@ -264,13 +266,13 @@ func (c *Curator) writeFeedEvent(event *events.Event) {
 		Source:    event.Source,
 		Type:      event.Type,
 		Actor:     event.Actor,
-		Summary:   c.generateSummary(event),
+		Summary:   Summarize(event),
 		Payload:   event.Payload,
 	}
 
 	// Check for aggregation opportunity
 	c.mu.Lock()
-	if event.Type == events.TypeSling {
+	if aggregateSling.Match(event.Record()) {
 		if records := c.recentSling[event.Actor]; len(records) >= minAggregateCount {
 			feedEvent.Count = len(records)
 			feedEvent.Summary = fmt.Sprintf("%s dispatching work to %d agents", event.Actor, len(records))
@@ -294,8 +296,8 @@ func (c *Curator) writeFeedEvent(event *events.Event) {
 	_, _ = f.Write(data)
 }
 
-// generateSummary creates a human-readable summary of an event.
-func (c *Curator) generateSummary(event *events.Event) string {
+// Summarize creates a human-readable summary of an event.
+func Summarize(event *events.Event) string {
 	switch event.Type {
 	case events.TypeSling:
 		if target, ok := event.Payload["target"].(string); ok {
@@ -346,6 +348,28 @@ func (c *Curator) generateSummary(event *events.Event) string {
 		}
 		return "Merge failed"
 
+	case events.TypeEscalationSent:
+		if reason, ok := event.Payload["reason"].(string); ok {
+			return fmt.Sprintf("%s escalated: %s", event.Actor, reason)
+		}
+		return fmt.Sprintf("%s escalated", event.Actor)
+
+	case events.TypeConvoyClosed:
+		if title, ok := event.Payload["title"].(string); ok && title != "" {
+			return fmt.Sprintf("Convoy landed: %s", title)
+		}
+		return "Convoy landed"
+
+	case events.TypePolecatCrashed:
+		polecat, _ := event.Payload["rig"].(string)
+		if name, ok := event.Payload["polecat"].(string); ok {
+			polecat += "/" + name
+		}
+		if restarted, _ := event.Payload["restarted"].(bool); restarted {
+			return fmt.Sprintf("Polecat %s crashed and was restarted", polecat)
+		}
+		return fmt.Sprintf("Polecat %s crashed; restart failed", polecat)
+
 	default:
 		return fmt.Sprintf("%s: %s", event.Actor, event.Type)
 	}
@@ -153,11 +153,6 @@ func TestCurator_DedupesDoneEvents(t *testing.T) {
 }
 
 func TestCurator_GeneratesSummary(t *testing.T) {
-	tmpDir, _ := os.MkdirTemp("", "feed-test-*")
-	defer os.RemoveAll(tmpDir)
-
-	curator := NewCurator(tmpDir)
-
 	tests := []struct {
 		event    *events.Event
 		expected string
@@ -185,12 +180,20 @@ func TestCurator_GeneratesSummary(t *testing.T) {
 			},
 			expected: "gastown/witness handed off to fresh session",
 		},
+		{
+			event: &events.Event{
+				Type:    events.TypePolecatCrashed,
+				Actor:   "daemon",
+				Payload: events.CrashPayload("gastown", "slit", "gt-123", ""),
+			},
+			expected: "Polecat gastown/slit crashed and was restarted",
+		},
 	}
 
 	for _, tc := range tests {
-		summary := curator.generateSummary(tc.event)
+		summary := Summarize(tc.event)
 		if summary != tc.expected {
-			t.Errorf("generateSummary(%s): expected %q, got %q", tc.event.Type, tc.expected, summary)
+			t.Errorf("Summarize(%s): expected %q, got %q", tc.event.Type, tc.expected, summary)
 		}
 	}
 }
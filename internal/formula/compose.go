@@ -0,0 +1,401 @@
+package formula
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Resolver finds the formulas a composition refers to by name: first in
+// its directories (e.g. the town's .beads/formulas), then in the embedded
+// formula library.
+type Resolver struct {
+	Dirs []string
+}
+
+// ParseWith parses formula.toml content, resolving extends, include and
+// compose against r.
+func ParseWith(data []byte, r *Resolver) (*Formula, error) {
+	return r.parse(data, make(map[string]bool))
+}
+
+func (r *Resolver) parse(data []byte, visiting map[string]bool) (*Formula, error) {
+	var f Formula
+	if _, err := toml.Decode(string(data), &f); err != nil {
+		return nil, fmt.Errorf("parsing TOML: %w", err)
+	}
+
+	if f.isComposed() {
+		if f.Name != "" {
+			visiting[f.Name] = true
+			defer delete(visiting, f.Name)
+		}
+		if err := r.resolve(&f, visiting); err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", f.Name, err)
+		}
+	}
+
+	// Infer type from content if not explicitly set
+	f.inferType()
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// load finds and parses the named formula.
+func (r *Resolver) load(name string, visiting map[string]bool) (*Formula, error) {
+	if visiting[name] {
+		return nil, fmt.Errorf("composition cycle through formula %s", name)
+	}
+
+	file := name + ".formula.toml"
+	var data []byte
+	for _, dir := range r.Dirs {
+		if b, err := os.ReadFile(filepath.Join(dir, file)); err == nil { //nolint:gosec // G304: path is from trusted formula directory
+			data = b
+			break
+		}
+	}
+	if data == nil {
+		b, err := formulasFS.ReadFile("formulas/" + file)
+		if err != nil {
+			return nil, fmt.Errorf("formula %s not found", name)
+		}
+		data = b
+	}
+
+	f, err := r.parse(data, visiting)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", name, err)
+	}
+	return f, nil
+}
+
+func (f *Formula) isComposed() bool {
+	return len(f.Extends) > 0 || len(f.Includes) > 0 || f.Compose != nil
+}
+
+// resolve flattens a composed workflow in place. Steps come from each
+// extended formula in order, then included steps, then the formula's own
+// steps; a step whose ID is already present overrides it, inheriting any
+// field it leaves unset. Expansions and aspects are then applied, and the
+// composition fields are cleared.
+func (r *Resolver) resolve(f *Formula, visiting map[string]bool) error {
+	var steps []Step
+	vars := make(map[string]Var)
+
+	for _, name := range f.Extends {
+		parent, err := r.load(name, visiting)
+		if err != nil {
+			return err
+		}
+		if parent.Type != TypeWorkflow {
+			return fmt.Errorf("cannot extend %s formula %s", parent.Type, name)
+		}
+		if f.Type == "" {
+			f.Type = parent.Type
+		}
+		steps = mergeSteps(steps, parent.Steps)
+		for k, v := range parent.Vars {
+			vars[k] = v
+		}
+	}
+
+	for _, inc := range f.Includes {
+		src, err := r.load(inc.Formula, visiting)
+		if err != nil {
+			return err
+		}
+		picked, err := pickSteps(src, inc.Steps)
+		if err != nil {
+			return err
+		}
+		steps = mergeSteps(steps, picked)
+		for k, v := range src.Vars {
+			if _, ok := vars[k]; !ok {
+				vars[k] = v
+			}
+		}
+	}
+
+	if f.Type == "" && len(steps) > 0 {
+		f.Type = TypeWorkflow
+	}
+	if f.Type != "" && f.Type != TypeWorkflow {
+		return fmt.Errorf("composition is only supported for workflow formulas")
+	}
+
+	steps = mergeSteps(steps, f.Steps)
+	for k, v := range f.Vars {
+		vars[k] = v
+	}
+
+	if f.Compose != nil {
+		for _, e := range f.Compose.Expand {
+			exp, err := r.load(e.With, visiting)
+			if err != nil {
+				return err
+			}
+			if steps, err = expandStep(steps, e.Target, exp); err != nil {
+				return err
+			}
+		}
+		for _, name := range f.Compose.Aspects {
+			aspect, err := r.load(name, visiting)
+			if err != nil {
+				return err
+			}
+			if aspect.Type != TypeAspect || len(aspect.Advice) == 0 {
+				return fmt.Errorf("%s has no advice to compose", name)
+			}
+			steps = weaveAspect(steps, aspect)
+		}
+	}
+
+	f.Steps = steps
+	if len(vars) > 0 {
+		f.Vars = vars
+	}
+	f.Extends, f.Includes, f.Compose = nil, nil, nil
+	return nil
+}
+
+// mergeSteps overlays steps onto base by ID. Overriding steps keep their
+// position in base; new steps are appended.
+func mergeSteps(base, overlay []Step) []Step {
+	out := append([]Step(nil), base...)
+	for _, o := range overlay {
+		idx := -1
+		for i := range out {
+			if out[i].ID == o.ID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			out = append(out, o)
+			continue
+		}
+		merged := out[idx]
+		if o.Title != "" {
+			merged.Title = o.Title
+		}
+		if o.Description != "" {
+			merged.Description = o.Description
+		}
+		if o.Needs != nil {
+			merged.Needs = o.Needs
+		}
+		if o.When != "" {
+			merged.When = o.When
+		}
+		if o.Timeout != "" {
+			merged.Timeout = o.Timeout
+		}
+		if o.Retry != nil {
+			merged.Retry = o.Retry
+		}
+		if o.Foreach != nil {
+			merged.Foreach = o.Foreach
+		}
+		out[idx] = merged
+	}
+	return out
+}
+
+// pickSteps returns the steps of src named in ids, or all of them.
+func pickSteps(src *Formula, ids []string) ([]Step, error) {
+	if len(ids) == 0 {
+		return src.Steps, nil
+	}
+	var picked []Step
+	for _, id := range ids {
+		step := src.GetStep(id)
+		if step == nil {
+			return nil, fmt.Errorf("formula %s has no step %q to include", src.Name, id)
+		}
+		picked = append(picked, *step)
+	}
+	return picked, nil
+}
+
+// expandStep replaces the target step with the templates of an expansion
+// formula bound to it. The expansion's first templates take over the
+// target's needs, and steps that needed the target need its last ones.
+func expandStep(steps []Step, target string, exp *Formula) ([]Step, error) {
+	if exp.Type != TypeExpansion {
+		return nil, fmt.Errorf("cannot expand with %s formula %s", exp.Type, exp.Name)
+	}
+	idx := -1
+	for i := range steps {
+		if steps[i].ID == target {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("expand target %q is not a step", target)
+	}
+	t := steps[idx]
+
+	inst, err := exp.Instantiate(map[string]string{
+		TargetVar:                  t.ID,
+		TargetVar + ".title":       t.Title,
+		TargetVar + ".description": t.Description,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	needed := make(map[string]bool)
+	for _, tmpl := range inst.Template {
+		for _, n := range tmpl.Needs {
+			needed[n] = true
+		}
+	}
+	var expanded []Step
+	var leaves []string
+	for _, tmpl := range inst.Template {
+		step := Step{ID: tmpl.ID, Title: tmpl.Title, Description: tmpl.Description, Needs: tmpl.Needs}
+		if len(step.Needs) == 0 {
+			step.Needs = t.Needs
+		}
+		if !needed[tmpl.ID] {
+			leaves = append(leaves, tmpl.ID)
+		}
+		expanded = append(expanded, step)
+	}
+
+	out := make([]Step, 0, len(steps)+len(expanded)-1)
+	out = append(out, steps[:idx]...)
+	out = append(out, expanded...)
+	out = append(out, steps[idx+1:]...)
+	for i := range out {
+		out[i].Needs = replaceNeed(out[i].Needs, target, leaves)
+	}
+	return out, nil
+}
+
+// weaveAspect inserts an aspect's advice around the steps it matches.
+// Before-advice takes over the step's needs and the step then needs the
+// last before-step; after-advice needs the step, and the step's dependents
+// need the last after-step instead. Advice placeholders {step.id} and
+// {step.title} name the advised step.
+func weaveAspect(steps []Step, aspect *Formula) []Step {
+	out := append([]Step(nil), steps...)
+	for _, adv := range aspect.Advice {
+		globs := []string{adv.Target}
+		if adv.Target == "" {
+			globs = nil
+			for _, pc := range aspect.Pointcuts {
+				globs = append(globs, pc.Glob)
+			}
+		}
+		before, after := adv.Before, adv.After
+		if adv.Around != nil {
+			before = append(append([]Step(nil), adv.Around.Before...), before...)
+			after = append(append([]Step(nil), after...), adv.Around.After...)
+		}
+
+		// Match against the steps as they were before this advice, so
+		// inserted steps are never advised by the advice that made them.
+		var targets []string
+		for _, s := range out {
+			if matchesAny(s.ID, globs) {
+				targets = append(targets, s.ID)
+			}
+		}
+		for _, id := range targets {
+			out = adviseStep(out, id, before, after)
+		}
+	}
+	return out
+}
+
+func adviseStep(steps []Step, id string, before, after []Step) []Step {
+	idx := -1
+	for i := range steps {
+		if steps[i].ID == id {
+			idx = i
+			break
+		}
+	}
+	target := steps[idx]
+	vars := map[string]string{"step.id": target.ID, "step.title": target.Title}
+	bind := func(s Step) Step {
+		s.ID = Substitute(s.ID, vars)
+		s.Title = Substitute(s.Title, vars)
+		s.Description = Substitute(s.Description, vars)
+		s.Needs = substituteAll(s.Needs, vars)
+		return s
+	}
+
+	var pre []Step
+	prev := target.Needs
+	for _, b := range before {
+		b = bind(b)
+		b.Needs = append(append([]string(nil), prev...), b.Needs...)
+		pre = append(pre, b)
+		prev = []string{b.ID}
+	}
+	target.Needs = prev
+
+	var post []Step
+	last := target.ID
+	for _, a := range after {
+		a = bind(a)
+		a.Needs = append([]string{last}, a.Needs...)
+		post = append(post, a)
+		last = a.ID
+	}
+
+	out := make([]Step, 0, len(steps)+len(pre)+len(post))
+	out = append(out, steps[:idx]...)
+	out = append(out, pre...)
+	out = append(out, target)
+	out = append(out, post...)
+	end := len(out)
+	out = append(out, steps[idx+1:]...)
+	for i := range out {
+		if i >= idx && i < end {
+			continue
+		}
+		out[i].Needs = replaceNeed(out[i].Needs, target.ID, []string{last})
+	}
+	return out
+}
+
+// replaceNeed replaces id in needs with the given IDs.
+func replaceNeed(needs []string, id string, with []string) []string {
+	var out []string
+	replaced := false
+	for _, n := range needs {
+		if n == id {
+			if !replaced {
+				out = append(out, with...)
+				replaced = true
+			}
+			continue
+		}
+		out = append(out, n)
+	}
+	if !replaced {
+		return needs
+	}
+	return out
+}
+
+func matchesAny(id string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, id); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,310 @@
+package formula
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFormula(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name+".formula.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func stepIDs(f *Formula) []string {
+	var ids []string
+	for _, s := range f.Steps {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+const baseWorkflow = `
+formula = "base"
+type = "workflow"
+
+[[steps]]
+id = "design"
+title = "Design"
+
+[[steps]]
+id = "build"
+title = "Build"
+description = "Build it."
+needs = ["design"]
+
+[[steps]]
+id = "ship"
+title = "Ship"
+needs = ["build"]
+
+[vars.feature]
+required = true
+`
+
+func TestParseWith_ExtendsOverridesByID(t *testing.T) {
+	dir := t.TempDir()
+	writeFormula(t, dir, "base", baseWorkflow)
+
+	f, err := ParseWith([]byte(`
+formula = "child"
+extends = "base"
+
+[[steps]]
+id = "build"
+title = "Build carefully"
+
+[[steps]]
+id = "announce"
+title = "Announce"
+needs = ["ship"]
+
+[vars.feature]
+default = "login"
+`), &Resolver{Dirs: []string{dir}})
+	if err != nil {
+		t.Fatalf("ParseWith: %v", err)
+	}
+
+	if f.Type != TypeWorkflow {
+		t.Errorf("Type = %s, want inherited workflow", f.Type)
+	}
+	if got, want := stepIDs(f), []string{"design", "build", "ship", "announce"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+	build := f.GetStep("build")
+	if build.Title != "Build carefully" {
+		t.Errorf("build title = %q, want override", build.Title)
+	}
+	if build.Description != "Build it." || len(build.Needs) != 1 {
+		t.Errorf("build = %+v, want description and needs inherited", build)
+	}
+	if v := f.Vars["feature"]; v.Default != "login" || v.Required {
+		t.Errorf("feature var = %+v, want child's declaration", v)
+	}
+	if f.Extends != nil || f.Compose != nil {
+		t.Error("composition fields not cleared")
+	}
+}
+
+func TestParseWith_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFormula(t, dir, "base", baseWorkflow)
+
+	f, err := ParseWith([]byte(`
+formula = "fragment"
+type = "workflow"
+
+[[include]]
+formula = "base"
+steps = ["design"]
+
+[[steps]]
+id = "spike"
+title = "Spike"
+needs = ["design"]
+`), &Resolver{Dirs: []string{dir}})
+	if err != nil {
+		t.Fatalf("ParseWith: %v", err)
+	}
+	if got, want := stepIDs(f), []string{"design", "spike"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+	if _, ok := f.Vars["feature"]; !ok {
+		t.Error("included formula's vars not merged")
+	}
+
+	_, err = ParseWith([]byte(`
+formula = "bad"
+[[include]]
+formula = "base"
+steps = ["missing"]
+`), &Resolver{Dirs: []string{dir}})
+	if err == nil || !strings.Contains(err.Error(), `no step "missing"`) {
+		t.Errorf("err = %v, want missing step error", err)
+	}
+}
+
+func TestParseWith_AspectAdvice(t *testing.T) {
+	dir := t.TempDir()
+	writeFormula(t, dir, "base", baseWorkflow)
+	writeFormula(t, dir, "audit", `
+formula = "audit"
+type = "aspect"
+
+[[advice]]
+[[advice.before]]
+id = "{step.id}-check"
+title = "Check {step.title}"
+
+[[advice.after]]
+id = "{step.id}-verify"
+title = "Verify {step.id}"
+
+[[pointcuts]]
+glob = "b*"
+`)
+
+	f, err := ParseWith([]byte(`
+formula = "audited"
+extends = ["base"]
+
+[compose]
+aspects = ["audit"]
+`), &Resolver{Dirs: []string{dir}})
+	if err != nil {
+		t.Fatalf("ParseWith: %v", err)
+	}
+
+	want := []string{"design", "build-check", "build", "build-verify", "ship"}
+	if got := stepIDs(f); !reflect.DeepEqual(got, want) {
+		t.Fatalf("steps = %v, want %v", got, want)
+	}
+	needs := map[string][]string{
+		"build-check":  {"design"},
+		"build":        {"build-check"},
+		"build-verify": {"build"},
+		"ship":         {"build-verify"},
+	}
+	for id, want := range needs {
+		if got := f.GetStep(id).Needs; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s needs = %v, want %v", id, got, want)
+		}
+	}
+	if got := f.GetStep("build-check").Title; got != "Check Build" {
+		t.Errorf("advice title = %q, want step placeholders bound", got)
+	}
+}
+
+func TestParseWith_Expand(t *testing.T) {
+	dir := t.TempDir()
+	writeFormula(t, dir, "base", baseWorkflow)
+	writeFormula(t, dir, "twice", `
+formula = "twice"
+type = "expansion"
+
+[[template]]
+id = "{target}.first"
+title = "First: {target.title}"
+
+[[template]]
+id = "{target}.second"
+title = "Second"
+needs = ["{target}.first"]
+`)
+
+	f, err := ParseWith([]byte(`
+formula = "expanded"
+extends = ["base"]
+
+[[compose.expand]]
+target = "build"
+with = "twice"
+`), &Resolver{Dirs: []string{dir}})
+	if err != nil {
+		t.Fatalf("ParseWith: %v", err)
+	}
+
+	want := []string{"design", "build.first", "build.second", "ship"}
+	if got := stepIDs(f); !reflect.DeepEqual(got, want) {
+		t.Fatalf("steps = %v, want %v", got, want)
+	}
+	if got := f.GetStep("build.first"); got.Title != "First: Build" || !reflect.DeepEqual(got.Needs, []string{"design"}) {
+		t.Errorf("build.first = %+v, want target's title and needs", got)
+	}
+	if got := f.GetStep("ship").Needs; !reflect.DeepEqual(got, []string{"build.second"}) {
+		t.Errorf("ship needs = %v, want last expanded step", got)
+	}
+}
+
+func TestParseWith_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFormula(t, dir, "loop-a", "formula = \"loop-a\"\nextends = \"loop-b\"\n")
+	writeFormula(t, dir, "loop-b", "formula = \"loop-b\"\nextends = \"loop-a\"\n")
+	writeFormula(t, dir, "base", baseWorkflow)
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"cycle", `formula = "loop-a"
+extends = "loop-b"`, "cycle"},
+		{"unknown", `formula = "x"
+extends = "nope"`, "not found"},
+		{"extend convoy", `formula = "x"
+extends = "code-review"`, "cannot extend convoy"},
+		{"expand missing target", `formula = "x"
+extends = "base"
+[[compose.expand]]
+target = "missing"
+with = "rule-of-five"`, "not a step"},
+		{"duplicate advice", `formula = "x"
+extends = "shiny"
+[compose]
+aspects = ["security-audit", "security-audit"]`, "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWith([]byte(tt.src), &Resolver{Dirs: []string{dir}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestParse_EmbeddedFormulas checks every embedded formula parses, which
+// resolves the composed ones against the rest of the library.
+func TestParse_EmbeddedFormulas(t *testing.T) {
+	entries, err := formulasFS.ReadDir("formulas")
+	if err != nil {
+		t.Fatalf("reading embedded formulas: %v", err)
+	}
+	for _, e := range entries {
+		t.Run(e.Name(), func(t *testing.T) {
+			data, err := formulasFS.ReadFile("formulas/" + e.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Parse(data); err != nil {
+				t.Errorf("Parse: %v", err)
+			}
+		})
+	}
+
+	data, _ := formulasFS.ReadFile("formulas/shiny-secure.formula.toml")
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse shiny-secure: %v", err)
+	}
+	if f.GetStep("implement-security-prescan") == nil || f.GetStep("submit-security-postscan") == nil {
+		t.Errorf("shiny-secure steps = %v, want security advice woven in", stepIDs(f))
+	}
+}
+
+func TestParseFile_ResolvesFromSearchPaths(t *testing.T) {
+	projectDir, townDir := t.TempDir(), t.TempDir()
+	writeFormula(t, townDir, "base", baseWorkflow)
+	writeFormula(t, projectDir, "child", `
+formula = "child"
+extends = "base"
+`)
+
+	path := filepath.Join(projectDir, "child.formula.toml")
+	if _, err := ParseFile(path); err == nil {
+		t.Fatal("ParseFile found base without the town search path")
+	}
+	f, err := ParseFile(path, townDir)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if got, want := stepIDs(f), []string{"design", "build", "ship"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition is a parsed step when condition.
+//
+// A condition is one or more comparisons joined by && and ||, where &&
+// binds tighter:
+//
+//	vars.mode == "fast"
+//	steps.check-queue.output != "empty" && !vars.dry_run
+//	steps.scan.status == skipped || vars.force
+//
+// Operands are vars.NAME, steps.ID.output and steps.ID.status (done or
+// skipped). A bare operand is true unless empty, "false", "0" or "no";
+// ! negates it. Values may be quoted with " or ', or bare words.
+type Condition struct {
+	any [][]comparison // OR of ANDs
+}
+
+type comparison struct {
+	negate  bool
+	operand string // vars.NAME or steps.ID.FIELD
+	op      string // "", "==" or "!="
+	value   string
+}
+
+// ParseCondition parses a when expression.
+func ParseCondition(expr string) (*Condition, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+
+	c := &Condition{}
+	var all []comparison
+	for i := 0; i < len(tokens); {
+		var cmp comparison
+		if tokens[i].text == "!" && !tokens[i].quoted {
+			cmp.negate = true
+			i++
+		}
+		if i >= len(tokens) || tokens[i].quoted || isConditionOp(tokens[i].text) {
+			return nil, fmt.Errorf("expected operand in %q", expr)
+		}
+		cmp.operand = tokens[i].text
+		if err := checkOperand(cmp.operand); err != nil {
+			return nil, err
+		}
+		i++
+
+		if i < len(tokens) && (tokens[i].text == "==" || tokens[i].text == "!=") && !tokens[i].quoted {
+			if cmp.negate {
+				return nil, fmt.Errorf("! cannot negate a comparison in %q", expr)
+			}
+			cmp.op = tokens[i].text
+			i++
+			if i >= len(tokens) || (!tokens[i].quoted && isConditionOp(tokens[i].text)) {
+				return nil, fmt.Errorf("expected value after %s in %q", cmp.op, expr)
+			}
+			cmp.value = tokens[i].text
+			i++
+		}
+		all = append(all, cmp)
+
+		if i == len(tokens) {
+			break
+		}
+		switch tokens[i].text {
+		case "&&":
+		case "||":
+			c.any = append(c.any, all)
+			all = nil
+		default:
+			return nil, fmt.Errorf("expected && or || before %q in %q", tokens[i].text, expr)
+		}
+		i++
+		if i == len(tokens) {
+			return nil, fmt.Errorf("dangling %s in %q", tokens[i-1].text, expr)
+		}
+	}
+	c.any = append(c.any, all)
+	return c, nil
+}
+
+// StepRefs returns the IDs of the steps the condition reads.
+func (c *Condition) StepRefs() []string {
+	return c.refs("steps.")
+}
+
+// VarRefs returns the names of the vars the condition reads.
+func (c *Condition) VarRefs() []string {
+	return c.refs("vars.")
+}
+
+func (c *Condition) refs(prefix string) []string {
+	seen := make(map[string]bool)
+	var refs []string
+	for _, all := range c.any {
+		for _, cmp := range all {
+			if !strings.HasPrefix(cmp.operand, prefix) {
+				continue
+			}
+			ref := strings.TrimPrefix(cmp.operand, prefix)
+			if prefix == "steps." {
+				ref = ref[:strings.LastIndex(ref, ".")]
+			}
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// Eval evaluates the condition against a run's state.
+func (c *Condition) Eval(state *RunState) bool {
+	for _, all := range c.any {
+		ok := true
+		for _, cmp := range all {
+			if !cmp.eval(state) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (cmp comparison) eval(state *RunState) bool {
+	val := resolveOperand(cmp.operand, state)
+	switch cmp.op {
+	case "==":
+		return val == cmp.value
+	case "!=":
+		return val != cmp.value
+	}
+	return truthy(val) != cmp.negate
+}
+
+func resolveOperand(operand string, state *RunState) string {
+	if name, ok := strings.CutPrefix(operand, "vars."); ok {
+		return state.Vars[name]
+	}
+	ref := strings.TrimPrefix(operand, "steps.")
+	dot := strings.LastIndex(ref, ".")
+	id, field := ref[:dot], ref[dot+1:]
+	if field == "status" {
+		return string(state.Status[id])
+	}
+	return strings.TrimSpace(state.Outputs[id])
+}
+
+func truthy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "false", "0", "no":
+		return false
+	}
+	return true
+}
+
+func checkOperand(operand string) error {
+	if name, ok := strings.CutPrefix(operand, "vars."); ok {
+		if name == "" {
+			return fmt.Errorf("missing var name in %q", operand)
+		}
+		return nil
+	}
+	if ref, ok := strings.CutPrefix(operand, "steps."); ok {
+		dot := strings.LastIndex(ref, ".")
+		if dot <= 0 {
+			return fmt.Errorf("expected steps.ID.output or steps.ID.status, got %q", operand)
+		}
+		if field := ref[dot+1:]; field != "output" && field != "status" {
+			return fmt.Errorf("unknown step field %q in %q (want output or status)", field, operand)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown operand %q (want vars.NAME or steps.ID.output)", operand)
+}
+
+func isConditionOp(s string) bool {
+	switch s {
+	case "&&", "||", "==", "!=", "!":
+		return true
+	}
+	return false
+}
+
+type conditionToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeCondition splits a condition into operators, quoted strings and
+// bare words.
+func tokenizeCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			i++
+		case ch == '"' || ch == '\'':
+			end := strings.IndexByte(expr[i+1:], ch)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %q", expr)
+			}
+			tokens = append(tokens, conditionToken{text: expr[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"),
+			strings.HasPrefix(expr[i:], "=="), strings.HasPrefix(expr[i:], "!="):
+			tokens = append(tokens, conditionToken{text: expr[i : i+2]})
+			i += 2
+		case ch == '!':
+			tokens = append(tokens, conditionToken{text: "!"})
+			i++
+		default:
+			j := i
+			for j < len(expr) && !strings.ContainsRune(" \t\n\"'&|=!", rune(expr[j])) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected %q in %q", expr[i], expr)
+			}
+			tokens = append(tokens, conditionToken{text: expr[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,71 @@
+package formula
+
+import "testing"
+
+func TestCondition_Eval(t *testing.T) {
+	state := NewRunState(map[string]string{"mode": "fast", "dry_run": "false"})
+	state.Status["scan"] = StepDone
+	state.Outputs["scan"] = "  clean\n"
+	state.Status["lint"] = StepSkipped
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`vars.mode == "fast"`, true},
+		{`vars.mode != 'fast'`, false},
+		{`vars.mode == slow`, false},
+		{`vars.dry_run`, false},
+		{`!vars.dry_run`, true},
+		{`!vars.missing`, true},
+		{`steps.scan.output == clean`, true},
+		{`steps.lint.status == skipped`, true},
+		{`steps.scan.output == dirty && vars.mode == fast`, false},
+		{`steps.scan.output == dirty || vars.mode == fast`, true},
+		{`vars.mode == slow || steps.scan.status == done && !vars.dry_run`, true},
+		{`steps.check-queue.output`, false},
+	}
+	for _, tt := range tests {
+		cond, err := ParseCondition(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCondition(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := cond.Eval(state); got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCondition_Refs(t *testing.T) {
+	cond, err := ParseCondition(`steps.a.output == x && steps.my.step.status == done || vars.v || steps.a.status`)
+	if err != nil {
+		t.Fatalf("ParseCondition: %v", err)
+	}
+	steps := cond.StepRefs()
+	if len(steps) != 2 || steps[0] != "a" || steps[1] != "my.step" {
+		t.Errorf("StepRefs = %v", steps)
+	}
+	if vars := cond.VarRefs(); len(vars) != 1 || vars[0] != "v" {
+		t.Errorf("VarRefs = %v", vars)
+	}
+}
+
+func TestParseCondition_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"vars.a ==",
+		"vars.a &&",
+		"vars.a vars.b",
+		`vars.a == "open`,
+		"!vars.a == b",
+		"steps.a",
+		"steps.a.result",
+		"vars.",
+		"== b",
+	} {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("ParseCondition(%q) succeeded, want error", expr)
+		}
+	}
+}
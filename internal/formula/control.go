@@ -0,0 +1,270 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Foreach bounds.
+const (
+	// DefaultForeachMax is the item limit of a foreach without max.
+	DefaultForeachMax = 20
+	// MaxForeachItems is the largest max a foreach may declare.
+	MaxForeachItems = 100
+	// DefaultForeachAs is the placeholder bound to each foreach item.
+	DefaultForeachAs = "item"
+)
+
+// FailedOutputPrefix starts the close reason of a step that failed, e.g.
+// "failed: tests red". A failed step is retried like a timed-out one.
+const FailedOutputPrefix = "failed:"
+
+// StepFailed reports whether a step's close reason marks it as failed.
+func StepFailed(output string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(output)), FailedOutputPrefix)
+}
+
+// StepStatus is the terminal status of a step in a run.
+type StepStatus string
+
+const (
+	// StepDone means the step ran and closed.
+	StepDone StepStatus = "done"
+	// StepSkipped means the step's when condition was false.
+	StepSkipped StepStatus = "skipped"
+)
+
+// RunState is the progress of a workflow run, used to evaluate when
+// conditions and find the next steps.
+type RunState struct {
+	// Vars are the bound formula variables.
+	Vars map[string]string
+
+	// Status holds the terminal status of every finished step.
+	Status map[string]StepStatus
+
+	// Outputs holds the output of every done step.
+	Outputs map[string]string
+}
+
+// NewRunState returns an empty run state over the bound vars.
+func NewRunState(vars map[string]string) *RunState {
+	return &RunState{
+		Vars:    vars,
+		Status:  make(map[string]StepStatus),
+		Outputs: make(map[string]string),
+	}
+}
+
+// Finished returns the set of finished (done or skipped) steps.
+func (s *RunState) Finished() map[string]bool {
+	finished := make(map[string]bool, len(s.Status))
+	for id := range s.Status {
+		finished[id] = true
+	}
+	return finished
+}
+
+// NextSteps returns the ready steps of a workflow split by their when
+// conditions: run holds steps to dispatch, skip holds steps whose condition
+// is false. Skipping a step can make others ready, so callers mark skipped
+// steps finished and call NextSteps again until skip is empty.
+func (f *Formula) NextSteps(state *RunState) (run, skip []string) {
+	for _, id := range f.ReadySteps(state.Finished()) {
+		step := f.GetStep(id)
+		if step == nil || step.When == "" {
+			run = append(run, id)
+			continue
+		}
+		cond, err := ParseCondition(step.When)
+		if err != nil || cond.Eval(state) {
+			// Validate rejects bad conditions; run rather than drop the step.
+			run = append(run, id)
+		} else {
+			skip = append(skip, id)
+		}
+	}
+	return run, skip
+}
+
+// stepNeeds returns a step's explicit needs plus the steps its when
+// condition reads, which must finish before it can be evaluated.
+func stepNeeds(step Step) []string {
+	if step.When == "" {
+		return step.Needs
+	}
+	cond, err := ParseCondition(step.When)
+	if err != nil {
+		return step.Needs
+	}
+	needs := append([]string(nil), step.Needs...)
+	for _, ref := range cond.StepRefs() {
+		found := false
+		for _, n := range needs {
+			if n == ref {
+				found = true
+				break
+			}
+		}
+		if !found {
+			needs = append(needs, ref)
+		}
+	}
+	return needs
+}
+
+// TimeoutDuration returns the step's timeout, or 0 if it has none.
+func (s *Step) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(s.Timeout)
+	return d
+}
+
+// MaxAttempts returns how many times the step may be dispatched.
+func (s *Step) MaxAttempts() int {
+	if s.Retry == nil || s.Retry.Attempts < 1 {
+		return 1
+	}
+	return s.Retry.Attempts
+}
+
+// Delay returns the backoff before retry n (1 for the first retry).
+func (r *Retry) Delay(n int) time.Duration {
+	if r == nil || n < 1 {
+		return 0
+	}
+	d, _ := time.ParseDuration(r.Backoff)
+	maxDelay, _ := time.ParseDuration(r.MaxBackoff)
+	for i := 1; i < n; i++ {
+		d *= 2
+		if maxDelay > 0 && d >= maxDelay {
+			break
+		}
+	}
+	if maxDelay > 0 && d > maxDelay {
+		d = maxDelay
+	}
+	return d
+}
+
+// validateStepControl checks a step's when, timeout, retry and foreach.
+func (f *Formula) validateStepControl(step Step, ids map[string]bool) error {
+	if step.When != "" {
+		cond, err := ParseCondition(step.When)
+		if err != nil {
+			return fmt.Errorf("step %q: invalid when: %w", step.ID, err)
+		}
+		for _, ref := range cond.StepRefs() {
+			if ref == step.ID {
+				return fmt.Errorf("step %q: when reads its own output", step.ID)
+			}
+			if !ids[ref] {
+				return fmt.Errorf("step %q: when reads unknown step: %s", step.ID, ref)
+			}
+			if s := f.GetStep(ref); s != nil && s.Foreach != nil {
+				return fmt.Errorf("step %q: when cannot read foreach step %s", step.ID, ref)
+			}
+		}
+		for _, name := range cond.VarRefs() {
+			if _, ok := f.Vars[name]; !ok {
+				return fmt.Errorf("step %q: when reads undeclared var: %s", step.ID, name)
+			}
+		}
+	}
+
+	if step.Timeout != "" {
+		if d, err := time.ParseDuration(step.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("step %q: invalid timeout %q", step.ID, step.Timeout)
+		}
+	}
+
+	if r := step.Retry; r != nil {
+		if r.Attempts < 1 {
+			return fmt.Errorf("step %q: retry attempts must be at least 1", step.ID)
+		}
+		for _, d := range []string{r.Backoff, r.MaxBackoff} {
+			if d == "" {
+				continue
+			}
+			if v, err := time.ParseDuration(d); err != nil || v < 0 {
+				return fmt.Errorf("step %q: invalid retry backoff %q", step.ID, d)
+			}
+		}
+	}
+
+	if fe := step.Foreach; fe != nil {
+		if fe.Over == "" {
+			return fmt.Errorf("step %q: foreach requires over", step.ID)
+		}
+		if _, ok := f.Vars[fe.Over]; !ok {
+			return fmt.Errorf("step %q: foreach over undeclared var: %s", step.ID, fe.Over)
+		}
+		if fe.Max < 0 || fe.Max > MaxForeachItems {
+			return fmt.Errorf("step %q: foreach max must be between 0 (default %d) and %d",
+				step.ID, DefaultForeachMax, MaxForeachItems)
+		}
+	}
+	return nil
+}
+
+// expandForeach replaces each foreach step with one step per item of its
+// list variable. Instances are named <id>.1, <id>.2, ... and steps that
+// need a foreach step need all of its instances.
+func expandForeach(steps []Step, vars map[string]string) ([]Step, error) {
+	instances := make(map[string][]string)
+	var out []Step
+	for _, s := range steps {
+		if s.Foreach == nil {
+			out = append(out, s)
+			continue
+		}
+		items := splitList(vars[s.Foreach.Over])
+		limit := s.Foreach.Max
+		if limit == 0 {
+			limit = DefaultForeachMax
+		}
+		if len(items) > limit {
+			return nil, fmt.Errorf("step %q: foreach over %s has %d items, max %d",
+				s.ID, s.Foreach.Over, len(items), limit)
+		}
+		as := s.Foreach.As
+		if as == "" {
+			as = DefaultForeachAs
+		}
+		instances[s.ID] = []string{}
+		for i, item := range items {
+			inst := s
+			inst.ID = fmt.Sprintf("%s.%d", s.ID, i+1)
+			inst.Foreach = nil
+			itemVars := map[string]string{as: item}
+			inst.Title = Substitute(s.Title, itemVars)
+			inst.Description = Substitute(s.Description, itemVars)
+			out = append(out, inst)
+			instances[s.ID] = append(instances[s.ID], inst.ID)
+		}
+	}
+
+	for i := range out {
+		var needs []string
+		for _, n := range out[i].Needs {
+			if ids, ok := instances[n]; ok {
+				needs = append(needs, ids...)
+			} else {
+				needs = append(needs, n)
+			}
+		}
+		out[i].Needs = needs
+	}
+	return out, nil
+}
+
+// splitList splits a comma-separated list variable, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -3,34 +3,26 @@ package formula
 import (
 	"fmt"
 	"os"
-
-	"github.com/BurntSushi/toml"
+	"path/filepath"
 )
 
-// ParseFile reads and parses a formula.toml file.
-func ParseFile(path string) (*Formula, error) {
+// ParseFile reads and parses a formula.toml file. Formulas it extends,
+// includes or composes are looked up next to the file, then in searchPaths
+// (the directories the formula itself was searched in), then in the
+// embedded formula library.
+func ParseFile(path string, searchPaths ...string) (*Formula, error) {
 	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from trusted formula directory
 	if err != nil {
 		return nil, fmt.Errorf("reading formula file: %w", err)
 	}
-	return Parse(data)
+	dirs := append([]string{filepath.Dir(path)}, searchPaths...)
+	return ParseWith(data, &Resolver{Dirs: dirs})
 }
 
-// Parse parses formula.toml content from bytes.
+// Parse parses formula.toml content from bytes. Formulas it extends,
+// includes or composes are resolved against the embedded formula library.
 func Parse(data []byte) (*Formula, error) {
-	var f Formula
-	if _, err := toml.Decode(string(data), &f); err != nil {
-		return nil, fmt.Errorf("parsing TOML: %w", err)
-	}
-
-	// Infer type from content if not explicitly set
-	f.inferType()
-
-	if err := f.Validate(); err != nil {
-		return nil, err
-	}
-
-	return &f, nil
+	return ParseWith(data, &Resolver{})
 }
 
 // inferType sets the formula type based on content when not explicitly set.
@@ -132,6 +124,13 @@ func (f *Formula) validateWorkflow() error {
 		}
 	}
 
+	// Validate conditions, timeouts, retries and loops
+	for _, step := range f.Steps {
+		if err := f.validateStepControl(step, seen); err != nil {
+			return err
+		}
+	}
+
 	// Check for cycles
 	if err := f.checkCycles(); err != nil {
 		return err
@@ -170,8 +169,8 @@ func (f *Formula) validateExpansion() error {
 }
 
 func (f *Formula) validateAspect() error {
-	if len(f.Aspects) == 0 {
-		return fmt.Errorf("aspect formula requires at least one aspect")
+	if len(f.Aspects) == 0 && len(f.Advice) == 0 {
+		return fmt.Errorf("aspect formula requires at least one aspect or advice")
 	}
 
 	// Check aspect IDs are unique
@@ -189,12 +188,13 @@ func (f *Formula) validateAspect() error {
 	return nil
 }
 
-// checkCycles detects circular dependencies in steps.
+// checkCycles detects circular dependencies in steps, including the
+// implicit dependencies of when conditions on the steps they read.
 func (f *Formula) checkCycles() error {
 	// Build adjacency list
 	deps := make(map[string][]string)
 	for _, step := range f.Steps {
-		deps[step.ID] = step.Needs
+		deps[step.ID] = stepNeeds(step)
 	}
 
 	// DFS for cycle detection
@@ -245,7 +245,7 @@ func (f *Formula) TopologicalSort() ([]string, error) {
 		}
 		deps = make(map[string][]string)
 		for _, step := range f.Steps {
-			deps[step.ID] = step.Needs
+			deps[step.ID] = stepNeeds(step)
 		}
 	case TypeExpansion:
 		for _, tmpl := range f.Template {
@@ -323,7 +323,9 @@ func (f *Formula) TopologicalSort() ([]string, error) {
 }
 
 // ReadySteps returns steps that have no unmet dependencies.
-// completed is a set of step IDs that have been completed.
+// completed is a set of step IDs that have been completed (or skipped).
+// A workflow step with a when condition also waits for the steps the
+// condition reads; NextSteps evaluates the condition itself.
 func (f *Formula) ReadySteps(completed map[string]bool) []string {
 	var ready []string
 
@@ -334,7 +336,7 @@ func (f *Formula) ReadySteps(completed map[string]bool) []string {
 				continue
 			}
 			allMet := true
-			for _, need := range step.Needs {
+			for _, need := range stepNeeds(step) {
 				if !completed[need] {
 					allMet = false
 					break
@@ -1,7 +1,9 @@
 package formula
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParse_Workflow(t *testing.T) {
@@ -353,3 +355,229 @@ title = "Leg 3"
 		t.Errorf("ReadySteps({leg1}) = %v, want 2 legs", ready)
 	}
 }
+
+func TestParse_WhenCondition(t *testing.T) {
+	data := []byte(`
+formula = "patrol"
+type = "workflow"
+[vars.force]
+description = "Process even when the queue looks empty"
+[[steps]]
+id = "check"
+title = "Check queue"
+[[steps]]
+id = "process"
+title = "Process queue"
+when = 'steps.check.output != "empty" || vars.force'
+[[steps]]
+id = "sleep"
+title = "Sleep"
+needs = ["process"]
+`)
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// The condition reads check, so process waits for it without needs.
+	if ready := f.ReadySteps(map[string]bool{}); len(ready) != 1 || ready[0] != "check" {
+		t.Errorf("ReadySteps({}) = %v, want [check]", ready)
+	}
+	order, err := f.TopologicalSort()
+	if err != nil {
+		t.Fatalf("TopologicalSort failed: %v", err)
+	}
+	if order[0] != "check" {
+		t.Errorf("TopologicalSort = %v, want check first", order)
+	}
+
+	state := NewRunState(map[string]string{})
+	state.Status["check"] = StepDone
+	state.Outputs["check"] = "empty\n"
+	if run, skip := f.NextSteps(state); len(run) != 0 || len(skip) != 1 || skip[0] != "process" {
+		t.Errorf("NextSteps(empty) = %v, %v, want process skipped", run, skip)
+	}
+	state.Vars["force"] = "true"
+	if run, skip := f.NextSteps(state); len(run) != 1 || len(skip) != 0 {
+		t.Errorf("NextSteps(force) = %v, %v, want process run", run, skip)
+	}
+
+	// A skipped step counts as finished for its dependents.
+	state.Status["process"] = StepSkipped
+	if run, _ := f.NextSteps(state); len(run) != 1 || run[0] != "sleep" {
+		t.Errorf("NextSteps after skip = %v, want [sleep]", run)
+	}
+}
+
+func TestParse_WhenCycle(t *testing.T) {
+	data := []byte(`
+formula = "test"
+type = "workflow"
+[[steps]]
+id = "a"
+title = "A"
+when = "steps.b.status == done"
+[[steps]]
+id = "b"
+title = "B"
+needs = ["a"]
+`)
+
+	_, err := Parse(data)
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Parse error = %v, want cycle through when", err)
+	}
+}
+
+func TestParse_RetryAndTimeout(t *testing.T) {
+	data := []byte(`
+formula = "test"
+type = "workflow"
+[[steps]]
+id = "deploy"
+title = "Deploy"
+timeout = "15m"
+[steps.retry]
+attempts = 3
+backoff = "1m"
+max_backoff = "3m"
+`)
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	step := f.GetStep("deploy")
+	if step.TimeoutDuration() != 15*time.Minute || step.MaxAttempts() != 3 {
+		t.Errorf("timeout = %v, attempts = %d", step.TimeoutDuration(), step.MaxAttempts())
+	}
+	for n, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 3 * time.Minute} {
+		if got := step.Retry.Delay(n); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestParse_RetryWithoutTimeout(t *testing.T) {
+	data := []byte(`
+formula = "test"
+type = "workflow"
+[[steps]]
+id = "deploy"
+title = "Deploy"
+[steps.retry]
+attempts = 2
+`)
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := f.GetStep("deploy").MaxAttempts(); got != 2 {
+		t.Errorf("attempts = %d, want failures retried without a timeout", got)
+	}
+}
+
+func TestStepFailed(t *testing.T) {
+	for output, want := range map[string]bool{
+		"failed: tests red": true,
+		" FAILED: no rig":   true,
+		"done":              false,
+		"":                  false,
+		"3 failed checks":   false,
+	} {
+		if got := StepFailed(output); got != want {
+			t.Errorf("StepFailed(%q) = %v, want %v", output, got, want)
+		}
+	}
+}
+
+func TestParse_Foreach(t *testing.T) {
+	data := []byte(`
+formula = "test"
+type = "workflow"
+[vars.rigs]
+description = "Rigs to patrol"
+default = "gastown, beads"
+[[steps]]
+id = "patrol"
+title = "Patrol {{rig}}"
+[steps.foreach]
+over = "rigs"
+as = "rig"
+max = 5
+[[steps]]
+id = "report"
+title = "Report"
+needs = ["patrol"]
+`)
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	vars, err := f.BindVars(nil)
+	if err != nil {
+		t.Fatalf("BindVars failed: %v", err)
+	}
+	inst, err := f.Instantiate(vars)
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+
+	ids := inst.GetAllIDs()
+	if strings.Join(ids, ",") != "patrol.1,patrol.2,report" {
+		t.Fatalf("steps = %v", ids)
+	}
+	if got := inst.GetStep("patrol.2").Title; got != "Patrol beads" {
+		t.Errorf("patrol.2 title = %q", got)
+	}
+	if got := inst.GetStep("report").Needs; strings.Join(got, ",") != "patrol.1,patrol.2" {
+		t.Errorf("report needs = %v, want every instance", got)
+	}
+
+	vars["rigs"] = "a,b,c,d,e,f"
+	if _, err := f.Instantiate(vars); err == nil || !strings.Contains(err.Error(), "max 5") {
+		t.Errorf("Instantiate over max error = %v", err)
+	}
+}
+
+func TestValidate_ControlErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		step string
+		want string
+	}{
+		{"bad when", `when = "steps.a.output =="`, "invalid when"},
+		{"unknown operand", `when = "queue == empty"`, "unknown operand"},
+		{"unknown step", `when = "steps.nope.output"`, "unknown step"},
+		{"undeclared var", `when = "vars.nope"`, "undeclared var"},
+		{"bad timeout", `timeout = "soon"`, "invalid timeout"},
+		{"zero attempts", "timeout = \"1m\"\n[steps.retry]\nattempts = 0", "at least 1"},
+		{"foreach undeclared", "[steps.foreach]\nover = \"nope\"", "undeclared var"},
+		{"foreach too large", "[steps.foreach]\nover = \"list\"\nmax = 1000", "between 0 (default 20) and 100"},
+		{"foreach negative", "[steps.foreach]\nover = \"list\"\nmax = -1", "foreach max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(`
+formula = "test"
+type = "workflow"
+[vars.list]
+description = "A list"
+[[steps]]
+id = "a"
+title = "A"
+[[steps]]
+id = "b"
+title = "B"
+` + tt.step + "\n")
+			_, err := Parse(data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
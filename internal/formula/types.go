@@ -8,6 +8,8 @@
 //   - aspect: Multi-aspect parallel analysis (like convoy but for analysis)
 package formula
 
+import "fmt"
+
 // FormulaType represents the type of formula.
 type FormulaType string
 
@@ -25,89 +27,207 @@ const (
 // Formula represents a parsed formula.toml file.
 type Formula struct {
 	// Common fields
-	Name        string      `toml:"formula"`
-	Description string      `toml:"description"`
-	Type        FormulaType `toml:"type"`
-	Version     int         `toml:"version"`
+	Name        string      `toml:"formula,omitempty"`
+	Description string      `toml:"description,omitempty"`
+	Type        FormulaType `toml:"type,omitempty"`
+	Version     int         `toml:"version,omitempty"`
 
 	// Convoy-specific
-	Inputs    map[string]Input `toml:"inputs"`
-	Prompts   map[string]string `toml:"prompts"`
-	Output    *Output           `toml:"output"`
-	Legs      []Leg             `toml:"legs"`
-	Synthesis *Synthesis        `toml:"synthesis"`
+	Inputs    map[string]Input `toml:"inputs,omitempty"`
+	Prompts   map[string]string `toml:"prompts,omitempty"`
+	Output    *Output           `toml:"output,omitempty"`
+	Legs      []Leg             `toml:"legs,omitempty"`
+	Synthesis *Synthesis        `toml:"synthesis,omitempty"`
 
 	// Workflow-specific
-	Steps []Step           `toml:"steps"`
-	Vars  map[string]Var   `toml:"vars"`
+	Steps []Step           `toml:"steps,omitempty"`
+	Vars  map[string]Var   `toml:"vars,omitempty"`
 
 	// Expansion-specific
-	Template []Template `toml:"template"`
+	Template []Template `toml:"template,omitempty"`
 
 	// Aspect-specific (similar to convoy but for analysis)
-	Aspects []Aspect `toml:"aspects"`
+	Aspects []Aspect `toml:"aspects,omitempty"`
+
+	// Advice and pointcuts make an aspect formula a mixin: composing it
+	// into a workflow inserts advice steps around matching steps.
+	Advice    []Advice   `toml:"advice,omitempty"`
+	Pointcuts []Pointcut `toml:"pointcuts,omitempty"`
+
+	// Composition, resolved away by Parse (see compose.go)
+	Extends  StringList `toml:"extends,omitempty"`
+	Includes []Include  `toml:"include,omitempty"`
+	Compose  *Compose   `toml:"compose,omitempty"`
+}
+
+// StringList is a TOML value that may be a single string or an array.
+type StringList []string
+
+// UnmarshalTOML accepts either `key = "a"` or `key = ["a", "b"]`.
+func (l *StringList) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		*l = StringList{v}
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected string, got %T", item)
+			}
+			*l = append(*l, s)
+		}
+	default:
+		return fmt.Errorf("expected string or array of strings, got %T", v)
+	}
+	return nil
+}
+
+// Include pulls steps from another formula into a workflow.
+type Include struct {
+	// Formula names the formula to include from.
+	Formula string `toml:"formula,omitempty"`
+
+	// Steps lists the step IDs to include; empty includes every step.
+	Steps []string `toml:"steps,omitempty"`
+}
+
+// Compose lists the mixins and expansions applied to a workflow.
+type Compose struct {
+	// Aspects names aspect formulas whose advice is woven into the steps.
+	Aspects []string `toml:"aspects,omitempty"`
+
+	// Expand replaces steps with expansion formulas.
+	Expand []Expand `toml:"expand,omitempty"`
+}
+
+// Expand replaces a workflow step with the templates of an expansion
+// formula, bound with the step as {target}.
+type Expand struct {
+	Target string `toml:"target,omitempty"`
+	With   string `toml:"with,omitempty"`
+}
+
+// Advice inserts steps before and/or after the steps it targets.
+type Advice struct {
+	// Target is a step ID or glob; empty applies the formula's pointcuts.
+	Target string `toml:"target,omitempty"`
+
+	Before []Step  `toml:"before,omitempty"`
+	After  []Step  `toml:"after,omitempty"`
+	Around *Around `toml:"around,omitempty"`
+}
+
+// Around groups before and after advice.
+type Around struct {
+	Before []Step `toml:"before,omitempty"`
+	After  []Step `toml:"after,omitempty"`
+}
+
+// Pointcut selects steps by glob for advice without its own target.
+type Pointcut struct {
+	Glob string `toml:"glob,omitempty"`
 }
 
 // Aspect represents a parallel analysis aspect in an aspect formula.
 type Aspect struct {
-	ID          string `toml:"id"`
-	Title       string `toml:"title"`
-	Focus       string `toml:"focus"`
-	Description string `toml:"description"`
+	ID          string `toml:"id,omitempty"`
+	Title       string `toml:"title,omitempty"`
+	Focus       string `toml:"focus,omitempty"`
+	Description string `toml:"description,omitempty"`
 }
 
 // Input represents an input parameter for a formula.
 type Input struct {
-	Description    string   `toml:"description"`
-	Type           string   `toml:"type"`
-	Required       bool     `toml:"required"`
-	RequiredUnless []string `toml:"required_unless"`
-	Default        string   `toml:"default"`
+	Description    string   `toml:"description,omitempty"`
+	Type           string   `toml:"type,omitempty"`
+	Required       bool     `toml:"required,omitempty"`
+	RequiredUnless []string `toml:"required_unless,omitempty"`
+	Default        string   `toml:"default,omitempty"`
 }
 
 // Output configures where formula outputs are written.
 type Output struct {
-	Directory  string `toml:"directory"`
-	LegPattern string `toml:"leg_pattern"`
-	Synthesis  string `toml:"synthesis"`
+	Directory  string `toml:"directory,omitempty"`
+	LegPattern string `toml:"leg_pattern,omitempty"`
+	Synthesis  string `toml:"synthesis,omitempty"`
 }
 
 // Leg represents a parallel execution unit in a convoy formula.
 type Leg struct {
-	ID          string `toml:"id"`
-	Title       string `toml:"title"`
-	Focus       string `toml:"focus"`
-	Description string `toml:"description"`
+	ID          string `toml:"id,omitempty"`
+	Title       string `toml:"title,omitempty"`
+	Focus       string `toml:"focus,omitempty"`
+	Description string `toml:"description,omitempty"`
 }
 
 // Synthesis represents the synthesis step that combines leg outputs.
 type Synthesis struct {
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	DependsOn   []string `toml:"depends_on"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	DependsOn   []string `toml:"depends_on,omitempty"`
 }
 
 // Step represents a sequential step in a workflow formula.
 type Step struct {
-	ID          string   `toml:"id"`
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	Needs       []string `toml:"needs"`
+	ID          string   `toml:"id,omitempty"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	Needs       []string `toml:"needs,omitempty"`
+
+	// When is a condition over vars and earlier step outputs, e.g.
+	// `steps.check-queue.output != "empty"`. The step is skipped when it
+	// is false. Steps it reads are implicit needs. See ParseCondition.
+	When string `toml:"when,omitempty"`
+
+	// Timeout bounds one attempt of the step, as a Go duration ("15m").
+	Timeout string `toml:"timeout,omitempty"`
+
+	// Retry re-dispatches the step when an attempt fails or times out.
+	Retry *Retry `toml:"retry,omitempty"`
+
+	// Foreach runs the step once per item of a list variable.
+	Foreach *Foreach `toml:"foreach,omitempty"`
+}
+
+// Retry configures re-dispatching a step whose attempt failed or timed
+// out. An attempt fails when it cannot be slung or its bead closes with a
+// FailedOutputPrefix reason.
+type Retry struct {
+	// Attempts is the total number of attempts, including the first.
+	Attempts int `toml:"attempts,omitempty"`
+
+	// Backoff is the delay before the first retry, as a Go duration.
+	// Each further retry doubles it, up to MaxBackoff.
+	Backoff    string `toml:"backoff,omitempty"`
+	MaxBackoff string `toml:"max_backoff,omitempty"`
+}
+
+// Foreach expands a step into one step per item of a list variable.
+type Foreach struct {
+	// Over names the variable holding the list (comma-separated).
+	Over string `toml:"over,omitempty"`
+
+	// As is the placeholder bound to each item (default "item"), so
+	// "{{item}}" in the step's title and description is the current item.
+	As string `toml:"as,omitempty"`
+
+	// Max bounds the number of items; 0 means DefaultForeachMax.
+	Max int `toml:"max,omitempty"`
 }
 
 // Template represents a template step in an expansion formula.
 type Template struct {
-	ID          string   `toml:"id"`
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	Needs       []string `toml:"needs"`
+	ID          string   `toml:"id,omitempty"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	Needs       []string `toml:"needs,omitempty"`
 }
 
 // Var represents a variable definition for formulas.
 type Var struct {
-	Description string `toml:"description"`
-	Required    bool   `toml:"required"`
-	Default     string `toml:"default"`
+	Description string `toml:"description,omitempty"`
+	Required    bool   `toml:"required,omitempty"`
+	Default     string `toml:"default,omitempty"`
 }
 
 // IsValid returns true if the formula type is recognized.
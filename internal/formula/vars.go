@@ -0,0 +1,135 @@
+package formula
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TargetVar is the variable an expansion formula expands over. It is
+// implicitly required for expansion formulas, along with the derived
+// target.title and target.description, which default to the target itself.
+const TargetVar = "target"
+
+// BindVars resolves the formula's variables from values. Declared
+// variables fall back to their defaults; a required variable with no value
+// is an error, as is a value for a variable the formula does not declare.
+func (f *Formula) BindVars(values map[string]string) (map[string]string, error) {
+	bound := make(map[string]string)
+	var missing, unknown []string
+
+	for name, v := range f.Vars {
+		if val, ok := values[name]; ok {
+			bound[name] = val
+		} else if v.Default != "" {
+			bound[name] = v.Default
+		} else if v.Required {
+			missing = append(missing, name)
+		}
+	}
+
+	if f.Type == TypeExpansion {
+		if target, ok := values[TargetVar]; ok && target != "" {
+			bound[TargetVar] = target
+			for _, field := range []string{"title", "description"} {
+				key := TargetVar + "." + field
+				if val, ok := values[key]; ok {
+					bound[key] = val
+				} else {
+					bound[key] = target
+				}
+			}
+		} else {
+			missing = append(missing, TargetVar)
+		}
+	}
+
+	for name := range values {
+		if _, declared := f.Vars[name]; declared {
+			continue
+		}
+		if f.Type == TypeExpansion && (name == TargetVar || strings.HasPrefix(name, TargetVar+".")) {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown variables: %s", strings.Join(unknown, ", "))
+	}
+	return bound, nil
+}
+
+// Substitute replaces {{name}} and {name} placeholders in s with the
+// bound variables. Placeholders that name unbound variables are left as-is,
+// so Go-template forms such as {{.leg.id}} pass through untouched.
+func Substitute(s string, vars map[string]string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	pairs := make([]string, 0, len(vars)*4)
+	for name, val := range vars {
+		pairs = append(pairs, "{{"+name+"}}", val)
+	}
+	for name, val := range vars {
+		pairs = append(pairs, "{"+name+"}", val)
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// Instantiate returns a copy of a workflow or expansion formula with its
+// steps (or templates) bound to vars: foreach steps are expanded, and
+// placeholders in IDs, titles, descriptions and needs are substituted. The
+// result is re-validated, since substitution can produce duplicate or
+// dangling step IDs.
+func (f *Formula) Instantiate(vars map[string]string) (*Formula, error) {
+	inst := *f
+	switch f.Type {
+	case TypeWorkflow:
+		steps, err := expandForeach(f.Steps, vars)
+		if err != nil {
+			return nil, err
+		}
+		inst.Steps = make([]Step, len(steps))
+		for i, s := range steps {
+			s.ID = Substitute(s.ID, vars)
+			s.Title = Substitute(s.Title, vars)
+			s.Description = Substitute(s.Description, vars)
+			s.Needs = substituteAll(s.Needs, vars)
+			inst.Steps[i] = s
+		}
+	case TypeExpansion:
+		inst.Template = make([]Template, len(f.Template))
+		for i, t := range f.Template {
+			inst.Template[i] = Template{
+				ID:          Substitute(t.ID, vars),
+				Title:       Substitute(t.Title, vars),
+				Description: Substitute(t.Description, vars),
+				Needs:       substituteAll(t.Needs, vars),
+			}
+		}
+	default:
+		return nil, fmt.Errorf("cannot instantiate %s formula", f.Type)
+	}
+
+	if err := inst.Validate(); err != nil {
+		return nil, fmt.Errorf("instantiating %s: %w", f.Name, err)
+	}
+	return &inst, nil
+}
+
+func substituteAll(list []string, vars map[string]string) []string {
+	if list == nil {
+		return nil
+	}
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = Substitute(s, vars)
+	}
+	return out
+}
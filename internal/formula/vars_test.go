@@ -0,0 +1,112 @@
+package formula
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBindVars_Workflow(t *testing.T) {
+	f := &Formula{
+		Name: "w",
+		Type: TypeWorkflow,
+		Vars: map[string]Var{
+			"feature":  {Required: true},
+			"assignee": {Default: "nux"},
+			"notes":    {},
+		},
+	}
+
+	if _, err := f.BindVars(nil); err == nil || !strings.Contains(err.Error(), "feature") {
+		t.Errorf("BindVars(nil) error = %v, want missing feature", err)
+	}
+	if _, err := f.BindVars(map[string]string{"feature": "x", "featur": "y"}); err == nil ||
+		!strings.Contains(err.Error(), "unknown variables: featur") {
+		t.Errorf("BindVars with typo error = %v", err)
+	}
+
+	vars, err := f.BindVars(map[string]string{"feature": "login"})
+	if err != nil {
+		t.Fatalf("BindVars: %v", err)
+	}
+	if vars["feature"] != "login" || vars["assignee"] != "nux" {
+		t.Errorf("vars = %v", vars)
+	}
+	if _, ok := vars["notes"]; ok {
+		t.Errorf("optional var without default should stay unbound: %v", vars)
+	}
+}
+
+func TestBindVars_ExpansionTarget(t *testing.T) {
+	f := &Formula{Name: "e", Type: TypeExpansion}
+
+	if _, err := f.BindVars(nil); err == nil || !strings.Contains(err.Error(), TargetVar) {
+		t.Errorf("BindVars(nil) error = %v, want missing target", err)
+	}
+
+	vars, err := f.BindVars(map[string]string{"target": "gt-abc", "target.title": "Login page"})
+	if err != nil {
+		t.Fatalf("BindVars: %v", err)
+	}
+	if vars["target.title"] != "Login page" || vars["target.description"] != "gt-abc" {
+		t.Errorf("vars = %v", vars)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	vars := map[string]string{"feature": "login", "target": "gt-abc", "target.title": "Login"}
+	tests := []struct {
+		in, want string
+	}{
+		{"Design {{feature}}", "Design login"},
+		{"{target}.draft", "gt-abc.draft"},
+		{"Draft: {target.title}", "Draft: Login"},
+		{"Leg {{.leg.id}} for {{unbound}}", "Leg {{.leg.id}} for {{unbound}}"},
+	}
+	for _, tt := range tests {
+		if got := Substitute(tt.in, vars); got != tt.want {
+			t.Errorf("Substitute(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInstantiate_Expansion(t *testing.T) {
+	f, err := Parse([]byte(`
+formula = "rule-of-two"
+type = "expansion"
+
+[[template]]
+id = "{target}.draft"
+title = "Draft: {target.title}"
+
+[[template]]
+id = "{target}.refine"
+needs = ["{target}.draft"]
+title = "Refine {target}"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	vars, err := f.BindVars(map[string]string{"target": "gt-abc"})
+	if err != nil {
+		t.Fatalf("BindVars: %v", err)
+	}
+
+	inst, err := f.Instantiate(vars)
+	if err != nil {
+		t.Fatalf("Instantiate: %v", err)
+	}
+	order, err := inst.TopologicalSort()
+	if err != nil {
+		t.Fatalf("TopologicalSort: %v", err)
+	}
+	if strings.Join(order, ",") != "gt-abc.draft,gt-abc.refine" {
+		t.Errorf("order = %v", order)
+	}
+	if got := inst.GetTemplate("gt-abc.draft").Title; got != "Draft: gt-abc" {
+		t.Errorf("draft title = %q", got)
+	}
+	// The original formula is untouched.
+	if f.Template[0].ID != "{target}.draft" {
+		t.Errorf("original template mutated: %q", f.Template[0].ID)
+	}
+}
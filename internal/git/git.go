@@ -9,6 +9,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/steveyegge/gastown/internal/connection"
 )
 
 // Common errors
@@ -22,7 +24,8 @@ var (
 // Git wraps git operations for a working directory.
 type Git struct {
 	workDir string
-	gitDir  string // Optional: explicit git directory (for bare repos)
+	gitDir  string            // Optional: explicit git directory (for bare repos)
+	runner  connection.Runner // Optional: nil runs git as a local subprocess
 }
 
 // NewGit creates a new Git wrapper for the given directory.
@@ -37,6 +40,13 @@ func NewGitWithDir(gitDir, workDir string) *Git {
 	return &Git{gitDir: gitDir, workDir: workDir}
 }
 
+// NewGitWithRunner creates a Git wrapper that runs git through r.
+// gitDir may be empty for a normal (non-bare) repository.
+// Clone operations always run locally and ignore the runner.
+func NewGitWithRunner(r connection.Runner, gitDir, workDir string) *Git {
+	return &Git{gitDir: gitDir, workDir: workDir, runner: r}
+}
+
 // WorkDir returns the working directory for this Git instance.
 func (g *Git) WorkDir() string {
 	return g.workDir
@@ -55,6 +65,14 @@ func (g *Git) run(args ...string) (string, error) {
 		args = append([]string{"--git-dir=" + g.gitDir}, args...)
 	}
 
+	if g.runner != nil {
+		stdout, stderr, err := g.runner.Run(g.workDir, nil, "git", args...)
+		if err != nil {
+			return "", g.wrapError(err, string(stderr), args)
+		}
+		return strings.TrimSpace(string(stdout)), nil
+	}
+
 	cmd := exec.Command("git", args...)
 	if g.workDir != "" {
 		cmd.Dir = g.workDir
@@ -409,6 +427,17 @@ func (g *Git) CheckConflicts(source, target string) ([]string, error) {
 // runMergeCheck runs a git merge command and returns error info from both stdout and stderr.
 // This is needed because git merge outputs CONFLICT info to stdout.
 func (g *Git) runMergeCheck(args ...string) (string, error) {
+	if g.runner != nil {
+		stdout, stderr, err := g.runner.Run(g.workDir, nil, "git", args...)
+		if err != nil {
+			if strings.Contains(string(stdout), "CONFLICT") {
+				return "", ErrMergeConflict
+			}
+			return "", g.wrapError(err, string(stderr), args)
+		}
+		return strings.TrimSpace(string(stdout)), nil
+	}
+
 	cmd := exec.Command("git", args...)
 	cmd.Dir = g.workDir
 
@@ -0,0 +1,159 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoEnvelope is returned when decoding a message that has no envelope.
+var ErrNoEnvelope = errors.New("message has no protocol envelope")
+
+// Envelope is the machine-readable part of a protocol message: the name of
+// the protocol, the version of its payload schema, and the payload as JSON.
+// Agents dispatch on the envelope; the subject and body are for humans.
+type Envelope struct {
+	// Protocol names the message kind (e.g., "MERGE_READY", "POLECAT_DONE").
+	Protocol string `json:"protocol"`
+
+	// Version is the payload schema version, starting at 1. Fields may be
+	// added within a version; removing or changing one bumps it.
+	Version int `json:"version"`
+
+	// Payload is the protocol's payload type, encoded as JSON.
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Validator is implemented by payloads that check their fields after
+// decoding, such as required fields being set.
+type Validator interface {
+	Validate() error
+}
+
+// NewEnvelope encodes payload as the given protocol and schema version.
+func NewEnvelope(protocol string, version int, payload interface{}) (*Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s payload: %w", protocol, err)
+	}
+	return &Envelope{Protocol: protocol, Version: version, Payload: data}, nil
+}
+
+// Decode unmarshals the payload into v, which must be a pointer to the
+// protocol's payload type. It fails if the envelope is for a different
+// protocol, has a schema version newer than maxVersion, doesn't match the
+// payload type, or (if v is a Validator) doesn't validate.
+func (e *Envelope) Decode(protocol string, maxVersion int, v interface{}) error {
+	if e.Protocol != protocol {
+		return fmt.Errorf("envelope is %s, not %s", e.Protocol, protocol)
+	}
+	if e.Version < 1 || e.Version > maxVersion {
+		return fmt.Errorf("unsupported %s schema version %d (supported: 1-%d)", protocol, e.Version, maxVersion)
+	}
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("decoding %s payload: %w", protocol, err)
+	}
+	if val, ok := v.(Validator); ok {
+		if err := val.Validate(); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", protocol, err)
+		}
+	}
+	return nil
+}
+
+// SetEnvelope attaches an envelope carrying payload to the message.
+func (m *Message) SetEnvelope(protocol string, version int, payload interface{}) error {
+	env, err := NewEnvelope(protocol, version, payload)
+	if err != nil {
+		return err
+	}
+	m.Envelope = env
+	return nil
+}
+
+// Protocol returns the protocol named by the message's envelope, or "" for
+// a message without one.
+func (m *Message) Protocol() string {
+	if m.Envelope == nil {
+		return ""
+	}
+	return m.Envelope.Protocol
+}
+
+// envelopeTrailer starts the line that carries a message's envelope at the
+// end of its beads description, which is where the body is stored.
+const envelopeTrailer = "Gt-Envelope: "
+
+// storedBody returns the message body as stored in beads, with the envelope
+// (if any) appended as a trailer line. Body lines that look like a trailer
+// are escaped, so a free-text body can't pass itself off as a protocol
+// message.
+func (m *Message) storedBody() string {
+	body := escapeTrailers(m.Body)
+	if m.Envelope == nil {
+		return body
+	}
+	data, err := json.Marshal(m.Envelope)
+	if err != nil {
+		return body
+	}
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	return body + "\n" + envelopeTrailer + string(data)
+}
+
+// splitEnvelope separates a stored description into the body and the
+// envelope trailer. A malformed trailer is left in the body.
+func splitEnvelope(description string) (string, *Envelope) {
+	idx := strings.LastIndex(description, envelopeTrailer)
+	if idx < 0 || (idx > 0 && description[idx-1] != '\n') {
+		return unescapeTrailers(description), nil
+	}
+	line := strings.TrimSpace(description[idx+len(envelopeTrailer):])
+	if strings.Contains(line, "\n") {
+		return unescapeTrailers(description), nil
+	}
+
+	var env Envelope
+	if err := json.Unmarshal([]byte(line), &env); err != nil || env.Protocol == "" {
+		return unescapeTrailers(description), nil
+	}
+	return unescapeTrailers(strings.TrimRight(description[:idx], "\n")), &env
+}
+
+// isTrailerLine reports whether a body line is an envelope trailer,
+// possibly escaped with leading backslashes.
+func isTrailerLine(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, `\`), envelopeTrailer)
+}
+
+// escapeTrailers prepends a backslash to every body line that is, or is an
+// escaped, envelope trailer. unescapeTrailers reverses it.
+func escapeTrailers(body string) string {
+	if !strings.Contains(body, envelopeTrailer) {
+		return body
+	}
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if isTrailerLine(line) {
+			lines[i] = `\` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unescapeTrailers removes the backslash escapeTrailers added.
+func unescapeTrailers(body string) string {
+	if !strings.Contains(body, `\`+envelopeTrailer) {
+		return body
+	}
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, `\`) && isTrailerLine(line) {
+			lines[i] = line[1:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
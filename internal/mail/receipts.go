@@ -0,0 +1,403 @@
+package mail
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// DeliveryState is how far a tracked message has got with one recipient.
+type DeliveryState string
+
+const (
+	// StateSent means the message is in the recipient's inbox, but their
+	// session hasn't been notified (it wasn't running).
+	StateSent DeliveryState = "sent"
+
+	// StateDelivered means the recipient's session was notified.
+	StateDelivered DeliveryState = "delivered"
+
+	// StateRead means the recipient opened the message (gt mail read).
+	StateRead DeliveryState = "read"
+
+	// StateAcked means the recipient acknowledged the message (gt mail ack,
+	// or archiving or deleting it).
+	StateAcked DeliveryState = "acked"
+)
+
+// rank orders states, so that a recipient never moves backwards.
+func (s DeliveryState) rank() int {
+	switch s {
+	case StateDelivered:
+		return 1
+	case StateRead:
+		return 2
+	case StateAcked:
+		return 3
+	default:
+		return 0
+	}
+}
+
+const (
+	// DefaultAckTimeout is how long recipients have to acknowledge a
+	// message that doesn't set AckBy.
+	DefaultAckTimeout = time.Hour
+
+	// renotifyBase and renotifyMax bound the backoff between
+	// renotifications: 1m, 2m, 4m, ... up to 30m.
+	renotifyBase = time.Minute
+	renotifyMax  = 30 * time.Minute
+
+	// receiptRetention is how long finished receipts are kept for
+	// gt mail status.
+	receiptRetention = 7 * 24 * time.Hour
+
+	// receiptSender is the From address of overdue receipts.
+	receiptSender = "daemon"
+)
+
+// RecipientReceipt tracks a message's delivery to one recipient.
+type RecipientReceipt struct {
+	// Address is the recipient's address.
+	Address string `json:"address"`
+
+	// MessageID is the ID of the bead holding the recipient's copy. CC
+	// recipients share the primary recipient's copy.
+	MessageID string `json:"message_id,omitempty"`
+
+	// CC marks a recipient who was CC'd rather than addressed.
+	CC bool `json:"cc,omitempty"`
+
+	State       DeliveryState `json:"state"`
+	DeliveredAt time.Time     `json:"delivered_at,omitempty"`
+	ReadAt      time.Time     `json:"read_at,omitempty"`
+	AckedAt     time.Time     `json:"acked_at,omitempty"`
+
+	// Attempts counts notifications, including the first.
+	Attempts int `json:"attempts"`
+
+	// NextAttempt is when the recipient is next renotified, if still
+	// unacknowledged.
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+}
+
+// Receipt tracks the delivery of a message sent with RequireAck to each of
+// its recipients, including list and group fan-out and CC.
+type Receipt struct {
+	ID         string              `json:"id"`
+	From       string              `json:"from"`
+	Subject    string              `json:"subject"`
+	SentAt     time.Time           `json:"sent_at"`
+	AckBy      time.Time           `json:"ack_by"`
+	Recipients []*RecipientReceipt `json:"recipients"`
+
+	// Overdue is set once the deadline has passed with recipients
+	// unacknowledged, and the sender has been sent a receipt.
+	Overdue bool `json:"overdue,omitempty"`
+}
+
+// Pending returns the recipients who haven't acknowledged the message.
+func (r *Receipt) Pending() []*RecipientReceipt {
+	var pending []*RecipientReceipt
+	for _, rr := range r.Recipients {
+		if rr.State != StateAcked {
+			pending = append(pending, rr)
+		}
+	}
+	return pending
+}
+
+// Done reports whether tracking has finished: every recipient has
+// acknowledged, or the deadline has passed.
+func (r *Receipt) Done() bool {
+	return r.Overdue || len(r.Pending()) == 0
+}
+
+// recipient returns the entry for address, matching a specific copy if
+// messageID is set. Returns nil if there is none.
+func (r *Receipt) recipient(address, messageID string) *RecipientReceipt {
+	identity := addressToIdentity(address)
+	for _, rr := range r.Recipients {
+		if addressToIdentity(rr.Address) != identity {
+			continue
+		}
+		if messageID == "" || rr.MessageID == "" || rr.MessageID == messageID {
+			return rr
+		}
+	}
+	return nil
+}
+
+// advance moves the recipient to state, if that is further along.
+func (rr *RecipientReceipt) advance(state DeliveryState, now time.Time) bool {
+	if state.rank() <= rr.State.rank() {
+		return false
+	}
+	rr.State = state
+	switch state {
+	case StateDelivered:
+		rr.DeliveredAt = now
+	case StateRead:
+		rr.ReadAt = now
+	case StateAcked:
+		rr.AckedAt = now
+	}
+	return true
+}
+
+// renotifyBackoff returns the wait after the given number of attempts.
+func renotifyBackoff(attempts int) time.Duration {
+	wait := renotifyBase
+	for i := 1; i < attempts && wait < renotifyMax; i++ {
+		wait *= 2
+	}
+	if wait > renotifyMax {
+		wait = renotifyMax
+	}
+	return wait
+}
+
+// ReceiptsFile returns the path of the town's delivery receipts.
+func ReceiptsFile(townRoot string) string {
+	return filepath.Join(constants.TownRuntimePath(townRoot), "mail-receipts.json")
+}
+
+// LoadReceipts returns the town's delivery receipts, keyed by receipt ID.
+func LoadReceipts(townRoot string) (map[string]*Receipt, error) {
+	receipts := make(map[string]*Receipt)
+	if err := readStateFile(ReceiptsFile(townRoot), &receipts); err != nil {
+		return nil, fmt.Errorf("reading mail receipts: %w", err)
+	}
+	return receipts, nil
+}
+
+// updateReceipts applies fn to the town's receipts and saves them.
+func updateReceipts(townRoot string, fn func(map[string]*Receipt)) error {
+	receipts := make(map[string]*Receipt)
+	if err := updateStateFile(ReceiptsFile(townRoot), &receipts, func() { fn(receipts) }); err != nil {
+		return fmt.Errorf("updating mail receipts: %w", err)
+	}
+	return nil
+}
+
+// FindReceipt returns the receipt with the given ID, or the receipt of the
+// message copy with that bead ID.
+func FindReceipt(townRoot, id string) (*Receipt, error) {
+	receipts, err := LoadReceipts(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := receipts[id]; ok {
+		return r, nil
+	}
+	for _, r := range receipts {
+		for _, rr := range r.Recipients {
+			if rr.MessageID == id {
+				return r, nil
+			}
+		}
+	}
+	return nil, ErrMessageNotFound
+}
+
+// trackDelivery records the copy of a tracked message sent to msg.To (and
+// its CC recipients) as messageID. delivered reports whether the
+// recipient's session was notified.
+func (r *Router) trackDelivery(msg *Message, messageID string, delivered bool) error {
+	if r.townRoot == "" {
+		return nil
+	}
+	now := time.Now()
+	return updateReceipts(r.townRoot, func(receipts map[string]*Receipt) {
+		rc, ok := receipts[msg.ReceiptID]
+		if !ok {
+			rc = &Receipt{
+				ID:      msg.ReceiptID,
+				From:    msg.From,
+				Subject: msg.Subject,
+				SentAt:  now,
+				AckBy:   msg.AckBy,
+			}
+			receipts[rc.ID] = rc
+		}
+
+		add := func(address string, cc bool, notified bool) {
+			if rc.recipient(address, messageID) != nil {
+				return
+			}
+			rr := &RecipientReceipt{
+				Address:     address,
+				MessageID:   messageID,
+				CC:          cc,
+				State:       StateSent,
+				Attempts:    1,
+				NextAttempt: now.Add(renotifyBackoff(1)),
+			}
+			if notified {
+				rr.advance(StateDelivered, now)
+			}
+			rc.Recipients = append(rc.Recipients, rr)
+		}
+		add(msg.To, false, delivered)
+		for _, cc := range msg.CC {
+			add(cc, true, false)
+		}
+	})
+}
+
+// RecordReceipt records that address has read or acknowledged msg, a
+// message received with a ReceiptID. Untracked messages are ignored.
+func (r *Router) RecordReceipt(msg *Message, address string, state DeliveryState) error {
+	if msg.ReceiptID == "" || r.townRoot == "" {
+		return nil
+	}
+	now := time.Now()
+	return updateReceipts(r.townRoot, func(receipts map[string]*Receipt) {
+		rc, ok := receipts[msg.ReceiptID]
+		if !ok {
+			return
+		}
+		if rr := rc.recipient(address, msg.ID); rr != nil {
+			rr.advance(state, now)
+		}
+	})
+}
+
+// renotification is a recipient due to be renotified of a tracked message.
+type renotification struct {
+	receiptID string
+	address   string
+	messageID string
+	from      string
+	subject   string
+}
+
+// Renotify nudges the recipients of tracked messages who haven't
+// acknowledged them, backing off between attempts, and sends the sender a
+// receipt for each message whose deadline passes unacknowledged. The
+// message itself stays in the recipient's inbox; only the notification is
+// repeated. Finished receipts are pruned after a week. The daemon calls
+// this every heartbeat.
+func (r *Router) Renotify() error {
+	if r.townRoot == "" {
+		return nil
+	}
+
+	var due []renotification
+	var overdue []*Receipt
+	err := updateReceipts(r.townRoot, func(receipts map[string]*Receipt) {
+		due, overdue = renotifyDue(receipts, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+
+	// Notify and send with the receipts lock released, so slow tmux calls
+	// and sends don't hold up recipients reading or acking their mail.
+	var errs []string
+	var notified []renotification
+	for _, n := range due {
+		if ok, _ := r.notifySession(n.address, n.from, "Unacknowledged: "+n.subject); ok {
+			notified = append(notified, n)
+		}
+	}
+	if len(notified) > 0 {
+		if err := updateReceipts(r.townRoot, func(receipts map[string]*Receipt) {
+			markNotified(receipts, notified, time.Now())
+		}); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	for _, rc := range overdue {
+		if err := r.Send(overdueReceipt(rc)); err != nil {
+			errs = append(errs, fmt.Sprintf("overdue receipt %s: %v", rc.ID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("renotifying tracked mail: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// renotifyDue advances receipts to now: marking overdue messages, claiming
+// the next attempt of each recipient due a renotification, and pruning
+// old receipts. It returns the recipients to notify and the newly overdue
+// receipts.
+func renotifyDue(receipts map[string]*Receipt, now time.Time) ([]renotification, []*Receipt) {
+	var due []renotification
+	var overdue []*Receipt
+	for id, rc := range receipts {
+		if rc.Done() {
+			if now.Sub(rc.SentAt) > receiptRetention {
+				delete(receipts, id)
+			}
+			continue
+		}
+
+		if now.After(rc.AckBy) {
+			rc.Overdue = true
+			overdue = append(overdue, rc)
+			continue
+		}
+
+		for _, rr := range rc.Pending() {
+			if now.Before(rr.NextAttempt) {
+				continue
+			}
+			rr.Attempts++
+			rr.NextAttempt = now.Add(renotifyBackoff(rr.Attempts))
+			due = append(due, renotification{
+				receiptID: rc.ID,
+				address:   rr.Address,
+				messageID: rr.MessageID,
+				from:      rc.From,
+				subject:   rc.Subject,
+			})
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].receiptID != due[j].receiptID {
+			return due[i].receiptID < due[j].receiptID
+		}
+		return due[i].address < due[j].address
+	})
+	sort.Slice(overdue, func(i, j int) bool { return overdue[i].SentAt.Before(overdue[j].SentAt) })
+	return due, overdue
+}
+
+// markNotified records that the sessions of renotified recipients were
+// reached.
+func markNotified(receipts map[string]*Receipt, notified []renotification, now time.Time) {
+	for _, n := range notified {
+		rc, ok := receipts[n.receiptID]
+		if !ok {
+			continue
+		}
+		if rr := rc.recipient(n.address, n.messageID); rr != nil {
+			rr.advance(StateDelivered, now)
+		}
+	}
+}
+
+// overdueReceipt builds the message telling a sender which recipients
+// didn't acknowledge their message in time.
+func overdueReceipt(rc *Receipt) *Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your message %q (%s) was not acknowledged by %s.\n\n",
+		rc.Subject, rc.ID, rc.AckBy.Format("2006-01-02 15:04"))
+	for _, rr := range rc.Recipients {
+		fmt.Fprintf(&b, "  %s: %s (%d attempts)\n", rr.Address, rr.State, rr.Attempts)
+	}
+	fmt.Fprintf(&b, "\nRun 'gt mail status %s' for details.", rc.ID)
+
+	msg := NewMessage(receiptSender, rc.From, "RECEIPT: unacknowledged: "+rc.Subject, b.String())
+	msg.Priority = PriorityHigh
+	return msg
+}
@@ -0,0 +1,164 @@
+package mail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenotifyBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		5:  16 * time.Minute,
+		6:  30 * time.Minute,
+		20: 30 * time.Minute,
+	}
+	for attempts, want := range tests {
+		if got := renotifyBackoff(attempts); got != want {
+			t.Errorf("renotifyBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestRecipientAdvanceNeverMovesBack(t *testing.T) {
+	now := time.Now()
+	rr := &RecipientReceipt{State: StateSent}
+	if !rr.advance(StateRead, now) || rr.State != StateRead || !rr.ReadAt.Equal(now) {
+		t.Fatalf("advance to read: %+v", rr)
+	}
+	if rr.advance(StateDelivered, now) || rr.State != StateRead {
+		t.Errorf("advance moved read back to %s", rr.State)
+	}
+	if !rr.advance(StateAcked, now) || rr.State != StateAcked {
+		t.Errorf("advance to acked: %+v", rr)
+	}
+}
+
+func TestRenotifyDue(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	rc := &Receipt{
+		ID:     "msg-1",
+		From:   "mayor/",
+		SentAt: now.Add(-10 * time.Minute),
+		AckBy:  now.Add(time.Hour),
+		Recipients: []*RecipientReceipt{
+			{Address: "gastown/Toast", State: StateAcked, Attempts: 1},
+			{Address: "gastown/Nux", State: StateSent, Attempts: 1, NextAttempt: now.Add(-time.Minute)},
+			{Address: "gastown/Ace", State: StateRead, Attempts: 2, NextAttempt: now.Add(time.Minute)},
+		},
+	}
+	old := &Receipt{
+		ID:         "msg-old",
+		SentAt:     now.Add(-8 * 24 * time.Hour),
+		AckBy:      now.Add(-8*24*time.Hour + time.Hour),
+		Recipients: []*RecipientReceipt{{Address: "mayor/", State: StateAcked}},
+	}
+	receipts := map[string]*Receipt{rc.ID: rc, old.ID: old}
+
+	due, overdue := renotifyDue(receipts, now)
+	if len(overdue) != 0 {
+		t.Fatalf("overdue = %v before the deadline", overdue)
+	}
+	if len(due) != 1 || due[0].address != "gastown/Nux" || due[0].receiptID != "msg-1" {
+		t.Fatalf("due = %+v, want only the due recipient", due)
+	}
+	nux := rc.Recipients[1]
+	if nux.State != StateSent || nux.Attempts != 2 || !nux.NextAttempt.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("after claiming the attempt: %+v", nux)
+	}
+	markNotified(receipts, due, now)
+	if nux.State != StateDelivered || !nux.DeliveredAt.Equal(now) {
+		t.Errorf("after notifying: %+v", nux)
+	}
+	if _, ok := receipts["msg-old"]; ok {
+		t.Error("finished receipt older than the retention period was not pruned")
+	}
+
+	due, overdue = renotifyDue(receipts, now.Add(2*time.Hour))
+	if len(overdue) != 1 || overdue[0] != rc || !rc.Overdue {
+		t.Fatalf("overdue = %v, want msg-1", overdue)
+	}
+	if len(due) != 0 {
+		t.Errorf("due %+v after the deadline", due)
+	}
+	if _, again := renotifyDue(receipts, now.Add(3*time.Hour)); len(again) != 0 {
+		t.Errorf("overdue receipt reported twice")
+	}
+
+	msg := overdueReceipt(rc)
+	if msg.To != "mayor/" || msg.Priority != PriorityHigh {
+		t.Errorf("overdue receipt = %+v", msg)
+	}
+}
+
+func TestTrackAndRecordReceipt(t *testing.T) {
+	townRoot := t.TempDir()
+	r := &Router{townRoot: townRoot}
+
+	msg := &Message{
+		From:       "mayor/",
+		To:         "gastown/Toast",
+		Subject:    "Freeze",
+		CC:         []string{"gastown/crew/max"},
+		RequireAck: true,
+		ReceiptID:  "msg-1",
+		AckBy:      time.Now().Add(time.Hour),
+	}
+	if err := r.trackDelivery(msg, "hq-1", true); err != nil {
+		t.Fatal(err)
+	}
+	second := *msg
+	second.To = "gastown/Nux"
+	second.CC = nil
+	if err := r.trackDelivery(&second, "hq-2", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The CC'd recipient reads the primary recipient's copy.
+	copyMsg := &Message{ID: "hq-1", ReceiptID: "msg-1"}
+	if err := r.RecordReceipt(copyMsg, "gastown/max", StateRead); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RecordReceipt(&Message{ID: "hq-2", ReceiptID: "msg-1"}, "gastown/Nux", StateAcked); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := FindReceipt(townRoot, "hq-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]DeliveryState{
+		"gastown/Toast":    StateDelivered,
+		"gastown/crew/max": StateRead,
+		"gastown/Nux":      StateAcked,
+	}
+	if rc.ID != "msg-1" || len(rc.Recipients) != len(want) {
+		t.Fatalf("receipt = %+v", rc)
+	}
+	for _, rr := range rc.Recipients {
+		if rr.State != want[rr.Address] {
+			t.Errorf("%s: state %s, want %s", rr.Address, rr.State, want[rr.Address])
+		}
+	}
+	if len(rc.Pending()) != 2 || rc.Done() {
+		t.Errorf("pending = %d, done = %v", len(rc.Pending()), rc.Done())
+	}
+
+	if _, err := FindReceipt(townRoot, "msg-missing"); err != ErrMessageNotFound {
+		t.Errorf("FindReceipt(missing) error = %v", err)
+	}
+}
+
+func TestReceiptLabel(t *testing.T) {
+	bm := &BeadsMessage{
+		ID:       "hq-1",
+		Title:    "Freeze",
+		Assignee: "gastown/Toast",
+		Labels:   []string{"from:mayor/", "receipt:msg-1"},
+	}
+	msg := bm.ToMessage()
+	if !msg.RequireAck || msg.ReceiptID != "msg-1" {
+		t.Errorf("ToMessage = RequireAck %v, ReceiptID %q", msg.RequireAck, msg.ReceiptID)
+	}
+}
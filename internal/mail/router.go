@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/session"
@@ -22,6 +23,9 @@ var ErrUnknownQueue = errors.New("unknown queue")
 // ErrUnknownAnnounce indicates an announce channel name was not found in configuration.
 var ErrUnknownAnnounce = errors.New("unknown announce channel")
 
+// ErrUnknownNudgeChannel indicates a nudge channel name was not found in configuration.
+var ErrUnknownNudgeChannel = errors.New("unknown nudge channel")
+
 // Router handles message delivery via beads.
 // It routes messages to the correct beads database based on address:
 // - Town-level (mayor/, deacon/) -> {townRoot}/.beads
@@ -150,6 +154,44 @@ func (r *Router) expandAnnounce(announceName string) (*config.AnnounceConfig, er
 	}, ErrUnknownAnnounce)
 }
 
+// ExpandNudgeChannel returns the members of a nudge channel, with @group
+// members resolved to agent addresses. Other members are returned as
+// written: they may be patterns ("gastown/polecats/*", "*/witness"), which
+// the caller matches against running sessions.
+// Returns ErrUnknownNudgeChannel if the channel is not found.
+func (r *Router) ExpandNudgeChannel(name string) ([]string, error) {
+	patterns, err := expandFromConfig(r, name, func(cfg *config.MessagingConfig) ([]string, bool) {
+		p, ok := cfg.NudgeChannels[name]
+		return p, ok
+	}, ErrUnknownNudgeChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	seen := make(map[string]bool)
+	add := func(member string) {
+		if !seen[member] {
+			seen[member] = true
+			members = append(members, member)
+		}
+	}
+	for _, pattern := range patterns {
+		if !isGroupAddress(pattern) {
+			add(pattern)
+			continue
+		}
+		resolved, err := r.ResolveGroupAddress(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("nudge channel %s: %w", name, err)
+		}
+		for _, addr := range resolved {
+			add(addr)
+		}
+	}
+	return members, nil
+}
+
 // detectTownRoot finds the town root by looking for mayor/town.json.
 func detectTownRoot(startDir string) string {
 	dir := startDir
@@ -499,7 +541,27 @@ func (r *Router) shouldBeWisp(msg *Message) bool {
 // Supports single-copy delivery for:
 // - Queues (queue:name) - stores single message for worker claiming
 // - Announces (announce:name) - bulletin board, no claiming, retention-limited
+//
+// Messages with a future DeliverAfter are held in the town's schedule and
+// sent by the daemon once due; lists and groups are expanded then.
+//
+// Messages with RequireAck get a delivery receipt covering every recipient
+// they fan out to; see Receipt. Queue and announce copies aren't tracked.
 func (r *Router) Send(msg *Message) error {
+	if msg.DeliverAfter.After(time.Now()) {
+		return r.schedule(msg)
+	}
+
+	if msg.RequireAck && msg.ReceiptID == "" {
+		msg.ReceiptID = msg.ID
+		if msg.ReceiptID == "" {
+			msg.ReceiptID = generateID()
+		}
+		if msg.AckBy.IsZero() {
+			msg.AckBy = time.Now().Add(DefaultAckTimeout)
+		}
+	}
+
 	// Check for mailing list address
 	if isListAddress(msg.To) {
 		return r.sendToList(msg)
@@ -578,12 +640,15 @@ func (r *Router) sendToSingle(msg *Message) error {
 		ccIdentity := addressToIdentity(cc)
 		labels = append(labels, "cc:"+ccIdentity)
 	}
+	if msg.RequireAck {
+		labels = append(labels, "receipt:"+msg.ReceiptID)
+	}
 
 	// Build command: bd create <subject> --type=message --assignee=<recipient> -d <body>
 	args := []string{"create", msg.Subject,
 		"--type", "message",
 		"--assignee", toIdentity,
-		"-d", msg.Body,
+		"-d", msg.storedBody(),
 	}
 
 	// Add priority flag
@@ -603,16 +668,32 @@ func (r *Router) sendToSingle(msg *Message) error {
 		args = append(args, "--ephemeral")
 	}
 
+	// Tracked messages need the bead ID of each recipient's copy
+	if msg.RequireAck {
+		args = append(args, "--json")
+	}
+
 	beadsDir := r.resolveBeadsDir(msg.To)
-	_, err := runBdCommand(args, filepath.Dir(beadsDir), beadsDir)
+	stdout, err := runBdCommand(args, filepath.Dir(beadsDir), beadsDir)
 	if err != nil {
 		return fmt.Errorf("sending message: %w", err)
 	}
 
 	// Notify recipient if they have an active session (best-effort notification)
 	// Skip notification for self-mail (handoffs to future-self don't need present-self notified)
+	notified := false
 	if !isSelfMail(msg.From, msg.To) {
-		_ = r.notifyRecipient(msg)
+		notified, _ = r.notifySession(msg.To, msg.From, msg.Subject)
+	}
+
+	if msg.RequireAck {
+		var created struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(stdout, &created)
+		if err := r.trackDelivery(msg, created.ID, notified); err != nil {
+			return fmt.Errorf("recording delivery receipt: %w", err)
+		}
 	}
 
 	return nil
@@ -694,7 +775,7 @@ func (r *Router) sendToQueue(msg *Message) error {
 	args := []string{"create", msg.Subject,
 		"--type", "message",
 		"--assignee", msg.To, // queue:name
-		"-d", msg.Body,
+		"-d", msg.storedBody(),
 	}
 
 	// Add priority flag
@@ -765,7 +846,7 @@ func (r *Router) sendToAnnounce(msg *Message) error {
 	args := []string{"create", msg.Subject,
 		"--type", "message",
 		"--assignee", msg.To, // announce:name
-		"-d", msg.Body,
+		"-d", msg.storedBody(),
 	}
 
 	// Add priority flag
@@ -862,23 +943,27 @@ func (r *Router) GetMailbox(address string) (*Mailbox, error) {
 	return NewMailboxFromAddress(address, workDir), nil
 }
 
-// notifyRecipient sends a notification to a recipient's tmux session.
+// notifySession sends a notification to a recipient's tmux session,
+// reporting whether there was a session to notify.
 // Uses send-keys to echo a visible banner to ensure notification is seen.
 // Supports mayor/, rig/polecat, and rig/refinery addresses.
-func (r *Router) notifyRecipient(msg *Message) error {
-	sessionID := addressToSessionID(msg.To)
+func (r *Router) notifySession(address, from, subject string) (bool, error) {
+	sessionID := addressToSessionID(address)
 	if sessionID == "" {
-		return nil // Unable to determine session ID
+		return false, nil // Unable to determine session ID
 	}
 
 	// Check if session exists
 	hasSession, err := r.tmux.HasSession(sessionID)
 	if err != nil || !hasSession {
-		return nil // No active session, skip notification
+		return false, nil // No active session, skip notification
 	}
 
 	// Send visible notification banner to the terminal
-	return r.tmux.SendNotificationBanner(sessionID, msg.From, msg.Subject)
+	if err := r.tmux.SendNotificationBanner(sessionID, from, subject); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // addressToSessionID converts a mail address to a tmux session ID.
@@ -813,3 +813,38 @@ func TestExpandAnnounceNoTownRoot(t *testing.T) {
 		t.Errorf("expandAnnounce error = %v, want containing 'no town root'", err)
 	}
 }
+
+func TestExpandNudgeChannel(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `{
+  "type": "messaging",
+  "version": 1,
+  "nudge_channels": {
+    "workers": ["gastown/polecats/*", "gastown/crew/*", "gastown/polecats/*"],
+    "witnesses": ["*/witness"]
+  }
+}`
+	if err := os.WriteFile(filepath.Join(configDir, "messaging.json"), []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRouterWithTownRoot(tmpDir, tmpDir)
+
+	got, err := r.ExpandNudgeChannel("workers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"gastown/polecats/*", "gastown/crew/*"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ExpandNudgeChannel(workers) = %v, want %v (deduplicated)", got, want)
+	}
+
+	if _, err := r.ExpandNudgeChannel("nonexistent"); err == nil || !contains(err.Error(), "unknown nudge channel") {
+		t.Errorf("ExpandNudgeChannel(nonexistent) error = %v, want unknown nudge channel", err)
+	}
+}
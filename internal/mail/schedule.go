@@ -0,0 +1,286 @@
+package mail
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// scheduleSender is the default From address of recurring messages.
+const scheduleSender = "daemon"
+
+// scheduleState is the town's mail schedule: messages waiting for their
+// DeliverAfter time, and when each recurring schedule last ran.
+type scheduleState struct {
+	Pending []*Message           `json:"pending,omitempty"`
+	LastRun map[string]time.Time `json:"last_run,omitempty"`
+}
+
+// ScheduleFile returns the path of the town's mail schedule.
+func ScheduleFile(townRoot string) string {
+	return filepath.Join(constants.TownRuntimePath(townRoot), "mail-scheduled.json")
+}
+
+// updateSchedule applies fn to the town's mail schedule and saves it.
+func updateSchedule(townRoot string, fn func(*scheduleState)) error {
+	var state scheduleState
+	if err := updateStateFile(ScheduleFile(townRoot), &state, func() { fn(&state) }); err != nil {
+		return fmt.Errorf("updating mail schedule: %w", err)
+	}
+	return nil
+}
+
+// schedule holds msg until its DeliverAfter time, giving it an ID if it
+// has none.
+func (r *Router) schedule(msg *Message) error {
+	if r.townRoot == "" {
+		return fmt.Errorf("scheduling message: no town root")
+	}
+	if msg.ID == "" {
+		msg.ID = generateID()
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	return updateSchedule(r.townRoot, func(s *scheduleState) {
+		s.Pending = append(s.Pending, msg)
+	})
+}
+
+// ListScheduled returns the messages waiting for delivery, soonest first.
+func (r *Router) ListScheduled() ([]*Message, error) {
+	if r.townRoot == "" {
+		return nil, nil
+	}
+	var state scheduleState
+	if err := readStateFile(ScheduleFile(r.townRoot), &state); err != nil {
+		return nil, fmt.Errorf("reading mail schedule: %w", err)
+	}
+	sort.SliceStable(state.Pending, func(i, j int) bool {
+		return state.Pending[i].DeliverAfter.Before(state.Pending[j].DeliverAfter)
+	})
+	return state.Pending, nil
+}
+
+// CancelScheduled removes a waiting message from the schedule, including
+// every recipient still waiting for a partly delivered list or group
+// message. Returns ErrMessageNotFound if there is no such message (it may
+// have been delivered already).
+func (r *Router) CancelScheduled(id string) (*Message, error) {
+	if r.townRoot == "" {
+		return nil, ErrMessageNotFound
+	}
+	var cancelled *Message
+	err := updateSchedule(r.townRoot, func(s *scheduleState) {
+		waiting := s.Pending[:0]
+		for _, msg := range s.Pending {
+			if msg.ID != id {
+				waiting = append(waiting, msg)
+			} else if cancelled == nil {
+				cancelled = msg
+			}
+		}
+		s.Pending = waiting
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cancelled == nil {
+		return nil, ErrMessageNotFound
+	}
+	return cancelled, nil
+}
+
+// ScheduledRun is a recurring schedule from the messaging config, with the
+// time it next runs.
+type ScheduledRun struct {
+	Name     string              `json:"name"`
+	Schedule config.MailSchedule `json:"schedule"`
+	LastRun  time.Time           `json:"last_run,omitempty"`
+	NextRun  time.Time           `json:"next_run"`
+}
+
+// ListRecurring returns the town's recurring schedules, soonest first.
+func (r *Router) ListRecurring() ([]ScheduledRun, error) {
+	if r.townRoot == "" {
+		return nil, nil
+	}
+	cfg, err := config.LoadOrCreateMessagingConfig(config.MessagingConfigPath(r.townRoot))
+	if err != nil {
+		return nil, fmt.Errorf("loading messaging config: %w", err)
+	}
+	var state scheduleState
+	if err := readStateFile(ScheduleFile(r.townRoot), &state); err != nil {
+		return nil, fmt.Errorf("reading mail schedule: %w", err)
+	}
+
+	now := time.Now()
+	var runs []ScheduledRun
+	for name, sched := range cfg.Schedules {
+		cron, err := util.ParseCron(sched.Cron)
+		if err != nil {
+			continue // Rejected when the config loads
+		}
+		from := state.LastRun[name]
+		if from.IsZero() {
+			from = now
+		}
+		runs = append(runs, ScheduledRun{Name: name, Schedule: sched, LastRun: state.LastRun[name], NextRun: cron.Next(from)})
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].NextRun.Before(runs[j].NextRun) })
+	return runs, nil
+}
+
+// DeliverScheduled sends the scheduled messages that are due, and the
+// recurring messages whose cron time has come, through the normal delivery
+// path, which notifies recipients' sessions. A recurring message missed
+// while the daemon was down is sent once, not once per missed run.
+// List and @group messages are sent one recipient at a time; recipients
+// that fail to receive a message stay scheduled and are retried next time,
+// so the others are not sent it twice. The daemon calls this every
+// heartbeat. Returns how many due messages reached all their recipients.
+func (r *Router) DeliverScheduled() (int, error) {
+	if r.townRoot == "" {
+		return 0, nil
+	}
+
+	var recurring map[string]config.MailSchedule
+	if cfg, err := config.LoadMessagingConfig(config.MessagingConfigPath(r.townRoot)); err == nil {
+		recurring = cfg.Schedules
+	}
+
+	var due []*Message
+	now := time.Now()
+	err := updateSchedule(r.townRoot, func(s *scheduleState) {
+		due = releaseDue(s, recurring, now)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	var failed []*Message
+	var errs []string
+	for _, msg := range due {
+		copies, err := r.fanOut(msg)
+		if err != nil {
+			failed = append(failed, msg)
+			errs = append(errs, fmt.Sprintf("%s: %v", msg.ID, err))
+			continue
+		}
+		ok := true
+		for _, c := range copies {
+			if err := r.Send(c); err != nil {
+				ok = false
+				failed = append(failed, c)
+				errs = append(errs, fmt.Sprintf("%s to %s: %v", msg.ID, c.To, err))
+			}
+		}
+		if ok {
+			delivered++
+		}
+	}
+	if len(failed) > 0 {
+		for _, msg := range failed {
+			msg.DeliverAfter = now
+		}
+		if err := updateSchedule(r.townRoot, func(s *scheduleState) {
+			s.Pending = append(s.Pending, failed...)
+		}); err != nil {
+			errs = append(errs, err.Error())
+		}
+		return delivered, fmt.Errorf("delivering scheduled mail: %s", strings.Join(errs, "; "))
+	}
+	return delivered, nil
+}
+
+// fanOut splits a list or @group message into one copy per recipient, so
+// a delivery that fails part way re-queues only the recipients it missed.
+// Other messages are returned as they are.
+func (r *Router) fanOut(msg *Message) ([]*Message, error) {
+	var recipients []string
+	var err error
+	switch {
+	case isListAddress(msg.To):
+		recipients, err = r.ExpandListAddress(msg.To)
+	case isGroupAddress(msg.To):
+		recipients, err = r.ResolveGroupAddress(msg.To)
+		if err == nil && len(recipients) == 0 {
+			err = fmt.Errorf("no recipients found for group: %s", msg.To)
+		}
+	default:
+		return []*Message{msg}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make([]*Message, 0, len(recipients))
+	for _, recipient := range recipients {
+		c := *msg
+		c.To = recipient
+		copies = append(copies, &c)
+	}
+	return copies, nil
+}
+
+// releaseDue removes the messages due at now from the schedule and builds
+// the recurring messages that are due, returning both ready to send.
+// Released messages have DeliverAfter cleared.
+func releaseDue(s *scheduleState, recurring map[string]config.MailSchedule, now time.Time) []*Message {
+	var due, waiting []*Message
+	for _, msg := range s.Pending {
+		if msg.DeliverAfter.After(now) {
+			waiting = append(waiting, msg)
+			continue
+		}
+		msg.DeliverAfter = time.Time{}
+		due = append(due, msg)
+	}
+	s.Pending = waiting
+
+	if s.LastRun == nil {
+		s.LastRun = make(map[string]time.Time)
+	}
+	for name := range s.LastRun {
+		if _, ok := recurring[name]; !ok {
+			delete(s.LastRun, name)
+		}
+	}
+
+	names := make([]string, 0, len(recurring))
+	for name := range recurring {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sched := recurring[name]
+		cron, err := util.ParseCron(sched.Cron)
+		if err != nil {
+			continue
+		}
+		last, ok := s.LastRun[name]
+		if !ok {
+			// New schedule: count from now rather than firing at once.
+			s.LastRun[name] = now
+			continue
+		}
+		if next := cron.Next(last); next.IsZero() || next.After(now) {
+			continue
+		}
+		s.LastRun[name] = now
+
+		from := sched.From
+		if from == "" {
+			from = scheduleSender
+		}
+		due = append(due, NewMessage(from, sched.To, sched.Subject, sched.Body))
+	}
+	return due
+}
@@ -0,0 +1,150 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestReleaseDue(t *testing.T) {
+	now := time.Date(2026, 1, 2, 9, 1, 0, 0, time.UTC)
+	s := &scheduleState{
+		Pending: []*Message{
+			{ID: "msg-due", To: "mayor/", DeliverAfter: now.Add(-time.Minute)},
+			{ID: "msg-later", To: "mayor/", DeliverAfter: now.Add(time.Hour)},
+		},
+		LastRun: map[string]time.Time{
+			"standup": now.Add(-24 * time.Hour),
+			"removed": now.Add(-time.Hour),
+		},
+	}
+	recurring := map[string]config.MailSchedule{
+		"standup": {Cron: "0 9 * * *", To: "list:oncall", Subject: "Standup"},
+		"new":     {Cron: "* * * * *", To: "announce:alerts", Subject: "Tick", From: "deacon/"},
+	}
+
+	due := releaseDue(s, recurring, now)
+	if len(due) != 2 || due[0].ID != "msg-due" || due[1].To != "list:oncall" {
+		t.Fatalf("due = %+v, want msg-due and the standup", due)
+	}
+	if !due[0].DeliverAfter.IsZero() {
+		t.Error("released message still has DeliverAfter set")
+	}
+	if due[1].From != scheduleSender || due[1].Subject != "Standup" {
+		t.Errorf("recurring message = %+v", due[1])
+	}
+	if len(s.Pending) != 1 || s.Pending[0].ID != "msg-later" {
+		t.Errorf("pending = %+v, want msg-later", s.Pending)
+	}
+	if _, ok := s.LastRun["removed"]; ok {
+		t.Error("last run of a removed schedule was kept")
+	}
+	if !s.LastRun["new"].Equal(now) || !s.LastRun["standup"].Equal(now) {
+		t.Errorf("last runs = %v", s.LastRun)
+	}
+
+	// Next beat: the standup already ran today; the new schedule is due.
+	due = releaseDue(s, recurring, now.Add(3*time.Minute))
+	if len(due) != 1 || due[0].From != "deacon/" {
+		t.Errorf("second beat due = %+v, want only the new schedule", due)
+	}
+}
+
+func TestScheduleAndCancel(t *testing.T) {
+	r := &Router{townRoot: t.TempDir()}
+
+	later := &Message{From: "mayor/", To: "gastown/witness", Subject: "Check", DeliverAfter: time.Now().Add(2 * time.Hour)}
+	sooner := &Message{From: "mayor/", To: "mayor/", Subject: "Reminder", DeliverAfter: time.Now().Add(time.Hour)}
+	for _, msg := range []*Message{later, sooner} {
+		if err := r.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.ID == "" {
+			t.Fatal("scheduled message was not given an ID")
+		}
+	}
+
+	pending, err := r.ListScheduled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].ID != sooner.ID || pending[1].ID != later.ID {
+		t.Fatalf("ListScheduled = %+v, want soonest first", pending)
+	}
+
+	if _, err := r.CancelScheduled(later.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CancelScheduled(later.ID); err != ErrMessageNotFound {
+		t.Errorf("second cancel error = %v, want ErrMessageNotFound", err)
+	}
+	pending, _ = r.ListScheduled()
+	if len(pending) != 1 || pending[0].Subject != "Reminder" {
+		t.Errorf("after cancel: %+v", pending)
+	}
+}
+
+// fakeBD puts a bd on PATH that logs the assignee of each message it
+// creates, failing for the assignee named by $FAIL_ASSIGNEE.
+func fakeBD(t *testing.T) (logPath string) {
+	t.Helper()
+	bin := t.TempDir()
+	logPath = filepath.Join(bin, "created.log")
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+  if [ "$1" = "--assignee" ]; then assignee="$2"; fi
+  shift
+done
+if [ -n "$FAIL_ASSIGNEE" ] && [ "$assignee" = "$FAIL_ASSIGNEE" ]; then
+  echo "bd unavailable" >&2
+  exit 1
+fi
+echo "$assignee" >> "` + logPath + `"
+`
+	if err := os.WriteFile(filepath.Join(bin, "bd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+func TestDeliverScheduled_RequeuesOnlyFailedRecipients(t *testing.T) {
+	town := t.TempDir()
+	cfg := config.NewMessagingConfig()
+	cfg.Lists["oncall"] = []string{"mayor/", "gastown/witness"}
+	if err := config.SaveMessagingConfig(config.MessagingConfigPath(town), cfg); err != nil {
+		t.Fatal(err)
+	}
+	created := fakeBD(t)
+	t.Setenv("FAIL_ASSIGNEE", "gastown/witness")
+
+	r := NewRouterWithTownRoot(town, town)
+	msg := &Message{ID: "msg-1", From: "mayor/", To: "list:oncall", Subject: "Standup", DeliverAfter: time.Now().Add(-time.Minute)}
+	if err := updateSchedule(town, func(s *scheduleState) { s.Pending = append(s.Pending, msg) }); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := r.DeliverScheduled(); err == nil || n != 0 {
+		t.Fatalf("DeliverScheduled = %d, %v; want a partial failure", n, err)
+	}
+	pending, _ := r.ListScheduled()
+	if len(pending) != 1 || pending[0].To != "gastown/witness" || pending[0].ID != "msg-1" {
+		t.Fatalf("pending = %+v, want only the witness re-queued", pending)
+	}
+
+	t.Setenv("FAIL_ASSIGNEE", "")
+	if n, err := r.DeliverScheduled(); err != nil || n != 1 {
+		t.Fatalf("retry DeliverScheduled = %d, %v", n, err)
+	}
+	data, err := os.ReadFile(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(data)); strings.Join(got, ",") != "mayor/,gastown/witness" {
+		t.Errorf("messages created for %v, want each recipient once", got)
+	}
+}
@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// readStateFile decodes the JSON state file at path into v, leaving v
+// unchanged if the file doesn't exist.
+func readStateFile(path string, v interface{}) error {
+	lock := flock.New(path + ".lock")
+	if err := lock.RLock(); err == nil {
+		defer func() { _ = lock.Unlock() }()
+	}
+	return decodeStateFile(path, v)
+}
+
+// updateStateFile decodes the JSON state file at path into v, calls fn to
+// modify v, and saves it, holding the file's lock throughout so that
+// concurrent gt commands and the daemon don't lose each other's updates.
+func updateStateFile(path string, v interface{}, fn func()) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating runtime directory: %w", err)
+	}
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking %s: %w", filepath.Base(path), err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	if err := decodeStateFile(path, v); err != nil {
+		return err
+	}
+	fn()
+	return util.AtomicWriteJSON(path, v)
+}
+
+// decodeStateFile reads path into v. Callers hold the lock.
+func decodeStateFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -107,6 +107,28 @@ type Message struct {
 	// CC contains addresses that should receive a copy of this message.
 	// CC'd recipients see the message in their inbox but are not the primary recipient.
 	CC []string `json:"cc,omitempty"`
+
+	// Envelope is the typed protocol payload of a machine-readable message.
+	// Nil for messages between humans and agents.
+	Envelope *Envelope `json:"envelope,omitempty"`
+
+	// RequireAck asks each recipient to acknowledge the message (gt mail ack).
+	// Until they do, the daemon renotifies them, and the sender gets a
+	// receipt if AckBy passes. See Receipt.
+	RequireAck bool `json:"require_ack,omitempty"`
+
+	// AckBy is the acknowledgement deadline. Defaults to DefaultAckTimeout
+	// after sending.
+	AckBy time.Time `json:"ack_by,omitempty"`
+
+	// ReceiptID identifies the delivery receipt of a message sent with
+	// RequireAck. Every recipient's copy carries the same ID.
+	ReceiptID string `json:"receipt_id,omitempty"`
+
+	// DeliverAfter defers delivery: until then the message waits in the
+	// town's schedule (gt mail scheduled), and the daemon sends it on its
+	// first heartbeat after. Zero means deliver now.
+	DeliverAfter time.Time `json:"deliver_after,omitempty"`
 }
 
 // NewMessage creates a new message with a generated ID and thread ID.
@@ -184,6 +206,7 @@ type BeadsMessage struct {
 	replyTo  string
 	msgType  string
 	cc       []string // CC recipients
+	receipt  string   // Delivery receipt ID, for messages requiring ack
 }
 
 // ParseLabels extracts metadata from the labels array.
@@ -199,6 +222,8 @@ func (bm *BeadsMessage) ParseLabels() {
 			bm.msgType = strings.TrimPrefix(label, "msg-type:")
 		} else if strings.HasPrefix(label, "cc:") {
 			bm.cc = append(bm.cc, strings.TrimPrefix(label, "cc:"))
+		} else if strings.HasPrefix(label, "receipt:") {
+			bm.receipt = strings.TrimPrefix(label, "receipt:")
 		}
 	}
 }
@@ -249,20 +274,25 @@ func (bm *BeadsMessage) ToMessage() *Message {
 		ccAddrs = append(ccAddrs, identityToAddress(cc))
 	}
 
+	body, envelope := splitEnvelope(bm.Description)
+
 	return &Message{
-		ID:        bm.ID,
-		From:      identityToAddress(bm.sender),
-		To:        identityToAddress(bm.Assignee),
-		Subject:   bm.Title,
-		Body:      bm.Description,
-		Timestamp: bm.CreatedAt,
-		Read:      bm.Status == "closed",
-		Priority:  priority,
-		Type:      msgType,
-		ThreadID:  bm.threadID,
-		ReplyTo:   bm.replyTo,
-		Wisp:      bm.Wisp,
-		CC:        ccAddrs,
+		ID:         bm.ID,
+		From:       identityToAddress(bm.sender),
+		To:         identityToAddress(bm.Assignee),
+		Subject:    bm.Title,
+		Body:       body,
+		Timestamp:  bm.CreatedAt,
+		Read:       bm.Status == "closed",
+		Priority:   priority,
+		Type:       msgType,
+		ThreadID:   bm.threadID,
+		ReplyTo:    bm.replyTo,
+		Wisp:       bm.Wisp,
+		CC:         ccAddrs,
+		Envelope:   envelope,
+		RequireAck: bm.receipt != "",
+		ReceiptID:  bm.receipt,
 	}
 }
 
@@ -13,6 +13,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/gofrs/flock"
 )
 
 // MR represents a merge request in the queue.
@@ -30,6 +32,7 @@ type MR struct {
 
 	// Priority scoring fields
 	RetryCount      int        `json:"retry_count,omitempty"`       // Conflict retry count for priority penalty
+	RebaseCount     int        `json:"rebase_count,omitempty"`      // Auto-rebase attempts, also counted toward the retry penalty
 	ConvoyID        string     `json:"convoy_id,omitempty"`         // Parent convoy ID if part of a convoy
 	ConvoyCreatedAt *time.Time `json:"convoy_created_at,omitempty"` // Convoy creation time for starvation prevention
 
@@ -238,13 +241,35 @@ func (q *Queue) Dir() string {
 // another worker can reclaim it.
 const ClaimStaleTimeout = 10 * time.Minute
 
+// lockClaims takes the queue-wide claim lock. Claim, Release, Refresh
+// and RecordRebase hold it across their read-modify-write so that two workers
+// (goroutines or processes) can never both see an MR as unclaimed, and
+// concurrent updates to an MR file don't overwrite each other.
+func (q *Queue) lockClaims() (func(), error) {
+	if err := q.EnsureDir(); err != nil {
+		return nil, fmt.Errorf("creating mq directory: %w", err)
+	}
+	fileLock := flock.New(filepath.Join(q.dir, ".claim.lock"))
+	if err := fileLock.Lock(); err != nil {
+		return nil, fmt.Errorf("acquiring claim lock: %w", err)
+	}
+	return func() { _ = fileLock.Unlock() }, nil
+}
+
 // Claim attempts to claim an MR for processing by a specific worker.
 // Returns nil if successful, ErrAlreadyClaimed if another worker has it,
 // or ErrNotFound if the MR doesn't exist.
-// Uses atomic file operations to prevent race conditions.
+// The check and the write happen under the queue's claim lock, so
+// concurrent claims for the same MR have exactly one winner.
 func (q *Queue) Claim(id, workerID string) error {
 	path := filepath.Join(q.dir, id+".json")
 
+	unlock, err := q.lockClaims()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Read current state
 	mr, err := q.load(path)
 	if err != nil {
@@ -292,6 +317,12 @@ func (q *Queue) Claim(id, workerID string) error {
 func (q *Queue) Release(id string) error {
 	path := filepath.Join(q.dir, id+".json")
 
+	unlock, err := q.lockClaims()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	mr, err := q.load(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -312,6 +343,49 @@ func (q *Queue) Release(id string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+// Refresh renews workerID's claim on an MR so it doesn't go stale while
+// the worker is still busy with it. Returns ErrAlreadyClaimed if the MR is
+// no longer claimed by workerID, or ErrNotFound if the MR doesn't exist.
+func (q *Queue) Refresh(id, workerID string) error {
+	path := filepath.Join(q.dir, id+".json")
+
+	unlock, err := q.lockClaims()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	mr, err := q.load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("loading MR: %w", err)
+	}
+	if mr.ClaimedBy != workerID {
+		return ErrAlreadyClaimed
+	}
+
+	now := time.Now()
+	mr.ClaimedAt = &now
+
+	data, err := json.MarshalIndent(mr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling MR: %w", err)
+	}
+
+	// Write atomically: refreshes happen while readers poll the queue.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath) // cleanup
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
 // ListUnclaimed returns MRs that are not claimed or have stale claims.
 // Sorted by priority then creation time.
 func (q *Queue) ListUnclaimed() ([]*MR, error) {
@@ -381,6 +455,38 @@ func (q *Queue) SetBlockedBy(mrID, taskID string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+// RecordRebase increments an MR's rebase count and returns the new value.
+// Called by the refinery each time it auto-rebases the MR's branch.
+func (q *Queue) RecordRebase(mrID string) (int, error) {
+	path := filepath.Join(q.dir, mrID+".json")
+
+	unlock, err := q.lockClaims()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	mr, err := q.load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("loading MR: %w", err)
+	}
+
+	mr.RebaseCount++
+
+	data, err := json.MarshalIndent(mr, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("marshaling MR: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return 0, err
+	}
+	return mr.RebaseCount, nil
+}
+
 // ClearBlockedBy removes the blocking task from an MR.
 func (q *Queue) ClearBlockedBy(mrID string) error {
 	return q.SetBlockedBy(mrID, "")
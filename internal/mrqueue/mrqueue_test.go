@@ -0,0 +1,175 @@
+package mrqueue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClaimRelease(t *testing.T) {
+	q := New(t.TempDir())
+	mr := &MR{Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if err := q.Claim(mr.ID, "w1"); err != nil {
+		t.Fatalf("Claim w1: %v", err)
+	}
+	if err := q.Claim(mr.ID, "w2"); err != ErrAlreadyClaimed {
+		t.Fatalf("Claim w2 = %v, want ErrAlreadyClaimed", err)
+	}
+	if err := q.Claim(mr.ID, "w1"); err != nil {
+		t.Errorf("re-Claim by holder: %v", err)
+	}
+
+	if err := q.Release(mr.ID); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := q.Claim(mr.ID, "w2"); err != nil {
+		t.Errorf("Claim after Release: %v", err)
+	}
+
+	if err := q.Claim("mr-missing", "w1"); err != ErrNotFound {
+		t.Errorf("Claim missing = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClaimConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	q := New(dir)
+	mr := &MR{Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	const workers = 16
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Separate Queue values, as separate refinery processes would have.
+			if err := New(dir).Claim(mr.ID, "worker-"+string(rune('a'+i))); err == nil {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Errorf("winners = %d, want exactly 1", winners)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	q := New(t.TempDir())
+	mr := &MR{Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if err := q.Claim(mr.ID, "w1"); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+
+	// Age the claim past the stale timeout, then renew it.
+	path := filepath.Join(q.Dir(), mr.ID+".json")
+	stale, err := q.load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	old := time.Now().Add(-2 * ClaimStaleTimeout)
+	stale.ClaimedAt = &old
+	data, _ := json.Marshal(stale)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Refresh(mr.ID, "w2"); err != ErrAlreadyClaimed {
+		t.Errorf("Refresh by non-holder = %v, want ErrAlreadyClaimed", err)
+	}
+	if err := q.Refresh(mr.ID, "w1"); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if err := q.Claim(mr.ID, "w2"); err != ErrAlreadyClaimed {
+		t.Errorf("Claim after Refresh = %v, want ErrAlreadyClaimed", err)
+	}
+
+	if err := q.Refresh("mr-missing", "w1"); err != ErrNotFound {
+		t.Errorf("Refresh missing = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRecordRebase(t *testing.T) {
+	q := New(t.TempDir())
+	mr := &MR{Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	for want := 1; want <= 2; want++ {
+		got, err := q.RecordRebase(mr.ID)
+		if err != nil {
+			t.Fatalf("RecordRebase: %v", err)
+		}
+		if got != want {
+			t.Errorf("RecordRebase = %d, want %d", got, want)
+		}
+	}
+
+	stored, err := q.Get(mr.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.RebaseCount != 2 {
+		t.Errorf("stored RebaseCount = %d, want 2", stored.RebaseCount)
+	}
+
+	if _, err := q.RecordRebase("mr-missing"); err != ErrNotFound {
+		t.Errorf("RecordRebase missing = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRecordRebaseConcurrentWithClaims(t *testing.T) {
+	dir := t.TempDir()
+	q := New(dir)
+	mr := &MR{Branch: "polecat/nux", Target: "main"}
+	if err := q.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	const rebases = 16
+	var wg sync.WaitGroup
+	for i := 0; i < rebases; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := New(dir).RecordRebase(mr.ID); err != nil {
+				t.Errorf("RecordRebase: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			qq := New(dir)
+			if err := qq.Claim(mr.ID, "w1"); err == nil {
+				_ = qq.Release(mr.ID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := q.Get(mr.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.RebaseCount != rebases {
+		t.Errorf("RebaseCount = %d, want %d (updates lost)", stored.RebaseCount, rebases)
+	}
+}
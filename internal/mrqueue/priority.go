@@ -115,8 +115,8 @@ type ScoreInput struct {
 	// Nil if MR is not part of a convoy (standalone work).
 	ConvoyCreatedAt *time.Time
 
-	// RetryCount is how many times this MR has been retried after conflicts.
-	// 0 = first attempt.
+	// RetryCount is how many times this MR has been retried after conflicts,
+	// including automatic rebases. 0 = first attempt.
 	RetryCount int
 
 	// Now is the current time (for deterministic testing).
@@ -202,7 +202,7 @@ func (mr *MR) ScoreAt(now time.Time) float64 {
 		Priority:        mr.Priority,
 		MRCreatedAt:     mr.CreatedAt,
 		ConvoyCreatedAt: mr.ConvoyCreatedAt,
-		RetryCount:      mr.RetryCount,
+		RetryCount:      mr.RetryCount + mr.RebaseCount,
 		Now:             now,
 	}
 	return ScoreMRWithDefaults(input)
@@ -287,6 +287,23 @@ func TestMR_Score(t *testing.T) {
 	}
 }
 
+func TestMR_ScoreCountsRebases(t *testing.T) {
+	now := time.Now()
+
+	mr := &MR{
+		Priority:    2,
+		CreatedAt:   now,
+		RetryCount:  1,
+		RebaseCount: 2,
+	}
+
+	// base(1000) + priority(2*100=200) - retry((1+2)*50=150)
+	expected := 1000.0 + 200.0 - 150.0
+	if score := mr.ScoreAt(now); score != expected {
+		t.Errorf("MR.ScoreAt expected %f, got %f", expected, score)
+	}
+}
+
 func TestScoreMR_EdgeCases(t *testing.T) {
 	now := time.Now()
 
@@ -0,0 +1,339 @@
+// Package notify delivers town events to outbound notification sinks.
+//
+// The dispatcher tails the events log and hands each event to every
+// configured sink whose type, severity and filter rules select it. Each sink
+// has its own queue and worker, so a slow or failing destination never
+// delays the others. Failed deliveries are retried with exponential backoff,
+// and every attempt is recorded in <town>/daemon/notifications.jsonl.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// LogFile is the delivery log's name within the daemon directory.
+const LogFile = "notifications.jsonl"
+
+// DefaultEvents are the event types a sink receives when it lists none.
+var DefaultEvents = []string{
+	events.TypeMerged,
+	events.TypeMergeFailed,
+	events.TypeEscalationSent,
+	events.TypeConvoyClosed,
+	events.TypePolecatCrashed,
+}
+
+// Delivery settings. Variables so tests can shorten them.
+var (
+	defaultMaxAttempts = 5
+	retryBase          = 2 * time.Second
+	retryMax           = 5 * time.Minute
+	queueSize          = 256
+)
+
+// Delivery statuses recorded in the delivery log.
+const (
+	StatusDelivered = "delivered"
+	StatusRetrying  = "retrying"
+	StatusFailed    = "failed"
+)
+
+// Delivery is one delivery attempt, as recorded in the delivery log.
+type Delivery struct {
+	Time    time.Time `json:"ts"`
+	Sink    string    `json:"sink"`
+	Seq     int64     `json:"seq,omitempty"`
+	Event   string    `json:"event"`
+	Attempt int       `json:"attempt"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// LogPath returns the path of townRoot's delivery log.
+func LogPath(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", LogFile)
+}
+
+// sender delivers one event to a sink's destination.
+type sender interface {
+	send(ctx context.Context, e *events.Event) error
+}
+
+// permanentError marks a failure that retrying cannot fix, such as a
+// rejected request.
+type permanentError struct{ err error }
+
+func (p *permanentError) Error() string { return p.err.Error() }
+func (p *permanentError) Unwrap() error { return p.err }
+
+// sink is a configured destination with its selection rules and queue.
+type sink struct {
+	name        string
+	sender      sender
+	types       *events.Filter
+	filter      *events.Filter
+	minSeverity int
+	maxAttempts int
+	queue       chan events.Event
+}
+
+// accepts reports whether the sink wants the event.
+func (s *sink) accepts(e events.Event) bool {
+	if events.SeverityRank(e.Severity()) < s.minSeverity {
+		return false
+	}
+	r := e.Record()
+	return s.types.Match(r) && s.filter.Match(r)
+}
+
+// Dispatcher routes events from the log to notification sinks.
+type Dispatcher struct {
+	townRoot string
+	sinks    []*sink
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	logMu  sync.Mutex
+}
+
+// NewDispatcher validates the sink configuration and returns a dispatcher
+// for townRoot. Nothing is delivered until Start is called.
+func NewDispatcher(townRoot string, configs []*config.NotificationSink) (*Dispatcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{townRoot: townRoot, ctx: ctx, cancel: cancel}
+	for i, cfg := range configs {
+		s, err := newSink(cfg)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("notification sink %d: %w", i+1, err)
+		}
+		d.sinks = append(d.sinks, s)
+	}
+	return d, nil
+}
+
+// newSink builds a sink from its configuration.
+func newSink(cfg *config.NotificationSink) (*sink, error) {
+	if cfg == nil {
+		return nil, errors.New("empty sink")
+	}
+	s := &sink{
+		name:        cfg.Name,
+		maxAttempts: cfg.MaxAttempts,
+		queue:       make(chan events.Event, queueSize),
+	}
+	if s.name == "" {
+		s.name = cfg.Type
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultMaxAttempts
+	}
+
+	var err error
+	switch cfg.Type {
+	case config.SinkWebhook:
+		s.sender, err = newWebhookSender(cfg)
+	case config.SinkSlack:
+		s.sender, err = newSlackSender(cfg)
+	case config.SinkEmail:
+		s.sender, err = newEmailSender(cfg)
+	case config.SinkCommand:
+		s.sender, err = newCommandSender(cfg)
+	default:
+		err = fmt.Errorf("unknown type %q (want webhook, slack, email or command)", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	types := cfg.Events
+	if len(types) == 0 {
+		types = DefaultEvents
+	}
+	for _, t := range types {
+		if t == "" {
+			return nil, errors.New("empty event type")
+		}
+	}
+	// Types are globs, so they go through the query parser quoted.
+	if s.types, err = events.ParseFilter(`"type=`+strings.Join(types, ",")+`"`, time.Time{}); err != nil {
+		return nil, fmt.Errorf("invalid events: %w", err)
+	}
+
+	if cfg.MinSeverity != "" {
+		s.minSeverity = events.SeverityRank(cfg.MinSeverity)
+		if s.minSeverity == 0 {
+			return nil, fmt.Errorf("invalid min_severity %q (want LOW, MEDIUM, HIGH or CRITICAL)", cfg.MinSeverity)
+		}
+	}
+	if cfg.Filter != "" {
+		if s.filter, err = events.ParseFilter(cfg.Filter, time.Now()); err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Start begins following the events log and delivering to the sinks.
+// Only events logged after Start are delivered.
+func (d *Dispatcher) Start() error {
+	tailer, err := events.NewTailer(d.townRoot)
+	if err != nil {
+		return err
+	}
+	for _, s := range d.sinks {
+		d.wg.Add(1)
+		go d.work(s)
+	}
+	d.wg.Add(1)
+	go d.follow(tailer)
+	return nil
+}
+
+// Stop stops delivery, abandoning queued events and pending retries.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// follow feeds events from the log to Dispatch.
+func (d *Dispatcher) follow(tailer *events.Tailer) {
+	defer d.wg.Done()
+	defer tailer.Close()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			lines, _ := tailer.Poll()
+			for _, line := range lines {
+				var e events.Event
+				if json.Unmarshal([]byte(line), &e) == nil {
+					d.Dispatch(e)
+				}
+			}
+		}
+	}
+}
+
+// Dispatch queues an event for every sink that accepts it. A sink whose
+// queue is full drops the event and records the failure.
+func (d *Dispatcher) Dispatch(e events.Event) {
+	for _, s := range d.sinks {
+		if !s.accepts(e) {
+			continue
+		}
+		select {
+		case s.queue <- e:
+		default:
+			d.record(s, e, 0, StatusFailed, errors.New("queue full"))
+		}
+	}
+}
+
+// work delivers a sink's queued events one at a time.
+func (d *Dispatcher) work(s *sink) {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case e := <-s.queue:
+			d.deliver(s, e)
+		}
+	}
+}
+
+// deliver sends an event, retrying with exponential backoff until it
+// succeeds, fails permanently or runs out of attempts.
+func (d *Dispatcher) deliver(s *sink, e events.Event) {
+	delay := retryBase
+	for attempt := 1; ; attempt++ {
+		err := s.sender.send(d.ctx, &e)
+		if err == nil {
+			d.record(s, e, attempt, StatusDelivered, nil)
+			return
+		}
+		var permanent *permanentError
+		if attempt >= s.maxAttempts || errors.As(err, &permanent) || d.ctx.Err() != nil {
+			d.record(s, e, attempt, StatusFailed, err)
+			return
+		}
+		d.record(s, e, attempt, StatusRetrying, err)
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMax)
+	}
+}
+
+// record appends a delivery attempt to the delivery log.
+func (d *Dispatcher) record(s *sink, e events.Event, attempt int, status string, err error) {
+	entry := Delivery{
+		Time:    time.Now().UTC(),
+		Sink:    s.name,
+		Seq:     e.Seq,
+		Event:   e.Type,
+		Attempt: attempt,
+		Status:  status,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	data, jerr := json.Marshal(entry)
+	if jerr != nil {
+		return
+	}
+
+	d.logMu.Lock()
+	defer d.logMu.Unlock()
+	path := LogPath(d.townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	f, ferr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: delivery log holds no secrets
+	if ferr != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(data, '\n'))
+}
+
+// ReadLog returns the delivery log's entries, oldest first.
+func ReadLog(townRoot string) ([]Delivery, error) {
+	data, err := os.ReadFile(LogPath(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading delivery log: %w", err)
+	}
+	var out []Delivery
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var entry Delivery
+		if json.Unmarshal(line, &entry) == nil {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
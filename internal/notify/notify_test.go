@@ -0,0 +1,386 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+func crashEvent() events.Event {
+	return events.Event{
+		Seq:        42,
+		Timestamp:  "2026-01-08T12:00:00Z",
+		Source:     "gt",
+		Type:       events.TypePolecatCrashed,
+		Actor:      "daemon",
+		Payload:    events.CrashPayload("gastown", "slit", "gt-123", "no worktree"),
+		Visibility: events.VisibilityFeed,
+	}
+}
+
+// waitForLog waits until the delivery log has n entries.
+func waitForLog(t *testing.T, town string, n int) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		log, err := ReadLog(town)
+		if err != nil {
+			t.Fatalf("ReadLog: %v", err)
+		}
+		if len(log) >= n {
+			return log
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery log has %d entries %+v, want %d", len(log), log, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func startDispatcher(t *testing.T, town string, sinks ...*config.NotificationSink) *Dispatcher {
+	t.Helper()
+	d, err := NewDispatcher(town, sinks)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(d.Stop)
+	return d
+}
+
+func TestWebhookSignsDelivery(t *testing.T) {
+	t.Setenv("GT_TEST_HOOK_SECRET", "s3cret")
+	got := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		got <- r
+	}))
+	defer srv.Close()
+
+	town := t.TempDir()
+	d := startDispatcher(t, town, &config.NotificationSink{
+		Type: config.SinkWebhook, URL: srv.URL, SecretEnv: "GT_TEST_HOOK_SECRET",
+	})
+	d.Dispatch(crashEvent())
+
+	r := <-got
+	if r.Header.Get("X-Gastown-Event") != events.TypePolecatCrashed || r.Header.Get("X-Gastown-Delivery") != "42" {
+		t.Errorf("headers = %v", r.Header)
+	}
+	if sig := r.Header.Get("X-Gastown-Signature"); sig != Sign("s3cret", body) {
+		t.Errorf("signature = %q, want %q", sig, Sign("s3cret", body))
+	}
+	var e events.Event
+	if err := json.Unmarshal(body, &e); err != nil || e.Seq != 42 {
+		t.Errorf("body = %s (%v), want the event", body, err)
+	}
+
+	log := waitForLog(t, town, 1)
+	if log[0].Status != StatusDelivered || log[0].Sink != "webhook" || log[0].Seq != 42 {
+		t.Errorf("log = %+v", log[0])
+	}
+}
+
+func TestWebhookMissingSecret(t *testing.T) {
+	_, err := NewDispatcher(t.TempDir(), []*config.NotificationSink{
+		{Type: config.SinkWebhook, URL: "http://localhost", SecretEnv: "GT_TEST_UNSET_SECRET"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "GT_TEST_UNSET_SECRET") {
+		t.Errorf("err = %v, want the unset variable named", err)
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	got := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		got <- msg
+	}))
+	defer srv.Close()
+
+	d := startDispatcher(t, t.TempDir(), &config.NotificationSink{Type: config.SinkSlack, URL: srv.URL})
+	d.Dispatch(crashEvent())
+
+	msg := <-got
+	want := "*[HIGH] polecat_crashed* Polecat gastown/slit crashed; restart failed"
+	if msg["text"] != want {
+		t.Errorf("text = %q, want %q", msg["text"], want)
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	oldBase := retryBase
+	retryBase = time.Millisecond
+	defer func() { retryBase = oldBase }()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	town := t.TempDir()
+	d := startDispatcher(t, town, &config.NotificationSink{Name: "ops", Type: config.SinkWebhook, URL: srv.URL})
+	d.Dispatch(crashEvent())
+
+	log := waitForLog(t, town, 3)
+	var statuses []string
+	for _, entry := range log {
+		statuses = append(statuses, entry.Status)
+	}
+	if strings.Join(statuses, ",") != "retrying,retrying,delivered" || log[2].Attempt != 3 {
+		t.Errorf("log = %+v", log)
+	}
+}
+
+func TestClientErrorIsPermanent(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	town := t.TempDir()
+	d := startDispatcher(t, town, &config.NotificationSink{Type: config.SinkSlack, URL: srv.URL})
+	d.Dispatch(crashEvent())
+
+	log := waitForLog(t, town, 1)
+	if log[0].Status != StatusFailed || !strings.Contains(log[0].Error, "404") || calls.Load() != 1 {
+		t.Errorf("log = %+v after %d calls, want one permanent failure", log, calls.Load())
+	}
+}
+
+func TestSinkSelection(t *testing.T) {
+	d, err := NewDispatcher(t.TempDir(), []*config.NotificationSink{
+		{Type: config.SinkCommand, Command: "true"},
+		{Type: config.SinkCommand, Command: "true", Events: []string{"merge*"}},
+		{Type: config.SinkCommand, Command: "true", MinSeverity: "high"},
+		{Type: config.SinkCommand, Command: "true", Events: []string{"*"}, Filter: "rig=beads"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	escalation := events.Event{Type: events.TypeEscalationSent, Actor: "gastown/witness",
+		Payload: map[string]interface{}{"severity": events.SeverityCritical}}
+	tests := []struct {
+		event events.Event
+		want  []bool
+	}{
+		{crashEvent(), []bool{true, false, true, false}},
+		{events.Event{Type: events.TypeMergeFailed, Actor: "beads/refinery"}, []bool{true, true, false, true}},
+		{events.Event{Type: events.TypeSling, Actor: "mayor"}, []bool{false, false, false, false}},
+		{escalation, []bool{true, false, true, false}},
+	}
+	for _, tc := range tests {
+		for i, s := range d.sinks {
+			if got := s.accepts(tc.event); got != tc.want[i] {
+				t.Errorf("sink %d accepts %s = %v, want %v", i, tc.event.Type, got, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestInvalidSinks(t *testing.T) {
+	for _, cfg := range []*config.NotificationSink{
+		{Type: "pager"},
+		{Type: config.SinkWebhook},
+		{Type: config.SinkEmail, SMTP: &config.SMTPConfig{Host: "localhost"}},
+		{Type: config.SinkCommand, Command: "true", MinSeverity: "urgent"},
+		{Type: config.SinkCommand, Command: "true", Filter: "colour=red"},
+	} {
+		if _, err := NewDispatcher(t.TempDir(), []*config.NotificationSink{cfg}); err == nil {
+			t.Errorf("NewDispatcher(%+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	town := t.TempDir()
+	out := filepath.Join(town, "out")
+	d := startDispatcher(t, town, &config.NotificationSink{
+		Type:    config.SinkCommand,
+		Command: `printf '%s %s ' "$GT_EVENT_TYPE" "$GT_EVENT_SEVERITY" > ` + out + ` && cat >> ` + out,
+	})
+	d.Dispatch(crashEvent())
+
+	waitForLog(t, town, 1)
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "polecat_crashed HIGH {") || !strings.Contains(string(data), `"seq":42`) {
+		t.Errorf("command saw %q", data)
+	}
+}
+
+func TestEmailSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	messages := make(chan string, 1)
+	go serveSMTP(ln, messages)
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+
+	town := t.TempDir()
+	d := startDispatcher(t, town, &config.NotificationSink{
+		Type: config.SinkEmail,
+		SMTP: &config.SMTPConfig{Host: host, Port: portNum},
+		From: "gt@example.com",
+		To:   []string{"ops@example.com"},
+	})
+	d.Dispatch(crashEvent())
+
+	select {
+	case msg := <-messages:
+		if !strings.Contains(msg, "Subject: [gt] [HIGH] polecat_crashed: Polecat gastown/slit crashed") {
+			t.Errorf("message = %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+	if log := waitForLog(t, town, 1); log[0].Status != StatusDelivered {
+		t.Errorf("log = %+v", log[0])
+	}
+}
+
+func TestEmailMessageMultiLineReason(t *testing.T) {
+	m := &emailSender{from: "gt@example.com", to: []string{"ops@example.com"}}
+	e := events.Event{
+		Seq:       7,
+		Timestamp: "2026-01-08T12:00:00Z",
+		Type:      events.TypeMergeFailed,
+		Actor:     "gastown/refinery",
+		Payload:   events.MergePayload("mr-1", "nux", "polecat/nux", "tests failed:\r\nBcc: attacker@example.com\n\nFAIL pkg/foo — ünïcode"),
+	}
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(m.message(&e)))
+	if err != nil {
+		t.Fatalf("parsing message: %v", err)
+	}
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("injected Bcc header: %q", bcc)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decoding subject: %v", err)
+	}
+	want := "[gt] [MEDIUM] merge_failed: Merge failed: tests failed: Bcc: attacker@example.com FAIL pkg/foo — ünïcode"
+	if subject != want {
+		t.Errorf("subject = %q, want %q", subject, want)
+	}
+}
+
+func TestEmailSinkStalledServerDoesNotBlockStop(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		// Accept and never greet.
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	d := startDispatcher(t, t.TempDir(), &config.NotificationSink{
+		Type: config.SinkEmail,
+		SMTP: &config.SMTPConfig{Host: host, Port: portNum},
+		From: "gt@example.com",
+		To:   []string{"ops@example.com"},
+	})
+	d.Dispatch(crashEvent())
+
+	select {
+	case conn := <-accepted:
+		defer conn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("sink never connected")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop blocked on a stalled SMTP server")
+	}
+}
+
+// serveSMTP is a minimal SMTP server that accepts one message.
+func serveSMTP(ln net.Listener, messages chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+
+	reply("220 localhost ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			messages <- msg.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/feed"
+)
+
+// Per-attempt time limits.
+const (
+	httpTimeout    = 10 * time.Second
+	smtpTimeout    = 30 * time.Second
+	commandTimeout = 30 * time.Second
+)
+
+// headline is the one-line description of an event used by chat and email
+// sinks, e.g. "[HIGH] polecat_crashed: Polecat gastown/slit crashed ...".
+func headline(e *events.Event) string {
+	return fmt.Sprintf("[%s] %s: %s", e.Severity(), e.Type, feed.Summarize(e))
+}
+
+// secretFromEnv reads a secret named by a sink's configuration.
+func secretFromEnv(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	secret := os.Getenv(name)
+	if secret == "" {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return secret, nil
+}
+
+// webhookSender POSTs the event as JSON. With a secret, the body is signed
+// with HMAC-SHA256 in the X-Gastown-Signature header ("sha256=<hex>").
+type webhookSender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func newWebhookSender(cfg *config.NotificationSink) (*webhookSender, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webhook sink requires url")
+	}
+	secret, err := secretFromEnv(cfg.SecretEnv)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookSender{url: cfg.URL, secret: secret, client: &http.Client{Timeout: httpTimeout}}, nil
+}
+
+func (w *webhookSender) send(ctx context.Context, e *events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return &permanentError{fmt.Errorf("encoding event: %w", err)}
+	}
+	headers := map[string]string{
+		"X-Gastown-Event":    e.Type,
+		"X-Gastown-Delivery": strconv.FormatInt(e.Seq, 10),
+	}
+	if w.secret != "" {
+		headers["X-Gastown-Signature"] = Sign(w.secret, body)
+	}
+	return postJSON(ctx, w.client, w.url, body, headers)
+}
+
+// Sign returns the X-Gastown-Signature value for a webhook body, so
+// receivers can verify deliveries.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// slackSender posts a Slack-compatible incoming webhook message.
+type slackSender struct {
+	url    string
+	client *http.Client
+}
+
+func newSlackSender(cfg *config.NotificationSink) (*slackSender, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("slack sink requires url")
+	}
+	return &slackSender{url: cfg.URL, client: &http.Client{Timeout: httpTimeout}}, nil
+}
+
+func (s *slackSender) send(ctx context.Context, e *events.Event) error {
+	text := fmt.Sprintf("*[%s] %s* %s", e.Severity(), e.Type, feed.Summarize(e))
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return &permanentError{fmt.Errorf("encoding message: %w", err)}
+	}
+	return postJSON(ctx, s.client, s.url, body, nil)
+}
+
+// postJSON POSTs body and classifies the response: 2xx succeeds, 429 and
+// 5xx are retried, other statuses fail permanently.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{fmt.Errorf("building request: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gastown-notify")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s responded %s", req.URL.Host, resp.Status)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return &permanentError{err}
+}
+
+// emailSender mails a plain-text message through an SMTP server.
+type emailSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func newEmailSender(cfg *config.NotificationSink) (*emailSender, error) {
+	if cfg.SMTP == nil || cfg.SMTP.Host == "" {
+		return nil, errors.New("email sink requires smtp.host")
+	}
+	if cfg.From == "" || len(cfg.To) == 0 {
+		return nil, errors.New("email sink requires from and to")
+	}
+	port := cfg.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	s := &emailSender{
+		addr: net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(port)),
+		from: cfg.From,
+		to:   cfg.To,
+	}
+	if cfg.SMTP.Username != "" {
+		password, err := secretFromEnv(cfg.SMTP.PasswordEnv)
+		if err != nil {
+			return nil, err
+		}
+		s.auth = smtp.PlainAuth("", cfg.SMTP.Username, password, cfg.SMTP.Host)
+	}
+	return s, nil
+}
+
+func (m *emailSender) send(ctx context.Context, e *events.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	if err := m.sendMail(ctx, m.message(e)); err != nil {
+		return fmt.Errorf("sending mail via %s: %w", m.addr, err)
+	}
+	return nil
+}
+
+// sendMail is smtp.SendMail bounded by ctx: the connection has ctx's
+// deadline and is closed when ctx is canceled, so a stalled server can't
+// block the sink (or Dispatcher.Stop).
+func (m *emailSender) sendMail(ctx context.Context, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(m.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	for _, to := range m.to {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message renders e as an RFC 5322 message.
+func (m *emailSender) message(e *events.Event) []byte {
+	payload, _ := json.MarshalIndent(e.Payload, "", "  ")
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", headerValue(m.from))
+	fmt.Fprintf(&msg, "To: %s\r\n", headerValue(strings.Join(m.to, ", ")))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue("[gt] "+headline(e))))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n", feed.Summarize(e))
+	fmt.Fprintf(&msg, "Type:     %s\r\nSeverity: %s\r\nActor:    %s\r\nTime:     %s\r\nSeq:      %d\r\n\r\n",
+		e.Type, e.Severity(), e.Actor, e.Timestamp, e.Seq)
+	msg.WriteString(strings.ReplaceAll(string(payload), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
+
+// headerValue folds s onto one line so it can't end the header or inject
+// another one: each run of whitespace containing a CR or LF becomes a
+// single space.
+func headerValue(s string) string {
+	var lines []string
+	for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+// commandSender runs a local shell command per event, with the event as
+// JSON on stdin and its main fields in GT_EVENT_* variables.
+type commandSender struct {
+	command string
+}
+
+func newCommandSender(cfg *config.NotificationSink) (*commandSender, error) {
+	if cfg.Command == "" {
+		return nil, errors.New("command sink requires command")
+	}
+	return &commandSender{command: cfg.Command}, nil
+}
+
+func (c *commandSender) send(ctx context.Context, e *events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return &permanentError{fmt.Errorf("encoding event: %w", err)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.command) //nolint:gosec // G204: command comes from town settings
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"GT_EVENT_TYPE="+e.Type,
+		"GT_EVENT_ACTOR="+e.Actor,
+		"GT_EVENT_SEQ="+strconv.FormatInt(e.Seq, 10),
+		"GT_EVENT_TIME="+e.Timestamp,
+		"GT_EVENT_SEVERITY="+e.Severity(),
+		"GT_EVENT_SUMMARY="+feed.Summarize(e),
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
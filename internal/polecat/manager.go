@@ -3,16 +3,16 @@ package polecat
 import (
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -44,10 +44,26 @@ type Manager struct {
 	git      *git.Git
 	beads    *beads.Beads
 	namePool *NamePool
+	conn     connection.Connection
+	tmux     *tmux.Tmux
 }
 
 // NewManager creates a new polecat manager.
 func NewManager(r *rig.Rig, g *git.Git) *Manager {
+	return newManager(r, g, connection.NewLocalConnection())
+}
+
+// NewManagerWithConnection creates a polecat manager for a rig on the
+// machine behind conn. All filesystem, git, bd and tmux operations run
+// through conn, so the same Manager API works for local and remote rigs.
+func NewManagerWithConnection(r *rig.Rig, conn connection.Connection) *Manager {
+	if conn.IsLocal() {
+		return newManager(r, git.NewGit(r.Path), conn)
+	}
+	return newManager(r, git.NewGitWithRunner(conn, "", r.Path), conn)
+}
+
+func newManager(r *rig.Rig, g *git.Git, conn connection.Connection) *Manager {
 	// Always use mayor/rig as the beads path.
 	// This matches routes.jsonl which maps prefixes to <rig>/mayor/rig.
 	// The rig root .beads/ only contains config.yaml (no database),
@@ -59,7 +75,11 @@ func NewManager(r *rig.Rig, g *git.Git) *Manager {
 	settingsPath := filepath.Join(r.Path, "settings", "config.json")
 	var pool *NamePool
 
-	settings, err := config.LoadRigSettings(settingsPath)
+	var settings *config.RigSettings
+	data, err := conn.ReadFile(settingsPath)
+	if err == nil {
+		settings, err = config.ParseRigSettings(data)
+	}
 	if err == nil && settings.Namepool != nil {
 		// Use configured namepool settings
 		pool = NewNamePoolWithConfig(
@@ -73,14 +93,38 @@ func NewManager(r *rig.Rig, g *git.Git) *Manager {
 		// Use defaults
 		pool = NewNamePool(r.Path, r.Name)
 	}
+	if !conn.IsLocal() {
+		pool.conn = conn
+	}
 	_ = pool.Load() // non-fatal: state file may not exist for new rigs
 
-	return &Manager{
+	m := &Manager{
 		rig:      r,
 		git:      g,
-		beads:    beads.New(beadsPath),
 		namePool: pool,
+		conn:     conn,
 	}
+	if conn.IsLocal() {
+		m.beads = beads.New(beadsPath)
+		m.tmux = tmux.NewTmux()
+	} else {
+		m.beads = beads.NewWithRunner(conn, beadsPath)
+		m.tmux = tmux.NewTmuxWithRunner(conn)
+	}
+	return m
+}
+
+// gitFor returns a Git wrapper for a working directory on the rig's machine.
+func (m *Manager) gitFor(workDir string) *git.Git {
+	if m.conn.IsLocal() {
+		return git.NewGit(workDir)
+	}
+	return git.NewGitWithRunner(m.conn, "", workDir)
+}
+
+// rigConfig loads the rig's config.json from the rig's machine.
+func (m *Manager) rigConfig() (*rig.RigConfig, error) {
+	return rig.LoadRigConfigFrom(m.conn, m.rig.Path)
 }
 
 // assigneeID returns the beads assignee identifier for a polecat.
@@ -93,6 +137,15 @@ func (m *Manager) assigneeID(name string) string {
 // Format: "<prefix>-<rig>-polecat-<name>" (e.g., "gt-gastown-polecat-Toast", "bd-beads-polecat-obsidian")
 // The prefix is looked up from routes.jsonl to support rigs with custom prefixes.
 func (m *Manager) agentBeadID(name string) string {
+	// Remote rigs: routes.jsonl lives on the other machine, so use the
+	// prefix recorded in the rig registry entry.
+	if !m.conn.IsLocal() {
+		if m.rig.Config != nil && m.rig.Config.Prefix != "" {
+			return beads.PolecatBeadIDWithPrefix(m.rig.Config.Prefix, m.rig.Name, name)
+		}
+		return beads.PolecatBeadID(m.rig.Name, name)
+	}
+
 	// Find town root to lookup prefix from routes.jsonl
 	townRoot, err := workspace.Find(m.rig.Path)
 	if err != nil || townRoot == "" {
@@ -165,17 +218,20 @@ func (m *Manager) checkCleanupStatus(name string, status CleanupStatus, force bo
 func (m *Manager) repoBase() (*git.Git, error) {
 	// First check for shared bare repo (new architecture)
 	bareRepoPath := filepath.Join(m.rig.Path, ".repo.git")
-	if info, err := os.Stat(bareRepoPath); err == nil && info.IsDir() {
+	if info, err := m.conn.Stat(bareRepoPath); err == nil && info.IsDir() {
 		// Bare repo exists - use it
-		return git.NewGitWithDir(bareRepoPath, ""), nil
+		if m.conn.IsLocal() {
+			return git.NewGitWithDir(bareRepoPath, ""), nil
+		}
+		return git.NewGitWithRunner(m.conn, bareRepoPath, ""), nil
 	}
 
 	// Fall back to mayor/rig (legacy architecture)
 	mayorPath := filepath.Join(m.rig.Path, "mayor", "rig")
-	if _, err := os.Stat(mayorPath); os.IsNotExist(err) {
+	if ok, _ := m.conn.Exists(mayorPath); !ok {
 		return nil, fmt.Errorf("no repo base found (neither .repo.git nor mayor/rig exists)")
 	}
-	return git.NewGit(mayorPath), nil
+	return m.gitFor(mayorPath), nil
 }
 
 // polecatDir returns the directory for a polecat.
@@ -185,8 +241,8 @@ func (m *Manager) polecatDir(name string) string {
 
 // exists checks if a polecat exists.
 func (m *Manager) exists(name string) bool {
-	_, err := os.Stat(m.polecatDir(name))
-	return err == nil
+	ok, _ := m.conn.Exists(m.polecatDir(name))
+	return ok
 }
 
 // AddOptions configures polecat creation.
@@ -221,7 +277,7 @@ func (m *Manager) AddWithOptions(name string, opts AddOptions) (*Polecat, error)
 
 	// Create polecats directory if needed
 	polecatsDir := filepath.Join(m.rig.Path, "polecats")
-	if err := os.MkdirAll(polecatsDir, 0755); err != nil {
+	if err := m.conn.MkdirAll(polecatsDir, 0755); err != nil {
 		return nil, fmt.Errorf("creating polecats dir: %w", err)
 	}
 
@@ -318,7 +374,7 @@ func (m *Manager) RemoveWithOptions(name string, force, nuclear bool) error {
 			}
 		} else {
 			// Fallback path: Check git directly (for polecats that haven't reported yet)
-			polecatGit := git.NewGit(polecatPath)
+			polecatGit := m.gitFor(polecatPath)
 			status, err := polecatGit.CheckUncommittedWork()
 			if err == nil && !status.Clean() {
 				// For backward compatibility: force only bypasses uncommitted changes, not stashes/unpushed
@@ -338,14 +394,14 @@ func (m *Manager) RemoveWithOptions(name string, force, nuclear bool) error {
 	repoGit, err := m.repoBase()
 	if err != nil {
 		// Fall back to direct removal if repo base not found
-		return os.RemoveAll(polecatPath)
+		return m.conn.RemoveAll(polecatPath)
 	}
 
 	// Try to remove as a worktree first (use force flag for worktree removal too)
 	if err := repoGit.WorktreeRemove(polecatPath, force); err != nil {
 		// Fall back to direct removal if worktree removal fails
 		// (e.g., if this is an old-style clone, not a worktree)
-		if removeErr := os.RemoveAll(polecatPath); removeErr != nil {
+		if removeErr := m.conn.RemoveAll(polecatPath); removeErr != nil {
 			return fmt.Errorf("removing polecat dir: %w", removeErr)
 		}
 	}
@@ -418,7 +474,7 @@ func (m *Manager) RepairWorktreeWithOptions(name string, force bool, opts AddOpt
 	}
 
 	polecatPath := m.polecatDir(name)
-	polecatGit := git.NewGit(polecatPath)
+	polecatGit := m.gitFor(polecatPath)
 
 	// Get the repo base (bare repo or mayor/rig)
 	repoGit, err := m.repoBase()
@@ -445,7 +501,7 @@ func (m *Manager) RepairWorktreeWithOptions(name string, force bool, opts AddOpt
 	// Remove the worktree (use force for git worktree removal)
 	if err := repoGit.WorktreeRemove(polecatPath, true); err != nil {
 		// Fall back to direct removal
-		if removeErr := os.RemoveAll(polecatPath); removeErr != nil {
+		if removeErr := m.conn.RemoveAll(polecatPath); removeErr != nil {
 			return nil, fmt.Errorf("removing polecat dir: %w", removeErr)
 		}
 	}
@@ -459,7 +515,7 @@ func (m *Manager) RepairWorktreeWithOptions(name string, force bool, opts AddOpt
 	// Determine the start point for the new worktree
 	// Use origin/<default-branch> to ensure we start from latest fetched commits
 	defaultBranch := "main"
-	if rigCfg, err := rig.LoadRigConfig(m.rig.Path); err == nil && rigCfg.DefaultBranch != "" {
+	if rigCfg, err := m.rigConfig(); err == nil && rigCfg.DefaultBranch != "" {
 		defaultBranch = rigCfg.DefaultBranch
 	}
 	startPoint := fmt.Sprintf("origin/%s", defaultBranch)
@@ -535,21 +591,14 @@ func (m *Manager) PoolStatus() (active int, names []string) {
 func (m *Manager) List() ([]*Polecat, error) {
 	polecatsDir := filepath.Join(m.rig.Path, "polecats")
 
-	entries, err := os.ReadDir(polecatsDir)
+	names, err := connection.ListDirs(m.conn, polecatsDir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, fmt.Errorf("reading polecats dir: %w", err)
 	}
 
 	var polecats []*Polecat
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		polecat, err := m.Get(entry.Name())
+	for _, name := range names {
+		polecat, err := m.Get(name)
 		if err != nil {
 			continue // Skip invalid polecats
 		}
@@ -678,7 +727,7 @@ func (m *Manager) loadFromBeads(name string) (*Polecat, error) {
 	polecatPath := m.polecatDir(name)
 
 	// Get actual branch from worktree (branches are now timestamped)
-	polecatGit := git.NewGit(polecatPath)
+	polecatGit := m.gitFor(polecatPath)
 	branchName, err := polecatGit.CurrentBranch()
 	if err != nil {
 		// Fall back to old format if we can't read the branch
@@ -747,7 +796,7 @@ func (m *Manager) setupSharedBeads(polecatPath string) error {
 	var sharedBeadsPath string
 	var redirectContent string
 
-	if _, err := os.Stat(mayorRigBeads); err == nil {
+	if ok, _ := m.conn.Exists(mayorRigBeads); ok {
 		// Source repo has .beads/ tracked - use mayor/rig/.beads
 		sharedBeadsPath = mayorRigBeads
 		redirectContent = "../../mayor/rig/.beads\n"
@@ -756,13 +805,13 @@ func (m *Manager) setupSharedBeads(polecatPath string) error {
 		sharedBeadsPath = rigRootBeads
 		redirectContent = "../../.beads\n"
 		// Ensure rig root has .beads/ directory
-		if err := os.MkdirAll(rigRootBeads, 0755); err != nil {
+		if err := m.conn.MkdirAll(rigRootBeads, 0755); err != nil {
 			return fmt.Errorf("creating rig .beads dir: %w", err)
 		}
 	}
 
 	// Verify shared beads exists
-	if _, err := os.Stat(sharedBeadsPath); os.IsNotExist(err) {
+	if ok, _ := m.conn.Exists(sharedBeadsPath); !ok {
 		return fmt.Errorf("no shared beads database found at %s", sharedBeadsPath)
 	}
 
@@ -770,21 +819,21 @@ func (m *Manager) setupSharedBeads(polecatPath string) error {
 	// This handles the case where the polecat was created from a branch that
 	// had .beads/ tracked (e.g., from previous bd sync operations)
 	polecatBeadsDir := filepath.Join(polecatPath, ".beads")
-	if _, err := os.Stat(polecatBeadsDir); err == nil {
+	if ok, _ := m.conn.Exists(polecatBeadsDir); ok {
 		// Directory exists - remove it entirely and recreate fresh
-		if err := os.RemoveAll(polecatBeadsDir); err != nil {
+		if err := m.conn.RemoveAll(polecatBeadsDir); err != nil {
 			return fmt.Errorf("cleaning existing .beads dir: %w", err)
 		}
 	}
 
 	// Create fresh .beads directory
-	if err := os.MkdirAll(polecatBeadsDir, 0755); err != nil {
+	if err := m.conn.MkdirAll(polecatBeadsDir, 0755); err != nil {
 		return fmt.Errorf("creating polecat .beads dir: %w", err)
 	}
 
 	// Create redirect file pointing to the shared beads location
 	redirectPath := filepath.Join(polecatBeadsDir, "redirect")
-	if err := os.WriteFile(redirectPath, []byte(redirectContent), 0644); err != nil {
+	if err := m.conn.WriteFile(redirectPath, []byte(redirectContent), 0644); err != nil {
 		return fmt.Errorf("creating redirect file: %w", err)
 	}
 
@@ -872,7 +921,7 @@ func (m *Manager) DetectStalePolecats(threshold int) ([]*StalenessInfo, error) {
 
 	// Get default branch from rig config
 	defaultBranch := "main"
-	if rigCfg, err := rig.LoadRigConfig(m.rig.Path); err == nil && rigCfg.DefaultBranch != "" {
+	if rigCfg, err := m.rigConfig(); err == nil && rigCfg.DefaultBranch != "" {
 		defaultBranch = rigCfg.DefaultBranch
 	}
 
@@ -885,10 +934,10 @@ func (m *Manager) DetectStalePolecats(threshold int) ([]*StalenessInfo, error) {
 		// Check for active tmux session
 		// Session name follows pattern: gt-<rig>-<polecat>
 		sessionName := fmt.Sprintf("gt-%s-%s", m.rig.Name, p.Name)
-		info.HasActiveSession = checkTmuxSession(sessionName)
+		info.HasActiveSession, _ = m.tmux.HasSession(sessionName)
 
 		// Check how far behind main
-		polecatGit := git.NewGit(p.ClonePath)
+		polecatGit := m.gitFor(p.ClonePath)
 		info.CommitsBehind = countCommitsBehind(polecatGit, defaultBranch)
 
 		// Check for uncommitted work
@@ -912,13 +961,6 @@ func (m *Manager) DetectStalePolecats(threshold int) ([]*StalenessInfo, error) {
 	return results, nil
 }
 
-// checkTmuxSession checks if a tmux session exists.
-func checkTmuxSession(sessionName string) bool {
-	// Use has-session command which returns 0 if session exists
-	cmd := exec.Command("tmux", "has-session", "-t", sessionName) //nolint:gosec // G204: sessionName is constructed internally
-	return cmd.Run() == nil
-}
-
 // countCommitsBehind counts how many commits a worktree is behind origin/<defaultBranch>.
 func countCommitsBehind(g *git.Git, defaultBranch string) int {
 	// Use rev-list to count commits: origin/main..HEAD shows commits ahead,
@@ -1,10 +1,12 @@
 package polecat
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
 )
@@ -268,3 +270,45 @@ func TestClearIssueWithoutAssignment(t *testing.T) {
 // We no longer write CLAUDE.md to worktrees - Gas Town context is injected
 // ephemerally via SessionStart hook (gt prime) to prevent leaking internal
 // architecture into project repos.
+
+func TestManagerWithConnection(t *testing.T) {
+	conn := connection.NewFakeConnection("vm")
+	for _, name := range []string{"Toast", "Cheedo"} {
+		if err := conn.MkdirAll("/town/gastown/polecats/"+name, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	conn.ExecHandler = func(c connection.FakeCommand) ([]byte, []byte, error) {
+		if c.Cmd == "git" && len(c.Args) > 0 && c.Args[0] == "rev-parse" {
+			return []byte("polecat/" + filepath.Base(c.Dir) + "-abc\n"), nil, nil
+		}
+		return nil, []byte("bd: not available"), errors.New("exit status 1")
+	}
+
+	r := &rig.Rig{Name: "gastown", Path: "/town/gastown"}
+	m := NewManagerWithConnection(r, conn)
+
+	polecats, err := m.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(polecats) != 2 {
+		t.Fatalf("polecats count = %d, want 2", len(polecats))
+	}
+	for _, p := range polecats {
+		if want := "polecat/" + p.Name + "-abc"; p.Branch != want {
+			t.Errorf("%s: Branch = %q, want %q (read via remote git)", p.Name, p.Branch, want)
+		}
+	}
+
+	name, err := m.AllocateName()
+	if err != nil {
+		t.Fatalf("AllocateName: %v", err)
+	}
+	if name == "Toast" || name == "Cheedo" {
+		t.Errorf("AllocateName returned in-use name %q", name)
+	}
+	if ok, _ := conn.Exists("/town/gastown/.runtime/namepool-state.json"); !ok {
+		t.Error("name pool state not written to remote machine")
+	}
+}
@@ -2,12 +2,14 @@ package polecat
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
 
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/util"
 )
 
@@ -87,6 +89,10 @@ type NamePool struct {
 
 	// stateFile is the path to persist pool state.
 	stateFile string
+
+	// conn, when set, reads and writes stateFile on a remote machine.
+	// nil means the local filesystem.
+	conn connection.Connection
 }
 
 // NewNamePool creates a new name pool for a rig.
@@ -142,9 +148,9 @@ func (p *NamePool) Load() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	data, err := os.ReadFile(p.stateFile)
+	data, err := p.readState()
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) || isNotFound(err) {
 			// Initialize with empty state
 			p.InUse = make(map[string]bool)
 			p.OverflowNext = p.MaxSize + 1
@@ -183,6 +189,17 @@ func (p *NamePool) Save() error {
 	defer p.mu.RUnlock()
 
 	dir := filepath.Dir(p.stateFile)
+	if p.conn != nil {
+		if err := p.conn.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return err
+		}
+		return p.conn.WriteFile(p.stateFile, data, 0644)
+	}
+
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
@@ -190,6 +207,20 @@ func (p *NamePool) Save() error {
 	return util.AtomicWriteJSON(p.stateFile, p)
 }
 
+// readState reads the raw state file from disk or the remote machine.
+func (p *NamePool) readState() ([]byte, error) {
+	if p.conn != nil {
+		return p.conn.ReadFile(p.stateFile)
+	}
+	return os.ReadFile(p.stateFile)
+}
+
+// isNotFound reports whether err is a connection-level "not found".
+func isNotFound(err error) bool {
+	var nf *connection.NotFoundError
+	return errors.As(err, &nf)
+}
+
 // Allocate returns a name from the pool.
 // It prefers names in order from the theme list, and falls back to overflow names
 // when the pool is exhausted.
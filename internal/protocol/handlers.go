@@ -29,8 +29,11 @@ func (r *HandlerRegistry) Register(msgType MessageType, handler Handler) {
 // Handle dispatches a message to the appropriate handler.
 // Returns an error if no handler is registered for the message type.
 func (r *HandlerRegistry) Handle(msg *mail.Message) error {
-	msgType := ParseMessageType(msg.Subject)
+	msgType := MessageTypeOf(msg)
 	if msgType == "" {
+		if msg.Envelope != nil {
+			return fmt.Errorf("unknown protocol: %s", msg.Envelope.Protocol)
+		}
 		return fmt.Errorf("unknown message type for subject: %s", msg.Subject)
 	}
 
@@ -44,7 +47,7 @@ func (r *HandlerRegistry) Handle(msg *mail.Message) error {
 
 // CanHandle returns true if a handler is registered for the message's type.
 func (r *HandlerRegistry) CanHandle(msg *mail.Message) bool {
-	msgType := ParseMessageType(msg.Subject)
+	msgType := MessageTypeOf(msg)
 	if msgType == "" {
 		return false
 	}
@@ -78,17 +81,26 @@ func WrapWitnessHandlers(h WitnessHandler) *HandlerRegistry {
 	registry := NewHandlerRegistry()
 
 	registry.Register(TypeMerged, func(msg *mail.Message) error {
-		payload := ParseMergedPayload(msg.Body)
+		payload, err := DecodeMerged(msg)
+		if err != nil {
+			return err
+		}
 		return h.HandleMerged(payload)
 	})
 
 	registry.Register(TypeMergeFailed, func(msg *mail.Message) error {
-		payload := ParseMergeFailedPayload(msg.Body)
+		payload, err := DecodeMergeFailed(msg)
+		if err != nil {
+			return err
+		}
 		return h.HandleMergeFailed(payload)
 	})
 
 	registry.Register(TypeReworkRequest, func(msg *mail.Message) error {
-		payload := ParseReworkRequestPayload(msg.Body)
+		payload, err := DecodeReworkRequest(msg)
+		if err != nil {
+			return err
+		}
 		return h.HandleReworkRequest(payload)
 	})
 
@@ -100,7 +112,10 @@ func WrapRefineryHandlers(h RefineryHandler) *HandlerRegistry {
 	registry := NewHandlerRegistry()
 
 	registry.Register(TypeMergeReady, func(msg *mail.Message) error {
-		payload := ParseMergeReadyPayload(msg.Body)
+		payload, err := DecodeMergeReady(msg)
+		if err != nil {
+			return err
+		}
 		return h.HandleMergeReady(payload)
 	})
 
@@ -111,7 +126,7 @@ func WrapRefineryHandlers(h RefineryHandler) *HandlerRegistry {
 // It returns (true, nil) if the message was handled successfully,
 // (true, error) if handling failed, or (false, nil) if not a protocol message.
 func (r *HandlerRegistry) ProcessProtocolMessage(msg *mail.Message) (bool, error) {
-	if !IsProtocolMessage(msg.Subject) {
+	if MessageTypeOf(msg) == "" {
 		return false, nil
 	}
 
@@ -30,6 +30,7 @@ func NewMergeReadyMessage(rig, polecat, branch, issue string) *mail.Message {
 	)
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeTask
+	attachEnvelope(msg, TypeMergeReady, payload)
 
 	return msg
 }
@@ -70,6 +71,7 @@ func NewMergedMessage(rig, polecat, branch, issue, targetBranch, mergeCommit str
 	)
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeNotification
+	attachEnvelope(msg, TypeMerged, payload)
 
 	return msg
 }
@@ -92,6 +94,12 @@ func formatMergedBody(p MergedPayload) string {
 // NewMergeFailedMessage creates a MERGE_FAILED protocol message.
 // Sent by Refinery to Witness when merge fails (tests, build, etc.).
 func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureType, errorMsg string) *mail.Message {
+	return NewMergeFailedMessageWithTests(rig, polecat, branch, issue, targetBranch, failureType, errorMsg, nil)
+}
+
+// NewMergeFailedMessageWithTests creates a MERGE_FAILED protocol message
+// that also lists the failing tests and their output excerpts.
+func NewMergeFailedMessageWithTests(rig, polecat, branch, issue, targetBranch, failureType, errorMsg string, failedTests []FailedTest) *mail.Message {
 	payload := MergeFailedPayload{
 		Branch:       branch,
 		Issue:        issue,
@@ -101,6 +109,7 @@ func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureTyp
 		FailureType:  failureType,
 		Error:        errorMsg,
 		TargetBranch: targetBranch,
+		FailedTests:  failedTests,
 	}
 
 	body := formatMergeFailedBody(payload)
@@ -113,6 +122,7 @@ func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureTyp
 	)
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeTask
+	attachEnvelope(msg, TypeMergeFailed, payload)
 
 	return msg
 }
@@ -128,9 +138,53 @@ func formatMergeFailedBody(p MergeFailedPayload) string {
 	sb.WriteString(fmt.Sprintf("Failed-At: %s\n", p.FailedAt.Format(time.RFC3339)))
 	sb.WriteString(fmt.Sprintf("Failure-Type: %s\n", p.FailureType))
 	sb.WriteString(fmt.Sprintf("Error: %s\n", p.Error))
+	if len(p.FailedTests) > 0 {
+		sb.WriteString(fmt.Sprintf("Failed-Tests: %d\n", len(p.FailedTests)))
+		for _, ft := range p.FailedTests {
+			sb.WriteString(fmt.Sprintf("%s%s %s\n", failedTestPrefix, ft.Package, ft.Name))
+			for _, line := range strings.Split(ft.Excerpt, "\n") {
+				if line != "" {
+					sb.WriteString(excerptIndent + line + "\n")
+				}
+			}
+		}
+	}
 	return sb.String()
 }
 
+// Failed tests in a MERGE_FAILED body are listed one per
+// "--- FAIL: <package> <name>" line, each followed by its output excerpt
+// indented by excerptIndent.
+const (
+	failedTestPrefix = "--- FAIL: "
+	excerptIndent    = "    "
+)
+
+// parseFailedTests extracts the failed test list from a MERGE_FAILED body.
+func parseFailedTests(body string) []FailedTest {
+	var tests []FailedTest
+	var excerpt []string
+	flush := func() {
+		if len(tests) > 0 {
+			tests[len(tests)-1].Excerpt = strings.Join(excerpt, "\n")
+		}
+		excerpt = nil
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		switch {
+		case strings.HasPrefix(line, failedTestPrefix):
+			flush()
+			pkg, name, _ := strings.Cut(strings.TrimPrefix(line, failedTestPrefix), " ")
+			tests = append(tests, FailedTest{Package: pkg, Name: name})
+		case len(tests) > 0 && strings.HasPrefix(line, excerptIndent):
+			excerpt = append(excerpt, strings.TrimPrefix(line, excerptIndent))
+		}
+	}
+	flush()
+	return tests
+}
+
 // NewReworkRequestMessage creates a REWORK_REQUEST protocol message.
 // Sent by Refinery to Witness when a branch needs rebasing due to conflicts.
 func NewReworkRequestMessage(rig, polecat, branch, issue, targetBranch string, conflictFiles []string) *mail.Message {
@@ -155,6 +209,7 @@ func NewReworkRequestMessage(rig, polecat, branch, issue, targetBranch string, c
 	)
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeTask
+	attachEnvelope(msg, TypeReworkRequest, payload)
 
 	return msg
 }
@@ -191,7 +246,65 @@ func formatRebaseInstructions(targetBranch string) string {
 The Refinery will retry the merge after rebase is complete.`, targetBranch, targetBranch)
 }
 
-// ParseMergeReadyPayload parses a MERGE_READY message body into a payload.
+// attachEnvelope sets msg's envelope to carry payload. Protocol payloads
+// always encode, so the error is ignored.
+func attachEnvelope(msg *mail.Message, msgType MessageType, payload interface{}) {
+	_ = msg.SetEnvelope(string(msgType), SchemaVersion, payload)
+}
+
+// DecodeMergeReady returns the payload of a MERGE_READY message: decoded
+// from its envelope, or parsed from the body of a legacy message.
+func DecodeMergeReady(msg *mail.Message) (*MergeReadyPayload, error) {
+	if msg.Envelope == nil {
+		return ParseMergeReadyPayload(msg.Body), nil
+	}
+	payload := &MergeReadyPayload{}
+	if err := msg.Envelope.Decode(string(TypeMergeReady), SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeMerged returns the payload of a MERGED message: decoded from its
+// envelope, or parsed from the body of a legacy message.
+func DecodeMerged(msg *mail.Message) (*MergedPayload, error) {
+	if msg.Envelope == nil {
+		return ParseMergedPayload(msg.Body), nil
+	}
+	payload := &MergedPayload{}
+	if err := msg.Envelope.Decode(string(TypeMerged), SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeMergeFailed returns the payload of a MERGE_FAILED message: decoded
+// from its envelope, or parsed from the body of a legacy message.
+func DecodeMergeFailed(msg *mail.Message) (*MergeFailedPayload, error) {
+	if msg.Envelope == nil {
+		return ParseMergeFailedPayload(msg.Body), nil
+	}
+	payload := &MergeFailedPayload{}
+	if err := msg.Envelope.Decode(string(TypeMergeFailed), SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeReworkRequest returns the payload of a REWORK_REQUEST message:
+// decoded from its envelope, or parsed from the body of a legacy message.
+func DecodeReworkRequest(msg *mail.Message) (*ReworkRequestPayload, error) {
+	if msg.Envelope == nil {
+		return ParseReworkRequestPayload(msg.Body), nil
+	}
+	payload := &ReworkRequestPayload{}
+	if err := msg.Envelope.Decode(string(TypeReworkRequest), SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// ParseMergeReadyPayload parses a legacy MERGE_READY message body into a payload.
 func ParseMergeReadyPayload(body string) *MergeReadyPayload {
 	return &MergeReadyPayload{
 		Branch:    parseField(body, "Branch"),
@@ -203,7 +316,7 @@ func ParseMergeReadyPayload(body string) *MergeReadyPayload {
 	}
 }
 
-// ParseMergedPayload parses a MERGED message body into a payload.
+// ParseMergedPayload parses a legacy MERGED message body into a payload.
 func ParseMergedPayload(body string) *MergedPayload {
 	payload := &MergedPayload{
 		Branch:       parseField(body, "Branch"),
@@ -224,7 +337,7 @@ func ParseMergedPayload(body string) *MergedPayload {
 	return payload
 }
 
-// ParseMergeFailedPayload parses a MERGE_FAILED message body into a payload.
+// ParseMergeFailedPayload parses a legacy MERGE_FAILED message body into a payload.
 func ParseMergeFailedPayload(body string) *MergeFailedPayload {
 	payload := &MergeFailedPayload{
 		Branch:       parseField(body, "Branch"),
@@ -234,6 +347,7 @@ func ParseMergeFailedPayload(body string) *MergeFailedPayload {
 		TargetBranch: parseField(body, "Target"),
 		FailureType:  parseField(body, "Failure-Type"),
 		Error:        parseField(body, "Error"),
+		FailedTests:  parseFailedTests(body),
 	}
 
 	// Parse timestamp
@@ -246,7 +360,7 @@ func ParseMergeFailedPayload(body string) *MergeFailedPayload {
 	return payload
 }
 
-// ParseReworkRequestPayload parses a REWORK_REQUEST message body into a payload.
+// ParseReworkRequestPayload parses a legacy REWORK_REQUEST message body into a payload.
 func ParseReworkRequestPayload(body string) *ReworkRequestPayload {
 	payload := &ReworkRequestPayload{
 		Branch:       parseField(body, "Branch"),
@@ -136,6 +136,31 @@ func TestNewMergeFailedMessage(t *testing.T) {
 	}
 }
 
+func TestMergeFailedMessageWithTests_RoundTrip(t *testing.T) {
+	tests := []FailedTest{
+		{Package: "example.com/a", Name: "TestBad", Excerpt: "a_test.go:12: want 1, got 2\nError: not a header"},
+		{Package: "example.com/b", Excerpt: "b.go:3:1: syntax error"},
+	}
+	msg := NewMergeFailedMessageWithTests("gastown", "nux", "polecat/nux", "gt-abc", "main", "tests", "2 tests failed", tests)
+
+	if !strings.Contains(msg.Body, "Failed-Tests: 2") {
+		t.Errorf("Body missing failed test count: %s", msg.Body)
+	}
+
+	payload := ParseMergeFailedPayload(msg.Body)
+	if payload.Error != "2 tests failed" {
+		t.Errorf("Error = %q", payload.Error)
+	}
+	if len(payload.FailedTests) != 2 {
+		t.Fatalf("FailedTests = %+v, want 2", payload.FailedTests)
+	}
+	for i, want := range tests {
+		if payload.FailedTests[i] != want {
+			t.Errorf("FailedTests[%d] = %+v, want %+v", i, payload.FailedTests[i], want)
+		}
+	}
+}
+
 func TestNewReworkRequestMessage(t *testing.T) {
 	conflicts := []string{"file1.go", "file2.go"}
 	msg := NewReworkRequestMessage("gastown", "nux", "polecat/nux/gt-abc", "gt-abc", "main", conflicts)
@@ -268,6 +293,45 @@ func TestWrapWitnessHandlers(t *testing.T) {
 	}
 }
 
+func TestEnvelopeDispatch(t *testing.T) {
+	var got *MergedPayload
+	registry := WrapWitnessHandlers(&recordingWitnessHandler{merged: &got})
+
+	// The envelope decides, whatever the subject says.
+	msg := NewMergedMessage("gastown", "nux", "polecat/nux", "gt-abc", "main", "abc123")
+	msg.Subject = "Your branch landed"
+	if MessageTypeOf(msg) != TypeMerged {
+		t.Fatalf("MessageTypeOf = %q, want MERGED", MessageTypeOf(msg))
+	}
+	if handled, err := registry.ProcessProtocolMessage(msg); !handled || err != nil {
+		t.Fatalf("ProcessProtocolMessage = %v, %v", handled, err)
+	}
+	if got == nil || got.MergeCommit != "abc123" || got.TargetBranch != "main" {
+		t.Errorf("payload = %+v", got)
+	}
+
+	// A subject that looks like protocol doesn't override the envelope.
+	other := &mail.Message{Subject: "MERGED nux", Envelope: &mail.Envelope{Protocol: "SOMETHING_ELSE", Version: 1}}
+	if MessageTypeOf(other) != "" {
+		t.Errorf("MessageTypeOf = %q for an unknown envelope protocol", MessageTypeOf(other))
+	}
+}
+
+func TestEnvelopeValidation(t *testing.T) {
+	registry := WrapWitnessHandlers(&mockWitnessHandler{})
+
+	msg := NewReworkRequestMessage("gastown", "", "polecat/nux", "gt-abc", "main", nil)
+	if err := registry.Handle(msg); err == nil || !strings.Contains(err.Error(), "missing polecat") {
+		t.Errorf("Handle error = %v, want a missing polecat error", err)
+	}
+
+	msg = NewMergeFailedMessage("gastown", "nux", "polecat/nux", "gt-abc", "main", "tests", "boom")
+	msg.Envelope.Version = SchemaVersion + 1
+	if err := registry.Handle(msg); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("Handle error = %v, want an unsupported version error", err)
+	}
+}
+
 func TestWrapRefineryHandlers(t *testing.T) {
 	handler := &mockRefineryHandler{}
 	registry := WrapRefineryHandlers(handler)
@@ -368,6 +432,17 @@ func (m *mockWitnessHandler) HandleReworkRequest(payload *ReworkRequestPayload)
 	return nil
 }
 
+// recordingWitnessHandler keeps the last MERGED payload.
+type recordingWitnessHandler struct {
+	mockWitnessHandler
+	merged **MergedPayload
+}
+
+func (r *recordingWitnessHandler) HandleMerged(payload *MergedPayload) error {
+	*r.merged = payload
+	return nil
+}
+
 type mockRefineryHandler struct {
 	readyCalled bool
 }
@@ -8,13 +8,25 @@
 //   - MERGED: Refinery → Witness (merge succeeded, cleanup ok)
 //   - MERGE_FAILED: Refinery → Witness (merge failed, needs rework)
 //   - REWORK_REQUEST: Refinery → Witness (rebase needed)
+//
+// Each message carries a mail.Envelope naming its type, with the payload as
+// versioned JSON. Handlers dispatch on the envelope; the subject is only for
+// humans. Messages sent before envelopes existed are still recognized by
+// their subject and parsed from their "Key: value" body.
 package protocol
 
 import (
+	"errors"
 	"strings"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/mail"
 )
 
+// SchemaVersion is the payload schema version of the messages this package
+// sends, and the newest it accepts.
+const SchemaVersion = 1
+
 // MessageType identifies the protocol message type.
 type MessageType string
 
@@ -46,14 +58,7 @@ func ParseMessageType(subject string) MessageType {
 	subject = strings.TrimSpace(subject)
 
 	// Check each known prefix
-	prefixes := []MessageType{
-		TypeMergeReady,
-		TypeMerged,
-		TypeMergeFailed,
-		TypeReworkRequest,
-	}
-
-	for _, prefix := range prefixes {
+	for _, prefix := range knownTypes {
 		if strings.HasPrefix(subject, string(prefix)) {
 			return prefix
 		}
@@ -62,6 +67,29 @@ func ParseMessageType(subject string) MessageType {
 	return ""
 }
 
+// knownTypes lists the protocol message types.
+var knownTypes = []MessageType{
+	TypeMergeReady,
+	TypeMerged,
+	TypeMergeFailed,
+	TypeReworkRequest,
+}
+
+// MessageTypeOf returns the protocol message type of msg: the protocol named
+// by its envelope, or for a legacy message without one, the type its subject
+// starts with. Returns empty string if msg is not a protocol message.
+func MessageTypeOf(msg *mail.Message) MessageType {
+	if msg.Envelope == nil {
+		return ParseMessageType(msg.Subject)
+	}
+	for _, t := range knownTypes {
+		if msg.Envelope.Protocol == string(t) {
+			return t
+		}
+	}
+	return ""
+}
+
 // MergeReadyPayload contains the data for a MERGE_READY message.
 // Sent by Witness after verifying polecat work is complete.
 type MergeReadyPayload struct {
@@ -135,6 +163,23 @@ type MergeFailedPayload struct {
 
 	// TargetBranch is the branch we tried to merge into.
 	TargetBranch string `json:"target_branch"`
+
+	// FailedTests lists the failing tests, when the refinery parsed
+	// structured test output.
+	FailedTests []FailedTest `json:"failed_tests,omitempty"`
+}
+
+// FailedTest identifies one failing test in a MERGE_FAILED payload.
+type FailedTest struct {
+	// Package is the test's package (or JUnit classname).
+	Package string `json:"package,omitempty"`
+
+	// Name is the test name; empty for a package-level failure such as a
+	// build error.
+	Name string `json:"name,omitempty"`
+
+	// Excerpt is the tail of the test's output.
+	Excerpt string `json:"excerpt,omitempty"`
 }
 
 // ReworkRequestPayload contains the data for a REWORK_REQUEST message.
@@ -165,6 +210,63 @@ type ReworkRequestPayload struct {
 	Instructions string `json:"instructions,omitempty"`
 }
 
+// errMissingField reports a required payload field that is empty.
+func errMissingField(name string) error {
+	return errors.New("missing " + name)
+}
+
+// validateRouting checks the fields every payload needs to find the polecat
+// and its work.
+func validateRouting(branch, polecat, rig string) error {
+	switch {
+	case branch == "":
+		return errMissingField("branch")
+	case polecat == "":
+		return errMissingField("polecat")
+	case rig == "":
+		return errMissingField("rig")
+	}
+	return nil
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *MergeReadyPayload) Validate() error {
+	return validateRouting(p.Branch, p.Polecat, p.Rig)
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *MergedPayload) Validate() error {
+	if err := validateRouting(p.Branch, p.Polecat, p.Rig); err != nil {
+		return err
+	}
+	if p.TargetBranch == "" {
+		return errMissingField("target_branch")
+	}
+	return nil
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *MergeFailedPayload) Validate() error {
+	if err := validateRouting(p.Branch, p.Polecat, p.Rig); err != nil {
+		return err
+	}
+	if p.FailureType == "" {
+		return errMissingField("failure_type")
+	}
+	return nil
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *ReworkRequestPayload) Validate() error {
+	if err := validateRouting(p.Branch, p.Polecat, p.Rig); err != nil {
+		return err
+	}
+	if p.TargetBranch == "" {
+		return errMissingField("target_branch")
+	}
+	return nil
+}
+
 // IsProtocolMessage returns true if the subject matches a known protocol type.
 func IsProtocolMessage(subject string) bool {
 	return ParseMessageType(subject) != ""
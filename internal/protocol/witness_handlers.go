@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/witness"
@@ -87,6 +88,9 @@ func (h *DefaultWitnessHandler) HandleMergeFailed(payload *MergeFailedPayload) e
 	fmt.Fprintf(h.Output, "  Issue: %s\n", payload.Issue)
 	fmt.Fprintf(h.Output, "  Failure type: %s\n", payload.FailureType)
 	fmt.Fprintf(h.Output, "  Error: %s\n", payload.Error)
+	for _, ft := range payload.FailedTests {
+		fmt.Fprintf(h.Output, "  Failed test: %s %s\n", ft.Package, ft.Name)
+	}
 
 	// Notify the polecat about the failure
 	if err := h.notifyPolecatFailed(payload); err != nil {
@@ -160,12 +164,13 @@ Branch: %s
 Issue: %s
 Failure: %s
 Error: %s
-
+%s
 Please fix the issue and resubmit your work with 'gt done'.`,
 			payload.Branch,
 			payload.Issue,
 			payload.FailureType,
 			payload.Error,
+			formatFailedTests(payload.FailedTests),
 		),
 	)
 	msg.Priority = mail.PriorityHigh
@@ -174,6 +179,29 @@ Please fix the issue and resubmit your work with 'gt done'.`,
 	return h.Router.Send(msg)
 }
 
+// formatFailedTests renders failing tests and their output excerpts for a
+// polecat notification. Returns an empty string when there are none.
+func formatFailedTests(tests []FailedTest) string {
+	if len(tests) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\nFailing tests:\n")
+	for _, ft := range tests {
+		name := ft.Name
+		if name == "" {
+			name = "(package)"
+		}
+		sb.WriteString(fmt.Sprintf("\n  %s %s\n", ft.Package, name))
+		for _, line := range strings.Split(ft.Excerpt, "\n") {
+			if line != "" {
+				sb.WriteString("      " + line + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
 // notifyPolecatRebase sends a rebase request notification to a polecat.
 func (h *DefaultWitnessHandler) notifyPolecatRebase(payload *ReworkRequestPayload) error {
 	conflictInfo := ""
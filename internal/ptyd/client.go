@@ -0,0 +1,305 @@
+package ptyd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+	"golang.org/x/term"
+)
+
+// Client timeouts.
+const (
+	dialTimeout    = 2 * time.Second
+	requestTimeout = 30 * time.Second
+)
+
+// detachKey ends an attach without touching the session (Ctrl-]).
+const detachKey = 0x1d
+
+// ErrNotRunning means no supervisor is listening: the daemon is down.
+var ErrNotRunning = errors.New("pty session supervisor not running (start it with: gt daemon start)")
+
+// Client reaches a Supervisor through its socket. It implements
+// tmux.SessionBackend for every gt process other than the daemon.
+type Client struct {
+	socket string
+}
+
+var _ tmux.SessionBackend = (*Client)(nil)
+
+// NewClient creates a client for the supervisor listening on socketPath.
+func NewClient(socketPath string) *Client {
+	return &Client{socket: socketPath}
+}
+
+func (c *Client) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("unix", c.socket, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	return conn, nil
+}
+
+// send writes req on a new connection and reads the response line.
+func (c *Client) send(req *request) (net.Conn, *bufio.Reader, *response, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(requestTimeout))
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		_ = conn.Close()
+		return nil, nil, nil, fmt.Errorf("sending %s: %w", req.Op, err)
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, nil, fmt.Errorf("reading %s response: %w", req.Op, err)
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		_ = conn.Close()
+		return nil, nil, nil, fmt.Errorf("decoding %s response: %w", req.Op, err)
+	}
+	if resp.Error != "" {
+		_ = conn.Close()
+		return nil, nil, nil, responseError(&resp)
+	}
+	return conn, r, &resp, nil
+}
+
+// call performs a request and closes the connection.
+func (c *Client) call(req *request) (*response, error) {
+	conn, _, resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.Close()
+	return resp, nil
+}
+
+func responseError(resp *response) error {
+	switch resp.Code {
+	case codeNotFound:
+		return tmux.ErrSessionNotFound
+	case codeExists:
+		return tmux.ErrSessionExists
+	}
+	return errors.New(resp.Error)
+}
+
+// Name identifies the backend.
+func (c *Client) Name() string {
+	return "pty"
+}
+
+// IsAvailable reports whether the supervisor is reachable.
+func (c *Client) IsAvailable() bool {
+	conn, err := c.dial()
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+func (c *Client) NewSession(name, workDir string) error {
+	_, err := c.call(&request{Op: "new", Session: name, Arg: workDir})
+	return err
+}
+
+func (c *Client) KillSession(name string) error {
+	_, err := c.call(&request{Op: "kill", Session: name})
+	return err
+}
+
+// HasSession reports false, like tmux with no server, when the
+// supervisor is not running.
+func (c *Client) HasSession(name string) (bool, error) {
+	resp, err := c.call(&request{Op: "has", Session: name})
+	if errors.Is(err, ErrNotRunning) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return resp.Bool, nil
+}
+
+// ListSessions returns no sessions when the supervisor is not running.
+func (c *Client) ListSessions() ([]string, error) {
+	resp, err := c.call(&request{Op: "list"})
+	if errors.Is(err, ErrNotRunning) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+func (c *Client) GetSessionInfo(name string) (*tmux.SessionInfo, error) {
+	resp, err := c.call(&request{Op: "info", Session: name})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Info, nil
+}
+
+func (c *Client) SendLiteral(name, text string) error {
+	_, err := c.call(&request{Op: "literal", Session: name, Arg: text})
+	return err
+}
+
+func (c *Client) SendKeysRaw(name, keys string) error {
+	_, err := c.call(&request{Op: "key", Session: name, Arg: keys})
+	return err
+}
+
+func (c *Client) CapturePane(name string, lines int) (string, error) {
+	if lines < 0 {
+		lines = 0
+	}
+	resp, err := c.call(&request{Op: "capture", Session: name, Lines: lines})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) CapturePaneAll(name string) (string, error) {
+	resp, err := c.call(&request{Op: "capture", Session: name, Lines: -1})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) ClearHistory(pane string) error {
+	_, err := c.call(&request{Op: "clear", Session: pane})
+	return err
+}
+
+func (c *Client) SetEnvironment(name, key, value string) error {
+	_, err := c.call(&request{Op: "setenv", Session: name, Arg: key, Value: value})
+	return err
+}
+
+func (c *Client) GetEnvironment(name, key string) (string, error) {
+	resp, err := c.call(&request{Op: "getenv", Session: name, Arg: key})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) GetPaneCommand(name string) (string, error) {
+	resp, err := c.call(&request{Op: "command", Session: name})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) GetPaneID(name string) (string, error) {
+	resp, err := c.call(&request{Op: "paneid", Session: name})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) GetPaneWorkDir(name string) (string, error) {
+	resp, err := c.call(&request{Op: "workdir", Session: name})
+	if err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) RespawnPane(pane, command string) error {
+	_, err := c.call(&request{Op: "respawn", Session: pane, Arg: command})
+	return err
+}
+
+func (c *Client) SetPaneDiedHook(name, agentID string) error {
+	_, err := c.call(&request{Op: "hook", Session: name, Arg: agentID})
+	return err
+}
+
+// AttachSession connects the terminal to a session until the session
+// ends or the user detaches with Ctrl-].
+func (c *Client) AttachSession(name string) error {
+	req := &request{Op: "attach", Session: name}
+	stdin := int(os.Stdin.Fd())
+	isTerm := term.IsTerminal(stdin)
+	if isTerm {
+		if cols, rows, err := term.GetSize(stdin); err == nil {
+			req.Rows, req.Cols = uint16(rows), uint16(cols) //nolint:gosec // G115: terminal sizes fit uint16
+		}
+	}
+
+	conn, r, _, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Time{})
+
+	fmt.Fprintf(os.Stderr, "Attached to %s (detach: Ctrl-])\r\n", name)
+	if isTerm {
+		state, err := term.MakeRaw(stdin)
+		if err != nil {
+			return fmt.Errorf("setting raw mode: %w", err)
+		}
+		defer func() { _ = term.Restore(stdin, state) }()
+	}
+
+	ended := make(chan struct{})
+	go func() {
+		defer close(ended)
+		_, _ = io.Copy(os.Stdout, r)
+	}()
+
+	detached := make(chan struct{})
+	go func() {
+		defer close(detached)
+		buf := make([]byte, 1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			for i := 0; i < n; i++ {
+				if buf[i] == detachKey {
+					_, _ = conn.Write(buf[:i])
+					return
+				}
+			}
+			if n > 0 {
+				if _, werr := conn.Write(buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-ended:
+	case <-detached:
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package ptyd
+
+import "os"
+
+// openPTY is not implemented on this platform.
+func openPTY() (*os.File, string, error) {
+	return nil, "", errUnsupported
+}
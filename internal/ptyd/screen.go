@@ -0,0 +1,236 @@
+package ptyd
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Screen limits.
+const (
+	// maxScrollback is how many lines a session keeps for capture.
+	maxScrollback = 5000
+	// maxReplay is how much raw output is replayed to a new attach.
+	maxReplay = 64 * 1024
+)
+
+// escape parser states.
+const (
+	stGround = iota
+	stEscape
+	stCSI
+	stOSC
+	stOSCEscape
+)
+
+// screen turns a session's output into plain text lines for capture. It
+// understands enough of the terminal protocol for line-oriented and
+// prompt-redrawing programs: cursor movement, line and screen erase, and
+// carriage return. Colors and other attributes are dropped.
+//
+// It also keeps the raw tail of the output, replayed when a client
+// attaches so its terminal starts with recent context.
+type screen struct {
+	rows, cols int
+	lines      [][]rune
+	row, col   int // cursor; row indexes lines
+
+	state   int
+	params  []byte
+	partial []byte // incomplete UTF-8 sequence from the previous write
+
+	raw []byte
+}
+
+func newScreen(rows, cols int) *screen {
+	return &screen{rows: rows, cols: cols, lines: [][]rune{nil}}
+}
+
+// Write feeds output into the screen.
+func (s *screen) Write(p []byte) {
+	s.raw = append(s.raw, p...)
+	if len(s.raw) > maxReplay {
+		s.raw = append([]byte(nil), s.raw[len(s.raw)-maxReplay:]...)
+	}
+
+	data := p
+	if len(s.partial) > 0 {
+		data = append(s.partial, p...)
+		s.partial = nil
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 && !utf8.FullRune(data) {
+			s.partial = append([]byte(nil), data...)
+			return
+		}
+		data = data[size:]
+		s.put(r)
+	}
+}
+
+func (s *screen) put(r rune) {
+	switch s.state {
+	case stEscape:
+		switch r {
+		case '[':
+			s.state, s.params = stCSI, s.params[:0]
+		case ']':
+			s.state = stOSC
+		default:
+			s.state = stGround // two-byte sequence, ignored
+		}
+		return
+	case stCSI:
+		if r >= 0x40 && r <= 0x7e {
+			s.state = stGround
+			s.csi(r)
+		} else {
+			s.params = append(s.params, byte(r))
+		}
+		return
+	case stOSC:
+		switch r {
+		case 0x07:
+			s.state = stGround
+		case 0x1b:
+			s.state = stOSCEscape
+		}
+		return
+	case stOSCEscape:
+		s.state = stGround
+		return
+	}
+
+	switch {
+	case r == 0x1b:
+		s.state = stEscape
+	case r == '\r':
+		s.col = 0
+	case r == '\n':
+		s.moveTo(s.row+1, s.col)
+	case r == '\b':
+		if s.col > 0 {
+			s.col--
+		}
+	case r == '\t':
+		s.col = (s.col/8 + 1) * 8
+	case r < 0x20 || r == 0x7f:
+		// other control characters have no visible effect
+	default:
+		line := s.lines[s.row]
+		for len(line) < s.col {
+			line = append(line, ' ')
+		}
+		if s.col < len(line) {
+			line[s.col] = r
+		} else {
+			line = append(line, r)
+		}
+		s.lines[s.row] = line
+		s.col++
+	}
+}
+
+// csi applies a control sequence with the given final byte.
+func (s *screen) csi(final rune) {
+	args := strings.Split(strings.TrimLeft(string(s.params), "?>="), ";")
+	arg := func(i, def int) int {
+		if i < len(args) {
+			if n, err := strconv.Atoi(args[i]); err == nil && n > 0 {
+				return n
+			}
+		}
+		return def
+	}
+	top := s.top()
+
+	switch final {
+	case 'A':
+		s.moveTo(max(s.row-arg(0, 1), top), s.col)
+	case 'B':
+		s.moveTo(s.row+arg(0, 1), s.col)
+	case 'C':
+		s.col += arg(0, 1)
+	case 'D':
+		s.col = max(s.col-arg(0, 1), 0)
+	case 'G':
+		s.col = arg(0, 1) - 1
+	case 'H', 'f':
+		s.moveTo(top+arg(0, 1)-1, arg(1, 1)-1)
+	case 'K':
+		line := s.lines[s.row]
+		switch arg(0, 0) {
+		case 0:
+			if s.col < len(line) {
+				s.lines[s.row] = line[:s.col]
+			}
+		case 1:
+			for i := 0; i < s.col && i < len(line); i++ {
+				line[i] = ' '
+			}
+		case 2:
+			s.lines[s.row] = nil
+		}
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			if s.col < len(s.lines[s.row]) {
+				s.lines[s.row] = s.lines[s.row][:s.col]
+			}
+			s.lines = s.lines[:s.row+1]
+		case 2, 3:
+			for i := top; i < len(s.lines); i++ {
+				s.lines[i] = nil
+			}
+		}
+	}
+}
+
+// top returns the line index of the first visible row.
+func (s *screen) top() int {
+	return max(len(s.lines)-s.rows, 0)
+}
+
+// moveTo moves the cursor, growing the screen as needed and trimming
+// scrollback beyond maxScrollback.
+func (s *screen) moveTo(row, col int) {
+	for row >= len(s.lines) {
+		s.lines = append(s.lines, nil)
+	}
+	if drop := len(s.lines) - maxScrollback; drop > 0 {
+		s.lines = append([][]rune(nil), s.lines[drop:]...)
+		row -= drop
+	}
+	s.row, s.col = max(row, 0), max(col, 0)
+}
+
+// Capture returns the last n lines of text (all lines if n <= 0), with
+// trailing blank lines removed.
+func (s *screen) Capture(n int) string {
+	end := len(s.lines)
+	for end > 0 && strings.TrimSpace(string(s.lines[end-1])) == "" {
+		end--
+	}
+	start := 0
+	if n > 0 && end-n > 0 {
+		start = end - n
+	}
+	out := make([]string, 0, end-start)
+	for _, line := range s.lines[start:end] {
+		out = append(out, strings.TrimRight(string(line), " "))
+	}
+	return strings.Join(out, "\n")
+}
+
+// Replay returns the raw tail of the output.
+func (s *screen) Replay() []byte {
+	return append([]byte(nil), s.raw...)
+}
+
+// Clear drops the scrollback, keeping the current line.
+func (s *screen) Clear() {
+	s.lines = [][]rune{s.lines[s.row]}
+	s.row = 0
+	s.raw = nil
+}
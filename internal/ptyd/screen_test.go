@@ -0,0 +1,71 @@
+package ptyd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreen_Capture(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain lines", "one\r\ntwo\r\n", "one\ntwo"},
+		{"carriage return overwrites", "working...\rdone      \r\n", "done"},
+		{"colors dropped", "\x1b[1;32mok\x1b[0m\r\n", "ok"},
+		{"erase line", "progress 10%\r\x1b[Kready\r\n", "ready"},
+		{"title sequence dropped", "\x1b]0;title\x07> ", ">"},
+		{"cursor column", "abc\x1b[1Gx", "xbc"},
+		{"backspace", "ab\bc", "ac"},
+		{"utf8", "héllo ✓", "héllo ✓"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScreen(defaultRows, defaultCols)
+			s.Write([]byte(tt.input))
+			if got := s.Capture(0); got != tt.want {
+				t.Errorf("Capture = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScreen_SplitWrites(t *testing.T) {
+	s := newScreen(defaultRows, defaultCols)
+	input := []byte("✓ \x1b[31mred\x1b[0m")
+	for i := range input {
+		s.Write(input[i : i+1])
+	}
+	if got := s.Capture(0); got != "✓ red" {
+		t.Errorf("Capture = %q, want %q", got, "✓ red")
+	}
+}
+
+func TestScreen_CaptureLast(t *testing.T) {
+	s := newScreen(defaultRows, defaultCols)
+	s.Write([]byte("a\r\nb\r\nc\r\n\r\n"))
+	if got := s.Capture(2); got != "b\nc" {
+		t.Errorf("Capture(2) = %q, want %q", got, "b\nc")
+	}
+
+	s.Clear()
+	s.Write([]byte("d"))
+	if got := s.Capture(0); got != "d" {
+		t.Errorf("after Clear, Capture = %q, want %q", got, "d")
+	}
+	if got := string(s.Replay()); got != "d" {
+		t.Errorf("after Clear, Replay = %q, want %q", got, "d")
+	}
+}
+
+func TestScreen_ScrollbackLimit(t *testing.T) {
+	s := newScreen(defaultRows, defaultCols)
+	s.Write([]byte(strings.Repeat("x\r\n", maxScrollback+100)))
+	if n := len(s.lines); n > maxScrollback {
+		t.Errorf("kept %d lines, want at most %d", n, maxScrollback)
+	}
+	if n := len(s.Replay()); n > maxReplay {
+		t.Errorf("kept %d replay bytes, want at most %d", n, maxReplay)
+	}
+}
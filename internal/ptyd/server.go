@@ -0,0 +1,243 @@
+package ptyd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Each connection carries one JSON request line and one JSON response
+// line. An attach request then turns the connection into a raw terminal
+// stream: session output one way, keyboard input the other.
+
+// request is a session operation sent by a Client.
+type request struct {
+	Op      string `json:"op"`
+	Session string `json:"session,omitempty"`
+	Arg     string `json:"arg,omitempty"`   // dir, text, key, env key or command
+	Value   string `json:"value,omitempty"` // env value
+	Lines   int    `json:"lines,omitempty"`
+	Rows    uint16 `json:"rows,omitempty"`
+	Cols    uint16 `json:"cols,omitempty"`
+}
+
+// response is the result of a request.
+type response struct {
+	Error  string            `json:"error,omitempty"`
+	Code   string            `json:"code,omitempty"` // see errorCode
+	Result string            `json:"result,omitempty"`
+	List   []string          `json:"list,omitempty"`
+	Bool   bool              `json:"bool,omitempty"`
+	Info   *tmux.SessionInfo `json:"info,omitempty"`
+}
+
+// Error codes carried across the socket so callers can still match the
+// tmux sentinel errors.
+const (
+	codeNotFound = "not_found"
+	codeExists   = "exists"
+)
+
+// clientWriteTimeout bounds how long session output waits on a slow
+// attached client before dropping it.
+const clientWriteTimeout = 5 * time.Second
+
+// Listen opens the supervisor socket, replacing a stale one.
+func Listen(socketPath string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return nil, fmt.Errorf("creating socket directory: %w", err)
+	}
+	_ = os.Remove(socketPath)
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("securing %s: %w", socketPath, err)
+	}
+	return ln, nil
+}
+
+// Serve handles client connections until ln is closed.
+func (s *Supervisor) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Supervisor) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return
+	}
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		writeResponse(conn, &response{Error: fmt.Sprintf("bad request: %v", err)})
+		return
+	}
+
+	if req.Op == "attach" {
+		s.serveAttach(conn, r, &req)
+		return
+	}
+	writeResponse(conn, s.dispatch(&req))
+}
+
+func (s *Supervisor) dispatch(req *request) *response {
+	resp := &response{}
+	var err error
+	switch req.Op {
+	case "new":
+		err = s.NewSession(req.Session, req.Arg)
+	case "kill":
+		err = s.KillSession(req.Session)
+	case "has":
+		resp.Bool, err = s.HasSession(req.Session)
+	case "list":
+		resp.List, err = s.ListSessions()
+	case "info":
+		resp.Info, err = s.GetSessionInfo(req.Session)
+	case "literal":
+		err = s.SendLiteral(req.Session, req.Arg)
+	case "key":
+		err = s.SendKeysRaw(req.Session, req.Arg)
+	case "capture":
+		if req.Lines < 0 {
+			resp.Result, err = s.CapturePaneAll(req.Session)
+		} else {
+			resp.Result, err = s.CapturePane(req.Session, req.Lines)
+		}
+	case "clear":
+		err = s.ClearHistory(req.Session)
+	case "setenv":
+		err = s.SetEnvironment(req.Session, req.Arg, req.Value)
+	case "getenv":
+		resp.Result, err = s.GetEnvironment(req.Session, req.Arg)
+	case "command":
+		resp.Result, err = s.GetPaneCommand(req.Session)
+	case "paneid":
+		resp.Result, err = s.GetPaneID(req.Session)
+	case "workdir":
+		resp.Result, err = s.GetPaneWorkDir(req.Session)
+	case "respawn":
+		err = s.RespawnPane(req.Session, req.Arg)
+	case "hook":
+		err = s.SetPaneDiedHook(req.Session, req.Arg)
+	default:
+		err = fmt.Errorf("unknown op %q", req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Code = errorCode(err)
+	}
+	return resp
+}
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, tmux.ErrSessionNotFound):
+		return codeNotFound
+	case errors.Is(err, tmux.ErrSessionExists):
+		return codeExists
+	}
+	return ""
+}
+
+// serveAttach streams a session to conn until the session ends or the
+// client disconnects.
+func (s *Supervisor) serveAttach(conn net.Conn, input *bufio.Reader, req *request) {
+	out := &deadlineWriter{conn: conn}
+	replay, closed, detach, err := s.attach(req.Session, out, req.Rows, req.Cols)
+	if err != nil {
+		writeResponse(conn, &response{Error: err.Error(), Code: errorCode(err)})
+		return
+	}
+	defer detach()
+	out.start(func() {
+		writeResponse(conn, &response{})
+		_, _ = conn.Write(replay)
+	})
+
+	inputDone := make(chan struct{})
+	go func() {
+		defer close(inputDone)
+		buf := make([]byte, 4096)
+		for {
+			n, err := input.Read(buf)
+			if n > 0 {
+				if s.write(req.Session, buf[:n]) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-closed:
+	case <-inputDone:
+	}
+}
+
+// deadlineWriter writes session output to an attached connection with a
+// timeout, so one stuck client cannot stall a session. Output arriving
+// before start is held back, so it follows the response and replay.
+type deadlineWriter struct {
+	conn    net.Conn
+	mu      sync.Mutex
+	started bool
+	pending [][]byte
+}
+
+func (w *deadlineWriter) start(preamble func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	preamble()
+	for _, p := range w.pending {
+		_, _ = w.write(p)
+	}
+	w.started, w.pending = true, nil
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started {
+		w.pending = append(w.pending, p)
+		return len(p), nil
+	}
+	return w.write(p)
+}
+
+func (w *deadlineWriter) write(p []byte) (int, error) {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+	return w.conn.Write(p)
+}
+
+func writeResponse(conn net.Conn, resp *response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	_, _ = conn.Write(append(data, '\n'))
+}
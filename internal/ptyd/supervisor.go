@@ -0,0 +1,534 @@
+// Package ptyd hosts agent sessions on native pseudo-terminals, for hosts
+// without tmux. The daemon owns a Supervisor, which runs each session's
+// shell on a PTY and serves the session operations over a unix socket;
+// every other gt process reaches it through a Client. Both implement
+// tmux.SessionBackend, so callers keep using tmux.Tmux unchanged.
+package ptyd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Terminal defaults for new sessions.
+const (
+	defaultRows = 50
+	defaultCols = 200
+	// killGrace is how long a killed session gets to exit on SIGHUP
+	// before it is sent SIGKILL.
+	killGrace = 2 * time.Second
+)
+
+// Supervisor runs sessions on PTYs. It implements tmux.SessionBackend
+// in-process; Serve exposes it to other processes.
+type Supervisor struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+
+	// Shell is the program each session runs (default $SHELL, or /bin/sh).
+	Shell string
+
+	// OnPaneDied is called when a session with a pane-died hook exits on
+	// its own. The default runs gt log crash, like the tmux hook.
+	OnPaneDied func(agentID, session string, exitCode int)
+}
+
+// session is one PTY session. Respawning replaces proc but keeps the
+// session, its screen and its attached clients.
+type session struct {
+	name     string
+	workDir  string
+	env      map[string]string
+	created  time.Time
+	activity time.Time
+	hook     string // agent ID for the pane-died hook
+	screen   *screen
+	proc     *process
+	clients  map[io.Writer]struct{}
+	closed   chan struct{} // closed when the session ends
+}
+
+// process is a command running on a session's PTY.
+type process struct {
+	cmd    *exec.Cmd
+	master *os.File
+	done   chan struct{}
+	killed bool // ended by KillSession or RespawnPane, not on its own
+}
+
+var _ tmux.SessionBackend = (*Supervisor)(nil)
+
+// NewSupervisor creates a Supervisor with no sessions.
+func NewSupervisor() *Supervisor {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return &Supervisor{
+		sessions:   make(map[string]*session),
+		Shell:      shell,
+		OnPaneDied: logCrash,
+	}
+}
+
+// logCrash records a session's unexpected exit in the town log.
+func logCrash(agentID, session string, exitCode int) {
+	_ = exec.Command("gt", "log", "crash", "--agent", agentID, "--session", session,
+		"--exit-code", strconv.Itoa(exitCode)).Run()
+}
+
+// Name identifies the backend.
+func (s *Supervisor) Name() string {
+	return "pty"
+}
+
+// IsAvailable reports whether this platform supports PTY sessions.
+func (s *Supervisor) IsAvailable() bool {
+	return ptySupported
+}
+
+// NewSession starts a shell on a new PTY.
+func (s *Supervisor) NewSession(name, workDir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[name]; ok {
+		return tmux.ErrSessionExists
+	}
+	now := time.Now()
+	sess := &session{
+		name:     name,
+		workDir:  workDir,
+		env:      make(map[string]string),
+		created:  now,
+		activity: now,
+		screen:   newScreen(defaultRows, defaultCols),
+		clients:  make(map[io.Writer]struct{}),
+		closed:   make(chan struct{}),
+	}
+	proc, err := s.start(sess, []string{s.Shell})
+	if err != nil {
+		return fmt.Errorf("starting session %s: %w", name, err)
+	}
+	sess.proc = proc
+	s.sessions[name] = sess
+	return nil
+}
+
+// start runs argv on a new PTY for sess. Called with s.mu held.
+func (s *Supervisor) start(sess *session, argv []string) (*process, error) {
+	master, slaveName, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	slave, err := os.OpenFile(slaveName, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close()
+		return nil, err
+	}
+	defer func() { _ = slave.Close() }()
+	_ = setWinsize(master, defaultRows, defaultCols)
+
+	cmd := exec.Command(argv[0], argv[1:]...) //nolint:gosec // G204: argv is the configured shell or a respawn command
+	cmd.Dir = sess.workDir
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color", "GT_SESSION="+sess.name)
+	for k, v := range sess.env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = sessionSysProcAttr()
+	if err := cmd.Start(); err != nil {
+		_ = master.Close()
+		return nil, err
+	}
+
+	proc := &process{cmd: cmd, master: master, done: make(chan struct{})}
+	go s.pump(sess, proc)
+	return proc, nil
+}
+
+// pump copies a process's output to its session until the process exits,
+// then ends the session unless the process was replaced or killed.
+func (s *Supervisor) pump(sess *session, proc *process) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := proc.master.Read(buf)
+		if n > 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			s.mu.Lock()
+			sess.screen.Write(chunk)
+			sess.activity = time.Now()
+			clients := make([]io.Writer, 0, len(sess.clients))
+			for w := range sess.clients {
+				clients = append(clients, w)
+			}
+			s.mu.Unlock()
+			for _, w := range clients {
+				_, _ = w.Write(chunk)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	exitCode := 0
+	if err := proc.cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = -1
+		}
+	}
+	_ = proc.master.Close()
+	close(proc.done)
+
+	s.mu.Lock()
+	current := s.sessions[sess.name] == sess && sess.proc == proc
+	if current {
+		delete(s.sessions, sess.name)
+		close(sess.closed)
+	}
+	hook, killed := sess.hook, proc.killed
+	s.mu.Unlock()
+
+	if current && hook != "" && !killed && s.OnPaneDied != nil {
+		s.OnPaneDied(hook, sess.name, exitCode)
+	}
+}
+
+// stop ends a process: SIGHUP, then SIGKILL if it outlives killGrace.
+func stop(proc *process) {
+	pid := proc.cmd.Process.Pid
+	killGroup(pid, syscall.SIGHUP)
+	go func() {
+		select {
+		case <-proc.done:
+		case <-time.After(killGrace):
+			killGroup(pid, syscall.SIGKILL)
+		}
+	}()
+}
+
+// get returns a session. Called with s.mu held.
+func (s *Supervisor) get(name string) (*session, error) {
+	sess, ok := s.sessions[name]
+	if !ok {
+		return nil, tmux.ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+// KillSession ends a session and its processes.
+func (s *Supervisor) KillSession(name string) error {
+	s.mu.Lock()
+	sess, err := s.get(name)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	delete(s.sessions, name)
+	close(sess.closed)
+	proc := sess.proc
+	proc.killed = true
+	s.mu.Unlock()
+
+	stop(proc)
+	return nil
+}
+
+// Shutdown kills every session, for daemon exit.
+func (s *Supervisor) Shutdown() {
+	names, _ := s.ListSessions()
+	for _, name := range names {
+		_ = s.KillSession(name)
+	}
+}
+
+// HasSession reports whether a session exists.
+func (s *Supervisor) HasSession(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[name]
+	return ok, nil
+}
+
+// ListSessions returns all session names, sorted.
+func (s *Supervisor) ListSessions() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.sessions))
+	for name := range s.sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetSessionInfo returns a session's details in tmux's formats.
+func (s *Supervisor) GetSessionInfo(name string) (*tmux.SessionInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tmux.SessionInfo{
+		Name:     sess.name,
+		Windows:  1,
+		Created:  sess.created.Format(time.ANSIC),
+		Attached: len(sess.clients) > 0,
+		Activity: strconv.FormatInt(sess.activity.Unix(), 10),
+	}, nil
+}
+
+// AttachSession needs a terminal, which the daemon does not have; clients
+// attach through Client.AttachSession.
+func (s *Supervisor) AttachSession(name string) error {
+	return fmt.Errorf("cannot attach to %s from the daemon; use gt from a terminal", name)
+}
+
+// attach registers w to receive a session's output and returns the
+// recent output to replay, plus a channel closed when the session ends.
+// detach unregisters w.
+func (s *Supervisor) attach(name string, w io.Writer, rows, cols uint16) (replay []byte, closed <-chan struct{}, detach func(), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if rows > 0 && cols > 0 {
+		_ = setWinsize(sess.proc.master, rows, cols)
+	}
+	sess.clients[w] = struct{}{}
+	detach = func() {
+		s.mu.Lock()
+		delete(sess.clients, w)
+		s.mu.Unlock()
+	}
+	return sess.screen.Replay(), sess.closed, detach, nil
+}
+
+// write sends raw input to a session's current process.
+func (s *Supervisor) write(name string, data []byte) error {
+	s.mu.Lock()
+	sess, err := s.get(name)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = sess.proc.master.Write(data)
+	return err
+}
+
+// SendLiteral types text into a session.
+func (s *Supervisor) SendLiteral(name, text string) error {
+	return s.write(name, []byte(text))
+}
+
+// SendKeysRaw sends a tmux-style key name; anything else is typed as-is.
+func (s *Supervisor) SendKeysRaw(name, keys string) error {
+	return s.write(name, []byte(keyBytes(keys)))
+}
+
+// keyBytes translates tmux key names to the bytes a terminal sends.
+func keyBytes(key string) string {
+	switch key {
+	case "Enter":
+		return "\r"
+	case "Escape":
+		return "\x1b"
+	case "Tab":
+		return "\t"
+	case "BSpace":
+		return "\x7f"
+	case "Space":
+		return " "
+	case "Up":
+		return "\x1b[A"
+	case "Down":
+		return "\x1b[B"
+	case "Right":
+		return "\x1b[C"
+	case "Left":
+		return "\x1b[D"
+	}
+	if len(key) == 3 && strings.HasPrefix(key, "C-") {
+		c := key[2]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c >= 'a' && c <= 'z' {
+			return string(rune(c - 'a' + 1))
+		}
+	}
+	return key
+}
+
+// CapturePane returns the last lines of a session's output.
+func (s *Supervisor) CapturePane(name string, lines int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return "", err
+	}
+	if lines <= 0 {
+		lines = defaultRows
+	}
+	return sess.screen.Capture(lines), nil
+}
+
+// CapturePaneAll returns all of a session's scrollback.
+func (s *Supervisor) CapturePaneAll(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return "", err
+	}
+	return sess.screen.Capture(0), nil
+}
+
+// ClearHistory drops a session's scrollback.
+func (s *Supervisor) ClearHistory(pane string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(pane)
+	if err != nil {
+		return err
+	}
+	sess.screen.Clear()
+	return nil
+}
+
+// SetEnvironment sets a variable for processes the session starts later,
+// as tmux set-environment does.
+func (s *Supervisor) SetEnvironment(name, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	sess.env[key] = value
+	return nil
+}
+
+// GetEnvironment returns a variable set with SetEnvironment.
+func (s *Supervisor) GetEnvironment(name, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return "", err
+	}
+	value, ok := sess.env[key]
+	if !ok {
+		return "", fmt.Errorf("unknown variable: %s", key)
+	}
+	return value, nil
+}
+
+// GetPaneCommand returns the name of the session's foreground process.
+func (s *Supervisor) GetPaneCommand(name string) (string, error) {
+	pid, _, err := s.foreground(name)
+	if err != nil {
+		return "", err
+	}
+	return processName(pid)
+}
+
+// GetPaneID returns the session name: PTY sessions have a single pane.
+func (s *Supervisor) GetPaneID(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.get(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// GetPaneWorkDir returns the foreground process's working directory,
+// falling back to the directory the session started in.
+func (s *Supervisor) GetPaneWorkDir(name string) (string, error) {
+	pid, workDir, err := s.foreground(name)
+	if err != nil {
+		return "", err
+	}
+	if dir, err := processDir(pid); err == nil {
+		return dir, nil
+	}
+	return workDir, nil
+}
+
+// foreground returns the PID leading a session's foreground process group
+// and the session's starting directory.
+func (s *Supervisor) foreground(name string) (int, string, error) {
+	s.mu.Lock()
+	sess, err := s.get(name)
+	if err != nil {
+		s.mu.Unlock()
+		return 0, "", err
+	}
+	proc, workDir := sess.proc, sess.workDir
+	s.mu.Unlock()
+
+	pid, err := foregroundPID(proc.master)
+	if err != nil || pid <= 0 {
+		pid = proc.cmd.Process.Pid
+	}
+	return pid, workDir, nil
+}
+
+// RespawnPane replaces the session's process with command, keeping the
+// session, its scrollback and attached clients.
+func (s *Supervisor) RespawnPane(pane, command string) error {
+	s.mu.Lock()
+	sess, err := s.get(pane)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	old := sess.proc
+	proc, err := s.start(sess, []string{s.Shell, "-c", command})
+	if err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("respawning %s: %w", pane, err)
+	}
+	old.killed = true
+	sess.proc = proc
+	s.mu.Unlock()
+
+	stop(old)
+	return nil
+}
+
+// SetPaneDiedHook records the agent to report if the session exits on
+// its own.
+func (s *Supervisor) SetPaneDiedHook(name, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	sess.hook = agentID
+	return nil
+}
+
+// SocketPath returns the path of the supervisor's socket in a town.
+func SocketPath(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "pty.sock")
+}
@@ -0,0 +1,180 @@
+package ptyd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// startSupervisor serves a supervisor running /bin/sh sessions and
+// returns a client connected to it.
+func startSupervisor(t *testing.T) (*Supervisor, *Client) {
+	t.Helper()
+	if !ptySupported {
+		t.Skip("pty sessions not supported on this platform")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	// Unix socket paths are length-limited, so avoid the long t.TempDir.
+	dir, err := os.MkdirTemp("", "ptyd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "pty.sock")
+
+	sup := NewSupervisor()
+	sup.Shell = "/bin/sh"
+	sup.OnPaneDied = nil
+	ln, err := Listen(socket)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go func() { _ = sup.Serve(ln) }()
+	t.Cleanup(func() {
+		_ = ln.Close()
+		sup.Shutdown()
+	})
+	return sup, NewClient(socket)
+}
+
+// waitForCapture polls a session until its output contains want.
+func waitForCapture(t *testing.T, c *Client, session, want string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	var out string
+	for time.Now().Before(deadline) {
+		var err error
+		out, err = c.CapturePaneAll(session)
+		if err != nil {
+			t.Fatalf("CapturePaneAll: %v", err)
+		}
+		if strings.Contains(out, want) {
+			return out
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("output never contained %q; got:\n%s", want, out)
+	return ""
+}
+
+func TestClient_SessionLifecycle(t *testing.T) {
+	_, c := startSupervisor(t)
+	const name = "gt-test-pty"
+	dir := t.TempDir()
+
+	if !c.IsAvailable() {
+		t.Fatal("IsAvailable = false with supervisor running")
+	}
+	if err := c.NewSession(name, dir); err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if err := c.NewSession(name, dir); !errors.Is(err, tmux.ErrSessionExists) {
+		t.Errorf("duplicate NewSession err = %v, want ErrSessionExists", err)
+	}
+	if has, err := c.HasSession(name); err != nil || !has {
+		t.Errorf("HasSession = %v, %v", has, err)
+	}
+	if list, _ := c.ListSessions(); len(list) != 1 || list[0] != name {
+		t.Errorf("ListSessions = %v", list)
+	}
+
+	if err := c.SetEnvironment(name, "GT_ROLE", "polecat"); err != nil {
+		t.Fatalf("SetEnvironment: %v", err)
+	}
+	if v, err := c.GetEnvironment(name, "GT_ROLE"); err != nil || v != "polecat" {
+		t.Errorf("GetEnvironment = %q, %v", v, err)
+	}
+
+	if err := c.SendLiteral(name, "echo hi-$GT_SESSION"); err != nil {
+		t.Fatalf("SendLiteral: %v", err)
+	}
+	if err := c.SendKeysRaw(name, "Enter"); err != nil {
+		t.Fatalf("SendKeysRaw: %v", err)
+	}
+	waitForCapture(t, c, name, "hi-"+name)
+
+	if wd, err := c.GetPaneWorkDir(name); err != nil {
+		t.Errorf("GetPaneWorkDir: %v", err)
+	} else if resolved, _ := filepath.EvalSymlinks(dir); wd != dir && wd != resolved {
+		t.Errorf("GetPaneWorkDir = %q, want %q", wd, dir)
+	}
+
+	if err := c.KillSession(name); err != nil {
+		t.Fatalf("KillSession: %v", err)
+	}
+	if has, _ := c.HasSession(name); has {
+		t.Error("session still exists after KillSession")
+	}
+	if _, err := c.CapturePane(name, 10); !errors.Is(err, tmux.ErrSessionNotFound) {
+		t.Errorf("CapturePane after kill err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestClient_Respawn(t *testing.T) {
+	_, c := startSupervisor(t)
+	const name = "gt-test-respawn"
+
+	if err := c.NewSession(name, t.TempDir()); err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if err := c.RespawnPane(name, "echo respawned; exec /bin/sh"); err != nil {
+		t.Fatalf("RespawnPane: %v", err)
+	}
+	waitForCapture(t, c, name, "respawned")
+	if has, _ := c.HasSession(name); !has {
+		t.Error("session ended on respawn")
+	}
+}
+
+func TestSupervisor_PaneDiedHook(t *testing.T) {
+	sup, c := startSupervisor(t)
+	const name = "gt-test-died"
+
+	died := make(chan int, 1)
+	sup.OnPaneDied = func(agentID, session string, exitCode int) {
+		if agentID == "rig/polecats/x" && session == name {
+			died <- exitCode
+		}
+	}
+	if err := c.NewSession(name, t.TempDir()); err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if err := c.SetPaneDiedHook(name, "rig/polecats/x"); err != nil {
+		t.Fatalf("SetPaneDiedHook: %v", err)
+	}
+	_ = c.SendLiteral(name, "exit 3")
+	_ = c.SendKeysRaw(name, "Enter")
+
+	select {
+	case code := <-died:
+		if code != 3 {
+			t.Errorf("exit code = %d, want 3", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pane-died hook not called")
+	}
+	if has, _ := c.HasSession(name); has {
+		t.Error("session still exists after its shell exited")
+	}
+}
+
+func TestClient_NotRunning(t *testing.T) {
+	c := NewClient(filepath.Join(t.TempDir(), "missing.sock"))
+	if c.IsAvailable() {
+		t.Error("IsAvailable = true with no supervisor")
+	}
+	if has, err := c.HasSession("gt-x"); has || err != nil {
+		t.Errorf("HasSession = %v, %v; want false, nil", has, err)
+	}
+	if err := c.NewSession("gt-x", ""); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("NewSession err = %v, want ErrNotRunning", err)
+	}
+}
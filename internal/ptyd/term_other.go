@@ -0,0 +1,25 @@
+//go:build !linux && !darwin
+
+package ptyd
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+const ptySupported = false
+
+var errUnsupported = errors.New("pty sessions are not supported on this platform")
+
+func setWinsize(master *os.File, rows, cols uint16) error { return errUnsupported }
+
+func foregroundPID(master *os.File) (int, error) { return 0, errUnsupported }
+
+func sessionSysProcAttr() *syscall.SysProcAttr { return nil }
+
+func killGroup(pid int, sig syscall.Signal) {}
+
+func processName(pid int) (string, error) { return "", errUnsupported }
+
+func processDir(pid int) (string, error) { return "", errUnsupported }
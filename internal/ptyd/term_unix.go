@@ -0,0 +1,80 @@
+//go:build linux || darwin
+
+package ptyd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ptySupported reports whether this platform can host pty sessions.
+const ptySupported = true
+
+// ioctl runs fn on the pty's descriptor. Unlike Fd, this is safe to race
+// with the pump closing the pty when its process exits.
+func ioctl(master *os.File, fn func(fd int) error) error {
+	conn, err := master.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var opErr error
+	if err := conn.Control(func(fd uintptr) { opErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return opErr
+}
+
+// setWinsize sets the terminal size of a pty.
+func setWinsize(master *os.File, rows, cols uint16) error {
+	return ioctl(master, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: rows, Col: cols})
+	})
+}
+
+// foregroundPID returns the process group leading the pty's foreground,
+// which is the command a user at the terminal is talking to.
+func foregroundPID(master *os.File) (int, error) {
+	var pgrp int
+	err := ioctl(master, func(fd int) error {
+		var err error
+		pgrp, err = unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+		return err
+	})
+	return pgrp, err
+}
+
+// sessionSysProcAttr starts a process as the session leader of its pty.
+func sessionSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true, Setctty: true}
+}
+
+// killGroup signals a process and its group.
+func killGroup(pid int, sig syscall.Signal) {
+	_ = syscall.Kill(-pid, sig)
+	_ = syscall.Kill(pid, sig)
+}
+
+// processName returns the command name of a process, like tmux's
+// #{pane_current_command}.
+func processName(pid int) (string, error) {
+	if data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/comm"); err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(strings.TrimSpace(string(out))), nil
+}
+
+// processDir returns a process's working directory, where the platform
+// exposes it.
+func processDir(pid int) (string, error) {
+	return os.Readlink("/proc/" + strconv.Itoa(pid) + "/cwd")
+}
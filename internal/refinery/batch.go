@@ -0,0 +1,357 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// Batch processing model
+//
+// With batch_size > 1 the refinery takes the top batch_size ready MRs by
+// score (all for the same target), merges them one after another into a
+// scratch worktree detached at origin/<target>, and runs the test command
+// once for the whole batch. A green batch is pushed in one go.
+//
+// A red batch is bisected. Each half is rebuilt on the last known-good
+// commit and tested; halves that pass become the new known-good base, and
+// failing halves are split again until single culprits remain. Once
+// max_bisect_depth halvings are used up, the remaining suspects are tested
+// one at a time. Everything that passed lands; each culprit is failed back
+// to the queue and, like any test failure, reported to the witness with
+// MERGE_FAILED.
+//
+// The whole batch runs under the train lock.
+
+// batchWorktreeName is the scratch worktree used for batches, under
+// mergeWorktreesDir.
+const batchWorktreeName = "batch"
+
+// batchOutcome accumulates the verdicts while a batch is built and
+// bisected.
+type batchOutcome struct {
+	good    []*mrqueue.MR
+	commits map[string]string // MR ID -> merge commit SHA
+	failed  map[string]ProcessResult
+	rebased map[string]bool
+}
+
+func newBatchOutcome() *batchOutcome {
+	return &batchOutcome{
+		commits: make(map[string]string),
+		failed:  make(map[string]ProcessResult),
+		rebased: make(map[string]bool),
+	}
+}
+
+// pass records mrs as good, with their merge commits from commits. MRs
+// already recorded as failed (they did not merge) are skipped.
+func (o *batchOutcome) pass(mrs []*mrqueue.MR, commits map[string]string) {
+	for _, mr := range mrs {
+		if _, failed := o.failed[mr.ID]; failed {
+			continue
+		}
+		o.good = append(o.good, mr)
+		o.commits[mr.ID] = commits[mr.ID]
+	}
+}
+
+// batchSize returns the effective batch size; 1 means batching is off.
+func (e *Engineer) batchSize() int {
+	if e.config.BatchSize < 1 {
+		return 1
+	}
+	return e.config.BatchSize
+}
+
+// batchWorkerID is the mrqueue claim identity for MRs in a batch.
+func (e *Engineer) batchWorkerID() string {
+	return e.claimer + "/batch"
+}
+
+// processBatch claims the next batch of ready MRs, skipping any in skip,
+// and processes it. Claimed MR IDs are added to skip when it is non-nil.
+// Returns how many MRs were in the batch.
+func (e *Engineer) processBatch(ctx context.Context, skip map[string]bool) (int, error) {
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		return 0, err
+	}
+
+	var batch []*mrqueue.MR
+	for _, mr := range ready {
+		if len(batch) >= e.batchSize() {
+			break
+		}
+		if skip[mr.ID] || (len(batch) > 0 && mr.Target != batch[0].Target) {
+			continue
+		}
+		if err := e.mrQueue.Claim(mr.ID, e.batchWorkerID()); err != nil {
+			if !errors.Is(err, mrqueue.ErrAlreadyClaimed) && !errors.Is(err, mrqueue.ErrNotFound) {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to claim %s: %v\n", mr.ID, err)
+			}
+			continue
+		}
+		mr.ClaimedBy = e.batchWorkerID()
+		if skip != nil {
+			skip[mr.ID] = true
+		}
+		batch = append(batch, mr)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	e.train.Lock()
+	defer e.train.Unlock()
+	e.runBatch(ctx, batch)
+	return len(batch), nil
+}
+
+// runBatch merges, tests and lands a claimed batch. Caller holds the
+// train lock.
+func (e *Engineer) runBatch(ctx context.Context, batch []*mrqueue.MR) {
+	target := batch[0].Target
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Batch of %d MRs -> %s\n", len(batch), target)
+	tracks := make(map[string]*mrTracker, len(batch))
+	for _, mr := range batch {
+		if err := e.eventLogger.LogMergeStarted(mr); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
+		}
+		tracks[mr.ID] = e.trackMR(mr)
+	}
+
+	stopHolding := e.holdClaims(batch...)
+	out := newBatchOutcome()
+	v, members := e.buildBatch(batch, out)
+	if v != nil {
+		defer e.removeWorktree(v.path)
+	}
+
+	if len(members) > 0 {
+		tested := e.testBatch(ctx, v, len(members))
+		head := v.base
+		if tested.Success {
+			head, _ = v.git.Rev("HEAD")
+			out.pass(members, out.commits)
+		} else {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Batch failed tests, bisecting %d MRs\n", len(members))
+			head = e.bisect(ctx, v, v.base, members, 1, tested, out)
+		}
+		e.landBatch(v, target, head, out)
+	}
+	stopHolding()
+
+	for _, mr := range batch {
+		if out.rebased[mr.ID] {
+			e.recordRebase(mr)
+		}
+	}
+	for _, mr := range out.good {
+		result := ProcessResult{Success: true, MergeCommit: out.commits[mr.ID]}
+		tracks[mr.ID].finish(result)
+		e.handleSuccessFromQueue(mr, result)
+	}
+	for _, mr := range batch {
+		result, failed := out.failed[mr.ID]
+		if !failed {
+			continue
+		}
+		tracks[mr.ID].finish(result)
+		e.handleFailureFromQueue(mr, result)
+		if err := e.mrQueue.Release(mr.ID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to release claim on %s: %v\n", mr.ID, err)
+		}
+	}
+}
+
+// buildBatch fetches the batch and merges each MR, in order, into a
+// scratch worktree detached at origin/<target>. MRs that cannot be merged
+// are recorded as failed. Returns the worktree (nil if it could not be
+// created) with v.base at the target tip, and the MRs that were merged.
+func (e *Engineer) buildBatch(batch []*mrqueue.MR, out *batchOutcome) (*verifiedMerge, []*mrqueue.MR) {
+	target := batch[0].Target
+	path := filepath.Join(constants.RigRuntimePath(e.rig.Path), mergeWorktreesDir, batchWorktreeName)
+
+	e.repoMu.Lock()
+	var fetched []*mrqueue.MR
+	for _, mr := range batch {
+		if err := e.git.FetchBranch("origin", mr.Branch); err != nil {
+			out.failed[mr.ID] = ProcessResult{Error: fmt.Sprintf("failed to fetch branch %s: %v", mr.Branch, err)}
+			continue
+		}
+		fetched = append(fetched, mr)
+	}
+	err := e.git.FetchBranch("origin", target)
+	if err != nil {
+		err = fmt.Errorf("failed to fetch target %s: %v", target, err)
+	} else {
+		_ = os.RemoveAll(path) // leftover from a crashed batch
+		_ = e.git.WorktreePrune()
+		if mkErr := os.MkdirAll(filepath.Dir(path), 0755); mkErr != nil {
+			err = mkErr
+		} else if wtErr := e.git.WorktreeAddDetached(path, "origin/"+target); wtErr != nil {
+			err = fmt.Errorf("creating worktree: %w", wtErr)
+		}
+	}
+	e.repoMu.Unlock()
+	if err != nil {
+		for _, mr := range fetched {
+			out.failed[mr.ID] = ProcessResult{Error: err.Error()}
+		}
+		return nil, nil
+	}
+
+	v := &verifiedMerge{git: git.NewGit(path), path: path}
+	base, err := v.git.Rev("HEAD")
+	if err != nil {
+		for _, mr := range fetched {
+			out.failed[mr.ID] = ProcessResult{Error: fmt.Sprintf("resolving origin/%s: %v", target, err)}
+		}
+		return v, nil
+	}
+
+	var members []*mrqueue.MR
+	for _, mr := range fetched {
+		// mergeInWorktree returns to v.base after an auto-rebase, so point
+		// it at the batch built so far.
+		if v.base, err = v.git.Rev("HEAD"); err != nil {
+			out.failed[mr.ID] = ProcessResult{Error: fmt.Sprintf("resolving batch head: %v", err)}
+			continue
+		}
+		result := e.mergeInWorktree(v, mr)
+		out.rebased[mr.ID] = result.RebaseAttempted
+		if !result.Success {
+			out.failed[mr.ID] = result
+			continue
+		}
+		out.commits[mr.ID], _ = v.git.Rev("HEAD")
+		members = append(members, mr)
+	}
+	v.base = base
+	return v, members
+}
+
+// bisect isolates the MRs in suspects that break the tests. The merge of
+// all suspects on top of base is known to fail with failure. Returns a
+// tested commit holding base plus every suspect that passed.
+func (e *Engineer) bisect(ctx context.Context, v *verifiedMerge, base string, suspects []*mrqueue.MR, depth int, failure ProcessResult, out *batchOutcome) string {
+	if len(suspects) == 1 {
+		mr := suspects[0]
+		if _, failed := out.failed[mr.ID]; !failed {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] %s: identified as failing the batch\n", mr.ID)
+			out.failed[mr.ID] = failure
+		}
+		return base
+	}
+	if depth > e.config.MaxBisectDepth {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Bisect depth exhausted, testing %d MRs individually\n", len(suspects))
+		for _, mr := range suspects {
+			base = e.tryBatch(ctx, v, base, []*mrqueue.MR{mr}, out)
+		}
+		return base
+	}
+
+	mid := len(suspects) / 2
+	left, right := suspects[:mid], suspects[mid:]
+
+	head, commits, result := e.buildAndTest(ctx, v, base, left, out)
+	if result.Success {
+		// The culprit is in the right half.
+		out.pass(left, commits)
+		return e.bisect(ctx, v, head, right, depth+1, failure, out)
+	}
+
+	base = e.bisect(ctx, v, base, left, depth+1, result, out)
+	head, commits, result = e.buildAndTest(ctx, v, base, right, out)
+	if result.Success {
+		out.pass(right, commits)
+		return head
+	}
+	return e.bisect(ctx, v, base, right, depth+1, result, out)
+}
+
+// tryBatch builds and tests mrs on base, recording them as good or (for a
+// single MR) failed. Returns the new known-good commit.
+func (e *Engineer) tryBatch(ctx context.Context, v *verifiedMerge, base string, mrs []*mrqueue.MR, out *batchOutcome) string {
+	head, commits, result := e.buildAndTest(ctx, v, base, mrs, out)
+	if result.Success {
+		out.pass(mrs, commits)
+		return head
+	}
+	for _, mr := range mrs {
+		if _, failed := out.failed[mr.ID]; !failed {
+			out.failed[mr.ID] = result
+		}
+	}
+	return base
+}
+
+// buildAndTest merges mrs onto base in the batch worktree and runs the
+// tests. MRs that no longer merge cleanly are recorded as failed and left
+// out. Returns the resulting head, the per-MR merge commits and the test
+// result.
+func (e *Engineer) buildAndTest(ctx context.Context, v *verifiedMerge, base string, mrs []*mrqueue.MR, out *batchOutcome) (string, map[string]string, ProcessResult) {
+	if err := v.git.Checkout(base); err != nil {
+		return base, nil, ProcessResult{Error: fmt.Sprintf("checkout %s: %v", shortSHA(base), err)}
+	}
+
+	commits := make(map[string]string, len(mrs))
+	merged := 0
+	for _, mr := range mrs {
+		if result := e.mergeInWorktreeWithRebase(v, mr, false); !result.Success {
+			out.failed[mr.ID] = result
+			continue
+		}
+		commits[mr.ID], _ = v.git.Rev("HEAD")
+		merged++
+	}
+	head, err := v.git.Rev("HEAD")
+	if err != nil {
+		return base, nil, ProcessResult{Error: fmt.Sprintf("resolving batch head: %v", err)}
+	}
+	if merged == 0 {
+		return head, commits, ProcessResult{Success: true}
+	}
+	return head, commits, e.testBatch(ctx, v, merged)
+}
+
+// testBatch runs the configured test command against the batch worktree.
+func (e *Engineer) testBatch(ctx context.Context, v *verifiedMerge, n int) ProcessResult {
+	if !e.config.RunTests || e.config.TestCommand == "" {
+		return ProcessResult{Success: true}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Testing batch of %d: %s\n", n, e.config.TestCommand)
+	if result := e.runTestsIn(ctx, v.path); !result.Success {
+		return ProcessResult{Success: false, TestsFailed: true, Error: result.Error, FailedTests: result.FailedTests}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Batch of %d passed\n", n)
+	return ProcessResult{Success: true}
+}
+
+// landBatch pushes head, the tested merge of every good MR, to the target.
+// If the push fails the good MRs are failed back to the queue instead.
+func (e *Engineer) landBatch(v *verifiedMerge, target, head string, out *batchOutcome) {
+	if len(out.good) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Pushing batch of %d to origin/%s...\n", len(out.good), target)
+	e.repoMu.Lock()
+	err := v.git.Push("origin", head+":refs/heads/"+target, false)
+	e.repoMu.Unlock()
+	if err == nil {
+		return
+	}
+
+	for _, mr := range out.good {
+		out.failed[mr.ID] = ProcessResult{Error: fmt.Sprintf("failed to push to origin: %v", err)}
+	}
+	out.good = nil
+}
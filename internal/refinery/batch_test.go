@@ -0,0 +1,164 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// newBatchEngineer returns an engineer in batch mode whose test command
+// appends to a run log and fails when a file named BAD is present.
+func newBatchEngineer(t *testing.T, clone string, batchSize int) (e *Engineer, runs func() int, sent *[]*mail.Message) {
+	t.Helper()
+	e, out := newTrainEngineer(t, clone, 1)
+	e.config.BatchSize = batchSize
+
+	runLog := filepath.Join(t.TempDir(), "runs")
+	e.config.TestCommand = "echo run >> " + runLog + " && test ! -e BAD"
+
+	var msgs []*mail.Message
+	e.sendMail = func(msg *mail.Message) error {
+		msgs = append(msgs, msg)
+		return nil
+	}
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("engineer output:\n%s", out.String())
+		}
+	})
+
+	runs = func() int {
+		data, _ := os.ReadFile(runLog)
+		return strings.Count(string(data), "run")
+	}
+	return e, runs, &msgs
+}
+
+func submitBranches(t *testing.T, e *Engineer, branches ...string) []*mrqueue.MR {
+	t.Helper()
+	var mrs []*mrqueue.MR
+	for i, branch := range branches {
+		// P0, P1, ... keeps ListByScore in submission order.
+		mr := &mrqueue.MR{Branch: branch, Target: "main", Worker: strings.TrimPrefix(branch, "polecat/"), Priority: i}
+		if err := e.mrQueue.Submit(mr); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		mrs = append(mrs, mr)
+	}
+	return mrs
+}
+
+func TestProcessQueue_BatchTestsOnce(t *testing.T) {
+	origin, clone := setupTrainRepo(t)
+	branches := []string{"polecat/nux", "polecat/toast", "polecat/slit"}
+	for _, b := range branches {
+		pushBranch(t, clone, b, map[string]string{strings.TrimPrefix(b, "polecat/") + ".txt": b + "\n"})
+	}
+
+	e, runs, sent := newBatchEngineer(t, clone, 3)
+	submitBranches(t, e, branches...)
+
+	n, err := e.ProcessQueue(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("processed = %d, want 3", n)
+	}
+	if got := runs(); got != 1 {
+		t.Errorf("test runs = %d, want 1", got)
+	}
+	if merges := gitCmd(t, origin, "rev-list", "--merges", "--count", "main"); merges != "3" {
+		t.Errorf("merge commits on main = %s, want 3", merges)
+	}
+	if c := e.mrQueue.Count(); c != 0 {
+		t.Errorf("queue count = %d, want 0", c)
+	}
+	if len(*sent) != 0 {
+		t.Errorf("sent %d messages, want none", len(*sent))
+	}
+}
+
+func TestProcessQueue_BatchBisectsCulprit(t *testing.T) {
+	origin, clone := setupTrainRepo(t)
+	pushBranch(t, clone, "polecat/nux", map[string]string{"nux.txt": "nux\n"})
+	pushBranch(t, clone, "polecat/toast", map[string]string{"toast.txt": "toast\n"})
+	pushBranch(t, clone, "polecat/slit", map[string]string{"BAD": "boom\n"})
+	pushBranch(t, clone, "polecat/furiosa", map[string]string{"furiosa.txt": "furiosa\n"})
+
+	e, runs, sent := newBatchEngineer(t, clone, 4)
+	mrs := submitBranches(t, e, "polecat/nux", "polecat/toast", "polecat/slit", "polecat/furiosa")
+
+	if _, err := e.ProcessQueue(context.Background()); err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+
+	// Full batch fails, {nux,toast} passes, {slit} fails on top of it,
+	// then {furiosa} passes on top of {nux,toast}.
+	if got := runs(); got != 4 {
+		t.Errorf("test runs = %d, want 4", got)
+	}
+
+	files := gitCmd(t, origin, "ls-tree", "--name-only", "main")
+	for _, f := range []string{"nux.txt", "toast.txt", "furiosa.txt"} {
+		if !strings.Contains(files, f) {
+			t.Errorf("origin/main missing %s; tree:\n%s", f, files)
+		}
+	}
+	if strings.Contains(files, "BAD") {
+		t.Error("culprit landed on origin/main")
+	}
+
+	remaining, err := e.mrQueue.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != mrs[2].ID {
+		t.Fatalf("remaining = %v, want only %s", remaining, mrs[2].ID)
+	}
+	if remaining[0].ClaimedBy != "" {
+		t.Errorf("culprit still claimed by %q", remaining[0].ClaimedBy)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d messages, want 1 MERGE_FAILED", len(*sent))
+	}
+	msg := (*sent)[0]
+	if msg.Subject != "MERGE_FAILED slit" || msg.To != "test-rig/witness" {
+		t.Errorf("message = %q to %q", msg.Subject, msg.To)
+	}
+	if !strings.Contains(msg.Body, "Branch: polecat/slit") || !strings.Contains(msg.Body, "Failure-Type: tests") {
+		t.Errorf("unexpected MERGE_FAILED body:\n%s", msg.Body)
+	}
+}
+
+func TestProcessQueue_BatchBisectDepthExhausted(t *testing.T) {
+	origin, clone := setupTrainRepo(t)
+	pushBranch(t, clone, "polecat/nux", map[string]string{"nux.txt": "nux\n"})
+	pushBranch(t, clone, "polecat/toast", map[string]string{"BAD": "boom\n"})
+	pushBranch(t, clone, "polecat/slit", map[string]string{"slit.txt": "slit\n"})
+
+	e, runs, sent := newBatchEngineer(t, clone, 3)
+	e.config.MaxBisectDepth = 0
+	submitBranches(t, e, "polecat/nux", "polecat/toast", "polecat/slit")
+
+	if _, err := e.ProcessQueue(context.Background()); err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+
+	// Batch run plus one run per MR.
+	if got := runs(); got != 4 {
+		t.Errorf("test runs = %d, want 4", got)
+	}
+	if merges := gitCmd(t, origin, "rev-list", "--merges", "--count", "main"); merges != "2" {
+		t.Errorf("merge commits on main = %s, want 2", merges)
+	}
+	if len(*sent) != 1 || !strings.Contains((*sent)[0].Body, "polecat/toast") {
+		t.Errorf("expected one MERGE_FAILED for polecat/toast, got %d", len(*sent))
+	}
+}
@@ -12,12 +12,19 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/protocol"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/telemetry"
+	"github.com/steveyegge/gastown/internal/testreport"
 )
 
 // MergeQueueConfig holds configuration for the merge queue processor.
@@ -46,15 +53,44 @@ type MergeQueueConfig struct {
 	// RetryFlakyTests is the number of times to retry flaky tests.
 	RetryFlakyTests int `json:"retry_flaky_tests"`
 
+	// TestFormat enables structured test results: "go-json" parses the
+	// test command's stdout as `go test -json`, "junit" reads the JUnit XML
+	// file at TestReport. Empty means only the exit status is used.
+	// With structured results only the failed tests are retried.
+	TestFormat string `json:"test_format"`
+
+	// TestReport is the JUnit XML file written by the test command,
+	// relative to the directory the tests run in.
+	TestReport string `json:"test_report"`
+
+	// TestRetryCommand re-runs a subset of tests. "{tests}" is replaced
+	// with a quoted regexp matching the failed test names. For go-json the
+	// default is TestCommand with -run appended.
+	TestRetryCommand string `json:"test_retry_command"`
+
 	// PollInterval is how often to check for new MRs.
 	PollInterval time.Duration `json:"poll_interval"`
 
 	// MaxConcurrent is the maximum number of MRs to process concurrently.
+	// Each MR is verified in its own scratch worktree; landing on the
+	// target branch is always serialized.
 	MaxConcurrent int `json:"max_concurrent"`
+
+	// BatchSize enables speculative batch merging when greater than 1:
+	// up to BatchSize MRs are merged together and tested once.
+	BatchSize int `json:"batch_size"`
+
+	// MaxBisectDepth bounds how many times a failing batch is halved
+	// looking for the culprit before the remaining suspects are tested
+	// one at a time.
+	MaxBisectDepth int `json:"max_bisect_depth"`
 }
 
 // DefaultMergeQueueConfig returns sensible defaults for merge queue configuration.
+// The merge train and batch defaults are those of config.MergeQueueConfig,
+// so a rig's saved settings mean the same thing here.
 func DefaultMergeQueueConfig() *MergeQueueConfig {
+	defaults := config.DefaultMergeQueueConfig()
 	return &MergeQueueConfig{
 		Enabled:              true,
 		TargetBranch:         "main",
@@ -65,7 +101,9 @@ func DefaultMergeQueueConfig() *MergeQueueConfig {
 		DeleteMergedBranches: true,
 		RetryFlakyTests:      1,
 		PollInterval:         30 * time.Second,
-		MaxConcurrent:        1,
+		MaxConcurrent:        defaults.MaxConcurrent,
+		BatchSize:            defaults.BatchSize,
+		MaxBisectDepth:       defaults.MaxBisectDepth,
 	}
 }
 
@@ -81,8 +119,31 @@ type Engineer struct {
 	output      io.Writer // Output destination for user-facing messages
 	eventLogger *mrqueue.EventLogger
 
+	// claimer prefixes this Engineer's mrqueue claim identities; see
+	// claimerID.
+	claimer string
+
+	// repoMu serializes git operations that touch the shared repository
+	// (fetch, worktree add/remove, push) across concurrent workers.
+	repoMu sync.Mutex
+
+	// train serializes landing on target branches and the bead bookkeeping
+	// that follows, so merges happen one at a time in a merge train.
+	train sync.Mutex
+
+	// inFlight maps MR IDs being processed by this Engineer to their
+	// worker slot.
+	inFlight   map[string]int
+	inFlightMu sync.Mutex
+	workers    sync.WaitGroup
+
+	// sendMail delivers protocol messages to the witness. Nil means a
+	// mail.Router rooted at workDir.
+	sendMail func(*mail.Message) error
+
 	// stopCh is used for graceful shutdown
-	stopCh chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 // NewEngineer creates a new Engineer for the given rig.
@@ -98,16 +159,19 @@ func NewEngineer(r *rig.Rig) *Engineer {
 		git:         git.NewGit(r.Path),
 		config:      cfg,
 		workDir:     r.Path,
-		output:      os.Stdout,
+		output:      &syncWriter{w: os.Stdout},
 		eventLogger: mrqueue.NewEventLoggerFromRig(r.Path),
+		claimer:     claimerID(r.Name),
+		inFlight:    make(map[string]int),
 		stopCh:      make(chan struct{}),
 	}
 }
 
 // SetOutput sets the output writer for user-facing messages.
 // This is useful for testing or redirecting output.
+// Writes are serialized, so w need not be safe for concurrent use.
 func (e *Engineer) SetOutput(w io.Writer) {
-	e.output = w
+	e.output = &syncWriter{w: w}
 }
 
 // LoadConfig loads merge queue configuration from the rig's config.json.
@@ -146,8 +210,13 @@ func (e *Engineer) LoadConfig() error {
 		TestCommand          *string `json:"test_command"`
 		DeleteMergedBranches *bool   `json:"delete_merged_branches"`
 		RetryFlakyTests      *int    `json:"retry_flaky_tests"`
+		TestFormat           *string `json:"test_format"`
+		TestReport           *string `json:"test_report"`
+		TestRetryCommand     *string `json:"test_retry_command"`
 		PollInterval         *string `json:"poll_interval"`
 		MaxConcurrent        *int    `json:"max_concurrent"`
+		BatchSize            *int    `json:"batch_size"`
+		MaxBisectDepth       *int    `json:"max_bisect_depth"`
 	}
 
 	if err := json.Unmarshal(rawConfig.MergeQueue, &mqRaw); err != nil {
@@ -179,9 +248,27 @@ func (e *Engineer) LoadConfig() error {
 	if mqRaw.RetryFlakyTests != nil {
 		e.config.RetryFlakyTests = *mqRaw.RetryFlakyTests
 	}
+	if mqRaw.TestFormat != nil {
+		if *mqRaw.TestFormat != "" && !testreport.ValidFormat(*mqRaw.TestFormat) {
+			return fmt.Errorf("invalid test_format %q", *mqRaw.TestFormat)
+		}
+		e.config.TestFormat = *mqRaw.TestFormat
+	}
+	if mqRaw.TestReport != nil {
+		e.config.TestReport = *mqRaw.TestReport
+	}
+	if mqRaw.TestRetryCommand != nil {
+		e.config.TestRetryCommand = *mqRaw.TestRetryCommand
+	}
 	if mqRaw.MaxConcurrent != nil {
 		e.config.MaxConcurrent = *mqRaw.MaxConcurrent
 	}
+	if mqRaw.BatchSize != nil {
+		e.config.BatchSize = *mqRaw.BatchSize
+	}
+	if mqRaw.MaxBisectDepth != nil {
+		e.config.MaxBisectDepth = *mqRaw.MaxBisectDepth
+	}
 	if mqRaw.PollInterval != nil {
 		dur, err := time.ParseDuration(*mqRaw.PollInterval)
 		if err != nil {
@@ -205,6 +292,19 @@ type ProcessResult struct {
 	Error       string
 	Conflict    bool
 	TestsFailed bool
+
+	// RebaseAttempted is set when the auto_rebase strategy rebased (or
+	// tried to rebase) the source branch while processing the MR.
+	RebaseAttempted bool
+
+	// FailedTests lists the failing tests when TestsFailed is set and the
+	// test output was parsed (see MergeQueueConfig.TestFormat).
+	FailedTests []testreport.Result
+
+	// Requeue is set when the MR was not landed but is not at fault on its
+	// own: the merge train should release it to be verified again rather
+	// than fail it.
+	Requeue bool
 }
 
 // ProcessMR processes a single merge request from a beads issue.
@@ -229,7 +329,10 @@ func (e *Engineer) ProcessMR(ctx context.Context, mr *beads.Issue) ProcessResult
 
 // doMerge performs the actual git merge operation.
 // This is the core merge logic shared by ProcessMR and ProcessMRFromQueue.
-func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue string) ProcessResult {
+func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue string) (result ProcessResult) {
+	rebased := false
+	defer func() { result.RebaseAttempted = rebased }()
+
 	// Step 1: Fetch the source branch from origin
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Fetching branch %s from origin...\n", branch)
 	if err := e.git.FetchBranch("origin", branch); err != nil {
@@ -258,6 +361,23 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Checking for conflicts...\n")
 	remoteBranch := "origin/" + branch
 	conflicts, err := e.git.CheckConflicts(remoteBranch, target)
+	if err == nil && len(conflicts) > 0 && e.autoRebaseEnabled() {
+		// auto_rebase: rebase the source branch onto the updated target and
+		// check again; only a conflicting rebase falls through to assign-back.
+		rebased = true
+		rebaseErr := e.autoRebase(e.git, branch, target)
+		if checkoutErr := e.git.Checkout(target); checkoutErr != nil && rebaseErr == nil {
+			rebaseErr = checkoutErr
+		}
+		if rebaseErr != nil {
+			return ProcessResult{
+				Success:  false,
+				Conflict: true,
+				Error:    fmt.Sprintf("merge conflicts in: %v; auto-rebase failed: %v", conflicts, rebaseErr),
+			}
+		}
+		conflicts, err = e.git.CheckConflicts(remoteBranch, target)
+	}
 	if err != nil {
 		return ProcessResult{
 			Success:  false,
@@ -282,16 +402,14 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 				Success:     false,
 				TestsFailed: true,
 				Error:       result.Error,
+				FailedTests: result.FailedTests,
 			}
 		}
 		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
 	}
 
 	// Step 5: Perform the actual merge
-	mergeMsg := fmt.Sprintf("Merge %s into %s", branch, target)
-	if sourceIssue != "" {
-		mergeMsg = fmt.Sprintf("Merge %s into %s (%s)", branch, target, sourceIssue)
-	}
+	mergeMsg := mergeMessage(branch, target, sourceIssue)
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Merging with message: %s\n", mergeMsg)
 	if err := e.git.MergeNoFF(remoteBranch, mergeMsg); err != nil {
 		if errors.Is(err, git.ErrMergeConflict) {
@@ -335,9 +453,17 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 
 // runTests runs the configured test command and returns the result.
 func (e *Engineer) runTests(ctx context.Context) ProcessResult {
+	return e.runTestsIn(ctx, e.workDir)
+}
+
+// runTestsIn runs the configured test command in dir.
+func (e *Engineer) runTestsIn(ctx context.Context, dir string) ProcessResult {
 	if e.config.TestCommand == "" {
 		return ProcessResult{Success: true}
 	}
+	if e.config.TestFormat != "" {
+		return e.runStructuredTests(ctx, dir)
+	}
 
 	// Run the test command with retries for flaky tests
 	maxRetries := e.config.RetryFlakyTests
@@ -354,7 +480,7 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 		// Note: TestCommand comes from rig's config.json (trusted infrastructure config),
 		// not from PR branches. Shell execution is intentional for flexibility (pipes, etc).
 		cmd := exec.CommandContext(ctx, "sh", "-c", e.config.TestCommand) //nolint:gosec // G204: TestCommand is from trusted rig config
-		cmd.Dir = e.workDir
+		cmd.Dir = dir
 		var stdout, stderr bytes.Buffer
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
@@ -465,8 +591,86 @@ func (e *Engineer) ProcessMRFromQueue(ctx context.Context, mr *mrqueue.MR) Proce
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
 	}
 
+	track := e.trackMR(mr)
+
 	// Use the shared merge logic
-	return e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue)
+	result := e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue)
+	if result.RebaseAttempted {
+		e.recordRebase(mr)
+	}
+	track.finish(result)
+	return result
+}
+
+// mrTracker records one MR's trip through the refinery: its queue wait,
+// processing time and merge outcome, and a "refinery process MR" span.
+type mrTracker struct {
+	rec   telemetry.Recorder
+	rig   string
+	start time.Time
+	span  *telemetry.Span
+}
+
+// trackMR starts tracking mr as it is picked up for processing. Every MR
+// the refinery processes, alone or in a batch, is tracked exactly once.
+func (e *Engineer) trackMR(mr *mrqueue.MR) *mrTracker {
+	t := &mrTracker{
+		rec:   telemetry.At(findTownRoot(e.rig.Path)),
+		rig:   e.rig.Name,
+		start: time.Now(),
+	}
+	// The MR joins its convoy's trace, or its source issue's
+	t.span = t.rec.StartSpan("refinery process MR", telemetry.Labels{"rig": t.rig})
+	t.span.SetTrace(mr.ConvoyID, mr.SourceIssue)
+	t.span.SetAttr("mr", mr.ID)
+	t.span.SetAttr("bead", mr.SourceIssue)
+	t.span.SetAttr("convoy", mr.ConvoyID)
+	if !mr.CreatedAt.IsZero() {
+		t.rec.Observe(telemetry.MetricMRQueueWait, time.Since(mr.CreatedAt).Seconds(), telemetry.Labels{"rig": t.rig})
+	}
+	return t
+}
+
+// finish records the MR's outcome and ends its span.
+func (t *mrTracker) finish(result ProcessResult) {
+	outcome := mergeOutcome(result)
+	t.rec.Count(telemetry.MetricMerges, telemetry.Labels{"rig": t.rig, "result": outcome})
+	t.rec.Observe(telemetry.MetricMRProcess, time.Since(t.start).Seconds(), telemetry.Labels{"rig": t.rig, "result": outcome})
+	t.span.SetAttr("result", outcome)
+	var mergeErr error
+	if !result.Success {
+		mergeErr = errors.New(result.Error)
+	}
+	t.span.Finish(mergeErr)
+}
+
+// mergeOutcome labels a merge result for metrics: merged, requeued,
+// conflict, tests_failed or failed.
+func mergeOutcome(result ProcessResult) string {
+	switch {
+	case result.Success:
+		return "merged"
+	case result.Requeue:
+		return "requeued"
+	case result.Conflict:
+		return "conflict"
+	case result.TestsFailed:
+		return "tests_failed"
+	default:
+		return "failed"
+	}
+}
+
+// recordRebase bumps the MR's rebase count in the queue so repeated
+// rebases feed the retry penalty in priority scoring.
+func (e *Engineer) recordRebase(mr *mrqueue.MR) {
+	count, err := e.mrQueue.RecordRebase(mr.ID)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record rebase for %s: %v\n", mr.ID, err)
+		mr.RebaseCount++
+		return
+	}
+	mr.RebaseCount = count
 }
 
 // handleSuccessFromQueue handles a successful merge from wisp queue.
@@ -475,6 +679,10 @@ func (e *Engineer) handleSuccessFromQueue(mr *mrqueue.MR, result ProcessResult)
 	if err := e.eventLogger.LogMerged(mr, result.MergeCommit); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merged event: %v\n", err)
 	}
+	payload := events.MergePayload(mr.ID, mr.Worker, mr.Branch, "")
+	payload["rig"] = e.rig.Name
+	payload["commit"] = result.MergeCommit
+	_ = events.LogFeed(events.TypeMerged, e.rig.Name+"/refinery", payload)
 
 	// Release merge slot if this was a conflict resolution
 	// The slot is held while conflict resolution is in progress
@@ -561,6 +769,9 @@ func (e *Engineer) handleFailureFromQueue(mr *mrqueue.MR, result ProcessResult)
 	if err := e.eventLogger.LogMergeFailed(mr, result.Error); err != nil {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_failed event: %v\n", err)
 	}
+	payload := events.MergePayload(mr.ID, mr.Worker, mr.Branch, result.Error)
+	payload["rig"] = e.rig.Name
+	_ = events.LogFeed(events.TypeMergeFailed, e.rig.Name+"/refinery", payload)
 
 	// If this was a conflict, create a conflict-resolution task for dispatch
 	// and block the MR until the task is resolved (non-blocking delegation)
@@ -579,6 +790,11 @@ func (e *Engineer) handleFailureFromQueue(mr *mrqueue.MR, result ProcessResult)
 		}
 	}
 
+	// Test failures go back to the polecat via the witness
+	if result.TestsFailed {
+		e.notifyMergeFailed(mr, result)
+	}
+
 	// Log the failure - MR stays in queue but may be blocked
 	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Failed: %s - %s\n", mr.ID, result.Error)
 	if mr.BlockedBy != "" {
@@ -588,6 +804,29 @@ func (e *Engineer) handleFailureFromQueue(mr *mrqueue.MR, result ProcessResult)
 	}
 }
 
+// notifyMergeFailed sends MERGE_FAILED to the rig's witness so the polecat
+// is sent back to fix its branch. Failing tests, when known, are included
+// with an excerpt of their output.
+func (e *Engineer) notifyMergeFailed(mr *mrqueue.MR, result ProcessResult) {
+	var tests []protocol.FailedTest
+	for _, ft := range result.FailedTests {
+		tests = append(tests, protocol.FailedTest{
+			Package: ft.Package,
+			Name:    ft.Name,
+			Excerpt: testreport.Excerpt(ft.Output, failedTestExcerptLines),
+		})
+	}
+	msg := protocol.NewMergeFailedMessageWithTests(e.rig.Name, mr.Worker, mr.Branch, mr.SourceIssue, mr.Target, "tests", result.Error, tests)
+
+	send := e.sendMail
+	if send == nil {
+		send = mail.NewRouter(e.workDir).Send
+	}
+	if err := send(msg); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to send MERGE_FAILED for %s: %v\n", mr.ID, err)
+	}
+}
+
 // createConflictResolutionTask creates a dispatchable task for resolving merge conflicts.
 // This task will be picked up by bd ready and can be dispatched to an available polecat.
 // Returns the created task's ID for blocking the MR until resolution.
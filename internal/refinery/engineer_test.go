@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/rig"
 )
 
@@ -56,6 +57,40 @@ func TestEngineer_LoadConfig_NoFile(t *testing.T) {
 	}
 }
 
+func TestEngineer_LoadConfig_RoundTripsRigSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Defaults saved by the config package load as the refinery's defaults.
+	settings := config.NewRigSettings()
+	if err := config.SaveRigSettings(filepath.Join(tmpDir, "config.json"), settings); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	want := DefaultMergeQueueConfig()
+	if e.config.MaxConcurrent != want.MaxConcurrent || e.config.BatchSize != want.BatchSize || e.config.MaxBisectDepth != want.MaxBisectDepth {
+		t.Errorf("loaded train settings %d/%d/%d, want defaults %d/%d/%d",
+			e.config.MaxConcurrent, e.config.BatchSize, e.config.MaxBisectDepth,
+			want.MaxConcurrent, want.BatchSize, want.MaxBisectDepth)
+	}
+
+	// Zero values are saved, not dropped in favor of the refinery defaults.
+	settings.MergeQueue.BatchSize = 0
+	settings.MergeQueue.MaxBisectDepth = 0
+	if err := config.SaveRigSettings(filepath.Join(tmpDir, "config.json"), settings); err != nil {
+		t.Fatal(err)
+	}
+	e = NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+	if err := e.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if e.config.BatchSize != 0 || e.config.MaxBisectDepth != 0 {
+		t.Errorf("BatchSize = %d, MaxBisectDepth = %d; want the saved zeros", e.config.BatchSize, e.config.MaxBisectDepth)
+	}
+}
+
 func TestEngineer_LoadConfig_WithMergeQueue(t *testing.T) {
 	// Create a temp directory with config.json
 	tmpDir, err := os.MkdirTemp("", "engineer-test-*")
@@ -70,12 +105,14 @@ func TestEngineer_LoadConfig_WithMergeQueue(t *testing.T) {
 		"version": 1,
 		"name":    "test-rig",
 		"merge_queue": map[string]interface{}{
-			"enabled":        true,
-			"target_branch":  "develop",
-			"poll_interval":  "10s",
-			"max_concurrent": 2,
-			"run_tests":      false,
-			"test_command":   "make test",
+			"enabled":          true,
+			"target_branch":    "develop",
+			"poll_interval":    "10s",
+			"max_concurrent":   2,
+			"run_tests":        false,
+			"test_command":     "make test",
+			"batch_size":       8,
+			"max_bisect_depth": 2,
 		},
 	}
 
@@ -105,6 +142,12 @@ func TestEngineer_LoadConfig_WithMergeQueue(t *testing.T) {
 	if e.config.MaxConcurrent != 2 {
 		t.Errorf("expected MaxConcurrent 2, got %d", e.config.MaxConcurrent)
 	}
+	if e.config.BatchSize != 8 {
+		t.Errorf("expected BatchSize 8, got %d", e.config.BatchSize)
+	}
+	if e.config.MaxBisectDepth != 2 {
+		t.Errorf("expected MaxBisectDepth 2, got %d", e.config.MaxBisectDepth)
+	}
 	if e.config.RunTests != false {
 		t.Errorf("expected RunTests false, got %v", e.config.RunTests)
 	}
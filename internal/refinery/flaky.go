@@ -0,0 +1,140 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/testreport"
+)
+
+// flakyLedgerFile is the rig's flaky-test ledger, under .runtime.
+const flakyLedgerFile = "flaky-tests.json"
+
+// FlakyTest is a flaky-test ledger entry.
+type FlakyTest struct {
+	// ID is the test's package-qualified name.
+	ID string `json:"id"`
+
+	// Flakes counts runs where the test failed and then passed on retry.
+	Flakes int `json:"flakes"`
+
+	// Failures counts runs where the test failed on every attempt.
+	Failures int `json:"failures"`
+
+	LastFlake   time.Time `json:"last_flake,omitempty"`
+	LastFailure time.Time `json:"last_failure,omitempty"`
+}
+
+// flakyLedgerPath returns the path of the rig's flaky-test ledger.
+func (e *Engineer) flakyLedgerPath() string {
+	return filepath.Join(constants.RigRuntimePath(e.rig.Path), flakyLedgerFile)
+}
+
+// recordTestOutcomes adds the flakes and hard failures of one test run to
+// the ledger. The ledger is shared by all refinery workers and processes
+// of the rig, so updates happen under a file lock.
+func (e *Engineer) recordTestOutcomes(flaky, failed []testreport.Result) {
+	if len(flaky) == 0 && len(failed) == 0 {
+		return
+	}
+	if err := e.updateFlakyLedger(func(tests map[string]*FlakyTest) {
+		now := time.Now()
+		entry := func(id string) *FlakyTest {
+			t, ok := tests[id]
+			if !ok {
+				t = &FlakyTest{ID: id}
+				tests[id] = t
+			}
+			return t
+		}
+		for _, r := range flaky {
+			t := entry(r.ID())
+			t.Flakes++
+			t.LastFlake = now
+		}
+		for _, r := range failed {
+			t := entry(r.ID())
+			t.Failures++
+			t.LastFailure = now
+		}
+	}); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to update flaky-test ledger: %v\n", err)
+	}
+}
+
+// FlakyTests returns the rig's flaky-test ledger, most flaky first.
+func (e *Engineer) FlakyTests() ([]FlakyTest, error) {
+	tests, err := loadFlakyLedger(e.flakyLedgerPath())
+	if err != nil {
+		return nil, err
+	}
+	list := make([]FlakyTest, 0, len(tests))
+	for _, t := range tests {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Flakes != list[j].Flakes {
+			return list[i].Flakes > list[j].Flakes
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+// updateFlakyLedger applies fn to the ledger under its file lock.
+func (e *Engineer) updateFlakyLedger(fn func(map[string]*FlakyTest)) error {
+	path := e.flakyLedgerPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking ledger: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	tests, err := loadFlakyLedger(path)
+	if err != nil {
+		return err
+	}
+	fn(tests)
+
+	data, err := json.MarshalIndent(struct {
+		Tests map[string]*FlakyTest `json:"tests"`
+	}{tests}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadFlakyLedger reads the ledger at path; a missing file is empty.
+func loadFlakyLedger(path string) (map[string]*FlakyTest, error) {
+	var ledger struct {
+		Tests map[string]*FlakyTest `json:"tests"`
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]*FlakyTest), nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if ledger.Tests == nil {
+		ledger.Tests = make(map[string]*FlakyTest)
+	}
+	return ledger.Tests, nil
+}
@@ -0,0 +1,45 @@
+package refinery
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+)
+
+// autoRebaseEnabled reports whether conflicts should be handled by
+// rebasing the source branch rather than assigning the MR back.
+func (e *Engineer) autoRebaseEnabled() bool {
+	return e.config.OnConflict == config.OnConflictAutoRebase
+}
+
+// autoRebase rebases origin/<branch> onto origin/<target> in g's working
+// tree and force-pushes the result back to origin/<branch>, so the
+// polecat branch itself carries the rebased history.
+//
+// On a rebase conflict the rebase is aborted and an error is returned;
+// the caller falls back to the conflict-resolution task. g is left on a
+// detached HEAD either way, so callers must check out what they need next.
+func (e *Engineer) autoRebase(g *git.Git, branch, target string) error {
+	remoteBranch := "origin/" + branch
+	remoteTarget := "origin/" + target
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Auto-rebasing %s onto %s...\n", branch, remoteTarget)
+	if err := g.Checkout(remoteBranch); err != nil {
+		return fmt.Errorf("checkout %s: %w", remoteBranch, err)
+	}
+	if err := g.Rebase(remoteTarget); err != nil {
+		_ = g.AbortRebase()
+		return fmt.Errorf("rebase onto %s: %w", remoteTarget, err)
+	}
+
+	e.repoMu.Lock()
+	err := g.Push("origin", "HEAD:"+branch, true)
+	e.repoMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("pushing rebased %s: %w", branch, err)
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Rebased %s onto %s\n", branch, remoteTarget)
+	return nil
+}
@@ -0,0 +1,209 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/connection"
+	"github.com/steveyegge/gastown/internal/testreport"
+)
+
+// failedTestExcerptLines is how much of a failing test's output is kept
+// for error messages and MERGE_FAILED payloads.
+const failedTestExcerptLines = 20
+
+// maxNamedFailures caps how many test names are spelled out in
+// ProcessResult.Error.
+const maxNamedFailures = 5
+
+// plainGoTest matches a test command that is a single go test invocation
+// with plain arguments: no pipes, redirects, command lists, substitutions
+// or quoting. Only such a command can be narrowed by appending -run.
+var plainGoTest = regexp.MustCompile(`^\s*go\s+test(\s+[\w./=,:@+-]+)*\s*$`)
+
+// runStructuredTests runs the test command in dir and parses its results
+// according to TestFormat. On failure, only the failed tests are retried
+// (up to RetryFlakyTests attempts in total); tests that pass on a retry
+// are recorded as flakes in the rig's flaky-test ledger.
+func (e *Engineer) runStructuredTests(ctx context.Context, dir string) ProcessResult {
+	maxAttempts := e.config.RetryFlakyTests
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var failing, flaky []testreport.Result
+	var lastErr error
+	attempt := 1
+	for ; attempt <= maxAttempts; attempt++ {
+		command := e.config.TestCommand
+		narrowed := false
+		if attempt > 1 {
+			if retry := e.retryCommand(failing); retry != "" {
+				command = retry
+				narrowed = true
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Retrying %d failed tests (attempt %d/%d)...\n", len(failing), attempt, maxAttempts)
+			} else {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Retrying tests (attempt %d/%d)...\n", attempt, maxAttempts)
+			}
+		}
+
+		report, err := e.runTestReport(ctx, dir, command)
+		if ctx.Err() != nil {
+			return ProcessResult{Success: false, Error: "test run canceled"}
+		}
+		lastErr = err
+
+		if attempt == 1 || len(failing) == 0 {
+			if err == nil {
+				break
+			}
+			if report != nil {
+				failing = report.Failed()
+			}
+			continue
+		}
+
+		// Sort the previous failures into flakes and real failures.
+		var still []testreport.Result
+		for _, prev := range failing {
+			res, ran := testreport.Result{}, false
+			if report != nil {
+				res, ran = report.Get(prev.ID())
+			}
+			switch {
+			case ran && res.Status == testreport.StatusPass, !ran && err == nil:
+				flaky = append(flaky, prev)
+			case ran:
+				still = append(still, res)
+			default:
+				still = append(still, prev)
+			}
+		}
+		failing = still
+		if len(failing) == 0 {
+			if err == nil || narrowed {
+				lastErr = nil
+				break
+			}
+			// A full re-run failed on tests that passed before.
+			if report != nil {
+				failing = report.Failed()
+			}
+		}
+	}
+	if attempt > maxAttempts {
+		attempt = maxAttempts
+	}
+
+	if lastErr == nil {
+		failing = nil
+	}
+	e.recordTestOutcomes(flaky, failing)
+	for _, f := range flaky {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Flaky test passed on retry: %s\n", f.ID())
+	}
+	if lastErr == nil {
+		return ProcessResult{Success: true}
+	}
+
+	if len(failing) == 0 {
+		return ProcessResult{
+			Success:     false,
+			TestsFailed: true,
+			Error:       fmt.Sprintf("tests failed after %d attempts: %v", attempt, lastErr),
+		}
+	}
+	return ProcessResult{
+		Success:     false,
+		TestsFailed: true,
+		Error:       fmt.Sprintf("%d tests failed after %d attempts: %s", len(failing), attempt, describeFailures(failing)),
+		FailedTests: failing,
+	}
+}
+
+// runTestReport runs command in dir and parses its results. The report is
+// nil if the output could not be parsed; err is the command's exit error.
+func (e *Engineer) runTestReport(ctx context.Context, dir, command string) (*testreport.Report, error) {
+	var reportPath string
+	if e.config.TestFormat == testreport.FormatJUnit && e.config.TestReport != "" {
+		reportPath = e.config.TestReport
+		if !filepath.IsAbs(reportPath) {
+			reportPath = filepath.Join(dir, reportPath)
+		}
+		_ = os.Remove(reportPath) // never parse a stale report
+	}
+
+	// Note: TestCommand comes from rig's config.json (trusted infrastructure config),
+	// not from PR branches. Shell execution is intentional for flexibility (pipes, etc).
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // G204: command is from trusted rig config
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	data := stdout.Bytes()
+	if reportPath != "" {
+		var err error
+		if data, err = os.ReadFile(reportPath); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: reading test report: %v\n", err)
+			return nil, runErr
+		}
+	}
+	report, err := testreport.Parse(e.config.TestFormat, data)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: parsing %s test output: %v\n", e.config.TestFormat, err)
+		return nil, runErr
+	}
+	return report, runErr
+}
+
+// retryCommand returns the command that re-runs only the failed tests, or
+// "" if they cannot be selected (no retry command for the format, or a
+// package-level failure such as a build error). Without TestRetryCommand,
+// go test output is narrowed by appending -run, and only when TestCommand
+// is a plain go test invocation; anything else is re-run in full.
+func (e *Engineer) retryCommand(failed []testreport.Result) string {
+	if len(failed) == 0 {
+		return ""
+	}
+	for _, f := range failed {
+		if f.Name == "" {
+			return ""
+		}
+	}
+
+	names := testreport.TopLevelNames(failed)
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	pattern := connection.ShellQuote("^(" + strings.Join(names, "|") + ")$")
+
+	switch {
+	case e.config.TestRetryCommand != "":
+		return strings.ReplaceAll(e.config.TestRetryCommand, "{tests}", pattern)
+	case e.config.TestFormat == testreport.FormatGoJSON && plainGoTest.MatchString(e.config.TestCommand):
+		return strings.TrimSpace(e.config.TestCommand) + " -run " + pattern
+	default:
+		return ""
+	}
+}
+
+// describeFailures names the failing tests for an error message.
+func describeFailures(failed []testreport.Result) string {
+	var names []string
+	for i, f := range failed {
+		if i == maxNamedFailures {
+			names = append(names, fmt.Sprintf("and %d more", len(failed)-i))
+			break
+		}
+		names = append(names, f.ID())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,211 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/protocol"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/testreport"
+)
+
+// fakeGoTest is a stand-in for `go test -json`. TestFlaky fails on the
+// first run only; TestBroken fails unless BROKEN=0. Arguments are logged
+// to args.log so tests can check what was re-run.
+const fakeGoTest = `#!/bin/sh
+echo "args:$*" >> args.log
+pkg=example.com/p
+status=0
+emit() {
+	if [ -n "$3" ]; then
+		printf '{"Action":"output","Package":"%s","Test":"%s","Output":"%s\\n"}\n' "$pkg" "$1" "$3"
+	fi
+	printf '{"Action":"%s","Package":"%s","Test":"%s"}\n' "$2" "$pkg" "$1"
+	[ "$2" = fail ] && status=1
+}
+runs() { [ -z "$PATTERN" ] || echo "$1" | grep -Eq "$PATTERN"; }
+[ "$1" = "-run" ] && PATTERN="$2"
+runs TestOK && emit TestOK pass
+if runs TestFlaky; then
+	if [ -e flaky.state ]; then emit TestFlaky pass; else touch flaky.state; emit TestFlaky fail "timing out"; fi
+fi
+if runs TestBroken; then
+	if [ "$BROKEN" = 0 ]; then emit TestBroken pass; else emit TestBroken fail "p_test.go:9: want 1, got 2"; fi
+fi
+printf '{"Action":"%s","Package":"%s"}\n' "$([ $status = 0 ] && echo pass || echo fail)" "$pkg"
+exit $status
+`
+
+func newStructuredEngineer(t *testing.T, format string) (*Engineer, string, *bytes.Buffer) {
+	t.Helper()
+	dir := t.TempDir()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.config.RunTests = true
+	e.config.TestFormat = format
+	e.config.RetryFlakyTests = 2
+	var out bytes.Buffer
+	e.SetOutput(&out)
+	return e, dir, &out
+}
+
+func TestRunTests_GoJSONRetriesOnlyFailed(t *testing.T) {
+	e, dir, out := newStructuredEngineer(t, testreport.FormatGoJSON)
+	writeFile(t, filepath.Join(dir, "fake-go-test"), fakeGoTest)
+	e.config.TestCommand = "sh fake-go-test"
+	// Not a plain go test call, so failed tests are selected by template.
+	e.config.TestRetryCommand = "sh fake-go-test -run {tests}"
+
+	result := e.runTestsIn(context.Background(), dir)
+	if result.Success || !result.TestsFailed {
+		t.Fatalf("result = %+v, want test failure\n%s", result, out.String())
+	}
+	if len(result.FailedTests) != 1 || result.FailedTests[0].ID() != "example.com/p.TestBroken" {
+		t.Fatalf("FailedTests = %+v, want only TestBroken", result.FailedTests)
+	}
+	if !strings.Contains(result.FailedTests[0].Output, "want 1, got 2") {
+		t.Errorf("TestBroken output = %q", result.FailedTests[0].Output)
+	}
+	if !strings.Contains(result.Error, "1 tests failed after 2 attempts: example.com/p.TestBroken") {
+		t.Errorf("Error = %q", result.Error)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args.log"))
+	lines := strings.Split(strings.TrimSpace(string(args)), "\n")
+	if len(lines) != 2 || lines[0] != "args:" || lines[1] != "args:-run ^(TestBroken|TestFlaky)$" {
+		t.Errorf("runs = %q, want full run then retry of the two failures", lines)
+	}
+
+	flaky, err := e.FlakyTests()
+	if err != nil {
+		t.Fatalf("FlakyTests: %v", err)
+	}
+	if len(flaky) != 2 || flaky[0].ID != "example.com/p.TestFlaky" || flaky[0].Flakes != 1 ||
+		flaky[1].ID != "example.com/p.TestBroken" || flaky[1].Failures != 1 {
+		t.Errorf("ledger = %+v", flaky)
+	}
+}
+
+func TestRunTests_GoJSONFlakePasses(t *testing.T) {
+	e, dir, out := newStructuredEngineer(t, testreport.FormatGoJSON)
+	writeFile(t, filepath.Join(dir, "fake-go-test"), fakeGoTest)
+	e.config.TestCommand = "BROKEN=0 sh fake-go-test"
+
+	for run := 1; run <= 2; run++ {
+		_ = os.Remove(filepath.Join(dir, "flaky.state"))
+		if result := e.runTestsIn(context.Background(), dir); !result.Success {
+			t.Fatalf("run %d: result = %+v\n%s", run, result, out.String())
+		}
+	}
+
+	// The ledger accumulates across runs.
+	flaky, err := e.FlakyTests()
+	if err != nil {
+		t.Fatalf("FlakyTests: %v", err)
+	}
+	if len(flaky) != 1 || flaky[0].Flakes != 2 || flaky[0].Failures != 0 {
+		t.Errorf("ledger = %+v, want TestFlaky with 2 flakes", flaky)
+	}
+}
+
+func TestRunTests_JUnitReport(t *testing.T) {
+	e, dir, out := newStructuredEngineer(t, testreport.FormatJUnit)
+	e.config.TestReport = "report.xml"
+	e.config.TestCommand = `echo run >> runs.log; cat > report.xml <<'EOF'
+<testsuite name="widgets">
+  <testcase classname="widgets.Spec" name="renders"/>
+  <testcase classname="widgets.Spec" name="resizes"><failure message="expected 10">got 12</failure></testcase>
+</testsuite>
+EOF
+exit 1`
+
+	result := e.runTestsIn(context.Background(), dir)
+	if result.Success {
+		t.Fatalf("expected failure\n%s", out.String())
+	}
+	if len(result.FailedTests) != 1 || result.FailedTests[0].ID() != "widgets.Spec.resizes" {
+		t.Errorf("FailedTests = %+v", result.FailedTests)
+	}
+
+	// No retry command for JUnit, so the whole suite is re-run.
+	runs, _ := os.ReadFile(filepath.Join(dir, "runs.log"))
+	if n := strings.Count(string(runs), "run"); n != 2 {
+		t.Errorf("suite runs = %d, want 2", n)
+	}
+}
+
+func TestRetryCommand(t *testing.T) {
+	e, _, _ := newStructuredEngineer(t, testreport.FormatGoJSON)
+	e.config.TestCommand = "go test -json ./..."
+	failed := []testreport.Result{
+		{Package: "p", Name: "TestA/sub", Status: testreport.StatusFail},
+		{Package: "q", Name: "TestB", Status: testreport.StatusFail},
+	}
+
+	if got := e.retryCommand(failed); got != "go test -json ./... -run '^(TestA|TestB)$'" {
+		t.Errorf("retryCommand = %q", got)
+	}
+
+	// Commands that are not a single plain go test call are re-run in full.
+	for _, command := range []string{
+		"go test -json ./... | tee test.log",
+		"make test && go test -json ./...",
+		"go test -json ./... > out.json; cat out.json",
+		"cd sub && go test -json ./...",
+		"go test -json $(go list ./...)",
+		"gotestsum --jsonfile - ./...",
+	} {
+		e.config.TestCommand = command
+		if got := e.retryCommand(failed); got != "" {
+			t.Errorf("retryCommand for %q = %q, want full re-run", command, got)
+		}
+	}
+
+	e.config.TestRetryCommand = "make test-only T={tests}"
+	if got := e.retryCommand(failed); got != "make test-only T='^(TestA|TestB)$'" {
+		t.Errorf("retryCommand with template = %q", got)
+	}
+
+	buildFailure := append(failed, testreport.Result{Package: "r", Status: testreport.StatusFail})
+	if got := e.retryCommand(buildFailure); got != "" {
+		t.Errorf("retryCommand with package failure = %q, want full re-run", got)
+	}
+}
+
+func TestNotifyMergeFailed_IncludesTests(t *testing.T) {
+	e, _, _ := newStructuredEngineer(t, testreport.FormatGoJSON)
+	var sent []*mail.Message
+	e.sendMail = func(msg *mail.Message) error {
+		sent = append(sent, msg)
+		return nil
+	}
+
+	mr := &mrqueue.MR{ID: "mr-1", Branch: "polecat/nux", Target: "main", Worker: "nux", SourceIssue: "gt-abc"}
+	e.notifyMergeFailed(mr, ProcessResult{
+		TestsFailed: true,
+		Error:       "1 tests failed after 2 attempts: example.com/p.TestBroken",
+		FailedTests: []testreport.Result{{
+			Package: "example.com/p",
+			Name:    "TestBroken",
+			Status:  testreport.StatusFail,
+			Output:  "=== RUN   TestBroken\n    p_test.go:9: want 1, got 2\n",
+		}},
+	})
+
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	payload := protocol.ParseMergeFailedPayload(sent[0].Body)
+	if payload.Polecat != "nux" || payload.FailureType != "tests" {
+		t.Errorf("payload = %+v", payload)
+	}
+	if len(payload.FailedTests) != 1 || payload.FailedTests[0].Name != "TestBroken" ||
+		!strings.Contains(payload.FailedTests[0].Excerpt, "want 1, got 2") {
+		t.Errorf("FailedTests = %+v", payload.FailedTests)
+	}
+}
@@ -0,0 +1,482 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// Concurrent processing model
+//
+// Up to MaxConcurrent MRs are in flight at once. Each one is claimed in the
+// mrqueue (so other refinery processes skip it), fetched, and merged into
+// a scratch worktree detached at origin/<target>. The conflict check and
+// the test run happen there, in parallel with other MRs. Claim identities
+// carry the hostname and pid, so two refinery processes on one rig never
+// share one, and claims are renewed while the MR is in flight so a long
+// test run doesn't let another process take it over.
+//
+// Landing is a merge train: one MR at a time takes the train lock, rebuilds
+// its merge on the current target tip if another MR landed in the meantime,
+// and pushes the merge commit to origin/<target>. A rebuilt merge is
+// tested again before it is pushed; if it fails, the MR is requeued to be
+// verified afresh on the new tip rather than landed. Bead bookkeeping for
+// the MR also runs under the train lock.
+
+// mergeWorktreesDir is where per-MR scratch worktrees live, under the
+// rig's .runtime directory.
+const mergeWorktreesDir = "merge-worktrees"
+
+// syncWriter serializes writes from concurrent workers.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// verifiedMerge is an MR merged and tested in its scratch worktree,
+// waiting for its turn on the merge train.
+type verifiedMerge struct {
+	git  *git.Git
+	path string
+	base string // target SHA the merge was built and tested on
+}
+
+// maxConcurrent returns the effective worker count.
+func (e *Engineer) maxConcurrent() int {
+	if e.config.MaxConcurrent < 1 {
+		return 1
+	}
+	return e.config.MaxConcurrent
+}
+
+// claimRefreshInterval is how often in-flight claims are renewed. It is
+// well inside mrqueue.ClaimStaleTimeout. A variable so tests can shorten it.
+var claimRefreshInterval = mrqueue.ClaimStaleTimeout / 3
+
+// claimerID returns this process's mrqueue claim identity on a rig:
+// <rig>/refinery/<host>:<pid>.
+func claimerID(rigName string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s/refinery/%s:%d", rigName, host, os.Getpid())
+}
+
+// workerID returns the mrqueue claim identity for worker slot n.
+func (e *Engineer) workerID(n int) string {
+	return fmt.Sprintf("%s/%d", e.claimer, n)
+}
+
+// holdClaims renews the claims on mrs (held by each MR's ClaimedBy) every
+// claimRefreshInterval until the returned stop function is called. Stop
+// must be called before the MRs are released or removed from the queue.
+func (e *Engineer) holdClaims(mrs ...*mrqueue.MR) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(claimRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			for _, mr := range mrs {
+				if err := e.mrQueue.Refresh(mr.ID, mr.ClaimedBy); err != nil {
+					_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to renew claim on %s: %v\n", mr.ID, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// Run polls the queue every PollInterval and keeps up to MaxConcurrent
+// MRs in flight until ctx is canceled or Stop is called. In-flight MRs
+// are allowed to finish before Run returns.
+func (e *Engineer) Run(ctx context.Context) error {
+	interval := e.config.PollInterval
+	if interval <= 0 {
+		interval = DefaultMergeQueueConfig().PollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer e.workers.Wait()
+
+	for {
+		var err error
+		if e.batchSize() > 1 {
+			_, err = e.processBatch(ctx, nil)
+		} else {
+			_, err = e.dispatch(ctx, nil)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: listing ready MRs: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.stopCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop asks a running Run loop to exit.
+func (e *Engineer) Stop() {
+	e.stopOnce.Do(func() { close(e.stopCh) })
+}
+
+// ProcessQueue dispatches the ready MRs (up to MaxConcurrent at a time,
+// or in batches of BatchSize when batching is on) and waits for all of
+// them to finish. Each MR is attempted at most once per call. Returns the
+// number of MRs processed.
+func (e *Engineer) ProcessQueue(ctx context.Context) (int, error) {
+	attempted := make(map[string]bool)
+	for ctx.Err() == nil {
+		var n int
+		var err error
+		if e.batchSize() > 1 {
+			n, err = e.processBatch(ctx, attempted)
+		} else {
+			n, err = e.dispatch(ctx, attempted)
+			e.workers.Wait()
+		}
+		if err != nil {
+			return len(attempted), err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return len(attempted), nil
+}
+
+// dispatch claims ready MRs into free worker slots and starts a goroutine
+// for each, skipping any MR in skip. Dispatched MR IDs are added to skip
+// when it is non-nil. Returns how many MRs were dispatched.
+func (e *Engineer) dispatch(ctx context.Context, skip map[string]bool) (int, error) {
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		return 0, err
+	}
+
+	e.inFlightMu.Lock()
+	defer e.inFlightMu.Unlock()
+
+	dispatched := 0
+	for _, mr := range ready {
+		if len(e.inFlight) >= e.maxConcurrent() {
+			break
+		}
+		if _, busy := e.inFlight[mr.ID]; busy || skip[mr.ID] {
+			continue
+		}
+
+		slot := e.freeSlotLocked()
+		workerID := e.workerID(slot)
+		if err := e.mrQueue.Claim(mr.ID, workerID); err != nil {
+			if !errors.Is(err, mrqueue.ErrAlreadyClaimed) && !errors.Is(err, mrqueue.ErrNotFound) {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to claim %s: %v\n", mr.ID, err)
+			}
+			continue
+		}
+		mr.ClaimedBy = workerID
+
+		e.inFlight[mr.ID] = slot
+		if skip != nil {
+			skip[mr.ID] = true
+		}
+		e.workers.Add(1)
+		dispatched++
+		go func(mr *mrqueue.MR) {
+			defer e.workers.Done()
+			defer func() {
+				e.inFlightMu.Lock()
+				delete(e.inFlight, mr.ID)
+				e.inFlightMu.Unlock()
+			}()
+			e.processClaimed(ctx, mr)
+		}(mr)
+	}
+
+	return dispatched, nil
+}
+
+// freeSlotLocked returns the lowest worker slot number not in use.
+// Caller holds inFlightMu.
+func (e *Engineer) freeSlotLocked() int {
+	used := make(map[int]bool, len(e.inFlight))
+	for _, slot := range e.inFlight {
+		used[slot] = true
+	}
+	n := 1
+	for used[n] {
+		n++
+	}
+	return n
+}
+
+// processClaimed verifies a claimed MR in its own worktree, then lands it
+// on the merge train. The claim is released if the MR is not merged.
+func (e *Engineer) processClaimed(ctx context.Context, mr *mrqueue.MR) {
+	_, _ = fmt.Fprintf(e.output, "[Engineer] %s: processing %s -> %s (worker %s)\n", mr.ID, mr.Branch, mr.Target, mr.ClaimedBy)
+
+	if err := e.eventLogger.LogMergeStarted(mr); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
+	}
+
+	stopHolding := e.holdClaims(mr)
+	track := e.trackMR(mr)
+	verified, result := e.verifyInWorktree(ctx, mr)
+	if verified != nil {
+		defer e.removeWorktree(verified.path)
+	}
+
+	e.train.Lock()
+	defer e.train.Unlock()
+
+	if result.Success {
+		rebasedBefore := result.RebaseAttempted
+		result = e.land(ctx, mr, verified)
+		result.RebaseAttempted = result.RebaseAttempted || rebasedBefore
+	}
+	if result.RebaseAttempted {
+		e.recordRebase(mr)
+	}
+	track.finish(result)
+	stopHolding()
+
+	if result.Success {
+		e.handleSuccessFromQueue(mr, result)
+		return
+	}
+	if result.Requeue {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] %s: requeued: %s\n", mr.ID, result.Error)
+		if err := e.mrQueue.Release(mr.ID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to release claim on %s: %v\n", mr.ID, err)
+		}
+		return
+	}
+
+	e.handleFailureFromQueue(mr, result)
+	if err := e.mrQueue.Release(mr.ID); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to release claim on %s: %v\n", mr.ID, err)
+	}
+}
+
+// verifyInWorktree builds the merge of mr into its target in a scratch
+// worktree and runs the tests there. The returned worktree (if non-nil)
+// must be removed by the caller.
+func (e *Engineer) verifyInWorktree(ctx context.Context, mr *mrqueue.MR) (*verifiedMerge, ProcessResult) {
+	remoteTarget := "origin/" + mr.Target
+	path := filepath.Join(constants.RigRuntimePath(e.rig.Path), mergeWorktreesDir, mr.ID)
+
+	e.repoMu.Lock()
+	err := e.fetchMR(mr)
+	if err == nil {
+		_ = os.RemoveAll(path) // leftover from a crashed worker
+		_ = e.git.WorktreePrune()
+		if mkErr := os.MkdirAll(filepath.Dir(path), 0755); mkErr != nil {
+			err = mkErr
+		} else if wtErr := e.git.WorktreeAddDetached(path, remoteTarget); wtErr != nil {
+			err = fmt.Errorf("creating worktree: %w", wtErr)
+		}
+	}
+	e.repoMu.Unlock()
+	if err != nil {
+		return nil, ProcessResult{Success: false, Error: err.Error()}
+	}
+
+	v := &verifiedMerge{git: git.NewGit(path), path: path}
+	if v.base, err = v.git.Rev("HEAD"); err != nil {
+		return v, ProcessResult{Success: false, Error: fmt.Sprintf("resolving %s: %v", remoteTarget, err)}
+	}
+
+	merged := e.mergeInWorktree(v, mr)
+	if !merged.Success {
+		return v, merged
+	}
+
+	if result := e.testInWorktree(ctx, v, mr); !result.Success {
+		result.RebaseAttempted = merged.RebaseAttempted
+		return v, result
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] %s: verified on %s@%s, waiting for merge train\n", mr.ID, mr.Target, shortSHA(v.base))
+	return v, ProcessResult{Success: true, RebaseAttempted: merged.RebaseAttempted}
+}
+
+// testInWorktree runs the configured test command against the worktree.
+func (e *Engineer) testInWorktree(ctx context.Context, v *verifiedMerge, mr *mrqueue.MR) ProcessResult {
+	if !e.config.RunTests || e.config.TestCommand == "" {
+		return ProcessResult{Success: true}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] %s: running tests: %s\n", mr.ID, e.config.TestCommand)
+	if result := e.runTestsIn(ctx, v.path); !result.Success {
+		return ProcessResult{Success: false, TestsFailed: true, Error: result.Error, FailedTests: result.FailedTests}
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] %s: tests passed\n", mr.ID)
+	return ProcessResult{Success: true}
+}
+
+// fetchMR fetches the MR's source and target branches. Caller holds repoMu.
+func (e *Engineer) fetchMR(mr *mrqueue.MR) error {
+	if err := e.git.FetchBranch("origin", mr.Branch); err != nil {
+		return fmt.Errorf("failed to fetch branch %s: %v", mr.Branch, err)
+	}
+	if err := e.git.FetchBranch("origin", mr.Target); err != nil {
+		return fmt.Errorf("failed to fetch target %s: %v", mr.Target, err)
+	}
+	return nil
+}
+
+// mergeInWorktree merges origin/<branch> into the worktree's HEAD,
+// reporting conflicting files if the merge is not clean. With the
+// auto_rebase strategy a conflicting branch is first rebased onto the
+// target; only a conflicting rebase is reported as a conflict.
+func (e *Engineer) mergeInWorktree(v *verifiedMerge, mr *mrqueue.MR) ProcessResult {
+	return e.mergeInWorktreeWithRebase(v, mr, e.autoRebaseEnabled())
+}
+
+func (e *Engineer) mergeInWorktreeWithRebase(v *verifiedMerge, mr *mrqueue.MR, allowRebase bool) ProcessResult {
+	remoteBranch := "origin/" + mr.Branch
+
+	conflicts, err := v.git.CheckConflicts(remoteBranch, "HEAD")
+	if err == nil && len(conflicts) > 0 && allowRebase {
+		rebaseErr := e.autoRebase(v.git, mr.Branch, mr.Target)
+		if checkoutErr := v.git.Checkout(v.base); checkoutErr != nil && rebaseErr == nil {
+			rebaseErr = checkoutErr
+		}
+		if rebaseErr != nil {
+			return ProcessResult{
+				Success:         false,
+				Conflict:        true,
+				RebaseAttempted: true,
+				Error:           fmt.Sprintf("merge conflicts in: %v; auto-rebase failed: %v", conflicts, rebaseErr),
+			}
+		}
+		result := e.mergeInWorktreeWithRebase(v, mr, false)
+		result.RebaseAttempted = true
+		return result
+	}
+	if err != nil {
+		return ProcessResult{Success: false, Conflict: true, Error: fmt.Sprintf("conflict check failed: %v", err)}
+	}
+	if len(conflicts) > 0 {
+		return ProcessResult{Success: false, Conflict: true, Error: fmt.Sprintf("merge conflicts in: %v", conflicts)}
+	}
+
+	if err := v.git.MergeNoFF(remoteBranch, mergeMessage(mr.Branch, mr.Target, mr.SourceIssue)); err != nil {
+		if errors.Is(err, git.ErrMergeConflict) {
+			_ = v.git.AbortMerge()
+			return ProcessResult{Success: false, Conflict: true, Error: "merge conflict during actual merge"}
+		}
+		return ProcessResult{Success: false, Error: fmt.Sprintf("merge failed: %v", err)}
+	}
+	return ProcessResult{Success: true}
+}
+
+// land pushes a verified merge to the target branch. If the target moved
+// since verification (an earlier MR in the train landed), the merge is
+// rebuilt on the new tip and tested again first. A rebuilt merge that
+// fails its tests is not pushed: the result asks for the MR to be
+// requeued, so it is verified again on the new tip without being failed
+// back to the polecat. Caller holds the train lock.
+func (e *Engineer) land(ctx context.Context, mr *mrqueue.MR, v *verifiedMerge) (result ProcessResult) {
+	rebased := false
+	defer func() { result.RebaseAttempted = result.RebaseAttempted || rebased }()
+	e.repoMu.Lock()
+	err := e.git.FetchBranch("origin", mr.Target)
+	e.repoMu.Unlock()
+	if err != nil {
+		return ProcessResult{Success: false, Error: fmt.Sprintf("failed to fetch target %s: %v", mr.Target, err)}
+	}
+
+	tip, err := v.git.Rev("origin/" + mr.Target)
+	if err != nil {
+		return ProcessResult{Success: false, Error: fmt.Sprintf("resolving origin/%s: %v", mr.Target, err)}
+	}
+	if tip != v.base {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] %s: %s moved to %s, rebuilding merge\n", mr.ID, mr.Target, shortSHA(tip))
+		if err := v.git.Checkout(tip); err != nil {
+			return ProcessResult{Success: false, Error: fmt.Sprintf("checkout %s: %v", shortSHA(tip), err)}
+		}
+		v.base = tip
+		merged := e.mergeInWorktree(v, mr)
+		if !merged.Success {
+			return merged
+		}
+		rebased = merged.RebaseAttempted
+		if tested := e.testInWorktree(ctx, v, mr); !tested.Success {
+			tested.Requeue = true
+			tested.Error = fmt.Sprintf("merge rebuilt on %s@%s failed tests: %s", mr.Target, shortSHA(tip), tested.Error)
+			return tested
+		}
+	}
+
+	mergeCommit, err := v.git.Rev("HEAD")
+	if err != nil {
+		return ProcessResult{Success: false, Error: fmt.Sprintf("failed to get merge commit SHA: %v", err)}
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] %s: pushing to origin/%s...\n", mr.ID, mr.Target)
+	e.repoMu.Lock()
+	err = v.git.Push("origin", "HEAD:"+mr.Target, false)
+	e.repoMu.Unlock()
+	if err != nil {
+		return ProcessResult{Success: false, Error: fmt.Sprintf("failed to push to origin: %v", err)}
+	}
+
+	return ProcessResult{Success: true, MergeCommit: mergeCommit}
+}
+
+// removeWorktree deletes a scratch worktree.
+func (e *Engineer) removeWorktree(path string) {
+	e.repoMu.Lock()
+	defer e.repoMu.Unlock()
+	if err := e.git.WorktreeRemove(path, true); err != nil {
+		_ = os.RemoveAll(path)
+		_ = e.git.WorktreePrune()
+	}
+}
+
+// mergeMessage builds the merge commit message for a branch.
+func mergeMessage(branch, target, sourceIssue string) string {
+	if sourceIssue != "" {
+		return fmt.Sprintf("Merge %s into %s (%s)", branch, target, sourceIssue)
+	}
+	return fmt.Sprintf("Merge %s into %s", branch, target)
+}
+
+// shortSHA abbreviates a commit SHA for log output.
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,407 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/telemetry"
+)
+
+// setupTrainRepo creates a bare origin with a main branch and a clone of it
+// to act as the refinery's rig. Returns the clone path.
+func setupTrainRepo(t *testing.T) (origin, clone string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tmp := t.TempDir()
+	origin = filepath.Join(tmp, "origin.git")
+	seed := filepath.Join(tmp, "seed")
+	clone = filepath.Join(tmp, "rig")
+
+	gitCmd(t, tmp, "init", "--bare", "-b", "main", origin)
+	gitCmd(t, tmp, "clone", origin, seed)
+	configureGitUser(t, seed)
+	writeFile(t, filepath.Join(seed, "README.md"), "# Test\n")
+	gitCmd(t, seed, "add", ".")
+	gitCmd(t, seed, "commit", "-m", "initial")
+	gitCmd(t, seed, "push", "origin", "HEAD:main")
+
+	gitCmd(t, tmp, "clone", origin, clone)
+	configureGitUser(t, clone)
+	return origin, clone
+}
+
+// pushBranch commits files to a new branch off origin/main and pushes it.
+func pushBranch(t *testing.T, clone, branch string, files map[string]string) {
+	t.Helper()
+	gitCmd(t, clone, "fetch", "origin")
+	gitCmd(t, clone, "checkout", "-q", "-B", branch, "origin/main")
+	for name, content := range files {
+		writeFile(t, filepath.Join(clone, name), content)
+	}
+	gitCmd(t, clone, "add", ".")
+	gitCmd(t, clone, "commit", "-m", "work on "+branch)
+	gitCmd(t, clone, "push", "-q", "origin", branch)
+	gitCmd(t, clone, "checkout", "-q", "main")
+}
+
+func configureGitUser(t *testing.T, dir string) {
+	t.Helper()
+	gitCmd(t, dir, "config", "user.email", "test@test.com")
+	gitCmd(t, dir, "config", "user.name", "Test User")
+}
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func newTrainEngineer(t *testing.T, clone string, maxConcurrent int) (*Engineer, *bytes.Buffer) {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: clone})
+	e.config.TargetBranch = "main"
+	e.config.MaxConcurrent = maxConcurrent
+	e.config.RunTests = true
+	e.config.TestCommand = "sleep 0.2"
+	e.config.DeleteMergedBranches = false
+	var out bytes.Buffer
+	e.SetOutput(&out)
+	return e, &out
+}
+
+func TestProcessQueue_ConcurrentMergeTrain(t *testing.T) {
+	origin, clone := setupTrainRepo(t)
+	pushBranch(t, clone, "polecat/nux", map[string]string{"nux.txt": "nux\n"})
+	pushBranch(t, clone, "polecat/toast", map[string]string{"toast.txt": "toast\n"})
+	pushBranch(t, clone, "polecat/slit", map[string]string{"slit.txt": "slit\n"})
+
+	e, out := newTrainEngineer(t, clone, 3)
+	for _, branch := range []string{"polecat/nux", "polecat/toast", "polecat/slit"} {
+		if err := e.mrQueue.Submit(&mrqueue.MR{Branch: branch, Target: "main", Rig: "test-rig"}); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+
+	n, err := e.ProcessQueue(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("processed = %d, want 3\n%s", n, out.String())
+	}
+
+	// All three landed on origin/main, each as its own merge commit.
+	files := gitCmd(t, origin, "ls-tree", "--name-only", "main")
+	for _, f := range []string{"nux.txt", "toast.txt", "slit.txt"} {
+		if !strings.Contains(files, f) {
+			t.Errorf("origin/main missing %s; tree:\n%s\noutput:\n%s", f, files, out.String())
+		}
+	}
+	merges := gitCmd(t, origin, "rev-list", "--merges", "--count", "main")
+	if merges != "3" {
+		t.Errorf("merge commits on main = %s, want 3", merges)
+	}
+
+	if c := e.mrQueue.Count(); c != 0 {
+		t.Errorf("queue count = %d, want 0", c)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(clone, ".runtime", mergeWorktreesDir)); len(entries) != 0 {
+		t.Errorf("scratch worktrees not cleaned up: %d left", len(entries))
+	}
+}
+
+func TestProcessQueue_RecordsMergeMetrics(t *testing.T) {
+	for _, batchSize := range []int{1, 2} {
+		t.Run(fmt.Sprintf("batch_size=%d", batchSize), func(t *testing.T) {
+			_, clone := setupTrainRepo(t)
+			town := filepath.Dir(clone)
+			if err := os.MkdirAll(filepath.Join(town, "mayor"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			pushBranch(t, clone, "polecat/nux", map[string]string{"nux.txt": "nux\n"})
+			pushBranch(t, clone, "polecat/toast", map[string]string{"toast.txt": "toast\n"})
+
+			e, runs, _ := newBatchEngineer(t, clone, batchSize)
+			submitBranches(t, e, "polecat/nux", "polecat/toast")
+			if _, err := e.ProcessQueue(context.Background()); err != nil {
+				t.Fatalf("ProcessQueue: %v", err)
+			}
+
+			snap, err := telemetry.Load(town)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var merged, waits float64
+			for _, s := range snap.Series {
+				switch {
+				case s.Name == telemetry.MetricMerges && s.Labels["result"] == "merged":
+					merged = s.Value
+				case s.Name == telemetry.MetricMRQueueWait:
+					waits = float64(s.Count)
+				}
+			}
+			if merged != 2 || waits != 2 {
+				t.Errorf("merges = %v, queue waits = %v; want both MRs counted (%d test runs)", merged, waits, runs())
+			}
+		})
+	}
+}
+
+func TestClaims_PerProcessAndRenewed(t *testing.T) {
+	r := &rig.Rig{Name: "gastown", Path: t.TempDir()}
+	e := NewEngineer(r)
+	e.SetOutput(&bytes.Buffer{})
+	// A second refinery process on the same rig.
+	other := NewEngineer(r)
+	other.claimer = "gastown/refinery/otherhost:1"
+
+	if e.workerID(1) == other.workerID(1) || e.batchWorkerID() == other.batchWorkerID() {
+		t.Fatalf("processes share claim identities: %s, %s", e.workerID(1), e.batchWorkerID())
+	}
+
+	mr := &mrqueue.MR{Branch: "polecat/nux", Target: "main"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if err := e.mrQueue.Claim(mr.ID, e.workerID(1)); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	mr.ClaimedBy = e.workerID(1)
+	if err := other.mrQueue.Claim(mr.ID, other.workerID(1)); err != mrqueue.ErrAlreadyClaimed {
+		t.Errorf("second process Claim = %v, want ErrAlreadyClaimed", err)
+	}
+
+	claimed, err := e.mrQueue.Get(mr.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	old := claimRefreshInterval
+	claimRefreshInterval = 10 * time.Millisecond
+	defer func() { claimRefreshInterval = old }()
+
+	stop := e.holdClaims(mr)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := e.mrQueue.Get(mr.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.ClaimedAt.After(*claimed.ClaimedAt) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("claim was never renewed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+}
+
+func TestProcessQueue_ConflictReleasesClaim(t *testing.T) {
+	origin, clone := setupTrainRepo(t)
+	pushBranch(t, clone, "polecat/nux", map[string]string{"README.md": "# nux\n"})
+	pushBranch(t, clone, "polecat/toast", map[string]string{"README.md": "# toast\n"})
+
+	e, out := newTrainEngineer(t, clone, 2)
+	nux := &mrqueue.MR{Branch: "polecat/nux", Target: "main", Priority: 0}
+	toast := &mrqueue.MR{Branch: "polecat/toast", Target: "main", Priority: 4}
+	for _, mr := range []*mrqueue.MR{nux, toast} {
+		if err := e.mrQueue.Submit(mr); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+
+	if _, err := e.ProcessQueue(context.Background()); err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+
+	// Exactly one of the two conflicting MRs lands; the other stays queued
+	// and unclaimed so it can be retried after resolution.
+	merges := gitCmd(t, origin, "rev-list", "--merges", "--count", "main")
+	if merges != "1" {
+		t.Fatalf("merge commits on main = %s, want 1\n%s", merges, out.String())
+	}
+	remaining, err := e.mrQueue.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(remaining) != 1 {
+		t.Fatalf("remaining MRs = %d, want 1", len(remaining))
+	}
+	if remaining[0].ClaimedBy != "" {
+		t.Errorf("failed MR still claimed by %q", remaining[0].ClaimedBy)
+	}
+}
+
+// setupRebaseScenario builds a branch whose merge into main conflicts but
+// whose rebase is clean: main already carries the branch's first commit
+// (cherry-picked), and the branch's second commit edits the same line.
+func setupRebaseScenario(t *testing.T) (origin, clone string) {
+	t.Helper()
+	origin, clone = setupTrainRepo(t)
+
+	gitCmd(t, clone, "checkout", "-q", "-B", "polecat/nux", "origin/main")
+	writeFile(t, filepath.Join(clone, "README.md"), "# B\n")
+	gitCmd(t, clone, "commit", "-qam", "B")
+	first := gitCmd(t, clone, "rev-parse", "HEAD")
+	writeFile(t, filepath.Join(clone, "README.md"), "# C\n")
+	gitCmd(t, clone, "commit", "-qam", "C")
+	gitCmd(t, clone, "push", "-q", "origin", "polecat/nux")
+
+	gitCmd(t, clone, "checkout", "-q", "main")
+	gitCmd(t, clone, "cherry-pick", "-x", first)
+	writeFile(t, filepath.Join(clone, "other.txt"), "other\n")
+	gitCmd(t, clone, "add", ".")
+	gitCmd(t, clone, "commit", "-qm", "other")
+	gitCmd(t, clone, "push", "-q", "origin", "main")
+	return origin, clone
+}
+
+func TestProcessQueue_AutoRebase(t *testing.T) {
+	origin, clone := setupRebaseScenario(t)
+
+	e, out := newTrainEngineer(t, clone, 2)
+	e.config.OnConflict = "auto_rebase"
+	if err := e.mrQueue.Submit(&mrqueue.MR{Branch: "polecat/nux", Target: "main"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if _, err := e.ProcessQueue(context.Background()); err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+
+	if got := gitCmd(t, origin, "show", "main:README.md"); got != "# C" {
+		t.Fatalf("main README = %q, want %q\n%s", got, "# C", out.String())
+	}
+	if !strings.Contains(out.String(), "Rebased polecat/nux") {
+		t.Errorf("expected auto-rebase in output:\n%s", out.String())
+	}
+	// The polecat branch itself was rebased onto main and force-pushed.
+	gitCmd(t, origin, "merge-base", "--is-ancestor", "main~1", "polecat/nux")
+	if c := e.mrQueue.Count(); c != 0 {
+		t.Errorf("queue count = %d, want 0", c)
+	}
+}
+
+func TestProcessQueue_AutoRebaseConflictFallsBack(t *testing.T) {
+	origin, clone := setupTrainRepo(t)
+	pushBranch(t, clone, "polecat/nux", map[string]string{"README.md": "# nux\n"})
+	gitCmd(t, clone, "checkout", "-q", "main")
+	writeFile(t, filepath.Join(clone, "README.md"), "# main\n")
+	gitCmd(t, clone, "commit", "-qam", "main change")
+	gitCmd(t, clone, "push", "-q", "origin", "main")
+
+	e, out := newTrainEngineer(t, clone, 1)
+	e.config.OnConflict = "auto_rebase"
+	mr := &mrqueue.MR{Branch: "polecat/nux", Target: "main"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if _, err := e.ProcessQueue(context.Background()); err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+
+	if got := gitCmd(t, origin, "show", "main:README.md"); got != "# main" {
+		t.Errorf("main README = %q, want unchanged", got)
+	}
+	if !strings.Contains(out.String(), "auto-rebase failed") {
+		t.Errorf("expected auto-rebase failure in output:\n%s", out.String())
+	}
+	got, err := e.mrQueue.Get(mr.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.RebaseCount != 1 {
+		t.Errorf("RebaseCount = %d, want 1", got.RebaseCount)
+	}
+}
+
+func TestProcessMRFromQueue_AutoRebase(t *testing.T) {
+	origin, clone := setupRebaseScenario(t)
+
+	e, out := newTrainEngineer(t, clone, 1)
+	e.config.OnConflict = "auto_rebase"
+	mr := &mrqueue.MR{Branch: "polecat/nux", Target: "main"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	result := e.ProcessMRFromQueue(context.Background(), mr)
+	if !result.Success {
+		t.Fatalf("ProcessMRFromQueue failed: %s\n%s", result.Error, out.String())
+	}
+	if !result.RebaseAttempted {
+		t.Error("expected RebaseAttempted")
+	}
+	if got := gitCmd(t, origin, "show", "main:README.md"); got != "# C" {
+		t.Errorf("main README = %q, want %q", got, "# C")
+	}
+	if stored, err := e.mrQueue.Get(mr.ID); err != nil || stored.RebaseCount != 1 {
+		t.Errorf("stored RebaseCount = %v (err %v), want 1", stored, err)
+	}
+}
+
+func TestLand_RebuiltMergeFailsTestsIsRequeued(t *testing.T) {
+	origin, clone := setupTrainRepo(t)
+	pushBranch(t, clone, "polecat/nux", map[string]string{"nux.txt": "nux\n"})
+
+	e, out := newTrainEngineer(t, clone, 1)
+	// Green on its own, red once main carries breaks-nux.txt.
+	e.config.TestCommand = "! test -f breaks-nux.txt || ! test -f nux.txt"
+	mr := &mrqueue.MR{Branch: "polecat/nux", Target: "main"}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	v, result := e.verifyInWorktree(context.Background(), mr)
+	if v != nil {
+		defer e.removeWorktree(v.path)
+	}
+	if !result.Success {
+		t.Fatalf("verify failed: %s\n%s", result.Error, out.String())
+	}
+
+	// Another MR lands while nux waits for the train.
+	gitCmd(t, clone, "checkout", "-q", "main")
+	writeFile(t, filepath.Join(clone, "breaks-nux.txt"), "x\n")
+	gitCmd(t, clone, "add", ".")
+	gitCmd(t, clone, "commit", "-qm", "breaks nux")
+	gitCmd(t, clone, "push", "-q", "origin", "main")
+	before := gitCmd(t, origin, "rev-parse", "main")
+
+	result = e.land(context.Background(), mr, v)
+	if result.Success {
+		t.Fatalf("land pushed a merge that fails its tests\n%s", out.String())
+	}
+	if !result.Requeue || !result.TestsFailed {
+		t.Errorf("result = %+v, want a requeued test failure", result)
+	}
+	if after := gitCmd(t, origin, "rev-parse", "main"); after != before {
+		t.Errorf("origin/main moved to %s, want %s", after, before)
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/templates"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -50,6 +51,7 @@ type Manager struct {
 	townRoot string
 	config   *config.RigsConfig
 	git      *git.Git
+	conn     connection.Connection
 }
 
 // NewManager creates a new rig manager.
@@ -58,6 +60,19 @@ func NewManager(townRoot string, rigsConfig *config.RigsConfig, g *git.Git) *Man
 		townRoot: townRoot,
 		config:   rigsConfig,
 		git:      g,
+		conn:     connection.NewLocalConnection(),
+	}
+}
+
+// NewManagerWithConnection creates a rig manager for a town on the machine
+// behind conn. townRoot is the town's path on that machine.
+// Discovery and loading go through conn; rig creation (AddRig) is local-only.
+func NewManagerWithConnection(townRoot string, rigsConfig *config.RigsConfig, conn connection.Connection) *Manager {
+	return &Manager{
+		townRoot: townRoot,
+		config:   rigsConfig,
+		git:      git.NewGitWithRunner(conn, "", townRoot),
+		conn:     conn,
 	}
 }
 
@@ -99,7 +114,7 @@ func (m *Manager) loadRig(name string, entry config.RigEntry) (*Rig, error) {
 	rigPath := filepath.Join(m.townRoot, name)
 
 	// Verify directory exists
-	info, err := os.Stat(rigPath)
+	info, err := m.conn.Stat(rigPath)
 	if err != nil {
 		return nil, fmt.Errorf("rig directory: %w", err)
 	}
@@ -114,42 +129,35 @@ func (m *Manager) loadRig(name string, entry config.RigEntry) (*Rig, error) {
 		LocalRepo: entry.LocalRepo,
 		Config:    entry.BeadsConfig,
 	}
+	if !m.conn.IsLocal() {
+		rig.Machine = m.conn.Name()
+	}
 
 	// Scan for polecats
-	polecatsDir := filepath.Join(rigPath, "polecats")
-	if entries, err := os.ReadDir(polecatsDir); err == nil {
-		for _, e := range entries {
-			if e.IsDir() {
-				rig.Polecats = append(rig.Polecats, e.Name())
-			}
-		}
+	if names, err := connection.ListDirs(m.conn, filepath.Join(rigPath, "polecats")); err == nil {
+		rig.Polecats = names
 	}
 
 	// Scan for crew workers
-	crewDir := filepath.Join(rigPath, "crew")
-	if entries, err := os.ReadDir(crewDir); err == nil {
-		for _, e := range entries {
-			if e.IsDir() {
-				rig.Crew = append(rig.Crew, e.Name())
-			}
-		}
+	if names, err := connection.ListDirs(m.conn, filepath.Join(rigPath, "crew")); err == nil {
+		rig.Crew = names
 	}
 
 	// Check for witness (witnesses don't have clones, just the witness directory)
 	witnessPath := filepath.Join(rigPath, "witness")
-	if info, err := os.Stat(witnessPath); err == nil && info.IsDir() {
+	if info, err := m.conn.Stat(witnessPath); err == nil && info.IsDir() {
 		rig.HasWitness = true
 	}
 
 	// Check for refinery
 	refineryPath := filepath.Join(rigPath, "refinery", "rig")
-	if _, err := os.Stat(refineryPath); err == nil {
+	if ok, _ := m.conn.Exists(refineryPath); ok {
 		rig.HasRefinery = true
 	}
 
 	// Check for mayor clone
 	mayorPath := filepath.Join(rigPath, "mayor", "rig")
-	if _, err := os.Stat(mayorPath); err == nil {
+	if ok, _ := m.conn.Exists(mayorPath); ok {
 		rig.HasMayor = true
 	}
 
@@ -490,6 +498,19 @@ func LoadRigConfig(rigPath string) (*RigConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseRigConfig(data)
+}
+
+// LoadRigConfigFrom reads the rig configuration through conn.
+func LoadRigConfigFrom(conn connection.Connection, rigPath string) (*RigConfig, error) {
+	data, err := conn.ReadFile(filepath.Join(rigPath, "config.json"))
+	if err != nil {
+		return nil, err
+	}
+	return parseRigConfig(data)
+}
+
+func parseRigConfig(data []byte) (*RigConfig, error) {
 	var cfg RigConfig
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, err
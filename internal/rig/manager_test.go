@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/git"
 )
 
@@ -112,6 +113,44 @@ func TestGetRig(t *testing.T) {
 	}
 }
 
+func TestGetRigWithConnection(t *testing.T) {
+	conn := connection.NewFakeConnection("vm")
+	for _, dir := range []string{
+		"/town/gastown/polecats/Toast",
+		"/town/gastown/polecats/Cheedo",
+		"/town/gastown/crew/max",
+		"/town/gastown/witness",
+		"/town/gastown/refinery/rig",
+	} {
+		if err := conn.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+
+	rigsConfig := &config.RigsConfig{
+		Version: 1,
+		Rigs:    map[string]config.RigEntry{"gastown": {GitURL: "git@github.com:test/gastown.git"}},
+	}
+	manager := NewManagerWithConnection("/town", rigsConfig, conn)
+
+	r, err := manager.GetRig("gastown")
+	if err != nil {
+		t.Fatalf("GetRig: %v", err)
+	}
+	if r.Machine != "vm" {
+		t.Errorf("Machine = %q, want vm", r.Machine)
+	}
+	if got := strings.Join(r.Polecats, ","); got != "Cheedo,Toast" {
+		t.Errorf("Polecats = %q, want Cheedo,Toast", got)
+	}
+	if len(r.Crew) != 1 || r.Crew[0] != "max" {
+		t.Errorf("Crew = %v, want [max]", r.Crew)
+	}
+	if !r.HasWitness || !r.HasRefinery || r.HasMayor {
+		t.Errorf("HasWitness=%v HasRefinery=%v HasMayor=%v, want true true false", r.HasWitness, r.HasRefinery, r.HasMayor)
+	}
+}
+
 func TestGetRigNotFound(t *testing.T) {
 	root, rigsConfig := setupTestTown(t)
 	manager := NewManager(root, rigsConfig, git.NewGit(root))
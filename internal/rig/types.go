@@ -11,8 +11,12 @@ type Rig struct {
 	Name string `json:"name"`
 
 	// Path is the absolute path to the rig directory.
+	// For remote rigs this is the path on the remote machine.
 	Path string `json:"path"`
 
+	// Machine is the federation machine hosting the rig (empty = local).
+	Machine string `json:"machine,omitempty"`
+
 	// GitURL is the remote repository URL.
 	GitURL string `json:"git_url"`
 
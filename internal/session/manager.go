@@ -5,13 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/agent"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -37,6 +37,7 @@ var (
 type Manager struct {
 	tmux *tmux.Tmux
 	rig  *rig.Rig
+	conn connection.Connection
 }
 
 // NewManager creates a new session manager for a rig.
@@ -44,6 +45,21 @@ func NewManager(t *tmux.Tmux, r *rig.Rig) *Manager {
 	return &Manager{
 		tmux: t,
 		rig:  r,
+		conn: connection.NewLocalConnection(),
+	}
+}
+
+// NewManagerWithConnection creates a session manager for a rig on the
+// machine behind conn. Sessions are created on that machine's tmux server.
+func NewManagerWithConnection(conn connection.Connection, r *rig.Rig) *Manager {
+	t := tmux.NewTmux()
+	if !conn.IsLocal() {
+		t = tmux.NewTmuxWithRunner(conn)
+	}
+	return &Manager{
+		tmux: t,
+		rig:  r,
+		conn: conn,
 	}
 }
 
@@ -93,6 +109,11 @@ type Info struct {
 	LastActivity time.Time `json:"last_activity,omitempty"`
 }
 
+// Tmux returns the tmux server the manager's sessions live on.
+func (m *Manager) Tmux() *tmux.Tmux {
+	return m.tmux
+}
+
 // SessionName generates the tmux session name for a polecat.
 func (m *Manager) SessionName(polecat string) string {
 	return fmt.Sprintf("gt-%s-%s", m.rig.Name, polecat)
@@ -107,7 +128,7 @@ func (m *Manager) polecatDir(polecat string) string {
 func (m *Manager) hasPolecat(polecat string) bool {
 	// Check filesystem directly to handle newly-created polecats
 	polecatPath := m.polecatDir(polecat)
-	info, err := os.Stat(polecatPath)
+	info, err := m.conn.Stat(polecatPath)
 	if err != nil {
 		return false
 	}
@@ -142,7 +163,11 @@ func (m *Manager) Start(polecat string, opts StartOptions) error {
 	}
 
 	// Ensure Claude settings exist (autonomous role needs mail in SessionStart)
-	if err := startupAdapter.EnsureRoleSettings(workDir, "polecat"); err != nil {
+	if m.conn.IsLocal() {
+		if err := startupAdapter.EnsureRoleSettings(workDir, "polecat"); err != nil {
+			return fmt.Errorf("ensuring Claude settings: %w", err)
+		}
+	} else if err := startupAdapter.EnsureRoleSettingsOn(m.conn, workDir, "polecat"); err != nil {
 		return fmt.Errorf("ensuring Claude settings: %w", err)
 	}
 
@@ -194,7 +219,7 @@ func (m *Manager) Start(polecat string, opts StartOptions) error {
 	if command == "" {
 		// Polecats run with full permissions - Gas Town is for grownups
 		// Export env vars inline so Claude's role detection works
-		command = config.BuildPolecatStartupCommand(m.rig.Name, polecat, m.rig.Path, "")
+		command = config.BuildPolecatStartupCommandWithAccount(m.rig.Name, polecat, m.rig.Path, opts.ClaudeConfigDir, "")
 	}
 	if err := m.tmux.SendKeys(sessionID, command); err != nil {
 		return fmt.Errorf("sending command: %w", err)
@@ -277,9 +302,8 @@ func (m *Manager) Stop(polecat string, force bool) error {
 
 // syncBeads runs bd sync in the given directory.
 func (m *Manager) syncBeads(workDir string) error {
-	cmd := exec.Command("bd", "sync")
-	cmd.Dir = workDir
-	return cmd.Run()
+	_, err := m.conn.ExecDir(workDir, "bd", "sync")
+	return err
 }
 
 // IsRunning checks if a polecat session is active.
@@ -463,10 +487,11 @@ func (m *Manager) StopAll(force bool) error {
 // This makes the work visible via 'gt hook' when the session starts.
 func (m *Manager) hookIssue(issueID, agentID, workDir string) error {
 	// Use bd update to set status=hooked and assign to the polecat
-	cmd := exec.Command("bd", "update", issueID, "--status=hooked", "--assignee="+agentID) //nolint:gosec // G204: bd is a trusted internal tool
-	cmd.Dir = workDir
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	out, err := m.conn.ExecDir(workDir, "bd", "update", issueID, "--status=hooked", "--assignee="+agentID)
+	if err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("bd update failed: %w: %s", err, strings.TrimSpace(string(out)))
+		}
 		return fmt.Errorf("bd update failed: %w", err)
 	}
 	fmt.Printf("✓ Hooked issue %s to %s\n", issueID, agentID)
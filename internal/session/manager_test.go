@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
@@ -190,3 +191,57 @@ func TestPolecatCommandFormat(t *testing.T) {
 		t.Error("GT_ROLE must be 'polecat', not 'mayor' or 'crew'")
 	}
 }
+
+func TestManagerWithConnection(t *testing.T) {
+	conn := connection.NewFakeConnection("vm")
+	if err := conn.MkdirAll("/town/gastown/polecats/Toast", 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := conn.TmuxNewSession("gt-gastown-Toast", "/town/gastown/polecats/Toast"); err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+	if err := conn.TmuxSendKeys("gt-gastown-Toast", "hello from vm"); err != nil {
+		t.Fatalf("send keys: %v", err)
+	}
+
+	r := &rig.Rig{Name: "gastown", Path: "/town/gastown"}
+	m := NewManagerWithConnection(conn, r)
+
+	if !m.hasPolecat("Toast") {
+		t.Error("expected hasPolecat(Toast) = true on remote filesystem")
+	}
+	if m.hasPolecat("Nux") {
+		t.Error("expected hasPolecat(Nux) = false")
+	}
+
+	running, err := m.IsRunning("Toast")
+	if err != nil {
+		t.Fatalf("IsRunning: %v", err)
+	}
+	if !running {
+		t.Error("expected IsRunning(Toast) = true")
+	}
+
+	infos, err := m.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Polecat != "Toast" {
+		t.Errorf("List = %+v, want single Toast session", infos)
+	}
+
+	out, err := m.Capture("Toast", 10)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if !strings.Contains(out, "hello from vm") {
+		t.Errorf("Capture = %q, want pane contents", out)
+	}
+
+	if err := m.Stop("Toast", true); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if conn.Session("gt-gastown-Toast") != nil {
+		t.Error("session still exists after Stop")
+	}
+}
@@ -0,0 +1,351 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// serviceName identifies Gas Town in exported telemetry.
+const serviceName = "gastown"
+
+// Exporter ships metrics and spans to a telemetry backend.
+type Exporter interface {
+	ExportMetrics(ctx context.Context, snap *Snapshot) error
+	ExportSpans(ctx context.Context, spans []Span) error
+}
+
+// Exporters builds the exporters configured for townRoot. Without an
+// explicit OTLP endpoint, the standard OTEL_EXPORTER_OTLP_ENDPOINT (and
+// OTEL_EXPORTER_OTLP_HEADERS) environment variables are honored.
+func Exporters(townRoot string, cfg *config.TelemetryConfig) []Exporter {
+	if cfg == nil {
+		cfg = &config.TelemetryConfig{}
+	}
+	var exporters []Exporter
+
+	endpoint, headersVar := cfg.OTLPEndpoint, cfg.OTLPHeadersEnv
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if headersVar == "" {
+		headersVar = "OTEL_EXPORTER_OTLP_HEADERS"
+	}
+	if endpoint != "" {
+		exporters = append(exporters, NewOTLPExporter(endpoint, parseHeaders(os.Getenv(headersVar))))
+	}
+
+	if cfg.File != "" {
+		path := cfg.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(townRoot, path)
+		}
+		exporters = append(exporters, NewFileExporter(path))
+	}
+	return exporters
+}
+
+// parseHeaders parses "key=value,key2=value2".
+func parseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok && strings.TrimSpace(k) != "" {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
+
+// OTLPExporter sends telemetry to an OTLP/HTTP collector using the JSON
+// encoding, at <endpoint>/v1/metrics and <endpoint>/v1/traces.
+type OTLPExporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewOTLPExporter returns an exporter for the collector at endpoint,
+// e.g. "http://localhost:4318".
+func NewOTLPExporter(endpoint string, headers map[string]string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ExportMetrics posts the snapshot to the collector.
+func (e *OTLPExporter) ExportMetrics(ctx context.Context, snap *Snapshot) error {
+	return e.post(ctx, "/v1/metrics", otlpMetrics(snap, time.Now()))
+}
+
+// ExportSpans posts spans to the collector.
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []Span) error {
+	return e.post(ctx, "/v1/traces", otlpTraces(spans))
+}
+
+func (e *OTLPExporter) post(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding OTLP payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting to %s: %w", e.endpoint+path, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("exporting to %s: %s", e.endpoint+path, resp.Status)
+	}
+	return nil
+}
+
+// FileExporter appends OTLP JSON payloads to a file, one per line, for
+// local inspection and testing.
+type FileExporter struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileExporter returns an exporter writing to path.
+func NewFileExporter(path string) *FileExporter {
+	return &FileExporter{path: path}
+}
+
+// ExportMetrics appends the snapshot as an OTLP metrics payload.
+func (e *FileExporter) ExportMetrics(_ context.Context, snap *Snapshot) error {
+	return e.write(otlpMetrics(snap, time.Now()))
+}
+
+// ExportSpans appends spans as an OTLP traces payload.
+func (e *FileExporter) ExportSpans(_ context.Context, spans []Span) error {
+	return e.write(otlpTraces(spans))
+}
+
+func (e *FileExporter) write(payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding OTLP payload: %w", err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return fmt.Errorf("creating export directory: %w", err)
+	}
+	if err := appendLine(e.path, data); err != nil {
+		return fmt.Errorf("writing %s: %w", e.path, err)
+	}
+	return nil
+}
+
+// OTLP JSON encoding. 64-bit integers are strings, and trace and span IDs
+// are hex, as the OTLP/HTTP JSON mapping requires.
+
+type otlpKeyValue struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+func otlpAttrs(labels Labels) []otlpKeyValue {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpKeyValue{Key: k, Value: map[string]string{"stringValue": labels[k]}})
+	}
+	return attrs
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+var otlpService = otlpResource{Attributes: otlpAttrs(Labels{"service.name": serviceName})}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+// aggregationCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const aggregationCumulative = 2
+
+type otlpSum struct {
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsDouble          float64        `json:"asDouble"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+}
+
+// otlpMetrics encodes a snapshot, one metric per name.
+func otlpMetrics(snap *Snapshot, now time.Time) otlpMetricsRequest {
+	start, at := otlpTime(snap.Start), otlpTime(now)
+	var metrics []otlpMetric
+	byName := make(map[string]int)
+	for _, s := range snap.Series {
+		i, ok := byName[s.Name]
+		if !ok {
+			i = len(metrics)
+			metrics = append(metrics, otlpMetric{Name: s.Name, Description: help[s.Name]})
+			byName[s.Name] = i
+		}
+		m := &metrics[i]
+		switch s.Kind {
+		case KindCounter:
+			if m.Sum == nil {
+				m.Sum = &otlpSum{AggregationTemporality: aggregationCumulative, IsMonotonic: true}
+			}
+			m.Sum.DataPoints = append(m.Sum.DataPoints, otlpNumberPoint{
+				Attributes: otlpAttrs(s.Labels), StartTimeUnixNano: start, TimeUnixNano: at, AsDouble: s.Value,
+			})
+		case KindHistogram:
+			if m.Histogram == nil {
+				m.Histogram = &otlpHistogram{AggregationTemporality: aggregationCumulative}
+			}
+			counts := make([]string, len(s.Buckets))
+			for i, c := range s.Buckets {
+				counts[i] = strconv.FormatUint(c, 10)
+			}
+			m.Histogram.DataPoints = append(m.Histogram.DataPoints, otlpHistogramPoint{
+				Attributes: otlpAttrs(s.Labels), StartTimeUnixNano: start, TimeUnixNano: at,
+				Count: strconv.FormatUint(s.Count, 10), Sum: s.Sum,
+				BucketCounts: counts, ExplicitBounds: DefaultBuckets,
+			})
+		}
+	}
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpService,
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: serviceName}, Metrics: metrics}},
+	}}}
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Span kind and status codes.
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+// otlpTraces encodes spans.
+func otlpTraces(spans []Span) otlpTracesRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		status := otlpStatus{Code: statusOK}
+		if s.Error != "" {
+			status = otlpStatus{Code: statusError, Message: s.Error}
+		}
+		out = append(out, otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: otlpTime(s.Start),
+			EndTimeUnixNano:   otlpTime(s.End),
+			Attributes:        otlpAttrs(s.Attrs),
+			Status:            status,
+		})
+	}
+	return otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpService,
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: serviceName}, Spans: out}},
+	}}}
+}
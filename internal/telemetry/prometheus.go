@@ -0,0 +1,80 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes the snapshot in the Prometheus text exposition
+// format.
+func WritePrometheus(w io.Writer, snap *Snapshot) error {
+	var b strings.Builder
+	last := ""
+	for _, s := range snap.Series {
+		if s.Name != last {
+			if h := help[s.Name]; h != "" {
+				fmt.Fprintf(&b, "# HELP %s %s\n", s.Name, h)
+			}
+			fmt.Fprintf(&b, "# TYPE %s %s\n", s.Name, s.Kind)
+			last = s.Name
+		}
+		switch s.Kind {
+		case KindCounter:
+			fmt.Fprintf(&b, "%s%s %s\n", s.Name, promLabels(s.Labels, ""), promFloat(s.Value))
+		case KindHistogram:
+			var cumulative uint64
+			for i, bound := range DefaultBuckets {
+				if i < len(s.Buckets) {
+					cumulative += s.Buckets[i]
+				}
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", s.Name, promLabels(s.Labels, promFloat(bound)), cumulative)
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", s.Name, promLabels(s.Labels, "+Inf"), s.Count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", s.Name, promLabels(s.Labels, ""), promFloat(s.Sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", s.Name, promLabels(s.Labels, ""), s.Count)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves townRoot's metrics for Prometheus to scrape.
+func Handler(townRoot string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap, err := Load(townRoot)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WritePrometheus(w, snap)
+	})
+}
+
+// promLabels formats labels, plus an "le" bucket label when le is set.
+func promLabels(labels Labels, le string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	if le != "" {
+		parts = append(parts, fmt.Sprintf("le=%q", le))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func promFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,242 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// spanRetention bounds how long spans wait for a failing exporter.
+const spanRetention = 24 * time.Hour
+
+// Series is the cumulative value of one metric with one set of labels.
+type Series struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Labels Labels `json:"labels,omitempty"`
+
+	// Value is a counter's total.
+	Value float64 `json:"value,omitempty"`
+
+	// Count, Sum and Buckets summarize a histogram. Buckets[i] counts
+	// observations no greater than DefaultBuckets[i] (and greater than the
+	// bound before it); the final entry counts those above every bound.
+	Count   uint64   `json:"count,omitempty"`
+	Sum     float64  `json:"sum,omitempty"`
+	Buckets []uint64 `json:"buckets,omitempty"`
+}
+
+// Snapshot is the cumulative state of every metric since Start.
+type Snapshot struct {
+	Start  time.Time `json:"start"`
+	Series []*Series `json:"series"`
+
+	index map[string]*Series
+}
+
+// add folds an observation into the snapshot.
+func (s *Snapshot) add(o observation) {
+	if s.index == nil {
+		s.index = make(map[string]*Series, len(s.Series))
+		for _, existing := range s.Series {
+			s.index[seriesKey(existing.Name, existing.Labels)] = existing
+		}
+	}
+	key := seriesKey(o.Name, o.Labels)
+	series := s.index[key]
+	if series == nil {
+		series = &Series{Name: o.Name, Kind: o.Kind, Labels: o.Labels}
+		if o.Kind == KindHistogram {
+			series.Buckets = make([]uint64, len(DefaultBuckets)+1)
+		}
+		s.Series = append(s.Series, series)
+		s.index[key] = series
+	}
+
+	switch series.Kind {
+	case KindCounter:
+		series.Value += o.Value
+	case KindHistogram:
+		series.Count++
+		series.Sum += o.Value
+		series.Buckets[sort.SearchFloat64s(DefaultBuckets, o.Value)]++
+	}
+}
+
+// sortSeries orders series by name, then labels.
+func (s *Snapshot) sortSeries() {
+	sort.Slice(s.Series, func(i, j int) bool {
+		return seriesKey(s.Series[i].Name, s.Series[i].Labels) < seriesKey(s.Series[j].Name, s.Series[j].Labels)
+	})
+}
+
+// seriesKey identifies a series: its name and sorted labels.
+func seriesKey(name string, labels Labels) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\x00%s=%s", k, labels[k])
+	}
+	return b.String()
+}
+
+// Load returns townRoot's current metrics: the folded totals plus any
+// observations recorded since the last Flush.
+func Load(townRoot string) (*Snapshot, error) {
+	dir := filepath.Join(townRoot, Dir)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return &Snapshot{Start: time.Now().UTC()}, nil
+	}
+	lock := flock.New(filepath.Join(dir, lockFile))
+	if err := lock.RLock(); err == nil {
+		defer func() { _ = lock.Unlock() }()
+	}
+	return loadSnapshot(dir)
+}
+
+// loadSnapshot reads state and pending observations. Callers hold the lock.
+func loadSnapshot(dir string) (*Snapshot, error) {
+	snap := &Snapshot{}
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, snap); err != nil {
+			return nil, fmt.Errorf("parsing telemetry state: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("reading telemetry state: %w", err)
+	}
+
+	err = scanFile(filepath.Join(dir, metricsFile), func(line []byte) {
+		var o observation
+		if json.Unmarshal(line, &o) == nil && (o.Kind == KindCounter || o.Kind == KindHistogram) {
+			if snap.Start.IsZero() {
+				snap.Start = o.Time
+			}
+			snap.add(o)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if snap.Start.IsZero() {
+		snap.Start = time.Now().UTC()
+	}
+	snap.sortSeries()
+	return snap, nil
+}
+
+// Flush folds pending observations into the totals and exports the totals
+// and all finished spans. Spans are dropped once exported, or straight away
+// when there are no exporters. Spans that fail to export are kept for a
+// later flush, up to a day.
+func Flush(ctx context.Context, townRoot string, exporters []Exporter) error {
+	var snap *Snapshot
+	var spans []Span
+	err := withLock(townRoot, func(dir string) error {
+		var err error
+		if snap, err = loadSnapshot(dir); err != nil {
+			return err
+		}
+		if err := util.AtomicWriteJSON(filepath.Join(dir, stateFile), snap); err != nil {
+			return fmt.Errorf("saving telemetry state: %w", err)
+		}
+		if err := os.Truncate(filepath.Join(dir, metricsFile), 0); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("truncating telemetry metrics: %w", err)
+		}
+		if spans, err = readSpans(dir); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, spansFile), nil, 0644) //nolint:gosec // G306: telemetry is non-sensitive operational data
+	})
+	if err != nil || len(exporters) == 0 {
+		return err
+	}
+
+	var errs []error
+	spansFailed := false
+	for _, exp := range exporters {
+		if err := exp.ExportMetrics(ctx, snap); err != nil {
+			errs = append(errs, err)
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		if err := exp.ExportSpans(ctx, spans); err != nil {
+			errs = append(errs, err)
+			spansFailed = true
+		}
+	}
+	if spansFailed {
+		requeueSpans(townRoot, spans)
+	}
+	return errors.Join(errs...)
+}
+
+// requeueSpans puts spans back for the next flush, dropping stale ones.
+func requeueSpans(townRoot string, spans []Span) {
+	cutoff := time.Now().Add(-spanRetention)
+	_ = withLock(townRoot, func(dir string) error {
+		for _, s := range spans {
+			if s.End.Before(cutoff) {
+				continue
+			}
+			data, err := json.Marshal(s)
+			if err != nil {
+				continue
+			}
+			if err := appendLine(filepath.Join(dir, spansFile), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readSpans reads the finished spans. Callers hold the lock.
+func readSpans(dir string) ([]Span, error) {
+	var spans []Span
+	err := scanFile(filepath.Join(dir, spansFile), func(line []byte) {
+		var s Span
+		if json.Unmarshal(line, &s) == nil {
+			spans = append(spans, s)
+		}
+	})
+	return spans, err
+}
+
+// scanFile calls fn with each line of a file. A missing file has none.
+func scanFile(path string, fn func([]byte)) error {
+	f, err := os.Open(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("opening %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
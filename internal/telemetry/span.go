@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Span is a timed operation in a trace. Spans about the same piece of work
+// share a trace: its ID is derived from the convoy or bead ID, so spans
+// recorded by different processes (gt sling, gt done, the refinery) line up
+// without passing context between them.
+type Span struct {
+	TraceID string    `json:"trace_id"`
+	SpanID  string    `json:"span_id"`
+	Name    string    `json:"name"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Attrs   Labels    `json:"attrs,omitempty"`
+	Error   string    `json:"error,omitempty"`
+
+	rec   Recorder
+	ended bool
+}
+
+// StartSpan starts a span. It is recorded when Finish is called.
+func (r Recorder) StartSpan(name string, attrs Labels) *Span {
+	if attrs == nil {
+		attrs = Labels{}
+	}
+	return &Span{SpanID: randomHex(8), Name: name, Start: time.Now().UTC(), Attrs: attrs, rec: r}
+}
+
+// RecordSpan records a span that has already finished, such as one whose
+// start was observed by another process.
+func (r Recorder) RecordSpan(name string, start, end time.Time, attrs Labels, traceKeys ...string) {
+	s := r.StartSpan(name, nil)
+	for k, v := range attrs {
+		s.SetAttr(k, v)
+	}
+	s.Start = start.UTC()
+	s.SetTrace(traceKeys...)
+	s.finishAt(end, nil)
+}
+
+// SetTrace places the span in the trace of the first non-empty key,
+// normally a convoy ID falling back to a bead ID.
+func (s *Span) SetTrace(keys ...string) {
+	s.TraceID = TraceID(keys...)
+}
+
+// SetAttr sets a span attribute. Empty values are ignored.
+func (s *Span) SetAttr(key, value string) {
+	if value != "" {
+		s.Attrs[key] = value
+	}
+}
+
+// Finish ends the span, marking it failed if err is non-nil, and records it.
+// Only the first call has any effect.
+func (s *Span) Finish(err error) {
+	s.finishAt(time.Now(), err)
+}
+
+func (s *Span) finishAt(end time.Time, err error) {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	s.End = end.UTC()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	if s.TraceID == "" {
+		s.TraceID = TraceID()
+	}
+	s.rec.write(spansFile, s)
+}
+
+// TraceID returns the trace ID for the first non-empty key, or a random one
+// if there is none. IDs are 16 bytes, hex-encoded, as in OpenTelemetry.
+func TraceID(keys ...string) string {
+	for _, key := range keys {
+		if key != "" {
+			sum := sha256.Sum256([]byte("gastown/" + key))
+			return hex.EncodeToString(sum[:16])
+		}
+	}
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
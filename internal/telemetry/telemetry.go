@@ -0,0 +1,169 @@
+// Package telemetry records metrics and traces for the agent lifecycle.
+//
+// Gas Town's work happens across many short-lived gt processes, so
+// measurements are not held in memory: each process appends counter
+// increments, histogram observations and finished spans to files under
+// <town>/.telemetry. The daemon periodically folds the observations into
+// cumulative totals (state.json) and hands totals and spans to the
+// configured exporters; the dashboard serves the same totals in Prometheus
+// format at /metrics.
+//
+// Like the events log, recording is best-effort: outside a town, or on any
+// I/O error, measurements are silently dropped.
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Dir is the directory, relative to the town root, holding telemetry files.
+const Dir = ".telemetry"
+
+const (
+	lockFile    = ".lock"
+	metricsFile = "metrics.jsonl"
+	spansFile   = "spans.jsonl"
+	stateFile   = "state.json"
+)
+
+// Metric names. Durations are in seconds.
+const (
+	MetricSlings          = "gt_slings_total"
+	MetricDone            = "gt_done_total"
+	MetricPolecatWork     = "gt_polecat_work_seconds"
+	MetricMRQueueWait     = "gt_mr_queue_wait_seconds"
+	MetricMRProcess       = "gt_mr_process_seconds"
+	MetricMerges          = "gt_merges_total"
+	MetricWitnessPatrols  = "gt_witness_patrols_total"
+	MetricWitnessPatrol   = "gt_witness_patrol_seconds"
+	MetricWitnessNudges   = "gt_witness_nudges_total"
+	MetricHeartbeats      = "gt_daemon_heartbeats_total"
+	MetricHeartbeatLength = "gt_daemon_heartbeat_seconds"
+)
+
+// help describes each metric for exporters.
+var help = map[string]string{
+	MetricSlings:          "Beads slung to agents.",
+	MetricDone:            "Polecats that ran gt done, by exit status.",
+	MetricPolecatWork:     "Time from sling to gt done.",
+	MetricMRQueueWait:     "Time merge requests waited in the queue before processing.",
+	MetricMRProcess:       "Time the refinery spent processing a merge request.",
+	MetricMerges:          "Merge requests processed by the refinery, by result.",
+	MetricWitnessPatrols:  "Witness patrols started.",
+	MetricWitnessPatrol:   "Witness patrol duration.",
+	MetricWitnessNudges:   "Polecats nudged by witnesses.",
+	MetricHeartbeats:      "Daemon heartbeats run.",
+	MetricHeartbeatLength: "Daemon heartbeat duration.",
+}
+
+// DefaultBuckets are the histogram bucket bounds, in seconds. They span
+// sub-second daemon heartbeats to day-long polecat work.
+var DefaultBuckets = []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200, 14400, 43200, 86400}
+
+// Labels are a metric's dimensions or a span's attributes.
+type Labels map[string]string
+
+// Metric kinds.
+const (
+	KindCounter   = "counter"
+	KindHistogram = "histogram"
+)
+
+// observation is one line of the metrics file.
+type observation struct {
+	Time   time.Time `json:"ts"`
+	Kind   string    `json:"kind"`
+	Name   string    `json:"name"`
+	Labels Labels    `json:"labels,omitempty"`
+	Value  float64   `json:"value"`
+}
+
+// Recorder records telemetry for one town. The zero Recorder drops
+// everything.
+type Recorder struct {
+	townRoot string
+}
+
+// At returns a Recorder for townRoot, for callers (like the daemon) whose
+// working directory may be outside the town.
+func At(townRoot string) Recorder {
+	return Recorder{townRoot: townRoot}
+}
+
+// current returns a Recorder for the town containing the working directory.
+func current() Recorder {
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil {
+		return Recorder{}
+	}
+	return Recorder{townRoot: townRoot}
+}
+
+// Count increments a counter in the current town.
+func Count(name string, labels Labels) { current().Count(name, labels) }
+
+// Observe records a histogram observation in the current town.
+func Observe(name string, value float64, labels Labels) { current().Observe(name, value, labels) }
+
+// StartSpan starts a span in the current town.
+func StartSpan(name string, attrs Labels) *Span { return current().StartSpan(name, attrs) }
+
+// RecordSpan records a finished span in the current town.
+func RecordSpan(name string, start, end time.Time, attrs Labels, traceKeys ...string) {
+	current().RecordSpan(name, start, end, attrs, traceKeys...)
+}
+
+// Count increments a counter.
+func (r Recorder) Count(name string, labels Labels) {
+	r.write(metricsFile, observation{Time: time.Now().UTC(), Kind: KindCounter, Name: name, Labels: labels, Value: 1})
+}
+
+// Observe records a histogram observation.
+func (r Recorder) Observe(name string, value float64, labels Labels) {
+	r.write(metricsFile, observation{Time: time.Now().UTC(), Kind: KindHistogram, Name: name, Labels: labels, Value: value})
+}
+
+// write appends a record to one of the telemetry files.
+func (r Recorder) write(file string, record interface{}) {
+	if r.townRoot == "" {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	_ = withLock(r.townRoot, func(dir string) error {
+		return appendLine(filepath.Join(dir, file), data)
+	})
+}
+
+// withLock runs fn holding the town's telemetry lock exclusively.
+func withLock(townRoot string, fn func(dir string) error) error {
+	dir := filepath.Join(townRoot, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating telemetry directory: %w", err)
+	}
+	lock := flock.New(filepath.Join(dir, lockFile))
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking telemetry: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+	return fn(dir)
+}
+
+func appendLine(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: telemetry is non-sensitive operational data
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,229 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotAggregates(t *testing.T) {
+	town := t.TempDir()
+	rec := At(town)
+	rec.Count(MetricSlings, Labels{"rig": "gastown"})
+	rec.Count(MetricSlings, Labels{"rig": "gastown"})
+	rec.Count(MetricSlings, Labels{"rig": "beads"})
+	rec.Observe(MetricPolecatWork, 45, Labels{"rig": "gastown"})
+	rec.Observe(MetricPolecatWork, 4000, Labels{"rig": "gastown"})
+
+	snap, err := Load(town)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(snap.Series) != 3 {
+		t.Fatalf("series = %+v, want 3", snap.Series)
+	}
+	// Sorted by name, then labels.
+	if s := snap.Series[1]; s.Name != MetricSlings || s.Labels["rig"] != "beads" || s.Value != 1 {
+		t.Errorf("series[1] = %+v", s)
+	}
+	if s := snap.Series[2]; s.Value != 2 {
+		t.Errorf("gastown slings = %v, want 2", s.Value)
+	}
+	h := snap.Series[0]
+	if h.Count != 2 || h.Sum != 4045 || h.Buckets[6] != 1 || h.Buckets[12] != 1 {
+		t.Errorf("histogram = %+v", h)
+	}
+}
+
+func TestFlushKeepsTotals(t *testing.T) {
+	town := t.TempDir()
+	rec := At(town)
+	rec.Count(MetricHeartbeats, nil)
+	if err := Flush(context.Background(), town, nil); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	rec.Count(MetricHeartbeats, nil)
+
+	snap, err := Load(town)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Series) != 1 || snap.Series[0].Value != 2 {
+		t.Errorf("series = %+v, want one counter at 2", snap.Series)
+	}
+	if data, _ := os.ReadFile(filepath.Join(town, Dir, metricsFile)); strings.Count(string(data), "\n") != 1 {
+		t.Errorf("metrics file holds %q, want only the post-flush observation", data)
+	}
+}
+
+func TestConcurrentRecording(t *testing.T) {
+	town := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				At(town).Count(MetricWitnessNudges, Labels{"rig": "gastown"})
+			}
+		}()
+	}
+	wg.Wait()
+	snap, err := Load(town)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Series[0].Value != 200 {
+		t.Errorf("count = %v, want 200", snap.Series[0].Value)
+	}
+}
+
+func TestPrometheusFormat(t *testing.T) {
+	town := t.TempDir()
+	At(town).Count(MetricMerges, Labels{"rig": "gastown", "result": "merged"})
+	At(town).Observe(MetricMRQueueWait, 0.3, Labels{"rig": "gastown"})
+
+	srv := httptest.NewServer(Handler(town))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	out := string(body)
+
+	for _, want := range []string{
+		"# TYPE gt_merges_total counter\n",
+		`gt_merges_total{result="merged",rig="gastown"} 1`,
+		"# TYPE gt_mr_queue_wait_seconds histogram\n",
+		`gt_mr_queue_wait_seconds_bucket{rig="gastown",le="0.1"} 0`,
+		`gt_mr_queue_wait_seconds_bucket{rig="gastown",le="0.5"} 1`,
+		`gt_mr_queue_wait_seconds_bucket{rig="gastown",le="+Inf"} 1`,
+		`gt_mr_queue_wait_seconds_sum{rig="gastown"} 0.3`,
+		`gt_mr_queue_wait_seconds_count{rig="gastown"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestTraceIDFromKeys(t *testing.T) {
+	convoy := TraceID("", "hq-cv-1", "gt-abc")
+	if convoy != TraceID("hq-cv-1") || len(convoy) != 32 {
+		t.Errorf("TraceID = %q, want the convoy's 32-hex-digit ID", convoy)
+	}
+	if TraceID() == TraceID() {
+		t.Error("keyless trace IDs should be random")
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	town := t.TempDir()
+	rec := At(town)
+	rec.Count(MetricDone, Labels{"rig": "gastown", "exit": "COMPLETED"})
+	span := rec.StartSpan("gt done", Labels{"bead": "gt-abc"})
+	span.SetTrace("hq-cv-1")
+	span.Finish(errors.New("boom"))
+	rec.RecordSpan("polecat work", time.Now().Add(-time.Hour), time.Now(), nil, "gt-abc")
+
+	out := filepath.Join(town, "otlp.jsonl")
+	if err := Flush(context.Background(), town, []Exporter{NewFileExporter(out)}); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var payloads []map[string]json.RawMessage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var p map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			t.Fatalf("bad payload %s: %v", scanner.Text(), err)
+		}
+		payloads = append(payloads, p)
+	}
+	if len(payloads) != 2 || payloads[0]["resourceMetrics"] == nil || payloads[1]["resourceSpans"] == nil {
+		t.Fatalf("payloads = %v, want metrics then spans", payloads)
+	}
+
+	var traces otlpTracesRequest
+	if err := json.Unmarshal(payloads[1]["resourceSpans"], &traces.ResourceSpans); err != nil {
+		t.Fatal(err)
+	}
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 || spans[0].TraceID != TraceID("hq-cv-1") || spans[0].Status.Code != statusError {
+		t.Errorf("spans = %+v", spans)
+	}
+
+	// Exported spans are not exported again.
+	if err := Flush(context.Background(), town, []Exporter{NewFileExporter(out)}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	if strings.Count(string(data), "resourceSpans") != 1 {
+		t.Error("spans exported twice")
+	}
+}
+
+func TestOTLPExporterRequeuesSpans(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path+" "+r.Header.Get("Authorization"))
+		if fail && r.URL.Path == "/v1/traces" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	town := t.TempDir()
+	At(town).StartSpan("daemon heartbeat", nil).Finish(nil)
+	exp := NewOTLPExporter(srv.URL+"/", map[string]string{"Authorization": "Bearer t"})
+	if err := Flush(context.Background(), town, []Exporter{exp}); err == nil {
+		t.Fatal("Flush succeeded despite the collector rejecting traces")
+	}
+	fail = false
+	if err := Flush(context.Background(), town, []Exporter{exp}); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	want := "/v1/metrics Bearer t,/v1/traces Bearer t,/v1/metrics Bearer t,/v1/traces Bearer t"
+	if strings.Join(paths, ",") != want {
+		t.Errorf("requests = %v, want the span retried", paths)
+	}
+}
+
+func TestExportersFromConfig(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	if exps := Exporters("/town", nil); len(exps) != 0 {
+		t.Errorf("Exporters(nil) = %v, want none", exps)
+	}
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-token=abc, x-team = ops")
+	exps := Exporters("/town", nil)
+	if len(exps) != 1 {
+		t.Fatalf("Exporters = %v, want the env endpoint", exps)
+	}
+	otlp := exps[0].(*OTLPExporter)
+	if otlp.endpoint != "http://collector:4318" || otlp.headers["x-team"] != "ops" {
+		t.Errorf("exporter = %+v", otlp)
+	}
+}
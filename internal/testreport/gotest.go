@@ -0,0 +1,81 @@
+package testreport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// goTestEvent is one line of `go test -json` output (see `go doc test2json`).
+type goTestEvent struct {
+	Time    time.Time `json:"Time"`
+	Action  string    `json:"Action"`
+	Package string    `json:"Package"`
+	Test    string    `json:"Test"`
+	Elapsed float64   `json:"Elapsed"`
+	Output  string    `json:"Output"`
+}
+
+// ParseGoJSON parses the event stream written by `go test -json`. Lines
+// that are not JSON events (such as build errors printed by older
+// toolchains) are ignored.
+func ParseGoJSON(data []byte) (*Report, error) {
+	report := &Report{}
+	index := make(map[string]int) // Result.ID() -> index in report.Results
+	output := make(map[string]*strings.Builder)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	events := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var ev goTestEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			continue
+		}
+		events++
+
+		key := Result{Package: ev.Package, Name: ev.Test}.ID()
+		switch ev.Action {
+		case "output":
+			b, ok := output[key]
+			if !ok {
+				b = &strings.Builder{}
+				output[key] = b
+			}
+			b.WriteString(ev.Output)
+		case "pass", "fail", "skip":
+			res := Result{
+				Package: ev.Package,
+				Name:    ev.Test,
+				Status:  Status(ev.Action),
+				Elapsed: time.Duration(ev.Elapsed * float64(time.Second)),
+			}
+			if i, ok := index[key]; ok {
+				report.Results[i] = res // re-run within the same stream
+			} else {
+				index[key] = len(report.Results)
+				report.Results = append(report.Results, res)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading go test output: %w", err)
+	}
+	if events == 0 && len(bytes.TrimSpace(data)) > 0 {
+		return nil, fmt.Errorf("no go test -json events in output")
+	}
+
+	for i := range report.Results {
+		if b, ok := output[report.Results[i].ID()]; ok {
+			report.Results[i].Output = b.String()
+		}
+	}
+	return report, nil
+}
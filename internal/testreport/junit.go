@@ -0,0 +1,113 @@
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Cases  []junitCase  `xml:"testcase"`
+	Suites []junitSuite `xml:"testsuite"` // nested suites
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *junitProblem `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+	SystemErr string        `xml:"system-err"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// ParseJUnit parses a JUnit XML report. Both a <testsuites> root and a
+// bare <testsuite> root are accepted. A test case's package is its
+// classname, falling back to the suite name.
+func ParseJUnit(data []byte) (*Report, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parsing junit xml: %w", err)
+	}
+
+	var suites []junitSuite
+	switch root.XMLName.Local {
+	case "testsuites":
+		var all junitSuites
+		if err := xml.Unmarshal(data, &all); err != nil {
+			return nil, fmt.Errorf("parsing junit xml: %w", err)
+		}
+		suites = all.Suites
+	case "testsuite":
+		var one junitSuite
+		if err := xml.Unmarshal(data, &one); err != nil {
+			return nil, fmt.Errorf("parsing junit xml: %w", err)
+		}
+		suites = []junitSuite{one}
+	default:
+		return nil, fmt.Errorf("parsing junit xml: unexpected root element <%s>", root.XMLName.Local)
+	}
+
+	report := &Report{}
+	for _, s := range suites {
+		appendJUnitSuite(report, s)
+	}
+	return report, nil
+}
+
+func appendJUnitSuite(report *Report, s junitSuite) {
+	for _, c := range s.Cases {
+		res := Result{
+			Package: c.ClassName,
+			Name:    c.Name,
+			Status:  StatusPass,
+		}
+		if res.Package == "" {
+			res.Package = s.Name
+		}
+		if secs, err := strconv.ParseFloat(c.Time, 64); err == nil {
+			res.Elapsed = time.Duration(secs * float64(time.Second))
+		}
+
+		var out []string
+		switch {
+		case c.Failure != nil:
+			res.Status = StatusFail
+			out = append(out, c.Failure.Message, c.Failure.Body)
+		case c.Error != nil:
+			res.Status = StatusFail
+			out = append(out, c.Error.Message, c.Error.Body)
+		case c.Skipped != nil:
+			res.Status = StatusSkip
+			out = append(out, c.Skipped.Message)
+		}
+		out = append(out, c.SystemOut, c.SystemErr)
+
+		var parts []string
+		for _, o := range out {
+			if o = strings.TrimSpace(o); o != "" {
+				parts = append(parts, o)
+			}
+		}
+		res.Output = strings.Join(parts, "\n")
+		report.Results = append(report.Results, res)
+	}
+	for _, nested := range s.Suites {
+		appendJUnitSuite(report, nested)
+	}
+}
@@ -0,0 +1,157 @@
+// Package testreport parses structured test runner output into per-test
+// results. The refinery uses it to retry only failing tests, to track
+// flaky tests, and to tell polecats exactly which tests broke.
+//
+// Supported formats:
+//   - go-json: the event stream written by `go test -json`
+//   - junit:   JUnit XML reports (<testsuites> or a single <testsuite>)
+package testreport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Format names accepted by Parse.
+const (
+	FormatGoJSON = "go-json"
+	FormatJUnit  = "junit"
+)
+
+// Status is the outcome of a single test.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Result is the outcome of one test. A result with an empty Name is a
+// package-level outcome (for example a build failure or a panic in
+// TestMain).
+type Result struct {
+	Package string        `json:"package,omitempty"`
+	Name    string        `json:"name,omitempty"`
+	Status  Status        `json:"status"`
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+	Output  string        `json:"output,omitempty"`
+}
+
+// ID identifies the test across runs: "package.Name", or just the
+// package for package-level results.
+func (r Result) ID() string {
+	if r.Name == "" {
+		return r.Package
+	}
+	if r.Package == "" {
+		return r.Name
+	}
+	return r.Package + "." + r.Name
+}
+
+// TopLevel returns the top-level test name, without any subtest path.
+func (r Result) TopLevel() string {
+	name, _, _ := strings.Cut(r.Name, "/")
+	return name
+}
+
+// Report is the set of results from one test run, in the order the
+// runner reported them.
+type Report struct {
+	Results []Result
+}
+
+// Parse parses data in the given format.
+func Parse(format string, data []byte) (*Report, error) {
+	switch format {
+	case FormatGoJSON:
+		return ParseGoJSON(data)
+	case FormatJUnit:
+		return ParseJUnit(data)
+	default:
+		return nil, fmt.Errorf("unknown test report format %q", format)
+	}
+}
+
+// ValidFormat reports whether format is a supported report format.
+func ValidFormat(format string) bool {
+	return format == FormatGoJSON || format == FormatJUnit
+}
+
+// Failed returns the failing results. A package-level failure is included
+// only when none of the package's tests failed, since a failing test also
+// fails its package. When a subtest fails only the subtest is returned,
+// not its parent.
+func (r *Report) Failed() []Result {
+	failedPkgs := make(map[string]bool)
+	parents := make(map[string]bool)
+	for _, res := range r.Results {
+		if res.Status != StatusFail || res.Name == "" {
+			continue
+		}
+		failedPkgs[res.Package] = true
+		if i := strings.LastIndex(res.Name, "/"); i >= 0 {
+			parents[res.Package+"."+res.Name[:i]] = true
+		}
+	}
+
+	var failed []Result
+	for _, res := range r.Results {
+		if res.Status != StatusFail {
+			continue
+		}
+		if res.Name == "" && failedPkgs[res.Package] {
+			continue
+		}
+		if res.Name != "" && parents[res.Package+"."+res.Name] {
+			continue
+		}
+		failed = append(failed, res)
+	}
+	return failed
+}
+
+// Get returns the result for the test with the given ID.
+func (r *Report) Get(id string) (Result, bool) {
+	for _, res := range r.Results {
+		if res.ID() == id {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// TopLevelNames returns the sorted, de-duplicated top-level test names of
+// results, skipping package-level results.
+func TopLevelNames(results []Result) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, res := range results {
+		if res.Name == "" || seen[res.TopLevel()] {
+			continue
+		}
+		seen[res.TopLevel()] = true
+		names = append(names, res.TopLevel())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Excerpt returns the last maxLines non-empty lines of output, prefixed
+// with a marker when lines were dropped.
+func Excerpt(output string, maxLines int) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	if maxLines > 0 && len(lines) > maxLines {
+		dropped := len(lines) - maxLines
+		lines = append([]string{fmt.Sprintf("... (%d lines omitted)", dropped)}, lines[dropped:]...)
+	}
+	return strings.Join(lines, "\n")
+}
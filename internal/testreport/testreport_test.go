@@ -0,0 +1,129 @@
+package testreport
+
+import (
+	"strings"
+	"testing"
+)
+
+const goJSONSample = `{"Action":"start","Package":"example.com/a"}
+{"Action":"run","Package":"example.com/a","Test":"TestOK"}
+{"Action":"output","Package":"example.com/a","Test":"TestOK","Output":"=== RUN   TestOK\n"}
+{"Action":"pass","Package":"example.com/a","Test":"TestOK","Elapsed":0.01}
+{"Action":"run","Package":"example.com/a","Test":"TestBad"}
+{"Action":"output","Package":"example.com/a","Test":"TestBad","Output":"=== RUN   TestBad\n"}
+{"Action":"output","Package":"example.com/a","Test":"TestBad","Output":"    a_test.go:12: want 1, got 2\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestBad","Elapsed":0.02}
+{"Action":"run","Package":"example.com/a","Test":"TestTable/empty"}
+{"Action":"output","Package":"example.com/a","Test":"TestTable/empty","Output":"    a_test.go:30: boom\n"}
+{"Action":"fail","Package":"example.com/a","Test":"TestTable/empty","Elapsed":0}
+{"Action":"fail","Package":"example.com/a","Test":"TestTable","Elapsed":0}
+{"Action":"fail","Package":"example.com/a","Elapsed":0.05}
+# example.com/b
+b.go:3:1: syntax error
+{"Action":"output","Package":"example.com/b","Output":"FAIL\texample.com/b [build failed]\n"}
+{"Action":"fail","Package":"example.com/b","Elapsed":0}
+{"Action":"skip","Package":"example.com/c","Test":"TestSkip"}
+`
+
+func TestParseGoJSON(t *testing.T) {
+	report, err := Parse(FormatGoJSON, []byte(goJSONSample))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	failed := report.Failed()
+	var ids []string
+	for _, f := range failed {
+		ids = append(ids, f.ID())
+	}
+	want := []string{"example.com/a.TestBad", "example.com/a.TestTable/empty", "example.com/b"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("Failed() = %v, want %v", ids, want)
+	}
+
+	bad, ok := report.Get("example.com/a.TestBad")
+	if !ok || !strings.Contains(bad.Output, "want 1, got 2") {
+		t.Errorf("TestBad output = %q", bad.Output)
+	}
+	if bad.Elapsed.Milliseconds() != 20 {
+		t.Errorf("TestBad elapsed = %v", bad.Elapsed)
+	}
+	if skip, _ := report.Get("example.com/c.TestSkip"); skip.Status != StatusSkip {
+		t.Errorf("TestSkip status = %q", skip.Status)
+	}
+
+	if names := TopLevelNames(failed); strings.Join(names, ",") != "TestBad,TestTable" {
+		t.Errorf("TopLevelNames = %v", names)
+	}
+}
+
+func TestParseGoJSON_NoEvents(t *testing.T) {
+	if _, err := ParseGoJSON([]byte("ok  \texample.com/a\t0.01s\n")); err == nil {
+		t.Error("expected error for plain go test output")
+	}
+	if report, err := ParseGoJSON(nil); err != nil || len(report.Results) != 0 {
+		t.Errorf("empty input = %v, %v", report, err)
+	}
+}
+
+func TestParseJUnit(t *testing.T) {
+	data := `<?xml version="1.0"?>
+<testsuites>
+  <testsuite name="suite">
+    <testcase classname="pkg.Widget" name="test_ok" time="0.5"/>
+    <testcase classname="pkg.Widget" name="test_bad" time="1.25">
+      <failure message="assert failed">expected 1 == 2</failure>
+      <system-out>widget log line</system-out>
+    </testcase>
+    <testcase name="test_err"><error message="boom"/></testcase>
+    <testcase classname="pkg.Widget" name="test_skip"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+
+	report, err := Parse(FormatJUnit, []byte(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(report.Results) != 4 {
+		t.Fatalf("results = %d, want 4", len(report.Results))
+	}
+
+	failed := report.Failed()
+	if len(failed) != 2 {
+		t.Fatalf("failed = %v, want 2", failed)
+	}
+	if failed[0].ID() != "pkg.Widget.test_bad" || failed[0].Elapsed.Seconds() != 1.25 {
+		t.Errorf("failed[0] = %+v", failed[0])
+	}
+	for _, want := range []string{"assert failed", "expected 1 == 2", "widget log line"} {
+		if !strings.Contains(failed[0].Output, want) {
+			t.Errorf("failed[0] output missing %q:\n%s", want, failed[0].Output)
+		}
+	}
+	if failed[1].ID() != "suite.test_err" {
+		t.Errorf("failed[1] = %q, want suite classname fallback", failed[1].ID())
+	}
+}
+
+func TestParseJUnit_SingleSuite(t *testing.T) {
+	report, err := ParseJUnit([]byte(`<testsuite name="s"><testcase name="a"/></testsuite>`))
+	if err != nil {
+		t.Fatalf("ParseJUnit: %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Status != StatusPass {
+		t.Errorf("results = %+v", report.Results)
+	}
+	if _, err := ParseJUnit([]byte(`<html/>`)); err == nil {
+		t.Error("expected error for non-junit root")
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	out := "a\n\nb\nc\nd\n"
+	if got := Excerpt(out, 2); got != "... (2 lines omitted)\nc\nd" {
+		t.Errorf("Excerpt = %q", got)
+	}
+	if got := Excerpt(out, 0); got != "a\nb\nc\nd" {
+		t.Errorf("Excerpt unlimited = %q", got)
+	}
+}
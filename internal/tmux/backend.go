@@ -0,0 +1,90 @@
+package tmux
+
+import "sync"
+
+// SessionBackend is the set of session operations agent lifecycle code
+// relies on: create, kill, keys, capture, environment, liveness and the
+// pane-died hook. Tmux implements it by running tmux; the ptyd package
+// implements it with PTYs supervised by the daemon, for hosts without tmux.
+//
+// Panes are addressed by session name on backends with one pane per
+// session, so GetPaneID may simply return the session name.
+type SessionBackend interface {
+	// Name identifies the backend ("tmux", "pty").
+	Name() string
+	// IsAvailable reports whether the backend can host sessions.
+	IsAvailable() bool
+
+	NewSession(name, workDir string) error
+	KillSession(name string) error
+	HasSession(name string) (bool, error)
+	ListSessions() ([]string, error)
+	GetSessionInfo(name string) (*SessionInfo, error)
+	AttachSession(session string) error
+
+	// SendLiteral types text into the session without pressing Enter.
+	SendLiteral(session, text string) error
+	// SendKeysRaw sends a named key ("Enter", "C-c", "Down", ...).
+	SendKeysRaw(session, keys string) error
+
+	CapturePane(session string, lines int) (string, error)
+	CapturePaneAll(session string) (string, error)
+	ClearHistory(pane string) error
+
+	SetEnvironment(session, key, value string) error
+	GetEnvironment(session, key string) (string, error)
+
+	// GetPaneCommand returns the foreground command, for liveness checks.
+	GetPaneCommand(session string) (string, error)
+	GetPaneID(session string) (string, error)
+	GetPaneWorkDir(session string) (string, error)
+	RespawnPane(pane, command string) error
+	SetPaneDiedHook(session, agentID string) error
+}
+
+var _ SessionBackend = (*Tmux)(nil)
+
+var (
+	defaultBackendMu sync.RWMutex
+	defaultBackend   SessionBackend
+)
+
+// SetDefaultBackend makes NewTmux delegate session operations to b. The
+// CLI and daemon call it at startup when the town selects a non-tmux
+// backend; nil restores plain tmux.
+func SetDefaultBackend(b SessionBackend) {
+	defaultBackendMu.Lock()
+	defer defaultBackendMu.Unlock()
+	defaultBackend = b
+}
+
+// DefaultBackend returns the backend set by SetDefaultBackend, or nil.
+func DefaultBackend() SessionBackend {
+	defaultBackendMu.RLock()
+	defer defaultBackendMu.RUnlock()
+	return defaultBackend
+}
+
+// NewTmuxWithBackend creates a Tmux wrapper that delegates session
+// operations to b. tmux-only features (themes, status line, key bindings)
+// become no-ops.
+func NewTmuxWithBackend(b SessionBackend) *Tmux {
+	return &Tmux{backend: b}
+}
+
+// Name returns the name of the backend hosting sessions.
+func (t *Tmux) Name() string {
+	if t.backend != nil {
+		return t.backend.Name()
+	}
+	return "tmux"
+}
+
+// SendLiteral types text into a session in literal mode, without Enter.
+func (t *Tmux) SendLiteral(session, text string) error {
+	if t.backend != nil {
+		return t.backend.SendLiteral(session, text)
+	}
+	_, err := t.run("send-keys", "-t", session, "-l", text)
+	return err
+}
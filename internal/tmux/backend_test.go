@@ -0,0 +1,95 @@
+package tmux
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingBackend is a SessionBackend that records what it is sent.
+type recordingBackend struct {
+	sent    []string
+	capture string
+}
+
+func (b *recordingBackend) Name() string                          { return "fake" }
+func (b *recordingBackend) IsAvailable() bool                     { return true }
+func (b *recordingBackend) NewSession(name, workDir string) error { return nil }
+func (b *recordingBackend) KillSession(name string) error         { return nil }
+func (b *recordingBackend) HasSession(name string) (bool, error)  { return name == "gt-x", nil }
+func (b *recordingBackend) ListSessions() ([]string, error)       { return []string{"gt-x"}, nil }
+func (b *recordingBackend) GetSessionInfo(name string) (*SessionInfo, error) {
+	return &SessionInfo{Name: name}, nil
+}
+func (b *recordingBackend) AttachSession(session string) error { return nil }
+func (b *recordingBackend) SendLiteral(session, text string) error {
+	b.sent = append(b.sent, "literal:"+text)
+	return nil
+}
+func (b *recordingBackend) SendKeysRaw(session, keys string) error {
+	b.sent = append(b.sent, "key:"+keys)
+	return nil
+}
+func (b *recordingBackend) CapturePane(session string, lines int) (string, error) {
+	return b.capture, nil
+}
+func (b *recordingBackend) CapturePaneAll(session string) (string, error) { return b.capture, nil }
+func (b *recordingBackend) ClearHistory(pane string) error                { return nil }
+func (b *recordingBackend) SetEnvironment(session, key, value string) error {
+	return nil
+}
+func (b *recordingBackend) GetEnvironment(session, key string) (string, error) { return "", nil }
+func (b *recordingBackend) GetPaneCommand(session string) (string, error)      { return "node", nil }
+func (b *recordingBackend) GetPaneID(session string) (string, error)           { return session, nil }
+func (b *recordingBackend) GetPaneWorkDir(session string) (string, error)      { return "/", nil }
+func (b *recordingBackend) RespawnPane(pane, command string) error             { return nil }
+func (b *recordingBackend) SetPaneDiedHook(session, agentID string) error      { return nil }
+
+func TestBackend_Delegates(t *testing.T) {
+	b := &recordingBackend{capture: "working\n> "}
+	tm := NewTmuxWithBackend(b)
+
+	if err := tm.NudgeSession("gt-x", "hello"); err != nil {
+		t.Fatalf("NudgeSession: %v", err)
+	}
+	if got := strings.Join(b.sent, ","); got != "literal:hello,key:Enter" {
+		t.Errorf("sent = %q, want literal text then Enter", got)
+	}
+	if !tm.IsAgentRunning("gt-x") {
+		t.Error("IsAgentRunning = false, want backend's pane command used")
+	}
+	if err := tm.WaitForClaudeReady("gt-x", time.Second); err != nil {
+		t.Errorf("WaitForClaudeReady: %v", err)
+	}
+	ids, err := tm.ListSessionIDs()
+	if err != nil || ids["gt-x"] != "gt-x" {
+		t.Errorf("ListSessionIDs = %v, %v", ids, err)
+	}
+}
+
+func TestBackend_TmuxOnlyFeatures(t *testing.T) {
+	tm := NewTmuxWithBackend(&recordingBackend{})
+
+	// Cosmetics are skipped rather than failing session startup.
+	if err := tm.ConfigureGasTownSession("gt-x", DefaultPalette[0], "rig", "worker", "polecat"); err != nil {
+		t.Errorf("ConfigureGasTownSession: %v", err)
+	}
+	// Anything else that needs tmux itself says so.
+	err := tm.RenameSession("gt-x", "gt-y")
+	if err == nil || !strings.Contains(err.Error(), "not supported by the fake session backend") {
+		t.Errorf("RenameSession err = %v", err)
+	}
+}
+
+func TestSetDefaultBackend(t *testing.T) {
+	b := &recordingBackend{}
+	SetDefaultBackend(b)
+	defer SetDefaultBackend(nil)
+
+	if got := NewTmux().Name(); got != "fake" {
+		t.Errorf("NewTmux().Name() = %q, want default backend", got)
+	}
+	if got := NewTmuxWithRunner(nil).Name(); got != "tmux" {
+		t.Errorf("NewTmuxWithRunner().Name() = %q, want tmux", got)
+	}
+}
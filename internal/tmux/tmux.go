@@ -21,16 +21,44 @@ var (
 	ErrSessionNotFound = errors.New("session not found")
 )
 
+// Runner executes commands on the machine hosting the tmux server, which
+// lets the same Tmux API drive sessions on a remote machine. It mirrors
+// connection.Runner (git and beads use that one directly); tmux can't
+// import the connection package, which imports tmux.
+type Runner interface {
+	Run(dir string, env map[string]string, cmd string, args ...string) (stdout, stderr []byte, err error)
+}
+
 // Tmux wraps tmux operations.
-type Tmux struct{}
+type Tmux struct {
+	runner  Runner         // nil runs tmux as a local subprocess
+	backend SessionBackend // non-nil delegates sessions to another backend
+}
 
-// NewTmux creates a new Tmux wrapper.
+// NewTmux creates a new Tmux wrapper. If the town selected another session
+// backend (see SetDefaultBackend), session operations go to it instead.
 func NewTmux() *Tmux {
-	return &Tmux{}
+	return &Tmux{backend: DefaultBackend()}
+}
+
+// NewTmuxWithRunner creates a Tmux wrapper that runs tmux through r.
+func NewTmuxWithRunner(r Runner) *Tmux {
+	return &Tmux{runner: r}
 }
 
 // run executes a tmux command and returns stdout.
 func (t *Tmux) run(args ...string) (string, error) {
+	if t.backend != nil {
+		return "", fmt.Errorf("tmux %s is not supported by the %s session backend", args[0], t.backend.Name())
+	}
+	if t.runner != nil {
+		stdout, stderr, err := t.runner.Run("", nil, "tmux", args...)
+		if err != nil {
+			return "", t.wrapError(err, string(stderr), args)
+		}
+		return strings.TrimSpace(string(stdout)), nil
+	}
+
 	cmd := exec.Command("tmux", args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -69,6 +97,9 @@ func (t *Tmux) wrapError(err error, stderr string, args []string) error {
 
 // NewSession creates a new detached tmux session.
 func (t *Tmux) NewSession(name, workDir string) error {
+	if t.backend != nil {
+		return t.backend.NewSession(name, workDir)
+	}
 	args := []string{"new-session", "-d", "-s", name}
 	if workDir != "" {
 		args = append(args, "-c", workDir)
@@ -113,12 +144,25 @@ func (t *Tmux) EnsureSessionFresh(name, workDir string) error {
 
 // KillSession terminates a tmux session.
 func (t *Tmux) KillSession(name string) error {
+	if t.backend != nil {
+		return t.backend.KillSession(name)
+	}
 	_, err := t.run("kill-session", "-t", name)
 	return err
 }
 
 // KillServer terminates the entire tmux server and all sessions.
 func (t *Tmux) KillServer() error {
+	if t.backend != nil {
+		sessions, err := t.backend.ListSessions()
+		if err != nil {
+			return err
+		}
+		for _, name := range sessions {
+			_ = t.backend.KillSession(name)
+		}
+		return nil
+	}
 	_, err := t.run("kill-server")
 	if errors.Is(err, ErrNoServer) {
 		return nil // Already dead
@@ -128,6 +172,13 @@ func (t *Tmux) KillServer() error {
 
 // IsAvailable checks if tmux is installed and can be invoked.
 func (t *Tmux) IsAvailable() bool {
+	if t.backend != nil {
+		return t.backend.IsAvailable()
+	}
+	if t.runner != nil {
+		_, _, err := t.runner.Run("", nil, "tmux", "-V")
+		return err == nil
+	}
 	cmd := exec.Command("tmux", "-V")
 	return cmd.Run() == nil
 }
@@ -136,6 +187,9 @@ func (t *Tmux) IsAvailable() bool {
 // Uses "=" prefix for exact matching, preventing prefix matches
 // (e.g., "gt-deacon-boot" won't match when checking for "gt-deacon").
 func (t *Tmux) HasSession(name string) (bool, error) {
+	if t.backend != nil {
+		return t.backend.HasSession(name)
+	}
 	_, err := t.run("has-session", "-t", "="+name)
 	if err != nil {
 		if errors.Is(err, ErrSessionNotFound) || errors.Is(err, ErrNoServer) {
@@ -148,6 +202,9 @@ func (t *Tmux) HasSession(name string) (bool, error) {
 
 // ListSessions returns all session names.
 func (t *Tmux) ListSessions() ([]string, error) {
+	if t.backend != nil {
+		return t.backend.ListSessions()
+	}
 	out, err := t.run("list-sessions", "-F", "#{session_name}")
 	if err != nil {
 		if errors.Is(err, ErrNoServer) {
@@ -164,8 +221,20 @@ func (t *Tmux) ListSessions() ([]string, error) {
 }
 
 // ListSessionIDs returns a map of session name to session ID.
-// Session IDs are in the format "$N" where N is a number.
+// Session IDs are in the format "$N" where N is a number. Other backends
+// have no separate IDs, so sessions map to their own names.
 func (t *Tmux) ListSessionIDs() (map[string]string, error) {
+	if t.backend != nil {
+		sessions, err := t.backend.ListSessions()
+		if err != nil || len(sessions) == 0 {
+			return nil, err
+		}
+		result := make(map[string]string, len(sessions))
+		for _, name := range sessions {
+			result[name] = name
+		}
+		return result, nil
+	}
 	out, err := t.run("list-sessions", "-F", "#{session_name}:#{session_id}")
 	if err != nil {
 		if errors.Is(err, ErrNoServer) {
@@ -206,7 +275,7 @@ func (t *Tmux) SendKeys(session, keys string) error {
 // This prevents race conditions where Enter arrives before paste is processed.
 func (t *Tmux) SendKeysDebounced(session, keys string, debounceMs int) error {
 	// Send text using literal mode (-l) to handle special chars
-	if _, err := t.run("send-keys", "-t", session, "-l", keys); err != nil {
+	if err := t.SendLiteral(session, keys); err != nil {
 		return err
 	}
 	// Wait for paste to be processed
@@ -214,12 +283,14 @@ func (t *Tmux) SendKeysDebounced(session, keys string, debounceMs int) error {
 		time.Sleep(time.Duration(debounceMs) * time.Millisecond)
 	}
 	// Send Enter separately - more reliable than appending to send-keys
-	_, err := t.run("send-keys", "-t", session, "Enter")
-	return err
+	return t.SendKeysRaw(session, "Enter")
 }
 
 // SendKeysRaw sends keystrokes without adding Enter.
 func (t *Tmux) SendKeysRaw(session, keys string) error {
+	if t.backend != nil {
+		return t.backend.SendKeysRaw(session, keys)
+	}
 	_, err := t.run("send-keys", "-t", session, keys)
 	return err
 }
@@ -230,7 +301,7 @@ func (t *Tmux) SendKeysRaw(session, keys string) error {
 // The delay parameter controls how long to wait after clearing before sending (ms).
 func (t *Tmux) SendKeysReplace(session, keys string, clearDelayMs int) error {
 	// Send Ctrl-U to clear any pending input on the line
-	if _, err := t.run("send-keys", "-t", session, "C-u"); err != nil {
+	if err := t.SendKeysRaw(session, "C-u"); err != nil {
 		return err
 	}
 
@@ -268,7 +339,7 @@ func (t *Tmux) SendKeysDelayedDebounced(session, keys string, preDelayMs, deboun
 // Verification is the Witness's job (AI), not this function.
 func (t *Tmux) NudgeSession(session, message string) error {
 	// 1. Send text in literal mode (handles special characters)
-	if _, err := t.run("send-keys", "-t", session, "-l", message); err != nil {
+	if err := t.SendLiteral(session, message); err != nil {
 		return err
 	}
 
@@ -281,7 +352,7 @@ func (t *Tmux) NudgeSession(session, message string) error {
 		if attempt > 0 {
 			time.Sleep(200 * time.Millisecond)
 		}
-		if _, err := t.run("send-keys", "-t", session, "Enter"); err != nil {
+		if err := t.SendKeysRaw(session, "Enter"); err != nil {
 			lastErr = err
 			continue
 		}
@@ -294,7 +365,7 @@ func (t *Tmux) NudgeSession(session, message string) error {
 // Same pattern as NudgeSession but targets a pane ID (e.g., "%9") instead of session name.
 func (t *Tmux) NudgePane(pane, message string) error {
 	// 1. Send text in literal mode (handles special characters)
-	if _, err := t.run("send-keys", "-t", pane, "-l", message); err != nil {
+	if err := t.SendLiteral(pane, message); err != nil {
 		return err
 	}
 
@@ -307,7 +378,7 @@ func (t *Tmux) NudgePane(pane, message string) error {
 		if attempt > 0 {
 			time.Sleep(200 * time.Millisecond)
 		}
-		if _, err := t.run("send-keys", "-t", pane, "Enter"); err != nil {
+		if err := t.SendKeysRaw(pane, "Enter"); err != nil {
 			lastErr = err
 			continue
 		}
@@ -341,7 +412,7 @@ func (t *Tmux) AcceptBypassPermissionsWarning(session string) error {
 	}
 
 	// Press Down to select "Yes, I accept" (option 2)
-	if _, err := t.run("send-keys", "-t", session, "Down"); err != nil {
+	if err := t.SendKeysRaw(session, "Down"); err != nil {
 		return err
 	}
 
@@ -349,16 +420,15 @@ func (t *Tmux) AcceptBypassPermissionsWarning(session string) error {
 	time.Sleep(200 * time.Millisecond)
 
 	// Press Enter to confirm
-	if _, err := t.run("send-keys", "-t", session, "Enter"); err != nil {
-		return err
-	}
-
-	return nil
+	return t.SendKeysRaw(session, "Enter")
 }
 
 // GetPaneCommand returns the current command running in a pane.
 // Returns "bash", "zsh", "claude", "node", etc.
 func (t *Tmux) GetPaneCommand(session string) (string, error) {
+	if t.backend != nil {
+		return t.backend.GetPaneCommand(session)
+	}
 	out, err := t.run("list-panes", "-t", session, "-F", "#{pane_current_command}")
 	if err != nil {
 		return "", err
@@ -369,6 +439,9 @@ func (t *Tmux) GetPaneCommand(session string) (string, error) {
 // GetPaneID returns the pane identifier for a session's first pane.
 // Returns a pane ID like "%0" that can be used with RespawnPane.
 func (t *Tmux) GetPaneID(session string) (string, error) {
+	if t.backend != nil {
+		return t.backend.GetPaneID(session)
+	}
 	out, err := t.run("list-panes", "-t", session, "-F", "#{pane_id}")
 	if err != nil {
 		return "", err
@@ -382,6 +455,9 @@ func (t *Tmux) GetPaneID(session string) (string, error) {
 
 // GetPaneWorkDir returns the current working directory of a pane.
 func (t *Tmux) GetPaneWorkDir(session string) (string, error) {
+	if t.backend != nil {
+		return t.backend.GetPaneWorkDir(session)
+	}
 	out, err := t.run("list-panes", "-t", session, "-F", "#{pane_current_path}")
 	if err != nil {
 		return "", err
@@ -427,11 +503,17 @@ func (t *Tmux) FindSessionByWorkDir(targetDir string, checkAgent bool) ([]string
 
 // CapturePane captures the visible content of a pane.
 func (t *Tmux) CapturePane(session string, lines int) (string, error) {
+	if t.backend != nil {
+		return t.backend.CapturePane(session, lines)
+	}
 	return t.run("capture-pane", "-p", "-t", session, "-S", fmt.Sprintf("-%d", lines))
 }
 
 // CapturePaneAll captures all scrollback history.
 func (t *Tmux) CapturePaneAll(session string) (string, error) {
+	if t.backend != nil {
+		return t.backend.CapturePaneAll(session)
+	}
 	return t.run("capture-pane", "-p", "-t", session, "-S", "-")
 }
 
@@ -450,6 +532,9 @@ func (t *Tmux) CapturePaneLines(session string, lines int) ([]string, error) {
 // AttachSession attaches to an existing session.
 // Note: This replaces the current process with tmux attach.
 func (t *Tmux) AttachSession(session string) error {
+	if t.backend != nil {
+		return t.backend.AttachSession(session)
+	}
 	_, err := t.run("attach-session", "-t", session)
 	return err
 }
@@ -462,12 +547,18 @@ func (t *Tmux) SelectWindow(session string, index int) error {
 
 // SetEnvironment sets an environment variable in the session.
 func (t *Tmux) SetEnvironment(session, key, value string) error {
+	if t.backend != nil {
+		return t.backend.SetEnvironment(session, key, value)
+	}
 	_, err := t.run("set-environment", "-t", session, key, value)
 	return err
 }
 
 // GetEnvironment gets an environment variable from the session.
 func (t *Tmux) GetEnvironment(session, key string) (string, error) {
+	if t.backend != nil {
+		return t.backend.GetEnvironment(session, key)
+	}
 	out, err := t.run("show-environment", "-t", session, key)
 	if err != nil {
 		return "", err
@@ -500,6 +591,9 @@ type SessionInfo struct {
 // This is non-disruptive - it doesn't interrupt the session's input.
 // Duration is specified in milliseconds.
 func (t *Tmux) DisplayMessage(session, message string, durationMs int) error {
+	if t.backend != nil {
+		return nil // tmux-only feature
+	}
 	// Set display time temporarily, show message, then restore
 	// Use -d flag for duration in tmux 2.9+
 	_, err := t.run("display-message", "-t", session, "-d", fmt.Sprintf("%d", durationMs), message)
@@ -637,6 +731,9 @@ func (t *Tmux) WaitForClaudeReady(session string, timeout time.Duration) error {
 
 // GetSessionInfo returns detailed information about a session.
 func (t *Tmux) GetSessionInfo(name string) (*SessionInfo, error) {
+	if t.backend != nil {
+		return t.backend.GetSessionInfo(name)
+	}
 	format := "#{session_name}|#{session_windows}|#{session_created_string}|#{session_attached}|#{session_activity}|#{session_last_attached}"
 	out, err := t.run("list-sessions", "-F", format, "-f", fmt.Sprintf("#{==:#{session_name},%s}", name))
 	if err != nil {
@@ -674,6 +771,9 @@ func (t *Tmux) GetSessionInfo(name string) (*SessionInfo, error) {
 
 // ApplyTheme sets the status bar style for a session.
 func (t *Tmux) ApplyTheme(session string, theme Theme) error {
+	if t.backend != nil {
+		return nil // tmux-only feature
+	}
 	_, err := t.run("set-option", "-t", session, "status-style", theme.Style())
 	return err
 }
@@ -697,6 +797,9 @@ var roleIcons = map[string]string{
 // SetStatusFormat configures the left side of the status bar.
 // Shows compact identity: icon + minimal context
 func (t *Tmux) SetStatusFormat(session, rig, worker, role string) error {
+	if t.backend != nil {
+		return nil // tmux-only feature
+	}
 	// Get icon for role (empty string if not found)
 	icon := roleIcons[role]
 
@@ -726,6 +829,9 @@ func (t *Tmux) SetStatusFormat(session, rig, worker, role string) error {
 // SetDynamicStatus configures the right side with dynamic content.
 // Uses a shell command that tmux calls periodically to get current status.
 func (t *Tmux) SetDynamicStatus(session string) error {
+	if t.backend != nil {
+		return nil // tmux-only feature
+	}
 	// tmux calls this command every status-interval seconds
 	// gt status-line reads env vars and mail to build the status
 	right := fmt.Sprintf(`#(gt status-line --session=%s 2>/dev/null) %%H:%%M`, session)
@@ -774,6 +880,9 @@ func IsInsideTmux() bool {
 // SetMailClickBinding configures left-click on status-right to show mail preview.
 // This creates a popup showing the first unread message when clicking the mail icon area.
 func (t *Tmux) SetMailClickBinding(session string) error {
+	if t.backend != nil {
+		return nil // tmux-only feature
+	}
 	// Bind left-click on status-right to show mail popup
 	// The popup runs gt mail peek and closes on any key
 	_, err := t.run("bind-key", "-T", "root", "MouseDown1StatusRight",
@@ -785,6 +894,9 @@ func (t *Tmux) SetMailClickBinding(session string) error {
 // This is used for "hot reload" of agent sessions - instantly restart in place.
 // The pane parameter should be a pane ID (e.g., "%0") or session:window.pane format.
 func (t *Tmux) RespawnPane(pane, command string) error {
+	if t.backend != nil {
+		return t.backend.RespawnPane(pane, command)
+	}
 	_, err := t.run("respawn-pane", "-k", "-t", pane, command)
 	return err
 }
@@ -793,6 +905,9 @@ func (t *Tmux) RespawnPane(pane, command string) error {
 // This resets copy-mode display from [0/N] to [0/0].
 // The pane parameter should be a pane ID (e.g., "%0") or session:window.pane format.
 func (t *Tmux) ClearHistory(pane string) error {
+	if t.backend != nil {
+		return t.backend.ClearHistory(pane)
+	}
 	_, err := t.run("clear-history", "-t", pane)
 	return err
 }
@@ -837,6 +952,9 @@ func (t *Tmux) SetTownCycleBindings(session string) error {
 // reliably preserve the session context. tmux expands #{session_name} at binding
 // resolution time (when the key is pressed), giving us the correct session.
 func (t *Tmux) SetCycleBindings(session string) error {
+	if t.backend != nil {
+		return nil // tmux-only feature
+	}
 	// C-b n → gt cycle next for GT sessions, next-window otherwise
 	// The if-shell checks if session name starts with "gt-"
 	if _, err := t.run("bind-key", "-T", "prefix", "n",
@@ -863,6 +981,9 @@ func (t *Tmux) SetCycleBindings(session string) error {
 // (those starting with "gt-"). For non-GT sessions, a help message is shown.
 // See: https://github.com/steveyegge/gastown/issues/13
 func (t *Tmux) SetFeedBinding(session string) error {
+	if t.backend != nil {
+		return nil // tmux-only feature
+	}
 	// C-b a → gt feed --window for GT sessions, help message otherwise
 	_, err := t.run("bind-key", "-T", "prefix", "a",
 		"if-shell", "echo '#{session_name}' | grep -q '^gt-'",
@@ -875,6 +996,9 @@ func (t *Tmux) SetFeedBinding(session string) error {
 // When the pane exits, tmux runs the hook command with exit status info.
 // The agentID is used to identify the agent in crash logs (e.g., "gastown/Toast").
 func (t *Tmux) SetPaneDiedHook(session, agentID string) error {
+	if t.backend != nil {
+		return t.backend.SetPaneDiedHook(session, agentID)
+	}
 	// Hook command logs the crash with exit status
 	// #{pane_dead_status} is the exit code of the process that died
 	// We run gt log crash which records to the town log
@@ -1,28 +1,15 @@
 package convoy
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"fmt"
-	"os/exec"
-	"path/filepath"
-	"regexp"
 	"sort"
-	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/steveyegge/gastown/internal/beads"
 )
 
-// convoyIDPattern validates convoy IDs to prevent SQL injection.
-var convoyIDPattern = regexp.MustCompile(`^hq-[a-zA-Z0-9-]+$`)
-
-// subprocessTimeout is the timeout for bd and sqlite3 calls.
-const subprocessTimeout = 5 * time.Second
-
 // IssueItem represents a tracked issue within a convoy.
 type IssueItem struct {
 	ID     string
@@ -84,32 +71,17 @@ func (m Model) fetchConvoys() tea.Msg {
 
 // loadConvoys loads convoy data from the beads directory.
 func loadConvoys(townBeads string) ([]ConvoyItem, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), subprocessTimeout)
-	defer cancel()
+	b := beads.New(townBeads).Cached()
 
 	// Get list of open convoys
-	listArgs := []string{"list", "--type=convoy", "--json"}
-	listCmd := exec.CommandContext(ctx, "bd", listArgs...)
-	listCmd.Dir = townBeads
-	var stdout bytes.Buffer
-	listCmd.Stdout = &stdout
-
-	if err := listCmd.Run(); err != nil {
+	rawConvoys, err := b.List(beads.ListOptions{Type: "convoy", Priority: -1})
+	if err != nil {
 		return nil, fmt.Errorf("listing convoys: %w", err)
 	}
 
-	var rawConvoys []struct {
-		ID     string `json:"id"`
-		Title  string `json:"title"`
-		Status string `json:"status"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &rawConvoys); err != nil {
-		return nil, fmt.Errorf("parsing convoy list: %w", err)
-	}
-
 	convoys := make([]ConvoyItem, 0, len(rawConvoys))
 	for _, rc := range rawConvoys {
-		issues, completed, total := loadTrackedIssues(townBeads, rc.ID)
+		issues, completed, total := loadTrackedIssues(b, rc.ID)
 		convoys = append(convoys, ConvoyItem{
 			ID:       rc.ID,
 			Title:    rc.Title,
@@ -124,54 +96,20 @@ func loadConvoys(townBeads string) ([]ConvoyItem, error) {
 }
 
 // loadTrackedIssues loads issues tracked by a convoy.
-func loadTrackedIssues(townBeads, convoyID string) ([]IssueItem, int, int) {
-	// Validate convoy ID to prevent SQL injection
-	if !convoyIDPattern.MatchString(convoyID) {
-		return nil, 0, 0
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), subprocessTimeout)
-	defer cancel()
-
-	dbPath := filepath.Join(townBeads, "beads.db")
-
-	// Query tracked issues from SQLite (ID validated above)
-	query := fmt.Sprintf(`
-		SELECT d.depends_on_id
-		FROM dependencies d
-		WHERE d.issue_id = '%s' AND d.type = 'tracks'
-	`, convoyID)
-
-	cmd := exec.CommandContext(ctx, "sqlite3", "-json", dbPath, query) //nolint:gosec // G204: sqlite3 with controlled query
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
-		return nil, 0, 0
-	}
-
-	var deps []struct {
-		DependsOnID string `json:"depends_on_id"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &deps); err != nil {
+func loadTrackedIssues(b *beads.Beads, convoyID string) ([]IssueItem, int, int) {
+	deps, err := b.Dependencies(convoyID, beads.DepTracks)
+	if err != nil {
 		return nil, 0, 0
 	}
 
 	// Collect issue IDs, handling external references
 	issueIDs := make([]string, 0, len(deps))
 	for _, dep := range deps {
-		issueID := dep.DependsOnID
-		if strings.HasPrefix(issueID, "external:") {
-			parts := strings.SplitN(issueID, ":", 3)
-			if len(parts) == 3 {
-				issueID = parts[2]
-			}
-		}
-		issueIDs = append(issueIDs, issueID)
+		issueIDs = append(issueIDs, beads.ExternalRefID(dep.DependsOnID))
 	}
 
-	// Batch fetch all issue details in one call
-	detailsMap := getIssueDetailsBatch(townBeads, issueIDs)
+	// Batch fetch all issue details in one lookup
+	detailsMap := getIssueDetailsBatch(b, issueIDs)
 
 	issues := make([]IssueItem, 0, len(deps))
 	completed := 0
@@ -195,41 +133,21 @@ func loadTrackedIssues(townBeads, convoyID string) ([]IssueItem, int, int) {
 	return issues, completed, len(issues)
 }
 
-// getIssueDetailsBatch fetches details for multiple issues in a single bd show call.
+// getIssueDetailsBatch fetches details for multiple issues in one lookup.
 // Returns a map from issue ID to details.
-func getIssueDetailsBatch(townBeads string, issueIDs []string) map[string]IssueItem {
+func getIssueDetailsBatch(b *beads.Beads, issueIDs []string) map[string]IssueItem {
 	result := make(map[string]IssueItem)
 	if len(issueIDs) == 0 {
 		return result
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), subprocessTimeout)
-	defer cancel()
-
-	// Build args: bd show id1 id2 id3 ... --json
-	args := append([]string{"show"}, issueIDs...)
-	args = append(args, "--json")
-
-	cmd := exec.CommandContext(ctx, "bd", args...) //nolint:gosec // G204: bd is a trusted internal tool
-	cmd.Dir = townBeads
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
+	issues, err := b.ShowMultiple(issueIDs)
+	if err != nil {
 		return result // Return empty map on error
 	}
 
-	var issues []struct {
-		ID     string `json:"id"`
-		Title  string `json:"title"`
-		Status string `json:"status"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil {
-		return result
-	}
-
-	for _, issue := range issues {
-		result[issue.ID] = IssueItem{
+	for id, issue := range issues {
+		result[id] = IssueItem{
 			ID:     issue.ID,
 			Title:  issue.Title,
 			Status: issue.Status,
@@ -1,27 +1,16 @@
 package feed
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"fmt"
-	"os/exec"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/steveyegge/gastown/internal/beads"
 )
 
-// convoyIDPattern validates convoy IDs to prevent SQL injection
-var convoyIDPattern = regexp.MustCompile(`^hq-[a-zA-Z0-9-]+$`)
-
-// convoySubprocessTimeout is the timeout for bd and sqlite3 calls in the convoy panel.
-// Prevents TUI freezing if these commands hang.
-const convoySubprocessTimeout = 5 * time.Second
-
 // Convoy represents a convoy's status for the dashboard
 type Convoy struct {
 	ID        string    `json:"id"`
@@ -42,7 +31,7 @@ type ConvoyState struct {
 
 // FetchConvoys retrieves convoy status from town-level beads
 func FetchConvoys(townRoot string) (*ConvoyState, error) {
-	townBeads := filepath.Join(townRoot, ".beads")
+	townBeads := beads.New(filepath.Join(townRoot, ".beads")).Cached()
 
 	state := &ConvoyState{
 		InProgress: make([]Convoy, 0),
@@ -87,39 +76,12 @@ func FetchConvoys(townRoot string) (*ConvoyState, error) {
 }
 
 // listConvoys returns convoys with the given status
-func listConvoys(beadsDir, status string) ([]convoyListItem, error) {
-	listArgs := []string{"list", "--type=convoy", "--status=" + status, "--json"}
-
-	ctx, cancel := context.WithTimeout(context.Background(), convoySubprocessTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "bd", listArgs...) //nolint:gosec // G204: args are constructed internally
-	cmd.Dir = beadsDir
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
-		return nil, err
-	}
-
-	var items []convoyListItem
-	if err := json.Unmarshal(stdout.Bytes(), &items); err != nil {
-		return nil, err
-	}
-
-	return items, nil
-}
-
-type convoyListItem struct {
-	ID        string `json:"id"`
-	Title     string `json:"title"`
-	Status    string `json:"status"`
-	CreatedAt string `json:"created_at"`
-	ClosedAt  string `json:"closed_at,omitempty"`
+func listConvoys(b *beads.Beads, status string) ([]*beads.Issue, error) {
+	return b.List(beads.ListOptions{Type: "convoy", Status: status, Priority: -1})
 }
 
 // enrichConvoy adds tracked issue counts to a convoy
-func enrichConvoy(beadsDir string, item convoyListItem) Convoy {
+func enrichConvoy(b *beads.Beads, item *beads.Issue) Convoy {
 	convoy := Convoy{
 		ID:     item.ID,
 		Title:  item.Title,
@@ -139,7 +101,7 @@ func enrichConvoy(beadsDir string, item convoyListItem) Convoy {
 	}
 
 	// Get tracked issues and their status
-	tracked := getTrackedIssueStatus(beadsDir, item.ID)
+	tracked := getTrackedIssueStatus(b, item.ID)
 	convoy.Total = len(tracked)
 	for _, t := range tracked {
 		if t.Status == "closed" {
@@ -155,79 +117,33 @@ type trackedStatus struct {
 	Status string
 }
 
-// getTrackedIssueStatus queries tracked issues and their status
-func getTrackedIssueStatus(beadsDir, convoyID string) []trackedStatus {
-	// Validate convoyID to prevent SQL injection
-	if !convoyIDPattern.MatchString(convoyID) {
+// getTrackedIssueStatus looks up tracked issues and their status
+func getTrackedIssueStatus(b *beads.Beads, convoyID string) []trackedStatus {
+	deps, err := b.Dependencies(convoyID, beads.DepTracks)
+	if err != nil {
 		return nil
 	}
 
-	dbPath := filepath.Join(beadsDir, "beads.db")
-
-	ctx, cancel := context.WithTimeout(context.Background(), convoySubprocessTimeout)
-	defer cancel()
-
-	// Query tracked dependencies from SQLite
-	// convoyID is validated above to match ^hq-[a-zA-Z0-9-]+$
-	cmd := exec.CommandContext(ctx, "sqlite3", "-json", dbPath, //nolint:gosec // G204: convoyID is validated against strict pattern
-		fmt.Sprintf(`SELECT depends_on_id FROM dependencies WHERE issue_id = '%s' AND type = 'tracks'`, convoyID))
-
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-	if err := cmd.Run(); err != nil {
-		return nil
+	issueIDs := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		// Handle external reference format: external:rig:issue-id
+		issueIDs = append(issueIDs, beads.ExternalRefID(dep.DependsOnID))
 	}
 
-	var deps []struct {
-		DependsOnID string `json:"depends_on_id"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &deps); err != nil {
-		return nil
-	}
+	issues, _ := b.ShowMultiple(issueIDs)
 
 	var tracked []trackedStatus
-	for _, dep := range deps {
-		issueID := dep.DependsOnID
-
-		// Handle external reference format: external:rig:issue-id
-		if strings.HasPrefix(issueID, "external:") {
-			parts := strings.SplitN(issueID, ":", 3)
-			if len(parts) == 3 {
-				issueID = parts[2]
-			}
+	for _, issueID := range issueIDs {
+		status := "unknown"
+		if issue, ok := issues[issueID]; ok {
+			status = issue.Status
 		}
-
-		// Get issue status
-		status := getIssueStatus(issueID)
 		tracked = append(tracked, trackedStatus{ID: issueID, Status: status})
 	}
 
 	return tracked
 }
 
-// getIssueStatus fetches just the status of an issue
-func getIssueStatus(issueID string) string {
-	ctx, cancel := context.WithTimeout(context.Background(), convoySubprocessTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "bd", "show", issueID, "--json")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
-		return "unknown"
-	}
-
-	var issues []struct {
-		Status string `json:"status"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil || len(issues) == 0 {
-		return "unknown"
-	}
-
-	return issues[0].Status
-}
-
 // Convoy panel styles
 var (
 	ConvoyPanelStyle = lipgloss.NewStyle().
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/events"
 )
 
 // EventSource represents a source of events
@@ -214,7 +215,7 @@ func parseBeadContext(beadID string) (actor, rig, role string) {
 
 // GtEventsSource reads events from ~/gt/.events.jsonl (gt activity log)
 type GtEventsSource struct {
-	file   *os.File
+	tailer *events.Tailer
 	events chan Event
 	cancel context.CancelFunc
 }
@@ -231,8 +232,7 @@ type GtEvent struct {
 
 // NewGtEventsSource creates a source that tails ~/gt/.events.jsonl
 func NewGtEventsSource(townRoot string) (*GtEventsSource, error) {
-	eventsPath := filepath.Join(townRoot, ".events.jsonl")
-	file, err := os.Open(eventsPath)
+	tailer, err := events.NewTailer(townRoot)
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +240,7 @@ func NewGtEventsSource(townRoot string) (*GtEventsSource, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	source := &GtEventsSource{
-		file:   file,
+		tailer: tailer,
 		events: make(chan Event, 100),
 		cancel: cancel,
 	}
@@ -254,10 +254,6 @@ func NewGtEventsSource(townRoot string) (*GtEventsSource, error) {
 func (s *GtEventsSource) tail(ctx context.Context) {
 	defer close(s.events)
 
-	// Seek to end for live tailing
-	_, _ = s.file.Seek(0, 2)
-
-	scanner := bufio.NewScanner(s.file)
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -266,8 +262,8 @@ func (s *GtEventsSource) tail(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			for scanner.Scan() {
-				line := scanner.Text()
+			lines, _ := s.tailer.Poll()
+			for _, line := range lines {
 				if event := parseGtEventLine(line); event != nil {
 					select {
 					case s.events <- *event:
@@ -287,7 +283,7 @@ func (s *GtEventsSource) Events() <-chan Event {
 // Close stops the source
 func (s *GtEventsSource) Close() error {
 	s.cancel()
-	return s.file.Close()
+	return s.tailer.Close()
 }
 
 // parseGtEventLine parses a line from .events.jsonl
@@ -364,6 +360,7 @@ func parseGtEventLine(line string) *Event {
 		Rig:     rig,
 		Role:    role,
 		Raw:     line,
+		Payload: ge.Payload,
 	}
 }
 
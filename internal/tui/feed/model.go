@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/events"
 )
 
 // Panel represents which panel has focus
@@ -30,6 +31,29 @@ type Event struct {
 	Rig      string // which rig
 	Role     string // actor's role
 	Raw      string // raw line for fallback display
+
+	Payload map[string]interface{} // structured data, for gt events
+}
+
+// record returns the event's filterable view. The affected issue is exposed
+// as payload.bead, as gt events name it.
+func (e Event) record() events.Record {
+	payload := e.Payload
+	if e.Target != "" && payload["bead"] == nil {
+		payload = make(map[string]interface{}, len(e.Payload)+1)
+		for k, v := range e.Payload {
+			payload[k] = v
+		}
+		payload["bead"] = e.Target
+	}
+	return events.Record{
+		Time:     e.Time,
+		Type:     e.Type,
+		Actor:    e.Actor,
+		Rig:      e.Rig,
+		Severity: events.Event{Type: e.Type, Payload: e.Payload}.Severity(),
+		Payload:  payload,
+	}
 }
 
 // Agent represents an agent in the tree
@@ -73,7 +97,12 @@ type Model struct {
 	keys     KeyMap
 	help     help.Model
 	showHelp bool
-	filter   string
+
+	// Feed filter (see events.Filter for the query syntax)
+	filter      *events.Filter
+	filtering   bool   // Typing a filter query
+	filterInput string // Query being typed
+	filterErr   string // Why the last query was rejected
 
 	// Event source
 	eventChan <-chan Event
@@ -220,6 +249,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // handleKey processes key presses
 func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.filtering {
+		return m.handleFilterKey(msg)
+	}
+
 	switch {
 	case key.Matches(msg, m.keys.Quit):
 		m.closeOnce.Do(func() { close(m.done) })
@@ -257,6 +290,18 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keys.Refresh):
 		m.updateViewContent()
 		return m, nil
+
+	case key.Matches(msg, m.keys.Filter):
+		m.filtering = true
+		m.filterInput = m.filter.String()
+		m.filterErr = ""
+		return m, nil
+
+	case key.Matches(msg, m.keys.ClearFilter):
+		m.filter = nil
+		m.filterErr = ""
+		m.updateViewContent()
+		return m, nil
 	}
 
 	// Pass to focused viewport
@@ -272,6 +317,39 @@ func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// handleFilterKey edits the filter query while it is being typed. Enter
+// applies it, esc abandons the edit.
+func (m *Model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.filtering = false
+		if m.filterInput == "" {
+			m.filter = nil
+			m.updateViewContent()
+			return m, nil
+		}
+		f, err := events.ParseFilter(m.filterInput, time.Now())
+		if err != nil {
+			m.filterErr = err.Error()
+			return m, nil
+		}
+		m.filter = f
+		m.filterErr = ""
+		m.updateViewContent()
+	case tea.KeyEsc:
+		m.filtering = false
+	case tea.KeyBackspace:
+		if r := []rune(m.filterInput); len(r) > 0 {
+			m.filterInput = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		m.filterInput += " "
+	case tea.KeyRunes:
+		m.filterInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
 // updateViewportSizes recalculates viewport dimensions
 func (m *Model) updateViewportSizes() {
 	// Reserve space: header (1) + borders (6 for 3 panels) + status bar (1) + help (1-2)
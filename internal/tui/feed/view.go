@@ -48,9 +48,14 @@ func (m *Model) renderHeader() string {
 	title := TitleStyle.Render("GT Feed")
 
 	filter := ""
-	if m.filter != "" {
+	switch {
+	case m.filtering:
+		filter = FilterStyle.Render(fmt.Sprintf("Filter: %s█", m.filterInput))
+	case m.filterErr != "":
+		filter = FilterStyle.Render(fmt.Sprintf("Filter error: %s", m.filterErr))
+	case m.filter != nil:
 		filter = FilterStyle.Render(fmt.Sprintf("Filter: %s", m.filter))
-	} else {
+	default:
 		filter = FilterStyle.Render("Filter: all")
 	}
 
@@ -221,16 +226,17 @@ func (m *Model) renderFeed() string {
 
 	var lines []string
 
-	// Show most recent events first (reversed)
-	start := 0
-	if len(m.events) > 100 {
-		start = len(m.events) - 100
-	}
-
-	for i := len(m.events) - 1; i >= start; i-- {
+	// Show the 100 most recent matching events first (reversed)
+	for i := len(m.events) - 1; i >= 0 && len(lines) < 100; i-- {
 		event := m.events[i]
+		if !m.filter.Match(event.record()) {
+			continue
+		}
 		lines = append(lines, m.renderEvent(event))
 	}
+	if len(lines) == 0 {
+		return AgentIdleStyle.Render("No events match the filter")
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -335,6 +341,7 @@ func (m *Model) renderShortHelp() string {
 		HelpKeyStyle.Render("j/k") + HelpDescStyle.Render(":scroll"),
 		HelpKeyStyle.Render("tab") + HelpDescStyle.Render(":switch"),
 		HelpKeyStyle.Render("/") + HelpDescStyle.Render(":search"),
+		HelpKeyStyle.Render("f") + HelpDescStyle.Render(":filter"),
 		HelpKeyStyle.Render("q") + HelpDescStyle.Render(":quit"),
 		HelpKeyStyle.Render("?") + HelpDescStyle.Render(":help"),
 	}
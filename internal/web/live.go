@@ -0,0 +1,217 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+const (
+	// eventPollInterval is how often Run checks the event log.
+	eventPollInterval = time.Second
+
+	// minRefreshGap limits the refreshes triggered by events, so a burst
+	// of agent output doesn't shell out to bd and tmux every poll.
+	minRefreshGap = 2 * time.Second
+
+	// subscriberBuffer is how many updates a subscriber may fall behind
+	// before it is dropped.
+	subscriberBuffer = 32
+)
+
+// TownUpdate is one change to the town snapshot, as pushed to live
+// dashboard clients. Only the sections that changed are set; applying the
+// update to the snapshot with the previous version yields this version.
+type TownUpdate struct {
+	Version    uint64                   `json:"version"`
+	Time       time.Time                `json:"time"`
+	Convoys    *ListDiff[ConvoyRow]     `json:"convoys,omitempty"`
+	MergeQueue *ListDiff[MergeQueueRow] `json:"merge_queue,omitempty"`
+	Polecats   *ListDiff[PolecatRow]    `json:"polecats,omitempty"`
+	Agents     *ListDiff[AgentState]    `json:"agents,omitempty"`
+	Jobs       *ListDiff[JobState]      `json:"jobs,omitempty"`
+	Events     []events.Event           `json:"events,omitempty"`
+}
+
+// ListDiff lists the rows of a snapshot section that were added or changed,
+// and the keys of the rows that were removed.
+type ListDiff[T any] struct {
+	Upsert []T      `json:"upsert,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// convoyKey returns the key of a convoy row: its ID.
+func convoyKey(c ConvoyRow) string { return c.ID }
+
+// mergeQueueKey returns the key of a merge queue row: "repo#number".
+func mergeQueueKey(m MergeQueueRow) string { return fmt.Sprintf("%s#%d", m.Repo, m.Number) }
+
+// polecatKey returns the key of a polecat row: "rig/name".
+func polecatKey(p PolecatRow) string { return p.Rig + "/" + p.Name }
+
+// agentKey returns the key of an agent: "role/name".
+func agentKey(a AgentState) string { return a.Role + "/" + a.Name }
+
+// jobKey returns the key of a job: its ID.
+func jobKey(j JobState) string { return j.ID }
+
+func (u TownUpdate) empty() bool {
+	return u.Convoys == nil && u.MergeQueue == nil && u.Polecats == nil &&
+		u.Agents == nil && u.Jobs == nil && len(u.Events) == 0
+}
+
+// diffSnapshots returns the update that turns prev's rows into next's.
+func diffSnapshots(prev, next TownSnapshot) TownUpdate {
+	return TownUpdate{
+		Convoys:    diffRows(prev.Convoys, next.Convoys, convoyKey),
+		MergeQueue: diffRows(prev.MergeQueue, next.MergeQueue, mergeQueueKey),
+		Polecats:   diffRows(prev.Polecats, next.Polecats, polecatKey),
+		Agents:     diffRows(prev.Agents, next.Agents, agentKey),
+		Jobs:       diffRows(prev.Jobs, next.Jobs, jobKey),
+	}
+}
+
+// diffRows compares two versions of a section by key, returning nil if
+// nothing changed. Rows are compared by their JSON encoding, which is what
+// clients see.
+func diffRows[T any](prev, next []T, key func(T) string) *ListDiff[T] {
+	old := make(map[string][]byte, len(prev))
+	for _, row := range prev {
+		data, _ := json.Marshal(row)
+		old[key(row)] = data
+	}
+
+	d := &ListDiff[T]{}
+	seen := make(map[string]bool, len(next))
+	for _, row := range next {
+		k := key(row)
+		seen[k] = true
+		data, _ := json.Marshal(row)
+		if before, ok := old[k]; !ok || !bytes.Equal(before, data) {
+			d.Upsert = append(d.Upsert, row)
+		}
+	}
+	for _, row := range prev {
+		if k := key(row); !seen[k] {
+			d.Remove = append(d.Remove, k)
+		}
+	}
+
+	if len(d.Upsert) == 0 && len(d.Remove) == 0 {
+		return nil
+	}
+	return d
+}
+
+// Subscribe returns the current snapshot and a channel of the updates that
+// follow it. The channel is closed if the subscriber falls too far behind;
+// it should then subscribe again for a fresh snapshot. Call cancel when
+// done.
+func (e *TownStateEngine) Subscribe() (TownSnapshot, <-chan TownUpdate, func()) {
+	ch := make(chan TownUpdate, subscriberBuffer)
+
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+	e.subs[ch] = struct{}{}
+
+	cancel := func() {
+		e.stateMu.Lock()
+		defer e.stateMu.Unlock()
+		if _, ok := e.subs[ch]; ok {
+			delete(e.subs, ch)
+			close(ch)
+		}
+	}
+	return e.snapshot, ch, cancel
+}
+
+// publish stamps an update with the next version and sends it to every
+// subscriber. The caller must hold stateMu for writing and has already
+// applied the update to the snapshot.
+func (e *TownStateEngine) publish(u TownUpdate) {
+	e.snapshot.Version++
+	u.Version = e.snapshot.Version
+	u.Time = time.Now().UTC()
+
+	for ch := range e.subs {
+		select {
+		case ch <- u:
+		default:
+			// Too far behind to catch up from diffs.
+			delete(e.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publishEvents adds feed events to the snapshot and publishes them.
+func (e *TownStateEngine) publishEvents(evs []events.Event) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	recent := append(append([]events.Event{}, e.snapshot.Events...), evs...)
+	if len(recent) > recentEventsLimit {
+		recent = recent[len(recent)-recentEventsLimit:]
+	}
+	e.snapshot.Events = recent
+	e.publish(TownUpdate{Events: evs})
+}
+
+// Run keeps the snapshot fresh until ctx is done. It refreshes every
+// interval and follows townRoot's event log, publishing feed events as they
+// arrive and refreshing soon after them, since most events (slings,
+// merges, closed convoys) mean the town has changed.
+func (e *TownStateEngine) Run(ctx context.Context, townRoot string, interval time.Duration) {
+	// Without an event log, fall back to interval refreshes alone.
+	tailer, err := events.NewTailer(townRoot)
+	var poll <-chan time.Time
+	if err == nil {
+		defer tailer.Close()
+		ticker := time.NewTicker(eventPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	_ = e.Refresh()
+
+	refresh := time.NewTicker(interval)
+	defer refresh.Stop()
+
+	stale := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh.C:
+			_ = e.Refresh()
+			stale = false
+		case <-poll:
+			if feed := feedEvents(tailer); len(feed) > 0 {
+				e.publishEvents(feed)
+				stale = true
+			}
+			if stale && time.Since(e.Snapshot().UpdatedAt) >= minRefreshGap {
+				_ = e.Refresh()
+				stale = false
+			}
+		}
+	}
+}
+
+// feedEvents returns the feed-visible events appended to the log since the
+// last poll.
+func feedEvents(tailer *events.Tailer) []events.Event {
+	lines, _ := tailer.Poll()
+	var feed []events.Event
+	for _, line := range lines {
+		var ev events.Event
+		if json.Unmarshal([]byte(line), &ev) == nil && ev.Visibility != events.VisibilityAudit {
+			feed = append(feed, ev)
+		}
+	}
+	return feed
+}
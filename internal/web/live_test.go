@@ -0,0 +1,243 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// syncFetcher is a MockConvoyFetcher whose rows can change between reads.
+type syncFetcher struct {
+	mu   sync.Mutex
+	mock MockConvoyFetcher
+}
+
+func (f *syncFetcher) set(fn func(m *MockConvoyFetcher)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(&f.mock)
+}
+
+func (f *syncFetcher) FetchConvoys() ([]ConvoyRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mock.FetchConvoys()
+}
+
+func (f *syncFetcher) FetchMergeQueue() ([]MergeQueueRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mock.FetchMergeQueue()
+}
+
+func (f *syncFetcher) FetchPolecats() ([]PolecatRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mock.FetchPolecats()
+}
+
+func TestRefreshPublishesDiffs(t *testing.T) {
+	fetcher := &syncFetcher{mock: MockConvoyFetcher{
+		Convoys: []ConvoyRow{
+			{ID: "hq-cv-1", Title: "One", Status: "open"},
+			{ID: "hq-cv-2", Title: "Two", Status: "open"},
+		},
+		Polecats: []PolecatRow{{Name: "nux", Rig: "gastown"}},
+	}}
+	engine := NewTownStateEngine(fetcher)
+	if err := engine.Refresh(); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	snap, updates, cancel := engine.Subscribe()
+	defer cancel()
+	if snap.Version != 1 || len(snap.Convoys) != 2 || len(snap.Agents) != 1 {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+
+	// Nothing changed: no update.
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case u := <-updates:
+		t.Fatalf("unexpected update %+v", u)
+	default:
+	}
+
+	fetcher.set(func(m *MockConvoyFetcher) {
+		m.Convoys = []ConvoyRow{
+			{ID: "hq-cv-1", Title: "One", Status: "closed"},
+			{ID: "hq-cv-3", Title: "Three", Status: "open"},
+		}
+	})
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	u := <-updates
+	if u.Version != 2 || u.Polecats != nil || u.Agents != nil {
+		t.Errorf("update = %+v, want only convoy and job changes", u)
+	}
+	if u.Convoys == nil || len(u.Convoys.Upsert) != 2 || strings.Join(u.Convoys.Remove, ",") != "hq-cv-2" {
+		t.Errorf("convoys diff = %+v", u.Convoys)
+	}
+	if got := engine.Snapshot().Convoys[0].Status; got != "closed" {
+		t.Errorf("snapshot convoy status = %q, want closed", got)
+	}
+}
+
+func TestRefreshKeepsRowsOnPartialFailure(t *testing.T) {
+	fetcher := &syncFetcher{mock: MockConvoyFetcher{
+		MergeQueue: []MergeQueueRow{{Number: 7, Repo: "gastown"}},
+	}}
+	engine := NewTownStateEngine(fetcher)
+	if err := engine.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher.set(func(m *MockConvoyFetcher) { m.Error = errFetchFailed })
+	if err := engine.Refresh(); err == nil {
+		t.Fatal("Refresh succeeded despite the convoy fetch failing")
+	}
+	if mq, err := engine.FetchMergeQueue(); err != nil || len(mq) != 1 {
+		t.Errorf("FetchMergeQueue = %v, %v; want the cached row", mq, err)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	engine := NewTownStateEngine(&MockConvoyFetcher{})
+	_, updates, cancel := engine.Subscribe()
+	defer cancel()
+
+	for i := 0; i < 60; i++ {
+		engine.publishEvents([]events.Event{{Type: events.TypeSling}})
+	}
+	n := 0
+	for range updates {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("received %d updates before the close, want %d", n, subscriberBuffer)
+	}
+	if got := len(engine.Snapshot().Events); got != recentEventsLimit {
+		t.Errorf("snapshot holds %d events, want %d", got, recentEventsLimit)
+	}
+}
+
+func TestSnapshotHandlerJSON(t *testing.T) {
+	engine := NewTownStateEngine(&MockConvoyFetcher{
+		Convoys: []ConvoyRow{{ID: "hq-cv-1", Title: "One", Status: "open"}},
+	})
+	w := httptest.NewRecorder()
+	NewTownSnapshotHandler(engine).ServeHTTP(w, httptest.NewRequest("GET", "/api/town/snapshot", nil))
+
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad JSON %s: %v", w.Body, err)
+	}
+	for _, field := range []string{"version", "agents", "jobs", "convoys", "merge_queue", "polecats", "events"} {
+		if got[field] == nil {
+			t.Errorf("snapshot missing %q: %s", field, w.Body)
+		}
+	}
+	if string(got["merge_queue"]) != "[]" {
+		t.Errorf("merge_queue = %s, want []", got["merge_queue"])
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	town := t.TempDir()
+	fetcher := &syncFetcher{mock: MockConvoyFetcher{
+		Convoys: []ConvoyRow{{ID: "hq-cv-1", Title: "One", Status: "open"}},
+	}}
+	engine := NewTownStateEngine(fetcher)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go engine.Run(ctx, town, time.Hour)
+	// Run creates the event log when it starts following it.
+	for i := 0; ; i++ {
+		if _, err := os.Stat(filepath.Join(town, events.EventsFile)); err == nil {
+			break
+		}
+		if i == 100 {
+			t.Fatal("Run never opened the event log")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	srv := httptest.NewServer(NewTownStreamHandler(engine))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	messages := make(chan [2]string)
+	go func() {
+		var event string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				event = name
+			} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+				messages <- [2]string{event, data}
+			}
+		}
+		close(messages)
+	}()
+	next := func() (string, string) {
+		select {
+		case m := <-messages:
+			return m[0], m[1]
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a stream message")
+			return "", ""
+		}
+	}
+
+	event, data := next()
+	var snap TownSnapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil || event != "snapshot" || len(snap.Convoys) != 1 {
+		t.Fatalf("first message = %s %s (%v), want the snapshot", event, data, err)
+	}
+
+	// A logged event is pushed, and triggers a refresh that picks up the
+	// closed convoy.
+	fetcher.set(func(m *MockConvoyFetcher) {
+		m.Convoys = []ConvoyRow{{ID: "hq-cv-1", Title: "One", Status: "closed"}}
+	})
+	line, _ := json.Marshal(events.Event{Type: events.TypeConvoyClosed, Actor: "gt", Visibility: events.VisibilityFeed})
+	if err := os.WriteFile(filepath.Join(town, events.EventsFile), append(line, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sawEvent, sawConvoy bool
+	for !sawEvent || !sawConvoy {
+		event, data := next()
+		var u TownUpdate
+		if err := json.Unmarshal([]byte(data), &u); err != nil || event != "update" {
+			t.Fatalf("message = %s %s (%v), want an update", event, data, err)
+		}
+		if len(u.Events) == 1 && u.Events[0].Type == events.TypeConvoyClosed {
+			sawEvent = true
+		}
+		if u.Convoys != nil && u.Convoys.Upsert[0].Status == "closed" {
+			sawConvoy = true
+		}
+	}
+}
@@ -5,59 +5,148 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
 )
 
-// TownStateEngine maintains a snapshot of the town state for API reads.
+// snapshotMaxAge is how old the cached snapshot may get before a read
+// refreshes it. While the engine is running it never gets this old.
+const snapshotMaxAge = 30 * time.Second
+
+// recentEventsLimit caps the events kept in the snapshot for new clients.
+const recentEventsLimit = 50
+
+// TownSnapshot is the dashboard's view of the town: the convoy, merge-queue
+// and polecat rows the HTML dashboard renders, the TownState derived from
+// them for the town map, and the most recent feed events.
+type TownSnapshot struct {
+	Version   uint64    `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	TownState
+	Convoys    []ConvoyRow     `json:"convoys"`
+	MergeQueue []MergeQueueRow `json:"merge_queue"`
+	Polecats   []PolecatRow    `json:"polecats"`
+	Events     []events.Event  `json:"events"`
+}
+
+// TownStateEngine maintains a snapshot of the town state for API reads and
+// pushes changes to subscribers. It implements ConvoyFetcher over the
+// cached snapshot, so the HTML dashboard renders without shelling out.
 type TownStateEngine struct {
 	fetcher   ConvoyFetcher
+	refreshMu sync.Mutex // Serializes Refresh
 	stateMu   sync.RWMutex
-	state     TownState
-	updatedAt time.Time
+	snapshot  TownSnapshot
+	subs      map[chan TownUpdate]struct{}
 }
 
 // NewTownStateEngine creates a TownStateEngine backed by the given fetcher.
 func NewTownStateEngine(fetcher ConvoyFetcher) *TownStateEngine {
 	return &TownStateEngine{
-		fetcher: fetcher,
+		fetcher:  fetcher,
+		snapshot: TownSnapshot{Events: []events.Event{}},
+		subs:     make(map[chan TownUpdate]struct{}),
 	}
 }
 
-// Refresh rebuilds the cached town state snapshot from the fetcher.
+// Refresh rebuilds the cached town state snapshot from the fetcher and
+// publishes what changed.
 func (e *TownStateEngine) Refresh() error {
+	e.refreshMu.Lock()
+	defer e.refreshMu.Unlock()
+
 	convoys, err := e.fetcher.FetchConvoys()
 	if err != nil {
 		return err
 	}
 
+	// The merge queue and polecats are non-fatal: keep the last known rows
+	// rather than telling clients they all disappeared.
+	prev := e.Snapshot()
+	mergeQueue, err := e.fetcher.FetchMergeQueue()
+	if err != nil {
+		mergeQueue = prev.MergeQueue
+	}
 	polecats, err := e.fetcher.FetchPolecats()
 	if err != nil {
-		polecats = nil
+		polecats = prev.Polecats
 	}
 
-    // --- CHANGE STARTS HERE ---
-    // Wrap the separate slices into the ConvoyData struct expected by the new function signature
-	data := ConvoyData{
-		Convoys:  convoys,
-		Polecats: polecats,
+	// Empty sections encode as [] rather than null for API clients.
+	if convoys == nil {
+		convoys = []ConvoyRow{}
+	}
+	if mergeQueue == nil {
+		mergeQueue = []MergeQueueRow{}
+	}
+	if polecats == nil {
+		polecats = []PolecatRow{}
 	}
 
-	state := TownStateFromConvoyData(data)
-    // --- CHANGE ENDS HERE ---
+	data := ConvoyData{
+		Convoys:    convoys,
+		MergeQueue: mergeQueue,
+		Polecats:   polecats,
+	}
+	next := TownSnapshot{
+		TownState:  TownStateFromConvoyData(data),
+		Convoys:    convoys,
+		MergeQueue: mergeQueue,
+		Polecats:   polecats,
+	}
 
 	e.stateMu.Lock()
-	e.state = state
-	e.updatedAt = time.Now()
-	e.stateMu.Unlock()
-
+	defer e.stateMu.Unlock()
+	update := diffSnapshots(e.snapshot, next)
+	e.snapshot.UpdatedAt = time.Now()
+	if update.empty() {
+		return nil
+	}
+	e.snapshot.TownState = next.TownState
+	e.snapshot.Convoys = next.Convoys
+	e.snapshot.MergeQueue = next.MergeQueue
+	e.snapshot.Polecats = next.Polecats
+	e.publish(update)
 	return nil
 }
 
 // Snapshot returns the current town state snapshot.
-func (e *TownStateEngine) Snapshot() TownState {
+func (e *TownStateEngine) Snapshot() TownSnapshot {
 	e.stateMu.RLock()
 	defer e.stateMu.RUnlock()
 
-	return e.state
+	return e.snapshot
+}
+
+// current returns the snapshot, refreshing it first if it is missing or
+// stale. A failed refresh is only an error if there is nothing cached.
+func (e *TownStateEngine) current() (TownSnapshot, error) {
+	snap := e.Snapshot()
+	if time.Since(snap.UpdatedAt) < snapshotMaxAge {
+		return snap, nil
+	}
+	if err := e.Refresh(); err != nil && snap.UpdatedAt.IsZero() {
+		return snap, err
+	}
+	return e.Snapshot(), nil
+}
+
+// FetchConvoys returns the cached convoys.
+func (e *TownStateEngine) FetchConvoys() ([]ConvoyRow, error) {
+	snap, err := e.current()
+	return snap.Convoys, err
+}
+
+// FetchMergeQueue returns the cached merge queue.
+func (e *TownStateEngine) FetchMergeQueue() ([]MergeQueueRow, error) {
+	snap, err := e.current()
+	return snap.MergeQueue, err
+}
+
+// FetchPolecats returns the cached polecats.
+func (e *TownStateEngine) FetchPolecats() ([]PolecatRow, error) {
+	snap, err := e.current()
+	return snap.Polecats, err
 }
 
 // TownSnapshotHandler serves the town snapshot API endpoint.
@@ -78,13 +167,12 @@ func (h *TownSnapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.engine.Refresh(); err != nil {
+	snapshot, err := h.engine.current()
+	if err != nil {
 		http.Error(w, "Failed to build town snapshot", http.StatusInternalServerError)
 		return
 	}
 
-	snapshot := h.engine.Snapshot()
-
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment, so proxies
+// don't close it and the browser notices a dead server.
+const streamKeepAlive = 15 * time.Second
+
+// TownStreamHandler streams the town to browsers as Server-Sent Events: a
+// "snapshot" event with the full TownSnapshot, then an "update" event with
+// a TownUpdate for each change. Event IDs are snapshot versions.
+type TownStreamHandler struct {
+	engine    *TownStateEngine
+	keepAlive time.Duration
+}
+
+// NewTownStreamHandler creates a new stream handler.
+func NewTownStreamHandler(engine *TownStateEngine) *TownStreamHandler {
+	return &TownStreamHandler{engine: engine, keepAlive: streamKeepAlive}
+}
+
+// ServeHTTP handles GET /api/town/stream requests.
+func (h *TownStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := h.engine.current(); err != nil {
+		http.Error(w, "Failed to build town snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream outlives the server's write timeout.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	snapshot, updates, cancel := h.engine.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	if writeEvent(w, "snapshot", snapshot.Version, snapshot) != nil || rc.Flush() != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				// Dropped for falling behind; the browser reconnects and
+				// starts over from a fresh snapshot.
+				return
+			}
+			if writeEvent(w, "update", update.Version, update) != nil || rc.Flush() != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes one Server-Sent Event with a JSON data line.
+func writeEvent(w io.Writer, event string, id uint64, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, data)
+	return err
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -8,22 +9,22 @@ import (
 // It is derived from ConvoyData (ConvoyRow, MergeQueueRow, and PolecatRow) which
 // currently powers the HTML dashboard.
 type TownState struct {
-	Agents []AgentState
-	Jobs   []JobState
+	Agents []AgentState `json:"agents"`
+	Jobs   []JobState   `json:"jobs"`
 }
 
 // AgentState represents an agent in the town map.
 type AgentState struct {
-	Name   string
-	Role   string
-	Status AgentStatus
+	Name   string      `json:"name"`
+	Role   string      `json:"role"`
+	Status AgentStatus `json:"status"`
 }
 
 // JobState represents a unit of work tied to a convoy or tracked issue.
 type JobState struct {
-	ID     string
-	Title  string
-	Status JobStatus
+	ID     string    `json:"id"`
+	Title  string    `json:"title"`
+	Status JobStatus `json:"status"`
 }
 
 // AgentStatus describes the coarse state of an agent.
@@ -125,6 +126,7 @@ func TownStateFromConvoyData(data ConvoyData) TownState {
 	for _, job := range jobs {
 		jobStates = append(jobStates, job)
 	}
+	sort.Slice(jobStates, func(i, j int) bool { return jobStates[i].ID < jobStates[j].ID })
 
 	agents := make([]AgentState, 0, len(data.Polecats))
 	for _, polecat := range data.Polecats {
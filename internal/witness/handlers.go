@@ -45,7 +45,7 @@ func HandlePolecatDone(workDir, rigName string, msg *mail.Message) *HandlerResul
 	}
 
 	// Parse the message
-	payload, err := ParsePolecatDone(msg.Subject, msg.Body)
+	payload, err := DecodePolecatDone(msg)
 	if err != nil {
 		result.Error = fmt.Errorf("parsing POLECAT_DONE: %w", err)
 		return result
@@ -115,13 +115,12 @@ func HandleLifecycleShutdown(workDir, rigName string, msg *mail.Message) *Handle
 		ProtocolType: ProtoLifecycleShutdown,
 	}
 
-	// Extract polecat name from subject
-	matches := PatternLifecycleShutdown.FindStringSubmatch(msg.Subject)
-	if len(matches) < 2 {
-		result.Error = fmt.Errorf("invalid LIFECYCLE:Shutdown subject: %s", msg.Subject)
+	payload, err := DecodeLifecycleShutdown(msg)
+	if err != nil {
+		result.Error = err
 		return result
 	}
-	polecatName := matches[1]
+	polecatName := payload.PolecatName
 
 	// Shutdown means no pending work - try to auto-nuke immediately
 	nukeResult := AutoNukeIfClean(workDir, rigName, polecatName)
@@ -158,7 +157,7 @@ func HandleHelp(workDir, rigName string, msg *mail.Message, router *mail.Router)
 	}
 
 	// Parse the message
-	payload, err := ParseHelp(msg.Subject, msg.Body)
+	payload, err := DecodeHelp(msg)
 	if err != nil {
 		result.Error = fmt.Errorf("parsing HELP: %w", err)
 		return result
@@ -199,7 +198,7 @@ func HandleMerged(workDir, rigName string, msg *mail.Message) *HandlerResult {
 	}
 
 	// Parse the message
-	payload, err := ParseMerged(msg.Subject, msg.Body)
+	payload, err := DecodeMerged(msg)
 	if err != nil {
 		result.Error = fmt.Errorf("parsing MERGED: %w", err)
 		return result
@@ -303,7 +302,7 @@ func HandleSwarmStart(workDir string, msg *mail.Message) *HandlerResult {
 	}
 
 	// Parse the message
-	payload, err := ParseSwarmStart(msg.Body)
+	payload, err := DecodeSwarmStart(msg)
 	if err != nil {
 		result.Error = fmt.Errorf("parsing SWARM_START: %w", err)
 		return result
@@ -2,13 +2,40 @@
 package witness
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/mail"
 )
 
-// Protocol message patterns for Witness inbox routing.
+// SchemaVersion is the payload schema version of the Witness protocol
+// messages, and the newest the Witness accepts.
+const SchemaVersion = 1
+
+// Envelope protocol names of the messages the Witness handles. MERGED is
+// sent by the Refinery (see protocol.TypeMerged); the Witness decodes only
+// the fields it needs.
+const (
+	ProtocolPolecatDone       = "POLECAT_DONE"
+	ProtocolLifecycleShutdown = "LIFECYCLE_SHUTDOWN"
+	ProtocolHelp              = "HELP"
+	ProtocolMerged            = "MERGED"
+	ProtocolSwarmStart        = "SWARM_START"
+)
+
+// envelopeProtocols maps envelope protocol names to protocol types.
+var envelopeProtocols = map[string]ProtocolType{
+	ProtocolPolecatDone:       ProtoPolecatDone,
+	ProtocolLifecycleShutdown: ProtoLifecycleShutdown,
+	ProtocolHelp:              ProtoHelp,
+	ProtocolMerged:            ProtoMerged,
+	ProtocolSwarmStart:        ProtoSwarmStart,
+}
+
+// Legacy subject patterns, for messages sent without an envelope.
 var (
 	// POLECAT_DONE <name> - polecat signaling work completion
 	PatternPolecatDone = regexp.MustCompile(`^POLECAT_DONE\s+(\S+)`)
@@ -44,41 +71,173 @@ const (
 
 // PolecatDonePayload contains parsed data from a POLECAT_DONE message.
 type PolecatDonePayload struct {
-	PolecatName string
-	Exit        string // COMPLETED, ESCALATED, DEFERRED, PHASE_COMPLETE
-	IssueID     string
-	MRID        string
-	Branch      string
-	Gate        string // Gate ID when Exit is PHASE_COMPLETE
+	PolecatName string `json:"polecat"`
+	Exit        string `json:"exit"` // COMPLETED, ESCALATED, DEFERRED, PHASE_COMPLETE
+	IssueID     string `json:"issue,omitempty"`
+	MRID        string `json:"mr,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Gate        string `json:"gate,omitempty"` // Gate ID when Exit is PHASE_COMPLETE
+}
+
+// LifecycleShutdownPayload contains parsed data from a LIFECYCLE:Shutdown message.
+type LifecycleShutdownPayload struct {
+	PolecatName string `json:"polecat"`
 }
 
 // HelpPayload contains parsed data from a HELP message.
 type HelpPayload struct {
-	Topic       string
-	Agent       string
-	IssueID     string
-	Problem     string
-	Tried       string
-	RequestedAt time.Time
+	Topic       string    `json:"topic"`
+	Agent       string    `json:"agent,omitempty"`
+	IssueID     string    `json:"issue,omitempty"`
+	Problem     string    `json:"problem,omitempty"`
+	Tried       string    `json:"tried,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
 }
 
 // MergedPayload contains parsed data from a MERGED message.
 type MergedPayload struct {
-	PolecatName string
-	Branch      string
-	IssueID     string
-	MergedAt    time.Time
+	PolecatName string    `json:"polecat"`
+	Branch      string    `json:"branch"`
+	IssueID     string    `json:"issue"`
+	MergedAt    time.Time `json:"merged_at"`
 }
 
 // SwarmStartPayload contains parsed data from a SWARM_START message.
 type SwarmStartPayload struct {
-	SwarmID   string
-	BeadIDs   []string
-	Total     int
-	StartedAt time.Time
+	SwarmID   string    `json:"swarm_id"`
+	BeadIDs   []string  `json:"beads,omitempty"`
+	Total     int       `json:"total"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *PolecatDonePayload) Validate() error {
+	switch {
+	case p.PolecatName == "":
+		return errors.New("missing polecat")
+	case p.Exit == "":
+		return errors.New("missing exit")
+	}
+	return nil
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *LifecycleShutdownPayload) Validate() error {
+	if p.PolecatName == "" {
+		return errors.New("missing polecat")
+	}
+	return nil
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *HelpPayload) Validate() error {
+	if p.Topic == "" {
+		return errors.New("missing topic")
+	}
+	return nil
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *MergedPayload) Validate() error {
+	if p.PolecatName == "" {
+		return errors.New("missing polecat")
+	}
+	return nil
+}
+
+// Validate checks that the payload's required fields are set.
+func (p *SwarmStartPayload) Validate() error {
+	if p.SwarmID == "" {
+		return errors.New("missing swarm_id")
+	}
+	return nil
+}
+
+// Classify determines the protocol type of a message: from its envelope if
+// it has one, otherwise from its subject.
+func Classify(msg *mail.Message) ProtocolType {
+	if msg.Envelope == nil {
+		return ClassifyMessage(msg.Subject)
+	}
+	if proto, ok := envelopeProtocols[msg.Envelope.Protocol]; ok {
+		return proto
+	}
+	return ProtoUnknown
+}
+
+// DecodePolecatDone returns the payload of a POLECAT_DONE message, from its
+// envelope or, for a legacy message, its subject and body.
+func DecodePolecatDone(msg *mail.Message) (*PolecatDonePayload, error) {
+	if msg.Envelope == nil {
+		return ParsePolecatDone(msg.Subject, msg.Body)
+	}
+	payload := &PolecatDonePayload{}
+	if err := msg.Envelope.Decode(ProtocolPolecatDone, SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeLifecycleShutdown returns the payload of a LIFECYCLE:Shutdown
+// message, from its envelope or, for a legacy message, its subject.
+func DecodeLifecycleShutdown(msg *mail.Message) (*LifecycleShutdownPayload, error) {
+	if msg.Envelope == nil {
+		matches := PatternLifecycleShutdown.FindStringSubmatch(msg.Subject)
+		if len(matches) < 2 {
+			return nil, fmt.Errorf("invalid LIFECYCLE:Shutdown subject: %s", msg.Subject)
+		}
+		return &LifecycleShutdownPayload{PolecatName: matches[1]}, nil
+	}
+	payload := &LifecycleShutdownPayload{}
+	if err := msg.Envelope.Decode(ProtocolLifecycleShutdown, SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeHelp returns the payload of a HELP message, from its envelope or,
+// for a legacy message, its subject and body.
+func DecodeHelp(msg *mail.Message) (*HelpPayload, error) {
+	if msg.Envelope == nil {
+		return ParseHelp(msg.Subject, msg.Body)
+	}
+	payload := &HelpPayload{}
+	if err := msg.Envelope.Decode(ProtocolHelp, SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeMerged returns the payload of a MERGED message, from its envelope
+// or, for a legacy message, its subject and body.
+func DecodeMerged(msg *mail.Message) (*MergedPayload, error) {
+	if msg.Envelope == nil {
+		return ParseMerged(msg.Subject, msg.Body)
+	}
+	payload := &MergedPayload{}
+	if err := msg.Envelope.Decode(ProtocolMerged, SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeSwarmStart returns the payload of a SWARM_START message, from its
+// envelope or, for a legacy message, its body.
+func DecodeSwarmStart(msg *mail.Message) (*SwarmStartPayload, error) {
+	if msg.Envelope == nil {
+		return ParseSwarmStart(msg.Body)
+	}
+	payload := &SwarmStartPayload{}
+	if err := msg.Envelope.Decode(ProtocolSwarmStart, SchemaVersion, payload); err != nil {
+		return nil, err
+	}
+	if payload.Total == 0 {
+		payload.Total = len(payload.BeadIDs)
+	}
+	return payload, nil
 }
 
-// ClassifyMessage determines the protocol type from a message subject.
+// ClassifyMessage determines the protocol type from a legacy message subject.
 func ClassifyMessage(subject string) ProtocolType {
 	switch {
 	case PatternPolecatDone.MatchString(subject):
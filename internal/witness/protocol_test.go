@@ -2,6 +2,8 @@ package witness
 
 import (
 	"testing"
+
+	"github.com/steveyegge/gastown/internal/mail"
 )
 
 func TestClassifyMessage(t *testing.T) {
@@ -33,6 +35,66 @@ func TestClassifyMessage(t *testing.T) {
 	}
 }
 
+func TestClassifyEnvelope(t *testing.T) {
+	msg := &mail.Message{Subject: "Work finished"}
+	if err := msg.SetEnvelope(ProtocolPolecatDone, SchemaVersion, &PolecatDonePayload{PolecatName: "nux", Exit: "COMPLETED"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := Classify(msg); got != ProtoPolecatDone {
+		t.Errorf("Classify = %v, want %v", got, ProtoPolecatDone)
+	}
+
+	// Legacy messages fall back to the subject.
+	if got := Classify(&mail.Message{Subject: "HELP: Tests failing"}); got != ProtoHelp {
+		t.Errorf("Classify(legacy) = %v, want %v", got, ProtoHelp)
+	}
+
+	msg.Envelope.Protocol = "NOT_A_PROTOCOL"
+	if got := Classify(msg); got != ProtoUnknown {
+		t.Errorf("Classify(unknown envelope) = %v, want %v", got, ProtoUnknown)
+	}
+}
+
+func TestDecodePolecatDone(t *testing.T) {
+	msg := &mail.Message{Subject: "POLECAT_DONE nux", Body: "Exit: COMPLETED"}
+	if err := msg.SetEnvelope(ProtocolPolecatDone, SchemaVersion, &PolecatDonePayload{
+		PolecatName: "ace", Exit: "PHASE_COMPLETE", Gate: "gt-gate-1",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := DecodePolecatDone(msg)
+	if err != nil {
+		t.Fatalf("DecodePolecatDone() error = %v", err)
+	}
+	if payload.PolecatName != "ace" || payload.Exit != "PHASE_COMPLETE" || payload.Gate != "gt-gate-1" {
+		t.Errorf("payload = %+v, want the envelope's fields", payload)
+	}
+
+	if err := msg.SetEnvelope(ProtocolPolecatDone, SchemaVersion, &PolecatDonePayload{PolecatName: "ace"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodePolecatDone(msg); err == nil {
+		t.Error("DecodePolecatDone() accepted a payload without an exit")
+	}
+}
+
+func TestDecodeMergedFromRefinery(t *testing.T) {
+	// The Refinery's MERGED payload has more fields than the Witness reads.
+	msg := &mail.Message{Envelope: &mail.Envelope{
+		Protocol: ProtocolMerged,
+		Version:  1,
+		Payload:  []byte(`{"branch":"polecat/nux","issue":"gt-abc","polecat":"nux","rig":"gastown","merged_at":"2026-01-02T03:04:05Z","target_branch":"main"}`),
+	}}
+	payload, err := DecodeMerged(msg)
+	if err != nil {
+		t.Fatalf("DecodeMerged() error = %v", err)
+	}
+	if payload.PolecatName != "nux" || payload.IssueID != "gt-abc" || payload.MergedAt.IsZero() {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
 func TestParsePolecatDone(t *testing.T) {
 	subject := "POLECAT_DONE nux"
 	body := `Exit: MERGED
@@ -5,7 +5,7 @@ function App() {
     <div className="app">
       <header className="app-header">
         <h1>Gas Town Snapshot</h1>
-        <p>Live positions from /api/town/snapshot</p>
+        <p>Live positions from /api/town/stream</p>
       </header>
       <TownMap />
     </div>
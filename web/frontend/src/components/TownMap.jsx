@@ -8,6 +8,7 @@ import officeSprite from "../assets/building_office_large.png";
 import reviewStationSprite from "../assets/building_review_station.png";
 import mergeDepotSprite from "../assets/building_depot.png";
 import Minimap from "./Minimap.jsx";
+import { agentKey, useTownStream } from "../townStream.js";
 
 // Building Assets
 import cityHallImg from "../assets/building_city_hall.png";
@@ -34,7 +35,7 @@ const statusToZone = {
 const zoneSprites = {
   city_hall: {
     sprite: cityHallSprite,
-    emoji: "🏛️"
+    emoji: "🏛️",
     cols: 4,
     rows: 4
   },
@@ -46,7 +47,7 @@ const zoneSprites = {
   },
   merge_depot: {
     sprite: mergeDepotSprite,
-    emoji: "🚌"
+    emoji: "🚌",
     cols: 4,
     rows: 4
   },
@@ -58,7 +59,7 @@ const zoneSprites = {
   },
   commercial_district: {
     sprite: officeSprite,
-    emoji: "🏢"
+    emoji: "🏢",
     cols: 4,
     rows: 4
   }
@@ -105,8 +106,8 @@ function hashString(value) {
 }
 
 export default function TownMap() {
-  const [snapshot, setSnapshot] = useState({ agents: [] });
   const [isDemoMode, setIsDemoMode] = useState(true);
+  const liveSnapshot = useTownStream(!isDemoMode);
   const layout = layoutData.layout;
   
   // Viewport State
@@ -131,37 +132,7 @@ export default function TownMap() {
     ]
   };
 
-  useEffect(() => {
-    let isMounted = true;
-
-    const fetchSnapshot = async () => {
-      if (isDemoMode) {
-        if (isMounted) setSnapshot(mockSnapshot);
-        return;
-      }
-
-      try {
-        const response = await fetch("/api/town/snapshot");
-        if (!response.ok) {
-          return;
-        }
-        const data = await response.json();
-        if (isMounted) {
-          setSnapshot(data);
-        }
-      } catch (e) {
-        console.error("Failed to fetch snapshot", e);
-      }
-    };
-
-    fetchSnapshot();
-    const timer = setInterval(fetchSnapshot, 1000);
-
-    return () => {
-      isMounted = false;
-      clearInterval(timer);
-    };
-  }, [isDemoMode]);
+  const snapshot = isDemoMode ? mockSnapshot : liveSnapshot ?? { agents: [] };
 
   const zones = useMemo(() => {
     const entries = [];
@@ -343,7 +314,7 @@ export default function TownMap() {
             })}
             {agentPositions.map((agent) => (
               <Character
-                key={agent.name}
+                key={agentKey(agent)}
                 name={agent.name}
                 role={agent.role}
                 status={agent.status}
@@ -0,0 +1,78 @@
+import { useEffect, useState } from "react";
+
+const STREAM_URL = "/api/town/stream";
+const RECENT_EVENTS_LIMIT = 50;
+
+// Row keys, matching the server's: updates name removed rows by key.
+const sectionKeys = {
+  convoys: (convoy) => convoy.id,
+  merge_queue: (mr) => `${mr.repo}#${mr.number}`,
+  polecats: (polecat) => `${polecat.rig}/${polecat.name}`,
+  agents: (agent) => `${agent.role}/${agent.name}`,
+  jobs: (job) => job.id
+};
+
+export function agentKey(agent) {
+  return sectionKeys.agents(agent);
+}
+
+// applyDiff upserts and removes rows, keeping existing rows in place and
+// appending new ones.
+function applyDiff(rows, diff, key) {
+  const removed = new Set(diff.remove ?? []);
+  const upserts = new Map((diff.upsert ?? []).map((row) => [key(row), row]));
+  const next = [];
+  for (const row of rows ?? []) {
+    const k = key(row);
+    if (removed.has(k)) {
+      continue;
+    }
+    if (upserts.has(k)) {
+      next.push(upserts.get(k));
+      upserts.delete(k);
+    } else {
+      next.push(row);
+    }
+  }
+  return next.concat([...upserts.values()]);
+}
+
+// applyUpdate returns the snapshot with a stream update applied.
+export function applyUpdate(snapshot, update) {
+  const next = { ...snapshot, version: update.version, updated_at: update.time };
+  Object.entries(sectionKeys).forEach(([section, key]) => {
+    if (update[section]) {
+      next[section] = applyDiff(snapshot[section], update[section], key);
+    }
+  });
+  if (update.events) {
+    next.events = (snapshot.events ?? []).concat(update.events).slice(-RECENT_EVENTS_LIMIT);
+  }
+  return next;
+}
+
+// useTownStream follows the dashboard's event stream, returning the latest
+// town snapshot (null until the first one arrives). EventSource reconnects
+// on its own, and each connection starts with a fresh snapshot.
+export function useTownStream(enabled) {
+  const [snapshot, setSnapshot] = useState(null);
+
+  useEffect(() => {
+    if (!enabled) {
+      return undefined;
+    }
+
+    const source = new EventSource(STREAM_URL);
+    source.addEventListener("snapshot", (e) => {
+      setSnapshot(JSON.parse(e.data));
+    });
+    source.addEventListener("update", (e) => {
+      const update = JSON.parse(e.data);
+      setSnapshot((current) => (current ? applyUpdate(current, update) : current));
+    });
+
+    return () => source.close();
+  }, [enabled]);
+
+  return snapshot;
+}